@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/rs/zerolog"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/grpcconn"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/state"
 	"github.com/derpartizanen/gophkeeper/internal/logger"
 )
 
@@ -22,15 +24,20 @@ var (
 	appKeyName appKey = "gophkeeper"
 
 	ErrNotInitialized = errors.New("application is not initialized")
+	ErrNoStateDir     = errors.New("state directory is unknown: pass it with --state-dir")
 )
 
 // App implements keeperctl service.
 type App struct {
 	AccessToken   string
+	RefreshToken  string
+	AllowLegacy   bool
 	conn          *grpcconn.Connection
+	repos         *repo.Repositories
 	EncryptionKey encryption.Key
 	Log           *logger.Logger
 	Services      *service.Services
+	Accounts      *state.Store
 }
 
 // New creates new App object.
@@ -49,22 +56,49 @@ func New(cfg *config.Config) (*App, error) {
 
 	log.Debug().Msg(cfg.String())
 
-	conn, err := grpcconn.New(cfg.Address, cfg.CAPath)
+	if cfg.StateDir == "" {
+		return nil, ErrNoStateDir
+	}
+
+	deviceName := cfg.DeviceName
+	if deviceName == "" {
+		// The name is informational only, the session is recorded without it.
+		deviceName, _ = os.Hostname()
+	}
+
+	conn, err := grpcconn.New(cfg.Address, cfg.CAPath, cfg.ClientCert, cfg.ClientKey, deviceName)
 	if err != nil {
 		return nil, fmt.Errorf("grpc connection error: %w", err)
 	}
 
-	key := encryption.NewKey(cfg.Username, cfg.Password)
 	repos := repo.New(conn)
-	services := service.New(key, repos)
+	services := service.New(repos)
 
 	return &App{
 		Log:      log,
 		conn:     conn,
+		repos:    repos,
 		Services: services,
+		Accounts: state.New(cfg.StateDir),
 	}, nil
 }
 
+// Authenticate stores credentials of the authenticated user
+// and initializes services which require the encryption key.
+// Legacy secrets are accepted by the services only if AllowLegacy is set.
+func (a *App) Authenticate(accessToken string, key encryption.Key) {
+	a.AccessToken = accessToken
+	a.EncryptionKey = key
+	a.Services.Secrets = service.NewSecretsService(key, a.AllowLegacy, a.repos.Secrets)
+}
+
+// Deauthenticate discards credentials of the user.
+func (a *App) Deauthenticate() {
+	a.AccessToken = ""
+	a.RefreshToken = ""
+	a.EncryptionKey = encryption.Key{}
+}
+
 // WithContext injects App into provided context.
 func (a *App) WithContext(ctx context.Context) context.Context {
 	return context.WithValue(ctx, appKeyName, a)
@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/viper"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
 )
 
@@ -13,15 +16,58 @@ import (
 type Config struct {
 	Username string
 	Password creds.Password
+	KeyFile  string
 	Address  string
 	CAPath   string
 	Verbose  bool
+
+	// Client certificate and its key authenticating the user instead of access tokens.
+	ClientCert string
+	ClientKey  string
+
+	// API token authenticating the user instead of access tokens, see token command.
+	APIToken creds.Password
+
+	// Name of the device shown in the list of sessions, hostname if empty.
+	DeviceName string
+
+	// Directory keeping tokens of the login session between runs.
+	StateDir string
+
+	// Allows the one-time login of a user registered before SRP, which sends the security key,
+	// and marks the account as having secrets encrypted before envelope encryption.
+	Legacy bool
+
+	// One-time code of the authenticator app or backup code,
+	// required on login if two-factor authentication is enabled.
+	OTP creds.Password
+
+	// New master password, used by passwd command only.
+	NewPassword creds.Password
+
+	// Path to the emergency kit with recovery code, used by register command only.
+	EmergencyKit string
+
+	// Recovery code replacing forgotten master password, used by recover command only.
+	RecoveryCode creds.Password
+
+	// Argon2id cost used to derive encryption key of a new user.
+	KDFTime    uint32
+	KDFMemory  uint32
+	KDFThreads uint8
 }
 
 // New create application config from ENVs and cmd flags.
 func New() *Config {
 	viper.SetDefault("address", "127.0.0.1:9090")
 	viper.SetDefault("verbose", false)
+	viper.SetDefault("kdf-time", encryption.DefaultKDFTime)
+	viper.SetDefault("kdf-memory", encryption.DefaultKDFMemory)
+	viper.SetDefault("kdf-threads", encryption.DefaultKDFThreads)
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		viper.SetDefault("state-dir", filepath.Join(dir, "keeperctl"))
+	}
 
 	viper.SetEnvPrefix("GOPH")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -30,9 +76,32 @@ func New() *Config {
 	cfg := &Config{
 		Username: viper.GetString("username"),
 		Password: creds.Password(viper.GetString("password")),
+		KeyFile:  viper.GetString("key-file"),
 		Address:  viper.GetString("address"),
 		CAPath:   viper.GetString("ca-path"),
 		Verbose:  viper.GetBool("verbose"),
+
+		ClientCert: viper.GetString("client-cert"),
+		ClientKey:  viper.GetString("client-key"),
+
+		APIToken: creds.Password(viper.GetString("api-token")),
+
+		DeviceName: viper.GetString("device-name"),
+
+		StateDir: viper.GetString("state-dir"),
+
+		Legacy: viper.GetBool("legacy"),
+
+		OTP: creds.Password(viper.GetString("otp")),
+
+		NewPassword: creds.Password(viper.GetString("new-password")),
+
+		EmergencyKit: viper.GetString("emergency-kit"),
+		RecoveryCode: creds.Password(viper.GetString("recovery-code")),
+
+		KDFTime:    viper.GetUint32("kdf-time"),
+		KDFMemory:  viper.GetUint32("kdf-memory"),
+		KDFThreads: uint8(viper.GetUint("kdf-threads")),
 	}
 
 	return cfg
@@ -44,9 +113,23 @@ func (c *Config) String() string {
 	sb.WriteString("Config:\n")
 	sb.WriteString(fmt.Sprintf("\t\tUsername: %s\n", c.Username))
 	sb.WriteString(fmt.Sprintf("\t\tPassword: %s\n", c.Password))
+	sb.WriteString(fmt.Sprintf("\t\tKey file: %s\n", c.KeyFile))
 	sb.WriteString(fmt.Sprintf("\t\tAddress: %s\n", c.Address))
 	sb.WriteString(fmt.Sprintf("\t\tCA path: %s\n", c.CAPath))
-	sb.WriteString(fmt.Sprintf("\t\tVerbose: %t", c.Verbose))
+	sb.WriteString(fmt.Sprintf("\t\tVerbose: %t\n", c.Verbose))
+	sb.WriteString(fmt.Sprintf("\t\tClient certificate: %s\n", c.ClientCert))
+	sb.WriteString(fmt.Sprintf("\t\tClient key: %s\n", c.ClientKey))
+	sb.WriteString(fmt.Sprintf("\t\tAPI token: %s\n", c.APIToken))
+	sb.WriteString(fmt.Sprintf("\t\tDevice name: %s\n", c.DeviceName))
+	sb.WriteString(fmt.Sprintf("\t\tState dir: %s\n", c.StateDir))
+	sb.WriteString(fmt.Sprintf("\t\tLegacy: %t\n", c.Legacy))
+	sb.WriteString(fmt.Sprintf("\t\tOTP: %s\n", c.OTP))
+	sb.WriteString(fmt.Sprintf("\t\tNew password: %s\n", c.NewPassword))
+	sb.WriteString(fmt.Sprintf("\t\tEmergency kit: %s\n", c.EmergencyKit))
+	sb.WriteString(fmt.Sprintf("\t\tRecovery code: %s\n", c.RecoveryCode))
+	sb.WriteString(fmt.Sprintf("\t\tKDF time: %d\n", c.KDFTime))
+	sb.WriteString(fmt.Sprintf("\t\tKDF memory: %d KiB\n", c.KDFMemory))
+	sb.WriteString(fmt.Sprintf("\t\tKDF threads: %d", c.KDFThreads))
 
 	return sb.String()
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/require"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/config"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
@@ -32,13 +33,21 @@ func TestDefaultConfig(t *testing.T) {
 func TestConfigFromEnv(t *testing.T) {
 	_ = os.Setenv("GOPH_USERNAME", gophtest.Username)
 	_ = os.Setenv("GOPH_PASSWORD", string(gophtest.Password))
+	_ = os.Setenv("GOPH_KEY_FILE", "/home/user/.gophkeeper.key")
 	_ = os.Setenv("GOPH_ADDRESS", "192.168.0.10:8080")
 	_ = os.Setenv("GOPH_CA_PATH", "/etc/ssl/root.crt")
 	_ = os.Setenv("GOPH_VERBOSE", "1")
+	_ = os.Setenv("GOPH_CLIENT_CERT", "/etc/ssl/client.crt")
+	_ = os.Setenv("GOPH_CLIENT_KEY", "/etc/ssl/client.key")
+	_ = os.Setenv("GOPH_DEVICE_NAME", gophtest.DeviceName)
+	_ = os.Setenv("GOPH_STATE_DIR", "/home/user/.config/keeperctl")
+	_ = os.Setenv("GOPH_API_TOKEN", gophtest.APIToken)
+	_ = os.Setenv("GOPH_OTP", gophtest.BackupCode)
 
 	t.Cleanup(unsetGophEnv)
 
 	sat := config.New()
 
+	require.NotContains(t, sat.String(), gophtest.BackupCode)
 	snaps.MatchSnapshot(t, sat.String())
 }
@@ -0,0 +1,174 @@
+package cmdline
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+var errDeletionNotConfirmed = stderrors.New("account deletion is not confirmed")
+
+var (
+	accountCmd = &cobra.Command{
+		Use:   "account",
+		Short: "Manage the account",
+	}
+
+	accountRenameCmd = &cobra.Command{
+		Use:   "rename [new username] [flags]",
+		Short: "Change username and re-encrypt all secrets",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doRenameAccount,
+	}
+
+	accountDeleteCmd = &cobra.Command{
+		Use:   "delete [flags]",
+		Short: "Delete the account and all its secrets permanently",
+		Args:  cobra.NoArgs,
+		RunE:  doDeleteAccount,
+	}
+)
+
+func init() {
+	accountRenameCmd.Flags().AddFlagSet(kdfFlags)
+
+	accountCmd.AddCommand(accountRenameCmd)
+	accountCmd.AddCommand(accountDeleteCmd)
+
+	rootCmd.AddCommand(accountCmd)
+}
+
+func doRenameAccount(cmd *cobra.Command, args []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	kdf, err := encryption.NewKDFParams(cfg.KDFTime, cfg.KDFMemory, cfg.KDFThreads)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	key, err := clientApp.Services.Users.Rename(
+		cmd.Context(),
+		clientApp.AccessToken,
+		cfg.Username,
+		args[0],
+		cfg.Password,
+		keyFile,
+		kdf,
+		clientApp.AllowLegacy,
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, encryption.ErrIntegrity) {
+			return encryption.ErrIntegrity
+		}
+
+		if stderrors.Is(err, encryption.ErrUnsupportedEnvelope) {
+			return encryption.ErrUnsupportedEnvelope
+		}
+
+		if stderrors.Is(err, srp.ErrInvalidProof) {
+			return errUntrustedServer
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	if err := markMigrated(clientApp); err != nil {
+		return err
+	}
+
+	clientApp.Authenticate(clientApp.AccessToken, key)
+	clientApp.Log.Debug().Msg("Username successfully changed")
+
+	if err := rememberKey(clientApp); err != nil {
+		return err
+	}
+
+	if err := moveAccount(clientApp, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Username changed, log in as %s from now on.\n", args[0])
+
+	return nil
+}
+
+func doDeleteAccount(cmd *cobra.Command, _ []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintln(out, "The account and all its secrets will be deleted permanently, this cannot be undone.")
+	fmt.Fprintf(out, "Type the username (%s) to confirm: ", cfg.Username)
+
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !stderrors.Is(err, io.EOF) {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != cfg.Username {
+		return errDeletionNotConfirmed
+	}
+
+	err = clientApp.Services.Users.Delete(
+		cmd.Context(),
+		clientApp.AccessToken,
+		cfg.Username,
+		cfg.Password,
+		keyFile,
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, srp.ErrInvalidProof) {
+			return errUntrustedServer
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	clientApp.Deauthenticate()
+	clientApp.Log.Debug().Msg("Account successfully deleted")
+
+	return clientApp.Accounts.Remove(cfg.Address, cfg.Username)
+}
+
+// moveAccount keeps the saved state of the renamed account under the new username.
+func moveAccount(clientApp *app.App, newUsername string) error {
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if err := clientApp.Accounts.Save(cfg.Address, newUsername, account); err != nil {
+		return err
+	}
+
+	return clientApp.Accounts.Remove(cfg.Address, cfg.Username)
+}
@@ -8,14 +8,20 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 )
 
-var deleteCmd = &cobra.Command{
-	Use:   "delete [secret id] [flags]",
-	Short: "Delete the secret",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  doDelete,
-}
+var (
+	deletePermanent bool
+
+	deleteCmd = &cobra.Command{
+		Use:   "delete [secret id] [flags]",
+		Short: "Move the secret to trash, see trash and restore to get it back",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  doDelete,
+	}
+)
 
 func init() {
+	deleteCmd.Flags().BoolVar(&deletePermanent, "permanent", false, "Delete the secret permanently, skipping trash")
+
 	rootCmd.AddCommand(deleteCmd)
 }
 
@@ -30,7 +36,12 @@ func doDelete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := clientApp.Services.Secrets.Delete(cmd.Context(), clientApp.AccessToken, id); err != nil {
+	remove := clientApp.Services.Secrets.Delete
+	if deletePermanent {
+		remove = clientApp.Services.Secrets.Purge
+	}
+
+	if err := remove(cmd.Context(), clientApp.AccessToken, id); err != nil {
 		clientApp.Log.Debug().Err(err).Msg("")
 
 		return errors.Unwrap(err)
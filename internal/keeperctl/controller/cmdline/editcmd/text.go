@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
 )
 
@@ -50,6 +51,14 @@ func doEditText(cmd *cobra.Command, _args []string) error {
 			return service.ErrKindMismatch
 		}
 
+		if errors.Is(err, encryption.ErrIntegrity) {
+			return encryption.ErrIntegrity
+		}
+
+		if errors.Is(err, encryption.ErrUnsupportedEnvelope) {
+			return encryption.ErrUnsupportedEnvelope
+		}
+
 		return errors.Unwrap(err)
 	}
 
@@ -0,0 +1,55 @@
+package cmdline
+
+import (
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [secret id] [flags]",
+	Short: "List kept versions of the secret, the latest go first",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+func doHistory(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	versions, err := clientApp.Services.Secrets.ListVersions(cmd.Context(), clientApp.AccessToken, id)
+	if err != nil {
+		return pullError(clientApp, err)
+	}
+
+	t := tabby.New()
+	t.AddHeader("Version", "Updated", "Name", "Description")
+
+	for _, version := range versions {
+		t.AddLine(
+			version.GetVersion(),
+			version.GetUpdatedAt().AsTime().Local().Format(time.DateTime),
+			string(version.GetName()),
+			string(version.GetMetadata()),
+		)
+	}
+
+	t.Print()
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmdline
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+)
+
+// kdfFlags are shared by all commands deriving a new encryption key.
+var kdfFlags = newKDFFlags()
+
+func newKDFFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("kdf", pflag.ContinueOnError)
+
+	flags.Uint32(
+		"kdf-time",
+		encryption.DefaultKDFTime,
+		"Argon2id number of passes used to derive encryption key",
+	)
+	flags.Uint32(
+		"kdf-memory",
+		encryption.DefaultKDFMemory,
+		"Argon2id memory in KiB used to derive encryption key",
+	)
+	flags.Uint8(
+		"kdf-threads",
+		encryption.DefaultKDFThreads,
+		"Argon2id number of threads used to derive encryption key",
+	)
+
+	viper.BindPFlag("kdf-time", flags.Lookup("kdf-time"))
+	viper.BindPFlag("kdf-memory", flags.Lookup("kdf-memory"))
+	viper.BindPFlag("kdf-threads", flags.Lookup("kdf-threads"))
+
+	return flags
+}
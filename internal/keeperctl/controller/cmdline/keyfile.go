@@ -0,0 +1,79 @@
+package cmdline
+
+import (
+	stderrors "errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+)
+
+var errKeyFileNotSet = stderrors.New("path to the key file is not set")
+
+var keyfileCmd = &cobra.Command{
+	Use:   "keyfile",
+	Short: "Manage key file used as the second factor",
+	// Key file is managed offline, so neither connection nor credentials are needed.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		for _, name := range []string{"username", "password"} {
+			err := cmd.Flags().SetAnnotation(name, cobra.BashCompOneRequiredFlag, []string{"false"})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+var keyfileGenerateCmd = &cobra.Command{
+	Use:   "generate [flags]",
+	Short: "Generate new random key file at the key file path",
+	Args:  cobra.NoArgs,
+	RunE:  doKeyfileGenerate,
+}
+
+func init() {
+	keyfileCmd.AddCommand(keyfileGenerateCmd)
+
+	rootCmd.AddCommand(keyfileCmd)
+}
+
+func doKeyfileGenerate(cmd *cobra.Command, args []string) error {
+	if cfg.KeyFile == "" {
+		return errKeyFileNotSet
+	}
+
+	contents, err := encryption.GenerateKeyFile()
+	if err != nil {
+		return err
+	}
+
+	// Existing file is never overwritten, as it may protect other account.
+	f, err := os.OpenFile(cfg.KeyFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(contents); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// loadKeyFile reads the key file, if its path is set.
+func loadKeyFile() (encryption.KeyFile, error) {
+	if cfg.KeyFile == "" {
+		return encryption.KeyFile{}, nil
+	}
+
+	contents, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return encryption.KeyFile{}, err
+	}
+
+	return encryption.NewKeyFile(contents)
+}
@@ -0,0 +1,47 @@
+package cmdline
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const emergencyKitTemplate = `GophKeeper emergency kit
+
+Keep this file offline in a safe place. Anyone knowing the recovery code
+and the username can set a new master password and read all the secrets.
+
+Created:       %s
+Server:        %s
+Username:      %s
+Recovery code: %s
+
+To regain access after the master password is forgotten, run:
+
+    keeperctl recover --address %s -u %s -p <new master password> --recovery-code <recovery code>
+`
+
+// createEmergencyKit creates the emergency kit file readable by the owner only.
+// Existing file is never overwritten, as it may hold the recovery code of other account.
+func createEmergencyKit(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+}
+
+// writeEmergencyKit writes the recovery code and instructions to the emergency kit.
+func writeEmergencyKit(f *os.File, address, username, code string) error {
+	_, err := fmt.Fprintf(
+		f,
+		emergencyKitTemplate,
+		time.Now().Format(time.DateOnly),
+		address,
+		username,
+		code,
+		address,
+		username,
+	)
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
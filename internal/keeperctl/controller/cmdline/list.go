@@ -1,33 +1,80 @@
 package cmdline
 
 import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+
 	"github.com/cheynewallace/tabby"
 	"github.com/spf13/cobra"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
-var listCmd = &cobra.Command{
-	Use:   "list [flags]",
-	Short: "List secrets of current user (without data)",
-	RunE:  doList,
-}
+var (
+	listKinds []string
+	listLimit int
+
+	listCmd = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List secrets of current user (without data)",
+		RunE:  doList,
+	}
+)
 
 func init() {
+	listCmd.Flags().StringSliceVar(
+		&listKinds,
+		"kind",
+		nil,
+		"Kind of secrets to list (binary, card, credentials or text), could be repeated, all kinds by default",
+	)
+	listCmd.Flags().IntVar(
+		&listLimit,
+		"limit",
+		0,
+		"Maximum number of secrets to list, no limit by default",
+	)
+
 	rootCmd.AddCommand(listCmd)
 }
 
 func doList(cmd *cobra.Command, args []string) error {
+	if listLimit < 0 {
+		return fmt.Errorf("invalid limit %d, must not be negative", listLimit)
+	}
+
+	kinds := make([]proto.DataKind, 0, len(listKinds))
+
+	for _, src := range listKinds {
+		kind, ok := proto.DataKind_value[strings.ToUpper(src)]
+		if !ok {
+			return fmt.Errorf("unknown kind of secret %q", src)
+		}
+
+		kinds = append(kinds, proto.DataKind(kind))
+	}
+
 	clientApp, err := app.FromContext(cmd.Context())
 	if err != nil {
 		return err
 	}
 
-	data, err := clientApp.Services.Secrets.List(cmd.Context(), clientApp.AccessToken)
+	data, err := clientApp.Services.Secrets.List(cmd.Context(), clientApp.AccessToken, kinds, listLimit)
 	if err != nil {
 		clientApp.Log.Debug().Err(err).Msg("")
 
+		if stderrors.Is(err, encryption.ErrIntegrity) {
+			return encryption.ErrIntegrity
+		}
+
+		if stderrors.Is(err, encryption.ErrUnsupportedEnvelope) {
+			return encryption.ErrUnsupportedEnvelope
+		}
+
 		return errors.Unwrap(err)
 	}
 
@@ -35,7 +82,7 @@ func doList(cmd *cobra.Command, args []string) error {
 	t.AddHeader("ID", "Name", "Kind", "Description")
 
 	for _, secret := range data {
-		t.AddLine(secret.GetId(), secret.GetName(), secret.Kind.String(), string(secret.GetMetadata()))
+		t.AddLine(secret.GetId(), string(secret.GetName()), secret.Kind.String(), string(secret.GetMetadata()))
 	}
 
 	t.Print()
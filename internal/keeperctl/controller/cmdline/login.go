@@ -1,10 +1,30 @@
 package cmdline
 
 import (
+	"bytes"
+	stderrors "errors"
+
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+var (
+	errWrongPasswordOrKeyFile = stderrors.New("invalid credentials: wrong master password or key file")
+	errUntrustedServer        = stderrors.New("server failed to prove knowledge of the password verifier")
+	errOTPRequired            = stderrors.New("two-factor authentication is enabled: pass one-time code with --otp")
+	errClientKeyRequired      = stderrors.New("client certificate requires its key: pass it with --client-key")
+	errLegacyLoginRequired    = stderrors.New(
+		"the account is registered before SRP: pass --legacy to upgrade it once",
+	)
+	errLegacyLoginRefused = stderrors.New(
+		"server asks for legacy login, but the account has been upgraded to SRP already: refusing to downgrade",
+	)
 )
 
 func login(cmd *cobra.Command, _ []string) error {
@@ -13,21 +33,314 @@ func login(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	token, err := clientApp.Services.Auth.Login(
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	if cfg.APIToken != "" {
+		if err := unlock(cmd, clientApp, keyFile, string(cfg.APIToken)); err != nil {
+			return err
+		}
+
+		clientApp.Log.Debug().Msg("Authenticated with API token")
+
+		return nil
+	}
+
+	if cfg.ClientCert != "" {
+		if cfg.ClientKey == "" {
+			return errClientKeyRequired
+		}
+
+		if err := unlock(cmd, clientApp, keyFile, ""); err != nil {
+			return err
+		}
+
+		clientApp.Log.Debug().Msg("Authenticated with client certificate")
+
+		return nil
+	}
+
+	resumed, err := resume(cmd, clientApp, keyFile)
+	if err != nil || resumed {
+		return err
+	}
+
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	tokens, key, err := clientApp.Services.Auth.Login(
 		cmd.Context(),
 		cfg.Username,
-		clientApp.EncryptionKey,
+		cfg.Password,
+		keyFile,
+		cfg.Legacy && !account.Upgraded,
 	)
 	if err != nil {
 		clientApp.Log.Debug().Err(err).Msg("")
 
+		if stderrors.Is(err, service.ErrLegacyLogin) && account.Upgraded {
+			return errLegacyLoginRefused
+		}
+
+		if stderrors.Is(err, service.ErrLegacyLogin) {
+			return errLegacyLoginRequired
+		}
+
+		if stderrors.Is(err, encryption.ErrKeyFileRequired) {
+			return encryption.ErrKeyFileRequired
+		}
+
+		if stderrors.Is(err, encryption.ErrKeyFileNotUsed) {
+			return encryption.ErrKeyFileNotUsed
+		}
+
+		if stderrors.Is(err, srp.ErrInvalidProof) {
+			return errUntrustedServer
+		}
+
+		// The service can't tell which of them is wrong.
+		if !keyFile.IsEmpty() && errors.HasCode(err, codes.Unauthenticated) {
+			return errWrongPasswordOrKeyFile
+		}
+
 		return errors.Unwrap(err)
 	}
 
-	clientApp.AccessToken = token
+	// The legacy user is upgraded already, even if the second factor is not passed yet.
+	if err := markUpgraded(clientApp); err != nil {
+		return err
+	}
+
+	if tokens.PartialToken != "" {
+		if cfg.OTP == "" {
+			return errOTPRequired
+		}
+
+		tokens, err = clientApp.Services.Auth.VerifySecondFactor(cmd.Context(), tokens.PartialToken, string(cfg.OTP))
+		if err != nil {
+			clientApp.Log.Debug().Err(err).Msg("")
+
+			return errors.Unwrap(err)
+		}
+	}
+
+	clientApp.Authenticate(tokens.AccessToken, key)
+	clientApp.RefreshToken = tokens.RefreshToken
 	clientApp.Log.Debug().
-		Str("access-token", token).
+		Str("access-token", tokens.AccessToken).
 		Msg("Login successful")
 
+	if err := rememberKey(clientApp); err != nil {
+		return err
+	}
+
+	return saveSession(clientApp, tokens)
+}
+
+// resume continues the login session saved by the previous run,
+// so every run doesn't start a new session on the service.
+// Returns false if there is no saved session or it is revoked or expired, so login is required.
+func resume(cmd *cobra.Command, clientApp *app.App, keyFile encryption.KeyFile) (bool, error) {
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return false, err
+	}
+
+	if account.RefreshToken == "" {
+		return false, nil
+	}
+
+	tokens, err := clientApp.Services.Auth.Resume(
+		cmd.Context(),
+		service.Tokens{AccessToken: account.AccessToken, RefreshToken: account.RefreshToken},
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if errors.HasCode(err, codes.Unauthenticated) {
+			return false, nil
+		}
+
+		return false, errors.Unwrap(err)
+	}
+
+	if err := unlock(cmd, clientApp, keyFile, tokens.AccessToken); err != nil {
+		return false, err
+	}
+
+	clientApp.RefreshToken = tokens.RefreshToken
+	clientApp.Log.Debug().Msg("Login session resumed")
+
+	return true, saveSession(clientApp, tokens)
+}
+
+// saveSession keeps tokens of the login session between runs,
+// so the session is resumed by the next run and could be revoked by logout command.
+// The session is started only by the user upgraded to SRP.
+func saveSession(clientApp *app.App, tokens service.Tokens) error {
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	account.AccessToken = tokens.AccessToken
+	account.RefreshToken = tokens.RefreshToken
+	account.Upgraded = true
+
+	return clientApp.Accounts.Save(cfg.Address, cfg.Username, account)
+}
+
+// allowLegacy accepts secrets encrypted before envelope encryption
+// only for the account marked as having them, see state.Account.
+// The account is marked by --legacy flag until its secrets are migrated.
+func allowLegacy(clientApp *app.App) error {
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Legacy && !account.Legacy {
+		account.Legacy = true
+
+		if err := clientApp.Accounts.Save(cfg.Address, cfg.Username, account); err != nil {
+			return err
+		}
+	}
+
+	clientApp.AllowLegacy = account.Legacy
+
+	return nil
+}
+
+// markMigrated records that all secrets of the user are re-encrypted with data keys,
+// so legacy secrets are refused from now on.
+func markMigrated(clientApp *app.App) error {
+	clientApp.AllowLegacy = false
+
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if !account.Legacy {
+		return nil
+	}
+
+	account.Legacy = false
+
+	return clientApp.Accounts.Save(cfg.Address, cfg.Username, account)
+}
+
+// markUpgraded records that the user logs in with SRP,
+// so the legacy login is refused from now on, even if the service asks for it.
+func markUpgraded(clientApp *app.App) error {
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if account.Upgraded {
+		return nil
+	}
+
+	account.Upgraded = true
+
+	return clientApp.Accounts.Save(cfg.Address, cfg.Username, account)
+}
+
+// rememberKey records ID of the vault key the user is authenticated with,
+// so the key derived to unlock the vault without login could be checked, see checkKey.
+func rememberKey(clientApp *app.App) error {
+	keyID, err := clientApp.EncryptionKey.ID()
+	if err != nil {
+		return err
+	}
+
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(account.KeyID, keyID) {
+		return nil
+	}
+
+	account.KeyID = keyID
+
+	return clientApp.Accounts.Save(cfg.Address, cfg.Username, account)
+}
+
+// checkKey makes sure the vault is unlocked with the right master password and key file,
+// as neither the token nor the client certificate proves them to the service.
+// The key is compared with the one remembered on login.
+// Otherwise, e.g. if the password was changed by other client,
+// the key must open a secret of the user, so the key of the empty vault is accepted
+// only if no other key is remembered.
+func checkKey(cmd *cobra.Command, clientApp *app.App) error {
+	keyID, err := clientApp.EncryptionKey.ID()
+	if err != nil {
+		return err
+	}
+
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(account.KeyID, keyID) {
+		return nil
+	}
+
+	secrets, err := clientApp.Services.Secrets.List(cmd.Context(), clientApp.AccessToken, nil, 1)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, encryption.ErrIntegrity) {
+			return errWrongPasswordOrKeyFile
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	if len(secrets) == 0 && account.KeyID != nil {
+		return errWrongPasswordOrKeyFile
+	}
+
+	return rememberKey(clientApp)
+}
+
+// unlock derives the encryption key of the user authenticated with the client certificate,
+// with API token or with the token of the saved session,
+// the service identifies the user by them, so no login is needed.
+// The master password is still required, as secrets are encrypted by client,
+// the derived key is checked by checkKey.
+func unlock(cmd *cobra.Command, clientApp *app.App, keyFile encryption.KeyFile, token string) error {
+	key, err := clientApp.Services.Auth.Unlock(cmd.Context(), cfg.Username, cfg.Password, keyFile)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, encryption.ErrKeyFileRequired) {
+			return encryption.ErrKeyFileRequired
+		}
+
+		if stderrors.Is(err, encryption.ErrKeyFileNotUsed) {
+			return encryption.ErrKeyFileNotUsed
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	clientApp.Authenticate(token, key)
+
+	if err := checkKey(cmd, clientApp); err != nil {
+		clientApp.Deauthenticate()
+
+		return err
+	}
+
 	return nil
 }
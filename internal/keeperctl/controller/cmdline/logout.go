@@ -0,0 +1,71 @@
+package cmdline
+
+import (
+	stderrors "errors"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+)
+
+var errNotLoggedIn = stderrors.New("no saved login session: nothing to log out from")
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout [flags]",
+	Short: "Revoke the saved login session on the server and discard its tokens",
+	Args:  cobra.NoArgs,
+	RunE:  doLogout,
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+}
+
+func doLogout(cmd *cobra.Command, _ []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	account, err := clientApp.Accounts.Load(cfg.Address, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	if account.RefreshToken == "" {
+		return errNotLoggedIn
+	}
+
+	// The saved access token may be expired already, while the session is still alive.
+	tokens, err := clientApp.Services.Auth.Resume(
+		cmd.Context(),
+		service.Tokens{AccessToken: account.AccessToken, RefreshToken: account.RefreshToken},
+	)
+	if err == nil {
+		err = clientApp.Services.Auth.Logout(cmd.Context(), tokens)
+	}
+
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		// The session has been revoked or expired already, so only the saved tokens are discarded.
+		if !errors.HasCode(err, codes.Unauthenticated) {
+			return errors.Unwrap(err)
+		}
+	}
+
+	account.AccessToken = ""
+	account.RefreshToken = ""
+
+	if err := clientApp.Accounts.Save(cfg.Address, cfg.Username, account); err != nil {
+		return err
+	}
+
+	clientApp.Deauthenticate()
+	clientApp.Log.Debug().Msg("Logout successful")
+
+	return nil
+}
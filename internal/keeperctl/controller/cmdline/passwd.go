@@ -0,0 +1,84 @@
+package cmdline
+
+import (
+	stderrors "errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+var passwdCmd = &cobra.Command{
+	Use:   "passwd [flags]",
+	Short: "Change master password and re-encrypt all secrets",
+	RunE:  doPasswd,
+}
+
+func init() {
+	passwdCmd.Flags().String("new-password", "", "New master password")
+	passwdCmd.Flags().AddFlagSet(kdfFlags)
+
+	passwdCmd.MarkFlagRequired("new-password")
+
+	viper.BindPFlag("new-password", passwdCmd.Flags().Lookup("new-password"))
+
+	rootCmd.AddCommand(passwdCmd)
+}
+
+func doPasswd(cmd *cobra.Command, args []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	kdf, err := encryption.NewKDFParams(cfg.KDFTime, cfg.KDFMemory, cfg.KDFThreads)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	key, err := clientApp.Services.Users.ChangePassword(
+		cmd.Context(),
+		clientApp.AccessToken,
+		cfg.Username,
+		cfg.Password,
+		cfg.NewPassword,
+		keyFile,
+		kdf,
+		clientApp.AllowLegacy,
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, encryption.ErrIntegrity) {
+			return encryption.ErrIntegrity
+		}
+
+		if stderrors.Is(err, encryption.ErrUnsupportedEnvelope) {
+			return encryption.ErrUnsupportedEnvelope
+		}
+
+		if stderrors.Is(err, srp.ErrInvalidProof) {
+			return errUntrustedServer
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	if err := markMigrated(clientApp); err != nil {
+		return err
+	}
+
+	clientApp.Authenticate(clientApp.AccessToken, key)
+	clientApp.Log.Debug().Msg("Master password successfully changed")
+
+	return rememberKey(clientApp)
+}
@@ -0,0 +1,123 @@
+// Package progress renders progress of long running transfers in terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const barWidth = 30
+
+// Bar is a text progress bar redrawn in place every time the percentage changes.
+// Total is allowed to be approximate, the bar never goes beyond 100%.
+type Bar struct {
+	out     io.Writer
+	label   string
+	total   int64
+	current int64
+	percent int
+}
+
+// New creates progress bar printing to out.
+func New(out io.Writer, label string, total int64) *Bar {
+	return &Bar{out: out, label: label, total: total, percent: -1}
+}
+
+// Add advances the bar by n bytes.
+func (b *Bar) Add(n int) {
+	b.current += int64(n)
+
+	percent := 100
+	if b.total > 0 && b.current < b.total {
+		percent = int(b.current * 100 / b.total)
+	}
+
+	if percent == b.percent {
+		return
+	}
+
+	b.percent = percent
+	b.render()
+}
+
+// Finish completes the bar and moves the cursor to the next line.
+func (b *Bar) Finish() {
+	if b.percent != 100 {
+		b.percent = 100
+		b.render()
+	}
+
+	fmt.Fprintln(b.out)
+}
+
+// Stop moves the cursor to the next line leaving the bar as is,
+// so the following output doesn't mix with an interrupted transfer.
+func (b *Bar) Stop() {
+	fmt.Fprintln(b.out)
+}
+
+// Reader wraps r advancing the bar on every read.
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	return &reader{r, b}
+}
+
+// Writer wraps w advancing the bar on every write.
+func (b *Bar) Writer(w io.Writer) io.Writer {
+	return &writer{w, b}
+}
+
+func (b *Bar) render() {
+	filled := b.percent * barWidth / 100
+
+	fmt.Fprintf(
+		b.out,
+		"\r%s [%s%s] %3d%% %s",
+		b.label,
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", barWidth-filled),
+		b.percent,
+		formatBytes(b.current),
+	)
+}
+
+type reader struct {
+	r   io.Reader
+	bar *Bar
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.bar.Add(n)
+
+	return n, err
+}
+
+type writer struct {
+	w   io.Writer
+	bar *Bar
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.bar.Add(n)
+
+	return n, err
+}
+
+// formatBytes prints size in binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,53 @@
+package progress_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/controller/cmdline/progress"
+)
+
+func TestBarReader(t *testing.T) {
+	var out bytes.Buffer
+
+	bar := progress.New(&out, "Uploading", 4096)
+	_, err := io.Copy(io.Discard, bar.Reader(strings.NewReader(strings.Repeat("x", 4096))))
+	require.NoError(t, err)
+	bar.Finish()
+
+	require.Contains(t, out.String(), "\rUploading [==============================] 100% 4.0 KiB\n")
+}
+
+func TestBarWriter(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		dest bytes.Buffer
+	)
+
+	bar := progress.New(&out, "Downloading", 2048)
+	_, err := bar.Writer(&dest).Write(make([]byte, 1024))
+	require.NoError(t, err)
+
+	require.Equal(t, 1024, dest.Len())
+	require.Equal(t, "\rDownloading [===============               ]  50% 1.0 KiB", out.String())
+}
+
+func TestBarWithApproximateTotal(t *testing.T) {
+	var out bytes.Buffer
+
+	bar := progress.New(&out, "Downloading", 100)
+	bar.Add(90)
+	bar.Add(20)
+	bar.Finish()
+
+	require.Equal(
+		t,
+		"\rDownloading [===========================   ]  90% 90 B"+
+			"\rDownloading [==============================] 100% 110 B\n",
+		out.String(),
+	)
+}
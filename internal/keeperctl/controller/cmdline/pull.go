@@ -1,23 +1,52 @@
 package cmdline
 
 import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
 	"github.com/cheynewallace/tabby"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+	protobuf "google.golang.org/protobuf/proto"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/controller/cmdline/progress"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
-var pullCmd = &cobra.Command{
-	Use:   "pull [secret id] [flags]",
-	Short: "Show the secret and stored data",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  doPull,
-}
+var (
+	pullOut     string
+	pullVersion int32
+
+	pullCmd = &cobra.Command{
+		Use:   "pull [secret id] [flags]",
+		Short: "Show the secret and stored data",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  doPull,
+	}
+)
 
 func init() {
+	pullCmd.Flags().StringVarP(
+		&pullOut,
+		"out",
+		"o",
+		"",
+		"Stream content of binary secret pushed as file to the provided path",
+	)
+	pullCmd.Flags().Int32Var(
+		&pullVersion,
+		"version",
+		0,
+		"Version of the secret to show instead of the current one, see history for available versions",
+	)
+
 	rootCmd.AddCommand(pullCmd)
 }
 
@@ -27,22 +56,42 @@ func doPull(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if pullVersion < 0 {
+		return fmt.Errorf("invalid version %d, must be positive", pullVersion)
+	}
+
 	clientApp, err := app.FromContext(cmd.Context())
 	if err != nil {
 		return err
 	}
 
-	secret, data, err := clientApp.Services.Secrets.Get(cmd.Context(), clientApp.AccessToken, id)
-	if err != nil {
-		clientApp.Log.Debug().Err(err).Msg("")
+	if pullOut != "" {
+		if pullVersion != 0 {
+			return stderrors.New("--version could not be combined with --out")
+		}
+
+		return doPullFile(cmd, clientApp, id)
+	}
+
+	var (
+		secret *proto.Secret
+		data   protobuf.Message
+	)
+
+	if pullVersion != 0 {
+		secret, data, err = clientApp.Services.Secrets.GetVersion(cmd.Context(), clientApp.AccessToken, id, pullVersion)
+	} else {
+		secret, data, err = clientApp.Services.Secrets.Get(cmd.Context(), clientApp.AccessToken, id)
+	}
 
-		return errors.Unwrap(err)
+	if err != nil {
+		return pullError(clientApp, err)
 	}
 
 	header := []any{"ID", "Name", "Kind", "Description"}
 	line := []any{
 		secret.GetId(),
-		secret.GetName(),
+		string(secret.GetName()),
 		secret.GetKind().String(),
 		string(secret.GetMetadata()),
 	}
@@ -75,3 +124,70 @@ func doPull(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// doPullFile streams content of the blob to temporary file next to the destination.
+// The file is renamed only when the whole content has been authenticated,
+// so tampered or interrupted download never replaces the destination.
+func doPullFile(cmd *cobra.Command, clientApp *app.App, id uuid.UUID) error {
+	secret, src, err := clientApp.Services.Secrets.OpenFile(cmd.Context(), clientApp.AccessToken, id)
+	if err != nil {
+		return pullError(clientApp, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(pullOut), "."+filepath.Base(pullOut)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	bar := progress.New(cmd.ErrOrStderr(), "Downloading", secret.GetBlobSize())
+
+	if _, err := io.Copy(bar.Writer(tmp), src); err != nil {
+		bar.Stop()
+		tmp.Close()
+
+		return pullError(clientApp, err)
+	}
+
+	bar.Finish()
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), pullOut); err != nil {
+		return err
+	}
+
+	t := tabby.New()
+	t.AddHeader("ID", "Name", "Kind", "Description", "File")
+	t.AddLine(
+		secret.GetId(),
+		string(secret.GetName()),
+		secret.GetKind().String(),
+		string(secret.GetMetadata()),
+		pullOut,
+	)
+	t.Print()
+
+	return nil
+}
+
+// pullError hides details of failed pull behind error suitable for the user.
+func pullError(clientApp *app.App, err error) error {
+	clientApp.Log.Debug().Err(err).Msg("")
+
+	if stderrors.Is(err, encryption.ErrIntegrity) {
+		return encryption.ErrIntegrity
+	}
+
+	if stderrors.Is(err, encryption.ErrUnsupportedEnvelope) {
+		return encryption.ErrUnsupportedEnvelope
+	}
+
+	if stderrors.Is(err, service.ErrBlobSecret) {
+		return fmt.Errorf("%w, pull it with --out", service.ErrBlobSecret)
+	}
+
+	return errors.Unwrap(err)
+}
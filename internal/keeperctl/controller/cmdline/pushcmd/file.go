@@ -0,0 +1,53 @@
+package pushcmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/controller/cmdline/progress"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+)
+
+var fileCmd = &cobra.Command{
+	Use:     "file [path] [flags]",
+	Short:   "Stream file of any size as binary secret",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: preRun,
+	RunE:    doPushFile,
+}
+
+func doPushFile(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	bar := progress.New(cmd.ErrOrStderr(), "Uploading", info.Size())
+
+	id, err := clientApp.Services.Secrets.PushFile(
+		cmd.Context(),
+		clientApp.AccessToken,
+		secretName,
+		description,
+		bar.Reader(f),
+	)
+	if err != nil {
+		bar.Stop()
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	bar.Finish()
+
+	clientApp.Log.Debug().Str("secret-id", id.String()).Msg("Secret saved successfully")
+
+	return nil
+}
@@ -39,6 +39,7 @@ func init() {
 	PushCmd.AddCommand(binCmd)
 	PushCmd.AddCommand(cardCmd)
 	PushCmd.AddCommand(credsCmd)
+	PushCmd.AddCommand(fileCmd)
 	PushCmd.AddCommand(textCmd)
 }
 
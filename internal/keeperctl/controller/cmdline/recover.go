@@ -0,0 +1,93 @@
+package cmdline
+
+import (
+	stderrors "errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover [flags]",
+	Short: "Set new master password using recovery code from the emergency kit",
+	RunE:  doRecover,
+}
+
+func init() {
+	recoverCmd.Flags().String("recovery-code", "", "Recovery code from the emergency kit")
+	recoverCmd.Flags().AddFlagSet(kdfFlags)
+
+	recoverCmd.MarkFlagRequired("recovery-code")
+
+	viper.BindPFlag("recovery-code", recoverCmd.Flags().Lookup("recovery-code"))
+
+	rootCmd.AddCommand(recoverCmd)
+}
+
+func doRecover(cmd *cobra.Command, args []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	kdf, err := encryption.NewKDFParams(cfg.KDFTime, cfg.KDFMemory, cfg.KDFThreads)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	tokens, key, err := clientApp.Services.Users.Recover(
+		cmd.Context(),
+		cfg.Username,
+		cfg.RecoveryCode,
+		cfg.OTP,
+		cfg.Password,
+		keyFile,
+		kdf,
+		clientApp.AllowLegacy,
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		if stderrors.Is(err, service.ErrSecondFactorRequired) {
+			return errOTPRequired
+		}
+
+		if stderrors.Is(err, encryption.ErrInvalidRecoveryCode) {
+			return encryption.ErrInvalidRecoveryCode
+		}
+
+		if stderrors.Is(err, encryption.ErrIntegrity) {
+			return encryption.ErrIntegrity
+		}
+
+		if stderrors.Is(err, encryption.ErrUnsupportedEnvelope) {
+			return encryption.ErrUnsupportedEnvelope
+		}
+
+		return errors.Unwrap(err)
+	}
+
+	if err := markMigrated(clientApp); err != nil {
+		return err
+	}
+
+	clientApp.Authenticate(tokens.AccessToken, key)
+	clientApp.RefreshToken = tokens.RefreshToken
+	clientApp.Log.Debug().Msg("Master password successfully reset")
+
+	if err := rememberKey(clientApp); err != nil {
+		return err
+	}
+
+	return saveSession(clientApp, tokens)
+}
@@ -1,9 +1,13 @@
 package cmdline
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 )
 
@@ -14,6 +18,15 @@ var registerCmd = &cobra.Command{
 }
 
 func init() {
+	registerCmd.Flags().String(
+		"emergency-kit",
+		"",
+		"Generate recovery code and save it to the new emergency kit file",
+	)
+	registerCmd.Flags().AddFlagSet(kdfFlags)
+
+	viper.BindPFlag("emergency-kit", registerCmd.Flags().Lookup("emergency-kit"))
+
 	rootCmd.AddCommand(registerCmd)
 }
 
@@ -23,18 +36,65 @@ func doRegister(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	accessToken, err := clientApp.Services.Users.Register(
+	kdf, err := encryption.NewKDFParams(cfg.KDFTime, cfg.KDFMemory, cfg.KDFThreads)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err := loadKeyFile()
+	if err != nil {
+		return err
+	}
+
+	// The kit is created in advance, so the recovery code is never lost
+	// because of the file system error after the user is registered.
+	var kit *os.File
+
+	if cfg.EmergencyKit != "" {
+		kit, err = createEmergencyKit(cfg.EmergencyKit)
+		if err != nil {
+			return err
+		}
+		defer kit.Close()
+	}
+
+	tokens, key, code, err := clientApp.Services.Users.Register(
 		cmd.Context(),
 		cfg.Username,
-		clientApp.EncryptionKey,
+		cfg.Password,
+		keyFile,
+		kdf,
+		kit != nil,
 	)
 	if err != nil {
 		clientApp.Log.Debug().Err(err).Msg("")
 
+		if kit != nil {
+			os.Remove(cfg.EmergencyKit)
+		}
+
 		return errors.Unwrap(err)
 	}
 
-	clientApp.Log.Debug().Str("access-token", accessToken).Msg("New user successfully created")
+	clientApp.Authenticate(tokens.AccessToken, key)
+	clientApp.RefreshToken = tokens.RefreshToken
+
+	if err := rememberKey(clientApp); err != nil {
+		return err
+	}
+
+	if err := saveSession(clientApp, tokens); err != nil {
+		return err
+	}
+
+	if kit != nil {
+		if err := writeEmergencyKit(kit, cfg.Address, cfg.Username, code); err != nil {
+			return err
+		}
+
+		clientApp.Log.Info().Str("path", cfg.EmergencyKit).Msg("Emergency kit saved, keep it in a safe place")
+	}
+	clientApp.Log.Debug().Str("access-token", tokens.AccessToken).Msg("New user successfully created")
 
 	return nil
 }
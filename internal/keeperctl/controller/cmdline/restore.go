@@ -0,0 +1,66 @@
+package cmdline
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+)
+
+var (
+	restoreVersion int32
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore [secret id] [flags]",
+		Short: "Restore the secret from trash or make its version current again, see history for available versions",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doRestore,
+	}
+)
+
+func init() {
+	restoreCmd.Flags().Int32Var(
+		&restoreVersion,
+		"version",
+		0,
+		"Version of the secret to restore, the secret is restored from trash by default",
+	)
+
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func doRestore(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	if restoreVersion < 0 {
+		return fmt.Errorf("invalid version %d, must not be negative", restoreVersion)
+	}
+
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	version, err := clientApp.Services.Secrets.Restore(cmd.Context(), clientApp.AccessToken, id, restoreVersion)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	if restoreVersion == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Secret restored from trash as version %d.\n", version)
+
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Version %d restored as version %d.\n", restoreVersion, version)
+
+	return nil
+}
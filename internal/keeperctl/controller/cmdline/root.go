@@ -18,8 +18,16 @@ var (
 	verbose  bool
 	address  string
 	caPath   string
+	crtPath  string
+	crtKey   string
+	apiToken string
 	username string
 	password string
+	keyFile  string
+	otp      string
+	device   string
+	stateDir string
+	legacy   bool
 
 	rootCmd = &cobra.Command{
 		Use:               "keeperctl",
@@ -52,16 +60,75 @@ func init() {
 		"",
 		"Path to certificate authority to verify server certificate",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&crtPath,
+		"client-cert",
+		"",
+		"Path to client certificate authenticating the user instead of access tokens",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&crtKey,
+		"client-key",
+		"",
+		"Path to key of the client certificate",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&apiToken,
+		"api-token",
+		"",
+		"API token authenticating the user instead of access tokens",
+	)
 	rootCmd.PersistentFlags().StringVarP(&username, "username", "u", "", "Name of a user")
 	rootCmd.PersistentFlags().StringVarP(&password, "password", "p", "", "Master password")
+	rootCmd.PersistentFlags().StringVar(
+		&keyFile,
+		"key-file",
+		"",
+		"Path to the key file mixed into encryption key",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&otp,
+		"otp",
+		"",
+		"One-time code or backup code if two-factor authentication is enabled",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&legacy,
+		"legacy",
+		false,
+		"Upgrade the account registered before SRP, sends the security key to the service once, "+
+			"and accept its secrets encrypted before envelope encryption until the password is changed",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&device,
+		"device-name",
+		"",
+		"Name of the device shown in the list of sessions, hostname by default",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&stateDir,
+		"state-dir",
+		"",
+		"Directory keeping tokens of the login session between runs, user config directory by default",
+	)
 
 	rootCmd.MarkFlagRequired("username")
 	rootCmd.MarkFlagRequired("password")
 
 	viper.BindPFlag("username", rootCmd.PersistentFlags().Lookup("username"))
 	viper.BindPFlag("password", rootCmd.PersistentFlags().Lookup("password"))
+	viper.BindPFlag("key-file", rootCmd.PersistentFlags().Lookup("key-file"))
+	viper.BindPFlag("otp", rootCmd.PersistentFlags().Lookup("otp"))
+	viper.BindPFlag("legacy", rootCmd.PersistentFlags().Lookup("legacy"))
 	viper.BindPFlag("address", rootCmd.PersistentFlags().Lookup("address"))
 	viper.BindPFlag("ca-path", rootCmd.PersistentFlags().Lookup("ca-path"))
+	viper.BindPFlag("client-cert", rootCmd.PersistentFlags().Lookup("client-cert"))
+	viper.BindPFlag("client-key", rootCmd.PersistentFlags().Lookup("client-key"))
+	viper.BindPFlag("api-token", rootCmd.PersistentFlags().Lookup("api-token"))
+	viper.BindPFlag("device-name", rootCmd.PersistentFlags().Lookup("device-name"))
+	viper.BindPFlag("state-dir", rootCmd.PersistentFlags().Lookup("state-dir"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 
 	rootCmd.AddCommand(pushcmd.PushCmd)
@@ -89,7 +156,17 @@ func initApp(cmd *cobra.Command, args []string) error {
 
 	cmd.SetContext(clientApp.WithContext(cmd.Context()))
 
-	if cmd.Name() == "register" {
+	if err := allowLegacy(clientApp); err != nil {
+		return err
+	}
+
+	// These commands authenticate the user themselves.
+	if cmd.Name() == "register" || cmd.Name() == "recover" {
+		return nil
+	}
+
+	// Logout ends the saved session, so new one must not be started.
+	if cmd.Name() == "logout" {
 		return nil
 	}
 
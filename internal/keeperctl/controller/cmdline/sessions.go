@@ -0,0 +1,98 @@
+package cmdline
+
+import (
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+)
+
+var (
+	sessionsCmd = &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage devices logged in to the account",
+	}
+
+	sessionsListCmd = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List active login sessions, the current one is marked with *",
+		Args:  cobra.NoArgs,
+		RunE:  doListSessions,
+	}
+
+	sessionsRevokeCmd = &cobra.Command{
+		Use:   "revoke [session id] [flags]",
+		Short: "Revoke the session, so the device has to log in again",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doRevokeSession,
+	}
+)
+
+func init() {
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsRevokeCmd)
+
+	rootCmd.AddCommand(sessionsCmd)
+}
+
+func doListSessions(cmd *cobra.Command, _ []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	sessions, err := clientApp.Services.Sessions.List(cmd.Context(), clientApp.AccessToken)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	t := tabby.New()
+	t.AddHeader("", "ID", "Device", "Address", "User agent", "Created", "Last seen")
+
+	for _, session := range sessions {
+		var current string
+		if session.GetCurrent() {
+			current = "*"
+		}
+
+		t.AddLine(
+			current,
+			session.GetId(),
+			session.GetDeviceName(),
+			session.GetPeerAddress(),
+			session.GetUserAgent(),
+			session.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			session.GetLastSeenAt().AsTime().Local().Format(time.DateTime),
+		)
+	}
+
+	t.Print()
+
+	return nil
+}
+
+func doRevokeSession(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := clientApp.Services.Sessions.Revoke(cmd.Context(), clientApp.AccessToken, id); err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package cmdline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+)
+
+var (
+	tokenReadOnly  bool
+	tokenSecrets   []string
+	tokenExpiresIn time.Duration
+
+	tokenCmd = &cobra.Command{
+		Use:   "token",
+		Short: "Manage API tokens for CI and automation",
+	}
+
+	tokenCreateCmd = &cobra.Command{
+		Use:   "create [name] [flags]",
+		Short: "Create API token, pass it with --api-token or GOPH_API_TOKEN instead of login",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doCreateToken,
+	}
+
+	tokenListCmd = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List API tokens",
+		Args:  cobra.NoArgs,
+		RunE:  doListTokens,
+	}
+
+	tokenRevokeCmd = &cobra.Command{
+		Use:   "revoke [token id] [flags]",
+		Short: "Revoke API token, so it is rejected from now on",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doRevokeToken,
+	}
+)
+
+func init() {
+	tokenCreateCmd.Flags().BoolVar(
+		&tokenReadOnly,
+		"read-only",
+		false,
+		"Only allow to list and get secrets",
+	)
+	tokenCreateCmd.Flags().StringSliceVar(
+		&tokenSecrets,
+		"secret",
+		nil,
+		"ID of a secret the token is limited to, could be repeated, all secrets by default",
+	)
+	tokenCreateCmd.Flags().DurationVar(
+		&tokenExpiresIn,
+		"expires-in",
+		0,
+		"Lifetime of the token, e.g. 720h, never expires by default",
+	)
+
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	rootCmd.AddCommand(tokenCmd)
+}
+
+func doCreateToken(cmd *cobra.Command, args []string) error {
+	secretIDs := make([]uuid.UUID, 0, len(tokenSecrets))
+
+	for _, src := range tokenSecrets {
+		id, err := uuid.Parse(src)
+		if err != nil {
+			return err
+		}
+
+		secretIDs = append(secretIDs, id)
+	}
+
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	token, info, err := clientApp.Services.APITokens.Create(
+		cmd.Context(),
+		clientApp.AccessToken,
+		args[0],
+		tokenReadOnly,
+		secretIDs,
+		tokenExpiresIn,
+	)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	clientApp.Log.Debug().Str("id", info.GetId()).Msg("API token created")
+
+	fmt.Println("API token, it is not shown again:")
+	fmt.Println(token)
+
+	return nil
+}
+
+func doListTokens(cmd *cobra.Command, _ []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	tokens, err := clientApp.Services.APITokens.List(cmd.Context(), clientApp.AccessToken)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	t := tabby.New()
+	t.AddHeader("ID", "Name", "Access", "Secrets", "Created", "Expires")
+
+	for _, token := range tokens {
+		access := "read-write"
+		if token.GetReadOnly() {
+			access = "read-only"
+		}
+
+		secrets := "all"
+		if len(token.GetSecretIds()) != 0 {
+			secrets = strings.Join(token.GetSecretIds(), ",")
+		}
+
+		expires := "never"
+		if token.GetExpiresAt() != nil {
+			expires = token.GetExpiresAt().AsTime().Local().Format(time.DateTime)
+		}
+
+		t.AddLine(
+			token.GetId(),
+			token.GetName(),
+			access,
+			secrets,
+			token.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			expires,
+		)
+	}
+
+	t.Print()
+
+	return nil
+}
+
+func doRevokeToken(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := clientApp.Services.APITokens.Revoke(cmd.Context(), clientApp.AccessToken, id); err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	return nil
+}
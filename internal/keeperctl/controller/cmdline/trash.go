@@ -0,0 +1,50 @@
+package cmdline
+
+import (
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash [flags]",
+	Short: "List deleted secrets kept in trash (without data), the latest deleted go first",
+	Args:  cobra.NoArgs,
+	RunE:  doTrash,
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+}
+
+func doTrash(cmd *cobra.Command, args []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	secrets, err := clientApp.Services.Secrets.ListTrash(cmd.Context(), clientApp.AccessToken)
+	if err != nil {
+		return pullError(clientApp, err)
+	}
+
+	t := tabby.New()
+	t.AddHeader("ID", "Name", "Kind", "Description", "Deleted")
+
+	for _, secret := range secrets {
+		t.AddLine(
+			secret.GetId(),
+			string(secret.GetName()),
+			secret.Kind.String(),
+			string(secret.GetMetadata()),
+			secret.GetDeletedAt().AsTime().Local().Format(time.DateTime),
+		)
+	}
+
+	t.Print()
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package cmdline
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/app"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+)
+
+var errNoOTP = stderrors.New("one-time code is not provided")
+
+var (
+	twoFactorCmd = &cobra.Command{
+		Use:   "2fa",
+		Short: "Manage two-factor authentication with authenticator app",
+	}
+
+	twoFactorEnableCmd = &cobra.Command{
+		Use:   "enable [flags]",
+		Short: "Enable two-factor authentication and print backup codes",
+		Args:  cobra.NoArgs,
+		RunE:  doEnableTwoFactor,
+	}
+
+	twoFactorDisableCmd = &cobra.Command{
+		Use:   "disable [one-time code or backup code] [flags]",
+		Short: "Disable two-factor authentication",
+		Args:  cobra.ExactArgs(1),
+		RunE:  doDisableTwoFactor,
+	}
+)
+
+func init() {
+	twoFactorCmd.AddCommand(twoFactorEnableCmd)
+	twoFactorCmd.AddCommand(twoFactorDisableCmd)
+
+	rootCmd.AddCommand(twoFactorCmd)
+}
+
+func doEnableTwoFactor(cmd *cobra.Command, _ []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	uri, err := clientApp.Services.Users.SetupTOTP(cmd.Context(), clientApp.AccessToken)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintln(out, "Add the following URI to your authenticator app:")
+	fmt.Fprintln(out, uri)
+	fmt.Fprint(out, "Enter one-time code from the app: ")
+
+	code, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !stderrors.Is(err, io.EOF) {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return errNoOTP
+	}
+
+	backupCodes, err := clientApp.Services.Users.EnableTOTP(cmd.Context(), clientApp.AccessToken, code)
+	if err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	fmt.Fprintln(out, "Two-factor authentication is enabled.")
+	fmt.Fprintln(out, "Keep these backup codes in a safe place, each of them can be used once instead of one-time code:")
+
+	for _, backupCode := range backupCodes {
+		fmt.Fprintln(out, backupCode)
+	}
+
+	return nil
+}
+
+func doDisableTwoFactor(cmd *cobra.Command, args []string) error {
+	clientApp, err := app.FromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := clientApp.Services.Users.DisableTOTP(cmd.Context(), clientApp.AccessToken, args[0]); err != nil {
+		clientApp.Log.Debug().Err(err).Msg("")
+
+		return errors.Unwrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidDataKey = errors.New("invalid data key")
+
+// NewDataKey generates random key encrypting content of a single secret.
+func NewDataKey() (Key, error) {
+	var key Key
+
+	if _, err := io.ReadFull(rand.Reader, key.sum[:]); err != nil {
+		return key, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	return key, nil
+}
+
+// Wrap encrypts the data key, so it could be stored alongside the secret.
+// Additional data binds the wrapped key to the secret, see Seal.
+func (k Key) Wrap(dataKey Key, additionalData []byte) ([]byte, error) {
+	return k.Seal(dataKey.sum[:], additionalData)
+}
+
+// Unwrap decrypts the data key wrapped by the key.
+func (k Key) Unwrap(wrapped, additionalData []byte) (Key, error) {
+	var key Key
+
+	raw, err := k.Open(wrapped, additionalData)
+	if err != nil {
+		return key, err
+	}
+
+	if len(raw) != KeyLength {
+		return key, fmt.Errorf("%w: length %d", ErrInvalidDataKey, len(raw))
+	}
+
+	copy(key.sum[:], raw)
+
+	return key, nil
+}
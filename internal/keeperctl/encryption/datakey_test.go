@@ -0,0 +1,74 @@
+package encryption_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestWrapDataKey(t *testing.T) {
+	vault, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	dataKey, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := vault.Wrap(dataKey, []byte(gophtest.SecretName))
+	require.NoError(t, err)
+
+	unwrapped, err := vault.Unwrap(wrapped, []byte(gophtest.SecretName))
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unwrapped)
+}
+
+func TestNewDataKeyIsRandom(t *testing.T) {
+	first, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	second, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	require.NotEqual(t, first, second)
+}
+
+func TestUnwrapDataKeyWithWrongKey(t *testing.T) {
+	vault, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	other, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := vault.Wrap(other, nil)
+	require.NoError(t, err)
+
+	_, err = other.Unwrap(wrapped, nil)
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+}
+
+func TestUnwrapDataKeyOfOtherSecret(t *testing.T) {
+	vault, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	dataKey, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := vault.Wrap(dataKey, []byte(gophtest.SecretName))
+	require.NoError(t, err)
+
+	_, err = vault.Unwrap(wrapped, []byte(gophtest.SecretName+"ex"))
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+}
+
+func TestUnwrapMalformedDataKey(t *testing.T) {
+	vault, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := vault.Encrypt([]byte(gophtest.DataKey))
+	require.NoError(t, err)
+
+	_, err = vault.Unwrap(wrapped, nil)
+	require.ErrorIs(t, err, encryption.ErrInvalidDataKey)
+}
@@ -0,0 +1,160 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+)
+
+// Algorithm identifies the cipher used to seal an envelope.
+type Algorithm byte
+
+const (
+	AlgorithmAES256GCM Algorithm = 1
+)
+
+// Envelope format:
+//
+//	magic(2) | version(1) | algorithm(1) | key id(8) | nonce | ciphertext
+//
+// The header is authenticated together with the additional data,
+// so neither the algorithm nor the key id could be swapped unnoticed.
+const (
+	EnvelopeVersion = 1
+	KeyIDLength     = 8
+
+	envelopeHeaderLength = len(envelopeMagic) + 2 + KeyIDLength
+)
+
+// envelopeMagic prefixes every envelope.
+// Blobs without it were produced before envelopes were introduced
+// and are bare nonce||ciphertext sealed with AES-256-GCM.
+const envelopeMagic = "GK"
+
+// HKDF label of the key id.
+const keyIDInfo = "gophkeeper key id"
+
+var ErrUnsupportedEnvelope = errors.New("unsupported envelope format")
+
+// envelope is the parsed sealed message.
+type envelope struct {
+	version   byte
+	algorithm Algorithm
+	keyID     []byte
+	header    []byte
+	payload   []byte
+}
+
+// parseEnvelope splits the message into header and payload.
+// Returns false if the message has no envelope header.
+func parseEnvelope(data []byte) (envelope, bool) {
+	if len(data) < envelopeHeaderLength || !bytes.HasPrefix(data, []byte(envelopeMagic)) {
+		return envelope{}, false
+	}
+
+	offset := len(envelopeMagic)
+
+	return envelope{
+		version:   data[offset],
+		algorithm: Algorithm(data[offset+1]),
+		keyID:     data[offset+2 : envelopeHeaderLength],
+		header:    data[:envelopeHeaderLength],
+		payload:   data[envelopeHeaderLength:],
+	}, true
+}
+
+// ID is a short fingerprint of the key stored in the envelope header.
+// It tells which key sealed the message without revealing the key.
+func (k Key) ID() ([]byte, error) {
+	idKey, err := k.expand(keyIDInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return idKey.sum[:KeyIDLength], nil
+}
+
+// header builds the envelope header for the algorithm.
+func (k Key) header(algorithm Algorithm) ([]byte, error) {
+	keyID, err := k.ID()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, envelopeHeaderLength)
+	header = append(header, envelopeMagic...)
+	header = append(header, EnvelopeVersion, byte(algorithm))
+
+	return append(header, keyID...), nil
+}
+
+// openEnvelope decrypts the payload of the parsed envelope.
+func (k Key) openEnvelope(env envelope, additionalData []byte) ([]byte, error) {
+	if env.version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedEnvelope, env.version)
+	}
+
+	aead, err := k.aead(env.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := k.ID()
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(env.keyID, keyID) {
+		return nil, fmt.Errorf("%w: message is sealed with other key", ErrIntegrity)
+	}
+
+	return open(aead, env.payload, envelopeAdditionalData(env.header, additionalData))
+}
+
+// aead provides the cipher implementing the algorithm.
+func (k Key) aead(algorithm Algorithm) (cipher.AEAD, error) {
+	switch algorithm {
+	case AlgorithmAES256GCM:
+		cipherBlock, err := aes.NewCipher(k.sum[:])
+		if err != nil {
+			return nil, fmt.Errorf("newCipher error: %w", err)
+		}
+
+		gcm, err := cipher.NewGCM(cipherBlock)
+		if err != nil {
+			return nil, fmt.Errorf("newgcm error: %w", err)
+		}
+
+		return gcm, nil
+
+	default:
+		return nil, fmt.Errorf("%w: algorithm %d", ErrUnsupportedEnvelope, algorithm)
+	}
+}
+
+// open splits the payload into nonce and ciphertext and decrypts it.
+func open(aead cipher.AEAD, payload, additionalData []byte) ([]byte, error) {
+	nonceLength := aead.NonceSize()
+	if len(payload) < nonceLength+aead.Overhead() {
+		return nil, fmt.Errorf("%w: message is too short", ErrIntegrity)
+	}
+
+	nonce, ciphertext := payload[:nonceLength], payload[nonceLength:]
+
+	decrypted, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrIntegrity
+	}
+
+	return decrypted, nil
+}
+
+// envelopeAdditionalData authenticates the header together with the additional data.
+func envelopeAdditionalData(header, additionalData []byte) []byte {
+	ad := make([]byte, 0, len(header)+len(additionalData))
+	ad = append(ad, header...)
+
+	return append(ad, additionalData...)
+}
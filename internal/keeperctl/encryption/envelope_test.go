@@ -0,0 +1,144 @@
+package encryption_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+const envelopeHeaderLength = 2 + 2 + encryption.KeyIDLength
+
+func newTestLegacyKey(t *testing.T) encryption.Key {
+	t.Helper()
+
+	key, err := encryption.NewKey(
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		encryption.KDFParams{Algorithm: encryption.KDFSHA256},
+	)
+	require.NoError(t, err)
+
+	return key
+}
+
+// sealLegacy seals the message the way it was done before envelopes were introduced.
+func sealLegacy(t *testing.T, nonce, msg, ad []byte) []byte {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(gophtest.Username + "@" + string(gophtest.Password)))
+
+	block, err := aes.NewCipher(sum[:])
+	require.NoError(t, err)
+
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	return gcm.Seal(append([]byte{}, nonce...), nonce, msg, ad)
+}
+
+func TestSealProducesEnvelope(t *testing.T) {
+	key := newTestLegacyKey(t)
+
+	keyID, err := key.ID()
+	require.NoError(t, err)
+	require.Len(t, keyID, encryption.KeyIDLength)
+
+	sat, err := key.Seal([]byte("TestSealProducesEnvelope"), nil)
+
+	require.NoError(t, err)
+	require.Equal(t, "GK", string(sat[:2]))
+	require.Equal(t, byte(encryption.EnvelopeVersion), sat[2])
+	require.Equal(t, byte(encryption.AlgorithmAES256GCM), sat[3])
+	require.Equal(t, keyID, sat[4:envelopeHeaderLength])
+}
+
+func TestOpenLegacyMessage(t *testing.T) {
+	key := newTestLegacyKey(t)
+	msg := []byte("TestOpenLegacyMessage")
+	ad := []byte(gophtest.SecretName)
+
+	tt := []struct {
+		name  string
+		nonce []byte
+	}{
+		{
+			name:  "Headerless message",
+			nonce: []byte("0123456789ab"),
+		},
+		{
+			name:  "Headerless message with nonce looking like envelope",
+			nonce: []byte("GK\x01\x01456789ab"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sat, err := key.Open(sealLegacy(t, tc.nonce, msg, ad), ad)
+
+			require.NoError(t, err)
+			require.Equal(t, msg, sat)
+		})
+	}
+}
+
+func TestOpenTamperedEnvelope(t *testing.T) {
+	key := newTestLegacyKey(t)
+
+	sealed, err := key.Seal([]byte("TestOpenTamperedEnvelope"), nil)
+	require.NoError(t, err)
+
+	tamper := func(offset int, value byte) []byte {
+		data := append([]byte{}, sealed...)
+		data[offset] = value
+
+		return data
+	}
+
+	otherKey, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	otherSealed, err := otherKey.Seal([]byte("TestOpenTamperedEnvelope"), nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{
+			name:     "Unknown version",
+			data:     tamper(2, encryption.EnvelopeVersion+1),
+			expected: encryption.ErrUnsupportedEnvelope,
+		},
+		{
+			name:     "Unknown algorithm",
+			data:     tamper(3, 0xff),
+			expected: encryption.ErrUnsupportedEnvelope,
+		},
+		{
+			name:     "Key id is changed",
+			data:     tamper(4, sealed[4]^1),
+			expected: encryption.ErrIntegrity,
+		},
+		{
+			name:     "Message is sealed with other key",
+			data:     otherSealed,
+			expected: encryption.ErrIntegrity,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := key.Open(tc.data, nil)
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
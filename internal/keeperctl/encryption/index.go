@@ -0,0 +1,35 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// HKDF label of the key used to compute blind indexes.
+const indexKeyInfo = "gophkeeper name index key"
+
+// BlindIndexLength is the length of blind index in bytes.
+const BlindIndexLength = sha256.Size
+
+// BlindIndex computes keyed hash of the normalized value.
+// It lets the server check uniqueness of values without knowing them.
+// The index key is derived from the key, so index changes together with the key.
+func (k Key) BlindIndex(value string) ([]byte, error) {
+	indexKey, err := k.expand(indexKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, indexKey.sum[:])
+	mac.Write([]byte(normalize(value)))
+
+	return mac.Sum(nil), nil
+}
+
+// normalize brings equivalent representations of the value to the same form.
+func normalize(value string) string {
+	return norm.NFC.String(strings.TrimSpace(value))
+}
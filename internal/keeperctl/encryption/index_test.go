@@ -0,0 +1,71 @@
+package encryption_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestBlindIndex(t *testing.T) {
+	key := newTestMasterKey(t)
+
+	sat, err := key.BlindIndex(gophtest.SecretName)
+
+	require.NoError(t, err)
+	require.Len(t, sat, encryption.BlindIndexLength)
+	require.NotContains(t, string(sat), gophtest.SecretName)
+}
+
+func TestBlindIndexOfNormalizedNames(t *testing.T) {
+	key := newTestMasterKey(t)
+
+	tt := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "Surrounding spaces are ignored",
+			value:    "  " + gophtest.SecretName + "\n",
+			expected: gophtest.SecretName,
+		},
+		{
+			name:     "Decomposed characters are composed",
+			value:    "Café",
+			expected: "Café",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, err := key.BlindIndex(tc.expected)
+			require.NoError(t, err)
+
+			sat, err := key.BlindIndex(tc.value)
+
+			require.NoError(t, err)
+			require.Equal(t, expected, sat)
+		})
+	}
+}
+
+func TestBlindIndexDependsOnKeyAndName(t *testing.T) {
+	key := newTestMasterKey(t)
+
+	expected, err := key.BlindIndex(gophtest.SecretName)
+	require.NoError(t, err)
+
+	other, err := key.BlindIndex(gophtest.SecretName + "ex")
+	require.NoError(t, err)
+	require.NotEqual(t, expected, other)
+
+	dataKey, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	other, err = dataKey.BlindIndex(gophtest.SecretName)
+	require.NoError(t, err)
+	require.NotEqual(t, expected, other)
+}
@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// KDFAlgorithm is function used to derive encryption key from master password.
+type KDFAlgorithm int32
+
+// Values match goph.KDFAlgorithm.
+const (
+	KDFSHA256   KDFAlgorithm = 0
+	KDFArgon2id KDFAlgorithm = 1
+)
+
+// KeySchedule defines how keys are derived from the master key.
+type KeySchedule int32
+
+// Values match goph.KeySchedule.
+const (
+	KeyScheduleLegacy     KeySchedule = 0
+	KeyScheduleAuthSubkey KeySchedule = 1
+	KeyScheduleSubkeys    KeySchedule = 2
+)
+
+// Recommended Argon2id cost (RFC 9106, second recommended option).
+const (
+	DefaultKDFTime    = 3
+	DefaultKDFMemory  = 64 * 1024
+	DefaultKDFThreads = 4
+
+	SaltLength = 16
+)
+
+// Bounds of Argon2id parameters received from the server, the same as keeperd accepts on registration.
+// Weaker parameters would weaken the key, stronger ones would exhaust memory or CPU of the client.
+const (
+	MinKDFSaltLength = 16
+	MaxKDFSaltLength = 64
+	MaxKDFTime       = 16
+	MinKDFMemory     = 19 * 1024
+	MaxKDFMemory     = 1024 * 1024
+	MaxKDFThreads    = 16
+)
+
+// KDFParams are parameters of key derivation chosen at registration.
+// Time is number of passes over the memory, Memory is size of the memory in KiB.
+type KDFParams struct {
+	Algorithm KDFAlgorithm
+	Salt      []byte
+	Time      uint32
+	Memory    uint32
+	Threads   uint8
+
+	Schedule KeySchedule
+
+	// KeyFile is set if a key file is mixed into the master key.
+	KeyFile bool
+}
+
+// NewKDFParams creates Argon2id parameters with new random salt.
+// Separate auth and vault keys are derived with the parameters.
+func NewKDFParams(time, memory uint32, threads uint8) (KDFParams, error) {
+	kdf := KDFParams{
+		Algorithm: KDFArgon2id,
+		Salt:      make([]byte, SaltLength),
+		Time:      time,
+		Memory:    memory,
+		Threads:   threads,
+
+		Schedule: KeyScheduleSubkeys,
+	}
+
+	if _, err := io.ReadFull(rand.Reader, kdf.Salt); err != nil {
+		return kdf, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	if err := kdf.validate(); err != nil {
+		return kdf, err
+	}
+
+	return kdf, nil
+}
+
+// validate verifies that Argon2id parameters are within the bounds.
+func (p KDFParams) validate() error {
+	if l := len(p.Salt); l < MinKDFSaltLength || l > MaxKDFSaltLength {
+		return fmt.Errorf("%w: salt should be %d-%d bytes", ErrUnsupportedKDF, MinKDFSaltLength, MaxKDFSaltLength)
+	}
+
+	if p.Time == 0 || p.Time > MaxKDFTime {
+		return fmt.Errorf("%w: time should be 1-%d", ErrUnsupportedKDF, MaxKDFTime)
+	}
+
+	if p.Memory < MinKDFMemory || p.Memory > MaxKDFMemory {
+		return fmt.Errorf("%w: memory should be %d-%d KiB", ErrUnsupportedKDF, MinKDFMemory, MaxKDFMemory)
+	}
+
+	if p.Threads == 0 || p.Threads > MaxKDFThreads {
+		return fmt.Errorf("%w: threads should be 1-%d", ErrUnsupportedKDF, MaxKDFThreads)
+	}
+
+	return nil
+}
@@ -1,29 +1,68 @@
 package encryption
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 
+	"golang.org/x/crypto/argon2"
+
 	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
 )
 
-const NonceLength = 12
+const (
+	NonceLength = 12
+	KeyLength   = 32
+)
+
+var (
+	ErrUnsupportedKDF = errors.New("unsupported key derivation parameters")
+	ErrIntegrity      = errors.New("integrity check failed, data could be tampered with")
+)
 
 // Key is user's encryption key.
 type Key struct {
-	sum [sha256.Size]byte
+	sum [KeyLength]byte
 }
 
-// NewKey creates new encryption key.
-func NewKey(username string, password creds.Password) Key {
-	sum := sha256.Sum256([]byte(username + "@" + string(password)))
+// NewKey derives new encryption key from the master password
+// using provided key derivation parameters.
+// The key file must be provided if and only if the parameters require it.
+func NewKey(username string, password creds.Password, keyFile KeyFile, kdf KDFParams) (Key, error) {
+	var key Key
+
+	if kdf.KeyFile && keyFile.IsEmpty() {
+		return key, ErrKeyFileRequired
+	}
+
+	if !kdf.KeyFile && !keyFile.IsEmpty() {
+		return key, ErrKeyFileNotUsed
+	}
+
+	switch kdf.Algorithm {
+	case KDFSHA256:
+		key.sum = sha256.Sum256([]byte(username + "@" + string(password)))
+
+	case KDFArgon2id:
+		if err := kdf.validate(); err != nil {
+			return key, err
+		}
+
+		sum := argon2.IDKey([]byte(password), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, KeyLength)
+		copy(key.sum[:], sum)
+
+	default:
+		return key, fmt.Errorf("%w: algorithm %d", ErrUnsupportedKDF, kdf.Algorithm)
+	}
+
+	if kdf.KeyFile {
+		key = keyFile.mix(key)
+	}
 
-	return Key{sum: sum}
+	return key, nil
 }
 
 // Hash provides hash of the encryption key.
@@ -37,17 +76,31 @@ func (k Key) Encrypt(data []byte) ([]byte, error) {
 		return data, nil
 	}
 
-	gcm, err := k.getGCM()
+	return k.Seal(data, nil)
+}
+
+// Seal encrypts provided message and authenticates it together with additional data.
+// The same additional data must be provided to open the message.
+// The result is an envelope describing the algorithm and the key, see envelope.go.
+func (k Key) Seal(data, additionalData []byte) ([]byte, error) {
+	header, err := k.header(AlgorithmAES256GCM)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.aead(AlgorithmAES256GCM)
 	if err != nil {
 		return nil, err
 	}
 
-	nonce := make([]byte, NonceLength)
+	nonce := make([]byte, aead.NonceSize())
 	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, fmt.Errorf("ReadFull error: %w", err)
 	}
 
-	return gcm.Seal(nonce, nonce, data, nil), nil
+	sealed := append(header, nonce...)
+
+	return aead.Seal(sealed, nonce, data, envelopeAdditionalData(header, additionalData)), nil
 }
 
 // Decrypt decrypts provided data.
@@ -56,31 +109,37 @@ func (k Key) Decrypt(data []byte) ([]byte, error) {
 		return data, nil
 	}
 
-	gcm, err := k.getGCM()
-	if err != nil {
-		return nil, err
+	return k.Open(data, nil)
+}
+
+// Open decrypts provided data sealed with the additional data.
+// Messages sealed before envelopes were introduced are opened as well.
+// Returns ErrIntegrity if the data or the additional data was changed.
+func (k Key) Open(data, additionalData []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return k.openLegacy(data, additionalData)
 	}
 
-	nonce, payload := data[:NonceLength], data[NonceLength:]
+	decrypted, err := k.openEnvelope(env, additionalData)
+	if err == nil {
+		return decrypted, nil
+	}
 
-	decrypted, err := gcm.Open(nil, nonce, payload, nil)
-	if err != nil {
-		return nil, fmt.Errorf("can't decript key: %w", err)
+	// Random nonce of a legacy message could start with the envelope magic.
+	if decrypted, legacyErr := k.openLegacy(data, additionalData); legacyErr == nil {
+		return decrypted, nil
 	}
 
-	return decrypted, nil
+	return nil, err
 }
 
-func (k Key) getGCM() (cipher.AEAD, error) {
-	cipherBlock, err := aes.NewCipher(k.sum[:])
+// openLegacy decrypts headerless nonce||ciphertext sealed with AES-256-GCM.
+func (k Key) openLegacy(data, additionalData []byte) ([]byte, error) {
+	aead, err := k.aead(AlgorithmAES256GCM)
 	if err != nil {
-		return nil, fmt.Errorf("newCipher error: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(cipherBlock)
-	if err != nil {
-		return nil, fmt.Errorf("newgcm error: %w", err)
+		return nil, err
 	}
 
-	return gcm, nil
+	return open(aead, data, additionalData)
 }
@@ -11,10 +11,184 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
 )
 
+func newTestKDFParams() encryption.KDFParams {
+	return encryption.KDFParams{
+		Algorithm: encryption.KDFArgon2id,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+	}
+}
+
 func TestKeyToHash(t *testing.T) {
-	sat := encryption.NewKey(gophtest.Username, gophtest.Password)
+	tt := []struct {
+		name string
+		kdf  encryption.KDFParams
+	}{
+		{
+			name: "Legacy key",
+			kdf:  encryption.KDFParams{Algorithm: encryption.KDFSHA256},
+		},
+		{
+			name: "Argon2id key",
+			kdf:  newTestKDFParams(),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sat, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, tc.kdf)
+			require.NoError(t, err)
+
+			snaps.MatchSnapshot(t, sat.Hash())
+		})
+	}
+}
+
+func TestArgon2idKeyDependsOnSalt(t *testing.T) {
+	kdf := newTestKDFParams()
 
-	snaps.MatchSnapshot(t, sat.Hash())
+	first, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, kdf)
+	require.NoError(t, err)
+
+	kdf.Salt = []byte("fedcba9876543210")
+
+	second, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, kdf)
+	require.NoError(t, err)
+
+	require.NotEqual(t, first.Hash(), second.Hash())
+}
+
+func TestArgon2idKeyDoesNotDependOnUsername(t *testing.T) {
+	first, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+	require.NoError(t, err)
+
+	second, err := encryption.NewKey("root", gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+	require.NoError(t, err)
+
+	require.Equal(t, first.Hash(), second.Hash())
+}
+
+func TestNewKeyWithBadKDFParams(t *testing.T) {
+	tt := []struct {
+		name string
+		kdf  func(kdf encryption.KDFParams) encryption.KDFParams
+	}{
+		{
+			name: "Unknown algorithm",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Algorithm = 42
+
+				return kdf
+			},
+		},
+		{
+			name: "Empty salt",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Salt = nil
+
+				return kdf
+			},
+		},
+		{
+			name: "Too short salt",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Salt = make([]byte, encryption.MinKDFSaltLength-1)
+
+				return kdf
+			},
+		},
+		{
+			name: "Too long salt",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Salt = make([]byte, encryption.MaxKDFSaltLength+1)
+
+				return kdf
+			},
+		},
+		{
+			name: "Zero time",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Time = 0
+
+				return kdf
+			},
+		},
+		{
+			name: "Too much time",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Time = encryption.MaxKDFTime + 1
+
+				return kdf
+			},
+		},
+		{
+			name: "Zero threads",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Threads = 0
+
+				return kdf
+			},
+		},
+		{
+			name: "Too many threads",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Threads = encryption.MaxKDFThreads + 1
+
+				return kdf
+			},
+		},
+		{
+			name: "Not enough memory",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Memory = encryption.MinKDFMemory - 1
+
+				return kdf
+			},
+		},
+		{
+			name: "Too much memory",
+			kdf: func(kdf encryption.KDFParams) encryption.KDFParams {
+				kdf.Memory = encryption.MaxKDFMemory + 1
+
+				return kdf
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := encryption.NewKey(
+				gophtest.Username,
+				gophtest.Password,
+				encryption.KeyFile{},
+				tc.kdf(newTestKDFParams()),
+			)
+
+			require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
+		})
+	}
+}
+
+func TestNewKDFParams(t *testing.T) {
+	first, err := encryption.NewKDFParams(gophtest.KDFTime, gophtest.KDFMemory, gophtest.KDFThreads)
+	require.NoError(t, err)
+
+	second, err := encryption.NewKDFParams(gophtest.KDFTime, gophtest.KDFMemory, gophtest.KDFThreads)
+	require.NoError(t, err)
+
+	require.Equal(t, encryption.KDFArgon2id, first.Algorithm)
+	require.Len(t, first.Salt, encryption.SaltLength)
+	require.NotEqual(t, first.Salt, second.Salt)
+}
+
+func TestNewKDFParamsWithBadCost(t *testing.T) {
+	_, err := encryption.NewKDFParams(0, gophtest.KDFMemory, gophtest.KDFThreads)
+	require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
+
+	_, err = encryption.NewKDFParams(gophtest.KDFTime, encryption.MaxKDFMemory+1, gophtest.KDFThreads)
+	require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
 }
 
 func TestEncryptDecrypt(t *testing.T) {
@@ -46,7 +220,8 @@ func TestEncryptDecrypt(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			sat := encryption.NewKey(gophtest.Username, gophtest.Password)
+			sat, err := encryption.NewKey(tc.username, tc.password, encryption.KeyFile{}, newTestKDFParams())
+			require.NoError(t, err)
 
 			encrypted, err := sat.Encrypt(tc.msg)
 			require.NoError(t, err)
@@ -57,3 +232,85 @@ func TestEncryptDecrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestSealOpen(t *testing.T) {
+	tt := []struct {
+		name string
+		msg  []byte
+		ad   []byte
+	}{
+		{
+			name: "Message with additional data",
+			msg:  []byte("TestSealOpen"),
+			ad:   []byte(gophtest.SecretName),
+		},
+		{
+			name: "Message without additional data",
+			msg:  []byte("TestSealOpen"),
+			ad:   nil,
+		},
+		{
+			name: "Empty message is authenticated",
+			msg:  []byte{},
+			ad:   []byte(gophtest.SecretName),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sat, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+			require.NoError(t, err)
+
+			sealed, err := sat.Seal(tc.msg, tc.ad)
+			require.NoError(t, err)
+			require.NotEmpty(t, sealed)
+
+			opened, err := sat.Open(sealed, tc.ad)
+			require.NoError(t, err)
+			require.Equal(t, string(tc.msg), string(opened))
+		})
+	}
+}
+
+func TestOpenTamperedMessage(t *testing.T) {
+	sat, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+	require.NoError(t, err)
+
+	sealed, err := sat.Seal([]byte("TestOpenTamperedMessage"), []byte(gophtest.SecretName))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name string
+		data []byte
+		ad   []byte
+	}{
+		{
+			name: "Open fails if additional data differs",
+			data: sealed,
+			ad:   []byte(gophtest.SecretName + "ex"),
+		},
+		{
+			name: "Open fails if additional data is missing",
+			data: sealed,
+			ad:   nil,
+		},
+		{
+			name: "Open fails if message is changed",
+			data: append(append([]byte{}, sealed[:len(sealed)-1]...), sealed[len(sealed)-1]^1),
+			ad:   []byte(gophtest.SecretName),
+		},
+		{
+			name: "Open fails if message is truncated",
+			data: sealed[:encryption.NonceLength],
+			ad:   []byte(gophtest.SecretName),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sat.Open(tc.data, tc.ad)
+
+			require.ErrorIs(t, err, encryption.ErrIntegrity)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeyFileLength is the number of random bytes in a generated key file.
+const KeyFileLength = 64
+
+var (
+	ErrEmptyKeyFile    = errors.New("key file is empty")
+	ErrKeyFileRequired = errors.New("account is protected with a key file, but the key file is not provided")
+	ErrKeyFileNotUsed  = errors.New("account is not protected with a key file, but the key file is provided")
+)
+
+// KeyFile is a file mixed into the master key as something the user has.
+// Any non-empty file could be used, only the digest of its contents is kept.
+// Zero value means that the key file is not provided.
+type KeyFile struct {
+	sum [sha256.Size]byte
+	set bool
+}
+
+// NewKeyFile creates key file from the file contents.
+func NewKeyFile(contents []byte) (KeyFile, error) {
+	if len(contents) == 0 {
+		return KeyFile{}, ErrEmptyKeyFile
+	}
+
+	return KeyFile{sum: sha256.Sum256(contents), set: true}, nil
+}
+
+// GenerateKeyFile generates contents of a new random key file.
+func GenerateKeyFile() ([]byte, error) {
+	contents := make([]byte, KeyFileLength)
+	if _, err := io.ReadFull(rand.Reader, contents); err != nil {
+		return nil, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	return contents, nil
+}
+
+// IsEmpty reports whether the key file is not provided.
+func (f KeyFile) IsEmpty() bool {
+	return !f.set
+}
+
+// mix combines the key derived from the master password with the key file,
+// so the master key couldn't be derived without any of them.
+func (f KeyFile) mix(key Key) Key {
+	var mixed Key
+
+	copy(mixed.sum[:], hkdf.Extract(sha256.New, key.sum[:], f.sum[:]))
+
+	return mixed
+}
@@ -0,0 +1,93 @@
+package encryption_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestKeyFile(t *testing.T, contents string) encryption.KeyFile {
+	t.Helper()
+
+	keyFile, err := encryption.NewKeyFile([]byte(contents))
+	require.NoError(t, err)
+
+	return keyFile
+}
+
+func TestGenerateKeyFile(t *testing.T) {
+	contents, err := encryption.GenerateKeyFile()
+	require.NoError(t, err)
+	require.Len(t, contents, encryption.KeyFileLength)
+
+	other, err := encryption.GenerateKeyFile()
+	require.NoError(t, err)
+	require.NotEqual(t, contents, other)
+
+	keyFile, err := encryption.NewKeyFile(contents)
+	require.NoError(t, err)
+	require.False(t, keyFile.IsEmpty())
+}
+
+func TestNewKeyFileFromEmptyFile(t *testing.T) {
+	_, err := encryption.NewKeyFile(nil)
+
+	require.ErrorIs(t, err, encryption.ErrEmptyKeyFile)
+}
+
+func TestKeyFileIsMixedIntoKey(t *testing.T) {
+	kdf := newTestKDFParams()
+
+	withoutKeyFile, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, kdf)
+	require.NoError(t, err)
+
+	kdf.KeyFile = true
+
+	first, err := encryption.NewKey(gophtest.Username, gophtest.Password, newTestKeyFile(t, gophtest.KeyFile), kdf)
+	require.NoError(t, err)
+	require.NotEqual(t, withoutKeyFile.Hash(), first.Hash())
+
+	second, err := encryption.NewKey(gophtest.Username, gophtest.Password, newTestKeyFile(t, gophtest.KeyFile), kdf)
+	require.NoError(t, err)
+	require.Equal(t, first.Hash(), second.Hash())
+
+	other, err := encryption.NewKey(gophtest.Username, gophtest.Password, newTestKeyFile(t, "other"), kdf)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Hash(), other.Hash())
+}
+
+func TestNewKeyWithMismatchedKeyFile(t *testing.T) {
+	tt := []struct {
+		name     string
+		keyFile  encryption.KeyFile
+		required bool
+		expected error
+	}{
+		{
+			name:     "Key file is required, but not provided",
+			keyFile:  encryption.KeyFile{},
+			required: true,
+			expected: encryption.ErrKeyFileRequired,
+		},
+		{
+			name:     "Key file is provided, but not used",
+			keyFile:  newTestKeyFile(t, gophtest.KeyFile),
+			required: false,
+			expected: encryption.ErrKeyFileNotUsed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			kdf := newTestKDFParams()
+			kdf.KeyFile = tc.required
+
+			_, err := encryption.NewKey(gophtest.Username, gophtest.Password, tc.keyFile, kdf)
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// RecoveryCodeEntropy is the number of random bytes in a recovery code.
+const RecoveryCodeEntropy = 20
+
+// Number of characters in a group of the printed recovery code.
+const recoveryCodeGroupLength = 4
+
+// HKDF salt of the recovery key.
+const recoveryKeySalt = "gophkeeper recovery key"
+
+var ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCode generates random high entropy recovery code,
+// formatted in dash separated groups to be written down by a user.
+func NewRecoveryCode() (string, error) {
+	entropy := make([]byte, RecoveryCodeEntropy)
+	if _, err := io.ReadFull(rand.Reader, entropy); err != nil {
+		return "", fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	encoded := recoveryCodeEncoding.EncodeToString(entropy)
+	groups := make([]string, 0, len(encoded)/recoveryCodeGroupLength)
+
+	for i := 0; i < len(encoded); i += recoveryCodeGroupLength {
+		groups = append(groups, encoded[i:i+recoveryCodeGroupLength])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// NewRecoveryKey derives recovery key from the recovery code.
+// The code has enough entropy, so no password hashing is involved.
+// Case, spaces and dashes of the code are ignored.
+func NewRecoveryKey(code string) (Key, error) {
+	var key Key
+
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+
+	entropy, err := recoveryCodeEncoding.DecodeString(normalized)
+	if err != nil || len(entropy) != RecoveryCodeEntropy {
+		return key, ErrInvalidRecoveryCode
+	}
+
+	copy(key.sum[:], hkdf.Extract(sha256.New, entropy, []byte(recoveryKeySalt)))
+
+	return key, nil
+}
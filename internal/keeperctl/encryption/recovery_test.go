@@ -0,0 +1,84 @@
+package encryption_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestNewRecoveryCode(t *testing.T) {
+	code, err := encryption.NewRecoveryCode()
+	require.NoError(t, err)
+	require.Len(t, strings.Split(code, "-"), 8)
+
+	other, err := encryption.NewRecoveryCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code, other)
+
+	_, err = encryption.NewRecoveryKey(code)
+	require.NoError(t, err)
+}
+
+func TestNewRecoveryKeyIgnoresFormatting(t *testing.T) {
+	expected, err := encryption.NewRecoveryKey(gophtest.RecoveryCode)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name string
+		code string
+	}{
+		{
+			name: "Lower case code",
+			code: strings.ToLower(gophtest.RecoveryCode),
+		},
+		{
+			name: "Code without dashes",
+			code: strings.ReplaceAll(gophtest.RecoveryCode, "-", ""),
+		},
+		{
+			name: "Code with spaces",
+			code: " " + strings.ReplaceAll(gophtest.RecoveryCode, "-", " ") + "\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sat, err := encryption.NewRecoveryKey(tc.code)
+
+			require.NoError(t, err)
+			require.Equal(t, expected.Hash(), sat.Hash())
+		})
+	}
+}
+
+func TestNewRecoveryKeyWithBadCode(t *testing.T) {
+	tt := []struct {
+		name string
+		code string
+	}{
+		{
+			name: "Empty code",
+			code: "",
+		},
+		{
+			name: "Truncated code",
+			code: gophtest.RecoveryCode[:len(gophtest.RecoveryCode)-5],
+		},
+		{
+			name: "Code with invalid characters",
+			code: strings.ReplaceAll(gophtest.RecoveryCode, "A", "1"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := encryption.NewRecoveryKey(tc.code)
+
+			require.ErrorIs(t, err, encryption.ErrInvalidRecoveryCode)
+		})
+	}
+}
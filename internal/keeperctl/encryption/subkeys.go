@@ -0,0 +1,75 @@
+package encryption
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// HKDF labels of the subkeys.
+const (
+	authKeyInfo  = "gophkeeper auth key"
+	vaultKeyInfo = "gophkeeper vault key"
+)
+
+// Keys are derived from the master key.
+// Auth is the security key sent to the server, Vault encrypts secrets
+// and never leaves the client.
+type Keys struct {
+	Auth  string
+	Vault Key
+}
+
+// Subkeys derives auth and vault keys from the master key
+// according to the key schedule.
+func (k Key) Subkeys(schedule KeySchedule) (Keys, error) {
+	var keys Keys
+
+	switch schedule {
+	case KeyScheduleLegacy:
+		keys.Auth = k.Hash()
+		keys.Vault = k
+
+	case KeyScheduleAuthSubkey:
+		auth, err := k.expand(authKeyInfo)
+		if err != nil {
+			return keys, err
+		}
+
+		keys.Auth = auth.Hash()
+		keys.Vault = k
+
+	case KeyScheduleSubkeys:
+		auth, err := k.expand(authKeyInfo)
+		if err != nil {
+			return keys, err
+		}
+
+		vault, err := k.expand(vaultKeyInfo)
+		if err != nil {
+			return keys, err
+		}
+
+		keys.Auth = auth.Hash()
+		keys.Vault = vault
+
+	default:
+		return keys, fmt.Errorf("%w: key schedule %d", ErrUnsupportedKDF, schedule)
+	}
+
+	return keys, nil
+}
+
+// expand derives new key from the master key with HKDF-SHA256.
+func (k Key) expand(info string) (Key, error) {
+	var key Key
+
+	r := hkdf.New(sha256.New, k.sum[:], nil, []byte(info))
+	if _, err := io.ReadFull(r, key.sum[:]); err != nil {
+		return key, fmt.Errorf("hkdf error: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,59 @@
+package encryption_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestMasterKey(t *testing.T) encryption.Key {
+	t.Helper()
+
+	key, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestLegacySubkeys(t *testing.T) {
+	master := newTestMasterKey(t)
+
+	sat, err := master.Subkeys(encryption.KeyScheduleLegacy)
+
+	require.NoError(t, err)
+	require.Equal(t, master.Hash(), sat.Auth)
+	require.Equal(t, master, sat.Vault)
+}
+
+func TestAuthSubkey(t *testing.T) {
+	master := newTestMasterKey(t)
+
+	sat, err := master.Subkeys(encryption.KeyScheduleAuthSubkey)
+
+	require.NoError(t, err)
+	require.NotEqual(t, master.Hash(), sat.Auth)
+	require.Equal(t, master, sat.Vault)
+}
+
+func TestSubkeys(t *testing.T) {
+	master := newTestMasterKey(t)
+
+	upgraded, err := master.Subkeys(encryption.KeyScheduleAuthSubkey)
+	require.NoError(t, err)
+
+	sat, err := master.Subkeys(encryption.KeyScheduleSubkeys)
+
+	require.NoError(t, err)
+	require.Equal(t, upgraded.Auth, sat.Auth)
+	require.NotEqual(t, master, sat.Vault)
+	require.NotEqual(t, sat.Auth, sat.Vault.Hash())
+}
+
+func TestSubkeysWithUnknownSchedule(t *testing.T) {
+	_, err := newTestMasterKey(t).Subkeys(encryption.KeySchedule(42))
+
+	require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
+}
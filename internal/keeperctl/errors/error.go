@@ -3,9 +3,12 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
@@ -17,6 +20,9 @@ type RequestError struct {
 	code        uint32
 	description string
 	details     []string
+
+	// Delay before the throttled request could be retried, if the service has told so.
+	retryAfter time.Duration
 }
 
 // NewRequestError create RequestError from provided possibly gRPC error.
@@ -29,16 +35,23 @@ func NewRequestError(err error) error {
 		}
 	}
 
-	details := make([]string, 0)
+	var (
+		details    = make([]string, 0)
+		retryAfter time.Duration
+	)
 
 	for _, detail := range st.Details() {
-		if t, ok := detail.(*errdetails.BadRequest); ok {
+		switch t := detail.(type) {
+		case *errdetails.BadRequest:
 			for _, violation := range t.GetFieldViolations() {
 				details = append(
 					details,
 					fmt.Sprintf("%q: %s", violation.GetField(), violation.GetDescription()),
 				)
 			}
+
+		case *errdetails.RetryInfo:
+			retryAfter = t.GetRetryDelay().AsDuration()
 		}
 	}
 
@@ -46,6 +59,7 @@ func NewRequestError(err error) error {
 		code:        uint32(st.Code()),
 		description: st.Message(),
 		details:     details,
+		retryAfter:  retryAfter,
 	}
 }
 
@@ -53,7 +67,14 @@ func NewRequestError(err error) error {
 func (e RequestError) Error() string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("%s (%d)", e.description, e.code))
+	sb.WriteString(e.description)
+
+	if e.retryAfter > 0 {
+		sb.WriteString(", try again in ")
+		sb.WriteString(formatSeconds(e.retryAfter))
+	}
+
+	sb.WriteString(fmt.Sprintf(" (%d)", e.code))
 
 	for _, detail := range e.details {
 		sb.WriteString(fmt.Sprintf("\n\t%s", detail))
@@ -72,3 +93,21 @@ func Unwrap(err error) error {
 
 	return err
 }
+
+// HasCode reports whether the error is RequestError with provided gRPC status code.
+func HasCode(err error, code codes.Code) bool {
+	var rErr RequestError
+
+	return errors.As(err, &rErr) && rErr.code == uint32(code)
+}
+
+// formatSeconds rounds the duration up to whole seconds,
+// so the user doesn't retry too early.
+func formatSeconds(d time.Duration) string {
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds == 1 {
+		return "1 second"
+	}
+
+	return fmt.Sprintf("%d seconds", seconds)
+}
@@ -3,6 +3,7 @@ package errors_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/require"
@@ -10,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 )
@@ -50,6 +52,38 @@ func TestRequestErrorFromGRPCBadRequest(t *testing.T) {
 	snaps.MatchSnapshot(t, sat.Error())
 }
 
+func TestRequestErrorFromGRPCRetryInfo(t *testing.T) {
+	tt := []struct {
+		name     string
+		delay    time.Duration
+		expected string
+	}{
+		{
+			name:     "Retry delay is shown in seconds",
+			delay:    time.Minute,
+			expected: "too many failed attempts, try again in 60 seconds (8)",
+		},
+		{
+			name:     "Retry delay is rounded up",
+			delay:    500 * time.Millisecond,
+			expected: "too many failed attempts, try again in 1 second (8)",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.New(codes.ResourceExhausted, "too many failed attempts")
+			st, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(tc.delay)})
+			require.NoError(t, err)
+
+			sat := errors.NewRequestError(st.Err())
+
+			require.Equal(t, tc.expected, sat.Error())
+			require.True(t, errors.HasCode(sat, codes.ResourceExhausted))
+		})
+	}
+}
+
 func TestUnwrap(t *testing.T) {
 	tt := []struct {
 		name string
@@ -73,3 +107,35 @@ func TestUnwrap(t *testing.T) {
 		})
 	}
 }
+
+func TestHasCode(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "RequestError with the code",
+			err:      errors.NewRequestError(status.Error(codes.Unauthenticated, "bad login or password")),
+			expected: true,
+		},
+		{
+			name:     "RequestError with other code",
+			err:      errors.NewRequestError(status.Error(codes.Internal, "internal error")),
+			expected: false,
+		},
+		{
+			name:     "Other error",
+			err:      grpc.ErrServerStopped,
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fmt.Errorf("ErrorTest - TestHasCode - SomeError: %w", tc.err)
+
+			require.Equal(t, tc.expected, errors.HasCode(err, codes.Unauthenticated))
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package grpcconn
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -10,6 +11,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
 var ErrAppendRootCert = errors.New("failed to append root certificate")
@@ -19,8 +21,14 @@ type Connection struct {
 	conn *grpc.ClientConn
 }
 
+// UserAgent identifies keeperctl in the list of sessions.
+const UserAgent = "keeperctl"
+
 // New create and initializes new gRPC Connection.
-func New(address, caPath string) (*Connection, error) {
+// The client certificate is presented to the service, if both certificate and key paths are set,
+// so the user is authenticated with it instead of access tokens.
+// The device name is sent with every request, so the service could record it on login.
+func New(address, caPath, clientCrtPath, clientKeyPath, deviceName string) (*Connection, error) {
 	rootCA, err := os.ReadFile(caPath)
 	if err != nil {
 		return nil, err
@@ -37,9 +45,20 @@ func New(address, caPath string) (*Connection, error) {
 		RootCAs:            cp,
 	}
 
+	if clientCrtPath != "" && clientKeyPath != "" {
+		crt, err := tls.LoadX509KeyPair(clientCrtPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("grpcconn - New - tls.LoadX509KeyPair: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{crt}
+	}
+
 	conn, err := grpc.NewClient(
 		address,
 		grpc.WithTransportCredentials(credentials.NewTLS(config)),
+		grpc.WithUserAgent(UserAgent),
+		grpc.WithUnaryInterceptor(deviceInterceptor(deviceName)),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("grpcconn - New - grpc.Dial: %w", err)
@@ -48,6 +67,24 @@ func New(address, caPath string) (*Connection, error) {
 	return &Connection{conn}, nil
 }
 
+// deviceInterceptor attaches the device name to outgoing requests.
+func deviceInterceptor(deviceName string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if deviceName != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "device-name", deviceName)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
 // Instance grants access to the underlying gRPC client connection.
 func (c *Connection) Instance() *grpc.ClientConn {
 	return c.conn
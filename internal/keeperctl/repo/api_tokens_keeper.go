@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ APITokens = (*APITokensRepo)(nil)
+
+// APITokensRepo is facade to API tokens stored in Keeper.
+type APITokensRepo struct {
+	client proto.APITokensClient
+}
+
+// NewAPITokensRepo creates and initializes APITokensRepo object.
+func NewAPITokensRepo(client proto.APITokensClient) *APITokensRepo {
+	return &APITokensRepo{client}
+}
+
+// Create issues new API token of the user.
+// Zero expiresAt means that the token never expires.
+func (r *APITokensRepo) Create(
+	ctx context.Context,
+	token, name string,
+	readOnly bool,
+	secretIDs []uuid.UUID,
+	expiresAt time.Time,
+) (string, *proto.APIToken, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.CreateAPITokenRequest{
+		Name:      name,
+		ReadOnly:  readOnly,
+		SecretIds: make([]string, 0, len(secretIDs)),
+	}
+
+	for _, id := range secretIDs {
+		req.SecretIds = append(req.SecretIds, id.String())
+	}
+
+	if !expiresAt.IsZero() {
+		req.ExpiresAt = timestamppb.New(expiresAt)
+	}
+
+	resp, err := r.client.Create(ctx, req)
+	if err != nil {
+		return "", nil, fmt.Errorf("APITokensRepo - Create - r.client.Create: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetToken(), resp.GetApiToken(), nil
+}
+
+// List returns API tokens of the user.
+func (r *APITokensRepo) List(ctx context.Context, token string) ([]*proto.APIToken, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.List(ctx, &proto.ListAPITokensRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("APITokensRepo - List - r.client.List: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetApiTokens(), nil
+}
+
+// Revoke deletes API token of the user.
+func (r *APITokensRepo) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.RevokeAPITokenRequest{Id: id.String()}
+
+	if _, err := r.client.Revoke(ctx, req); err != nil {
+		return fmt.Errorf("APITokensRepo - Revoke - r.client.Revoke: %w", errors.NewRequestError(err))
+	}
+
+	return nil
+}
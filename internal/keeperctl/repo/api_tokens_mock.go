@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ APITokens = (*APITokensRepoMock)(nil)
+
+type APITokensRepoMock struct {
+	mock.Mock
+}
+
+func (m *APITokensRepoMock) Create(
+	ctx context.Context,
+	token, name string,
+	readOnly bool,
+	secretIDs []uuid.UUID,
+	expiresAt time.Time,
+) (string, *proto.APIToken, error) {
+	args := m.Called(ctx, token, name, readOnly, secretIDs, expiresAt)
+
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*proto.APIToken), args.Error(2)
+}
+
+func (m *APITokensRepoMock) List(ctx context.Context, token string) ([]*proto.APIToken, error) {
+	args := m.Called(ctx, token)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*proto.APIToken), args.Error(1)
+}
+
+func (m *APITokensRepoMock) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	args := m.Called(ctx, token, id)
+
+	return args.Error(0)
+}
@@ -0,0 +1,130 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func doCreateAPIToken(t *testing.T, expiresAt time.Time, mockErr error) (string, *proto.APIToken, error) {
+	t.Helper()
+
+	secretID := uuid.New()
+
+	m := &proto.APITokensClientMock{}
+	m.On(
+		"Create",
+		mock.Anything,
+		mock.MatchedBy(func(req *proto.CreateAPITokenRequest) bool {
+			return req.GetName() == gophtest.APITokenName &&
+				req.GetReadOnly() &&
+				len(req.GetSecretIds()) == 1 && req.GetSecretIds()[0] == secretID.String() &&
+				(req.GetExpiresAt() == nil) == expiresAt.IsZero()
+		}),
+		mock.Anything,
+	).
+		Return(
+			&proto.CreateAPITokenResponse{
+				Token:    gophtest.APIToken,
+				ApiToken: &proto.APIToken{Id: uuid.NewString(), Name: gophtest.APITokenName},
+			},
+			mockErr,
+		)
+
+	sat := repo.NewAPITokensRepo(m)
+	token, info, err := sat.Create(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.APITokenName,
+		true,
+		[]uuid.UUID{secretID},
+		expiresAt,
+	)
+
+	m.AssertExpectations(t)
+
+	return token, info, err
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	for _, expiresAt := range []time.Time{{}, time.Now().Add(time.Hour)} {
+		token, info, err := doCreateAPIToken(t, expiresAt, nil)
+
+		require.NoError(t, err)
+		require.Equal(t, gophtest.APIToken, token)
+		require.Equal(t, gophtest.APITokenName, info.GetName())
+	}
+}
+
+func TestCreateAPITokenOnClientFailure(t *testing.T) {
+	_, _, err := doCreateAPIToken(t, time.Time{}, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doListAPITokens(t *testing.T, mockErr error) ([]*proto.APIToken, error) {
+	t.Helper()
+
+	m := &proto.APITokensClientMock{}
+	m.On("List", mock.Anything, &proto.ListAPITokensRequest{}, mock.Anything).
+		Return(
+			&proto.ListAPITokensResponse{
+				ApiTokens: []*proto.APIToken{{Id: uuid.NewString(), Name: gophtest.APITokenName}},
+			},
+			mockErr,
+		)
+
+	sat := repo.NewAPITokensRepo(m)
+	tokens, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	m.AssertExpectations(t)
+
+	return tokens, err
+}
+
+func TestListAPITokens(t *testing.T) {
+	tokens, err := doListAPITokens(t, nil)
+
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, gophtest.APITokenName, tokens[0].GetName())
+}
+
+func TestListAPITokensOnClientFailure(t *testing.T) {
+	_, err := doListAPITokens(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doRevokeAPIToken(t *testing.T, mockErr error) error {
+	t.Helper()
+
+	id := uuid.New()
+
+	m := &proto.APITokensClientMock{}
+	m.On("Revoke", mock.Anything, &proto.RevokeAPITokenRequest{Id: id.String()}, mock.Anything).
+		Return(&proto.RevokeAPITokenResponse{}, mockErr)
+
+	sat := repo.NewAPITokensRepo(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	m.AssertExpectations(t)
+
+	return err
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	require.NoError(t, doRevokeAPIToken(t, nil))
+}
+
+func TestRevokeAPITokenOnClientFailure(t *testing.T) {
+	require.Error(t, doRevokeAPIToken(t, gophtest.ErrUnexpected))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"google.golang.org/grpc/metadata"
+
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
@@ -20,17 +22,170 @@ func NewAuthRepo(client proto.AuthClient) *AuthRepo {
 	return &AuthRepo{client}
 }
 
-// Login authenticates user in the Keeperd service.
-func (r *AuthRepo) Login(ctx context.Context, username, securityKey string) (string, error) {
+// Prelogin requests key derivation parameters of the user
+// and whether the user must log in with the security key to set the verifier.
+func (r *AuthRepo) Prelogin(ctx context.Context, username string) (*proto.KDFParams, bool, error) {
+	req := &proto.PreloginRequest{
+		Username: username,
+	}
+
+	resp, err := r.client.Prelogin(ctx, req)
+	if err != nil {
+		return nil, false, fmt.Errorf("AuthRepo - Prelogin - r.client.Prelogin: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetKdfParams(), resp.GetLegacyLogin(), nil
+}
+
+// Login authenticates user registered before SRP in the Keeperd service.
+// The security key of the user is replaced with the verifier.
+// Returns access token and refresh token, or partial token
+// if the user must pass the second factor.
+func (r *AuthRepo) Login(
+	ctx context.Context,
+	username, securityKey string,
+	verifier *proto.SRPVerifier,
+) (*proto.LoginResponse, error) {
 	req := &proto.LoginRequest{
 		Username:    username,
 		SecurityKey: securityKey,
+		Verifier:    verifier,
 	}
 
 	resp, err := r.client.Login(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("AuthRepo - Login - r.client.Login: %w", errors.NewRequestError(err))
+		return nil, fmt.Errorf("AuthRepo - Login - r.client.Login: %w", errors.NewRequestError(err))
+	}
+
+	return resp, nil
+}
+
+// StartLogin starts SRP handshake of the user.
+func (r *AuthRepo) StartLogin(
+	ctx context.Context,
+	username string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	req := &proto.StartLoginRequest{
+		Username:     username,
+		ClientPublic: clientPublic,
+	}
+
+	resp, err := r.client.StartLogin(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("AuthRepo - StartLogin - r.client.StartLogin: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetChallenge(), nil
+}
+
+// FinishLogin sends proof of the session key to the Keeperd service.
+// Returns the server proof of the session key together with access token and refresh token,
+// or partial token if the user must pass the second factor.
+func (r *AuthRepo) FinishLogin(ctx context.Context, proof *proto.SRPProof) (*proto.FinishLoginResponse, error) {
+	req := &proto.FinishLoginRequest{
+		Proof: proof,
+	}
+
+	resp, err := r.client.FinishLogin(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"AuthRepo - FinishLogin - r.client.FinishLogin: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp, nil
+}
+
+// VerifySecondFactor exchanges the partial token and one-time code
+// for access token and refresh token.
+func (r *AuthRepo) VerifySecondFactor(ctx context.Context, partialToken, code string) (string, string, error) {
+	md := metadata.New(map[string]string{"authorization": partialToken})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.VerifySecondFactorRequest{
+		Code: code,
+	}
+
+	resp, err := r.client.VerifySecondFactor(ctx, req)
+	if err != nil {
+		return "", "", fmt.Errorf(
+			"AuthRepo - VerifySecondFactor - r.client.VerifySecondFactor: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetAccessToken(), resp.GetRefreshToken(), nil
+}
+
+// Refresh exchanges the refresh token for new access token and the next refresh token.
+func (r *AuthRepo) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	req := &proto.RefreshRequest{
+		RefreshToken: refreshToken,
+	}
+
+	resp, err := r.client.Refresh(ctx, req)
+	if err != nil {
+		return "", "", fmt.Errorf("AuthRepo - Refresh - r.client.Refresh: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetAccessToken(), resp.GetRefreshToken(), nil
+}
+
+// Logout revokes the access token and the refresh token of the session, if provided.
+func (r *AuthRepo) Logout(ctx context.Context, token, refreshToken string) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.LogoutRequest{
+		RefreshToken: refreshToken,
+	}
+
+	if _, err := r.client.Logout(ctx, req); err != nil {
+		return fmt.Errorf("AuthRepo - Logout - r.client.Logout: %w", errors.NewRequestError(err))
+	}
+
+	return nil
+}
+
+// Recover authenticates user with the security key derived from the recovery code.
+// Returns the vault key wrapped by the recovery key together with access token and refresh token,
+// or only partial token if the user must pass the second factor, see RecoverVaultKey.
+func (r *AuthRepo) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (*proto.RecoverResponse, error) {
+	req := &proto.RecoverRequest{
+		Username:            username,
+		RecoverySecurityKey: recoverySecurityKey,
+	}
+
+	resp, err := r.client.Recover(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("AuthRepo - Recover - r.client.Recover: %w", errors.NewRequestError(err))
+	}
+
+	return resp, nil
+}
+
+// RecoverVaultKey requests the vault key wrapped by the recovery key
+// after the second factor is passed on recovery.
+func (r *AuthRepo) RecoverVaultKey(ctx context.Context, token, recoverySecurityKey string) ([]byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.RecoverVaultKeyRequest{
+		RecoverySecurityKey: recoverySecurityKey,
+	}
+
+	resp, err := r.client.RecoverVaultKey(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"AuthRepo - RecoverVaultKey - r.client.RecoverVaultKey: %w",
+			errors.NewRequestError(err),
+		)
 	}
 
-	return resp.GetAccessToken(), nil
+	return resp.GetVaultKey(), nil
 }
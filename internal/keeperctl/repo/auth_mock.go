@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
 var _ Auth = (*AuthRepoMock)(nil)
@@ -12,11 +14,97 @@ type AuthRepoMock struct {
 	mock.Mock
 }
 
+func (m *AuthRepoMock) Prelogin(
+	ctx context.Context,
+	username string,
+) (*proto.KDFParams, bool, error) {
+	args := m.Called(ctx, username)
+
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+
+	return args.Get(0).(*proto.KDFParams), args.Bool(1), args.Error(2)
+}
+
 func (m *AuthRepoMock) Login(
 	ctx context.Context,
 	username, securityKey string,
-) (string, error) {
-	args := m.Called(ctx, username, securityKey)
+	verifier *proto.SRPVerifier,
+) (*proto.LoginResponse, error) {
+	args := m.Called(ctx, username, securityKey, verifier)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.LoginResponse), args.Error(1)
+}
+
+func (m *AuthRepoMock) StartLogin(
+	ctx context.Context,
+	username string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	args := m.Called(ctx, username, clientPublic)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.SRPChallenge), args.Error(1)
+}
+
+func (m *AuthRepoMock) FinishLogin(
+	ctx context.Context,
+	proof *proto.SRPProof,
+) (*proto.FinishLoginResponse, error) {
+	args := m.Called(ctx, proof)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.FinishLoginResponse), args.Error(1)
+}
+
+func (m *AuthRepoMock) VerifySecondFactor(ctx context.Context, partialToken, code string) (string, string, error) {
+	args := m.Called(ctx, partialToken, code)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *AuthRepoMock) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	args := m.Called(ctx, refreshToken)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *AuthRepoMock) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (*proto.RecoverResponse, error) {
+	args := m.Called(ctx, username, recoverySecurityKey)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.RecoverResponse), args.Error(1)
+}
+
+func (m *AuthRepoMock) RecoverVaultKey(ctx context.Context, token, recoverySecurityKey string) ([]byte, error) {
+	args := m.Called(ctx, token, recoverySecurityKey)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *AuthRepoMock) Logout(ctx context.Context, token, refreshToken string) error {
+	args := m.Called(ctx, token, refreshToken)
 
-	return args.String(0), args.Error(1)
+	return args.Error(0)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -12,16 +13,66 @@ import (
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+func newTestVerifier() *proto.SRPVerifier {
+	return &proto.SRPVerifier{
+		Salt:     []byte(gophtest.SRPSalt),
+		Verifier: []byte(gophtest.SRPVerifier),
+	}
+}
+
 func newLoginRequest() *proto.LoginRequest {
 	return &proto.LoginRequest{
 		Username:    gophtest.Username,
 		SecurityKey: gophtest.SecurityKey,
+		Verifier:    newTestVerifier(),
+	}
+}
+
+func TestPrelogin(t *testing.T) {
+	resp := &proto.PreloginResponse{
+		KdfParams:   newTestKDFParams(),
+		LegacyLogin: true,
 	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Prelogin",
+		mock.Anything,
+		&proto.PreloginRequest{Username: gophtest.Username},
+		mock.Anything,
+	).
+		Return(resp, nil)
+
+	sat := repo.NewAuthRepo(m)
+	kdf, legacy, err := sat.Prelogin(context.Background(), gophtest.Username)
+
+	require.NoError(t, err)
+	require.Equal(t, newTestKDFParams(), kdf)
+	require.True(t, legacy)
+	m.AssertExpectations(t)
+}
+
+func TestPreloginOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Prelogin",
+		mock.Anything,
+		&proto.PreloginRequest{Username: gophtest.Username},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, _, err := sat.Prelogin(context.Background(), gophtest.Username)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
 }
 
 func TestLogin(t *testing.T) {
 	resp := &proto.LoginResponse{
-		AccessToken: gophtest.AccessToken,
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
 	}
 
 	m := &proto.AuthClientMock{}
@@ -34,10 +85,15 @@ func TestLogin(t *testing.T) {
 		Return(resp, nil)
 
 	sat := repo.NewAuthRepo(m)
-	token, err := sat.Login(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	rv, err := sat.Login(
+		context.Background(),
+		gophtest.Username,
+		gophtest.SecurityKey,
+		newTestVerifier(),
+	)
 
 	require.NoError(t, err)
-	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, resp, rv)
 	m.AssertExpectations(t)
 }
 
@@ -52,7 +108,318 @@ func TestLoginOnClientFailure(t *testing.T) {
 		Return(nil, gophtest.ErrUnexpected)
 
 	sat := repo.NewAuthRepo(m)
-	_, err := sat.Login(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	_, err := sat.Login(
+		context.Background(),
+		gophtest.Username,
+		gophtest.SecurityKey,
+		newTestVerifier(),
+	)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func newStartLoginRequest() *proto.StartLoginRequest {
+	return &proto.StartLoginRequest{
+		Username:     gophtest.Username,
+		ClientPublic: []byte(gophtest.ClientPublic),
+	}
+}
+
+func TestStartLogin(t *testing.T) {
+	challenge := &proto.SRPChallenge{
+		HandshakeId:  uuid.NewString(),
+		Salt:         []byte(gophtest.SRPSalt),
+		ServerPublic: []byte(gophtest.ServerPublic),
+	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"StartLogin",
+		mock.Anything,
+		newStartLoginRequest(),
+		mock.Anything,
+	).
+		Return(&proto.StartLoginResponse{Challenge: challenge}, nil)
+
+	sat := repo.NewAuthRepo(m)
+	rv, err := sat.StartLogin(context.Background(), gophtest.Username, []byte(gophtest.ClientPublic))
+
+	require.NoError(t, err)
+	require.Equal(t, challenge, rv)
+	m.AssertExpectations(t)
+}
+
+func TestStartLoginOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"StartLogin",
+		mock.Anything,
+		newStartLoginRequest(),
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, err := sat.StartLogin(context.Background(), gophtest.Username, []byte(gophtest.ClientPublic))
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func newTestProof() *proto.SRPProof {
+	return &proto.SRPProof{
+		HandshakeId: uuid.NewString(),
+		ClientProof: []byte(gophtest.ClientProof),
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	proof := newTestProof()
+	resp := &proto.FinishLoginResponse{
+		AccessToken:  gophtest.AccessToken,
+		ServerProof:  []byte(gophtest.ServerProof),
+		RefreshToken: gophtest.RefreshToken,
+	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"FinishLogin",
+		mock.Anything,
+		&proto.FinishLoginRequest{Proof: proof},
+		mock.Anything,
+	).
+		Return(resp, nil)
+
+	sat := repo.NewAuthRepo(m)
+	rv, err := sat.FinishLogin(context.Background(), proof)
+
+	require.NoError(t, err)
+	require.Equal(t, resp, rv)
+	m.AssertExpectations(t)
+}
+
+func TestFinishLoginOnClientFailure(t *testing.T) {
+	proof := newTestProof()
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"FinishLogin",
+		mock.Anything,
+		&proto.FinishLoginRequest{Proof: proof},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, err := sat.FinishLogin(context.Background(), proof)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	resp := &proto.VerifySecondFactorResponse{
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
+	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"VerifySecondFactor",
+		mock.Anything,
+		&proto.VerifySecondFactorRequest{Code: gophtest.OTPCode},
+		mock.Anything,
+	).
+		Return(resp, nil)
+
+	sat := repo.NewAuthRepo(m)
+	token, refreshToken, err := sat.VerifySecondFactor(context.Background(), gophtest.PartialToken, gophtest.OTPCode)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, gophtest.RefreshToken, refreshToken)
+	m.AssertExpectations(t)
+}
+
+func TestVerifySecondFactorOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"VerifySecondFactor",
+		mock.Anything,
+		&proto.VerifySecondFactorRequest{Code: gophtest.OTPCode},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, _, err := sat.VerifySecondFactor(context.Background(), gophtest.PartialToken, gophtest.OTPCode)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestRefresh(t *testing.T) {
+	resp := &proto.RefreshResponse{
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: "next",
+	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Refresh",
+		mock.Anything,
+		&proto.RefreshRequest{RefreshToken: gophtest.RefreshToken},
+		mock.Anything,
+	).
+		Return(resp, nil)
+
+	sat := repo.NewAuthRepo(m)
+	token, refreshToken, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, "next", refreshToken)
+	m.AssertExpectations(t)
+}
+
+func TestRefreshOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Refresh",
+		mock.Anything,
+		&proto.RefreshRequest{RefreshToken: gophtest.RefreshToken},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, _, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func newRecoverRequest() *proto.RecoverRequest {
+	return &proto.RecoverRequest{
+		Username:            gophtest.Username,
+		RecoverySecurityKey: gophtest.RecoverySecurityKey,
+	}
+}
+
+func TestRecover(t *testing.T) {
+	resp := &proto.RecoverResponse{
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
+		VaultKey:     []byte(gophtest.WrappedVaultKey),
+	}
+
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Recover",
+		mock.Anything,
+		newRecoverRequest(),
+		mock.Anything,
+	).
+		Return(resp, nil)
+
+	sat := repo.NewAuthRepo(m)
+	rv, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, resp, rv)
+	m.AssertExpectations(t)
+}
+
+func TestRecoverOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Recover",
+		mock.Anything,
+		newRecoverRequest(),
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestRecoverVaultKey(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"RecoverVaultKey",
+		mock.Anything,
+		&proto.RecoverVaultKeyRequest{RecoverySecurityKey: gophtest.RecoverySecurityKey},
+		mock.Anything,
+	).
+		Return(&proto.RecoverVaultKeyResponse{VaultKey: []byte(gophtest.WrappedVaultKey)}, nil)
+
+	sat := repo.NewAuthRepo(m)
+	vaultKey, err := sat.RecoverVaultKey(context.Background(), gophtest.AccessToken, gophtest.RecoverySecurityKey)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), vaultKey)
+	m.AssertExpectations(t)
+}
+
+func TestRecoverVaultKeyOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"RecoverVaultKey",
+		mock.Anything,
+		&proto.RecoverVaultKeyRequest{RecoverySecurityKey: gophtest.RecoverySecurityKey},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	_, err := sat.RecoverVaultKey(context.Background(), gophtest.AccessToken, gophtest.RecoverySecurityKey)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestLogout(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Logout",
+		mock.Anything,
+		&proto.LogoutRequest{RefreshToken: gophtest.RefreshToken},
+		mock.Anything,
+	).
+		Return(&proto.LogoutResponse{}, nil)
+
+	sat := repo.NewAuthRepo(m)
+	err := sat.Logout(context.Background(), gophtest.AccessToken, gophtest.RefreshToken)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestLogoutOnClientFailure(t *testing.T) {
+	m := &proto.AuthClientMock{}
+	m.On(
+		"Logout",
+		mock.Anything,
+		&proto.LogoutRequest{RefreshToken: gophtest.RefreshToken},
+		mock.Anything,
+	).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewAuthRepo(m)
+	err := sat.Logout(context.Background(), gophtest.AccessToken, gophtest.RefreshToken)
 
 	require.Error(t, err)
 	m.AssertExpectations(t)
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,42 +11,141 @@ import (
 )
 
 type Auth interface {
-	Login(ctx context.Context, username, securityKey string) (string, error)
+	Prelogin(ctx context.Context, username string) (*proto.KDFParams, bool, error)
+	Login(ctx context.Context, username, securityKey string, verifier *proto.SRPVerifier) (*proto.LoginResponse, error)
+	StartLogin(ctx context.Context, username string, clientPublic []byte) (*proto.SRPChallenge, error)
+	FinishLogin(ctx context.Context, proof *proto.SRPProof) (*proto.FinishLoginResponse, error)
+	VerifySecondFactor(ctx context.Context, partialToken, code string) (string, string, error)
+	Refresh(ctx context.Context, refreshToken string) (string, string, error)
+	Logout(ctx context.Context, token, refreshToken string) error
+	Recover(ctx context.Context, username, recoverySecurityKey string) (*proto.RecoverResponse, error)
+	RecoverVaultKey(ctx context.Context, token, recoverySecurityKey string) ([]byte, error)
 }
 
 type Secrets interface {
 	Push(
 		ctx context.Context,
-		token, name string,
+		token string,
+		id uuid.UUID,
+		name, nameIndex []byte,
 		kind proto.DataKind,
-		description, payload []byte,
-	) (uuid.UUID, error)
+		dataKey, description, payload []byte,
+	) error
+
+	UploadBlob(
+		ctx context.Context,
+		token string,
+		id uuid.UUID,
+		name, nameIndex []byte,
+		dataKey, description []byte,
+		chunks func() ([]byte, error),
+	) error
 
-	List(ctx context.Context, token string) ([]*proto.Secret, error)
-	Get(ctx context.Context, token string, id uuid.UUID) (*proto.Secret, []byte, error)
+	List(
+		ctx context.Context,
+		token string,
+		kinds []proto.DataKind,
+		limit int,
+	) ([]*proto.Secret, int64, error)
+	Get(ctx context.Context, token string, id uuid.UUID, trashed bool) (*proto.Secret, []byte, error)
+	DownloadBlob(ctx context.Context, token string, id uuid.UUID) (*proto.Secret, func() ([]byte, error), error)
 
 	Update(
 		ctx context.Context,
 		token string,
 		id uuid.UUID,
-		name string,
-		description []byte,
+		version int32,
+		name, nameIndex []byte,
+		dataKey, description []byte,
 		noDescription bool,
 		data []byte,
 	) error
 
+	ListVersions(ctx context.Context, token string, id uuid.UUID, trashed bool) ([]*proto.Secret, error)
+	GetVersion(
+		ctx context.Context,
+		token string,
+		id uuid.UUID,
+		version int32,
+		trashed bool,
+	) (*proto.Secret, []byte, error)
+	Restore(ctx context.Context, token string, id uuid.UUID, version int32) (int32, error)
 	Delete(ctx context.Context, token string, id uuid.UUID) error
+	ListTrash(ctx context.Context, token string) ([]*proto.Secret, error)
+	Purge(ctx context.Context, token string, id uuid.UUID) error
+}
+
+type APITokens interface {
+	Create(
+		ctx context.Context,
+		token, name string,
+		readOnly bool,
+		secretIDs []uuid.UUID,
+		expiresAt time.Time,
+	) (string, *proto.APIToken, error)
+
+	List(ctx context.Context, token string) ([]*proto.APIToken, error)
+	Revoke(ctx context.Context, token string, id uuid.UUID) error
+}
+
+type Sessions interface {
+	List(ctx context.Context, token string) ([]*proto.Session, error)
+	Revoke(ctx context.Context, token string, id uuid.UUID) error
 }
 
 type Users interface {
-	Register(ctx context.Context, username, securityKey string) (string, error)
+	Register(
+		ctx context.Context,
+		username string,
+		verifier *proto.SRPVerifier,
+		kdf *proto.KDFParams,
+		recovery *proto.RecoveryKit,
+	) (string, string, error)
+
+	StartChangePassword(ctx context.Context, token string, clientPublic []byte) (*proto.SRPChallenge, error)
+
+	ChangePassword(
+		ctx context.Context,
+		token string,
+		proof *proto.SRPProof,
+		recoverySecurityKey string,
+		verifier *proto.SRPVerifier,
+		kdf *proto.KDFParams,
+		vaultVersion int64,
+		secrets []*proto.ReencryptedSecret,
+		recovery *proto.RecoveryKit,
+	) ([]byte, error)
+
+	StartDelete(ctx context.Context, token string, clientPublic []byte) (*proto.SRPChallenge, error)
+	Delete(ctx context.Context, token string, proof *proto.SRPProof) ([]byte, error)
+
+	StartRename(ctx context.Context, token string, clientPublic []byte) (*proto.SRPChallenge, error)
+
+	Rename(
+		ctx context.Context,
+		token string,
+		username string,
+		proof *proto.SRPProof,
+		verifier *proto.SRPVerifier,
+		kdf *proto.KDFParams,
+		vaultVersion int64,
+		secrets []*proto.ReencryptedSecret,
+		recovery *proto.RecoveryKit,
+	) ([]byte, error)
+
+	GetRecoveryKey(ctx context.Context, token string) ([]byte, error)
+	SetupTOTP(ctx context.Context, token string) (string, error)
+	EnableTOTP(ctx context.Context, token, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, token, code string) error
 }
 
 // Repositories is a collection of data repositories.
 type Repositories struct {
-	Auth    Auth
-	Secrets Secrets
-	Users   Users
+	APITokens APITokens
+	Auth      Auth
+	Secrets   Secrets
+	Sessions  Sessions
+	Users     Users
 }
 
 // New creates and initializes collection of data repositories.
@@ -53,8 +153,10 @@ func New(conn *grpcconn.Connection) *Repositories {
 	c := conn.Instance()
 
 	return &Repositories{
-		Auth:    NewAuthRepo(proto.NewAuthClient(c)),
-		Secrets: NewSecretsRepo(proto.NewSecretsClient(c)),
-		Users:   NewUsersRepo(proto.NewUsersClient(c)),
+		APITokens: NewAPITokensRepo(proto.NewAPITokensClient(c)),
+		Auth:      NewAuthRepo(proto.NewAuthClient(c)),
+		Secrets:   NewSecretsRepo(proto.NewSecretsClient(c)),
+		Sessions:  NewSessionsRepo(proto.NewSessionsClient(c)),
+		Users:     NewUsersRepo(proto.NewUsersClient(c)),
 	}
 }
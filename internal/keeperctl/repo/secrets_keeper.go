@@ -2,7 +2,9 @@ package repo
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc/metadata"
@@ -12,8 +14,13 @@ import (
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+// ListPageSize is number of secrets requested from the server in one page.
+const ListPageSize = 100
+
 var _ Secrets = (*SecretsRepo)(nil)
 
+var ErrUnexpectedMessage = stderrors.New("unexpected message in the stream")
+
 // SecretsRepo is facade to secrets stored in Keeper.
 type SecretsRepo struct {
 	client proto.SecretsClient
@@ -27,63 +34,175 @@ func NewSecretsRepo(client proto.SecretsClient) *SecretsRepo {
 // Push send new secret data to the server.
 func (r *SecretsRepo) Push(
 	ctx context.Context,
-	token, name string,
+	token string,
+	id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	description, payload []byte,
-) (uuid.UUID, error) {
-	var id uuid.UUID
-
+	dataKey, description, payload []byte,
+) error {
 	md := metadata.New(map[string]string{"authorization": token})
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
 	req := &proto.CreateSecretRequest{
-		Name:     name,
-		Metadata: description,
-		Kind:     kind,
-		Data:     payload,
+		Id:        id.String(),
+		Name:      name,
+		NameIndex: nameIndex,
+		Metadata:  description,
+		Kind:      kind,
+		Data:      payload,
+		DataKey:   dataKey,
 	}
 
-	resp, err := r.client.Create(ctx, req)
-	if err != nil {
-		return id, fmt.Errorf("SecretsRepo - Push - r.client.Create: %w", errors.NewRequestError(err))
+	if _, err := r.client.Create(ctx, req); err != nil {
+		return fmt.Errorf("SecretsRepo - Push - r.client.Create: %w", errors.NewRequestError(err))
 	}
 
-	id, err = uuid.Parse(resp.GetId())
+	return nil
+}
+
+// UploadBlob sends new binary secret to the server chunk by chunk.
+// Chunks are read from the source until it returns io.EOF,
+// the upload is aborted if reading of a chunk fails, so nothing is stored.
+func (r *SecretsRepo) UploadBlob(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, description []byte,
+	chunks func() ([]byte, error),
+) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := r.client.UploadBlob(ctx)
 	if err != nil {
-		return id, fmt.Errorf("SecretsRepo - Push - uuid.Parse: %w", err)
+		return fmt.Errorf("SecretsRepo - UploadBlob - r.client.UploadBlob: %w", errors.NewRequestError(err))
 	}
 
-	return id, nil
+	header := &proto.UploadBlobRequest{
+		Part: &proto.UploadBlobRequest_Header{
+			Header: &proto.BlobHeader{
+				Id:        id.String(),
+				Name:      name,
+				NameIndex: nameIndex,
+				Metadata:  description,
+				DataKey:   dataKey,
+			},
+		},
+	}
+
+	if err := stream.Send(header); err != nil {
+		return r.closeUpload(stream, err)
+	}
+
+	for {
+		chunk, err := chunks()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("SecretsRepo - UploadBlob - chunks: %w", err)
+		}
+
+		req := &proto.UploadBlobRequest{
+			Part: &proto.UploadBlobRequest_Chunk{Chunk: chunk},
+		}
+
+		if err := stream.Send(req); err != nil {
+			return r.closeUpload(stream, err)
+		}
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fmt.Errorf("SecretsRepo - UploadBlob - stream.CloseAndRecv: %w", errors.NewRequestError(err))
+	}
+
+	return nil
 }
 
-// List returns list of user's secrets without data.
+// closeUpload retrieves the reason why the server has aborted the upload.
+// Send returns io.EOF in this case and the actual status is returned by CloseAndRecv.
+func (r *SecretsRepo) closeUpload(
+	stream proto.Secrets_UploadBlobClient,
+	sendErr error,
+) error {
+	if !stderrors.Is(sendErr, io.EOF) {
+		return fmt.Errorf("SecretsRepo - UploadBlob - stream.Send: %w", errors.NewRequestError(sendErr))
+	}
+
+	_, err := stream.CloseAndRecv()
+	if err == nil {
+		err = sendErr
+	}
+
+	return fmt.Errorf("SecretsRepo - UploadBlob - stream.CloseAndRecv: %w", errors.NewRequestError(err))
+}
+
+// List returns list of user's secrets without data and version of the vault.
+// Only secrets of the given kinds are returned if any kinds are specified,
+// at most limit secrets are returned if limit is positive.
+// Pages of the list are requested one by one until the list is exhausted.
 func (r *SecretsRepo) List(
 	ctx context.Context,
 	token string,
-) ([]*proto.Secret, error) {
+	kinds []proto.DataKind,
+	limit int,
+) ([]*proto.Secret, int64, error) {
 	md := metadata.New(map[string]string{"authorization": token})
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
-	req := &proto.ListSecretsRequest{}
+	req := &proto.ListSecretsRequest{PageSize: ListPageSize, Kinds: kinds}
 
-	resp, err := r.client.List(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("SecretsRepo - List - r.client.List: %w", errors.NewRequestError(err))
+	var (
+		secrets []*proto.Secret
+		version int64
+	)
+
+	for {
+		if limit > 0 && limit-len(secrets) < ListPageSize {
+			req.PageSize = int32(limit - len(secrets))
+		}
+
+		resp, err := r.client.List(ctx, req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("SecretsRepo - List - r.client.List: %w", errors.NewRequestError(err))
+		}
+
+		if req.GetPageToken() == "" {
+			version = resp.GetVaultVersion()
+		}
+
+		secrets = append(secrets, resp.GetSecrets()...)
+
+		if resp.GetNextPageToken() == "" || (limit > 0 && len(secrets) >= limit) {
+			break
+		}
+
+		req.PageToken = resp.GetNextPageToken()
+	}
+
+	if secrets == nil {
+		secrets = []*proto.Secret{}
 	}
 
-	return resp.Secrets, nil
+	return secrets, version, nil
 }
 
-// Get downloads full user's secret.
+// Get downloads full user's secret, either from the vault or from the trash.
 func (r *SecretsRepo) Get(
 	ctx context.Context,
 	token string,
 	id uuid.UUID,
+	trashed bool,
 ) (*proto.Secret, []byte, error) {
 	md := metadata.New(map[string]string{"authorization": token})
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
-	req := &proto.GetSecretRequest{Id: id.String()}
+	req := &proto.GetSecretRequest{Id: id.String(), Trashed: trashed}
 
 	resp, err := r.client.Get(ctx, req)
 	if err != nil {
@@ -93,32 +212,88 @@ func (r *SecretsRepo) Get(
 	return resp.GetSecret(), resp.GetData(), nil
 }
 
+// DownloadBlob starts download of binary secret stored as a blob.
+// Returns the secret info and a function reading the chunks one by one,
+// the function returns io.EOF after the last chunk.
+// The download is aborted once the context is canceled.
+func (r *SecretsRepo) DownloadBlob(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) (*proto.Secret, func() ([]byte, error), error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.DownloadBlobRequest{Id: id.String()}
+
+	stream, err := r.client.DownloadBlob(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"SecretsRepo - DownloadBlob - r.client.DownloadBlob: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsRepo - DownloadBlob - stream.Recv: %w", errors.NewRequestError(err))
+	}
+
+	secret := resp.GetSecret()
+	if secret == nil {
+		return nil, nil, fmt.Errorf("SecretsRepo - DownloadBlob - resp.GetSecret: %w", ErrUnexpectedMessage)
+	}
+
+	chunks := func() ([]byte, error) {
+		resp, err := stream.Recv()
+		if stderrors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("SecretsRepo - DownloadBlob - stream.Recv: %w", errors.NewRequestError(err))
+		}
+
+		if resp.GetSecret() != nil {
+			return nil, fmt.Errorf("SecretsRepo - DownloadBlob - resp.GetChunk: %w", ErrUnexpectedMessage)
+		}
+
+		return resp.GetChunk(), nil
+	}
+
+	return secret, chunks, nil
+}
+
 // Update changes parameters of stored secret.
+// Non-zero version is the version the changes are based on,
+// keeper aborts the update if the secret was changed since.
 func (r *SecretsRepo) Update(
 	ctx context.Context,
 	token string,
 	id uuid.UUID,
-	name string,
-	description []byte,
+	version int32,
+	name, nameIndex []byte,
+	dataKey, description []byte,
 	noDescription bool,
 	data []byte,
 ) error {
 	md := metadata.New(map[string]string{"authorization": token})
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
-	req := &proto.UpdateSecretRequest{Id: id.String()}
+	req := &proto.UpdateSecretRequest{Id: id.String(), Version: version}
 
 	mask, err := fieldmaskpb.New(req)
 	if err != nil {
 		return fmt.Errorf("SecretsRepo - Update - fieldmaskpb.New: %w", err)
 	}
 
-	if name != "" {
+	if len(name) != 0 {
 		if err := mask.Append(req, "name"); err != nil {
 			return fmt.Errorf("SecretsRepo - Update - mask.Append: %w", err)
 		}
 
 		req.Name = name
+		req.NameIndex = nameIndex
 	}
 
 	if len(description) != 0 || noDescription {
@@ -137,6 +312,14 @@ func (r *SecretsRepo) Update(
 		req.Data = data
 	}
 
+	if len(dataKey) != 0 {
+		if err := mask.Append(req, "data_key"); err != nil {
+			return fmt.Errorf("SecretsRepo - Update - mask.Append: %w", err)
+		}
+
+		req.DataKey = dataKey
+	}
+
 	req.UpdateMask = mask
 
 	if _, err := r.client.Update(ctx, req); err != nil {
@@ -146,7 +329,72 @@ func (r *SecretsRepo) Update(
 	return nil
 }
 
-// Delete removes user's secret.
+// ListVersions returns versions of the secret without data, the latest go first.
+// The secret is looked up in the trash if trashed is set.
+func (r *SecretsRepo) ListVersions(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	trashed bool,
+) ([]*proto.Secret, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.ListSecretVersionsRequest{Id: id.String(), Trashed: trashed}
+
+	resp, err := r.client.ListVersions(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsRepo - ListVersions - r.client.ListVersions: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetVersions(), nil
+}
+
+// GetVersion returns particular version of the secret with data.
+// The secret is looked up in the trash if trashed is set.
+func (r *SecretsRepo) GetVersion(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*proto.Secret, []byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.GetSecretVersionRequest{Id: id.String(), Version: version, Trashed: trashed}
+
+	resp, err := r.client.GetVersion(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsRepo - GetVersion - r.client.GetVersion: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetSecret(), resp.GetData(), nil
+}
+
+// Restore makes content of the version current again,
+// zero version moves the secret back from trash.
+// Returns the version of the secret with the restored content.
+func (r *SecretsRepo) Restore(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+) (int32, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.RestoreSecretRequest{Id: id.String(), Version: version}
+
+	resp, err := r.client.Restore(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("SecretsRepo - Restore - r.client.Restore: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetVersion(), nil
+}
+
+// Delete moves user's secret to trash.
 func (r *SecretsRepo) Delete(
 	ctx context.Context,
 	token string,
@@ -163,3 +411,34 @@ func (r *SecretsRepo) Delete(
 
 	return nil
 }
+
+// ListTrash returns secrets moved to trash by Delete without data.
+func (r *SecretsRepo) ListTrash(ctx context.Context, token string) ([]*proto.Secret, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.ListTrash(ctx, &proto.ListTrashRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("SecretsRepo - ListTrash - r.client.ListTrash: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetSecrets(), nil
+}
+
+// Purge removes user's secret permanently, either trashed or not.
+func (r *SecretsRepo) Purge(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.PurgeSecretRequest{Id: id.String()}
+
+	if _, err := r.client.Purge(ctx, req); err != nil {
+		return fmt.Errorf("SecretsRepo - Purge - r.client.Purge: %w", errors.NewRequestError(err))
+	}
+
+	return nil
+}
@@ -17,48 +17,123 @@ type SecretsRepoMock struct {
 
 func (m *SecretsRepoMock) Push(
 	ctx context.Context,
-	token, name string,
+	token string,
+	id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	description, payload []byte,
-) (uuid.UUID, error) {
-	args := m.Called(ctx, token, name, kind, description, payload)
+	dataKey, description, payload []byte,
+) error {
+	args := m.Called(ctx, token, id, name, nameIndex, kind, dataKey, description, payload)
+
+	return args.Error(0)
+}
+
+func (m *SecretsRepoMock) UploadBlob(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, description []byte,
+	chunks func() ([]byte, error),
+) error {
+	args := m.Called(ctx, token, id, name, nameIndex, dataKey, description, chunks)
 
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	return args.Error(0)
 }
 
 func (m *SecretsRepoMock) List(
 	ctx context.Context,
 	token string,
-) ([]*proto.Secret, error) {
-	args := m.Called(ctx, token)
+	kinds []proto.DataKind,
+	limit int,
+) ([]*proto.Secret, int64, error) {
+	args := m.Called(ctx, token, kinds, limit)
 
-	return args.Get(0).([]*proto.Secret), args.Error(1)
+	return args.Get(0).([]*proto.Secret), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *SecretsRepoMock) Get(
 	ctx context.Context,
 	token string,
 	id uuid.UUID,
+	trashed bool,
 ) (*proto.Secret, []byte, error) {
-	args := m.Called(ctx, token, id)
+	args := m.Called(ctx, token, id, trashed)
 
 	return args.Get(0).(*proto.Secret), args.Get(1).([]byte), args.Error(2)
 }
 
+func (m *SecretsRepoMock) DownloadBlob(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) (*proto.Secret, func() ([]byte, error), error) {
+	args := m.Called(ctx, token, id)
+
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+
+	return args.Get(0).(*proto.Secret), args.Get(1).(func() ([]byte, error)), args.Error(2)
+}
+
 func (m *SecretsRepoMock) Update(
 	ctx context.Context,
 	token string,
 	id uuid.UUID,
-	name string,
-	description []byte,
+	version int32,
+	name, nameIndex []byte,
+	dataKey, description []byte,
 	noDescription bool,
 	data []byte,
 ) error {
-	args := m.Called(ctx, token, id, name, description, noDescription, data)
+	args := m.Called(ctx, token, id, version, name, nameIndex, dataKey, description, noDescription, data)
 
 	return args.Error(0)
 }
 
+func (m *SecretsRepoMock) ListVersions(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	trashed bool,
+) ([]*proto.Secret, error) {
+	args := m.Called(ctx, token, id, trashed)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*proto.Secret), args.Error(1)
+}
+
+func (m *SecretsRepoMock) GetVersion(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*proto.Secret, []byte, error) {
+	args := m.Called(ctx, token, id, version, trashed)
+
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+
+	return args.Get(0).(*proto.Secret), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *SecretsRepoMock) Restore(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+) (int32, error) {
+	args := m.Called(ctx, token, id, version)
+
+	return args.Get(0).(int32), args.Error(1)
+}
+
 func (m *SecretsRepoMock) Delete(
 	ctx context.Context,
 	token string,
@@ -68,3 +143,23 @@ func (m *SecretsRepoMock) Delete(
 
 	return args.Error(0)
 }
+
+func (m *SecretsRepoMock) ListTrash(ctx context.Context, token string) ([]*proto.Secret, error) {
+	args := m.Called(ctx, token)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*proto.Secret), args.Error(1)
+}
+
+func (m *SecretsRepoMock) Purge(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) error {
+	args := m.Called(ctx, token, id)
+
+	return args.Error(0)
+}
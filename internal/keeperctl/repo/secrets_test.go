@@ -2,14 +2,20 @@ package repo_test
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
 	"testing"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
 	"github.com/derpartizanen/gophkeeper/proto"
@@ -17,16 +23,20 @@ import (
 
 func doCreateSecret(
 	t *testing.T,
+	id uuid.UUID,
 	mockRV *proto.CreateSecretResponse,
 	mockErr error,
-) (uuid.UUID, error) {
+) error {
 	t.Helper()
 
 	req := &proto.CreateSecretRequest{
-		Name:     gophtest.SecretName,
-		Metadata: []byte(gophtest.Metadata),
-		Kind:     proto.DataKind_TEXT,
-		Data:     []byte(gophtest.TextData),
+		Id:        id.String(),
+		Name:      []byte(gophtest.SecretName),
+		NameIndex: []byte(gophtest.NameIndex),
+		Metadata:  []byte(gophtest.Metadata),
+		Kind:      proto.DataKind_TEXT,
+		Data:      []byte(gophtest.TextData),
+		DataKey:   []byte(gophtest.DataKey),
 	}
 
 	m := &proto.SecretsClientMock{}
@@ -39,28 +49,31 @@ func doCreateSecret(
 		Return(mockRV, mockErr)
 
 	sat := repo.NewSecretsRepo(m)
-	rv, err := sat.Push(
+	err := sat.Push(
 		context.Background(),
 		gophtest.AccessToken,
-		gophtest.SecretName,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
 		proto.DataKind_TEXT,
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	)
 
 	m.AssertExpectations(t)
 
-	return rv, err
+	return err
 }
 
 func doListSecrets(
 	t *testing.T,
 	mockRV *proto.ListSecretsResponse,
 	mockErr error,
-) ([]*proto.Secret, error) {
+) ([]*proto.Secret, int64, error) {
 	t.Helper()
 
-	req := &proto.ListSecretsRequest{}
+	req := &proto.ListSecretsRequest{PageSize: repo.ListPageSize}
 
 	m := &proto.SecretsClientMock{}
 	m.On(
@@ -72,11 +85,11 @@ func doListSecrets(
 		Return(mockRV, mockErr)
 
 	sat := repo.NewSecretsRepo(m)
-	rv, err := sat.List(context.Background(), gophtest.AccessToken)
+	rv, version, err := sat.List(context.Background(), gophtest.AccessToken, nil, 0)
 
 	m.AssertExpectations(t)
 
-	return rv, err
+	return rv, version, err
 }
 
 func doGetSecret(
@@ -99,7 +112,7 @@ func doGetSecret(
 		Return(mockRV, mockErr)
 
 	sat := repo.NewSecretsRepo(m)
-	secret, data, err := sat.Get(context.Background(), gophtest.AccessToken, id)
+	secret, data, err := sat.Get(context.Background(), gophtest.AccessToken, id, false)
 
 	m.AssertExpectations(t)
 
@@ -108,8 +121,8 @@ func doGetSecret(
 
 func doUpdateSecret(
 	t *testing.T,
-	name string,
-	description []byte,
+	name, nameIndex []byte,
+	dataKey, description []byte,
 	noDescription bool,
 	data []byte,
 	changed []string,
@@ -119,10 +132,13 @@ func doUpdateSecret(
 
 	id := uuid.New()
 	req := &proto.UpdateSecretRequest{
-		Id:       id.String(),
-		Name:     name,
-		Metadata: description,
-		Data:     data,
+		Id:        id.String(),
+		Version:   2,
+		Name:      name,
+		NameIndex: nameIndex,
+		Metadata:  description,
+		Data:      data,
+		DataKey:   dataKey,
 	}
 
 	mask, err := fieldmaskpb.New(req, changed...)
@@ -144,7 +160,10 @@ func doUpdateSecret(
 		context.Background(),
 		gophtest.AccessToken,
 		id,
+		2,
 		name,
+		nameIndex,
+		dataKey,
 		description,
 		noDescription,
 		data,
@@ -179,19 +198,18 @@ func doDeleteSecret(t *testing.T, mockErr error) error {
 }
 
 func TestCreateSecret(t *testing.T) {
-	expected := uuid.New()
+	id := uuid.New()
 	resp := &proto.CreateSecretResponse{
-		Id: expected.String(),
+		Id: id.String(),
 	}
 
-	id, err := doCreateSecret(t, resp, nil)
+	err := doCreateSecret(t, id, resp, nil)
 
 	require.NoError(t, err)
-	require.Equal(t, expected, id)
 }
 
 func TestCreateSecretOnClientFailure(t *testing.T) {
-	_, err := doCreateSecret(t, nil, gophtest.ErrUnexpected)
+	err := doCreateSecret(t, uuid.New(), nil, gophtest.ErrUnexpected)
 
 	require.Error(t, err)
 }
@@ -206,7 +224,7 @@ func TestListSecrets(t *testing.T) {
 			secrets: []*proto.Secret{
 				{
 					Id:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
-					Name:     gophtest.SecretName,
+					Name:     []byte(gophtest.SecretName),
 					Kind:     proto.DataKind_TEXT,
 					Metadata: []byte(gophtest.Metadata),
 				},
@@ -221,19 +239,82 @@ func TestListSecrets(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			resp := &proto.ListSecretsResponse{
-				Secrets: tc.secrets,
+				Secrets:      tc.secrets,
+				VaultVersion: gophtest.VaultVersion,
 			}
 
-			rv, err := doListSecrets(t, resp, nil)
+			rv, version, err := doListSecrets(t, resp, nil)
 
 			require.NoError(t, err)
+			require.Equal(t, gophtest.VaultVersion, version)
 			snaps.MatchSnapshot(t, rv)
 		})
 	}
 }
 
+func TestListSecretsPageByPage(t *testing.T) {
+	first := &proto.Secret{Id: uuid.New().String(), Kind: proto.DataKind_TEXT}
+	second := &proto.Secret{Id: uuid.New().String(), Kind: proto.DataKind_TEXT}
+	kinds := []proto.DataKind{proto.DataKind_TEXT}
+
+	m := &proto.SecretsClientMock{}
+	m.On(
+		"List",
+		mock.Anything,
+		&proto.ListSecretsRequest{PageSize: repo.ListPageSize, Kinds: kinds},
+		mock.Anything,
+	).
+		Return(&proto.ListSecretsResponse{
+			Secrets:       []*proto.Secret{first},
+			VaultVersion:  gophtest.VaultVersion,
+			NextPageToken: "next",
+		}, nil)
+	m.On(
+		"List",
+		mock.Anything,
+		&proto.ListSecretsRequest{PageSize: repo.ListPageSize, PageToken: "next", Kinds: kinds},
+		mock.Anything,
+	).
+		Return(&proto.ListSecretsResponse{
+			Secrets:      []*proto.Secret{second},
+			VaultVersion: gophtest.VaultVersion,
+		}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	rv, version, err := sat.List(context.Background(), gophtest.AccessToken, kinds, 0)
+
+	m.AssertExpectations(t)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.VaultVersion, version)
+	require.Equal(t, []*proto.Secret{first, second}, rv)
+}
+
+func TestListSecretsWithLimit(t *testing.T) {
+	expected := []*proto.Secret{{Id: uuid.New().String(), Kind: proto.DataKind_TEXT}}
+
+	m := &proto.SecretsClientMock{}
+	m.On(
+		"List",
+		mock.Anything,
+		&proto.ListSecretsRequest{PageSize: 1},
+		mock.Anything,
+	).
+		Return(&proto.ListSecretsResponse{
+			Secrets:       expected,
+			VaultVersion:  gophtest.VaultVersion,
+			NextPageToken: "next",
+		}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	rv, _, err := sat.List(context.Background(), gophtest.AccessToken, nil, 1)
+
+	m.AssertExpectations(t)
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+}
+
 func TestListSecretsOnClientFailure(t *testing.T) {
-	_, err := doListSecrets(t, nil, gophtest.ErrUnexpected)
+	_, _, err := doListSecrets(t, nil, gophtest.ErrUnexpected)
 
 	require.Error(t, err)
 }
@@ -241,7 +322,7 @@ func TestListSecretsOnClientFailure(t *testing.T) {
 func TestGetSecret(t *testing.T) {
 	expSecret := &proto.Secret{
 		Id:       uuid.New().String(),
-		Name:     gophtest.SecretName,
+		Name:     []byte(gophtest.SecretName),
 		Kind:     proto.DataKind_TEXT,
 		Metadata: []byte(gophtest.Metadata),
 	}
@@ -268,7 +349,9 @@ func TestGetSecretOnClientFailure(t *testing.T) {
 func TestUpdateSecret(t *testing.T) {
 	tt := []struct {
 		name          string
-		secretName    string
+		secretName    []byte
+		nameIndex     []byte
+		dataKey       []byte
 		description   []byte
 		noDescription bool
 		data          []byte
@@ -276,14 +359,17 @@ func TestUpdateSecret(t *testing.T) {
 	}{
 		{
 			name:        "Update all fields of a secret",
-			secretName:  gophtest.SecretName,
+			secretName:  []byte(gophtest.SecretName),
+			nameIndex:   []byte(gophtest.NameIndex),
+			dataKey:     []byte(gophtest.DataKey),
 			description: []byte(gophtest.Metadata),
 			data:        []byte(gophtest.TextData),
-			changed:     []string{"name", "metadata", "data"},
+			changed:     []string{"name", "metadata", "data", "data_key"},
 		},
 		{
 			name:       "Update secret's name",
-			secretName: gophtest.SecretName,
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  []byte(gophtest.NameIndex),
 			changed:    []string{"name"},
 		},
 		{
@@ -308,6 +394,8 @@ func TestUpdateSecret(t *testing.T) {
 			err := doUpdateSecret(
 				t,
 				tc.secretName,
+				tc.nameIndex,
+				tc.dataKey,
 				tc.description,
 				tc.noDescription,
 				tc.data,
@@ -321,7 +409,7 @@ func TestUpdateSecret(t *testing.T) {
 }
 
 func TestUpdateSecretOnClientFailure(t *testing.T) {
-	err := doUpdateSecret(t, "", nil, false, nil, nil, gophtest.ErrUnexpected)
+	err := doUpdateSecret(t, nil, nil, nil, nil, false, nil, nil, gophtest.ErrUnexpected)
 
 	require.Error(t, err)
 }
@@ -337,3 +425,370 @@ func TestDeleteSecretOnClientFailure(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func newTestUploadHeader(id uuid.UUID) *proto.UploadBlobRequest {
+	return &proto.UploadBlobRequest{
+		Part: &proto.UploadBlobRequest_Header{
+			Header: &proto.BlobHeader{
+				Id:        id.String(),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				Metadata:  []byte(gophtest.Metadata),
+				DataKey:   []byte(gophtest.DataKey),
+			},
+		},
+	}
+}
+
+func newTestUploadChunk(chunk []byte) *proto.UploadBlobRequest {
+	return &proto.UploadBlobRequest{Part: &proto.UploadBlobRequest_Chunk{Chunk: chunk}}
+}
+
+func doUploadBlob(
+	t *testing.T,
+	id uuid.UUID,
+	stream *proto.UploadBlobClientMock,
+	chunks func() ([]byte, error),
+) error {
+	t.Helper()
+
+	m := &proto.SecretsClientMock{}
+	m.On("UploadBlob", mock.Anything, mock.Anything).
+		Return(stream, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	err := sat.UploadBlob(
+		context.Background(),
+		gophtest.AccessToken,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
+		[]byte(gophtest.Metadata),
+		chunks,
+	)
+
+	m.AssertExpectations(t)
+	stream.AssertExpectations(t)
+
+	return err
+}
+
+// newChunkSource provides the chunks and then io.EOF.
+func newChunkSource(chunks ...[]byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		return chunk, nil
+	}
+}
+
+func TestUploadBlob(t *testing.T) {
+	id := uuid.New()
+	first, second := []byte("first"), []byte("second")
+
+	stream := &proto.UploadBlobClientMock{}
+	stream.On("Send", newTestUploadHeader(id)).Return(nil)
+	stream.On("Send", newTestUploadChunk(first)).Return(nil)
+	stream.On("Send", newTestUploadChunk(second)).Return(nil)
+	stream.On("CloseAndRecv").Return(&proto.UploadBlobResponse{Id: id.String()}, nil)
+
+	err := doUploadBlob(t, id, stream, newChunkSource(first, second))
+
+	require.NoError(t, err)
+}
+
+func TestUploadBlobAbortedByServer(t *testing.T) {
+	id := uuid.New()
+	chunk := []byte("chunk")
+
+	stream := &proto.UploadBlobClientMock{}
+	stream.On("Send", newTestUploadHeader(id)).Return(nil)
+	stream.On("Send", newTestUploadChunk(chunk)).Return(io.EOF)
+	stream.On("CloseAndRecv").Return(nil, status.Error(codes.ResourceExhausted, "blob exceeds size limit"))
+
+	err := doUploadBlob(t, id, stream, newChunkSource(chunk, chunk))
+
+	require.True(t, errors.HasCode(err, codes.ResourceExhausted))
+}
+
+func TestUploadBlobOnSourceFailure(t *testing.T) {
+	id := uuid.New()
+
+	stream := &proto.UploadBlobClientMock{}
+	stream.On("Send", newTestUploadHeader(id)).Return(nil)
+
+	err := doUploadBlob(t, id, stream, func() ([]byte, error) {
+		return nil, gophtest.ErrUnexpected
+	})
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func doDownloadBlob(
+	t *testing.T,
+	stream *proto.DownloadBlobClientMock,
+) (*proto.Secret, [][]byte, error) {
+	t.Helper()
+
+	id := uuid.New()
+
+	m := &proto.SecretsClientMock{}
+	m.On("DownloadBlob", mock.Anything, &proto.DownloadBlobRequest{Id: id.String()}, mock.Anything).
+		Return(stream, nil)
+
+	sat := repo.NewSecretsRepo(m)
+
+	secret, next, err := sat.DownloadBlob(context.Background(), gophtest.AccessToken, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var chunks [][]byte
+
+	for {
+		chunk, err := next()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return secret, chunks, err
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	m.AssertExpectations(t)
+	stream.AssertExpectations(t)
+
+	return secret, chunks, nil
+}
+
+func newTestDownloadSecret(secret *proto.Secret) *proto.DownloadBlobResponse {
+	return &proto.DownloadBlobResponse{Part: &proto.DownloadBlobResponse_Secret{Secret: secret}}
+}
+
+func newTestDownloadChunk(chunk []byte) *proto.DownloadBlobResponse {
+	return &proto.DownloadBlobResponse{Part: &proto.DownloadBlobResponse_Chunk{Chunk: chunk}}
+}
+
+func TestDownloadBlob(t *testing.T) {
+	expected := &proto.Secret{Id: uuid.New().String(), Kind: proto.DataKind_BINARY, BlobSize: 11}
+	first, second := []byte("first"), []byte("second")
+
+	stream := &proto.DownloadBlobClientMock{}
+	stream.On("Recv").Return(newTestDownloadSecret(expected), nil).Once()
+	stream.On("Recv").Return(newTestDownloadChunk(first), nil).Once()
+	stream.On("Recv").Return(newTestDownloadChunk(second), nil).Once()
+	stream.On("Recv").Return(nil, io.EOF).Once()
+
+	secret, chunks, err := doDownloadBlob(t, stream)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, secret)
+	require.Equal(t, [][]byte{first, second}, chunks)
+}
+
+func TestDownloadBlobFailure(t *testing.T) {
+	secret := newTestDownloadSecret(&proto.Secret{Id: uuid.New().String()})
+
+	tt := []struct {
+		name      string
+		responses []*proto.DownloadBlobResponse
+		err       error
+	}{
+		{
+			name: "Download blob fails if secret is not found",
+			err:  status.Error(codes.NotFound, "secret not found"),
+		},
+		{
+			name:      "Download blob fails if stream doesn't start with secret",
+			responses: []*proto.DownloadBlobResponse{newTestDownloadChunk([]byte("chunk"))},
+			err:       repo.ErrUnexpectedMessage,
+		},
+		{
+			name:      "Download blob fails if secret is sent twice",
+			responses: []*proto.DownloadBlobResponse{secret, secret},
+			err:       repo.ErrUnexpectedMessage,
+		},
+		{
+			name:      "Download blob fails if stream breaks",
+			responses: []*proto.DownloadBlobResponse{secret},
+			err:       status.Error(codes.Unavailable, "connection lost"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &proto.DownloadBlobClientMock{}
+
+			for _, resp := range tc.responses {
+				stream.On("Recv").Return(resp, nil).Once()
+			}
+
+			if _, ok := status.FromError(tc.err); ok {
+				stream.On("Recv").Return(nil, tc.err).Once()
+			}
+
+			_, _, err := doDownloadBlob(t, stream)
+
+			require.Error(t, err)
+
+			if stderrors.Is(tc.err, repo.ErrUnexpectedMessage) {
+				require.ErrorIs(t, err, repo.ErrUnexpectedMessage)
+			}
+		})
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	id := uuid.New()
+	expected := []*proto.Secret{
+		{Id: id.String(), Name: []byte(gophtest.SecretName), Version: 2},
+		{Id: id.String(), Name: []byte(gophtest.SecretName), Version: 1},
+	}
+
+	m := &proto.SecretsClientMock{}
+	m.On("ListVersions", mock.Anything, &proto.ListSecretVersionsRequest{Id: id.String()}, mock.Anything).
+		Return(&proto.ListSecretVersionsResponse{Versions: expected}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	versions, err := sat.ListVersions(context.Background(), gophtest.AccessToken, id, false)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+	m.AssertExpectations(t)
+}
+
+func TestListVersionsOnClientFailure(t *testing.T) {
+	m := &proto.SecretsClientMock{}
+	m.On("ListVersions", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.NotFound, "secret not found"))
+
+	sat := repo.NewSecretsRepo(m)
+	_, err := sat.ListVersions(context.Background(), gophtest.AccessToken, uuid.New(), false)
+
+	require.True(t, errors.HasCode(err, codes.NotFound))
+	m.AssertExpectations(t)
+}
+
+func TestGetVersion(t *testing.T) {
+	id := uuid.New()
+	expected := &proto.Secret{Id: id.String(), Name: []byte(gophtest.SecretName), Version: 1}
+	expData := []byte(gophtest.TextData)
+
+	m := &proto.SecretsClientMock{}
+	m.On(
+		"GetVersion",
+		mock.Anything,
+		&proto.GetSecretVersionRequest{Id: id.String(), Version: 1, Trashed: true},
+		mock.Anything,
+	).
+		Return(&proto.GetSecretVersionResponse{Secret: expected, Data: expData}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	secret, data, err := sat.GetVersion(context.Background(), gophtest.AccessToken, id, 1, true)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, secret)
+	require.Equal(t, expData, data)
+	m.AssertExpectations(t)
+}
+
+func TestGetVersionOnClientFailure(t *testing.T) {
+	m := &proto.SecretsClientMock{}
+	m.On("GetVersion", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.NotFound, "secret version not found"))
+
+	sat := repo.NewSecretsRepo(m)
+	_, _, err := sat.GetVersion(context.Background(), gophtest.AccessToken, uuid.New(), 1, false)
+
+	require.True(t, errors.HasCode(err, codes.NotFound))
+	m.AssertExpectations(t)
+}
+
+func TestRestoreVersion(t *testing.T) {
+	id := uuid.New()
+
+	m := &proto.SecretsClientMock{}
+	m.On("Restore", mock.Anything, &proto.RestoreSecretRequest{Id: id.String(), Version: 1}, mock.Anything).
+		Return(&proto.RestoreSecretResponse{Version: 5}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	version, err := sat.Restore(context.Background(), gophtest.AccessToken, id, 1)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(5), version)
+	m.AssertExpectations(t)
+}
+
+func TestRestoreVersionOnClientFailure(t *testing.T) {
+	m := &proto.SecretsClientMock{}
+	m.On("Restore", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.AlreadyExists, "secret with such name already exists"))
+
+	sat := repo.NewSecretsRepo(m)
+	_, err := sat.Restore(context.Background(), gophtest.AccessToken, uuid.New(), 1)
+
+	require.True(t, errors.HasCode(err, codes.AlreadyExists))
+	m.AssertExpectations(t)
+}
+
+func TestListTrash(t *testing.T) {
+	secrets := []*proto.Secret{{Id: uuid.New().String(), DeletedAt: timestamppb.Now()}}
+
+	m := &proto.SecretsClientMock{}
+	m.On("ListTrash", mock.Anything, &proto.ListTrashRequest{}, mock.Anything).
+		Return(&proto.ListTrashResponse{Secrets: secrets}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	rv, err := sat.ListTrash(context.Background(), gophtest.AccessToken)
+
+	require.NoError(t, err)
+	require.Equal(t, secrets, rv)
+	m.AssertExpectations(t)
+}
+
+func TestListTrashOnClientFailure(t *testing.T) {
+	m := &proto.SecretsClientMock{}
+	m.On("ListTrash", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := repo.NewSecretsRepo(m)
+	_, err := sat.ListTrash(context.Background(), gophtest.AccessToken)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestPurgeSecret(t *testing.T) {
+	id := uuid.New()
+
+	m := &proto.SecretsClientMock{}
+	m.On("Purge", mock.Anything, &proto.PurgeSecretRequest{Id: id.String()}, mock.Anything).
+		Return(&proto.PurgeSecretResponse{}, nil)
+
+	sat := repo.NewSecretsRepo(m)
+	err := sat.Purge(context.Background(), gophtest.AccessToken, id)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestPurgeSecretOnClientFailure(t *testing.T) {
+	m := &proto.SecretsClientMock{}
+	m.On("Purge", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.NotFound, "secret not found"))
+
+	sat := repo.NewSecretsRepo(m)
+	err := sat.Purge(context.Background(), gophtest.AccessToken, uuid.New())
+
+	require.True(t, errors.HasCode(err, codes.NotFound))
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ Sessions = (*SessionsRepo)(nil)
+
+// SessionsRepo is facade to login sessions stored in Keeper.
+type SessionsRepo struct {
+	client proto.SessionsClient
+}
+
+// NewSessionsRepo creates and initializes SessionsRepo object.
+func NewSessionsRepo(client proto.SessionsClient) *SessionsRepo {
+	return &SessionsRepo{client}
+}
+
+// List returns active login sessions of the user.
+func (r *SessionsRepo) List(ctx context.Context, token string) ([]*proto.Session, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.List(ctx, &proto.ListSessionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("SessionsRepo - List - r.client.List: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetSessions(), nil
+}
+
+// Revoke ends the login session of the user.
+func (r *SessionsRepo) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.RevokeSessionRequest{Id: id.String()}
+
+	if _, err := r.client.Revoke(ctx, req); err != nil {
+		return fmt.Errorf("SessionsRepo - Revoke - r.client.Revoke: %w", errors.NewRequestError(err))
+	}
+
+	return nil
+}
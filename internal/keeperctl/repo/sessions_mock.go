@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ Sessions = (*SessionsRepoMock)(nil)
+
+type SessionsRepoMock struct {
+	mock.Mock
+}
+
+func (m *SessionsRepoMock) List(ctx context.Context, token string) ([]*proto.Session, error) {
+	args := m.Called(ctx, token)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*proto.Session), args.Error(1)
+}
+
+func (m *SessionsRepoMock) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	args := m.Called(ctx, token, id)
+
+	return args.Error(0)
+}
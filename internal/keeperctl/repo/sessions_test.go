@@ -0,0 +1,73 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func doListSessions(t *testing.T, mockErr error) ([]*proto.Session, error) {
+	t.Helper()
+
+	m := &proto.SessionsClientMock{}
+	m.On("List", mock.Anything, &proto.ListSessionsRequest{}, mock.Anything).
+		Return(
+			&proto.ListSessionsResponse{
+				Sessions: []*proto.Session{{Id: uuid.NewString(), DeviceName: gophtest.DeviceName}},
+			},
+			mockErr,
+		)
+
+	sat := repo.NewSessionsRepo(m)
+	sessions, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	m.AssertExpectations(t)
+
+	return sessions, err
+}
+
+func TestListSessions(t *testing.T) {
+	sessions, err := doListSessions(t, nil)
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, gophtest.DeviceName, sessions[0].GetDeviceName())
+}
+
+func TestListSessionsOnClientFailure(t *testing.T) {
+	_, err := doListSessions(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doRevokeSession(t *testing.T, mockErr error) error {
+	t.Helper()
+
+	id := uuid.New()
+
+	m := &proto.SessionsClientMock{}
+	m.On("Revoke", mock.Anything, &proto.RevokeSessionRequest{Id: id.String()}, mock.Anything).
+		Return(&proto.RevokeSessionResponse{}, mockErr)
+
+	sat := repo.NewSessionsRepo(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	m.AssertExpectations(t)
+
+	return err
+}
+
+func TestRevokeSession(t *testing.T) {
+	require.NoError(t, doRevokeSession(t, nil))
+}
+
+func TestRevokeSessionOnClientFailure(t *testing.T) {
+	require.Error(t, doRevokeSession(t, gophtest.ErrUnexpected))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"google.golang.org/grpc/metadata"
+
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
@@ -21,19 +23,261 @@ func NewUsersRepo(client proto.UsersClient) *UsersRepo {
 }
 
 // Register creates a new user.
+// Recovery is set up only if the recovery kit is provided.
+// Returns access and refresh tokens of the login session started on registration.
 func (r *UsersRepo) Register(
 	ctx context.Context,
-	username, securityKey string,
-) (string, error) {
+	username string,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	recovery *proto.RecoveryKit,
+) (string, string, error) {
 	req := &proto.RegisterUserRequest{
-		Username:    username,
-		SecurityKey: securityKey,
+		Username:  username,
+		Verifier:  verifier,
+		KdfParams: kdf,
+		Recovery:  recovery,
 	}
 
 	resp, err := r.client.Register(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("UsersRepo - Register - r.client.Register: %w", errors.NewRequestError(err))
+		return "", "", fmt.Errorf("UsersRepo - Register - r.client.Register: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetAccessToken(), resp.GetRefreshToken(), nil
+}
+
+// StartChangePassword starts SRP handshake to prove the current master password.
+func (r *UsersRepo) StartChangePassword(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.StartChangePasswordRequest{
+		ClientPublic: clientPublic,
+	}
+
+	resp, err := r.client.StartChangePassword(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - StartChangePassword - r.client.StartChangePassword: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetChallenge(), nil
+}
+
+// ChangePassword replaces master password of the user.
+// Secrets must contain all secrets of the user encrypted with the new key.
+// The user is verified by recoverySecurityKey instead of the proof on recovery.
+// Recovery kit must be rewrapped with the new key if recovery is set up.
+// Returns the server proof of the session key, empty on recovery.
+func (r *UsersRepo) ChangePassword(
+	ctx context.Context,
+	token string,
+	proof *proto.SRPProof,
+	recoverySecurityKey string,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	vaultVersion int64,
+	secrets []*proto.ReencryptedSecret,
+	recovery *proto.RecoveryKit,
+) ([]byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.ChangePasswordRequest{
+		Proof:               proof,
+		RecoverySecurityKey: recoverySecurityKey,
+		Verifier:            verifier,
+		KdfParams:           kdf,
+		VaultVersion:        vaultVersion,
+		Secrets:             secrets,
+		Recovery:            recovery,
+	}
+
+	resp, err := r.client.ChangePassword(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - ChangePassword - r.client.ChangePassword: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetServerProof(), nil
+}
+
+// StartDelete starts SRP handshake to prove the master password before deletion.
+func (r *UsersRepo) StartDelete(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.StartDeleteUserRequest{
+		ClientPublic: clientPublic,
+	}
+
+	resp, err := r.client.StartDelete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - StartDelete - r.client.StartDelete: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetChallenge(), nil
+}
+
+// Delete removes the user together with all secrets.
+// Returns the server proof of the session key.
+func (r *UsersRepo) Delete(ctx context.Context, token string, proof *proto.SRPProof) ([]byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.Delete(ctx, &proto.DeleteUserRequest{Proof: proof})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - Delete - r.client.Delete: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetServerProof(), nil
+}
+
+// StartRename starts SRP handshake to prove the master password before renaming.
+func (r *UsersRepo) StartRename(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.StartRenameUserRequest{
+		ClientPublic: clientPublic,
+	}
+
+	resp, err := r.client.StartRename(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - StartRename - r.client.StartRename: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetChallenge(), nil
+}
+
+// Rename changes username of the user.
+// Secrets must contain all secrets of the user encrypted with the key derived for the new username.
+// Recovery kit must be rewrapped with the new key if recovery is set up.
+// Returns the server proof of the session key.
+func (r *UsersRepo) Rename(
+	ctx context.Context,
+	token string,
+	username string,
+	proof *proto.SRPProof,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	vaultVersion int64,
+	secrets []*proto.ReencryptedSecret,
+	recovery *proto.RecoveryKit,
+) ([]byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.RenameUserRequest{
+		Username:     username,
+		Proof:        proof,
+		Verifier:     verifier,
+		KdfParams:    kdf,
+		VaultVersion: vaultVersion,
+		Secrets:      secrets,
+		Recovery:     recovery,
+	}
+
+	resp, err := r.client.Rename(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - Rename - r.client.Rename: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetServerProof(), nil
+}
+
+// GetRecoveryKey requests recovery key of the user wrapped by the vault key.
+// Returns nil if recovery is not set up.
+func (r *UsersRepo) GetRecoveryKey(ctx context.Context, token string) ([]byte, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.GetRecoveryKey(ctx, &proto.GetRecoveryKeyRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"UsersRepo - GetRecoveryKey - r.client.GetRecoveryKey: %w",
+			errors.NewRequestError(err),
+		)
+	}
+
+	return resp.GetRecoveryKey(), nil
+}
+
+// SetupTOTP requests new TOTP secret of the user.
+// Returns provisioning URI of the secret for authenticator apps.
+func (r *UsersRepo) SetupTOTP(ctx context.Context, token string) (string, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := r.client.SetupTOTP(ctx, &proto.SetupTOTPRequest{})
+	if err != nil {
+		return "", fmt.Errorf("UsersRepo - SetupTOTP - r.client.SetupTOTP: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetUri(), nil
+}
+
+// EnableTOTP turns on two-factor authentication of the user
+// confirming the TOTP secret with the first one-time code.
+// Returns backup codes.
+func (r *UsersRepo) EnableTOTP(ctx context.Context, token, code string) ([]string, error) {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.EnableTOTPRequest{
+		Code: code,
+	}
+
+	resp, err := r.client.EnableTOTP(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - EnableTOTP - r.client.EnableTOTP: %w", errors.NewRequestError(err))
+	}
+
+	return resp.GetBackupCodes(), nil
+}
+
+// DisableTOTP turns off two-factor authentication of the user.
+// The code is either one-time code or backup code.
+func (r *UsersRepo) DisableTOTP(ctx context.Context, token, code string) error {
+	md := metadata.New(map[string]string{"authorization": token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	req := &proto.DisableTOTPRequest{
+		Code: code,
+	}
+
+	if _, err := r.client.DisableTOTP(ctx, req); err != nil {
+		return fmt.Errorf("UsersRepo - DisableTOTP - r.client.DisableTOTP: %w", errors.NewRequestError(err))
 	}
 
-	return resp.GetAccessToken(), nil
+	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
 var _ Users = (*UsersRepoMock)(nil)
@@ -14,9 +16,136 @@ type UsersRepoMock struct {
 
 func (m *UsersRepoMock) Register(
 	ctx context.Context,
-	username, securityKey string,
-) (string, error) {
-	args := m.Called(ctx, username, securityKey)
+	username string,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	recovery *proto.RecoveryKit,
+) (string, string, error) {
+	args := m.Called(ctx, username, verifier, kdf, recovery)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *UsersRepoMock) StartChangePassword(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	args := m.Called(ctx, token, clientPublic)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.SRPChallenge), args.Error(1)
+}
+
+func (m *UsersRepoMock) ChangePassword(
+	ctx context.Context,
+	token string,
+	proof *proto.SRPProof,
+	recoverySecurityKey string,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	vaultVersion int64,
+	secrets []*proto.ReencryptedSecret,
+	recovery *proto.RecoveryKit,
+) ([]byte, error) {
+	args := m.Called(
+		ctx,
+		token,
+		proof,
+		recoverySecurityKey,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersRepoMock) StartDelete(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	args := m.Called(ctx, token, clientPublic)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.SRPChallenge), args.Error(1)
+}
+
+func (m *UsersRepoMock) Delete(ctx context.Context, token string, proof *proto.SRPProof) ([]byte, error) {
+	args := m.Called(ctx, token, proof)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersRepoMock) StartRename(
+	ctx context.Context,
+	token string,
+	clientPublic []byte,
+) (*proto.SRPChallenge, error) {
+	args := m.Called(ctx, token, clientPublic)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*proto.SRPChallenge), args.Error(1)
+}
+
+func (m *UsersRepoMock) Rename(
+	ctx context.Context,
+	token string,
+	username string,
+	proof *proto.SRPProof,
+	verifier *proto.SRPVerifier,
+	kdf *proto.KDFParams,
+	vaultVersion int64,
+	secrets []*proto.ReencryptedSecret,
+	recovery *proto.RecoveryKit,
+) ([]byte, error) {
+	args := m.Called(
+		ctx,
+		token,
+		username,
+		proof,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersRepoMock) GetRecoveryKey(ctx context.Context, token string) ([]byte, error) {
+	args := m.Called(ctx, token)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersRepoMock) SetupTOTP(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
 
 	return args.String(0), args.Error(1)
 }
+
+func (m *UsersRepoMock) EnableTOTP(ctx context.Context, token, code string) ([]string, error) {
+	args := m.Called(ctx, token, code)
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *UsersRepoMock) DisableTOTP(ctx context.Context, token, code string) error {
+	args := m.Called(ctx, token, code)
+
+	return args.Error(0)
+}
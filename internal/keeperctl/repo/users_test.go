@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -12,16 +13,39 @@ import (
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+func newTestKDFParams() *proto.KDFParams {
+	return &proto.KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func newTestRecoveryKit() *proto.RecoveryKit {
+	return &proto.RecoveryKit{
+		SecurityKey: gophtest.RecoverySecurityKey,
+		VaultKey:    []byte(gophtest.WrappedVaultKey),
+		RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+	}
+}
+
 func newRegisterUserRequest() *proto.RegisterUserRequest {
 	return &proto.RegisterUserRequest{
-		Username:    gophtest.Username,
-		SecurityKey: gophtest.SecurityKey,
+		Username:  gophtest.Username,
+		Verifier:  newTestVerifier(),
+		KdfParams: newTestKDFParams(),
+		Recovery:  newTestRecoveryKit(),
 	}
 }
 
 func TestRegister(t *testing.T) {
 	resp := &proto.RegisterUserResponse{
-		AccessToken: gophtest.AccessToken,
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
 	}
 
 	m := &proto.UsersClientMock{}
@@ -34,10 +58,17 @@ func TestRegister(t *testing.T) {
 		Return(resp, nil)
 
 	sat := repo.NewUsersRepo(m)
-	token, err := sat.Register(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	accessToken, refreshToken, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		newTestVerifier(),
+		newTestKDFParams(),
+		newTestRecoveryKit(),
+	)
 
 	require.NoError(t, err)
-	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, gophtest.AccessToken, accessToken)
+	require.Equal(t, gophtest.RefreshToken, refreshToken)
 	m.AssertExpectations(t)
 }
 
@@ -52,8 +83,424 @@ func TestRegisterOnClientFailure(t *testing.T) {
 		Return(nil, gophtest.ErrUnexpected)
 
 	sat := repo.NewUsersRepo(m)
-	_, err := sat.Register(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	_, _, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		newTestVerifier(),
+		newTestKDFParams(),
+		newTestRecoveryKit(),
+	)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
+
+func newChangePasswordRequest() *proto.ChangePasswordRequest {
+	return &proto.ChangePasswordRequest{
+		Proof:        newTestProof(),
+		Verifier:     newTestVerifier(),
+		KdfParams:    newTestKDFParams(),
+		VaultVersion: gophtest.VaultVersion,
+		Secrets: []*proto.ReencryptedSecret{
+			{
+				Id:       uuid.NewString(),
+				Metadata: []byte(gophtest.Metadata),
+				Data:     []byte(gophtest.TextData),
+			},
+		},
+		Recovery: &proto.RecoveryKit{
+			VaultKey:    []byte(gophtest.WrappedVaultKey),
+			RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+		},
+	}
+}
+
+func doStartChangePassword(t *testing.T, mockErr error) (*proto.SRPChallenge, error) {
+	t.Helper()
+
+	resp := &proto.StartChangePasswordResponse{
+		Challenge: &proto.SRPChallenge{
+			HandshakeId:  uuid.NewString(),
+			Salt:         []byte(gophtest.SRPSalt),
+			ServerPublic: []byte(gophtest.ServerPublic),
+		},
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"StartChangePassword",
+		mock.Anything,
+		&proto.StartChangePasswordRequest{ClientPublic: []byte(gophtest.ClientPublic)},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	challenge, err := sat.StartChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		[]byte(gophtest.ClientPublic),
+	)
+
+	m.AssertExpectations(t)
+
+	return challenge, err
+}
+
+func TestStartChangePassword(t *testing.T) {
+	challenge, err := doStartChangePassword(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerPublic), challenge.GetServerPublic())
+}
+
+func TestStartChangePasswordOnClientFailure(t *testing.T) {
+	_, err := doStartChangePassword(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doChangePassword(t *testing.T, mockErr error) ([]byte, error) {
+	t.Helper()
+
+	req := newChangePasswordRequest()
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"ChangePassword",
+		mock.Anything,
+		req,
+		mock.Anything,
+	).
+		Return(&proto.ChangePasswordResponse{ServerProof: []byte(gophtest.ServerProof)}, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	serverProof, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		req.GetProof(),
+		"",
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		req.GetSecrets(),
+		req.GetRecovery(),
+	)
+
+	m.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestChangePassword(t *testing.T) {
+	serverProof, err := doChangePassword(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestChangePasswordOnClientFailure(t *testing.T) {
+	_, err := doChangePassword(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doStartDelete(t *testing.T, mockErr error) (*proto.SRPChallenge, error) {
+	t.Helper()
+
+	resp := &proto.StartDeleteUserResponse{
+		Challenge: &proto.SRPChallenge{
+			HandshakeId:  uuid.NewString(),
+			Salt:         []byte(gophtest.SRPSalt),
+			ServerPublic: []byte(gophtest.ServerPublic),
+		},
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"StartDelete",
+		mock.Anything,
+		&proto.StartDeleteUserRequest{ClientPublic: []byte(gophtest.ClientPublic)},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	challenge, err := sat.StartDelete(context.Background(), gophtest.AccessToken, []byte(gophtest.ClientPublic))
+
+	m.AssertExpectations(t)
+
+	return challenge, err
+}
+
+func TestStartDelete(t *testing.T) {
+	challenge, err := doStartDelete(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerPublic), challenge.GetServerPublic())
+}
+
+func TestStartDeleteOnClientFailure(t *testing.T) {
+	_, err := doStartDelete(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doDeleteUser(t *testing.T, mockErr error) ([]byte, error) {
+	t.Helper()
+
+	proof := &proto.SRPProof{HandshakeId: uuid.NewString(), ClientProof: []byte(gophtest.ClientProof)}
+
+	m := &proto.UsersClientMock{}
+	m.On("Delete", mock.Anything, &proto.DeleteUserRequest{Proof: proof}, mock.Anything).
+		Return(&proto.DeleteUserResponse{ServerProof: []byte(gophtest.ServerProof)}, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	serverProof, err := sat.Delete(context.Background(), gophtest.AccessToken, proof)
+
+	m.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestDeleteUser(t *testing.T) {
+	serverProof, err := doDeleteUser(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestDeleteUserOnClientFailure(t *testing.T) {
+	_, err := doDeleteUser(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doStartRename(t *testing.T, mockErr error) (*proto.SRPChallenge, error) {
+	t.Helper()
+
+	resp := &proto.StartRenameUserResponse{
+		Challenge: &proto.SRPChallenge{
+			HandshakeId:  uuid.NewString(),
+			Salt:         []byte(gophtest.SRPSalt),
+			ServerPublic: []byte(gophtest.ServerPublic),
+		},
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"StartRename",
+		mock.Anything,
+		&proto.StartRenameUserRequest{ClientPublic: []byte(gophtest.ClientPublic)},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	challenge, err := sat.StartRename(context.Background(), gophtest.AccessToken, []byte(gophtest.ClientPublic))
+
+	m.AssertExpectations(t)
+
+	return challenge, err
+}
+
+func TestStartRename(t *testing.T) {
+	challenge, err := doStartRename(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerPublic), challenge.GetServerPublic())
+}
+
+func TestStartRenameOnClientFailure(t *testing.T) {
+	_, err := doStartRename(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doRenameUser(t *testing.T, mockErr error) ([]byte, error) {
+	t.Helper()
+
+	changeReq := newChangePasswordRequest()
+	req := &proto.RenameUserRequest{
+		Username:     gophtest.Username,
+		Proof:        changeReq.GetProof(),
+		Verifier:     changeReq.GetVerifier(),
+		KdfParams:    changeReq.GetKdfParams(),
+		VaultVersion: changeReq.GetVaultVersion(),
+		Secrets:      changeReq.GetSecrets(),
+		Recovery:     changeReq.GetRecovery(),
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On("Rename", mock.Anything, req, mock.Anything).
+		Return(&proto.RenameUserResponse{ServerProof: []byte(gophtest.ServerProof)}, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	serverProof, err := sat.Rename(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		req.GetProof(),
+		req.GetVerifier(),
+		req.GetKdfParams(),
+		req.GetVaultVersion(),
+		req.GetSecrets(),
+		req.GetRecovery(),
+	)
+
+	m.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestRenameUser(t *testing.T) {
+	serverProof, err := doRenameUser(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestRenameUserOnClientFailure(t *testing.T) {
+	_, err := doRenameUser(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doGetRecoveryKey(t *testing.T, mockErr error) ([]byte, error) {
+	t.Helper()
+
+	resp := &proto.GetRecoveryKeyResponse{
+		RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"GetRecoveryKey",
+		mock.Anything,
+		&proto.GetRecoveryKeyRequest{},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	recoveryKey, err := sat.GetRecoveryKey(context.Background(), gophtest.AccessToken)
+
+	m.AssertExpectations(t)
+
+	return recoveryKey, err
+}
+
+func TestGetRecoveryKey(t *testing.T) {
+	recoveryKey, err := doGetRecoveryKey(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedRecoveryKey), recoveryKey)
+}
+
+func TestGetRecoveryKeyOnClientFailure(t *testing.T) {
+	_, err := doGetRecoveryKey(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doSetupTOTP(t *testing.T, mockErr error) (string, error) {
+	t.Helper()
+
+	resp := &proto.SetupTOTPResponse{
+		Uri: "otpauth://totp/GophKeeper:" + gophtest.Username,
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"SetupTOTP",
+		mock.Anything,
+		&proto.SetupTOTPRequest{},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	uri, err := sat.SetupTOTP(context.Background(), gophtest.AccessToken)
+
+	m.AssertExpectations(t)
+
+	return uri, err
+}
+
+func TestSetupTOTP(t *testing.T) {
+	uri, err := doSetupTOTP(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, "otpauth://totp/GophKeeper:"+gophtest.Username, uri)
+}
+
+func TestSetupTOTPOnClientFailure(t *testing.T) {
+	_, err := doSetupTOTP(t, gophtest.ErrUnexpected)
+
+	require.Error(t, err)
+}
+
+func doEnableTOTP(t *testing.T, mockErr error) ([]string, error) {
+	t.Helper()
+
+	resp := &proto.EnableTOTPResponse{
+		BackupCodes: []string{gophtest.BackupCode},
+	}
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"EnableTOTP",
+		mock.Anything,
+		&proto.EnableTOTPRequest{Code: gophtest.OTPCode},
+		mock.Anything,
+	).
+		Return(resp, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	backupCodes, err := sat.EnableTOTP(context.Background(), gophtest.AccessToken, gophtest.OTPCode)
+
+	m.AssertExpectations(t)
+
+	return backupCodes, err
+}
+
+func TestEnableTOTP(t *testing.T) {
+	backupCodes, err := doEnableTOTP(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{gophtest.BackupCode}, backupCodes)
+}
+
+func TestEnableTOTPOnClientFailure(t *testing.T) {
+	_, err := doEnableTOTP(t, gophtest.ErrUnexpected)
 
 	require.Error(t, err)
+}
+
+func doDisableTOTP(t *testing.T, mockErr error) error {
+	t.Helper()
+
+	m := &proto.UsersClientMock{}
+	m.On(
+		"DisableTOTP",
+		mock.Anything,
+		&proto.DisableTOTPRequest{Code: gophtest.BackupCode},
+		mock.Anything,
+	).
+		Return(&proto.DisableTOTPResponse{}, mockErr)
+
+	sat := repo.NewUsersRepo(m)
+	err := sat.DisableTOTP(context.Background(), gophtest.AccessToken, gophtest.BackupCode)
+
 	m.AssertExpectations(t)
+
+	return err
+}
+
+func TestDisableTOTP(t *testing.T) {
+	require.NoError(t, doDisableTOTP(t, nil))
+}
+
+func TestDisableTOTPOnClientFailure(t *testing.T) {
+	require.Error(t, doDisableTOTP(t, gophtest.ErrUnexpected))
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ APITokens = (*APITokensService)(nil)
+
+// APITokensService contains business logic related to API tokens.
+type APITokensService struct {
+	apiTokensRepo repo.APITokens
+}
+
+// NewAPITokensService create and initializes new APITokensService object.
+func NewAPITokensService(apiTokens repo.APITokens) *APITokensService {
+	return &APITokensService{apiTokens}
+}
+
+// Create issues new API token of the user, the token itself is returned only once.
+// The token is limited to the secrets with provided IDs, to all secrets if none provided.
+// Zero ttl means that the token never expires.
+func (uc *APITokensService) Create(
+	ctx context.Context,
+	token, name string,
+	readOnly bool,
+	secretIDs []uuid.UUID,
+	ttl time.Duration,
+) (string, *p.APIToken, error) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	apiToken, info, err := uc.apiTokensRepo.Create(ctx, token, name, readOnly, secretIDs, expiresAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("APITokensService - Create - uc.apiTokensRepo.Create: %w", err)
+	}
+
+	return apiToken, info, nil
+}
+
+// List returns API tokens of the user.
+func (uc *APITokensService) List(ctx context.Context, token string) ([]*p.APIToken, error) {
+	tokens, err := uc.apiTokensRepo.List(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("APITokensService - List - uc.apiTokensRepo.List: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes API token of the user, so it is rejected from now on.
+func (uc *APITokensService) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	if err := uc.apiTokensRepo.Revoke(ctx, token, id); err != nil {
+		return fmt.Errorf("APITokensService - Revoke - uc.apiTokensRepo.Revoke: %w", err)
+	}
+
+	return nil
+}
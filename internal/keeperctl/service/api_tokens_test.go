@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	secretIDs := []uuid.UUID{uuid.New()}
+	expected := &proto.APIToken{Id: uuid.NewString(), Name: gophtest.APITokenName}
+
+	tt := []struct {
+		name    string
+		ttl     time.Duration
+		expires bool
+	}{
+		{
+			name: "Create API token which never expires",
+		},
+		{
+			name:    "Create API token which expires",
+			ttl:     time.Hour,
+			expires: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.APITokensRepoMock{}
+			m.On(
+				"Create",
+				mock.Anything,
+				gophtest.AccessToken,
+				gophtest.APITokenName,
+				true,
+				secretIDs,
+				mock.MatchedBy(func(expiresAt time.Time) bool {
+					return expiresAt.IsZero() != tc.expires
+				}),
+			).
+				Return(gophtest.APIToken, expected, nil)
+
+			sat := service.NewAPITokensService(m)
+			token, info, err := sat.Create(
+				context.Background(),
+				gophtest.AccessToken,
+				gophtest.APITokenName,
+				true,
+				secretIDs,
+				tc.ttl,
+			)
+
+			require.NoError(t, err)
+			require.Equal(t, gophtest.APIToken, token)
+			require.Equal(t, expected, info)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateAPITokenOnRepoFailure(t *testing.T) {
+	m := &repo.APITokensRepoMock{}
+	m.On("Create", mock.Anything, gophtest.AccessToken, gophtest.APITokenName, false, []uuid.UUID(nil), time.Time{}).
+		Return("", nil, gophtest.ErrUnexpected)
+
+	sat := service.NewAPITokensService(m)
+	_, _, err := sat.Create(context.Background(), gophtest.AccessToken, gophtest.APITokenName, false, nil, 0)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestListAPITokens(t *testing.T) {
+	expected := []*proto.APIToken{{Id: uuid.NewString(), Name: gophtest.APITokenName}}
+
+	m := &repo.APITokensRepoMock{}
+	m.On("List", mock.Anything, gophtest.AccessToken).
+		Return(expected, nil)
+
+	sat := service.NewAPITokensService(m)
+	tokens, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, tokens)
+	m.AssertExpectations(t)
+}
+
+func TestListAPITokensOnRepoFailure(t *testing.T) {
+	m := &repo.APITokensRepoMock{}
+	m.On("List", mock.Anything, gophtest.AccessToken).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewAPITokensService(m)
+	_, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.APITokensRepoMock{}
+	m.On("Revoke", mock.Anything, gophtest.AccessToken, id).
+		Return(nil)
+
+	sat := service.NewAPITokensService(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeAPITokenOnRepoFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.APITokensRepoMock{}
+	m.On("Revoke", mock.Anything, gophtest.AccessToken, id).
+		Return(gophtest.ErrUnexpected)
+
+	sat := service.NewAPITokensService(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
@@ -2,32 +2,254 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 )
 
 var _ Auth = (*AuthService)(nil)
 
+// ErrLegacyLogin is returned if the service asks for login with the security key
+// of a user registered before SRP, but the legacy login is not allowed.
+var ErrLegacyLogin = stderrors.New("service asks for legacy login sending the security key")
+
+// expiryMargin is how long before expiration the access token is refreshed,
+// so it doesn't expire in the middle of the command.
+const expiryMargin = time.Minute
+
 // AuthService contains business logic related to authentication.
 type AuthService struct {
 	authRepo repo.Auth
 }
 
+// Tokens are issued by the service on login.
+// If the user has two-factor authentication enabled,
+// only the partial token is issued until the second factor is verified.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	PartialToken string
+}
+
 // NewAuthService create and initializes new AuthService object.
 func NewAuthService(auth repo.Auth) *AuthService {
 	return &AuthService{auth}
 }
 
-// Login authenticates a user.
-func (s *AuthService) Login(ctx context.Context, username string, key encryption.Key) (string, error) {
-	securityKey := key.Hash()
+// Login authenticates a user with SRP handshake,
+// so neither the auth subkey nor its equivalent is sent to the service.
+// The service must prove that it holds the verifier as well.
+// Returns access and refresh tokens and vault key derived from the master password
+// and the key file, if the user is registered with it.
+// If the user has two-factor authentication enabled, partial token is returned instead,
+// see VerifySecondFactor.
+// A user registered before SRP logs in with the security key once,
+// which is replaced with the verifier of the auth subkey,
+// while the master key of a legacy user is still used to encrypt secrets.
+// The security key lets the service derive the vault key of a legacy user,
+// so the legacy login is performed only if allowLegacy is set, otherwise ErrLegacyLogin is returned.
+func (s *AuthService) Login(
+	ctx context.Context,
+	username string,
+	password creds.Password,
+	keyFile encryption.KeyFile,
+	allowLegacy bool,
+) (Tokens, encryption.Key, error) {
+	var (
+		tokens Tokens
+		keys   encryption.Keys
+	)
+
+	resp, legacy, err := s.authRepo.Prelogin(ctx, username)
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	// Checked before the key is derived, so nothing is sent if the service lies.
+	if legacy && !allowLegacy {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", ErrLegacyLogin)
+	}
+
+	kdf := kdfParamsFromProto(resp)
+
+	master, err := encryption.NewKey(username, password, keyFile, kdf)
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	keys, err = master.Subkeys(kdf.Schedule)
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	if legacy {
+		tokens, err = s.upgrade(ctx, username, master, keys, kdf.Schedule)
+		if err != nil {
+			return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+		}
+
+		return tokens, keys.Vault, nil
+	}
+
+	client, err := srp.NewClient()
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	challenge, err := s.authRepo.StartLogin(ctx, username, client.Public())
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	proof, err := prove(client, keys.Auth, challenge)
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	finish, err := s.authRepo.FinishLogin(ctx, proof)
+	if err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	if err := client.VerifyServer(finish.GetServerProof()); err != nil {
+		return tokens, keys.Vault, fmt.Errorf("login error: %w", err)
+	}
+
+	tokens = Tokens{
+		AccessToken:  finish.GetAccessToken(),
+		RefreshToken: finish.GetRefreshToken(),
+		PartialToken: finish.GetPartialToken(),
+	}
+
+	return tokens, keys.Vault, nil
+}
+
+// Unlock derives the vault key of a user authenticated with the client certificate,
+// so no tokens are requested from the service.
+// The vault key is derived the same way as on login, see Login.
+func (s *AuthService) Unlock(
+	ctx context.Context,
+	username string,
+	password creds.Password,
+	keyFile encryption.KeyFile,
+) (encryption.Key, error) {
+	resp, _, err := s.authRepo.Prelogin(ctx, username)
+	if err != nil {
+		return encryption.Key{}, fmt.Errorf("unlock error: %w", err)
+	}
+
+	kdf := kdfParamsFromProto(resp)
+
+	master, err := encryption.NewKey(username, password, keyFile, kdf)
+	if err != nil {
+		return encryption.Key{}, fmt.Errorf("unlock error: %w", err)
+	}
+
+	keys, err := master.Subkeys(kdf.Schedule)
+	if err != nil {
+		return encryption.Key{}, fmt.Errorf("unlock error: %w", err)
+	}
+
+	return keys.Vault, nil
+}
+
+// VerifySecondFactor completes login of a user with two-factor authentication enabled.
+// The code is either current one-time code of the authenticator app or unused backup code.
+func (s *AuthService) VerifySecondFactor(ctx context.Context, partialToken, code string) (Tokens, error) {
+	accessToken, refreshToken, err := s.authRepo.VerifySecondFactor(ctx, partialToken, code)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("login error: %w", err)
+	}
+
+	return Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Refresh exchanges the refresh token for new access token and the next refresh token.
+// The used refresh token is no longer valid,
+// presenting it again revokes all tokens issued since login.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	accessToken, next, err := s.authRepo.Refresh(ctx, refreshToken)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("refresh error: %w", err)
+	}
+
+	return Tokens{AccessToken: accessToken, RefreshToken: next}, nil
+}
+
+// Resume continues the login session saved by the previous run.
+// The access token is refreshed if it is about to expire,
+// otherwise the tokens are returned as is.
+func (s *AuthService) Resume(ctx context.Context, tokens Tokens) (Tokens, error) {
+	// The token is verified by the service, the client only needs to know its expiration.
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &claims); err == nil &&
+		claims.ExpiresAt != nil &&
+		time.Until(claims.ExpiresAt.Time) > expiryMargin {
+		return tokens, nil
+	}
+
+	tokens, err := s.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("resume error: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Logout revokes the tokens of the session on the server,
+// so they couldn't be used even if leaked.
+func (s *AuthService) Logout(ctx context.Context, tokens Tokens) error {
+	if err := s.authRepo.Logout(ctx, tokens.AccessToken, tokens.RefreshToken); err != nil {
+		return fmt.Errorf("logout error: %w", err)
+	}
+
+	return nil
+}
+
+// upgrade logs in a user registered before SRP with the security key
+// and sets the verifier of the auth subkey.
+// Security key of a legacy user is the master key itself,
+// so the verifier is derived from the upgraded auth subkey.
+func (s *AuthService) upgrade(
+	ctx context.Context,
+	username string,
+	master encryption.Key,
+	keys encryption.Keys,
+	schedule encryption.KeySchedule,
+) (Tokens, error) {
+	authKey := keys.Auth
+
+	if schedule == encryption.KeyScheduleLegacy {
+		upgraded, err := master.Subkeys(encryption.KeyScheduleAuthSubkey)
+		if err != nil {
+			return Tokens{}, err
+		}
+
+		authKey = upgraded.Auth
+	}
+
+	verifier, err := newVerifier(authKey)
+	if err != nil {
+		return Tokens{}, err
+	}
 
-	token, err := s.authRepo.Login(ctx, username, securityKey)
+	resp, err := s.authRepo.Login(ctx, username, keys.Auth, verifier)
 	if err != nil {
-		return "", fmt.Errorf("login error: %w", err)
+		return Tokens{}, err
+	}
+
+	tokens := Tokens{
+		AccessToken:  resp.GetAccessToken(),
+		RefreshToken: resp.GetRefreshToken(),
+		PartialToken: resp.GetPartialToken(),
 	}
 
-	return token, nil
+	return tokens, nil
 }
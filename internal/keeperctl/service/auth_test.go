@@ -3,50 +3,518 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
 func TestLogin(t *testing.T) {
-	key := newTestKey()
+	expected := newTestKeys()
 
 	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+	newFakeSRPServer(t, expected.Auth).expectLogin(m, gophtest.AccessToken)
+
+	sat := service.NewAuthService(m)
+	tokens, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, tokens.AccessToken)
+	require.Equal(t, gophtest.RefreshToken, tokens.RefreshToken)
+	require.Equal(t, expected.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestLoginWithKeyFile(t *testing.T) {
+	expected := newTestKeysWithKeyFile(t)
+
+	kdf := newTestProtoKDFParams()
+	kdf.KeyFile = true
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(kdf, false, nil)
+	newFakeSRPServer(t, expected.Auth).expectLogin(m, gophtest.AccessToken)
+
+	sat := service.NewAuthService(m)
+	_, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, newTestKeyFile(t), false)
+
+	require.NoError(t, err)
+	require.Equal(t, expected.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestLoginWithMismatchedKeyFile(t *testing.T) {
+	tt := []struct {
+		name     string
+		keyFile  encryption.KeyFile
+		required bool
+		expected error
+	}{
+		{
+			name:     "Login fails if key file is required, but not provided",
+			keyFile:  encryption.KeyFile{},
+			required: true,
+			expected: encryption.ErrKeyFileRequired,
+		},
+		{
+			name:     "Login fails if key file is provided, but not used",
+			keyFile:  newTestKeyFile(t),
+			required: false,
+			expected: encryption.ErrKeyFileNotUsed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			kdf := newTestProtoKDFParams()
+			kdf.KeyFile = tc.required
+
+			m := &repo.AuthRepoMock{}
+			m.On("Prelogin", mock.Anything, gophtest.Username).
+				Return(kdf, false, nil)
+
+			sat := service.NewAuthService(m)
+			_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, tc.keyFile, false)
+
+			require.ErrorIs(t, err, tc.expected)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginOfLegacyUser(t *testing.T) {
+	expected, err := encryption.NewKey(
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		encryption.KDFParams{Algorithm: encryption.KDFSHA256},
+	)
+	require.NoError(t, err)
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(&p.KDFParams{Algorithm: p.KDFAlgorithm_KDF_SHA256}, true, nil)
 	m.On(
 		"Login",
 		mock.Anything,
 		gophtest.Username,
-		key.Hash(),
+		gophtest.SecurityKey,
+		verifierOf(gophtest.AuthKey),
 	).
-		Return(gophtest.AccessToken, nil)
+		Return(&p.LoginResponse{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken}, nil)
 
 	sat := service.NewAuthService(m)
-	token, err := sat.Login(context.Background(), gophtest.Username, key)
+	_, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, true)
 
 	require.NoError(t, err)
-	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, expected, key)
 	m.AssertExpectations(t)
 }
 
-func TestLoginOnRepoFailure(t *testing.T) {
-	key := newTestKey()
+func TestLoginOfLegacyUserWithoutPermission(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(&p.KDFParams{Algorithm: p.KDFAlgorithm_KDF_SHA256}, true, nil)
+
+	sat := service.NewAuthService(m)
+	_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	// The security key is never sent.
+	require.ErrorIs(t, err, service.ErrLegacyLogin)
+	m.AssertExpectations(t)
+}
+
+func TestLoginOfUpgradedLegacyUser(t *testing.T) {
+	expected, err := encryption.NewKey(
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		encryption.KDFParams{Algorithm: encryption.KDFSHA256},
+	)
+	require.NoError(t, err)
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(&p.KDFParams{
+			Algorithm:   p.KDFAlgorithm_KDF_SHA256,
+			KeySchedule: p.KeySchedule_KEY_SCHEDULE_AUTH_SUBKEY,
+		}, false, nil)
+	newFakeSRPServer(t, gophtest.AuthKey).expectLogin(m, gophtest.AccessToken)
+
+	sat := service.NewAuthService(m)
+	_, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, key)
+	m.AssertExpectations(t)
+}
+
+func TestLoginOnPreloginFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(nil, false, gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.Error(t, err)
+	m.AssertExpectations(t)
+}
 
+func TestLoginWithUnsupportedKDF(t *testing.T) {
 	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(&p.KDFParams{Algorithm: p.KDFAlgorithm(42)}, false, nil)
+
+	sat := service.NewAuthService(m)
+	_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
+	m.AssertExpectations(t)
+}
+
+func TestLoginOfLegacyUserWithSubkeys(t *testing.T) {
+	expected := newTestKeys()
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), true, nil)
 	m.On(
 		"Login",
 		mock.Anything,
 		gophtest.Username,
-		key.Hash(),
+		expected.Auth,
+		verifierOf(expected.Auth),
 	).
-		Return("", gophtest.ErrUnexpected)
+		Return(&p.LoginResponse{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken}, nil)
 
 	sat := service.NewAuthService(m)
-	_, err := sat.Login(context.Background(), gophtest.Username, key)
+	tokens, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, true)
 
-	require.Error(t, err)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, tokens.AccessToken)
+	require.Equal(t, gophtest.RefreshToken, tokens.RefreshToken)
+	require.Equal(t, expected.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestLoginWithWrongServerProof(t *testing.T) {
+	keys := newTestKeys()
+	server := newFakeSRPServer(t, keys.Auth)
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+	m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	m.On("FinishLogin", mock.Anything, mock.Anything).
+		Return(&p.FinishLoginResponse{AccessToken: gophtest.AccessToken, ServerProof: []byte(gophtest.ServerProof)}, nil)
+
+	sat := service.NewAuthService(m)
+	_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.ErrorIs(t, err, srp.ErrInvalidProof)
+	m.AssertExpectations(t)
+}
+
+func TestLoginWithInvalidServerPublic(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+	m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+		Return(&p.SRPChallenge{Salt: []byte(gophtest.SRPSalt), ServerPublic: make([]byte, srp.PublicLength)}, nil)
+
+	sat := service.NewAuthService(m)
+	_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.ErrorIs(t, err, srp.ErrInvalidPublic)
+	m.AssertExpectations(t)
+}
+
+func TestLoginOnRepoFailure(t *testing.T) {
+	tt := []struct {
+		name  string
+		setup func(m *repo.AuthRepoMock)
+	}{
+		{
+			name: "Login fails on legacy login failure",
+			setup: func(m *repo.AuthRepoMock) {
+				m.On("Prelogin", mock.Anything, gophtest.Username).
+					Return(newTestProtoKDFParams(), true, nil)
+				m.On("Login", mock.Anything, gophtest.Username, mock.Anything, mock.Anything).
+					Return(nil, gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Login fails on start of handshake failure",
+			setup: func(m *repo.AuthRepoMock) {
+				m.On("Prelogin", mock.Anything, gophtest.Username).
+					Return(newTestProtoKDFParams(), false, nil)
+				m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+					Return(nil, gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Login fails on finish of handshake failure",
+			setup: func(m *repo.AuthRepoMock) {
+				server := newFakeSRPServer(t, newTestKeys().Auth)
+
+				m.On("Prelogin", mock.Anything, gophtest.Username).
+					Return(newTestProtoKDFParams(), false, nil)
+				m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+					Run(func(args mock.Arguments) {
+						server.start(args.Get(2).([]byte))
+					}).
+					Return(server.challenge, nil)
+				m.On("FinishLogin", mock.Anything, mock.Anything).
+					Return(nil, gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.AuthRepoMock{}
+			tc.setup(m)
+
+			sat := service.NewAuthService(m)
+			_, _, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, true)
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginWithSecondFactor(t *testing.T) {
+	keys := newTestKeys()
+	server := newFakeSRPServer(t, keys.Auth)
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+	m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+
+	call := m.On("FinishLogin", mock.Anything, mock.Anything)
+	call.Run(func(args mock.Arguments) {
+		resp := &p.FinishLoginResponse{
+			ServerProof:  server.finish(args.Get(1).(*p.SRPProof)),
+			PartialToken: gophtest.PartialToken,
+		}
+
+		call.ReturnArguments = mock.Arguments{resp, nil}
+	})
+
+	sat := service.NewAuthService(m)
+	tokens, key, err := sat.Login(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{}, false)
+
+	require.NoError(t, err)
+	require.Equal(t, service.Tokens{PartialToken: gophtest.PartialToken}, tokens)
+	require.Equal(t, keys.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestUnlock(t *testing.T) {
+	expected := newTestKeys()
+
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	sat := service.NewAuthService(m)
+	key, err := sat.Unlock(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{})
+
+	require.NoError(t, err)
+	require.Equal(t, expected.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestUnlockOnPreloginFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(nil, false, gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	_, err := sat.Unlock(context.Background(), gophtest.Username, gophtest.Password, encryption.KeyFile{})
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("VerifySecondFactor", mock.Anything, gophtest.PartialToken, gophtest.OTPCode).
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
+
+	sat := service.NewAuthService(m)
+	tokens, err := sat.VerifySecondFactor(context.Background(), gophtest.PartialToken, gophtest.OTPCode)
+
+	require.NoError(t, err)
+	require.Equal(t, service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken}, tokens)
+	m.AssertExpectations(t)
+}
+
+func TestVerifySecondFactorOnRepoFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("VerifySecondFactor", mock.Anything, gophtest.PartialToken, gophtest.OTPCode).
+		Return("", "", gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	_, err := sat.VerifySecondFactor(context.Background(), gophtest.PartialToken, gophtest.OTPCode)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRefresh(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Refresh", mock.Anything, gophtest.RefreshToken).
+		Return(gophtest.AccessToken, "next", nil)
+
+	sat := service.NewAuthService(m)
+	tokens, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+	require.NoError(t, err)
+	require.Equal(t, service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: "next"}, tokens)
+	m.AssertExpectations(t)
+}
+
+func TestRefreshOnRepoFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Refresh", mock.Anything, gophtest.RefreshToken).
+		Return("", "", gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	_, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func newTestAccessToken(t *testing.T, expiresIn time.Duration) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}).SignedString([]byte(gophtest.Secret))
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestResume(t *testing.T) {
+	expected := service.Tokens{
+		AccessToken:  newTestAccessToken(t, 10*time.Minute),
+		RefreshToken: gophtest.RefreshToken,
+	}
+
+	m := &repo.AuthRepoMock{}
+
+	sat := service.NewAuthService(m)
+	tokens, err := sat.Resume(context.Background(), expected)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, tokens)
+	m.AssertExpectations(t)
+}
+
+func TestResumeRefreshesToken(t *testing.T) {
+	tt := []struct {
+		name        string
+		accessToken string
+	}{
+		{
+			name:        "Resume refreshes expired access token",
+			accessToken: newTestAccessToken(t, -time.Minute),
+		},
+		{
+			name:        "Resume refreshes access token about to expire",
+			accessToken: newTestAccessToken(t, 10*time.Second),
+		},
+		{
+			name:        "Resume refreshes malformed access token",
+			accessToken: gophtest.AccessToken,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.AuthRepoMock{}
+			m.On("Refresh", mock.Anything, gophtest.RefreshToken).
+				Return(gophtest.AccessToken, "next", nil)
+
+			sat := service.NewAuthService(m)
+			tokens, err := sat.Resume(
+				context.Background(),
+				service.Tokens{AccessToken: tc.accessToken, RefreshToken: gophtest.RefreshToken},
+			)
+
+			require.NoError(t, err)
+			require.Equal(t, service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: "next"}, tokens)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResumeOnRepoFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Refresh", mock.Anything, gophtest.RefreshToken).
+		Return("", "", gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	_, err := sat.Resume(
+		context.Background(),
+		service.Tokens{AccessToken: newTestAccessToken(t, -time.Minute), RefreshToken: gophtest.RefreshToken},
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestLogout(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Logout", mock.Anything, gophtest.AccessToken, gophtest.RefreshToken).
+		Return(nil)
+
+	sat := service.NewAuthService(m)
+	err := sat.Logout(
+		context.Background(),
+		service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken},
+	)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestLogoutOnRepoFailure(t *testing.T) {
+	m := &repo.AuthRepoMock{}
+	m.On("Logout", mock.Anything, gophtest.AccessToken, gophtest.RefreshToken).
+		Return(gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(m)
+	err := sat.Logout(
+		context.Background(),
+		service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken},
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
 	m.AssertExpectations(t)
 }
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+)
+
+// BlobChunkSize is size of plain text chunks a file is split into before encryption.
+const BlobChunkSize = 1 << 20
+
+// chunkSealer splits data read from the source into chunks and encrypts them one by one.
+// One chunk is read ahead to know which chunk is the last one,
+// empty source results in single empty last chunk.
+type chunkSealer struct {
+	cipher secretCipher
+	src    io.Reader
+
+	seq     int
+	started bool
+	done    bool
+
+	// Chunk read ahead and whether the source has ended after it.
+	pending    []byte
+	pendingEOF bool
+}
+
+func newChunkSealer(c secretCipher, src io.Reader) *chunkSealer {
+	return &chunkSealer{cipher: c, src: src}
+}
+
+// next returns next encrypted chunk or io.EOF after the last one.
+func (s *chunkSealer) next() ([]byte, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	if !s.started {
+		chunk, eof, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+
+		s.pending, s.pendingEOF, s.started = chunk, eof, true
+	}
+
+	chunk, last := s.pending, s.pendingEOF
+
+	if !last {
+		ahead, eof, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+
+		if eof && len(ahead) == 0 {
+			last = true
+		} else {
+			s.pending, s.pendingEOF = ahead, eof
+		}
+	}
+
+	sealed, err := s.cipher.seal(chunkField(s.seq, last), chunk)
+	if err != nil {
+		return nil, fmt.Errorf("chunkSealer - next - s.cipher.seal: %w", err)
+	}
+
+	s.seq++
+	s.done = last
+
+	return sealed, nil
+}
+
+// read reads up to BlobChunkSize bytes and reports whether the source has ended.
+func (s *chunkSealer) read() ([]byte, bool, error) {
+	buf := make([]byte, BlobChunkSize)
+
+	n, err := io.ReadFull(s.src, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return buf[:n], true, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("chunkSealer - read - io.ReadFull: %w", err)
+	}
+
+	return buf, false, nil
+}
+
+// chunkReader decrypts chunks of a blob as they are read.
+// One chunk is fetched ahead to know which chunk is the last one,
+// encryption.ErrIntegrity is returned if any chunk was tampered with,
+// reordered or the blob was truncated.
+type chunkReader struct {
+	cipher secretCipher
+	next   func() ([]byte, error)
+
+	seq     int
+	started bool
+	done    bool
+	err     error
+
+	// Decrypted data not read yet and encrypted chunk fetched ahead.
+	buf   []byte
+	ahead []byte
+}
+
+func newChunkReader(c secretCipher, next func() ([]byte, error)) *chunkReader {
+	return &chunkReader{cipher: c, next: next}
+}
+
+// Read implements io.Reader.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		if r.done {
+			return 0, io.EOF
+		}
+
+		r.err = r.advance()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// advance decrypts next chunk of the blob.
+func (r *chunkReader) advance() error {
+	if !r.started {
+		ahead, err := r.fetch()
+		if err != nil {
+			return err
+		}
+
+		r.ahead, r.started = ahead, true
+	}
+
+	if r.ahead == nil {
+		return fmt.Errorf("chunkReader - advance - blob is truncated: %w", encryption.ErrIntegrity)
+	}
+
+	chunk := r.ahead
+
+	ahead, err := r.fetch()
+	if err != nil {
+		return err
+	}
+
+	r.ahead = ahead
+	last := ahead == nil
+
+	r.buf, err = r.cipher.open(chunkField(r.seq, last), chunk)
+	if err != nil {
+		return fmt.Errorf("chunkReader - advance - r.cipher.open: %w", err)
+	}
+
+	r.seq++
+	r.done = last
+
+	return nil
+}
+
+// fetch returns next encrypted chunk or nil at the end of the blob.
+func (r *chunkReader) fetch() ([]byte, error) {
+	chunk, err := r.next()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("chunkReader - fetch - r.next: %w", err)
+	}
+
+	if chunk == nil {
+		chunk = []byte{}
+	}
+
+	return chunk, nil
+}
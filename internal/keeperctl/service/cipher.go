@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+// Roles of encrypted fields of a secret.
+const (
+	fieldDataKey  = "data_key"
+	fieldName     = "name"
+	fieldMetadata = "metadata"
+	fieldData     = "data"
+	fieldChunk    = "chunk"
+)
+
+// associatedData binds encrypted field to the secret,
+// so encrypted values can't be swapped between secrets or fields
+// and kind of the secret can't be changed.
+func associatedData(id string, kind p.DataKind, field string) []byte {
+	return []byte(fmt.Sprintf("gophkeeper:%s:%d:%s", id, kind, field))
+}
+
+// chunkField binds encrypted chunk of a blob to its position,
+// so chunks can't be reordered, dropped or appended after the last one.
+func chunkField(seq int, last bool) string {
+	if last {
+		return fmt.Sprintf("%s:%d:last", fieldChunk, seq)
+	}
+
+	return fmt.Sprintf("%s:%d", fieldChunk, seq)
+}
+
+// secretCipher encrypts content of a particular secret with its data key.
+// Legacy secrets are encrypted by the vault key directly without associated data.
+// The legacy format is accepted only if allowLegacy is set,
+// as the service could strip the envelope off any secret otherwise.
+type secretCipher struct {
+	key         encryption.Key
+	id          string
+	kind        p.DataKind
+	legacy      bool
+	allowLegacy bool
+}
+
+// newSecretCipher generates new data key for the secret.
+// Returns the data key wrapped by the vault key as well.
+func newSecretCipher(
+	vault encryption.Key,
+	id string,
+	kind p.DataKind,
+) (secretCipher, []byte, error) {
+	c := secretCipher{id: id, kind: kind}
+
+	key, err := encryption.NewDataKey()
+	if err != nil {
+		return c, nil, fmt.Errorf("encryption.NewDataKey: %w", err)
+	}
+
+	c.key = key
+
+	wrappedKey, err := c.wrap(vault)
+	if err != nil {
+		return c, nil, err
+	}
+
+	return c, wrappedKey, nil
+}
+
+// openSecretCipher unwraps data key of the secret.
+// Secret without data key is legacy one, it is opened only if allowLegacy is set,
+// otherwise ErrNotMigrated is returned.
+func openSecretCipher(vault encryption.Key, secret *p.Secret, allowLegacy bool) (secretCipher, error) {
+	c := secretCipher{id: secret.GetId(), kind: secret.GetKind(), allowLegacy: allowLegacy}
+
+	if len(secret.GetDataKey()) == 0 {
+		if !allowLegacy {
+			return c, ErrNotMigrated
+		}
+
+		c.key = vault
+		c.legacy = true
+
+		return c, nil
+	}
+
+	key, err := vault.Unwrap(secret.GetDataKey(), associatedData(c.id, c.kind, fieldDataKey))
+	if err != nil {
+		return c, fmt.Errorf("vault.Unwrap: %w", err)
+	}
+
+	c.key = key
+
+	return c, nil
+}
+
+// wrap encrypts the data key of the secret with the vault key.
+func (c secretCipher) wrap(vault encryption.Key) ([]byte, error) {
+	wrappedKey, err := vault.Wrap(c.key, associatedData(c.id, c.kind, fieldDataKey))
+	if err != nil {
+		return nil, fmt.Errorf("vault.Wrap: %w", err)
+	}
+
+	return wrappedKey, nil
+}
+
+// sealName encrypts name of the secret and computes its blind index with the vault key.
+func (c secretCipher) sealName(vault encryption.Key, name string) ([]byte, []byte, error) {
+	index, err := vault.BlindIndex(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("vault.BlindIndex: %w", err)
+	}
+
+	encName, err := c.seal(fieldName, []byte(name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("c.seal(name): %w", err)
+	}
+
+	return encName, index, nil
+}
+
+// openName decrypts name of the secret.
+// Names of legacy secrets without blind index are stored in plain text,
+// they are accepted only if legacy secrets are allowed, otherwise ErrNotMigrated is returned.
+func (c secretCipher) openName(secret *p.Secret) ([]byte, error) {
+	if len(secret.GetNameIndex()) == 0 {
+		if !c.allowLegacy {
+			return nil, ErrNotMigrated
+		}
+
+		return secret.GetName(), nil
+	}
+
+	return c.open(fieldName, secret.GetName())
+}
+
+// seal encrypts the field of the secret.
+func (c secretCipher) seal(field string, data []byte) ([]byte, error) {
+	if c.legacy {
+		return c.key.Encrypt(data)
+	}
+
+	return c.key.Seal(data, associatedData(c.id, c.kind, field))
+}
+
+// open decrypts the field of the secret.
+// Returns encryption.ErrIntegrity if the field was tampered with.
+func (c secretCipher) open(field string, data []byte) ([]byte, error) {
+	if c.legacy {
+		return c.key.Decrypt(data)
+	}
+
+	return c.key.Open(data, associatedData(c.id, c.kind, field))
+}
@@ -1,10 +1,224 @@
 package service_test
 
 import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+func newTestKDFParams() encryption.KDFParams {
+	return encryption.KDFParams{
+		Algorithm: encryption.KDFArgon2id,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		Schedule: encryption.KeyScheduleSubkeys,
+	}
+}
+
+func newTestProtoKDFParams() *p.KDFParams {
+	return &p.KDFParams{
+		Algorithm: p.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: p.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func newTestKeys() encryption.Keys {
+	master, err := encryption.NewKey(gophtest.Username, gophtest.Password, encryption.KeyFile{}, newTestKDFParams())
+	if err != nil {
+		panic(err)
+	}
+
+	keys, err := master.Subkeys(encryption.KeyScheduleSubkeys)
+	if err != nil {
+		panic(err)
+	}
+
+	return keys
+}
+
+// Associated data of the keys in the recovery kit.
+const (
+	recoveryVaultKeyAD    = "gophkeeper:recovery:vault_key"
+	recoveryRecoveryKeyAD = "gophkeeper:recovery:recovery_key"
 )
 
+func newTestRecoveryKeys(t *testing.T, code string) encryption.Keys {
+	t.Helper()
+
+	recovery, err := encryption.NewRecoveryKey(code)
+	require.NoError(t, err)
+
+	keys, err := recovery.Subkeys(encryption.KeyScheduleSubkeys)
+	require.NoError(t, err)
+
+	return keys
+}
+
+func newTestKeyFile(t *testing.T) encryption.KeyFile {
+	t.Helper()
+
+	keyFile, err := encryption.NewKeyFile([]byte(gophtest.KeyFile))
+	require.NoError(t, err)
+
+	return keyFile
+}
+
+func newTestKeysWithKeyFile(t *testing.T) encryption.Keys {
+	t.Helper()
+
+	kdf := newTestKDFParams()
+	kdf.KeyFile = true
+
+	master, err := encryption.NewKey(gophtest.Username, gophtest.Password, newTestKeyFile(t), kdf)
+	require.NoError(t, err)
+
+	keys, err := master.Subkeys(encryption.KeyScheduleSubkeys)
+	require.NoError(t, err)
+
+	return keys
+}
+
 func newTestKey() encryption.Key {
-	return encryption.NewKey(gophtest.Username, gophtest.Password)
+	return newTestKeys().Vault
+}
+
+func newTestAssociatedData(id string, kind p.DataKind, field string) []byte {
+	return []byte(fmt.Sprintf("gophkeeper:%s:%d:%s", id, kind, field))
+}
+
+// verifierOf matches SRP verifier derived from the auth key with any salt.
+func verifierOf(authKey string) any {
+	return mock.MatchedBy(func(v *p.SRPVerifier) bool {
+		return bytes.Equal(srp.Verifier(v.GetSalt(), []byte(authKey)), v.GetVerifier())
+	})
+}
+
+// fakeSRPServer plays the service side of SRP handshake
+// with the verifier of the auth key.
+type fakeSRPServer struct {
+	t         *testing.T
+	verifier  []byte
+	handshake srp.Handshake
+
+	// Challenge is filled on start, so it can be returned by a mock.
+	challenge *p.SRPChallenge
+}
+
+func newFakeSRPServer(t *testing.T, authKey string) *fakeSRPServer {
+	t.Helper()
+
+	salt := []byte(gophtest.SRPSalt)
+
+	return &fakeSRPServer{
+		t:         t,
+		verifier:  srp.Verifier(salt, []byte(authKey)),
+		challenge: &p.SRPChallenge{HandshakeId: uuid.NewString(), Salt: salt},
+	}
+}
+
+// start responds to the client public ephemeral value.
+func (s *fakeSRPServer) start(clientPublic []byte) {
+	h, err := srp.NewHandshake(s.verifier, clientPublic)
+	require.NoError(s.t, err)
+
+	s.handshake = h
+	s.challenge.ServerPublic = h.ServerPublic
+}
+
+// finish checks the client proof and returns the server proof.
+func (s *fakeSRPServer) finish(proof *p.SRPProof) []byte {
+	require.Equal(s.t, s.challenge.GetHandshakeId(), proof.GetHandshakeId())
+	require.NoError(s.t, s.handshake.VerifyClient(proof.GetClientProof()))
+
+	return s.handshake.ServerProof
+}
+
+// expectLogin sets up SRP login of a user to the fake server.
+func (s *fakeSRPServer) expectLogin(m *repo.AuthRepoMock, token string) {
+	m.On("StartLogin", mock.Anything, gophtest.Username, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s.start(args.Get(2).([]byte))
+		}).
+		Return(s.challenge, nil)
+
+	call := m.On("FinishLogin", mock.Anything, mock.Anything)
+	call.Run(func(args mock.Arguments) {
+		resp := &p.FinishLoginResponse{
+			AccessToken:  token,
+			RefreshToken: gophtest.RefreshToken,
+			ServerProof:  s.finish(args.Get(1).(*p.SRPProof)),
+		}
+
+		call.ReturnArguments = mock.Arguments{resp, nil}
+	})
+}
+
+// expectDelete sets up SRP handshake on deletion of the user to the fake server.
+func (s *fakeSRPServer) expectDelete(m *repo.UsersRepoMock) {
+	m.On("StartDelete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s.start(args.Get(2).([]byte))
+		}).
+		Return(s.challenge, nil)
+
+	call := m.On("Delete", mock.Anything, gophtest.AccessToken, mock.Anything)
+	call.Run(func(args mock.Arguments) {
+		call.ReturnArguments = mock.Arguments{s.finish(args.Get(2).(*p.SRPProof)), nil}
+	})
+}
+
+// expectRename sets up SRP handshake on rename of the user to the fake server.
+// The server proof is returned by the call of Rename after run.
+func (s *fakeSRPServer) expectRename(m *repo.UsersRepoMock, call *mock.Call, run func(args mock.Arguments)) {
+	m.On("StartRename", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s.start(args.Get(2).([]byte))
+		}).
+		Return(s.challenge, nil)
+
+	call.Run(func(args mock.Arguments) {
+		run(args)
+
+		call.ReturnArguments = mock.Arguments{s.finish(args.Get(3).(*p.SRPProof)), nil}
+	})
+}
+
+// expectChangePassword sets up SRP handshake on password change to the fake server.
+// The server proof is returned by the call of ChangePassword after run.
+func (s *fakeSRPServer) expectChangePassword(
+	m *repo.UsersRepoMock,
+	call *mock.Call,
+	run func(args mock.Arguments),
+) {
+	m.On("StartChangePassword", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s.start(args.Get(2).([]byte))
+		}).
+		Return(s.challenge, nil)
+
+	call.Run(func(args mock.Arguments) {
+		if run != nil {
+			run(args)
+		}
+
+		call.ReturnArguments = mock.Arguments{s.finish(args.Get(2).(*p.SRPProof)), nil}
+	})
 }
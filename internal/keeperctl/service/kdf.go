@@ -0,0 +1,34 @@
+package service
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+// kdfParamsFromProto converts key derivation parameters received from the server.
+func kdfParamsFromProto(kdf *p.KDFParams) encryption.KDFParams {
+	return encryption.KDFParams{
+		Algorithm: encryption.KDFAlgorithm(kdf.GetAlgorithm()),
+		Salt:      kdf.GetSalt(),
+		Time:      kdf.GetTime(),
+		Memory:    kdf.GetMemory(),
+		Threads:   uint8(min(kdf.GetThreads(), 255)),
+
+		Schedule: encryption.KeySchedule(kdf.GetKeySchedule()),
+		KeyFile:  kdf.GetKeyFile(),
+	}
+}
+
+// kdfParamsToProto converts key derivation parameters to send them to the server.
+func kdfParamsToProto(kdf encryption.KDFParams) *p.KDFParams {
+	return &p.KDFParams{
+		Algorithm: p.KDFAlgorithm(kdf.Algorithm),
+		Salt:      kdf.Salt,
+		Time:      kdf.Time,
+		Memory:    kdf.Memory,
+		Threads:   uint32(kdf.Threads),
+
+		KeySchedule: p.KeySchedule(kdf.Schedule),
+		KeyFile:     kdf.KeyFile,
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+// Associated data of the keys wrapped in the recovery kit,
+// so the vault key couldn't be swapped with the recovery key.
+var (
+	recoveryVaultKeyAD    = []byte("gophkeeper:recovery:vault_key")
+	recoveryRecoveryKeyAD = []byte("gophkeeper:recovery:recovery_key")
+)
+
+// recoveryKeys derives keys from the recovery code the same way as from the master key.
+// Auth is sent to the service, Vault wraps the vault key.
+func recoveryKeys(code string) (encryption.Keys, error) {
+	var keys encryption.Keys
+
+	recovery, err := encryption.NewRecoveryKey(code)
+	if err != nil {
+		return keys, err
+	}
+
+	return recovery.Subkeys(encryption.KeyScheduleSubkeys)
+}
+
+// newRecoveryKit generates new recovery code and the recovery kit of the vault key.
+func newRecoveryKit(vault encryption.Key) (string, *p.RecoveryKit, error) {
+	code, err := encryption.NewRecoveryCode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	keys, err := recoveryKeys(code)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kit, err := wrapRecoveryKit(keys.Vault, vault)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kit.SecurityKey = keys.Auth
+
+	return code, kit, nil
+}
+
+// wrapRecoveryKit wraps the vault key with the recovery key and vice versa.
+func wrapRecoveryKit(recovery, vault encryption.Key) (*p.RecoveryKit, error) {
+	vaultKey, err := recovery.Wrap(vault, recoveryVaultKeyAD)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryKey, err := vault.Wrap(recovery, recoveryRecoveryKeyAD)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p.RecoveryKit{VaultKey: vaultKey, RecoveryKey: recoveryKey}, nil
+}
+
+// rewrapRecoveryKit unwraps the recovery key with the old vault key
+// and wraps the recovery kit with the new one.
+// Returns nil if recovery is not set up.
+func rewrapRecoveryKit(oldVault, newVault encryption.Key, recoveryKey []byte) (*p.RecoveryKit, error) {
+	if len(recoveryKey) == 0 {
+		return nil, nil
+	}
+
+	recovery, err := oldVault.Unwrap(recoveryKey, recoveryRecoveryKeyAD)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapRecoveryKit(recovery, newVault)
+}
@@ -2,34 +2,53 @@ package service
 
 import (
 	"context"
-	"errors"
+	stderrors "errors"
 	"fmt"
-	"reflect"
+	"io"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
 var _ Secrets = (*SecretsService)(nil)
 
-var ErrKindMismatch = errors.New("secret kind doesn't match")
+var (
+	ErrKindMismatch = stderrors.New("secret kind doesn't match")
+	ErrBlobSecret   = stderrors.New("secret is stored as blob and should be streamed")
+
+	// ErrNotMigrated is returned for a secret in the format preceding envelope or name encryption,
+	// if the account is not marked as having such secrets, as the service may have downgraded it.
+	ErrNotMigrated = stderrors.New(
+		"secret is not migrated to envelope encryption, but the account is not marked as legacy",
+	)
+)
+
+// UpdateAttempts is the number of times changes of a secret are applied
+// to its fresh state if the secret is concurrently modified by other clients.
+const UpdateAttempts = 3
 
 // SecretsService contains business logic related to secrets management.
+// Legacy secrets are opened only if allowLegacy is set, see openSecretCipher.
 type SecretsService struct {
 	key         encryption.Key
+	allowLegacy bool
 	secretsRepo repo.Secrets
 }
 
 // NewSecretsService create and initializes new SecretsService object.
-func NewSecretsService(key encryption.Key, secrets repo.Secrets) *SecretsService {
-	return &SecretsService{key, secrets}
+func NewSecretsService(key encryption.Key, allowLegacy bool, secrets repo.Secrets) *SecretsService {
+	return &SecretsService{key, allowLegacy, secrets}
 }
 
 // push is low level function sending generic secret creation message to keeper.
+// Name, description and data are encrypted with new data key wrapped by the vault key
+// and bound to ID of the secret chosen by client.
 func (s *SecretsService) push(
 	ctx context.Context,
 	token string,
@@ -38,25 +57,44 @@ func (s *SecretsService) push(
 	description string,
 	data proto.Message,
 ) (uuid.UUID, error) {
-	var id uuid.UUID
+	id := uuid.New()
 
 	rawData, err := proto.Marshal(data)
 	if err != nil {
 		return id, fmt.Errorf("SecretsService - push - proto.Marshal: %w", err)
 	}
 
-	encData, err := s.key.Encrypt(rawData)
+	c, wrappedKey, err := newSecretCipher(s.key, id.String(), kind)
 	if err != nil {
-		return id, fmt.Errorf("SecretsService - push - uc.key.Encrypt(data): %w", err)
+		return id, fmt.Errorf("SecretsService - push - newSecretCipher: %w", err)
 	}
 
-	encDescription, err := s.key.Encrypt([]byte(description))
+	encName, nameIndex, err := c.sealName(s.key, name)
 	if err != nil {
-		return id, fmt.Errorf("SecretsService - push - uc.key.Encrypt(description): %w", err)
+		return id, fmt.Errorf("SecretsService - push - c.sealName: %w", err)
 	}
 
-	id, err = s.secretsRepo.Push(ctx, token, name, kind, encDescription, encData)
+	encData, err := c.seal(fieldData, rawData)
 	if err != nil {
+		return id, fmt.Errorf("SecretsService - push - c.seal(data): %w", err)
+	}
+
+	encDescription, err := c.seal(fieldMetadata, []byte(description))
+	if err != nil {
+		return id, fmt.Errorf("SecretsService - push - c.seal(description): %w", err)
+	}
+
+	if err := s.secretsRepo.Push(
+		ctx,
+		token,
+		id,
+		encName,
+		nameIndex,
+		kind,
+		wrappedKey,
+		encDescription,
+		encData,
+	); err != nil {
 		return id, fmt.Errorf("SecretsService - push - uc.secretsRepo.Push: %w", err)
 	}
 
@@ -76,6 +114,46 @@ func (s *SecretsService) PushBinary(
 	return s.push(ctx, token, name, p.DataKind_BINARY, description, data)
 }
 
+// PushFile creates new binary secret streaming content of the source in encrypted chunks.
+// Unlike PushBinary the content is not limited by size of a single message.
+func (s *SecretsService) PushFile(
+	ctx context.Context,
+	token, name, description string,
+	src io.Reader,
+) (uuid.UUID, error) {
+	id := uuid.New()
+
+	c, wrappedKey, err := newSecretCipher(s.key, id.String(), p.DataKind_BINARY)
+	if err != nil {
+		return id, fmt.Errorf("SecretsService - PushFile - newSecretCipher: %w", err)
+	}
+
+	encName, nameIndex, err := c.sealName(s.key, name)
+	if err != nil {
+		return id, fmt.Errorf("SecretsService - PushFile - c.sealName: %w", err)
+	}
+
+	encDescription, err := c.seal(fieldMetadata, []byte(description))
+	if err != nil {
+		return id, fmt.Errorf("SecretsService - PushFile - c.seal(description): %w", err)
+	}
+
+	if err := s.secretsRepo.UploadBlob(
+		ctx,
+		token,
+		id,
+		encName,
+		nameIndex,
+		wrappedKey,
+		encDescription,
+		newChunkSealer(c, src).next,
+	); err != nil {
+		return id, fmt.Errorf("SecretsService - PushFile - uc.secretsRepo.UploadBlob: %w", err)
+	}
+
+	return id, nil
+}
+
 // PushCard creates new secret containing bank card data.
 func (s *SecretsService) PushCard(
 	ctx context.Context,
@@ -119,17 +197,34 @@ func (s *SecretsService) PushText(
 }
 
 // List returns list of user's secrets.
-// All sensitive parts are decrypted.
-func (s *SecretsService) List(ctx context.Context, token string) ([]*p.Secret, error) {
-	data, err := s.secretsRepo.List(ctx, token)
+// The list is narrowed to the given kinds if any and to limit secrets if limit is positive.
+// All sensitive parts are decrypted, encryption.ErrIntegrity is returned
+// if any of them was tampered with.
+func (s *SecretsService) List(
+	ctx context.Context,
+	token string,
+	kinds []p.DataKind,
+	limit int,
+) ([]*p.Secret, error) {
+	data, _, err := s.secretsRepo.List(ctx, token, kinds, limit)
 	if err != nil {
 		return nil, fmt.Errorf("SecretsService - List - uc.secretsRepo.List: %w", err)
 	}
 
 	for i, val := range data {
-		data[i].Metadata, err = s.key.Decrypt(val.GetMetadata())
+		c, err := openSecretCipher(s.key, val, s.allowLegacy)
 		if err != nil {
-			return nil, fmt.Errorf("SecretsService - List - uc.key.Decrypt: %w", err)
+			return nil, fmt.Errorf("SecretsService - List - openSecretCipher: %w", err)
+		}
+
+		data[i].Name, err = c.openName(val)
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - List - c.openName: %w", err)
+		}
+
+		data[i].Metadata, err = c.open(fieldMetadata, val.GetMetadata())
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - List - c.open: %w", err)
 		}
 	}
 
@@ -137,6 +232,10 @@ func (s *SecretsService) List(ctx context.Context, token string) ([]*p.Secret, e
 }
 
 // update is low level function sending generic secret update message to keeper.
+// The whole content is encrypted with new data key on every change,
+// so edit is applied to the current data of the secret.
+// If the secret is modified concurrently, the changes are applied to its fresh state again,
+// the conflict is returned after UpdateAttempts failed attempts.
 func (s *SecretsService) update(
 	ctx context.Context,
 	token string,
@@ -144,37 +243,88 @@ func (s *SecretsService) update(
 	name string,
 	description string,
 	noDescription bool,
-	data proto.Message,
+	edit func(data proto.Message) error,
 ) error {
-	var encData []byte
+	var err error
 
-	if data != nil && !reflect.ValueOf(data).IsNil() {
-		rawData, err := proto.Marshal(data)
-		if err != nil {
-			return fmt.Errorf("SecretsService - update - proto.Marshal: %w", err)
+	for range UpdateAttempts {
+		err = s.tryUpdate(ctx, token, id, name, description, noDescription, edit)
+		if !errors.HasCode(err, codes.Aborted) {
+			return err
 		}
+	}
 
-		encData, err = s.key.Encrypt(rawData)
-		if err != nil {
-			return fmt.Errorf("SecretsService - update - uc.key.Encrypt(data): %w", err)
+	return err
+}
+
+// tryUpdate reads the current version of the secret, applies the changes and sends it back.
+// The update is aborted by keeper if the secret was changed since the version was read.
+func (s *SecretsService) tryUpdate(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	name string,
+	description string,
+	noDescription bool,
+	edit func(data proto.Message) error,
+) error {
+	secret, data, err := s.Get(ctx, token, id)
+	if err != nil {
+		return fmt.Errorf("SecretsService - tryUpdate - uc.Get: %w", err)
+	}
+
+	if name == "" {
+		name = string(secret.GetName())
+	}
+
+	if description != "" || noDescription {
+		secret.Metadata = []byte(description)
+	}
+
+	if edit != nil {
+		if err := edit(data); err != nil {
+			return err
 		}
 	}
 
-	encDescription, err := s.key.Encrypt([]byte(description))
+	rawData, err := proto.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("SecretsService - tryUpdate - proto.Marshal: %w", err)
+	}
+
+	c, wrappedKey, err := newSecretCipher(s.key, secret.GetId(), secret.GetKind())
+	if err != nil {
+		return fmt.Errorf("SecretsService - tryUpdate - newSecretCipher: %w", err)
+	}
+
+	encName, nameIndex, err := c.sealName(s.key, name)
+	if err != nil {
+		return fmt.Errorf("SecretsService - tryUpdate - c.sealName: %w", err)
+	}
+
+	encData, err := c.seal(fieldData, rawData)
+	if err != nil {
+		return fmt.Errorf("SecretsService - tryUpdate - c.seal(data): %w", err)
+	}
+
+	encDescription, err := c.seal(fieldMetadata, secret.GetMetadata())
 	if err != nil {
-		return fmt.Errorf("SecretsService - update - uc.key.Encrypt(description): %w", err)
+		return fmt.Errorf("SecretsService - tryUpdate - c.seal(description): %w", err)
 	}
 
 	if err = s.secretsRepo.Update(
 		ctx,
 		token,
 		id,
-		name,
+		secret.GetVersion(),
+		encName,
+		nameIndex,
+		wrappedKey,
 		encDescription,
-		noDescription,
+		true,
 		encData,
 	); err != nil {
-		return fmt.Errorf("SecretsService - update - uc.secretsRepo.Update: %w", err)
+		return fmt.Errorf("SecretsService - tryUpdate - uc.secretsRepo.Update: %w", err)
 	}
 
 	return nil
@@ -189,23 +339,22 @@ func (s *SecretsService) EditBinary(
 	noDescription bool,
 	binary []byte,
 ) error {
-	if len(binary) == 0 {
-		return s.update(ctx, token, id, name, description, noDescription, nil)
-	}
+	var edit func(msg proto.Message) error
 
-	_, msg, err := s.Get(ctx, token, id)
-	if err != nil {
-		return fmt.Errorf("SecretsService - EditBinary - uc.Get: %w", err)
-	}
+	if len(binary) != 0 {
+		edit = func(msg proto.Message) error {
+			data, ok := msg.(*p.Binary)
+			if !ok {
+				return fmt.Errorf("SecretsService - EditBinary - msg.(*goph.Binary): %w", ErrKindMismatch)
+			}
 
-	data, ok := msg.(*p.Binary)
-	if !ok {
-		return fmt.Errorf("SecretsService - EditBinary - msg.(*goph.Binary): %w", ErrKindMismatch)
-	}
+			data.Binary = binary
 
-	data.Binary = binary
+			return nil
+		}
+	}
 
-	return s.update(ctx, token, id, name, description, noDescription, data)
+	return s.update(ctx, token, id, name, description, noDescription, edit)
 }
 
 // EditCard changes parameters of stored bank card.
@@ -218,37 +367,36 @@ func (s *SecretsService) EditCard(
 	number, expiration, holder string,
 	cvv int32,
 ) error {
-	if number == "" && expiration == "" && holder == "" && cvv == 0 {
-		return s.update(ctx, token, id, name, description, noDescription, nil)
-	}
+	var edit func(msg proto.Message) error
 
-	_, msg, err := s.Get(ctx, token, id)
-	if err != nil {
-		return fmt.Errorf("SecretsService - EditCard - uc.Get: %w", err)
-	}
+	if number != "" || expiration != "" || holder != "" || cvv != 0 {
+		edit = func(msg proto.Message) error {
+			data, ok := msg.(*p.Card)
+			if !ok {
+				return fmt.Errorf("SecretsService - EditCard - msg.(*goph.Card): %w", ErrKindMismatch)
+			}
 
-	data, ok := msg.(*p.Card)
-	if !ok {
-		return fmt.Errorf("SecretsService - EditCard - msg.(*goph.Card): %w", ErrKindMismatch)
-	}
+			if number != "" {
+				data.Number = number
+			}
 
-	if number != "" {
-		data.Number = number
-	}
+			if expiration != "" {
+				data.Expiration = expiration
+			}
 
-	if expiration != "" {
-		data.Expiration = expiration
-	}
+			if holder != "" {
+				data.Holder = holder
+			}
 
-	if holder != "" {
-		data.Holder = holder
-	}
+			if cvv != 0 {
+				data.Cvv = cvv
+			}
 
-	if cvv != 0 {
-		data.Cvv = cvv
+			return nil
+		}
 	}
 
-	return s.update(ctx, token, id, name, description, noDescription, data)
+	return s.update(ctx, token, id, name, description, noDescription, edit)
 }
 
 // EditCreds changes parameters of stored credentials.
@@ -260,29 +408,28 @@ func (s *SecretsService) EditCreds(
 	noDescription bool,
 	login, password string,
 ) error {
-	if login == "" && password == "" {
-		return s.update(ctx, token, id, name, description, noDescription, nil)
-	}
+	var edit func(msg proto.Message) error
 
-	_, msg, err := s.Get(ctx, token, id)
-	if err != nil {
-		return fmt.Errorf("SecretsService - EditCreds - uc.Get: %w", err)
-	}
+	if login != "" || password != "" {
+		edit = func(msg proto.Message) error {
+			data, ok := msg.(*p.Credentials)
+			if !ok {
+				return fmt.Errorf("SecretsService - EditCreds - msg.(*goph.Credentials): %w", ErrKindMismatch)
+			}
 
-	data, ok := msg.(*p.Credentials)
-	if !ok {
-		return fmt.Errorf("SecretsService - EditCreds - msg.(*goph.Credentials): %w", ErrKindMismatch)
-	}
+			if login != "" {
+				data.Login = login
+			}
 
-	if login != "" {
-		data.Login = login
-	}
+			if password != "" {
+				data.Password = password
+			}
 
-	if password != "" {
-		data.Password = password
+			return nil
+		}
 	}
 
-	return s.update(ctx, token, id, name, description, noDescription, data)
+	return s.update(ctx, token, id, name, description, noDescription, edit)
 }
 
 // EditText changes parameters of stored text secret.
@@ -294,45 +441,74 @@ func (s *SecretsService) EditText(
 	noDescription bool,
 	text string,
 ) error {
-	if text == "" {
-		return s.update(ctx, token, id, name, description, noDescription, nil)
-	}
+	var edit func(msg proto.Message) error
 
-	_, msg, err := s.Get(ctx, token, id)
-	if err != nil {
-		return fmt.Errorf("SecretsService - EditText - uc.Get: %w", err)
-	}
+	if text != "" {
+		edit = func(msg proto.Message) error {
+			data, ok := msg.(*p.Text)
+			if !ok {
+				return fmt.Errorf("SecretsService - EditText - msg.(*goph.Text): %w", ErrKindMismatch)
+			}
 
-	data, ok := msg.(*p.Text)
-	if !ok {
-		return fmt.Errorf("SecretsService - EditText - msg.(*goph.Text): %w", ErrKindMismatch)
-	}
+			data.Text = text
 
-	data.Text = text
+			return nil
+		}
+	}
 
-	return s.update(ctx, token, id, name, description, noDescription, data)
+	return s.update(ctx, token, id, name, description, noDescription, edit)
 }
 
 // Get retrieves full user's secret.
-// All sensitive parts are decrypted.
+// All sensitive parts are decrypted, encryption.ErrIntegrity is returned
+// if any of them was tampered with or belongs to other secret.
 func (s *SecretsService) Get(
 	ctx context.Context,
 	token string,
 	id uuid.UUID,
 ) (*p.Secret, proto.Message, error) {
-	secret, data, err := s.secretsRepo.Get(ctx, token, id)
+	secret, data, err := s.secretsRepo.Get(ctx, token, id, false)
 	if err != nil {
 		return nil, nil, fmt.Errorf("SecretsService - Get - uc.secretsRepo.Get: %w", err)
 	}
 
-	secret.Metadata, err = s.key.Decrypt(secret.GetMetadata())
+	if secret.GetId() != id.String() {
+		return nil, nil, fmt.Errorf("SecretsService - Get - secret.GetId: %w", encryption.ErrIntegrity)
+	}
+
+	if secret.GetBlobSize() > 0 {
+		return nil, nil, fmt.Errorf("SecretsService - Get - secret.GetBlobSize: %w", ErrBlobSecret)
+	}
+
+	msg, err := s.open(secret, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - Get - s.open: %w", err)
+	}
+
+	return secret, msg, nil
+}
+
+// open decrypts name, description and data of the secret.
+// Name and description are replaced in the secret, data is returned as a message of the kind.
+func (s *SecretsService) open(secret *p.Secret, data []byte) (proto.Message, error) {
+	c, err := openSecretCipher(s.key, secret, s.allowLegacy)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - open - openSecretCipher: %w", err)
+	}
+
+	secret.Name, err = c.openName(secret)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - open - c.openName: %w", err)
+	}
+
+	secret.Metadata, err = c.open(fieldMetadata, secret.GetMetadata())
 	if err != nil {
-		return nil, nil, fmt.Errorf("SecretsService - Get - uc.key.Decrypt(metadata): %w", err)
+		return nil, fmt.Errorf("SecretsService - open - c.open(metadata): %w", err)
 	}
 
-	decryptedData, err := s.key.Decrypt(data)
+	decryptedData, err := c.open(fieldData, data)
 	if err != nil {
-		return nil, nil, fmt.Errorf("SecretsService - Get - uc.key.Decrypt(data): %w", err)
+		return nil, fmt.Errorf("SecretsService - open - c.open(data): %w", err)
 	}
 
 	var msg proto.Message
@@ -352,13 +528,137 @@ func (s *SecretsService) Get(
 	}
 
 	if err := proto.Unmarshal(decryptedData, msg); err != nil {
-		return nil, nil, fmt.Errorf("SecretsService - Get - proto.Unmarshal: %w", err)
+		return nil, fmt.Errorf("SecretsService - open - proto.Unmarshal: %w", err)
+	}
+
+	return msg, nil
+}
+
+// OpenFile retrieves binary secret stored as blob.
+// Name and description are decrypted at once, content is decrypted
+// while being read from the returned reader.
+func (s *SecretsService) OpenFile(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) (*p.Secret, io.Reader, error) {
+	secret, chunks, err := s.secretsRepo.DownloadBlob(ctx, token, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - uc.secretsRepo.DownloadBlob: %w", err)
+	}
+
+	if secret.GetId() != id.String() || secret.GetKind() != p.DataKind_BINARY {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - secret.GetId: %w", encryption.ErrIntegrity)
+	}
+
+	c, err := openSecretCipher(s.key, secret, s.allowLegacy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - openSecretCipher: %w", err)
+	}
+
+	// Blobs are always encrypted with data key, chunks of legacy secret can't be authenticated.
+	if c.legacy {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - openSecretCipher: %w", encryption.ErrIntegrity)
+	}
+
+	secret.Name, err = c.openName(secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - c.openName: %w", err)
+	}
+
+	secret.Metadata, err = c.open(fieldMetadata, secret.GetMetadata())
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - OpenFile - c.open(metadata): %w", err)
+	}
+
+	return secret, newChunkReader(c, chunks), nil
+}
+
+// ListVersions returns versions of the secret without data, the latest go first.
+// Names and descriptions of all versions are decrypted, encryption.ErrIntegrity is returned
+// if any of them was tampered with or belongs to other secret.
+func (s *SecretsService) ListVersions(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+) ([]*p.Secret, error) {
+	versions, err := s.secretsRepo.ListVersions(ctx, token, id, false)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - ListVersions - uc.secretsRepo.ListVersions: %w", err)
+	}
+
+	for _, version := range versions {
+		if version.GetId() != id.String() {
+			return nil, fmt.Errorf("SecretsService - ListVersions - version.GetId: %w", encryption.ErrIntegrity)
+		}
+
+		c, err := openSecretCipher(s.key, version, s.allowLegacy)
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListVersions - openSecretCipher: %w", err)
+		}
+
+		version.Name, err = c.openName(version)
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListVersions - c.openName: %w", err)
+		}
+
+		version.Metadata, err = c.open(fieldMetadata, version.GetMetadata())
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListVersions - c.open: %w", err)
+		}
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves particular version of user's secret.
+// All sensitive parts are decrypted the same way as by Get.
+func (s *SecretsService) GetVersion(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+) (*p.Secret, proto.Message, error) {
+	secret, data, err := s.secretsRepo.GetVersion(ctx, token, id, version, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - GetVersion - uc.secretsRepo.GetVersion: %w", err)
+	}
+
+	if secret.GetId() != id.String() {
+		return nil, nil, fmt.Errorf("SecretsService - GetVersion - secret.GetId: %w", encryption.ErrIntegrity)
+	}
+
+	if secret.GetBlobSize() > 0 {
+		return nil, nil, fmt.Errorf("SecretsService - GetVersion - secret.GetBlobSize: %w", ErrBlobSecret)
+	}
+
+	msg, err := s.open(secret, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SecretsService - GetVersion - s.open: %w", err)
 	}
 
 	return secret, msg, nil
 }
 
-// Delete removes user's secret.
+// Restore makes content of the version of user's secret current again,
+// zero version moves the secret back from trash.
+// Versions are encrypted by data keys bound to the secret, so nothing is re-encrypted.
+// Returns the version of the secret with the restored content.
+func (s *SecretsService) Restore(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+) (int32, error) {
+	restored, err := s.secretsRepo.Restore(ctx, token, id, version)
+	if err != nil {
+		return 0, fmt.Errorf("SecretsService - Restore - uc.secretsRepo.Restore: %w", err)
+	}
+
+	return restored, nil
+}
+
+// Delete moves user's secret to trash.
 func (s *SecretsService) Delete(
 	ctx context.Context,
 	token string,
@@ -370,3 +670,40 @@ func (s *SecretsService) Delete(
 
 	return nil
 }
+
+// ListTrash returns user's secrets moved to trash without data, the latest deleted go first.
+// Names and descriptions are decrypted the same way as by List.
+func (s *SecretsService) ListTrash(ctx context.Context, token string) ([]*p.Secret, error) {
+	secrets, err := s.secretsRepo.ListTrash(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - ListTrash - uc.secretsRepo.ListTrash: %w", err)
+	}
+
+	for _, secret := range secrets {
+		c, err := openSecretCipher(s.key, secret, s.allowLegacy)
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListTrash - openSecretCipher: %w", err)
+		}
+
+		secret.Name, err = c.openName(secret)
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListTrash - c.openName: %w", err)
+		}
+
+		secret.Metadata, err = c.open(fieldMetadata, secret.GetMetadata())
+		if err != nil {
+			return nil, fmt.Errorf("SecretsService - ListTrash - c.open: %w", err)
+		}
+	}
+
+	return secrets, nil
+}
+
+// Purge removes user's secret permanently, either trashed or not.
+func (s *SecretsService) Purge(ctx context.Context, token string, id uuid.UUID) error {
+	if err := s.secretsRepo.Purge(ctx, token, id); err != nil {
+		return fmt.Errorf("SecretsService - Purge - uc.secretsRepo.Purge: %w", err)
+	}
+
+	return nil
+}
@@ -1,22 +1,30 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	stderrors "errors"
+	"io"
 	"testing"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/errors"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
 	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
-func doPushText(t *testing.T, mockRV uuid.UUID, mockErr error) (uuid.UUID, error) {
+func doPushText(t *testing.T, mockErr error) (uuid.UUID, error) {
 	t.Helper()
 
 	m := &repo.SecretsRepoMock{}
@@ -24,14 +32,17 @@ func doPushText(t *testing.T, mockRV uuid.UUID, mockErr error) (uuid.UUID, error
 		"Push",
 		mock.Anything,
 		gophtest.AccessToken,
-		gophtest.SecretName,
+		mock.AnythingOfType("uuid.UUID"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
 		p.DataKind_TEXT,
 		mock.AnythingOfType("[]uint8"),
 		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
 	).
-		Return(mockRV, mockErr)
+		Return(mockErr)
 
-	sat := service.NewSecretsService(newTestKey(), m)
+	sat := service.NewSecretsService(newTestKey(), false, m)
 	id, err := sat.PushText(
 		context.Background(),
 		gophtest.AccessToken,
@@ -53,13 +64,17 @@ func doList(t *testing.T, mockRV []*p.Secret, mockErr error) ([]*p.Secret, error
 		"List",
 		mock.Anything,
 		gophtest.AccessToken,
+		[]p.DataKind{p.DataKind_TEXT},
+		1,
 	).
-		Return(mockRV, mockErr)
+		Return(mockRV, gophtest.VaultVersion, mockErr)
 
-	sat := service.NewSecretsService(newTestKey(), m)
+	sat := service.NewSecretsService(newTestKey(), true, m)
 	data, err := sat.List(
 		context.Background(),
 		gophtest.AccessToken,
+		[]p.DataKind{p.DataKind_TEXT},
+		1,
 	)
 
 	m.AssertExpectations(t)
@@ -76,6 +91,9 @@ func doGetSecret(
 	t.Helper()
 
 	id := uuid.New()
+	if mockSecret != nil {
+		id = gophtest.CreateUUID(t, mockSecret.GetId())
+	}
 
 	m := &repo.SecretsRepoMock{}
 	m.On(
@@ -83,10 +101,11 @@ func doGetSecret(
 		mock.Anything,
 		gophtest.AccessToken,
 		id,
+		false,
 	).
 		Return(mockSecret, mockData, mockErr)
 
-	sat := service.NewSecretsService(newTestKey(), m)
+	sat := service.NewSecretsService(newTestKey(), true, m)
 	secret, data, err := sat.Get(
 		context.Background(),
 		gophtest.AccessToken,
@@ -98,6 +117,56 @@ func doGetSecret(
 	return secret, data, err
 }
 
+func newTestTextSecret(t *testing.T, id uuid.UUID) (*p.Secret, []byte) {
+	t.Helper()
+
+	key := newTestKey()
+
+	dataKey, err := encryption.NewDataKey()
+	require.NoError(t, err)
+
+	wrappedKey, err := key.Wrap(
+		dataKey,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	name, err := dataKey.Seal(
+		[]byte(gophtest.SecretName),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "name"),
+	)
+	require.NoError(t, err)
+
+	nameIndex, err := key.BlindIndex(gophtest.SecretName)
+	require.NoError(t, err)
+
+	metadata, err := dataKey.Seal(
+		[]byte(gophtest.Metadata),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "metadata"),
+	)
+	require.NoError(t, err)
+
+	rawData, err := proto.Marshal(&p.Text{Text: gophtest.TextData})
+	require.NoError(t, err)
+
+	data, err := dataKey.Seal(
+		rawData,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data"),
+	)
+	require.NoError(t, err)
+
+	secret := &p.Secret{
+		Id:        id.String(),
+		Name:      name,
+		NameIndex: nameIndex,
+		Kind:      p.DataKind_TEXT,
+		DataKey:   wrappedKey,
+		Metadata:  metadata,
+	}
+
+	return secret, data
+}
+
 func doUpdateTextSecret(
 	t *testing.T,
 	name, description string,
@@ -108,21 +177,29 @@ func doUpdateTextSecret(
 	t.Helper()
 
 	id := uuid.New()
+	secret, data := newTestTextSecret(t, id)
+	secret.Version = 2
 
 	m := &repo.SecretsRepoMock{}
+	m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(secret, data, nil).
+		Maybe()
 	m.On(
 		"Update",
 		mock.Anything,
 		gophtest.AccessToken,
 		id,
-		name,
+		int32(2),
 		mock.AnythingOfType("[]uint8"),
-		noDescription,
 		mock.AnythingOfType("[]uint8"),
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
 	).
 		Return(repoErr)
 
-	sat := service.NewSecretsService(newTestKey(), m)
+	sat := service.NewSecretsService(newTestKey(), false, m)
 	err := sat.EditText(
 		context.Background(),
 		gophtest.AccessToken,
@@ -152,7 +229,7 @@ func doDelete(t *testing.T, mockErr error) error {
 	).
 		Return(mockErr)
 
-	sat := service.NewSecretsService(newTestKey(), m)
+	sat := service.NewSecretsService(newTestKey(), false, m)
 	err := sat.Delete(
 		context.Background(),
 		gophtest.AccessToken,
@@ -165,16 +242,97 @@ func doDelete(t *testing.T, mockErr error) error {
 }
 
 func TestPushSecret(t *testing.T) {
-	expected := uuid.New()
+	id, err := doPushText(t, nil)
+
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, id)
+}
 
-	id, err := doPushText(t, expected, nil)
+func TestPushSecretBindsContentToID(t *testing.T) {
+	key := newTestKey()
+
+	var (
+		id         uuid.UUID
+		name       []byte
+		nameIndex  []byte
+		wrappedKey []byte
+		metadata   []byte
+		encData    []byte
+	)
+
+	m := &repo.SecretsRepoMock{}
+	m.On(
+		"Push",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.AnythingOfType("uuid.UUID"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+		p.DataKind_TEXT,
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+	).
+		Run(func(args mock.Arguments) {
+			id = args.Get(2).(uuid.UUID)
+			name = args.Get(3).([]byte)
+			nameIndex = args.Get(4).([]byte)
+			wrappedKey = args.Get(6).([]byte)
+			metadata = args.Get(7).([]byte)
+			encData = args.Get(8).([]byte)
+		}).
+		Return(nil)
+
+	sat := service.NewSecretsService(key, false, m)
+	rv, err := sat.PushText(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.SecretName,
+		gophtest.Metadata,
+		gophtest.TextData,
+	)
 
 	require.NoError(t, err)
-	require.Equal(t, expected, id)
+	require.Equal(t, id, rv)
+
+	_, err = key.Unwrap(wrappedKey, nil)
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+
+	dataKey, err := key.Unwrap(
+		wrappedKey,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	decrypted, err := dataKey.Open(
+		metadata,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "metadata"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.Metadata, string(decrypted))
+
+	decrypted, err = dataKey.Open(
+		name,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "name"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.SecretName, string(decrypted))
+
+	expectedIndex, err := key.BlindIndex(gophtest.SecretName)
+	require.NoError(t, err)
+	require.Equal(t, expectedIndex, nameIndex)
+
+	_, err = dataKey.Open(
+		encData,
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "metadata"),
+	)
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+
+	m.AssertExpectations(t)
 }
 
 func TestPushSecretOnRepoFailure(t *testing.T) {
-	_, err := doPushText(t, uuid.UUID{}, gophtest.ErrUnexpected)
+	_, err := doPushText(t, gophtest.ErrUnexpected)
 
 	require.Error(t, err)
 }
@@ -189,13 +347,13 @@ func TestListSecrets(t *testing.T) {
 			secrets: []*p.Secret{
 				{
 					Id:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
-					Name:     gophtest.SecretName,
+					Name:     []byte(gophtest.SecretName),
 					Kind:     p.DataKind_TEXT,
 					Metadata: []byte(gophtest.Metadata),
 				},
 				{
 					Id:       gophtest.CreateUUID(t, "7728154c-9400-4f1b-a2a3-01deb83ece05").String(),
-					Name:     "No metadata",
+					Name:     []byte("No metadata"),
 					Kind:     p.DataKind_TEXT,
 					Metadata: []byte{},
 				},
@@ -235,11 +393,22 @@ func TestListSecrets(t *testing.T) {
 	}
 }
 
+func TestListEnvelopedSecrets(t *testing.T) {
+	secret, _ := newTestTextSecret(t, uuid.New())
+
+	rv, err := doList(t, []*p.Secret{secret}, nil)
+
+	require.NoError(t, err)
+	require.Len(t, rv, 1)
+	require.Equal(t, gophtest.SecretName, string(rv[0].GetName()))
+	require.Equal(t, gophtest.Metadata, string(rv[0].GetMetadata()))
+}
+
 func TestListSecretsOnDecryptFailure(t *testing.T) {
 	secrets := []*p.Secret{
 		{
 			Id:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
-			Name:     gophtest.SecretName,
+			Name:     []byte(gophtest.SecretName),
 			Kind:     p.DataKind_TEXT,
 			Metadata: []byte(gophtest.Metadata),
 		},
@@ -250,6 +419,16 @@ func TestListSecretsOnDecryptFailure(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestListTamperedSecret(t *testing.T) {
+	secret, _ := newTestTextSecret(t, uuid.New())
+	other, _ := newTestTextSecret(t, uuid.New())
+	secret.Metadata = other.GetMetadata()
+
+	_, err := doList(t, []*p.Secret{secret}, nil)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+}
+
 func TestListSecretsOnRepoFailure(t *testing.T) {
 	_, err := doList(t, nil, gophtest.ErrUnexpected)
 
@@ -266,7 +445,7 @@ func TestGetSecret(t *testing.T) {
 			name: "Get secret text secret",
 			secret: &p.Secret{
 				Id:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
-				Name:     gophtest.SecretName,
+				Name:     []byte(gophtest.SecretName),
 				Kind:     p.DataKind_TEXT,
 				Metadata: []byte(gophtest.Metadata),
 			},
@@ -276,7 +455,7 @@ func TestGetSecret(t *testing.T) {
 			name: "Get secret without metadata",
 			secret: &p.Secret{
 				Id:       gophtest.CreateUUID(t, "7728154c-9400-4f1b-a2a3-01deb83ece05").String(),
-				Name:     "No metadata",
+				Name:     []byte("No metadata"),
 				Kind:     p.DataKind_TEXT,
 				Metadata: []byte{},
 			},
@@ -315,6 +494,57 @@ func TestGetSecret(t *testing.T) {
 	}
 }
 
+func TestGetEnvelopedSecret(t *testing.T) {
+	id := uuid.New()
+	mockSecret, mockData := newTestTextSecret(t, id)
+
+	secret, data, err := doGetSecret(t, mockSecret, mockData, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.SecretName, string(secret.GetName()))
+	require.Equal(t, gophtest.Metadata, string(secret.GetMetadata()))
+	require.Equal(t, gophtest.TextData, data.(*p.Text).GetText())
+}
+
+func TestGetSecretNotMigrated(t *testing.T) {
+	tt := []struct {
+		name  string
+		strip func(secret *p.Secret)
+	}{
+		{
+			name: "Get fails if data key of the secret is stripped",
+			strip: func(secret *p.Secret) {
+				secret.DataKey = nil
+			},
+		},
+		{
+			name: "Get fails if name of the secret is replaced with plain text",
+			strip: func(secret *p.Secret) {
+				secret.Name = []byte(gophtest.SecretName)
+				secret.NameIndex = nil
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+			secret, data := newTestTextSecret(t, id)
+			tc.strip(secret)
+
+			m := &repo.SecretsRepoMock{}
+			m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+				Return(secret, data, nil)
+
+			sat := service.NewSecretsService(newTestKey(), false, m)
+			_, _, err := sat.Get(context.Background(), gophtest.AccessToken, id)
+
+			require.ErrorIs(t, err, service.ErrNotMigrated)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetSecretOnDecryptFailure(t *testing.T) {
 	tt := []struct {
 		name   string
@@ -325,17 +555,27 @@ func TestGetSecretOnDecryptFailure(t *testing.T) {
 			name: "Get secret fails if metadat decryption fails",
 			secret: &p.Secret{
 				Id:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
-				Name:     "Bad metadata",
+				Name:     []byte("Bad metadata"),
 				Kind:     p.DataKind_TEXT,
 				Metadata: []byte(gophtest.Metadata),
 			},
 			data: []byte(gophtest.TextData),
 		},
+		{
+			name: "Get secret fails if data key is malformed",
+			secret: &p.Secret{
+				Id:      gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45").String(),
+				Name:    []byte("Bad data key"),
+				Kind:    p.DataKind_TEXT,
+				DataKey: []byte(gophtest.DataKey),
+			},
+			data: []byte(gophtest.TextData),
+		},
 		{
 			name: "Get secret fails if data descryption fails",
 			secret: &p.Secret{
 				Id:       gophtest.CreateUUID(t, "7728154c-9400-4f1b-a2a3-01deb83ece05").String(),
-				Name:     "Bad data",
+				Name:     []byte("Bad data"),
 				Kind:     p.DataKind_TEXT,
 				Metadata: []byte{},
 			},
@@ -352,6 +592,90 @@ func TestGetSecretOnDecryptFailure(t *testing.T) {
 	}
 }
 
+func TestGetTamperedSecret(t *testing.T) {
+	tt := []struct {
+		name   string
+		tamper func(secret *p.Secret, data []byte) []byte
+	}{
+		{
+			name: "Get secret fails if data belongs to other secret",
+			tamper: func(_ *p.Secret, _ []byte) []byte {
+				_, data := newTestTextSecret(t, uuid.New())
+
+				return data
+			},
+		},
+		{
+			name: "Get secret fails if data key belongs to other secret",
+			tamper: func(secret *p.Secret, data []byte) []byte {
+				other, _ := newTestTextSecret(t, uuid.New())
+				secret.DataKey = other.GetDataKey()
+
+				return data
+			},
+		},
+		{
+			name: "Get secret fails if name belongs to other secret",
+			tamper: func(secret *p.Secret, data []byte) []byte {
+				other, _ := newTestTextSecret(t, uuid.New())
+				secret.Name = other.GetName()
+
+				return data
+			},
+		},
+		{
+			name: "Get secret fails if kind of secret is changed",
+			tamper: func(secret *p.Secret, data []byte) []byte {
+				secret.Kind = p.DataKind_BINARY
+
+				return data
+			},
+		},
+		{
+			name: "Get secret fails if metadata is swapped with data",
+			tamper: func(secret *p.Secret, data []byte) []byte {
+				secret.Metadata = data
+
+				return data
+			},
+		},
+		{
+			name: "Get secret fails if metadata is modified",
+			tamper: func(secret *p.Secret, data []byte) []byte {
+				secret.Metadata[len(secret.Metadata)-1] ^= 0xff
+
+				return data
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			secret, data := newTestTextSecret(t, uuid.New())
+			data = tc.tamper(secret, data)
+
+			_, _, err := doGetSecret(t, secret, data, nil)
+
+			require.ErrorIs(t, err, encryption.ErrIntegrity)
+		})
+	}
+}
+
+func TestGetSecretOfOtherID(t *testing.T) {
+	secret, data := newTestTextSecret(t, uuid.New())
+
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(secret, data, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, _, err := sat.Get(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+	m.AssertExpectations(t)
+}
+
 func TestGetSecretOnRepoFailure(t *testing.T) {
 	_, _, err := doGetSecret(t, nil, nil, gophtest.ErrUnexpected)
 
@@ -383,6 +707,10 @@ func TestUpdateTextSecret(t *testing.T) {
 			name:          "Reset secret's description",
 			noDescription: true,
 		},
+		{
+			name: "Update secret's text",
+			text: gophtest.TextData + "ex",
+		},
 	}
 
 	for _, tc := range tt {
@@ -392,7 +720,7 @@ func TestUpdateTextSecret(t *testing.T) {
 				tc.secretName,
 				tc.description,
 				tc.noDescription,
-				"",
+				tc.text,
 				nil,
 			)
 
@@ -401,6 +729,211 @@ func TestUpdateTextSecret(t *testing.T) {
 	}
 }
 
+func TestUpdateSecretReencryptsContent(t *testing.T) {
+	tt := []struct {
+		name         string
+		secretName   string
+		text         string
+		expectedName string
+		expectedText string
+	}{
+		{
+			name:         "Update of text re-encrypts the whole secret",
+			text:         gophtest.TextData + "ex",
+			expectedName: gophtest.SecretName,
+			expectedText: gophtest.TextData + "ex",
+		},
+		{
+			name:         "Rename of a secret re-encrypts the whole secret",
+			secretName:   gophtest.SecretName + "ex",
+			expectedName: gophtest.SecretName + "ex",
+			expectedText: gophtest.TextData,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+			key := newTestKey()
+			secret, data := newTestTextSecret(t, id)
+
+			var (
+				name       []byte
+				nameIndex  []byte
+				wrappedKey []byte
+				metadata   []byte
+				encData    []byte
+			)
+
+			m := &repo.SecretsRepoMock{}
+			m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+				Return(secret, data, nil)
+			m.On(
+				"Update",
+				mock.Anything,
+				gophtest.AccessToken,
+				id,
+				int32(0),
+				mock.AnythingOfType("[]uint8"),
+				mock.AnythingOfType("[]uint8"),
+				mock.AnythingOfType("[]uint8"),
+				mock.AnythingOfType("[]uint8"),
+				true,
+				mock.AnythingOfType("[]uint8"),
+			).
+				Run(func(args mock.Arguments) {
+					name = args.Get(4).([]byte)
+					nameIndex = args.Get(5).([]byte)
+					wrappedKey = args.Get(6).([]byte)
+					metadata = args.Get(7).([]byte)
+					encData = args.Get(9).([]byte)
+				}).
+				Return(nil)
+
+			sat := service.NewSecretsService(key, false, m)
+			err := sat.EditText(
+				context.Background(),
+				gophtest.AccessToken,
+				id,
+				tc.secretName,
+				"",
+				false,
+				tc.text,
+			)
+
+			require.NoError(t, err)
+			require.NotEqual(t, secret.GetDataKey(), wrappedKey)
+
+			dataKey, err := key.Unwrap(
+				wrappedKey,
+				newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+			)
+			require.NoError(t, err)
+
+			decrypted, err := dataKey.Open(
+				name,
+				newTestAssociatedData(id.String(), p.DataKind_TEXT, "name"),
+			)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedName, string(decrypted))
+
+			expectedIndex, err := key.BlindIndex(tc.expectedName)
+			require.NoError(t, err)
+			require.Equal(t, expectedIndex, nameIndex)
+
+			decrypted, err = dataKey.Open(
+				metadata,
+				newTestAssociatedData(id.String(), p.DataKind_TEXT, "metadata"),
+			)
+			require.NoError(t, err)
+			require.Equal(t, gophtest.Metadata, string(decrypted))
+
+			decrypted, err = dataKey.Open(
+				encData,
+				newTestAssociatedData(id.String(), p.DataKind_TEXT, "data"),
+			)
+			require.NoError(t, err)
+
+			text := &p.Text{}
+			require.NoError(t, proto.Unmarshal(decrypted, text))
+			require.Equal(t, tc.expectedText, text.GetText())
+
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateSecretRetriesOnConflict(t *testing.T) {
+	id := uuid.New()
+	stale, staleData := newTestTextSecret(t, id)
+	stale.Version = 2
+	fresh, freshData := newTestTextSecret(t, id)
+	fresh.Version = 3
+
+	conflict := errors.NewRequestError(status.Error(codes.Aborted, "secret was modified concurrently"))
+
+	m := &repo.SecretsRepoMock{}
+	m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(stale, staleData, nil).
+		Once()
+	m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(fresh, freshData, nil).
+		Once()
+	m.On(
+		"Update",
+		mock.Anything,
+		gophtest.AccessToken,
+		id,
+		int32(2),
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		true,
+		mock.Anything,
+	).
+		Return(conflict).
+		Once()
+	m.On(
+		"Update",
+		mock.Anything,
+		gophtest.AccessToken,
+		id,
+		int32(3),
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		true,
+		mock.Anything,
+	).
+		Return(nil).
+		Once()
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	err := sat.EditText(context.Background(), gophtest.AccessToken, id, "", "", false, gophtest.TextData+"ex")
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestUpdateSecretReportsConflict(t *testing.T) {
+	id := uuid.New()
+	conflict := errors.NewRequestError(status.Error(codes.Aborted, "secret was modified concurrently"))
+
+	m := &repo.SecretsRepoMock{}
+
+	// Every attempt decrypts a freshly read secret.
+	for range service.UpdateAttempts {
+		secret, data := newTestTextSecret(t, id)
+		m.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+			Return(secret, data, nil).
+			Once()
+	}
+
+	m.On(
+		"Update",
+		mock.Anything,
+		gophtest.AccessToken,
+		id,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		true,
+		mock.Anything,
+	).
+		Return(conflict)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	err := sat.EditText(context.Background(), gophtest.AccessToken, id, "", "", false, gophtest.TextData+"ex")
+
+	require.True(t, errors.HasCode(err, codes.Aborted))
+	m.AssertExpectations(t)
+	m.AssertNumberOfCalls(t, "Update", service.UpdateAttempts)
+}
+
 func TestUpdateSecretOnRepoFailure(t *testing.T) {
 	err := doUpdateTextSecret(t, "", "", false, "", gophtest.ErrUnexpected)
 
@@ -418,3 +951,465 @@ func TestDeleteSecretOnRepoFailure(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+// uploadedBlob is a blob captured from the repo mock.
+type uploadedBlob struct {
+	secret *p.Secret
+	chunks [][]byte
+}
+
+func doPushFile(t *testing.T, content []byte, mockErr error) (uuid.UUID, uploadedBlob, error) {
+	t.Helper()
+
+	var blob uploadedBlob
+
+	m := &repo.SecretsRepoMock{}
+	m.On(
+		"UploadBlob",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.AnythingOfType("uuid.UUID"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("[]uint8"),
+		mock.AnythingOfType("func() ([]uint8, error)"),
+	).
+		Run(func(args mock.Arguments) {
+			blob.secret = &p.Secret{
+				Id:        args.Get(2).(uuid.UUID).String(),
+				Name:      args.Get(3).([]byte),
+				NameIndex: args.Get(4).([]byte),
+				Kind:      p.DataKind_BINARY,
+				DataKey:   args.Get(5).([]byte),
+				Metadata:  args.Get(6).([]byte),
+			}
+
+			next := args.Get(7).(func() ([]byte, error))
+
+			for {
+				chunk, err := next()
+				if stderrors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				blob.chunks = append(blob.chunks, chunk)
+				blob.secret.BlobSize += int64(len(chunk))
+			}
+		}).
+		Return(mockErr)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	id, err := sat.PushFile(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.SecretName,
+		gophtest.Metadata,
+		bytes.NewReader(content),
+	)
+
+	m.AssertExpectations(t)
+
+	return id, blob, err
+}
+
+// newChunkSource provides the chunks and then io.EOF.
+func newChunkSource(chunks [][]byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		return chunk, nil
+	}
+}
+
+func doOpenFile(t *testing.T, id uuid.UUID, blob uploadedBlob) (*p.Secret, []byte, error) {
+	t.Helper()
+
+	m := &repo.SecretsRepoMock{}
+	m.On("DownloadBlob", mock.Anything, gophtest.AccessToken, id).
+		Return(blob.secret, newChunkSource(blob.chunks), nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+
+	secret, r, err := sat.OpenFile(context.Background(), gophtest.AccessToken, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := io.ReadAll(r)
+
+	m.AssertExpectations(t)
+
+	return secret, content, err
+}
+
+func TestPushFile(t *testing.T) {
+	tt := []struct {
+		name   string
+		size   int
+		chunks int
+	}{
+		{
+			name:   "Push empty file",
+			size:   0,
+			chunks: 1,
+		},
+		{
+			name:   "Push small file",
+			size:   100,
+			chunks: 1,
+		},
+		{
+			name:   "Push file of exactly one chunk",
+			size:   service.BlobChunkSize,
+			chunks: 1,
+		},
+		{
+			name:   "Push file of several chunks",
+			size:   2*service.BlobChunkSize + 7,
+			chunks: 3,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			content := make([]byte, tc.size)
+			_, err := rand.Read(content)
+			require.NoError(t, err)
+
+			id, blob, err := doPushFile(t, content, nil)
+
+			require.NoError(t, err)
+			require.Len(t, blob.chunks, tc.chunks)
+			require.NotContains(t, string(blob.secret.GetName()), gophtest.SecretName)
+
+			secret, opened, err := doOpenFile(t, id, blob)
+
+			require.NoError(t, err)
+			require.Equal(t, gophtest.SecretName, string(secret.GetName()))
+			require.Equal(t, gophtest.Metadata, string(secret.GetMetadata()))
+			require.Equal(t, content, opened)
+		})
+	}
+}
+
+func TestPushFileOnRepoFailure(t *testing.T) {
+	_, _, err := doPushFile(t, []byte(gophtest.TextData), gophtest.ErrUnexpected)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func TestOpenTamperedFile(t *testing.T) {
+	content := make([]byte, 3*service.BlobChunkSize)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	id, blob, err := doPushFile(t, content, nil)
+	require.NoError(t, err)
+
+	_, other, err := doPushFile(t, content, nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		chunks func(chunks [][]byte) [][]byte
+	}{
+		{
+			name: "Open file with modified chunk",
+			chunks: func(chunks [][]byte) [][]byte {
+				modified := bytes.Clone(chunks[1])
+				modified[len(modified)-1] ^= 0xff
+
+				return [][]byte{chunks[0], modified, chunks[2]}
+			},
+		},
+		{
+			name: "Open file with reordered chunks",
+			chunks: func(chunks [][]byte) [][]byte {
+				return [][]byte{chunks[1], chunks[0], chunks[2]}
+			},
+		},
+		{
+			name: "Open file without last chunk",
+			chunks: func(chunks [][]byte) [][]byte {
+				return chunks[:2]
+			},
+		},
+		{
+			name: "Open file without chunks",
+			chunks: func(_ [][]byte) [][]byte {
+				return nil
+			},
+		},
+		{
+			name: "Open file with chunk appended after last one",
+			chunks: func(chunks [][]byte) [][]byte {
+				return [][]byte{chunks[0], chunks[1], chunks[2], chunks[2]}
+			},
+		},
+		{
+			name: "Open file with chunk of other file",
+			chunks: func(chunks [][]byte) [][]byte {
+				return [][]byte{chunks[0], other.chunks[1], chunks[2]}
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := uploadedBlob{secret: blob.secret, chunks: tc.chunks(blob.chunks)}
+
+			_, _, err := doOpenFile(t, id, tampered)
+
+			require.ErrorIs(t, err, encryption.ErrIntegrity)
+		})
+	}
+}
+
+func TestOpenFileOfOtherID(t *testing.T) {
+	_, blob, err := doPushFile(t, []byte(gophtest.TextData), nil)
+	require.NoError(t, err)
+
+	_, _, err = doOpenFile(t, uuid.New(), blob)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+}
+
+func TestOpenFileOnRepoFailure(t *testing.T) {
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("DownloadBlob", mock.Anything, gophtest.AccessToken, id).
+		Return(nil, nil, gophtest.ErrUnexpected)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, _, err := sat.OpenFile(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestGetBlobSecret(t *testing.T) {
+	secret, data := newTestTextSecret(t, uuid.New())
+	secret.Kind = p.DataKind_BINARY
+	secret.BlobSize = 100
+
+	_, _, err := doGetSecret(t, secret, data, nil)
+
+	require.ErrorIs(t, err, service.ErrBlobSecret)
+}
+
+func TestListVersions(t *testing.T) {
+	id := uuid.New()
+	current, _ := newTestTextSecret(t, id)
+	current.Version = 2
+	previous, _ := newTestTextSecret(t, id)
+	previous.Version = 1
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ListVersions", mock.Anything, gophtest.AccessToken, id, false).
+		Return([]*p.Secret{current, previous}, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	versions, err := sat.ListVersions(context.Background(), gophtest.AccessToken, id)
+
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	for i, version := range versions {
+		require.Equal(t, int32(2-i), version.GetVersion())
+		require.Equal(t, gophtest.SecretName, string(version.GetName()))
+		require.Equal(t, gophtest.Metadata, string(version.GetMetadata()))
+	}
+
+	m.AssertExpectations(t)
+}
+
+func TestListVersionsOfOtherID(t *testing.T) {
+	secret, _ := newTestTextSecret(t, uuid.New())
+
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("ListVersions", mock.Anything, gophtest.AccessToken, id, false).
+		Return([]*p.Secret{secret}, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, err := sat.ListVersions(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+	m.AssertExpectations(t)
+}
+
+func TestListVersionsOnRepoFailure(t *testing.T) {
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("ListVersions", mock.Anything, gophtest.AccessToken, id, false).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, err := sat.ListVersions(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestGetVersion(t *testing.T) {
+	id := uuid.New()
+	mockSecret, mockData := newTestTextSecret(t, id)
+	mockSecret.Version = 3
+
+	m := &repo.SecretsRepoMock{}
+	m.On("GetVersion", mock.Anything, gophtest.AccessToken, id, int32(3), false).
+		Return(mockSecret, mockData, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	secret, data, err := sat.GetVersion(context.Background(), gophtest.AccessToken, id, 3)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(3), secret.GetVersion())
+	require.Equal(t, gophtest.SecretName, string(secret.GetName()))
+	require.Equal(t, gophtest.TextData, data.(*p.Text).GetText())
+	m.AssertExpectations(t)
+}
+
+func TestGetVersionOfOtherID(t *testing.T) {
+	secret, data := newTestTextSecret(t, uuid.New())
+
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("GetVersion", mock.Anything, gophtest.AccessToken, id, int32(1), false).
+		Return(secret, data, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, _, err := sat.GetVersion(context.Background(), gophtest.AccessToken, id, 1)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+	m.AssertExpectations(t)
+}
+
+func TestGetVersionOnRepoFailure(t *testing.T) {
+	m := &repo.SecretsRepoMock{}
+	id := uuid.New()
+	m.On("GetVersion", mock.Anything, gophtest.AccessToken, id, int32(1), false).
+		Return(nil, nil, gophtest.ErrUnexpected)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, _, err := sat.GetVersion(context.Background(), gophtest.AccessToken, id, 1)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRestoreVersion(t *testing.T) {
+	tt := []struct {
+		name    string
+		mockRV  int32
+		mockErr error
+	}{
+		{
+			name:   "Restore version",
+			mockRV: 5,
+		},
+		{
+			name:    "Restore version on repo failure",
+			mockErr: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.SecretsRepoMock{}
+			id := uuid.New()
+			m.On("Restore", mock.Anything, gophtest.AccessToken, id, int32(2)).
+				Return(tc.mockRV, tc.mockErr)
+
+			sat := service.NewSecretsService(newTestKey(), false, m)
+			version, err := sat.Restore(context.Background(), gophtest.AccessToken, id, 2)
+
+			require.ErrorIs(t, err, tc.mockErr)
+			require.Equal(t, tc.mockRV, version)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListTrash(t *testing.T) {
+	secret, _ := newTestTextSecret(t, uuid.New())
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{secret}, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	rv, err := sat.ListTrash(context.Background(), gophtest.AccessToken)
+
+	require.NoError(t, err)
+	require.Len(t, rv, 1)
+	require.Equal(t, gophtest.SecretName, string(rv[0].GetName()))
+	require.Equal(t, gophtest.Metadata, string(rv[0].GetMetadata()))
+	m.AssertExpectations(t)
+}
+
+func TestListTrashTamperedSecret(t *testing.T) {
+	secret, _ := newTestTextSecret(t, uuid.New())
+	other, _ := newTestTextSecret(t, uuid.New())
+	secret.Metadata = other.GetMetadata()
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{secret}, nil)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, err := sat.ListTrash(context.Background(), gophtest.AccessToken)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+	m.AssertExpectations(t)
+}
+
+func TestListTrashOnRepoFailure(t *testing.T) {
+	m := &repo.SecretsRepoMock{}
+	m.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewSecretsService(newTestKey(), false, m)
+	_, err := sat.ListTrash(context.Background(), gophtest.AccessToken)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestPurgeSecret(t *testing.T) {
+	tt := []struct {
+		name    string
+		mockErr error
+	}{
+		{
+			name: "Purge secret",
+		},
+		{
+			name:    "Purge secret on repo failure",
+			mockErr: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.SecretsRepoMock{}
+			id := uuid.New()
+			m.On("Purge", mock.Anything, gophtest.AccessToken, id).
+				Return(tc.mockErr)
+
+			sat := service.NewSecretsService(newTestKey(), false, m)
+			err := sat.Purge(context.Background(), gophtest.AccessToken, id)
+
+			require.ErrorIs(t, err, tc.mockErr)
+			m.AssertExpectations(t)
+		})
+	}
+}
@@ -2,50 +2,145 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
 	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
 type Auth interface {
-	Login(ctx context.Context, username string, key encryption.Key) (string, error)
+	Login(
+		ctx context.Context,
+		username string,
+		password creds.Password,
+		keyFile encryption.KeyFile,
+		allowLegacy bool,
+	) (Tokens, encryption.Key, error)
+
+	Unlock(
+		ctx context.Context,
+		username string,
+		password creds.Password,
+		keyFile encryption.KeyFile,
+	) (encryption.Key, error)
+
+	VerifySecondFactor(ctx context.Context, partialToken, code string) (Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (Tokens, error)
+	Resume(ctx context.Context, tokens Tokens) (Tokens, error)
+	Logout(ctx context.Context, tokens Tokens) error
 }
 
 type Secrets interface {
 	//todo: split to multiple interfaces
 	PushBinary(ctx context.Context, token, name, description string, binary []byte) (uuid.UUID, error)
+	PushFile(ctx context.Context, token, name, description string, src io.Reader) (uuid.UUID, error)
 	PushCard(ctx context.Context, token, name, description string, number, expiration, holder string, cvv int32) (uuid.UUID, error)
 	PushCreds(ctx context.Context, token, name, description, login, password string) (uuid.UUID, error)
 	PushText(ctx context.Context, token, name, description, text string) (uuid.UUID, error)
-	List(ctx context.Context, token string) ([]*p.Secret, error)
+	List(ctx context.Context, token string, kinds []p.DataKind, limit int) ([]*p.Secret, error)
 	Get(ctx context.Context, token string, id uuid.UUID) (*p.Secret, proto.Message, error)
+	OpenFile(ctx context.Context, token string, id uuid.UUID) (*p.Secret, io.Reader, error)
 	EditBinary(ctx context.Context, token string, id uuid.UUID, name, description string, noDescription bool, binary []byte) error
 	EditCard(ctx context.Context, token string, id uuid.UUID, name, description string, noDescription bool, number, expiration, holder string, cvv int32) error
 	EditCreds(ctx context.Context, token string, id uuid.UUID, name, description string, noDescription bool, login, password string) error
 	EditText(ctx context.Context, token string, id uuid.UUID, name, description string, noDescription bool, text string) error
+	ListVersions(ctx context.Context, token string, id uuid.UUID) ([]*p.Secret, error)
+	GetVersion(ctx context.Context, token string, id uuid.UUID, version int32) (*p.Secret, proto.Message, error)
+	Restore(ctx context.Context, token string, id uuid.UUID, version int32) (int32, error)
 	Delete(ctx context.Context, token string, id uuid.UUID) error
+	ListTrash(ctx context.Context, token string) ([]*p.Secret, error)
+	Purge(ctx context.Context, token string, id uuid.UUID) error
+}
+
+type APITokens interface {
+	Create(
+		ctx context.Context,
+		token, name string,
+		readOnly bool,
+		secretIDs []uuid.UUID,
+		ttl time.Duration,
+	) (string, *p.APIToken, error)
+
+	List(ctx context.Context, token string) ([]*p.APIToken, error)
+	Revoke(ctx context.Context, token string, id uuid.UUID) error
+}
+
+type Sessions interface {
+	List(ctx context.Context, token string) ([]*p.Session, error)
+	Revoke(ctx context.Context, token string, id uuid.UUID) error
 }
 
 type Users interface {
-	Register(ctx context.Context, username string, key encryption.Key) (string, error)
+	Register(
+		ctx context.Context,
+		username string,
+		password creds.Password,
+		keyFile encryption.KeyFile,
+		kdf encryption.KDFParams,
+		withRecovery bool,
+	) (Tokens, encryption.Key, string, error)
+
+	ChangePassword(
+		ctx context.Context,
+		token, username string,
+		password, newPassword creds.Password,
+		keyFile encryption.KeyFile,
+		kdf encryption.KDFParams,
+		allowLegacy bool,
+	) (encryption.Key, error)
+
+	Recover(
+		ctx context.Context,
+		username string,
+		code, otp, newPassword creds.Password,
+		keyFile encryption.KeyFile,
+		kdf encryption.KDFParams,
+		allowLegacy bool,
+	) (Tokens, encryption.Key, error)
+
+	Rename(
+		ctx context.Context,
+		token, username, newUsername string,
+		password creds.Password,
+		keyFile encryption.KeyFile,
+		kdf encryption.KDFParams,
+		allowLegacy bool,
+	) (encryption.Key, error)
+
+	Delete(
+		ctx context.Context,
+		token, username string,
+		password creds.Password,
+		keyFile encryption.KeyFile,
+	) error
+
+	SetupTOTP(ctx context.Context, token string) (string, error)
+	EnableTOTP(ctx context.Context, token, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, token, code string) error
 }
 
 // Services is a collection of business logic.
+// Secrets requires the encryption key, so it is available only after authentication.
 type Services struct {
-	Auth    Auth
-	Secrets Secrets
-	Users   Users
+	APITokens APITokens
+	Auth      Auth
+	Secrets   Secrets
+	Sessions  Sessions
+	Users     Users
 }
 
-// New creates and initializes collection of services.
-func New(key encryption.Key, repos *repo.Repositories) *Services {
+// New creates and initializes collection of services not requiring the encryption key.
+func New(repos *repo.Repositories) *Services {
 	return &Services{
-		Auth:    NewAuthService(repos.Auth),
-		Secrets: NewSecretsService(key, repos.Secrets),
-		Users:   NewUsersService(repos.Users),
+		APITokens: NewAPITokensService(repos.APITokens),
+		Auth:      NewAuthService(repos.Auth),
+		Sessions:  NewSessionsService(repos.Sessions),
+		Users:     NewUsersService(repos.Auth, repos.Users, repos.Secrets),
 	}
 }
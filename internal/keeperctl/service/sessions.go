@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+var _ Sessions = (*SessionsService)(nil)
+
+// SessionsService contains business logic related to login sessions.
+type SessionsService struct {
+	sessionsRepo repo.Sessions
+}
+
+// NewSessionsService create and initializes new SessionsService object.
+func NewSessionsService(sessions repo.Sessions) *SessionsService {
+	return &SessionsService{sessions}
+}
+
+// List returns active login sessions of the user, the most recently seen first.
+func (uc *SessionsService) List(ctx context.Context, token string) ([]*p.Session, error) {
+	sessions, err := uc.sessionsRepo.List(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("SessionsService - List - uc.sessionsRepo.List: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Revoke ends the login session of the user, so the device has to log in again.
+func (uc *SessionsService) Revoke(ctx context.Context, token string, id uuid.UUID) error {
+	if err := uc.sessionsRepo.Revoke(ctx, token, id); err != nil {
+		return fmt.Errorf("SessionsService - Revoke - uc.sessionsRepo.Revoke: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func TestListSessions(t *testing.T) {
+	expected := []*proto.Session{{Id: uuid.NewString(), DeviceName: gophtest.DeviceName, Current: true}}
+
+	m := &repo.SessionsRepoMock{}
+	m.On("List", mock.Anything, gophtest.AccessToken).
+		Return(expected, nil)
+
+	sat := service.NewSessionsService(m)
+	sessions, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, sessions)
+	m.AssertExpectations(t)
+}
+
+func TestListSessionsOnRepoFailure(t *testing.T) {
+	m := &repo.SessionsRepoMock{}
+	m.On("List", mock.Anything, gophtest.AccessToken).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewSessionsService(m)
+	_, err := sat.List(context.Background(), gophtest.AccessToken)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.SessionsRepoMock{}
+	m.On("Revoke", mock.Anything, gophtest.AccessToken, id).
+		Return(nil)
+
+	sat := service.NewSessionsService(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeSessionOnRepoFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.SessionsRepoMock{}
+	m.On("Revoke", mock.Anything, gophtest.AccessToken, id).
+		Return(gophtest.ErrUnexpected)
+
+	sat := service.NewSessionsService(m)
+	err := sat.Revoke(context.Background(), gophtest.AccessToken, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	p "github.com/derpartizanen/gophkeeper/proto"
+)
+
+// newVerifier derives SRP verifier of the auth subkey with new random salt.
+// The auth subkey itself is never sent to the service.
+func newVerifier(authKey string) (*p.SRPVerifier, error) {
+	salt, err := srp.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	return &p.SRPVerifier{Salt: salt, Verifier: srp.Verifier(salt, []byte(authKey))}, nil
+}
+
+// prove computes proof of the auth subkey for the challenge of the service.
+func prove(client *srp.Client, authKey string, challenge *p.SRPChallenge) (*p.SRPProof, error) {
+	clientProof, err := client.Proof(challenge.GetSalt(), []byte(authKey), challenge.GetServerPublic())
+	if err != nil {
+		return nil, err
+	}
+
+	return &p.SRPProof{HandshakeId: challenge.GetHandshakeId(), ClientProof: clientProof}, nil
+}
@@ -2,36 +2,721 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 
+	"github.com/google/uuid"
+
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
 var _ Users = (*UsersService)(nil)
 
+// ErrSecondFactorRequired is returned if the user must pass one-time code to proceed.
+var ErrSecondFactorRequired = stderrors.New("one-time code is required")
+
 // UsersService contains business logic related to users management.
 type UsersService struct {
-	usersRepo repo.Users
+	authRepo    repo.Auth
+	usersRepo   repo.Users
+	secretsRepo repo.Secrets
 }
 
 // NewUsersService create and initializes new UsersService object.
-func NewUsersService(users repo.Users) *UsersService {
-	return &UsersService{users}
+func NewUsersService(auth repo.Auth, users repo.Users, secrets repo.Secrets) *UsersService {
+	return &UsersService{auth, users, secrets}
 }
 
 // Register creates a new user.
+// The master key is derived from the master password with provided parameters,
+// which are stored in the service to derive the same key on login.
+// Only the verifier of the auth subkey is sent to the service, the vault key is returned.
+// If the key file is provided, it is mixed into the master key.
+// If withRecovery is set, new recovery code is generated and returned,
+// the service stores only the vault key wrapped by the key derived from it.
+// Returns tokens of the login session started on registration.
 func (uc *UsersService) Register(
 	ctx context.Context,
 	username string,
-	key encryption.Key,
-) (string, error) {
-	securityKey := key.Hash()
+	password creds.Password,
+	keyFile encryption.KeyFile,
+	kdf encryption.KDFParams,
+	withRecovery bool,
+) (Tokens, encryption.Key, string, error) {
+	var (
+		keys     encryption.Keys
+		code     string
+		recovery *p.RecoveryKit
+	)
+
+	kdf.KeyFile = !keyFile.IsEmpty()
+
+	master, err := encryption.NewKey(username, password, keyFile, kdf)
+	if err != nil {
+		return Tokens{}, keys.Vault, "", fmt.Errorf("UsersService - Register - encryption.NewKey: %w", err)
+	}
+
+	keys, err = master.Subkeys(kdf.Schedule)
+	if err != nil {
+		return Tokens{}, keys.Vault, "", fmt.Errorf("UsersService - Register - master.Subkeys: %w", err)
+	}
+
+	if withRecovery {
+		code, recovery, err = newRecoveryKit(keys.Vault)
+		if err != nil {
+			return Tokens{}, keys.Vault, "", fmt.Errorf("UsersService - Register - newRecoveryKit: %w", err)
+		}
+	}
+
+	verifier, err := newVerifier(keys.Auth)
+	if err != nil {
+		return Tokens{}, keys.Vault, "", fmt.Errorf("UsersService - Register - newVerifier: %w", err)
+	}
+
+	accessToken, refreshToken, err := uc.usersRepo.Register(ctx, username, verifier, kdfParamsToProto(kdf), recovery)
+	if err != nil {
+		return Tokens{}, keys.Vault, "", fmt.Errorf("UsersService - Register - uc.usersRepo.Register: %w", err)
+	}
+
+	return Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, keys.Vault, code, nil
+}
+
+// ChangePassword replaces master password of the user.
+// Data keys of all secrets are unwrapped with the current vault key and wrapped
+// with the key derived from the new password using provided parameters.
+// Legacy secrets encrypted by the vault key directly are re-encrypted with new data keys.
+// Blind indexes of names are recomputed with the new vault key.
+// Recovery kit is rewrapped with the new vault key, so the recovery code stays valid.
+// The key file is required by both the current and the new master key.
+// The current master password is proven with SRP handshake started right before
+// the change, as the handshake expires quickly.
+// Legacy secrets are accepted only if allowLegacy is set, see openSecretCipher.
+// Returns the new vault key.
+func (uc *UsersService) ChangePassword(
+	ctx context.Context,
+	token, username string,
+	password, newPassword creds.Password,
+	keyFile encryption.KeyFile,
+	kdf encryption.KDFParams,
+	allowLegacy bool,
+) (encryption.Key, error) {
+	vault, err := uc.rekeyVault(ctx, token, username, username, password, newPassword, keyFile, kdf, allowLegacy)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - ChangePassword - uc.rekeyVault: %w", err)
+	}
+
+	client, err := srp.NewClient()
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - ChangePassword - srp.NewClient: %w", err)
+	}
+
+	challenge, err := uc.usersRepo.StartChangePassword(ctx, token, client.Public())
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf(
+			"UsersService - ChangePassword - uc.usersRepo.StartChangePassword: %w",
+			err,
+		)
+	}
+
+	proof, err := prove(client, vault.oldKeys.Auth, challenge)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - ChangePassword - prove: %w", err)
+	}
+
+	serverProof, err := uc.usersRepo.ChangePassword(
+		ctx,
+		token,
+		proof,
+		"",
+		vault.verifier,
+		kdfParamsToProto(vault.kdf),
+		vault.version,
+		vault.secrets,
+		vault.recovery,
+	)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - ChangePassword - uc.usersRepo.ChangePassword: %w", err)
+	}
+
+	if err := client.VerifyServer(serverProof); err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - ChangePassword - client.VerifyServer: %w", err)
+	}
+
+	return vault.newKeys.Vault, nil
+}
+
+// Recover sets new master password of the user, who forgot the current one.
+// The vault key wrapped by the recovery key is received from the service
+// and unwrapped with the key derived from the recovery code,
+// then all secrets are re-encrypted as on password change.
+// The key file is mixed into the new master key if provided,
+// so the lost key file could be replaced or dropped as well.
+// If the user has two-factor authentication enabled, the one-time code is required
+// to exchange the partial token for the session tokens, and the wrapped vault key
+// is requested only after that.
+// Legacy secrets are accepted only if allowLegacy is set, see openSecretCipher.
+// Returns the session tokens and the new vault key.
+func (uc *UsersService) Recover(
+	ctx context.Context,
+	username string,
+	code, otp, newPassword creds.Password,
+	keyFile encryption.KeyFile,
+	kdf encryption.KDFParams,
+	allowLegacy bool,
+) (Tokens, encryption.Key, error) {
+	var (
+		tokens  Tokens
+		newKeys encryption.Keys
+	)
+
+	keys, err := recoveryKeys(string(code))
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - recoveryKeys: %w", err)
+	}
+
+	resp, err := uc.authRepo.Recover(ctx, username, keys.Auth)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - uc.authRepo.Recover: %w", err)
+	}
+
+	tokens = Tokens{AccessToken: resp.GetAccessToken(), RefreshToken: resp.GetRefreshToken()}
+	wrappedVault := resp.GetVaultKey()
+
+	if partialToken := resp.GetPartialToken(); partialToken != "" {
+		if otp == "" {
+			return Tokens{}, newKeys.Vault, ErrSecondFactorRequired
+		}
+
+		accessToken, refreshToken, err := uc.authRepo.VerifySecondFactor(ctx, partialToken, string(otp))
+		if err != nil {
+			return Tokens{}, newKeys.Vault, fmt.Errorf("UsersService - Recover - uc.authRepo.VerifySecondFactor: %w", err)
+		}
+
+		tokens = Tokens{AccessToken: accessToken, RefreshToken: refreshToken}
+
+		wrappedVault, err = uc.authRepo.RecoverVaultKey(ctx, tokens.AccessToken, keys.Auth)
+		if err != nil {
+			return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - uc.authRepo.RecoverVaultKey: %w", err)
+		}
+	}
+
+	oldVault, err := keys.Vault.Unwrap(wrappedVault, recoveryVaultKeyAD)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - keys.Vault.Unwrap: %w", err)
+	}
+
+	kdf.KeyFile = !keyFile.IsEmpty()
+
+	newMaster, err := encryption.NewKey(username, newPassword, keyFile, kdf)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - encryption.NewKey: %w", err)
+	}
+
+	newKeys, err = newMaster.Subkeys(kdf.Schedule)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - newMaster.Subkeys: %w", err)
+	}
+
+	recovery, err := wrapRecoveryKit(keys.Vault, newKeys.Vault)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - wrapRecoveryKit: %w", err)
+	}
+
+	reencrypted, version, err := uc.reencryptVault(ctx, tokens.AccessToken, oldVault, newKeys.Vault, allowLegacy)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - uc.reencryptVault: %w", err)
+	}
+
+	verifier, err := newVerifier(newKeys.Auth)
+	if err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - newVerifier: %w", err)
+	}
+
+	if _, err := uc.usersRepo.ChangePassword(
+		ctx,
+		tokens.AccessToken,
+		nil,
+		keys.Auth,
+		verifier,
+		kdfParamsToProto(kdf),
+		version,
+		reencrypted,
+		recovery,
+	); err != nil {
+		return tokens, newKeys.Vault, fmt.Errorf("UsersService - Recover - uc.usersRepo.ChangePassword: %w", err)
+	}
+
+	return tokens, newKeys.Vault, nil
+}
+
+// Delete removes the user together with all secrets after proving the master password
+// with SRP handshake, the same way as on password change.
+// The service must prove that it holds the verifier as well.
+func (uc *UsersService) Delete(
+	ctx context.Context,
+	token, username string,
+	password creds.Password,
+	keyFile encryption.KeyFile,
+) error {
+	resp, _, err := uc.authRepo.Prelogin(ctx, username)
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - uc.authRepo.Prelogin: %w", err)
+	}
+
+	kdf := kdfParamsFromProto(resp)
+
+	master, err := encryption.NewKey(username, password, keyFile, kdf)
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - encryption.NewKey: %w", err)
+	}
+
+	keys, err := master.Subkeys(kdf.Schedule)
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - master.Subkeys: %w", err)
+	}
+
+	client, err := srp.NewClient()
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - srp.NewClient: %w", err)
+	}
+
+	challenge, err := uc.usersRepo.StartDelete(ctx, token, client.Public())
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - uc.usersRepo.StartDelete: %w", err)
+	}
+
+	proof, err := prove(client, keys.Auth, challenge)
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - prove: %w", err)
+	}
+
+	serverProof, err := uc.usersRepo.Delete(ctx, token, proof)
+	if err != nil {
+		return fmt.Errorf("UsersService - Delete - uc.usersRepo.Delete: %w", err)
+	}
+
+	if err := client.VerifyServer(serverProof); err != nil {
+		return fmt.Errorf("UsersService - Delete - client.VerifyServer: %w", err)
+	}
+
+	return nil
+}
+
+// Rename changes username of the user.
+// The master key is derived for the new username with new key derivation parameters,
+// so all secrets are re-encrypted the same way as on password change.
+// The master password is proven with SRP handshake started right before the rename.
+// Legacy secrets are accepted only if allowLegacy is set, see openSecretCipher.
+// Returns the new vault key.
+func (uc *UsersService) Rename(
+	ctx context.Context,
+	token, username, newUsername string,
+	password creds.Password,
+	keyFile encryption.KeyFile,
+	kdf encryption.KDFParams,
+	allowLegacy bool,
+) (encryption.Key, error) {
+	vault, err := uc.rekeyVault(ctx, token, username, newUsername, password, password, keyFile, kdf, allowLegacy)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - uc.rekeyVault: %w", err)
+	}
+
+	client, err := srp.NewClient()
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - srp.NewClient: %w", err)
+	}
+
+	challenge, err := uc.usersRepo.StartRename(ctx, token, client.Public())
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - uc.usersRepo.StartRename: %w", err)
+	}
+
+	proof, err := prove(client, vault.oldKeys.Auth, challenge)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - prove: %w", err)
+	}
+
+	serverProof, err := uc.usersRepo.Rename(
+		ctx,
+		token,
+		newUsername,
+		proof,
+		vault.verifier,
+		kdfParamsToProto(vault.kdf),
+		vault.version,
+		vault.secrets,
+		vault.recovery,
+	)
+	if err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - uc.usersRepo.Rename: %w", err)
+	}
+
+	if err := client.VerifyServer(serverProof); err != nil {
+		return vault.newKeys.Vault, fmt.Errorf("UsersService - Rename - client.VerifyServer: %w", err)
+	}
+
+	return vault.newKeys.Vault, nil
+}
+
+// SetupTOTP generates new TOTP secret of the user.
+// Returns provisioning URI to add the secret to an authenticator app.
+// Two-factor authentication is not enabled until the first code is confirmed,
+// see EnableTOTP.
+func (uc *UsersService) SetupTOTP(ctx context.Context, token string) (string, error) {
+	uri, err := uc.usersRepo.SetupTOTP(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("UsersService - SetupTOTP - uc.usersRepo.SetupTOTP: %w", err)
+	}
+
+	return uri, nil
+}
+
+// EnableTOTP turns on two-factor authentication of the user.
+// Returns one-time backup codes to use if the authenticator app is lost.
+func (uc *UsersService) EnableTOTP(ctx context.Context, token, code string) ([]string, error) {
+	backupCodes, err := uc.usersRepo.EnableTOTP(ctx, token, code)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - uc.usersRepo.EnableTOTP: %w", err)
+	}
+
+	return backupCodes, nil
+}
+
+// DisableTOTP turns off two-factor authentication of the user.
+// The code is either one-time code or unused backup code.
+func (uc *UsersService) DisableTOTP(ctx context.Context, token, code string) error {
+	if err := uc.usersRepo.DisableTOTP(ctx, token, code); err != nil {
+		return fmt.Errorf("UsersService - DisableTOTP - uc.usersRepo.DisableTOTP: %w", err)
+	}
+
+	return nil
+}
+
+// rekeyedVault contains the vault prepared for the new master key.
+type rekeyedVault struct {
+	oldKeys  encryption.Keys
+	newKeys  encryption.Keys
+	kdf      encryption.KDFParams
+	verifier *p.SRPVerifier
+	version  int64
+	secrets  []*p.ReencryptedSecret
+	recovery *p.RecoveryKit
+}
+
+// rekeyVault derives the current master key and the new one
+// for the new username and password, then rewraps the recovery kit
+// and re-encrypts all secrets with the new vault key.
+// The key file is used the same way as before.
+func (uc *UsersService) rekeyVault(
+	ctx context.Context,
+	token, username, newUsername string,
+	password, newPassword creds.Password,
+	keyFile encryption.KeyFile,
+	kdf encryption.KDFParams,
+	allowLegacy bool,
+) (rekeyedVault, error) {
+	var vault rekeyedVault
+
+	resp, _, err := uc.authRepo.Prelogin(ctx, username)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - uc.authRepo.Prelogin: %w", err)
+	}
+
+	oldKDF := kdfParamsFromProto(resp)
+
+	oldMaster, err := encryption.NewKey(username, password, keyFile, oldKDF)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - encryption.NewKey(old): %w", err)
+	}
+
+	oldKeys, err := oldMaster.Subkeys(oldKDF.Schedule)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - oldMaster.Subkeys: %w", err)
+	}
+
+	kdf.KeyFile = oldKDF.KeyFile
+
+	newMaster, err := encryption.NewKey(newUsername, newPassword, keyFile, kdf)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - encryption.NewKey(new): %w", err)
+	}
+
+	vault.newKeys, err = newMaster.Subkeys(kdf.Schedule)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - newMaster.Subkeys: %w", err)
+	}
+
+	recoveryKey, err := uc.usersRepo.GetRecoveryKey(ctx, token)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - uc.usersRepo.GetRecoveryKey: %w", err)
+	}
+
+	recovery, err := rewrapRecoveryKit(oldKeys.Vault, vault.newKeys.Vault, recoveryKey)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - rewrapRecoveryKit: %w", err)
+	}
+
+	reencrypted, version, err := uc.reencryptVault(ctx, token, oldKeys.Vault, vault.newKeys.Vault, allowLegacy)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - uc.reencryptVault: %w", err)
+	}
+
+	verifier, err := newVerifier(vault.newKeys.Auth)
+	if err != nil {
+		return vault, fmt.Errorf("UsersService - rekeyVault - newVerifier: %w", err)
+	}
+
+	vault.oldKeys = oldKeys
+	vault.kdf = kdf
+	vault.verifier = verifier
+	vault.version = version
+	vault.secrets = reencrypted
+	vault.recovery = recovery
+
+	return vault, nil
+}
+
+// reencryptVault rewraps data keys of all secrets with the new vault key,
+// legacy secrets are migrated to data keys.
+// Trashed secrets and archived versions of all secrets are re-encrypted too,
+// so the history is kept and the trash could still be restored.
+// Returns re-encrypted secrets and version of the vault they were read at.
+func (uc *UsersService) reencryptVault(
+	ctx context.Context,
+	token string,
+	oldKey, newKey encryption.Key,
+	allowLegacy bool,
+) ([]*p.ReencryptedSecret, int64, error) {
+	secrets, version, err := uc.secretsRepo.List(ctx, token, nil, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("UsersService - reencryptVault - uc.secretsRepo.List: %w", err)
+	}
+
+	reencrypted := make([]*p.ReencryptedSecret, 0, len(secrets))
+
+	for _, secret := range secrets {
+		rv, err := uc.reencryptSecret(ctx, token, secret, false, oldKey, newKey, allowLegacy)
+		if err != nil {
+			return nil, 0, fmt.Errorf("UsersService - reencryptVault - uc.reencryptSecret: %w", err)
+		}
+
+		reencrypted = append(reencrypted, rv)
+	}
+
+	// The trash is listed after the vault version was read,
+	// so the vault is rejected by keeper if the trash changed meanwhile.
+	trash, err := uc.secretsRepo.ListTrash(ctx, token)
+	if err != nil {
+		return nil, 0, fmt.Errorf("UsersService - reencryptVault - uc.secretsRepo.ListTrash: %w", err)
+	}
+
+	for _, secret := range trash {
+		rv, err := uc.reencryptSecret(ctx, token, secret, true, oldKey, newKey, allowLegacy)
+		if err != nil {
+			return nil, 0, fmt.Errorf("UsersService - reencryptVault - uc.reencryptSecret(trash): %w", err)
+		}
+
+		reencrypted = append(reencrypted, rv)
+	}
+
+	return reencrypted, version, nil
+}
+
+// reencryptSecret rewraps data key of the secret with the new vault key,
+// or migrates the legacy secret if allowed, together with all its archived versions.
+// Trashed secret is looked up in the trash.
+func (uc *UsersService) reencryptSecret(
+	ctx context.Context,
+	token string,
+	secret *p.Secret,
+	trashed bool,
+	oldKey, newKey encryption.Key,
+	allowLegacy bool,
+) (*p.ReencryptedSecret, error) {
+	id, err := uuid.Parse(secret.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - reencryptSecret - uuid.Parse: %w", err)
+	}
+
+	var rv *p.ReencryptedSecret
+
+	switch {
+	case len(secret.GetDataKey()) != 0:
+		rv, err = rewrap(oldKey, newKey, secret, allowLegacy)
+	case allowLegacy:
+		rv, err = uc.migrateSecret(ctx, token, id, trashed, oldKey, newKey)
+	default:
+		err = ErrNotMigrated
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - reencryptSecret - rewrap: %w", err)
+	}
+
+	versions, err := uc.secretsRepo.ListVersions(ctx, token, id, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - reencryptSecret - uc.secretsRepo.ListVersions: %w", err)
+	}
+
+	for _, version := range versions {
+		// The current version is the secret itself.
+		if version.GetVersion() == secret.GetVersion() {
+			continue
+		}
+
+		var v *p.ReencryptedSecret
+
+		switch {
+		case len(version.GetDataKey()) != 0:
+			v, err = rewrap(oldKey, newKey, version, allowLegacy)
+		case allowLegacy:
+			v, err = uc.migrateVersion(ctx, token, id, version.GetVersion(), trashed, oldKey, newKey)
+		default:
+			err = ErrNotMigrated
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("UsersService - reencryptSecret - rewrap(version): %w", err)
+		}
+
+		rv.Versions = append(rv.Versions, &p.ReencryptedVersion{
+			Version:   version.GetVersion(),
+			DataKey:   v.GetDataKey(),
+			Name:      v.GetName(),
+			NameIndex: v.GetNameIndex(),
+			Metadata:  v.GetMetadata(),
+			Data:      v.GetData(),
+		})
+	}
+
+	return rv, nil
+}
+
+// rewrap unwraps data key of the secret with the old key and wraps it with the new one.
+// Blind index of the name is recomputed with the new key,
+// plain text name of the secret is encrypted with its data key.
+func rewrap(oldKey, newKey encryption.Key, secret *p.Secret, allowLegacy bool) (*p.ReencryptedSecret, error) {
+	c, err := openSecretCipher(oldKey, secret, allowLegacy)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := c.wrap(newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := c.openName(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	encName, nameIndex, err := c.sealName(newKey, string(name))
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &p.ReencryptedSecret{
+		Id:        secret.GetId(),
+		DataKey:   wrappedKey,
+		NameIndex: nameIndex,
+	}
+
+	if len(secret.GetNameIndex()) == 0 {
+		rv.Name = encName
+	}
+
+	return rv, nil
+}
+
+// migrateSecret re-encrypts legacy secret encrypted by the old vault key
+// with new data key wrapped by the new vault key.
+func (uc *UsersService) migrateSecret(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	trashed bool,
+	oldKey, newKey encryption.Key,
+) (*p.ReencryptedSecret, error) {
+	secret, data, err := uc.secretsRepo.Get(ctx, token, id, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - migrateSecret - uc.secretsRepo.Get: %w", err)
+	}
+
+	rv, err := migrate(oldKey, newKey, secret, data)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - migrateSecret - migrate: %w", err)
+	}
+
+	return rv, nil
+}
+
+// migrateVersion re-encrypts archived version of the secret encrypted by the old vault key
+// with new data key wrapped by the new vault key.
+func (uc *UsersService) migrateVersion(
+	ctx context.Context,
+	token string,
+	id uuid.UUID,
+	version int32,
+	trashed bool,
+	oldKey, newKey encryption.Key,
+) (*p.ReencryptedSecret, error) {
+	secret, data, err := uc.secretsRepo.GetVersion(ctx, token, id, version, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - migrateVersion - uc.secretsRepo.GetVersion: %w", err)
+	}
+
+	rv, err := migrate(oldKey, newKey, secret, data)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - migrateVersion - migrate: %w", err)
+	}
+
+	return rv, nil
+}
+
+// migrate encrypts content of the legacy secret with new data key wrapped by the new vault key.
+// Plain text name of the legacy secret is encrypted too.
+func migrate(oldKey, newKey encryption.Key, secret *p.Secret, data []byte) (*p.ReencryptedSecret, error) {
+	c, wrappedKey, err := newSecretCipher(newKey, secret.GetId(), secret.GetKind())
+	if err != nil {
+		return nil, err
+	}
+
+	encName, nameIndex, err := c.sealName(newKey, string(secret.GetName()))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := reencrypt(oldKey, c, fieldMetadata, secret.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = reencrypt(oldKey, c, fieldData, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p.ReencryptedSecret{
+		Id:        secret.GetId(),
+		DataKey:   wrappedKey,
+		Name:      encName,
+		NameIndex: nameIndex,
+		Metadata:  metadata,
+		Data:      data,
+	}, nil
+}
 
-	accessToken, err := uc.usersRepo.Register(ctx, username, securityKey)
+// reencrypt decrypts legacy field with the old key and seals it with cipher of the secret.
+func reencrypt(oldKey encryption.Key, c secretCipher, field string, data []byte) ([]byte, error) {
+	decrypted, err := oldKey.Decrypt(data)
 	if err != nil {
-		return "", fmt.Errorf("UsersService - Register - uc.usersRepo.Register: %w", err)
+		return nil, err
 	}
 
-	return accessToken, nil
+	return c.seal(field, decrypted)
 }
@@ -4,49 +4,1436 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/encryption"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperctl/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	p "github.com/derpartizanen/gophkeeper/proto"
 )
 
 func TestRegister(t *testing.T) {
-	key := newTestKey()
+	expected := newTestKeys()
 
 	m := &repo.UsersRepoMock{}
 	m.On(
 		"Register",
 		mock.Anything,
 		gophtest.Username,
-		key.Hash(),
+		verifierOf(expected.Auth),
+		newTestProtoKDFParams(),
+		(*p.RecoveryKit)(nil),
 	).
-		Return(gophtest.AccessToken, nil)
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
 
-	sat := service.NewUsersService(m)
-	token, err := sat.Register(context.Background(), gophtest.Username, key)
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, m, &repo.SecretsRepoMock{})
+	tokens, key, code, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		newTestKDFParams(),
+		false,
+	)
 
 	require.NoError(t, err)
-	require.Equal(t, gophtest.AccessToken, token)
+	require.Equal(t, service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken}, tokens)
+	require.Equal(t, expected.Vault, key)
+	require.Empty(t, code)
+	m.AssertExpectations(t)
+}
+
+func TestRegisterWithRecovery(t *testing.T) {
+	expected := newTestKeys()
+
+	var kit *p.RecoveryKit
+
+	m := &repo.UsersRepoMock{}
+	m.On(
+		"Register",
+		mock.Anything,
+		gophtest.Username,
+		verifierOf(expected.Auth),
+		newTestProtoKDFParams(),
+		mock.Anything,
+	).
+		Run(func(args mock.Arguments) {
+			kit = args.Get(4).(*p.RecoveryKit)
+		}).
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, m, &repo.SecretsRepoMock{})
+	tokens, key, code, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		newTestKDFParams(),
+		true,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, service.Tokens{AccessToken: gophtest.AccessToken, RefreshToken: gophtest.RefreshToken}, tokens)
+	require.Equal(t, expected.Vault, key)
+	require.NotNil(t, kit)
+
+	recovery := newTestRecoveryKeys(t, code)
+	require.Equal(t, recovery.Auth, kit.GetSecurityKey())
+
+	vaultKey, err := recovery.Vault.Unwrap(kit.GetVaultKey(), []byte(recoveryVaultKeyAD))
+	require.NoError(t, err)
+	require.Equal(t, expected.Vault, vaultKey)
+
+	recoveryKey, err := expected.Vault.Unwrap(kit.GetRecoveryKey(), []byte(recoveryRecoveryKeyAD))
+	require.NoError(t, err)
+	require.Equal(t, recovery.Vault, recoveryKey)
+
+	m.AssertExpectations(t)
+}
+
+func TestRegisterWithKeyFile(t *testing.T) {
+	expected := newTestKeysWithKeyFile(t)
+
+	kdf := newTestProtoKDFParams()
+	kdf.KeyFile = true
+
+	m := &repo.UsersRepoMock{}
+	m.On(
+		"Register",
+		mock.Anything,
+		gophtest.Username,
+		verifierOf(expected.Auth),
+		kdf,
+		(*p.RecoveryKit)(nil),
+	).
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, m, &repo.SecretsRepoMock{})
+	_, key, _, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		gophtest.Password,
+		newTestKeyFile(t),
+		newTestKDFParams(),
+		false,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, expected.Vault, key)
+	m.AssertExpectations(t)
+}
+
+func TestRegisterWithBadKDFParams(t *testing.T) {
+	kdf := newTestKDFParams()
+	kdf.Salt = nil
+
+	m := &repo.UsersRepoMock{}
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, m, &repo.SecretsRepoMock{})
+	_, _, _, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		kdf,
+		false,
+	)
+
+	require.ErrorIs(t, err, encryption.ErrUnsupportedKDF)
 	m.AssertExpectations(t)
 }
 
 func TestRegisterOnRepoFailure(t *testing.T) {
-	key := newTestKey()
+	keys := newTestKeys()
 
 	m := &repo.UsersRepoMock{}
 	m.On(
 		"Register",
 		mock.Anything,
 		gophtest.Username,
-		key.Hash(),
+		verifierOf(keys.Auth),
+		newTestProtoKDFParams(),
+		(*p.RecoveryKit)(nil),
 	).
-		Return("", gophtest.ErrUnexpected)
+		Return("", "", gophtest.ErrUnexpected)
 
-	sat := service.NewUsersService(m)
-	_, err := sat.Register(context.Background(), gophtest.Username, key)
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, m, &repo.SecretsRepoMock{})
+	_, _, _, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+		newTestKDFParams(),
+		false,
+	)
 
 	require.Error(t, err)
 	m.AssertExpectations(t)
 }
+
+const newPassword creds.Password = "3e2w1q"
+
+func newTestNewKDFParams() encryption.KDFParams {
+	kdf := newTestKDFParams()
+	kdf.Salt = []byte("fedcba9876543210")
+
+	return kdf
+}
+
+func newTestNewKeys(t *testing.T) encryption.Keys {
+	t.Helper()
+
+	master, err := encryption.NewKey(gophtest.Username, newPassword, encryption.KeyFile{}, newTestNewKDFParams())
+	require.NoError(t, err)
+
+	keys, err := master.Subkeys(encryption.KeyScheduleSubkeys)
+	require.NoError(t, err)
+
+	return keys
+}
+
+func newTestNewProtoKDFParams() *p.KDFParams {
+	kdf := newTestProtoKDFParams()
+	kdf.Salt = newTestNewKDFParams().Salt
+
+	return kdf
+}
+
+func TestChangePassword(t *testing.T) {
+	oldKeys := newTestKeys()
+	newKeys := newTestNewKeys(t)
+	id := uuid.New()
+
+	metadata, err := oldKeys.Vault.Encrypt([]byte(gophtest.Metadata))
+	require.NoError(t, err)
+
+	data, err := oldKeys.Vault.Encrypt([]byte(gophtest.TextData))
+	require.NoError(t, err)
+
+	secret := &p.Secret{
+		Id:       id.String(),
+		Name:     []byte(gophtest.SecretName),
+		Kind:     p.DataKind_TEXT,
+		Metadata: metadata,
+		Version:  2,
+	}
+
+	archivedData, err := oldKeys.Vault.Encrypt([]byte(gophtest.TextData + "old"))
+	require.NoError(t, err)
+
+	archived := &p.Secret{
+		Id:       id.String(),
+		Name:     []byte(gophtest.SecretName),
+		Kind:     p.DataKind_TEXT,
+		Metadata: metadata,
+		Version:  1,
+	}
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	envelopedID := uuid.New()
+	enveloped, _ := newTestTextSecret(t, envelopedID)
+	enveloped.Version = 2
+
+	envelopedArchived, _ := newTestTextSecret(t, envelopedID)
+	envelopedArchived.Version = 1
+
+	// Enveloped secret created before names were encrypted.
+	plainNamed, _ := newTestTextSecret(t, uuid.New())
+	plainNamed.Name = []byte(gophtest.SecretName + "ex")
+	plainNamed.NameIndex = nil
+
+	trashed, _ := newTestTextSecret(t, uuid.New())
+
+	trashedLegacyID := uuid.New()
+	trashedLegacy := &p.Secret{
+		Id:       trashedLegacyID.String(),
+		Name:     []byte(gophtest.SecretName),
+		Kind:     p.DataKind_TEXT,
+		Metadata: metadata,
+	}
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{secret, enveloped, plainNamed}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{trashed, trashedLegacy}, nil)
+	secretsMock.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(secret, data, nil)
+	secretsMock.On("Get", mock.Anything, gophtest.AccessToken, trashedLegacyID, true).
+		Return(trashedLegacy, data, nil)
+	secretsMock.On("ListVersions", mock.Anything, gophtest.AccessToken, id, false).
+		Return([]*p.Secret{secret, archived}, nil)
+	secretsMock.On("GetVersion", mock.Anything, gophtest.AccessToken, id, int32(1), false).
+		Return(archived, archivedData, nil)
+	secretsMock.On("ListVersions", mock.Anything, gophtest.AccessToken, envelopedID, false).
+		Return([]*p.Secret{enveloped, envelopedArchived}, nil)
+	secretsMock.On("ListVersions", mock.Anything, gophtest.AccessToken, mock.Anything, mock.Anything).
+		Return([]*p.Secret(nil), nil)
+
+	var reencrypted []*p.ReencryptedSecret
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	call := usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.Anything,
+		"",
+		verifierOf(newKeys.Auth),
+		newTestNewProtoKDFParams(),
+		gophtest.VaultVersion,
+		mock.Anything,
+		(*p.RecoveryKit)(nil),
+	)
+	newFakeSRPServer(t, oldKeys.Auth).expectChangePassword(usersMock, call, func(args mock.Arguments) {
+		reencrypted = args.Get(7).([]*p.ReencryptedSecret)
+	})
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	key, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		true,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, newKeys.Vault, key)
+	require.Len(t, reencrypted, 5)
+
+	// Legacy secret is re-encrypted with new data key.
+	require.Equal(t, id.String(), reencrypted[0].GetId())
+
+	dataKey, err := newKeys.Vault.Unwrap(
+		reencrypted[0].GetDataKey(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	decrypted, err := dataKey.Open(
+		reencrypted[0].GetMetadata(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "metadata"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.Metadata, string(decrypted))
+
+	decrypted, err = dataKey.Open(
+		reencrypted[0].GetData(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.TextData, string(decrypted))
+
+	decrypted, err = dataKey.Open(
+		reencrypted[0].GetName(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "name"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.SecretName, string(decrypted))
+
+	expectedIndex, err := newKeys.Vault.BlindIndex(gophtest.SecretName)
+	require.NoError(t, err)
+	require.Equal(t, expectedIndex, reencrypted[0].GetNameIndex())
+
+	// Archived versions are migrated along with the secret.
+	require.Len(t, reencrypted[0].GetVersions(), 1)
+	require.Equal(t, int32(1), reencrypted[0].GetVersions()[0].GetVersion())
+
+	dataKey, err = newKeys.Vault.Unwrap(
+		reencrypted[0].GetVersions()[0].GetDataKey(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	decrypted, err = dataKey.Open(
+		reencrypted[0].GetVersions()[0].GetData(),
+		newTestAssociatedData(id.String(), p.DataKind_TEXT, "data"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.TextData+"old", string(decrypted))
+
+	// Only data key is rewrapped and name index is recomputed for the rest.
+	require.Equal(t, enveloped.GetId(), reencrypted[1].GetId())
+	require.Empty(t, reencrypted[1].GetName())
+	require.Empty(t, reencrypted[1].GetMetadata())
+	require.Empty(t, reencrypted[1].GetData())
+	require.Equal(t, expectedIndex, reencrypted[1].GetNameIndex())
+	require.NotEqual(t, enveloped.GetNameIndex(), reencrypted[1].GetNameIndex())
+
+	ad := newTestAssociatedData(enveloped.GetId(), p.DataKind_TEXT, "data_key")
+
+	expected, err := oldKeys.Vault.Unwrap(enveloped.GetDataKey(), ad)
+	require.NoError(t, err)
+
+	dataKey, err = newKeys.Vault.Unwrap(reencrypted[1].GetDataKey(), ad)
+	require.NoError(t, err)
+	require.Equal(t, expected, dataKey)
+
+	require.Len(t, reencrypted[1].GetVersions(), 1)
+	require.Equal(t, int32(1), reencrypted[1].GetVersions()[0].GetVersion())
+	require.Empty(t, reencrypted[1].GetVersions()[0].GetData())
+
+	expected, err = oldKeys.Vault.Unwrap(envelopedArchived.GetDataKey(), ad)
+	require.NoError(t, err)
+
+	dataKey, err = newKeys.Vault.Unwrap(reencrypted[1].GetVersions()[0].GetDataKey(), ad)
+	require.NoError(t, err)
+	require.Equal(t, expected, dataKey)
+
+	// Plain text name is encrypted with the data key.
+	require.Equal(t, plainNamed.GetId(), reencrypted[2].GetId())
+
+	dataKey, err = newKeys.Vault.Unwrap(
+		reencrypted[2].GetDataKey(),
+		newTestAssociatedData(plainNamed.GetId(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	decrypted, err = dataKey.Open(
+		reencrypted[2].GetName(),
+		newTestAssociatedData(plainNamed.GetId(), p.DataKind_TEXT, "name"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.SecretName+"ex", string(decrypted))
+
+	expectedIndex, err = newKeys.Vault.BlindIndex(gophtest.SecretName + "ex")
+	require.NoError(t, err)
+	require.Equal(t, expectedIndex, reencrypted[2].GetNameIndex())
+
+	// Data key of trashed secret is rewrapped as well.
+	require.Equal(t, trashed.GetId(), reencrypted[3].GetId())
+
+	_, err = newKeys.Vault.Unwrap(
+		reencrypted[3].GetDataKey(),
+		newTestAssociatedData(trashed.GetId(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	// Legacy secret in trash is migrated instead of being dropped.
+	require.Equal(t, trashedLegacy.GetId(), reencrypted[4].GetId())
+
+	dataKey, err = newKeys.Vault.Unwrap(
+		reencrypted[4].GetDataKey(),
+		newTestAssociatedData(trashedLegacy.GetId(), p.DataKind_TEXT, "data_key"),
+	)
+	require.NoError(t, err)
+
+	decrypted, err = dataKey.Open(
+		reencrypted[4].GetData(),
+		newTestAssociatedData(trashedLegacy.GetId(), p.DataKind_TEXT, "data"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gophtest.TextData, string(decrypted))
+
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordKeepsKeyFile(t *testing.T) {
+	oldKeys := newTestKeysWithKeyFile(t)
+
+	oldKDF := newTestProtoKDFParams()
+	oldKDF.KeyFile = true
+
+	newKDF := newTestNewProtoKDFParams()
+	newKDF.KeyFile = true
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(oldKDF, false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	call := usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.Anything,
+		"",
+		mock.Anything,
+		newKDF,
+		gophtest.VaultVersion,
+		[]*p.ReencryptedSecret{},
+		(*p.RecoveryKit)(nil),
+	)
+	newFakeSRPServer(t, oldKeys.Auth).expectChangePassword(usersMock, call, nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		newTestKeyFile(t),
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.NoError(t, err)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithWrongPassword(t *testing.T) {
+	id := uuid.New()
+
+	data, err := newTestKeys().Vault.Encrypt([]byte(gophtest.TextData))
+	require.NoError(t, err)
+
+	secret := &p.Secret{Id: id.String(), Kind: p.DataKind_TEXT}
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{secret}, gophtest.VaultVersion, nil)
+	secretsMock.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+		Return(secret, data, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err = sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		"wrong",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		true,
+	)
+
+	require.Error(t, err)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithLegacySecretNotAllowed(t *testing.T) {
+	// The service stripped the envelope off the secret.
+	secret, _ := newTestTextSecret(t, uuid.New())
+	secret.DataKey = nil
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{secret}, gophtest.VaultVersion, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, service.ErrNotMigrated)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordOnListTrashFailure(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return(nil, gophtest.ErrUnexpected)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordOnRepoFailure(t *testing.T) {
+	server := newFakeSRPServer(t, newTestKeys().Auth)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	usersMock.On("StartChangePassword", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.Anything,
+		"",
+		mock.Anything,
+		mock.Anything,
+		gophtest.VaultVersion,
+		[]*p.ReencryptedSecret{},
+		(*p.RecoveryKit)(nil),
+	).
+		Return([]byte(nil), gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordOnStartFailure(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	usersMock.On("StartChangePassword", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithWrongServerProof(t *testing.T) {
+	server := newFakeSRPServer(t, newTestKeys().Auth)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	usersMock.On("StartChangePassword", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.Anything,
+		"",
+		mock.Anything,
+		mock.Anything,
+		gophtest.VaultVersion,
+		[]*p.ReencryptedSecret{},
+		(*p.RecoveryKit)(nil),
+	).
+		Return([]byte(gophtest.ServerProof), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, srp.ErrInvalidProof)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithRecovery(t *testing.T) {
+	oldKeys := newTestKeys()
+	newKeys := newTestNewKeys(t)
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	escrow, err := oldKeys.Vault.Wrap(recovery.Vault, []byte(recoveryRecoveryKeyAD))
+	require.NoError(t, err)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	var kit *p.RecoveryKit
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return(escrow, nil)
+	call := usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		mock.Anything,
+		"",
+		verifierOf(newKeys.Auth),
+		newTestNewProtoKDFParams(),
+		gophtest.VaultVersion,
+		[]*p.ReencryptedSecret{},
+		mock.Anything,
+	)
+	newFakeSRPServer(t, oldKeys.Auth).expectChangePassword(usersMock, call, func(args mock.Arguments) {
+		kit = args.Get(8).(*p.RecoveryKit)
+	})
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err = sat.ChangePassword(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.NoError(t, err)
+	require.NotNil(t, kit)
+	require.Empty(t, kit.GetSecurityKey())
+
+	vaultKey, err := recovery.Vault.Unwrap(kit.GetVaultKey(), []byte(recoveryVaultKeyAD))
+	require.NoError(t, err)
+	require.Equal(t, newKeys.Vault, vaultKey)
+
+	recoveryKey, err := newKeys.Vault.Unwrap(kit.GetRecoveryKey(), []byte(recoveryRecoveryKeyAD))
+	require.NoError(t, err)
+	require.Equal(t, recovery.Vault, recoveryKey)
+
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestRecover(t *testing.T) {
+	oldKeys := newTestKeys()
+	newKeys := newTestNewKeys(t)
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	vaultKey, err := recovery.Vault.Wrap(oldKeys.Vault, []byte(recoveryVaultKeyAD))
+	require.NoError(t, err)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, recovery.Auth).
+		Return(
+			&p.RecoverResponse{
+				AccessToken:  gophtest.AccessToken,
+				RefreshToken: gophtest.RefreshToken,
+				VaultKey:     vaultKey,
+			},
+			nil,
+		)
+
+	enveloped, _ := newTestTextSecret(t, uuid.New())
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{enveloped}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+	secretsMock.On("ListVersions", mock.Anything, gophtest.AccessToken, mock.Anything, mock.Anything).
+		Return([]*p.Secret(nil), nil)
+
+	var (
+		reencrypted []*p.ReencryptedSecret
+		kit         *p.RecoveryKit
+	)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		(*p.SRPProof)(nil),
+		recovery.Auth,
+		verifierOf(newKeys.Auth),
+		newTestNewProtoKDFParams(),
+		gophtest.VaultVersion,
+		mock.Anything,
+		mock.Anything,
+	).
+		Run(func(args mock.Arguments) {
+			reencrypted = args.Get(7).([]*p.ReencryptedSecret)
+			kit = args.Get(8).(*p.RecoveryKit)
+		}).
+		Return([]byte(nil), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	tokens, key, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		"",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, tokens.AccessToken)
+	require.Equal(t, gophtest.RefreshToken, tokens.RefreshToken)
+	require.Equal(t, newKeys.Vault, key)
+	require.Len(t, reencrypted, 1)
+
+	ad := newTestAssociatedData(enveloped.GetId(), p.DataKind_TEXT, "data_key")
+
+	expected, err := oldKeys.Vault.Unwrap(enveloped.GetDataKey(), ad)
+	require.NoError(t, err)
+
+	dataKey, err := newKeys.Vault.Unwrap(reencrypted[0].GetDataKey(), ad)
+	require.NoError(t, err)
+	require.Equal(t, expected, dataKey)
+
+	// Recovery code stays valid for the new master password.
+	require.NotNil(t, kit)
+
+	newVaultKey, err := recovery.Vault.Unwrap(kit.GetVaultKey(), []byte(recoveryVaultKeyAD))
+	require.NoError(t, err)
+	require.Equal(t, newKeys.Vault, newVaultKey)
+
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestRecoverWithInvalidCode(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+
+	sat := service.NewUsersService(authMock, &repo.UsersRepoMock{}, &repo.SecretsRepoMock{})
+	_, _, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		"wrong",
+		"",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, encryption.ErrInvalidRecoveryCode)
+	authMock.AssertExpectations(t)
+}
+
+func TestRecoverWithOtherCode(t *testing.T) {
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	other, err := encryption.NewRecoveryCode()
+	require.NoError(t, err)
+
+	vaultKey, err := newTestRecoveryKeys(t, other).Vault.Wrap(
+		newTestKeys().Vault,
+		[]byte(recoveryVaultKeyAD),
+	)
+	require.NoError(t, err)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, recovery.Auth).
+		Return(&p.RecoverResponse{AccessToken: gophtest.AccessToken, VaultKey: vaultKey}, nil)
+
+	sat := service.NewUsersService(authMock, &repo.UsersRepoMock{}, &repo.SecretsRepoMock{})
+	_, _, err = sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		"",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, encryption.ErrIntegrity)
+	authMock.AssertExpectations(t)
+}
+
+func TestRecoverOnRepoFailure(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, mock.Anything).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, &repo.UsersRepoMock{}, &repo.SecretsRepoMock{})
+	_, _, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		"",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+}
+
+func TestRecoverWithSecondFactor(t *testing.T) {
+	oldKeys := newTestKeys()
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	vaultKey, err := recovery.Vault.Wrap(oldKeys.Vault, []byte(recoveryVaultKeyAD))
+	require.NoError(t, err)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, recovery.Auth).
+		Return(&p.RecoverResponse{PartialToken: gophtest.PartialToken}, nil)
+	authMock.On("VerifySecondFactor", mock.Anything, gophtest.PartialToken, gophtest.OTPCode).
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
+	authMock.On("RecoverVaultKey", mock.Anything, gophtest.AccessToken, recovery.Auth).
+		Return(vaultKey, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On(
+		"ChangePassword",
+		mock.Anything,
+		gophtest.AccessToken,
+		(*p.SRPProof)(nil),
+		recovery.Auth,
+		mock.Anything,
+		mock.Anything,
+		gophtest.VaultVersion,
+		mock.Anything,
+		mock.Anything,
+	).
+		Return([]byte(nil), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	tokens, _, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		gophtest.OTPCode,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, tokens.AccessToken)
+	require.Equal(t, gophtest.RefreshToken, tokens.RefreshToken)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestRecoverWithoutOneTimeCode(t *testing.T) {
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, recovery.Auth).
+		Return(&p.RecoverResponse{PartialToken: gophtest.PartialToken}, nil)
+
+	sat := service.NewUsersService(authMock, &repo.UsersRepoMock{}, &repo.SecretsRepoMock{})
+	_, _, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		"",
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, service.ErrSecondFactorRequired)
+	authMock.AssertExpectations(t)
+}
+
+func TestRecoverOnVaultKeyFailure(t *testing.T) {
+	recovery := newTestRecoveryKeys(t, gophtest.RecoveryCode)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Recover", mock.Anything, gophtest.Username, recovery.Auth).
+		Return(&p.RecoverResponse{PartialToken: gophtest.PartialToken}, nil)
+	authMock.On("VerifySecondFactor", mock.Anything, gophtest.PartialToken, gophtest.OTPCode).
+		Return(gophtest.AccessToken, gophtest.RefreshToken, nil)
+	authMock.On("RecoverVaultKey", mock.Anything, gophtest.AccessToken, recovery.Auth).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, &repo.UsersRepoMock{}, &repo.SecretsRepoMock{})
+	_, _, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoveryCode,
+		gophtest.OTPCode,
+		newPassword,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+}
+
+func TestDeleteUser(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	newFakeSRPServer(t, newTestKeys().Auth).expectDelete(usersMock)
+
+	sat := service.NewUsersService(authMock, usersMock, &repo.SecretsRepoMock{})
+	err := sat.Delete(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+	)
+
+	require.NoError(t, err)
+	authMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestDeleteUserOnStartFailure(t *testing.T) {
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("StartDelete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Return(nil, gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, usersMock, &repo.SecretsRepoMock{})
+	err := sat.Delete(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestDeleteUserOnRepoFailure(t *testing.T) {
+	server := newFakeSRPServer(t, newTestKeys().Auth)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("StartDelete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	usersMock.On("Delete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Return([]byte(nil), gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, usersMock, &repo.SecretsRepoMock{})
+	err := sat.Delete(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestDeleteUserWithWrongServerProof(t *testing.T) {
+	server := newFakeSRPServer(t, newTestKeys().Auth)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("StartDelete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	usersMock.On("Delete", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Return([]byte(gophtest.ServerProof), nil)
+
+	sat := service.NewUsersService(authMock, usersMock, &repo.SecretsRepoMock{})
+	err := sat.Delete(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		gophtest.Password,
+		encryption.KeyFile{},
+	)
+
+	require.ErrorIs(t, err, srp.ErrInvalidProof)
+	authMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+const newUsername = "root"
+
+func TestRenameUser(t *testing.T) {
+	tt := []struct {
+		name   string
+		oldKDF *p.KDFParams
+		oldKey func() encryption.Keys
+	}{
+		{
+			name:   "Rename user",
+			oldKDF: newTestProtoKDFParams(),
+			oldKey: newTestKeys,
+		},
+		{
+			name:   "Rename user with legacy key derived from username",
+			oldKDF: &p.KDFParams{Algorithm: p.KDFAlgorithm_KDF_SHA256},
+			oldKey: func() encryption.Keys {
+				master, err := encryption.NewKey(
+					gophtest.Username,
+					gophtest.Password,
+					encryption.KeyFile{},
+					encryption.KDFParams{Algorithm: encryption.KDFSHA256},
+				)
+				require.NoError(t, err)
+
+				return encryption.Keys{Auth: master.Hash(), Vault: master}
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			oldKeys := tc.oldKey()
+
+			master, err := encryption.NewKey(newUsername, gophtest.Password, encryption.KeyFile{}, newTestNewKDFParams())
+			require.NoError(t, err)
+
+			newKeys, err := master.Subkeys(encryption.KeyScheduleSubkeys)
+			require.NoError(t, err)
+
+			id := uuid.New()
+
+			metadata, err := oldKeys.Vault.Encrypt([]byte(gophtest.Metadata))
+			require.NoError(t, err)
+
+			data, err := oldKeys.Vault.Encrypt([]byte(gophtest.TextData))
+			require.NoError(t, err)
+
+			secret := &p.Secret{
+				Id:       id.String(),
+				Name:     []byte(gophtest.SecretName),
+				Kind:     p.DataKind_TEXT,
+				Metadata: metadata,
+			}
+
+			authMock := &repo.AuthRepoMock{}
+			authMock.On("Prelogin", mock.Anything, gophtest.Username).
+				Return(tc.oldKDF, false, nil)
+
+			secretsMock := &repo.SecretsRepoMock{}
+			secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+				Return([]*p.Secret{secret}, gophtest.VaultVersion, nil)
+			secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+				Return([]*p.Secret{}, nil)
+			secretsMock.On("ListVersions", mock.Anything, gophtest.AccessToken, mock.Anything, mock.Anything).
+				Return([]*p.Secret(nil), nil)
+			secretsMock.On("Get", mock.Anything, gophtest.AccessToken, id, false).
+				Return(secret, data, nil)
+
+			var reencrypted []*p.ReencryptedSecret
+
+			usersMock := &repo.UsersRepoMock{}
+			usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+				Return([]byte(nil), nil)
+			call := usersMock.On(
+				"Rename",
+				mock.Anything,
+				gophtest.AccessToken,
+				newUsername,
+				mock.Anything,
+				verifierOf(newKeys.Auth),
+				newTestNewProtoKDFParams(),
+				gophtest.VaultVersion,
+				mock.Anything,
+				(*p.RecoveryKit)(nil),
+			)
+			newFakeSRPServer(t, oldKeys.Auth).expectRename(usersMock, call, func(args mock.Arguments) {
+				reencrypted = args.Get(7).([]*p.ReencryptedSecret)
+			})
+
+			sat := service.NewUsersService(authMock, usersMock, secretsMock)
+			key, err := sat.Rename(
+				context.Background(),
+				gophtest.AccessToken,
+				gophtest.Username,
+				newUsername,
+				gophtest.Password,
+				encryption.KeyFile{},
+				newTestNewKDFParams(),
+				true,
+			)
+
+			require.NoError(t, err)
+			require.Equal(t, newKeys.Vault, key)
+			require.Len(t, reencrypted, 1)
+
+			_, err = newKeys.Vault.Unwrap(
+				reencrypted[0].GetDataKey(),
+				newTestAssociatedData(id.String(), p.DataKind_TEXT, "data_key"),
+			)
+			require.NoError(t, err)
+
+			authMock.AssertExpectations(t)
+			secretsMock.AssertExpectations(t)
+			usersMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRenameUserOnRepoFailure(t *testing.T) {
+	server := newFakeSRPServer(t, newTestKeys().Auth)
+
+	authMock := &repo.AuthRepoMock{}
+	authMock.On("Prelogin", mock.Anything, gophtest.Username).
+		Return(newTestProtoKDFParams(), false, nil)
+
+	secretsMock := &repo.SecretsRepoMock{}
+	secretsMock.On("List", mock.Anything, gophtest.AccessToken, []p.DataKind(nil), 0).
+		Return([]*p.Secret{}, gophtest.VaultVersion, nil)
+	secretsMock.On("ListTrash", mock.Anything, gophtest.AccessToken).
+		Return([]*p.Secret{}, nil)
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("GetRecoveryKey", mock.Anything, gophtest.AccessToken).
+		Return([]byte(nil), nil)
+	usersMock.On("StartRename", mock.Anything, gophtest.AccessToken, mock.Anything).
+		Run(func(args mock.Arguments) {
+			server.start(args.Get(2).([]byte))
+		}).
+		Return(server.challenge, nil)
+	usersMock.On(
+		"Rename",
+		mock.Anything,
+		gophtest.AccessToken,
+		newUsername,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).
+		Return([]byte(nil), gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(authMock, usersMock, secretsMock)
+	_, err := sat.Rename(
+		context.Background(),
+		gophtest.AccessToken,
+		gophtest.Username,
+		newUsername,
+		gophtest.Password,
+		encryption.KeyFile{},
+		newTestNewKDFParams(),
+		false,
+	)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	authMock.AssertExpectations(t)
+	secretsMock.AssertExpectations(t)
+	usersMock.AssertExpectations(t)
+}
+
+func TestSetupTOTP(t *testing.T) {
+	uri := "otpauth://totp/GophKeeper:" + gophtest.Username
+
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("SetupTOTP", mock.Anything, gophtest.AccessToken).
+		Return(uri, nil)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	rv, err := sat.SetupTOTP(context.Background(), gophtest.AccessToken)
+
+	require.NoError(t, err)
+	require.Equal(t, uri, rv)
+	usersMock.AssertExpectations(t)
+}
+
+func TestSetupTOTPOnRepoFailure(t *testing.T) {
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("SetupTOTP", mock.Anything, gophtest.AccessToken).
+		Return("", gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	_, err := sat.SetupTOTP(context.Background(), gophtest.AccessToken)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	usersMock.AssertExpectations(t)
+}
+
+func TestEnableTOTP(t *testing.T) {
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("EnableTOTP", mock.Anything, gophtest.AccessToken, gophtest.OTPCode).
+		Return([]string{gophtest.BackupCode}, nil)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	backupCodes, err := sat.EnableTOTP(context.Background(), gophtest.AccessToken, gophtest.OTPCode)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{gophtest.BackupCode}, backupCodes)
+	usersMock.AssertExpectations(t)
+}
+
+func TestEnableTOTPOnRepoFailure(t *testing.T) {
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("EnableTOTP", mock.Anything, gophtest.AccessToken, gophtest.OTPCode).
+		Return([]string(nil), gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	_, err := sat.EnableTOTP(context.Background(), gophtest.AccessToken, gophtest.OTPCode)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	usersMock.AssertExpectations(t)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("DisableTOTP", mock.Anything, gophtest.AccessToken, gophtest.OTPCode).
+		Return(nil)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	err := sat.DisableTOTP(context.Background(), gophtest.AccessToken, gophtest.OTPCode)
+
+	require.NoError(t, err)
+	usersMock.AssertExpectations(t)
+}
+
+func TestDisableTOTPOnRepoFailure(t *testing.T) {
+	usersMock := &repo.UsersRepoMock{}
+	usersMock.On("DisableTOTP", mock.Anything, gophtest.AccessToken, gophtest.OTPCode).
+		Return(gophtest.ErrUnexpected)
+
+	sat := service.NewUsersService(&repo.AuthRepoMock{}, usersMock, &repo.SecretsRepoMock{})
+	err := sat.DisableTOTP(context.Background(), gophtest.AccessToken, gophtest.OTPCode)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	usersMock.AssertExpectations(t)
+}
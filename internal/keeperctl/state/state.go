@@ -0,0 +1,120 @@
+// Package state keeps data of keeperctl accounts between runs,
+// e.g. tokens of the login session, in files readable by the owner only.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Account is the state of the account kept between runs of keeperctl.
+type Account struct {
+	// Tokens of the login session, so the session could be revoked on logout.
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// Upgraded is set once the user has logged in with SRP,
+	// so the service can't make the client fall back to the legacy login afterwards.
+	Upgraded bool `json:"upgraded,omitempty"`
+
+	// Legacy is set while the account may have secrets encrypted before envelope and name encryption,
+	// until they are migrated on password change. Such secrets are refused otherwise,
+	// so the service can't strip the envelope off a secret.
+	Legacy bool `json:"legacy,omitempty"`
+
+	// KeyID identifies the vault key of the user, see encryption.Key.ID.
+	// It is remembered on login, so the master password typed to unlock the vault
+	// without login could be checked, as the service doesn't check it then.
+	KeyID []byte `json:"key_id,omitempty"`
+}
+
+// Store keeps state of the accounts in the directory,
+// one file per user of a particular keeperd service.
+type Store struct {
+	dir string
+}
+
+// New creates new Store object.
+func New(dir string) *Store {
+	return &Store{dir}
+}
+
+// Load returns the state of the account, empty one if nothing is kept yet.
+func (s *Store) Load(address, username string) (Account, error) {
+	var account Account
+
+	data, err := os.ReadFile(s.path(address, username))
+	if errors.Is(err, fs.ErrNotExist) {
+		return account, nil
+	}
+
+	if err != nil {
+		return account, fmt.Errorf("Store - Load - os.ReadFile: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &account); err != nil {
+		return account, fmt.Errorf("Store - Load - json.Unmarshal: %w", err)
+	}
+
+	return account, nil
+}
+
+// Save replaces the state of the account.
+// The file is replaced at once, so the state is never left half-written.
+func (s *Store) Save(address, username string, account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("Store - Save - json.Marshal: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("Store - Save - os.MkdirAll: %w", err)
+	}
+
+	// The temporary file is created readable by the owner only.
+	f, err := os.CreateTemp(s.dir, ".account-*")
+	if err != nil {
+		return fmt.Errorf("Store - Save - os.CreateTemp: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return fmt.Errorf("Store - Save - f.Write: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Store - Save - f.Close: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.path(address, username)); err != nil {
+		return fmt.Errorf("Store - Save - os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+// Remove discards the state of the account, e.g. after the account is deleted.
+func (s *Store) Remove(address, username string) error {
+	err := os.Remove(s.path(address, username))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Store - Remove - os.Remove: %w", err)
+	}
+
+	return nil
+}
+
+// path returns name of the file keeping state of the account.
+// The same username could be registered on different services.
+func (s *Store) path(address, username string) string {
+	sum := sha256.Sum256([]byte(address + "\x00" + username))
+
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,80 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperctl/state"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+const testAddress = "127.0.0.1:9090"
+
+func TestLoadMissingAccount(t *testing.T) {
+	sat := state.New(t.TempDir())
+
+	account, err := sat.Load(testAddress, gophtest.Username)
+
+	require.NoError(t, err)
+	require.Equal(t, state.Account{}, account)
+}
+
+func TestSaveAccount(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keeperctl")
+	sat := state.New(dir)
+
+	expected := state.Account{
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
+		Upgraded:     true,
+		Legacy:       true,
+		KeyID:        []byte("key-id"),
+	}
+
+	require.NoError(t, sat.Save(testAddress, gophtest.Username, expected))
+
+	account, err := sat.Load(testAddress, gophtest.Username)
+	require.NoError(t, err)
+	require.Equal(t, expected, account)
+
+	// The same user of other service has its own state.
+	account, err = sat.Load("192.168.0.10:8080", gophtest.Username)
+	require.NoError(t, err)
+	require.Equal(t, state.Account{}, account)
+
+	// Tokens are readable by the owner only.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, sat.Remove(testAddress, gophtest.Username))
+
+	account, err = sat.Load(testAddress, gophtest.Username)
+	require.NoError(t, err)
+	require.Equal(t, state.Account{}, account)
+
+	// Nothing to remove is fine.
+	require.NoError(t, sat.Remove(testAddress, gophtest.Username))
+}
+
+func TestLoadCorruptedAccount(t *testing.T) {
+	dir := t.TempDir()
+	sat := state.New(dir)
+
+	require.NoError(t, sat.Save(testAddress, gophtest.Username, state.Account{}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, entries[0].Name()), []byte("{"), 0o600))
+
+	_, err = sat.Load(testAddress, gophtest.Username)
+
+	require.Error(t, err)
+}
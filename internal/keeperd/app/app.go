@@ -13,7 +13,9 @@ import (
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/config"
 	cgrpc "github.com/derpartizanen/gophkeeper/internal/keeperd/controller/grpc"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/grpcserver"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/janitor"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
@@ -43,17 +45,29 @@ func Run(cfg *config.Config) error {
 		return fmt.Errorf("app - Run - postgres.New: %w", err)
 	}
 
+	keys, err := loadKeyring(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Run - loadKeyring: %w", err)
+	}
+
 	repos := repo.New(pg)
-	services := service.New(cfg, repos)
+	services := service.New(cfg, keys, repos)
 
 	grpcSrv, err := grpcserver.New(
 		cfg.Address,
 		cfg.CrtPath,
 		cfg.KeyPath,
+		cfg.ClientCAPath,
 		grpc.MaxRecvMsgSize(cgrpc.DefaultMaxMessageSize),
 		grpc.ChainUnaryInterceptor(
 			cgrpc.LoggingUnaryInterceptor(log),
-			cgrpc.AuthUnaryInterceptor(cfg.Secret),
+			cgrpc.PeerUnaryInterceptor(),
+			cgrpc.AuthUnaryInterceptor(keys, services.Auth, services.APITokens),
+		),
+		grpc.ChainStreamInterceptor(
+			cgrpc.LoggingStreamInterceptor(log),
+			cgrpc.PeerStreamInterceptor(),
+			cgrpc.AuthStreamInterceptor(keys, services.Auth, services.APITokens),
 		),
 	)
 	if err != nil {
@@ -63,6 +77,9 @@ func Run(cfg *config.Config) error {
 	cgrpc.RegisterRoutes(grpcSrv.Instance(), services)
 	grpcSrv.Start()
 
+	trashJanitor := janitor.New(services.Secrets, cfg.PurgeInterval, log)
+	trashJanitor.Start()
+
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt,
 		os.Interrupt,
@@ -87,7 +104,7 @@ func Run(cfg *config.Config) error {
 	defer cancel()
 
 	go func() {
-		shutdown(log, grpcSrv, pg)
+		shutdown(log, grpcSrv, trashJanitor, pg)
 		close(stopped)
 	}()
 
@@ -102,14 +119,54 @@ func Run(cfg *config.Config) error {
 	return nil
 }
 
+// loadKeyring reads keys signing and verifying access tokens.
+// The secret signs tokens if no signing key is configured.
+func loadKeyring(cfg *config.Config) (*entity.Keyring, error) {
+	signer := entity.NewSecretKey(cfg.Secret)
+
+	if cfg.SigningKeyPath != "" {
+		data, err := os.ReadFile(cfg.SigningKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile: %w", err)
+		}
+
+		signer, err = entity.ParseSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("entity.ParseSigningKey(%s): %w", cfg.SigningKeyPath, err)
+		}
+	}
+
+	verifiers := make([]entity.SigningKey, 0, len(cfg.VerificationKeyPaths))
+
+	for _, path := range cfg.VerificationKeyPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile: %w", err)
+		}
+
+		key, err := entity.ParseVerificationKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("entity.ParseVerificationKey(%s): %w", path, err)
+		}
+
+		verifiers = append(verifiers, key)
+	}
+
+	return entity.NewKeyring(signer, verifiers...), nil
+}
+
 func shutdown(
 	log *logger.Logger,
 	grpcSrv *grpcserver.Server,
+	trashJanitor *janitor.Janitor,
 	pg *postgres.Postgres,
 ) {
 	log.Info().Msg("Shutting down gRPC API...")
 	grpcSrv.Shutdown()
 
+	log.Info().Msg("Shutting down trash janitor...")
+	trashJanitor.Shutdown()
+
 	log.Info().Msg("Shutting down database connection...")
 	pg.Close()
 }
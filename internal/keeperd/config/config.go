@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -14,9 +15,29 @@ import (
 )
 
 var (
-	ErrSecretNotSet = errors.New("secret key required")
-	ErrCrtKeyNotSet = errors.New("certificate key required")
-	ErrCrtNotSet    = errors.New("service certificate required")
+	ErrSecretNotSet   = errors.New("secret key required")
+	ErrTOTPKeyNotSet  = errors.New("TOTP key required")
+	ErrTOTPKeyReused  = errors.New("TOTP key must differ from secret key")
+	ErrCrtKeyNotSet   = errors.New("certificate key required")
+	ErrCrtNotSet      = errors.New("service certificate required")
+	ErrBlobLimit      = errors.New("blob size limit must be positive")
+	ErrVersionLimit   = errors.New("version limit must be positive")
+	ErrTrashRetention = errors.New("trash retention must be positive")
+	ErrPurgeInterval  = errors.New("trash purge interval must be positive")
+)
+
+const (
+	// DefaultBlobLimit is the default limit of total size of encrypted chunks of a blob.
+	DefaultBlobLimit = 1024 * 1024 * 1024
+
+	// DefaultVersionLimit is the default number of archived revisions kept per secret.
+	DefaultVersionLimit = 10
+
+	// DefaultTrashRetention is the default period deleted secrets are kept in trash.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	// DefaultPurgeInterval is the default period between purges of expired secrets from trash.
+	DefaultPurgeInterval = time.Hour
 )
 
 type Config struct {
@@ -26,6 +47,30 @@ type Config struct {
 	CrtPath     string
 	KeyPath     string
 	LogLevel    string
+
+	// Key sealing TOTP secrets of users, kept apart from the secret signing tokens.
+	TOTPKey creds.Password
+
+	// Private key signing access tokens, tokens are signed with the secret if not set.
+	SigningKeyPath string
+
+	// Public keys of previous signing keys, tokens signed by them are still accepted.
+	VerificationKeyPaths []string
+
+	// CA verifying client certificates, clients may authenticate with certificates if set.
+	ClientCAPath string
+
+	// Maximum total size of encrypted chunks of a blob in bytes.
+	BlobLimit int64
+
+	// Maximum number of archived revisions kept per secret.
+	VersionLimit int
+
+	// Period deleted secrets are kept in trash before they are purged permanently.
+	TrashRetention time.Duration
+
+	// Period between purges of secrets kept in trash longer than the retention period.
+	PurgeInterval time.Duration
 }
 
 // Validate verifies values stored in resulting config.
@@ -34,6 +79,14 @@ func validate(cfg *Config) error {
 		return ErrSecretNotSet
 	}
 
+	if cfg.TOTPKey == "" {
+		return ErrTOTPKeyNotSet
+	}
+
+	if cfg.TOTPKey == cfg.Secret {
+		return ErrTOTPKeyReused
+	}
+
 	if cfg.CrtPath == "" {
 		return ErrCrtNotSet
 	}
@@ -42,6 +95,22 @@ func validate(cfg *Config) error {
 		return ErrCrtKeyNotSet
 	}
 
+	if cfg.BlobLimit <= 0 {
+		return ErrBlobLimit
+	}
+
+	if cfg.VersionLimit <= 0 {
+		return ErrVersionLimit
+	}
+
+	if cfg.TrashRetention <= 0 {
+		return ErrTrashRetention
+	}
+
+	if cfg.PurgeInterval <= 0 {
+		return ErrPurgeInterval
+	}
+
 	return nil
 }
 
@@ -58,9 +127,17 @@ func New() (*Config, error) {
 	flag.String("address", "", "address:port the service listens on")
 	flag.String("database-uri", "", "full Postgres database connection URI")
 	flag.String("secret", "", "secret key to sign JWT tokens")
+	flag.String("totp-key", "", "key to encrypt TOTP secrets of users, must differ from the secret key")
 	flag.String("crt-path", "", "path to server certificate")
 	flag.String("key-path", "", "path to server key certificate")
 	flag.String("log-level", "info", "log level of the service (info, warn, error, debug)")
+	flag.String("signing-key", "", "path to Ed25519 or RSA private key in PEM format to sign access tokens")
+	flag.String("verification-keys", "", "comma-separated paths to public keys still accepted to verify access tokens")
+	flag.String("client-ca-path", "", "path to certificate authority to verify client certificates")
+	flag.Int64("blob-limit", DefaultBlobLimit, "maximum total size of a blob uploaded in chunks, in bytes")
+	flag.Int("version-limit", DefaultVersionLimit, "maximum number of previous versions kept per secret")
+	flag.Duration("trash-retention", DefaultTrashRetention, "period deleted secrets are kept in trash, e.g. 720h")
+	flag.Duration("purge-interval", DefaultPurgeInterval, "period between purges of expired secrets from trash")
 
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
@@ -80,6 +157,18 @@ func New() (*Config, error) {
 		CrtPath:     viper.GetString("crt-path"),
 		KeyPath:     viper.GetString("key-path"),
 		LogLevel:    viper.GetString("log-level"),
+
+		TOTPKey: creds.Password(viper.GetString("totp-key")),
+
+		SigningKeyPath:       viper.GetString("signing-key"),
+		VerificationKeyPaths: splitPaths(viper.GetString("verification-keys")),
+
+		ClientCAPath: viper.GetString("client-ca-path"),
+
+		BlobLimit:      viper.GetInt64("blob-limit"),
+		VersionLimit:   viper.GetInt("version-limit"),
+		TrashRetention: viper.GetDuration("trash-retention"),
+		PurgeInterval:  viper.GetDuration("purge-interval"),
 	}
 
 	if err := validate(cfg); err != nil {
@@ -96,9 +185,30 @@ func (c *Config) String() string {
 	sb.WriteString(fmt.Sprintf("\t\tListening address: %s\n", c.Address))
 	sb.WriteString(fmt.Sprintf("\t\tDatabase URI: %s\n", c.DatabaseURI))
 	sb.WriteString(fmt.Sprintf("\t\tSecret: %s\n", c.Secret))
+	sb.WriteString(fmt.Sprintf("\t\tTOTP key: %s\n", c.TOTPKey))
 	sb.WriteString(fmt.Sprintf("\t\tCertificate path: %s\n", c.CrtPath))
 	sb.WriteString(fmt.Sprintf("\t\tCertificate key path: %s\n", c.KeyPath))
+	sb.WriteString(fmt.Sprintf("\t\tSigning key path: %s\n", c.SigningKeyPath))
+	sb.WriteString(fmt.Sprintf("\t\tVerification key paths: %s\n", strings.Join(c.VerificationKeyPaths, ", ")))
+	sb.WriteString(fmt.Sprintf("\t\tClient CA path: %s\n", c.ClientCAPath))
+	sb.WriteString(fmt.Sprintf("\t\tBlob size limit: %d\n", c.BlobLimit))
+	sb.WriteString(fmt.Sprintf("\t\tVersion limit: %d\n", c.VersionLimit))
+	sb.WriteString(fmt.Sprintf("\t\tTrash retention: %s\n", c.TrashRetention))
+	sb.WriteString(fmt.Sprintf("\t\tPurge interval: %s\n", c.PurgeInterval))
 	sb.WriteString(fmt.Sprintf("\t\tLog level: %s", c.LogLevel))
 
 	return sb.String()
 }
+
+// splitPaths splits comma-separated list of paths skipping empty ones.
+func splitPaths(src string) []string {
+	var rv []string
+
+	for _, path := range strings.Split(src, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			rv = append(rv, path)
+		}
+	}
+
+	return rv
+}
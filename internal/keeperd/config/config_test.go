@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,7 @@ func TestNewConfig(t *testing.T) {
 	os.Args = []string{
 		"",
 		"--secret=xxx",
+		"--totp-key=yyy",
 		"--crt-path=../../ssl/ca/keeper.crt",
 		"--key-path=../../ssl/ca/keeper.key",
 	}
@@ -36,6 +38,41 @@ func TestNewConfigFailsIfSecretNotSet(t *testing.T) {
 	require.ErrorIs(t, err, config.ErrSecretNotSet)
 }
 
+func TestNewConfigFailsIfTOTPKeyNotSet(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrTOTPKeyNotSet)
+}
+
+func TestNewConfigFailsIfTOTPKeyIsSecret(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=xxx",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrTOTPKeyReused)
+}
+
 func TestNewConfigFailsIfCrtPathNotSet(t *testing.T) {
 	initialArgs := os.Args
 
@@ -46,6 +83,7 @@ func TestNewConfigFailsIfCrtPathNotSet(t *testing.T) {
 	os.Args = []string{
 		"",
 		"--secret=xxx",
+		"--totp-key=yyy",
 	}
 
 	_, err := config.New()
@@ -63,6 +101,7 @@ func TestNewConfigFailsIfCrtKeyPathNotSet(t *testing.T) {
 	os.Args = []string{
 		"",
 		"--secret=xxx",
+		"--totp-key=yyy",
 		"--crt-path=../../ssl/ca/keeper.crt",
 	}
 
@@ -70,3 +109,222 @@ func TestNewConfigFailsIfCrtKeyPathNotSet(t *testing.T) {
 
 	require.ErrorIs(t, err, config.ErrCrtKeyNotSet)
 }
+
+func TestNewConfigWithSigningKeys(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--signing-key=../../ssl/ca/signing.key",
+		"--verification-keys=../../ssl/ca/old.pub, ,../../ssl/ca/older.pub",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, "../../ssl/ca/signing.key", sat.SigningKeyPath)
+	require.Equal(t, []string{"../../ssl/ca/old.pub", "../../ssl/ca/older.pub"}, sat.VerificationKeyPaths)
+}
+
+func TestNewConfigWithClientCA(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--client-ca-path=../../ssl/ca/root.crt",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, "../../ssl/ca/root.crt", sat.ClientCAPath)
+}
+
+func TestNewConfigWithBlobLimit(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--blob-limit=1048576",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, int64(1048576), sat.BlobLimit)
+}
+
+func TestNewConfigFailsIfBlobLimitNotPositive(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--blob-limit=0",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrBlobLimit)
+}
+
+func TestNewConfigWithVersionLimit(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--version-limit=5",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, 5, sat.VersionLimit)
+}
+
+func TestNewConfigFailsIfVersionLimitNotPositive(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--version-limit=0",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrVersionLimit)
+}
+
+func TestNewConfigWithTrashRetention(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--trash-retention=48h",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, 48*time.Hour, sat.TrashRetention)
+}
+
+func TestNewConfigFailsIfTrashRetentionNotPositive(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--trash-retention=0s",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrTrashRetention)
+}
+
+func TestNewConfigWithPurgeInterval(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--purge-interval=15m",
+	}
+
+	sat, err := config.New()
+
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, sat.PurgeInterval)
+}
+
+func TestNewConfigFailsIfPurgeIntervalNotPositive(t *testing.T) {
+	initialArgs := os.Args
+
+	defer t.Cleanup(func() {
+		os.Args = initialArgs
+	})
+
+	os.Args = []string{
+		"",
+		"--secret=xxx",
+		"--totp-key=yyy",
+		"--crt-path=../../ssl/ca/keeper.crt",
+		"--key-path=../../ssl/ca/keeper.key",
+		"--trash-retention=48h",
+		"--purge-interval=0s",
+	}
+
+	_, err := config.New()
+
+	require.ErrorIs(t, err, config.ErrPurgeInterval)
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// APITokensServer provides implementation of the APITokens API.
+type APITokensServer struct {
+	proto.UnimplementedAPITokensServer
+
+	apiTokensService service.APITokens
+}
+
+// NewAPITokensServer initializes and creates new APITokensServer.
+func NewAPITokensServer(apiTokens service.APITokens) *APITokensServer {
+	return &APITokensServer{apiTokensService: apiTokens}
+}
+
+// Create issues new API token of current user.
+// The token itself is returned only once, the service keeps just its hash.
+func (s APITokensServer) Create(
+	ctx context.Context,
+	req *proto.CreateAPITokenRequest,
+) (*proto.CreateAPITokenResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	scope, expiresAt, details := validateCreateAPITokenReq(req)
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	token, record, err := s.apiTokensService.Create(ctx, *owner, req.GetName(), scope, expiresAt)
+	if err != nil {
+		if errors.Is(err, entity.ErrAPITokenExists) {
+			return nil, status.Errorf(codes.AlreadyExists, entity.ErrAPITokenExists.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.CreateAPITokenResponse{
+		Token:    token.String(),
+		ApiToken: apiTokenToProto(record),
+	}, nil
+}
+
+// List returns API tokens of current user.
+func (s APITokensServer) List(
+	ctx context.Context,
+	_ *proto.ListAPITokensRequest,
+) (*proto.ListAPITokensResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	tokens, err := s.apiTokensService.List(ctx, owner.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	rv := make([]*proto.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		rv = append(rv, apiTokenToProto(token))
+	}
+
+	return &proto.ListAPITokensResponse{ApiTokens: rv}, nil
+}
+
+// Revoke deletes API token of current user, so it is rejected from now on.
+func (s APITokensServer) Revoke(
+	ctx context.Context,
+	req *proto.RevokeAPITokenRequest,
+) (*proto.RevokeAPITokenResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.apiTokensService.Revoke(ctx, owner.ID, id); err != nil {
+		if errors.Is(err, entity.ErrAPITokenNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrAPITokenNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RevokeAPITokenResponse{}, nil
+}
+
+func apiTokenToProto(token entity.APITokenRecord) *proto.APIToken {
+	secretIDs := make([]string, 0, len(token.SecretIDs))
+	for _, id := range token.SecretIDs {
+		secretIDs = append(secretIDs, id.String())
+	}
+
+	rv := &proto.APIToken{
+		Id:        token.ID.String(),
+		Name:      token.Name,
+		ReadOnly:  token.ReadOnly,
+		SecretIds: secretIDs,
+		CreatedAt: timestamppb.New(token.CreatedAt),
+	}
+
+	if !token.ExpiresAt.IsZero() {
+		rv.ExpiresAt = timestamppb.New(token.ExpiresAt)
+	}
+
+	return rv
+}
@@ -0,0 +1,260 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	secretID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	scope := entity.APITokenScope{ReadOnly: true, SecretIDs: []uuid.UUID{secretID}}
+	record := entity.APITokenRecord{
+		ID:            uuid.New(),
+		Name:          gophtest.APITokenName,
+		APITokenScope: scope,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     expiresAt,
+	}
+
+	m := newServicesMock()
+	m.APITokens.(*service.APITokensServiceMock).On(
+		"Create",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		gophtest.APITokenName,
+		scope,
+		expiresAt,
+	).
+		Return(entity.APIToken(gophtest.APIToken), record, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAPITokensClient(conn)
+	resp, err := client.Create(context.Background(), &proto.CreateAPITokenRequest{
+		Name:      gophtest.APITokenName,
+		ReadOnly:  true,
+		SecretIds: []string{secretID.String()},
+		ExpiresAt: timestamppb.New(expiresAt),
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.APIToken, resp.GetToken())
+	require.Equal(t, record.ID.String(), resp.GetApiToken().GetId())
+	require.Equal(t, []string{secretID.String()}, resp.GetApiToken().GetSecretIds())
+	require.True(t, resp.GetApiToken().GetReadOnly())
+	require.True(t, expiresAt.Equal(resp.GetApiToken().GetExpiresAt().AsTime()))
+	m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+}
+
+func TestCreateAPITokenFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewAPITokensClient(conn)
+	_, err := client.Create(context.Background(), &proto.CreateAPITokenRequest{Name: gophtest.APITokenName})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestCreateAPITokenWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name string
+		req  *proto.CreateAPITokenRequest
+	}{
+		{
+			name: "Create API token fails if name is empty",
+			req:  &proto.CreateAPITokenRequest{},
+		},
+		{
+			name: "Create API token fails if name is too long",
+			req:  &proto.CreateAPITokenRequest{Name: string(make([]byte, 129))},
+		},
+		{
+			name: "Create API token fails if secret ID is malformed",
+			req:  &proto.CreateAPITokenRequest{Name: gophtest.APITokenName, SecretIds: []string{"xxx"}},
+		},
+		{
+			name: "Create API token fails if token has already expired",
+			req: &proto.CreateAPITokenRequest{
+				Name:      gophtest.APITokenName,
+				ExpiresAt: timestamppb.New(time.Now().Add(-time.Hour)),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			client := proto.NewAPITokensClient(conn)
+			_, err := client.Create(context.Background(), tc.req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestCreateAPITokenOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Create API token fails if name is taken",
+			serviceErr: entity.ErrAPITokenExists,
+			expected:   codes.AlreadyExists,
+		},
+		{
+			name:       "Create API token fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.APITokens.(*service.APITokensServiceMock).On(
+				"Create",
+				mock.Anything,
+				mock.AnythingOfType("entity.User"),
+				gophtest.APITokenName,
+				mock.AnythingOfType("entity.APITokenScope"),
+				time.Time{},
+			).
+				Return(entity.APIToken(""), entity.APITokenRecord{}, tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewAPITokensClient(conn)
+			_, err := client.Create(context.Background(), &proto.CreateAPITokenRequest{Name: gophtest.APITokenName})
+
+			requireEqualCode(t, tc.expected, err)
+			m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestListAPITokens(t *testing.T) {
+	tokens := []entity.APITokenRecord{
+		{ID: uuid.New(), Name: gophtest.APITokenName, CreatedAt: time.Now()},
+	}
+
+	m := newServicesMock()
+	m.APITokens.(*service.APITokensServiceMock).On("List", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return(tokens, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAPITokensClient(conn)
+	resp, err := client.List(context.Background(), &proto.ListAPITokensRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.GetApiTokens(), 1)
+	require.Equal(t, gophtest.APITokenName, resp.GetApiTokens()[0].GetName())
+	require.Nil(t, resp.GetApiTokens()[0].GetExpiresAt())
+	m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+}
+
+func TestListAPITokensFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewAPITokensClient(conn)
+	_, err := client.List(context.Background(), &proto.ListAPITokensRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestListAPITokensOnServiceFailure(t *testing.T) {
+	m := newServicesMock()
+	m.APITokens.(*service.APITokensServiceMock).On("List", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return([]entity.APITokenRecord(nil), gophtest.ErrUnexpected)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAPITokensClient(conn)
+	_, err := client.List(context.Background(), &proto.ListAPITokensRequest{})
+
+	requireEqualCode(t, codes.Internal, err)
+	m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	id := uuid.New()
+
+	m := newServicesMock()
+	m.APITokens.(*service.APITokensServiceMock).On("Revoke", mock.Anything, mock.AnythingOfType("uuid.UUID"), id).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAPITokensClient(conn)
+	_, err := client.Revoke(context.Background(), &proto.RevokeAPITokenRequest{Id: id.String()})
+
+	require.NoError(t, err)
+	m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+}
+
+func TestRevokeAPITokenWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewAPITokensClient(conn)
+	_, err := client.Revoke(context.Background(), &proto.RevokeAPITokenRequest{Id: "xxx"})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestRevokeAPITokenOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Revoke API token fails if token is not found",
+			serviceErr: entity.ErrAPITokenNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Revoke API token fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newServicesMock()
+			m.APITokens.(*service.APITokensServiceMock).On(
+				"Revoke",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				id,
+			).
+				Return(tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewAPITokensClient(conn)
+			_, err := client.Revoke(context.Background(), &proto.RevokeAPITokenRequest{Id: id.String()})
+
+			requireEqualCode(t, tc.expected, err)
+			m.APITokens.(*service.APITokensServiceMock).AssertExpectations(t)
+		})
+	}
+}
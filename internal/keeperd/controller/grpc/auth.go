@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
@@ -24,22 +26,116 @@ func NewAuthServer(auth service.Auth) *AuthServer {
 	return &AuthServer{authService: auth}
 }
 
-// Login authenticates a user in the service.
+// Prelogin returns key derivation parameters of a user.
+func (s AuthServer) Prelogin(
+	ctx context.Context,
+	req *proto.PreloginRequest,
+) (*proto.PreloginResponse, error) {
+	if reason, ok := validateUsername(req.GetUsername()); !ok {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "username",
+					Description: reason,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	kdf, legacy, err := s.authService.Prelogin(ctx, req.GetUsername())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.PreloginResponse{KdfParams: kdfParamsToProto(kdf), LegacyLogin: legacy}, nil
+}
+
+// Login authenticates a user registered before SRP and sets the verifier.
 func (s AuthServer) Login(
 	ctx context.Context,
 	req *proto.LoginRequest,
 ) (*proto.LoginResponse, error) {
-	username := req.GetUsername()
-	key := req.GetSecurityKey()
+	if details, ok := validateLoginReq(req); !ok {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
 
-	if details, ok := validateCredentials(username, key); !ok {
+	tokens, err := s.authService.Login(
+		ctx,
+		req.GetUsername(),
+		req.GetSecurityKey(),
+		verifierFromProto(req.GetVerifier()),
+	)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.LoginResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		RefreshToken: tokens.RefreshToken.String(),
+		PartialToken: tokens.PartialToken.String(),
+	}, nil
+}
+
+// StartLogin starts SRP handshake of a user.
+func (s AuthServer) StartLogin(
+	ctx context.Context,
+	req *proto.StartLoginRequest,
+) (*proto.StartLoginResponse, error) {
+	if details, ok := validateStartLoginReq(req); !ok {
 		st := composeBadRequestError(details)
 
 		return nil, st.Err()
 	}
 
-	accessToken, err := s.authService.Login(ctx, username, key)
+	challenge, err := s.authService.StartLogin(ctx, req.GetUsername(), req.GetClientPublic())
 	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, srp.ErrInvalidPublic) {
+			return nil, status.Errorf(codes.InvalidArgument, srp.ErrInvalidPublic.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.StartLoginResponse{Challenge: challengeToProto(challenge)}, nil
+}
+
+// FinishLogin verifies proof of the session key and authenticates a user in the service.
+func (s AuthServer) FinishLogin(
+	ctx context.Context,
+	req *proto.FinishLoginRequest,
+) (*proto.FinishLoginResponse, error) {
+	proof, violations := validateProof("proof", req.GetProof())
+	if len(violations) != 0 {
+		st := composeBadRequestError(&errdetails.BadRequest{FieldViolations: violations})
+
+		return nil, st.Err()
+	}
+
+	tokens, serverProof, err := s.authService.FinishLogin(ctx, proof)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
 		if errors.Is(err, entity.ErrInvalidCredentials) {
 			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
 		}
@@ -47,5 +143,205 @@ func (s AuthServer) Login(
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
-	return &proto.LoginResponse{AccessToken: accessToken.String()}, nil
+	return &proto.FinishLoginResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		ServerProof:  serverProof,
+		RefreshToken: tokens.RefreshToken.String(),
+		PartialToken: tokens.PartialToken.String(),
+	}, nil
+}
+
+// VerifySecondFactor exchanges the partial token of the request
+// and a one-time code for full access and refresh tokens.
+func (s AuthServer) VerifySecondFactor(
+	ctx context.Context,
+	req *proto.VerifySecondFactorRequest,
+) (*proto.VerifySecondFactorResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	token := entity.TokenInfoFromContext(ctx)
+
+	if owner == nil || token == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if req.GetCode() == "" {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "code",
+					Description: MissingField,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	tokens, err := s.authService.VerifySecondFactor(ctx, *owner, *token, req.GetCode())
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidSecondFactor) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidSecondFactor.Error())
+		}
+
+		if errors.Is(err, entity.ErrTOTPNotEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, entity.ErrTOTPNotEnabled.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.VerifySecondFactorResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		RefreshToken: tokens.RefreshToken.String(),
+	}, nil
+}
+
+// Refresh issues new access token and rotates the refresh token.
+func (s AuthServer) Refresh(
+	ctx context.Context,
+	req *proto.RefreshRequest,
+) (*proto.RefreshResponse, error) {
+	if req.GetRefreshToken() == "" {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "refresh_token",
+					Description: MissingField,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	tokens, err := s.authService.Refresh(ctx, entity.RefreshToken(req.GetRefreshToken()))
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidRefreshToken) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidRefreshToken.Error())
+		}
+
+		if errors.Is(err, entity.ErrRefreshTokenReused) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrRefreshTokenReused.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RefreshResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		RefreshToken: tokens.RefreshToken.String(),
+	}, nil
+}
+
+// Logout revokes the access token of the request
+// and the refresh token of the session, if provided.
+func (s AuthServer) Logout(
+	ctx context.Context,
+	req *proto.LogoutRequest,
+) (*proto.LogoutResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	token := entity.TokenInfoFromContext(ctx)
+
+	if owner == nil || token == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	err := s.authService.Logout(ctx, owner.ID, *token, entity.RefreshToken(req.GetRefreshToken()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.LogoutResponse{}, nil
+}
+
+// Recover authenticates a user with the security key derived from the recovery code.
+func (s AuthServer) Recover(
+	ctx context.Context,
+	req *proto.RecoverRequest,
+) (*proto.RecoverResponse, error) {
+	if details, ok := validateRecoverReq(req); !ok {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	tokens, vaultKey, err := s.authService.Recover(
+		ctx,
+		req.GetUsername(),
+		req.GetRecoverySecurityKey(),
+	)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RecoverResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		VaultKey:     vaultKey,
+		RefreshToken: tokens.RefreshToken.String(),
+		PartialToken: tokens.PartialToken.String(),
+	}, nil
+}
+
+// RecoverVaultKey returns the vault key wrapped by the recovery key to current user,
+// once the second factor is verified after recovery.
+func (s AuthServer) RecoverVaultKey(
+	ctx context.Context,
+	req *proto.RecoverVaultKeyRequest,
+) (*proto.RecoverVaultKeyResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if reason, ok := validateSecurityKey(req.GetRecoverySecurityKey()); !ok {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "recovery_security_key",
+					Description: reason,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	vaultKey, err := s.authService.RecoverVaultKey(ctx, *owner, req.GetRecoverySecurityKey())
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RecoverVaultKeyResponse{VaultKey: vaultKey}, nil
+}
+
+// GetJWKS returns public keys verifying access tokens,
+// so other services could authenticate users without the signing key.
+func (s AuthServer) GetJWKS(
+	_ context.Context,
+	_ *proto.GetJWKSRequest,
+) (*proto.GetJWKSResponse, error) {
+	return &proto.GetJWKSResponse{Keys: jwksToProto(s.authService.PublicKeys())}, nil
 }
@@ -4,18 +4,118 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "google.golang.org/protobuf/proto"
 
 	cgrpc "github.com/derpartizanen/gophkeeper/internal/keeperd/controller/grpc"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+func TestPrelogin(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Prelogin",
+		mock.Anything,
+		gophtest.Username,
+	).
+		Return(newTestEntityKDFParams(), true, nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.PreloginRequest{Username: gophtest.Username}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.Prelogin(context.Background(), req)
+
+	require.NoError(t, err)
+	require.True(t, pb.Equal(newTestKDFParams(), resp.GetKdfParams()))
+	require.True(t, resp.GetLegacyLogin())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestPreloginOfUserWithKeyFile(t *testing.T) {
+	kdf := newTestEntityKDFParams()
+	kdf.KeyFile = true
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Prelogin",
+		mock.Anything,
+		gophtest.Username,
+	).
+		Return(kdf, false, nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.PreloginRequest{Username: gophtest.Username}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.Prelogin(context.Background(), req)
+
+	require.NoError(t, err)
+	require.True(t, resp.GetKdfParams().GetKeyFile())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestPreloginWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name     string
+		username string
+	}{
+		{
+			name:     "Prelogin fails if username is empty",
+			username: "",
+		},
+		{
+			name:     "Prelogin fails if username is too long",
+			username: strings.Repeat("#", cgrpc.DefaultMaxUsernameLength+1),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServer(t, newServicesMock())
+
+			req := &proto.PreloginRequest{Username: tc.username}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.Prelogin(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestPreloginOnServiceFailure(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Prelogin",
+		mock.Anything,
+		gophtest.Username,
+	).
+		Return(entity.KDFParams{}, false, gophtest.ErrUnexpected)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.PreloginRequest{Username: gophtest.Username}
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Prelogin(context.Background(), req)
+
+	requireEqualCode(t, codes.Internal, err)
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
 func TestLoginUser(t *testing.T) {
 	m := newServicesMock()
 	m.Auth.(*service.AuthServiceMock).On(
@@ -23,21 +123,24 @@ func TestLoginUser(t *testing.T) {
 		mock.Anything,
 		gophtest.Username,
 		gophtest.SecurityKey,
+		newTestEntityVerifier(),
 	).
-		Return(entity.AccessToken(gophtest.AccessToken), nil)
+		Return(newTestTokenPair(), nil)
 
 	conn := createTestServer(t, m)
 
 	req := &proto.LoginRequest{
 		Username:    gophtest.Username,
 		SecurityKey: gophtest.SecurityKey,
+		Verifier:    newTestVerifier(),
 	}
 
 	client := proto.NewAuthClient(conn)
 	resp, err := client.Login(context.Background(), req)
 
 	require.NoError(t, err)
-	require.Equal(t, gophtest.AccessToken, resp.AccessToken)
+	require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+	require.Equal(t, gophtest.RefreshToken, resp.GetRefreshToken())
 	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
 }
 
@@ -46,21 +149,49 @@ func TestLoginWithBadRequest(t *testing.T) {
 		name     string
 		username string
 		key      string
+		verifier *proto.SRPVerifier
 	}{
 		{
 			name:     "Login fails if username is empty",
 			username: "",
 			key:      gophtest.SecurityKey,
+			verifier: newTestVerifier(),
 		},
 		{
 			name:     "Login fails if security key is empty",
 			username: gophtest.Username,
 			key:      "",
+			verifier: newTestVerifier(),
 		},
 		{
 			name:     "Login fails if username is too long",
 			username: strings.Repeat("#", cgrpc.DefaultMaxUsernameLength+1),
 			key:      gophtest.SecurityKey,
+			verifier: newTestVerifier(),
+		},
+		{
+			name:     "Login fails if verifier is not set",
+			username: gophtest.Username,
+			key:      gophtest.SecurityKey,
+			verifier: nil,
+		},
+		{
+			name:     "Login fails if verifier salt is too short",
+			username: gophtest.Username,
+			key:      gophtest.SecurityKey,
+			verifier: &proto.SRPVerifier{
+				Salt:     []byte("salt"),
+				Verifier: []byte(gophtest.SRPVerifier),
+			},
+		},
+		{
+			name:     "Login fails if verifier is too long",
+			username: gophtest.Username,
+			key:      gophtest.SecurityKey,
+			verifier: &proto.SRPVerifier{
+				Salt:     []byte(gophtest.SRPSalt),
+				Verifier: make([]byte, srp.PublicLength+1),
+			},
 		},
 	}
 
@@ -71,6 +202,7 @@ func TestLoginWithBadRequest(t *testing.T) {
 			req := &proto.LoginRequest{
 				Username:    tc.username,
 				SecurityKey: tc.key,
+				Verifier:    tc.verifier,
 			}
 
 			client := proto.NewAuthClient(conn)
@@ -87,6 +219,11 @@ func TestLoginOnServiceFailure(t *testing.T) {
 		serviceErr error
 		expected   codes.Code
 	}{
+		{
+			name:       "Login fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
 		{
 			name:       "Login fails on invalid credentials",
 			serviceErr: entity.ErrInvalidCredentials,
@@ -107,14 +244,16 @@ func TestLoginOnServiceFailure(t *testing.T) {
 				mock.Anything,
 				gophtest.Username,
 				gophtest.SecurityKey,
+				newTestEntityVerifier(),
 			).
-				Return(entity.AccessToken(""), tc.serviceErr)
+				Return(entity.TokenPair{}, tc.serviceErr)
 
 			conn := createTestServer(t, m)
 
 			req := &proto.LoginRequest{
 				Username:    gophtest.Username,
 				SecurityKey: gophtest.SecurityKey,
+				Verifier:    newTestVerifier(),
 			}
 
 			client := proto.NewAuthClient(conn)
@@ -125,3 +264,735 @@ func TestLoginOnServiceFailure(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginThrottledWithRetryInfo(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Login",
+		mock.Anything,
+		gophtest.Username,
+		gophtest.SecurityKey,
+		newTestEntityVerifier(),
+	).
+		Return(entity.TokenPair{}, &entity.ThrottledError{RetryAfter: time.Minute})
+
+	conn := createTestServer(t, m)
+
+	req := &proto.LoginRequest{
+		Username:    gophtest.Username,
+		SecurityKey: gophtest.SecurityKey,
+		Verifier:    newTestVerifier(),
+	}
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Login(context.Background(), req)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	info, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.Equal(t, time.Minute, info.GetRetryDelay().AsDuration())
+}
+
+func TestStartLogin(t *testing.T) {
+	challenge := newTestChallenge()
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"StartLogin",
+		mock.Anything,
+		gophtest.Username,
+		[]byte(gophtest.ClientPublic),
+	).
+		Return(challenge, nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.StartLoginRequest{
+		Username:     gophtest.Username,
+		ClientPublic: []byte(gophtest.ClientPublic),
+	}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.StartLogin(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, challenge.HandshakeID.String(), resp.GetChallenge().GetHandshakeId())
+	require.Equal(t, challenge.Salt, resp.GetChallenge().GetSalt())
+	require.Equal(t, challenge.ServerPublic, resp.GetChallenge().GetServerPublic())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestStartLoginWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name         string
+		username     string
+		clientPublic []byte
+	}{
+		{
+			name:         "Start login fails if username is empty",
+			username:     "",
+			clientPublic: []byte(gophtest.ClientPublic),
+		},
+		{
+			name:         "Start login fails if client public is empty",
+			username:     gophtest.Username,
+			clientPublic: nil,
+		},
+		{
+			name:         "Start login fails if client public is too long",
+			username:     gophtest.Username,
+			clientPublic: make([]byte, srp.PublicLength+1),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServer(t, newServicesMock())
+
+			req := &proto.StartLoginRequest{
+				Username:     tc.username,
+				ClientPublic: tc.clientPublic,
+			}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.StartLogin(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestStartLoginOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Start login fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Start login fails on invalid client public",
+			serviceErr: srp.ErrInvalidPublic,
+			expected:   codes.InvalidArgument,
+		},
+		{
+			name:       "Start login fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On(
+				"StartLogin",
+				mock.Anything,
+				gophtest.Username,
+				[]byte(gophtest.ClientPublic),
+			).
+				Return(entity.Challenge{}, tc.serviceErr)
+
+			conn := createTestServer(t, m)
+
+			req := &proto.StartLoginRequest{
+				Username:     gophtest.Username,
+				ClientPublic: []byte(gophtest.ClientPublic),
+			}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.StartLogin(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	proof := newTestProof()
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("FinishLogin", mock.Anything, proof).
+		Return(newTestTokenPair(), []byte(gophtest.ServerProof), nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.FinishLoginRequest{Proof: proofToProto(proof)}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.FinishLogin(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+	require.Equal(t, gophtest.RefreshToken, resp.GetRefreshToken())
+	require.Equal(t, []byte(gophtest.ServerProof), resp.GetServerProof())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestFinishLoginWithSecondFactor(t *testing.T) {
+	proof := newTestProof()
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("FinishLogin", mock.Anything, proof).
+		Return(entity.TokenPair{PartialToken: gophtest.PartialToken}, []byte(gophtest.ServerProof), nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.FinishLoginRequest{Proof: proofToProto(proof)}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.FinishLogin(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Empty(t, resp.GetAccessToken())
+	require.Empty(t, resp.GetRefreshToken())
+	require.Equal(t, gophtest.PartialToken, resp.GetPartialToken())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestFinishLoginWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name  string
+		proof *proto.SRPProof
+	}{
+		{
+			name:  "Finish login fails if proof is not set",
+			proof: nil,
+		},
+		{
+			name: "Finish login fails if handshake ID is not UUID",
+			proof: &proto.SRPProof{
+				HandshakeId: "xxx",
+				ClientProof: []byte(gophtest.ClientProof),
+			},
+		},
+		{
+			name: "Finish login fails if client proof has wrong length",
+			proof: &proto.SRPProof{
+				HandshakeId: newTestProof().HandshakeID.String(),
+				ClientProof: []byte("proof"),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServer(t, newServicesMock())
+
+			req := &proto.FinishLoginRequest{Proof: tc.proof}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.FinishLogin(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestFinishLoginOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Finish login fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Finish login fails on invalid proof",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Finish login fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			proof := newTestProof()
+
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On("FinishLogin", mock.Anything, proof).
+				Return(entity.TokenPair{}, []byte(nil), tc.serviceErr)
+
+			conn := createTestServer(t, m)
+
+			req := &proto.FinishLoginRequest{Proof: proofToProto(proof)}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.FinishLogin(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("Refresh", mock.Anything, entity.RefreshToken(gophtest.RefreshToken)).
+		Return(entity.TokenPair{AccessToken: gophtest.AccessToken, RefreshToken: "next"}, nil)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.RefreshRequest{RefreshToken: gophtest.RefreshToken}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.Refresh(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+	require.Equal(t, "next", resp.GetRefreshToken())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestRefreshWithBadRequest(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Refresh(context.Background(), &proto.RefreshRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestRefreshOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Refresh fails on invalid token",
+			serviceErr: entity.ErrInvalidRefreshToken,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Refresh fails on reused token",
+			serviceErr: entity.ErrRefreshTokenReused,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Refresh fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On("Refresh", mock.Anything, entity.RefreshToken(gophtest.RefreshToken)).
+				Return(entity.TokenPair{}, tc.serviceErr)
+
+			conn := createTestServer(t, m)
+
+			req := &proto.RefreshRequest{RefreshToken: gophtest.RefreshToken}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.Refresh(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Logout",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		mock.AnythingOfType("entity.TokenInfo"),
+		entity.RefreshToken(gophtest.RefreshToken),
+	).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Logout(context.Background(), &proto.LogoutRequest{RefreshToken: gophtest.RefreshToken})
+
+	require.NoError(t, err)
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestLogoutFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Logout(context.Background(), &proto.LogoutRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestLogoutOnServiceFailure(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Logout",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		entity.RefreshToken(""),
+	).
+		Return(gophtest.ErrUnexpected)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.Logout(context.Background(), &proto.LogoutRequest{})
+
+	requireEqualCode(t, codes.Internal, err)
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"VerifySecondFactor",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		mock.AnythingOfType("entity.TokenInfo"),
+		gophtest.OTPCode,
+	).
+		Return(newTestTokenPair(), nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.VerifySecondFactorRequest{Code: gophtest.OTPCode}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.VerifySecondFactor(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+	require.Equal(t, gophtest.RefreshToken, resp.GetRefreshToken())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestVerifySecondFactorFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	req := &proto.VerifySecondFactorRequest{Code: gophtest.OTPCode}
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.VerifySecondFactor(context.Background(), req)
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestVerifySecondFactorWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.VerifySecondFactor(context.Background(), &proto.VerifySecondFactorRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestVerifySecondFactorOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Verify second factor fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Verify second factor fails on invalid code",
+			serviceErr: entity.ErrInvalidSecondFactor,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Verify second factor fails if two-factor authentication is disabled",
+			serviceErr: entity.ErrTOTPNotEnabled,
+			expected:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Verify second factor fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On(
+				"VerifySecondFactor",
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				gophtest.OTPCode,
+			).
+				Return(entity.TokenPair{}, tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			req := &proto.VerifySecondFactorRequest{Code: gophtest.OTPCode}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.VerifySecondFactor(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecoverUser(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"Recover",
+		mock.Anything,
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	).
+		Return(
+			entity.TokenPair{
+				AccessToken:  entity.AccessToken(gophtest.AccessToken),
+				RefreshToken: entity.RefreshToken(gophtest.RefreshToken),
+			},
+			[]byte(gophtest.WrappedVaultKey),
+			nil,
+		)
+
+	conn := createTestServer(t, m)
+
+	req := &proto.RecoverRequest{
+		Username:            gophtest.Username,
+		RecoverySecurityKey: gophtest.RecoverySecurityKey,
+	}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.Recover(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+	require.Equal(t, gophtest.RefreshToken, resp.GetRefreshToken())
+	require.Empty(t, resp.GetPartialToken())
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), resp.GetVaultKey())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestRecoverWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name     string
+		username string
+		key      string
+	}{
+		{
+			name:     "Recover fails if username is empty",
+			username: "",
+			key:      gophtest.RecoverySecurityKey,
+		},
+		{
+			name:     "Recover fails if recovery security key is empty",
+			username: gophtest.Username,
+			key:      "",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServer(t, newServicesMock())
+
+			req := &proto.RecoverRequest{
+				Username:            tc.username,
+				RecoverySecurityKey: tc.key,
+			}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.Recover(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestRecoverOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Recover fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Recover fails on invalid credentials",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Recover fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On(
+				"Recover",
+				mock.Anything,
+				gophtest.Username,
+				gophtest.RecoverySecurityKey,
+			).
+				Return(entity.TokenPair{}, []byte(nil), tc.serviceErr)
+
+			conn := createTestServer(t, m)
+
+			req := &proto.RecoverRequest{
+				Username:            gophtest.Username,
+				RecoverySecurityKey: gophtest.RecoverySecurityKey,
+			}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.Recover(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecoverVaultKey(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On(
+		"RecoverVaultKey",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		gophtest.RecoverySecurityKey,
+	).
+		Return([]byte(gophtest.WrappedVaultKey), nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.RecoverVaultKeyRequest{RecoverySecurityKey: gophtest.RecoverySecurityKey}
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.RecoverVaultKey(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), resp.GetVaultKey())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestRecoverVaultKeyFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	req := &proto.RecoverVaultKeyRequest{RecoverySecurityKey: gophtest.RecoverySecurityKey}
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.RecoverVaultKey(context.Background(), req)
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestRecoverVaultKeyWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewAuthClient(conn)
+	_, err := client.RecoverVaultKey(context.Background(), &proto.RecoverVaultKeyRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestRecoverVaultKeyOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Recover vault key fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Recover vault key fails on invalid credentials",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Recover vault key fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On(
+				"RecoverVaultKey",
+				mock.Anything,
+				mock.Anything,
+				gophtest.RecoverySecurityKey,
+			).
+				Return([]byte(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			req := &proto.RecoverVaultKeyRequest{RecoverySecurityKey: gophtest.RecoverySecurityKey}
+
+			client := proto.NewAuthClient(conn)
+			_, err := client.RecoverVaultKey(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	keys := []entity.JSONWebKey{
+		{
+			KeyType:   "OKP",
+			KeyID:     "kid",
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			Curve:     "Ed25519",
+			X:         "x",
+		},
+	}
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("PublicKeys").
+		Return(keys)
+
+	conn := createTestServer(t, m)
+
+	client := proto.NewAuthClient(conn)
+	resp, err := client.GetJWKS(context.Background(), &proto.GetJWKSRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.GetKeys(), 1)
+	require.Equal(t, "OKP", resp.GetKeys()[0].GetKty())
+	require.Equal(t, "kid", resp.GetKeys()[0].GetKid())
+	require.Equal(t, "sig", resp.GetKeys()[0].GetUse())
+	require.Equal(t, "EdDSA", resp.GetKeys()[0].GetAlg())
+	require.Equal(t, "Ed25519", resp.GetKeys()[0].GetCrv())
+	require.Equal(t, "x", resp.GetKeys()[0].GetX())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
@@ -4,6 +4,9 @@ import (
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 )
 
 // Craft gRPC Status with additional details regarding bad request's fields.
@@ -17,3 +20,17 @@ func composeBadRequestError(details *errdetails.BadRequest) *status.Status {
 
 	return st
 }
+
+// Craft gRPC Status telling the client when the throttled call could be retried.
+func composeThrottledError(throttled *entity.ThrottledError) *status.Status {
+	st := status.New(codes.ResourceExhausted, throttled.Error())
+
+	st, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(throttled.RetryAfter),
+	})
+	if err != nil {
+		return status.New(codes.Internal, err.Error())
+	}
+
+	return st
+}
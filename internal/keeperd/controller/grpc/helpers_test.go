@@ -2,8 +2,11 @@ package grpc_test
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -18,6 +21,7 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
 	"github.com/derpartizanen/gophkeeper/internal/logger"
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
 func requireEqualCode(t *testing.T, expected codes.Code, err error) {
@@ -29,26 +33,127 @@ func requireEqualCode(t *testing.T, expected codes.Code, err error) {
 	require.Equal(t, expected, rv.Code())
 }
 
+func newTestKDFParams() *proto.KDFParams {
+	return &proto.KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func newTestEntityKDFParams() entity.KDFParams {
+	return entity.KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func newTestRecoveryKit() *proto.RecoveryKit {
+	return &proto.RecoveryKit{
+		SecurityKey: gophtest.RecoverySecurityKey,
+		VaultKey:    []byte(gophtest.WrappedVaultKey),
+		RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+	}
+}
+
+func newTestVerifier() *proto.SRPVerifier {
+	return &proto.SRPVerifier{
+		Salt:     []byte(gophtest.SRPSalt),
+		Verifier: []byte(gophtest.SRPVerifier),
+	}
+}
+
+func newTestEntityVerifier() entity.Verifier {
+	return entity.Verifier{
+		Salt:     []byte(gophtest.SRPSalt),
+		Verifier: []byte(gophtest.SRPVerifier),
+	}
+}
+
+func newTestChallenge() entity.Challenge {
+	return entity.Challenge{
+		HandshakeID:  uuid.New(),
+		Salt:         []byte(gophtest.SRPSalt),
+		ServerPublic: []byte(gophtest.ServerPublic),
+	}
+}
+
+func newTestProof() entity.Proof {
+	return entity.Proof{
+		HandshakeID: uuid.New(),
+		ClientProof: []byte(gophtest.ClientProof),
+	}
+}
+
+func proofToProto(proof entity.Proof) *proto.SRPProof {
+	return &proto.SRPProof{
+		HandshakeId: proof.HandshakeID.String(),
+		ClientProof: proof.ClientProof,
+	}
+}
+
 func newServicesMock() service.Services {
 	return service.Services{
-		Auth:    &service.AuthServiceMock{},
-		Secrets: &service.SecretsServiceMock{},
-		Users:   &service.UsersServiceMock{},
+		APITokens: &service.APITokensServiceMock{},
+		Auth:      &service.AuthServiceMock{},
+		Secrets:   &service.SecretsServiceMock{},
+		Users:     &service.UsersServiceMock{},
 	}
 }
 
+// testSessionID is the session of access tokens injected by fakeAuthInterceptor.
+var testSessionID = uuid.New()
+
+// withFakeAuth injects a random user and a token of the test session into the context.
+func withFakeAuth(ctx context.Context) context.Context {
+	user := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+
+	token := entity.TokenInfo{
+		ID:        uuid.New(),
+		SessionID: testSessionID,
+		ExpiresAt: time.Now().Add(entity.TokenLifeTime),
+	}
+
+	return token.WithContext(user.WithContext(ctx))
+}
+
 func fakeAuthInterceptor(
 	ctx context.Context,
 	req any,
 	_ *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (any, error) {
-	user := entity.User{
-		ID:       uuid.New(),
-		Username: gophtest.Username,
-	}
+	return handler(withFakeAuth(ctx), req)
+}
+
+// fakeAuthStream overrides context of the stream with the fake auth.
+type fakeAuthStream struct {
+	grpc.ServerStream
+}
 
-	return handler(user.WithContext(ctx), req)
+func (s fakeAuthStream) Context() context.Context {
+	return withFakeAuth(s.ServerStream.Context())
+}
+
+func fakeAuthStreamInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, fakeAuthStream{ss})
 }
 
 func createTestServer(
@@ -108,5 +213,28 @@ func createTestServerWithFakeAuth(
 			cgrpc.LoggingUnaryInterceptor(log),
 			fakeAuthInterceptor,
 		),
+		grpc.ChainStreamInterceptor(
+			cgrpc.LoggingStreamInterceptor(log),
+			fakeAuthStreamInterceptor,
+		),
 	)
 }
+
+func newTestTokenPair() entity.TokenPair {
+	return entity.TokenPair{
+		AccessToken:  gophtest.AccessToken,
+		RefreshToken: gophtest.RefreshToken,
+	}
+}
+
+func newTestSigningKey(t *testing.T, private any) entity.SigningKey {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	key, err := entity.ParseSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	return key
+}
@@ -0,0 +1,26 @@
+package grpc
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// jwksToProto converts public keys to send them to client.
+func jwksToProto(keys []entity.JSONWebKey) []*proto.JSONWebKey {
+	rv := make([]*proto.JSONWebKey, 0, len(keys))
+
+	for _, key := range keys {
+		rv = append(rv, &proto.JSONWebKey{
+			Kty: key.KeyType,
+			Kid: key.KeyID,
+			Use: key.Use,
+			Alg: key.Algorithm,
+			Crv: key.Curve,
+			X:   key.X,
+			N:   key.N,
+			E:   key.E,
+		})
+	}
+
+	return rv
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// kdfParamsFromProto converts key derivation parameters received from client.
+func kdfParamsFromProto(kdf *proto.KDFParams) entity.KDFParams {
+	return entity.KDFParams{
+		Algorithm: kdf.GetAlgorithm(),
+		Salt:      kdf.GetSalt(),
+		Time:      kdf.GetTime(),
+		Memory:    kdf.GetMemory(),
+		Threads:   kdf.GetThreads(),
+
+		KeySchedule: kdf.GetKeySchedule(),
+		KeyFile:     kdf.GetKeyFile(),
+	}
+}
+
+// kdfParamsToProto converts stored key derivation parameters to send them to client.
+func kdfParamsToProto(kdf entity.KDFParams) *proto.KDFParams {
+	return &proto.KDFParams{
+		Algorithm: kdf.Algorithm,
+		Salt:      kdf.Salt,
+		Time:      kdf.Time,
+		Memory:    kdf.Memory,
+		Threads:   kdf.Threads,
+
+		KeySchedule: kdf.KeySchedule,
+		KeyFile:     kdf.KeyFile,
+	}
+}
@@ -2,20 +2,39 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"net"
 	"regexp"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
-	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/logger"
 )
 
-var methodsWithoutAuth = regexp.MustCompile(`/(Login|Register)`)
+var methodsWithoutAuth = regexp.MustCompile(`/(Prelogin|Login|StartLogin|FinishLogin|Refresh|Register|Recover|GetJWKS)$`)
+
+var methodsWithPartialAuth = regexp.MustCompile(`/VerifySecondFactor$`)
+
+// API tokens are limited to operations with secrets, see authorizeAPIToken.
+var (
+	methodsWithAPIToken = regexp.MustCompile(
+		`^/proto\.Secrets/(List|Get|Create|Update|Delete|UploadBlob|DownloadBlob|` +
+			`ListVersions|GetVersion|Restore|ListTrash|Purge)$`,
+	)
+	methodsReadOnly = regexp.MustCompile(
+		`^/proto\.Secrets/(List|Get|DownloadBlob|ListVersions|GetVersion|ListTrash)$`,
+	)
+	methodsCreatingSecret = regexp.MustCompile(`/(Create|UploadBlob)$`)
+)
 
 // LoggingUnaryInterceptor is gRPC unary server interceptor
 // which logs incoming requests and responses.
@@ -26,79 +45,378 @@ func LoggingUnaryInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		l := log.With().
-			Str("req-id", uuid.New().String()).
-			Logger()
-
-		l.Info().
-			Str("method", info.FullMethod).
-			Msg("")
+		l := requestLogger(log, info.FullMethod)
 
 		resp, err := handler(l.WithContext(ctx), req)
+		logStatus(l, err)
 
-		errStatus, ok := status.FromError(err)
-		if ok {
-			l.Info().
-				Str("status", errStatus.Code().String()).
-				Msg("")
+		return resp, err
+	}
 
-			return resp, err
-		}
+	return interceptor
+}
+
+// LoggingStreamInterceptor is gRPC stream server interceptor
+// which logs incoming streams and their final status.
+func LoggingStreamInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
+	interceptor := func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		l := requestLogger(log, info.FullMethod)
 
+		err := handler(srv, withStreamContext(ss, l.WithContext(ss.Context())))
+		logStatus(l, err)
+
+		return err
+	}
+
+	return interceptor
+}
+
+// requestLogger creates logger of a particular request and logs the called method.
+func requestLogger(log *logger.Logger, method string) zerolog.Logger {
+	l := log.With().
+		Str("req-id", uuid.New().String()).
+		Logger()
+
+	l.Info().
+		Str("method", method).
+		Msg("")
+
+	return l
+}
+
+// logStatus logs status code of the response.
+func logStatus(l zerolog.Logger, err error) {
+	errStatus, ok := status.FromError(err)
+	if ok {
 		l.Info().
-			Err(err).
+			Str("status", errStatus.Code().String()).
 			Msg("")
 
-		return resp, err
+		return
+	}
+
+	l.Info().
+		Err(err).
+		Msg("")
+}
+
+// PeerUnaryInterceptor is gRPC unary server interceptor
+// which injects IP address and device of the caller into the context,
+// so failed login attempts could be throttled per address
+// and new sessions are recorded with the device.
+// The device name is sent by client in "device-name" metadata.
+func PeerUnaryInterceptor() grpc.UnaryServerInterceptor {
+	interceptor := func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		return handler(withPeer(ctx), req)
 	}
 
 	return interceptor
 }
 
+// PeerStreamInterceptor is gRPC stream server interceptor
+// which injects IP address and device of the caller into the context of the stream,
+// see PeerUnaryInterceptor.
+func PeerStreamInterceptor() grpc.StreamServerInterceptor {
+	interceptor := func(
+		srv any,
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, withStreamContext(ss, withPeer(ss.Context())))
+	}
+
+	return interceptor
+}
+
+// withPeer injects IP address and device of the caller into the context.
+func withPeer(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = entity.WithDevice(ctx, entity.Device{
+		Name:      firstValue(md, "device-name"),
+		UserAgent: firstValue(md, "user-agent"),
+	})
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	return entity.WithPeerIP(ctx, ip)
+}
+
+// firstValue returns the first value of the metadata key, empty if there is none.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
 // AuthUnaryInterceptor is gRPC unary server interceptor extracts access token
-// from metadata and verifies it.
+// from metadata and verifies it with one of the active keys.
 // If the token is valid, request is passed further.
-// Token's subject ID is injected as user ID into the context to use later.
-func AuthUnaryInterceptor(secret creds.Password) grpc.UnaryServerInterceptor {
+// Revoked tokens are rejected, see service.Auth.Logout and service.Auth.RevokeSession.
+// Partial tokens issued before the second factor is verified
+// are accepted only by the methods which complete the login.
+// Token's subject ID is injected as user ID into the context to use later
+// together with identity of the token itself.
+// Requests without access token are authenticated with the client certificate,
+// if the caller has presented one signed by the trusted CA.
+// API tokens are passed in place of access token and only allowed within their scope.
+func AuthUnaryInterceptor(
+	keys *entity.Keyring,
+	auth service.Auth,
+	apiTokens service.APITokens,
+) grpc.UnaryServerInterceptor {
 	interceptor := func(
 		ctx context.Context,
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		if methodsWithoutAuth.MatchString(info.FullMethod) {
-			return handler(ctx, req)
+		ctx, err := authenticate(ctx, keys, auth, apiTokens, info.FullMethod, req)
+		if err != nil {
+			return nil, err
 		}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		return handler(ctx, req)
+	}
+
+	return interceptor
+}
+
+// AuthStreamInterceptor is gRPC stream server interceptor
+// authenticating the caller the same way as AuthUnaryInterceptor.
+// Messages of the stream are not available at this point,
+// so the handlers check scope of API token against particular secrets themselves.
+func AuthStreamInterceptor(
+	keys *entity.Keyring,
+	auth service.Auth,
+	apiTokens service.APITokens,
+) grpc.StreamServerInterceptor {
+	interceptor := func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(ss.Context(), keys, auth, apiTokens, info.FullMethod, nil)
+		if err != nil {
+			return err
 		}
 
-		values := md.Get("authorization")
-		if len(values) == 0 {
+		return handler(srv, withStreamContext(ss, ctx))
+	}
+
+	return interceptor
+}
+
+// authenticate verifies credentials of the caller, see AuthUnaryInterceptor.
+// Returns the context with identity of the caller injected.
+func authenticate(
+	ctx context.Context,
+	keys *entity.Keyring,
+	auth service.Auth,
+	apiTokens service.APITokens,
+	method string,
+	req any,
+) (context.Context, error) {
+	if methodsWithoutAuth.MatchString(method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) == 0 || values[0] == "" {
+		return authenticateCertificate(ctx, auth, method)
+	}
+
+	if entity.IsAPIToken(values[0]) {
+		return authenticateAPIToken(ctx, apiTokens, values[0], method, req)
+	}
+
+	claims, err := entity.TokenFromString(values[0]).Decode(keys)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Unauthorized access")
+
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if claims.Partial != methodsWithPartialAuth.MatchString(method) {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	token, err := claims.Info()
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Unauthorized access")
+
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	revoked, err := auth.IsRevoked(ctx, token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	if revoked {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	user := entity.User{
+		ID:       userID,
+		Username: claims.Username,
+	}
+
+	return token.WithContext(user.WithContext(ctx)), nil
+}
+
+// authenticateCertificate maps subject of the verified client certificate to a user.
+// Certificates are not accepted instead of partial tokens,
+// as their holders don't pass the second factor.
+func authenticateCertificate(
+	ctx context.Context,
+	auth service.Auth,
+	method string,
+) (context.Context, error) {
+	subject := certificateSubject(ctx)
+	if subject == "" || methodsWithPartialAuth.MatchString(method) {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	user, err := auth.AuthenticateCertificate(ctx, subject)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			logger.FromContext(ctx).Error().Err(err).Str("subject", subject).Msg("Unauthorized access")
+
 			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
 		}
 
-		claims, err := entity.TokenFromString(values[0]).Decode(secret)
-		if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return user.WithContext(ctx), nil
+}
+
+// authenticateAPIToken maps API token to its owner
+// and rejects requests which are not allowed by scope of the token.
+// The scope is injected into the context, so the handlers could narrow down their results.
+func authenticateAPIToken(
+	ctx context.Context,
+	apiTokens service.APITokens,
+	src string,
+	method string,
+	req any,
+) (context.Context, error) {
+	user, scope, err := apiTokens.Authenticate(ctx, entity.APIToken(entity.TokenFromString(src).String()))
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCredentials) {
 			logger.FromContext(ctx).Error().Err(err).Msg("Unauthorized access")
 
 			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
 		}
 
-		userID, err := uuid.Parse(claims.Subject)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, err.Error())
-		}
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
 
-		user := entity.User{
-			ID:       userID,
-			Username: claims.Username,
-		}
+	if !authorizeAPIToken(scope, method, req) {
+		return nil, status.Errorf(codes.PermissionDenied, entity.ErrOutOfScope.Error())
+	}
 
-		return handler(user.WithContext(ctx), req)
+	return scope.WithContext(user.WithContext(ctx)), nil
+}
+
+// authorizeAPIToken tells whether the call is allowed by scope of API token.
+// Tokens limited to particular secrets can't create new ones,
+// as the new secret would be out of the scope.
+// Malformed IDs are left for the handlers to report.
+func authorizeAPIToken(scope entity.APITokenScope, method string, req any) bool {
+	if !methodsWithAPIToken.MatchString(method) {
+		return false
 	}
 
-	return interceptor
+	if scope.ReadOnly && !methodsReadOnly.MatchString(method) {
+		return false
+	}
+
+	if len(scope.SecretIDs) == 0 {
+		return true
+	}
+
+	if methodsCreatingSecret.MatchString(method) {
+		return false
+	}
+
+	withID, ok := req.(interface{ GetId() string })
+	if !ok {
+		return true
+	}
+
+	id, err := uuid.Parse(withID.GetId())
+	if err != nil {
+		return true
+	}
+
+	return scope.AllowsSecret(id)
+}
+
+// certificateSubject returns common name of the client certificate verified during TLS handshake.
+// Returns empty string if the client hasn't presented a certificate.
+func certificateSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+
+	chains := tlsInfo.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return ""
+	}
+
+	return chains[0][0].Subject.CommonName
+}
+
+// contextStream overrides context of the server stream,
+// so values injected by interceptors reach the handler.
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+// Context returns the overridden context of the stream.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// withStreamContext wraps the stream to pass the context to the handler.
+func withStreamContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ServerStream: ss, ctx: ctx}
 }
@@ -0,0 +1,16 @@
+package grpc
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// recoveryKitFromProto converts recovery kit received from client.
+// Returns empty kit if recovery is not set up.
+func recoveryKitFromProto(kit *proto.RecoveryKit) entity.RecoveryKit {
+	return entity.RecoveryKit{
+		SecurityKey: kit.GetSecurityKey(),
+		VaultKey:    kit.GetVaultKey(),
+		RecoveryKey: kit.GetRecoveryKey(),
+	}
+}
@@ -9,16 +9,22 @@ import (
 )
 
 // DefaultMaxMessageSize suggests limit for maximum length of gRPC message.
-const DefaultMaxMessageSize = DefaultDataLimit + DefaultMetadataLimit + 2*DefaultMaxSecretNameLength
+const DefaultMaxMessageSize = DefaultDataLimit + DefaultMetadataLimit + 2*DefaultSecretNameLimit
 
 // RegisterRoutes injects new routes into the provided gRPC server.
 func RegisterRoutes(server *grpc.Server, services *service.Services) {
+	apiTokens := NewAPITokensServer(services.APITokens)
+	proto.RegisterAPITokensServer(server, apiTokens)
+
 	auth := NewAuthServer(services.Auth)
 	proto.RegisterAuthServer(server, auth)
 
 	secrets := NewSecretsServer(services.Secrets)
 	proto.RegisterSecretsServer(server, secrets)
 
+	sessions := NewSessionsServer(services.Auth)
+	proto.RegisterSessionsServer(server, sessions)
+
 	users := NewUsersServer(services.Users)
 	proto.RegisterUsersServer(server, users)
 }
@@ -3,16 +3,21 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+var errInvalidChunk = errors.New("invalid chunk")
+
 // SecretsServer provides implementation of the Secrets API.
 type SecretsServer struct {
 	proto.UnimplementedSecretsServer
@@ -35,21 +40,24 @@ func (s SecretsServer) Create(
 		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
 	}
 
-	if details, ok := validateCreateSecretReq(req); !ok {
+	id, details := validateCreateSecretReq(req)
+	if details != nil {
 		st := composeBadRequestError(details)
 
 		return nil, st.Err()
 	}
 
-	id, err := s.secretsService.Create(
+	if err := s.secretsService.Create(
 		ctx,
 		owner.ID,
+		id,
 		req.GetName(),
+		req.GetNameIndex(),
 		req.GetKind(),
+		req.GetDataKey(),
 		req.GetMetadata(),
 		req.GetData(),
-	)
-	if err != nil {
+	); err != nil {
 		if errors.Is(err, entity.ErrSecretExists) {
 			return nil, status.Errorf(codes.AlreadyExists, entity.ErrSecretExists.Error())
 		}
@@ -60,32 +68,122 @@ func (s SecretsServer) Create(
 	return &proto.CreateSecretResponse{Id: id.String()}, nil
 }
 
-// List retrieves list of the secrets stored a user.
+// UploadBlob creates new binary secret for a user from the stream of encrypted chunks.
+// The first message of the stream carries the header of the secret,
+// the rest carry the chunks which are stored as is.
+func (s SecretsServer) UploadBlob(stream proto.Secrets_UploadBlobServer) error {
+	ctx := stream.Context()
+
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	req, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	id, details := validateBlobHeader(req.GetHeader())
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return st.Err()
+	}
+
+	header := req.GetHeader()
+
+	chunks := func() ([]byte, error) {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		chunk := req.GetChunk()
+		if reason, ok := validateBlobChunk(chunk); !ok {
+			return nil, fmt.Errorf("%w: %s", errInvalidChunk, reason)
+		}
+
+		return chunk, nil
+	}
+
+	if err := s.secretsService.UploadBlob(
+		ctx,
+		owner.ID,
+		id,
+		header.GetName(),
+		header.GetNameIndex(),
+		header.GetDataKey(),
+		header.GetMetadata(),
+		chunks,
+	); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrSecretExists):
+			return status.Errorf(codes.AlreadyExists, entity.ErrSecretExists.Error())
+
+		case errors.Is(err, entity.ErrBlobTooLarge):
+			return status.Errorf(codes.ResourceExhausted, entity.ErrBlobTooLarge.Error())
+
+		case errors.Is(err, entity.ErrEmptyBlob), errors.Is(err, errInvalidChunk):
+			return status.Errorf(codes.InvalidArgument, err.Error())
+		}
+
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+
+		return status.Errorf(codes.Internal, err.Error())
+	}
+
+	return stream.SendAndClose(&proto.UploadBlobResponse{Id: id.String()})
+}
+
+// List retrieves a page of the secrets stored a user.
+// Requests made with API token get only the secrets within its scope.
 func (s SecretsServer) List(
 	ctx context.Context,
-	_ *proto.ListSecretsRequest,
+	req *proto.ListSecretsRequest,
 ) (*proto.ListSecretsResponse, error) {
 	owner := entity.UserFromContext(ctx)
 	if owner == nil {
 		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
 	}
 
-	data, err := s.secretsService.List(ctx, owner.ID)
+	filter, details := validateListSecretsReq(req)
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	if scope := entity.APITokenScopeFromContext(ctx); scope != nil {
+		filter.IDs = scope.SecretIDs
+	}
+
+	page, err := s.secretsService.List(ctx, owner.ID, filter)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
-	rv := make([]*proto.Secret, 0, len(data))
-	for _, val := range data {
+	rv := make([]*proto.Secret, 0, len(page.Secrets))
+	for _, val := range page.Secrets {
 		rv = append(rv, &proto.Secret{
-			Id:       val.ID.String(),
-			Name:     val.Name,
-			Kind:     val.Kind,
-			Metadata: val.Metadata,
+			Id:        val.ID.String(),
+			Name:      val.Name,
+			NameIndex: val.NameIndex,
+			Kind:      val.Kind,
+			DataKey:   val.DataKey,
+			Metadata:  val.Metadata,
+			BlobSize:  val.BlobSize,
+			Version:   val.Version,
 		})
 	}
 
-	return &proto.ListSecretsResponse{Secrets: rv}, nil
+	return &proto.ListSecretsResponse{
+		Secrets:       rv,
+		VaultVersion:  page.VaultVersion,
+		NextPageToken: entity.NewPageToken(page.Next),
+	}, nil
 }
 
 // Get returns particular secret with data.
@@ -103,7 +201,7 @@ func (s SecretsServer) Get(
 		return nil, status.Errorf(codes.InvalidArgument, err.Error())
 	}
 
-	secret, err := s.secretsService.Get(ctx, owner.ID, id)
+	secret, err := s.secretsService.Get(ctx, owner.ID, id, req.GetTrashed())
 	if err != nil {
 		if errors.Is(err, entity.ErrSecretNotFound) {
 			return nil, status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
@@ -114,15 +212,93 @@ func (s SecretsServer) Get(
 
 	return &proto.GetSecretResponse{
 		Secret: &proto.Secret{
-			Id:       secret.ID.String(),
-			Name:     secret.Name,
-			Kind:     secret.Kind,
-			Metadata: secret.Metadata,
+			Id:        secret.ID.String(),
+			Name:      secret.Name,
+			NameIndex: secret.NameIndex,
+			Kind:      secret.Kind,
+			DataKey:   secret.DataKey,
+			Metadata:  secret.Metadata,
+			BlobSize:  secret.BlobSize,
+			Version:   secret.Version,
 		},
 		Data: secret.Data,
 	}, nil
 }
 
+// DownloadBlob streams binary secret stored as a blob.
+// The secret info is sent in the first message followed by the encrypted chunks.
+// Requests made with API token get only the secrets within its scope.
+func (s SecretsServer) DownloadBlob(
+	req *proto.DownloadBlobRequest,
+	stream proto.Secrets_DownloadBlobServer,
+) error {
+	ctx := stream.Context()
+
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if scope := entity.APITokenScopeFromContext(ctx); scope != nil && !scope.AllowsSecret(id) {
+		return status.Errorf(codes.PermissionDenied, entity.ErrOutOfScope.Error())
+	}
+
+	secret, err := s.secretsService.Get(ctx, owner.ID, id, false)
+	if err != nil {
+		if errors.Is(err, entity.ErrSecretNotFound) {
+			return status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
+		}
+
+		return status.Errorf(codes.Internal, err.Error())
+	}
+
+	if !secret.IsBlob() {
+		return status.Errorf(codes.FailedPrecondition, entity.ErrNotBlob.Error())
+	}
+
+	if err := stream.Send(&proto.DownloadBlobResponse{
+		Part: &proto.DownloadBlobResponse_Secret{
+			Secret: &proto.Secret{
+				Id:        secret.ID.String(),
+				Name:      secret.Name,
+				NameIndex: secret.NameIndex,
+				Kind:      secret.Kind,
+				DataKey:   secret.DataKey,
+				Metadata:  secret.Metadata,
+				BlobSize:  secret.BlobSize,
+				Version:   secret.Version,
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	sink := func(chunk []byte) error {
+		return stream.Send(&proto.DownloadBlobResponse{
+			Part: &proto.DownloadBlobResponse_Chunk{Chunk: chunk},
+		})
+	}
+
+	if err := s.secretsService.DownloadBlob(ctx, owner.ID, id, sink); err != nil {
+		if errors.Is(err, entity.ErrSecretNotFound) {
+			return status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
+		}
+
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+
+		return status.Errorf(codes.Internal, err.Error())
+	}
+
+	return nil
+}
+
 // Update updates particular secret stored by a user.
 func (s SecretsServer) Update(
 	ctx context.Context,
@@ -147,8 +323,11 @@ func (s SecretsServer) Update(
 		ctx,
 		owner.ID,
 		id,
+		req.GetVersion(),
 		mask.GetPaths(),
 		req.GetName(),
+		req.GetNameIndex(),
+		req.GetDataKey(),
 		req.GetMetadata(),
 		req.GetData(),
 	); err != nil {
@@ -160,13 +339,139 @@ func (s SecretsServer) Update(
 			return nil, status.Errorf(codes.AlreadyExists, entity.ErrSecretNameConflict.Error())
 		}
 
+		if errors.Is(err, entity.ErrSecretModified) {
+			return nil, status.Errorf(codes.Aborted, entity.ErrSecretModified.Error())
+		}
+
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
 	return &proto.UpdateSecretResponse{}, nil
 }
 
-// Delete removes particular secret stored by a user.
+// ListVersions returns versions of particular secret without data, the latest go first.
+func (s SecretsServer) ListVersions(
+	ctx context.Context,
+	req *proto.ListSecretVersionsRequest,
+) (*proto.ListSecretVersionsResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	versions, err := s.secretsService.ListVersions(ctx, owner.ID, id, req.GetTrashed())
+	if err != nil {
+		if errors.Is(err, entity.ErrSecretNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	rv := make([]*proto.Secret, 0, len(versions))
+	for _, val := range versions {
+		rv = append(rv, &proto.Secret{
+			Id:        val.ID.String(),
+			Name:      val.Name,
+			NameIndex: val.NameIndex,
+			Kind:      val.Kind,
+			DataKey:   val.DataKey,
+			Metadata:  val.Metadata,
+			BlobSize:  val.BlobSize,
+			Version:   val.Version,
+			UpdatedAt: timestamppb.New(val.UpdatedAt),
+		})
+	}
+
+	return &proto.ListSecretVersionsResponse{Versions: rv}, nil
+}
+
+// GetVersion returns particular version of a secret with data.
+func (s SecretsServer) GetVersion(
+	ctx context.Context,
+	req *proto.GetSecretVersionRequest,
+) (*proto.GetSecretVersionResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, details := validateSecretVersionReq(req)
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	secret, err := s.secretsService.GetVersion(ctx, owner.ID, id, req.GetVersion(), req.GetTrashed())
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrVersionNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.GetSecretVersionResponse{
+		Secret: &proto.Secret{
+			Id:        secret.ID.String(),
+			Name:      secret.Name,
+			NameIndex: secret.NameIndex,
+			Kind:      secret.Kind,
+			DataKey:   secret.DataKey,
+			Metadata:  secret.Metadata,
+			BlobSize:  secret.BlobSize,
+			Version:   secret.Version,
+			UpdatedAt: timestamppb.New(secret.UpdatedAt),
+		},
+		Data: secret.Data,
+	}, nil
+}
+
+// Restore makes content of particular version of a secret current again,
+// or moves a secret back from trash if the version is 0.
+func (s SecretsServer) Restore(
+	ctx context.Context,
+	req *proto.RestoreSecretRequest,
+) (*proto.RestoreSecretResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, details := validateRestoreSecretReq(req)
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	version, err := s.secretsService.Restore(ctx, owner.ID, id, req.GetVersion())
+	if err != nil {
+		if errors.Is(err, entity.ErrSecretNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
+		}
+
+		if errors.Is(err, entity.ErrVersionNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrVersionNotFound.Error())
+		}
+
+		if errors.Is(err, entity.ErrSecretNameConflict) {
+			return nil, status.Errorf(codes.AlreadyExists, entity.ErrSecretNameConflict.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RestoreSecretResponse{Version: version}, nil
+}
+
+// Delete moves particular secret stored by a user to trash.
 func (s SecretsServer) Delete(
 	ctx context.Context,
 	req *proto.DeleteSecretRequest,
@@ -191,3 +496,70 @@ func (s SecretsServer) Delete(
 
 	return &proto.DeleteSecretResponse{}, nil
 }
+
+// ListTrash returns trashed secrets of a user without data, the latest deleted go first.
+// Requests made with API token get only the secrets within its scope.
+func (s SecretsServer) ListTrash(
+	ctx context.Context,
+	_ *proto.ListTrashRequest,
+) (*proto.ListTrashResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	secrets, err := s.secretsService.ListTrash(ctx, owner.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	scope := entity.APITokenScopeFromContext(ctx)
+
+	rv := make([]*proto.Secret, 0, len(secrets))
+	for _, val := range secrets {
+		if scope != nil && !scope.AllowsSecret(val.ID) {
+			continue
+		}
+
+		rv = append(rv, &proto.Secret{
+			Id:        val.ID.String(),
+			Name:      val.Name,
+			NameIndex: val.NameIndex,
+			Kind:      val.Kind,
+			DataKey:   val.DataKey,
+			Metadata:  val.Metadata,
+			BlobSize:  val.BlobSize,
+			Version:   val.Version,
+			UpdatedAt: timestamppb.New(val.UpdatedAt),
+			DeletedAt: timestamppb.New(val.DeletedAt),
+		})
+	}
+
+	return &proto.ListTrashResponse{Secrets: rv}, nil
+}
+
+// Purge removes particular secret stored by a user permanently, either trashed or not.
+func (s SecretsServer) Purge(
+	ctx context.Context,
+	req *proto.PurgeSecretRequest,
+) (*proto.PurgeSecretResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.secretsService.Purge(ctx, owner.ID, id); err != nil {
+		if errors.Is(err, entity.ErrSecretNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrSecretNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.PurgeSecretResponse{}, nil
+}
@@ -2,13 +2,17 @@ package grpc_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
@@ -31,8 +35,9 @@ func doListSecrets(
 		"List",
 		mock.Anything,
 		mock.AnythingOfType("uuid.UUID"),
+		entity.SecretsFilter{Limit: cgrpc.DefaultListPageSize},
 	).
-		Return(mockRV, mockErr)
+		Return(entity.SecretsPage{Secrets: mockRV, VaultVersion: gophtest.VaultVersion}, mockErr)
 
 	conn := createTestServerWithFakeAuth(t, m)
 	req := &proto.ListSecretsRequest{}
@@ -58,6 +63,7 @@ func doGetSecret(
 		mock.Anything,
 		mock.AnythingOfType("uuid.UUID"),
 		mock.AnythingOfType("uuid.UUID"),
+		false,
 	).
 		Return(mockRV, mockErr)
 
@@ -101,25 +107,25 @@ func doDeleteSecret(
 func TestCreateSecret(t *testing.T) {
 	tt := []struct {
 		name       string
-		secretName string
+		secretName []byte
 		metadata   []byte
 		data       []byte
 	}{
 		{
 			name:       "Create secret",
-			secretName: gophtest.SecretName,
+			secretName: []byte(gophtest.SecretName),
 			metadata:   []byte(gophtest.Metadata),
 			data:       []byte(gophtest.TextData),
 		},
 		{
 			name:       "Create secret without metadata",
-			secretName: gophtest.Username,
+			secretName: []byte(gophtest.Username),
 			metadata:   nil,
 			data:       []byte(gophtest.TextData),
 		},
 		{
 			name:       "Create secret of maximum size",
-			secretName: strings.Repeat("#", cgrpc.DefaultMaxSecretNameLength),
+			secretName: []byte(strings.Repeat("#", cgrpc.DefaultSecretNameLimit)),
 			metadata:   []byte(strings.Repeat("#", cgrpc.DefaultMetadataLimit)),
 			data:       []byte(strings.Repeat("#", cgrpc.DefaultDataLimit)),
 		},
@@ -127,34 +133,40 @@ func TestCreateSecret(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			expected := uuid.New()
+			id := uuid.New()
 
 			m := newServicesMock()
 			m.Secrets.(*service.SecretsServiceMock).On(
 				"Create",
 				mock.Anything,
 				mock.AnythingOfType("uuid.UUID"),
+				id,
 				tc.secretName,
+				[]byte(gophtest.NameIndex),
 				proto.DataKind_BINARY,
+				[]byte(gophtest.DataKey),
 				tc.metadata,
 				tc.data,
 			).
-				Return(expected, nil)
+				Return(nil)
 
 			conn := createTestServerWithFakeAuth(t, m)
 
 			req := &proto.CreateSecretRequest{
-				Name:     tc.secretName,
-				Kind:     proto.DataKind_BINARY,
-				Metadata: tc.metadata,
-				Data:     tc.data,
+				Id:        id.String(),
+				Name:      tc.secretName,
+				NameIndex: []byte(gophtest.NameIndex),
+				Kind:      proto.DataKind_BINARY,
+				DataKey:   []byte(gophtest.DataKey),
+				Metadata:  tc.metadata,
+				Data:      tc.data,
 			}
 
 			client := proto.NewSecretsClient(conn)
 			resp, err := client.Create(context.Background(), req)
 
 			require.NoError(t, err)
-			require.Equal(t, expected.String(), resp.GetId())
+			require.Equal(t, id.String(), resp.GetId())
 			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
 		})
 	}
@@ -163,40 +175,103 @@ func TestCreateSecret(t *testing.T) {
 func TestCreateSecretWithBadRequest(t *testing.T) {
 	tt := []struct {
 		name       string
-		secretName string
+		id         string
+		secretName []byte
+		nameIndex  []byte
+		dataKey    []byte
 		metadata   []byte
 		data       []byte
 	}{
 		{
 			name:       "Create secret fails if secret name is empty",
-			secretName: "",
+			id:         uuid.NewString(),
+			secretName: nil,
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(gophtest.Metadata),
 			data:       []byte(gophtest.TextData),
 		},
 		{
 			name:       "Create secret fails if secret name is too long",
-			secretName: strings.Repeat("#", cgrpc.DefaultMaxSecretNameLength+1),
+			id:         uuid.NewString(),
+			secretName: []byte(strings.Repeat("#", cgrpc.DefaultSecretNameLimit+1)),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(gophtest.Metadata),
 			data:       []byte(gophtest.TextData),
 		},
 		{
 			name:       "Create secret fails if metadata is too long",
-			secretName: gophtest.Username,
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.Username),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(strings.Repeat("#", cgrpc.DefaultMetadataLimit+1)),
 			data:       make([]byte, 0),
 		},
 		{
 			name:       "Create secret fails if data is empty",
-			secretName: gophtest.Username,
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.Username),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(gophtest.Metadata),
 			data:       make([]byte, 0),
 		},
 		{
 			name:       "Create secret fails if data is too long",
-			secretName: gophtest.Username,
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.Username),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(gophtest.Metadata),
 			data:       []byte(strings.Repeat("#", cgrpc.DefaultDataLimit+1)),
 		},
+		{
+			name:       "Create secret fails if data key is empty",
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.Username),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    nil,
+			metadata:   []byte(gophtest.Metadata),
+			data:       []byte(gophtest.TextData),
+		},
+		{
+			name:       "Create secret fails if data key is too long",
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.Username),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(strings.Repeat("#", cgrpc.DefaultDataKeyLimit+1)),
+			metadata:   []byte(gophtest.Metadata),
+			data:       []byte(gophtest.TextData),
+		},
+		{
+			name:       "Create secret fails if name index is empty",
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  nil,
+			dataKey:    []byte(gophtest.DataKey),
+			metadata:   []byte(gophtest.Metadata),
+			data:       []byte(gophtest.TextData),
+		},
+		{
+			name:       "Create secret fails if name index has wrong length",
+			id:         uuid.NewString(),
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  []byte(gophtest.NameIndex + "ex"),
+			dataKey:    []byte(gophtest.DataKey),
+			metadata:   []byte(gophtest.Metadata),
+			data:       []byte(gophtest.TextData),
+		},
+		{
+			name:       "Create secret fails if secret ID is invalid",
+			id:         "xxx",
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
+			metadata:   []byte(gophtest.Metadata),
+			data:       []byte(gophtest.TextData),
+		},
 	}
 
 	for _, tc := range tt {
@@ -204,10 +279,13 @@ func TestCreateSecretWithBadRequest(t *testing.T) {
 			conn := createTestServerWithFakeAuth(t, newServicesMock())
 
 			req := &proto.CreateSecretRequest{
-				Name:     tc.secretName,
-				Kind:     proto.DataKind_BINARY,
-				Metadata: tc.metadata,
-				Data:     tc.data,
+				Id:        tc.id,
+				Name:      tc.secretName,
+				NameIndex: tc.nameIndex,
+				Kind:      proto.DataKind_BINARY,
+				DataKey:   tc.dataKey,
+				Metadata:  tc.metadata,
+				Data:      tc.data,
 			}
 
 			client := proto.NewSecretsClient(conn)
@@ -247,25 +325,33 @@ func TestCreateServerOnServiceFailure(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
 			m := newServicesMock()
 			m.Secrets.(*service.SecretsServiceMock).On(
 				"Create",
 				mock.Anything,
 				mock.AnythingOfType("uuid.UUID"),
-				gophtest.SecretName,
+				id,
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
 				proto.DataKind_BINARY,
+				[]byte(gophtest.DataKey),
 				[]byte(gophtest.Metadata),
 				[]byte(gophtest.TextData),
 			).
-				Return(uuid.UUID{}, tc.err)
+				Return(tc.err)
 
 			conn := createTestServerWithFakeAuth(t, m)
 
 			req := &proto.CreateSecretRequest{
-				Name:     gophtest.SecretName,
-				Kind:     proto.DataKind_BINARY,
-				Metadata: []byte(gophtest.Metadata),
-				Data:     []byte(gophtest.TextData),
+				Id:        id.String(),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				Kind:      proto.DataKind_BINARY,
+				DataKey:   []byte(gophtest.DataKey),
+				Metadata:  []byte(gophtest.Metadata),
+				Data:      []byte(gophtest.TextData),
 			}
 
 			client := proto.NewSecretsClient(conn)
@@ -287,14 +373,16 @@ func TestListSecrets(t *testing.T) {
 			secrets: []entity.Secret{
 				{
 					ID:   gophtest.CreateUUID(t, "7728154c-9400-4f1b-a2a3-01deb83ece05"),
-					Name: gophtest.SecretName,
+					Name: []byte(gophtest.SecretName),
 					Kind: proto.DataKind_BINARY,
 				},
 				{
-					ID:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45"),
-					Name:     gophtest.SecretName + "ex",
-					Kind:     proto.DataKind_TEXT,
-					Metadata: []byte(gophtest.Metadata),
+					ID:        gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45"),
+					Name:      []byte(gophtest.SecretName + "ex"),
+					NameIndex: []byte(gophtest.NameIndex),
+					Kind:      proto.DataKind_TEXT,
+					DataKey:   []byte(gophtest.DataKey),
+					Metadata:  []byte(gophtest.Metadata),
 				},
 			},
 		},
@@ -309,6 +397,7 @@ func TestListSecrets(t *testing.T) {
 			rv, err := doListSecrets(t, tc.secrets, nil)
 
 			require.NoError(t, err)
+			require.Equal(t, gophtest.VaultVersion, rv.GetVaultVersion())
 			snaps.MatchSnapshot(t, rv.GetSecrets())
 		})
 	}
@@ -329,6 +418,113 @@ func TestListSecretsFailsOnServiceFailure(t *testing.T) {
 	requireEqualCode(t, codes.Internal, err)
 }
 
+func TestListSecretsPage(t *testing.T) {
+	after := uuid.New()
+	next := uuid.New()
+	filter := entity.SecretsFilter{
+		Kinds:     []proto.DataKind{proto.DataKind_CARD},
+		NameIndex: []byte(gophtest.NameIndex),
+		After:     after,
+		Limit:     1,
+	}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).On("List", mock.Anything, mock.AnythingOfType("uuid.UUID"), filter).
+		Return(entity.SecretsPage{Secrets: []entity.Secret{{ID: next}}, Next: next}, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.ListSecretsRequest{
+		PageSize:  1,
+		PageToken: entity.NewPageToken(after),
+		Kinds:     filter.Kinds,
+		NameIndex: filter.NameIndex,
+	}
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.List(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Len(t, rv.GetSecrets(), 1)
+	require.Equal(t, entity.NewPageToken(next), rv.GetNextPageToken())
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestListSecretsWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name string
+		req  *proto.ListSecretsRequest
+	}{
+		{
+			name: "List secrets fails if page size is negative",
+			req:  &proto.ListSecretsRequest{PageSize: -1},
+		},
+		{
+			name: "List secrets fails if page size is too big",
+			req:  &proto.ListSecretsRequest{PageSize: cgrpc.MaxListPageSize + 1},
+		},
+		{
+			name: "List secrets fails if page token is invalid",
+			req:  &proto.ListSecretsRequest{PageToken: "xxx"},
+		},
+		{
+			name: "List secrets fails if kind is unknown",
+			req:  &proto.ListSecretsRequest{Kinds: []proto.DataKind{proto.DataKind(42)}},
+		},
+		{
+			name: "List secrets fails if name index is invalid",
+			req:  &proto.ListSecretsRequest{NameIndex: []byte("xxx")},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			client := proto.NewSecretsClient(conn)
+			_, err := client.List(context.Background(), tc.req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestListSecretsWithinAPITokenScope(t *testing.T) {
+	allowed := uuid.New()
+	secrets := []entity.Secret{{ID: allowed}}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).On(
+		"List",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		entity.SecretsFilter{IDs: []uuid.UUID{allowed}, Limit: cgrpc.DefaultListPageSize},
+	).
+		Return(entity.SecretsPage{Secrets: secrets, VaultVersion: gophtest.VaultVersion}, nil)
+
+	scopeInterceptor := func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+		scope := entity.APITokenScope{ReadOnly: true, SecretIDs: []uuid.UUID{allowed}}
+
+		return handler(scope.WithContext(user.WithContext(ctx)), req)
+	}
+
+	conn := createTestServer(t, m, grpc.UnaryInterceptor(scopeInterceptor))
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.List(context.Background(), &proto.ListSecretsRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, rv.GetSecrets(), 1)
+	require.Equal(t, allowed.String(), rv.GetSecrets()[0].GetId())
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
 func TestGetSecret(t *testing.T) {
 	tt := []struct {
 		name   string
@@ -337,18 +533,20 @@ func TestGetSecret(t *testing.T) {
 		{
 			name: "Get secret",
 			secret: &entity.Secret{
-				ID:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45"),
-				Name:     gophtest.SecretName,
-				Kind:     proto.DataKind_TEXT,
-				Metadata: []byte(gophtest.Metadata),
-				Data:     []byte(gophtest.TextData),
+				ID:        gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45"),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				Kind:      proto.DataKind_TEXT,
+				DataKey:   []byte(gophtest.DataKey),
+				Metadata:  []byte(gophtest.Metadata),
+				Data:      []byte(gophtest.TextData),
 			},
 		},
 		{
 			name: "Get secret without metadata",
 			secret: &entity.Secret{
 				ID:       gophtest.CreateUUID(t, "df566e25-43a5-4c34-9123-3931fb809b45"),
-				Name:     gophtest.SecretName,
+				Name:     []byte(gophtest.SecretName),
 				Kind:     proto.DataKind_TEXT,
 				Metadata: []byte(gophtest.Metadata),
 				Data:     []byte(gophtest.TextData),
@@ -423,16 +621,19 @@ func TestUpdateSecret(t *testing.T) {
 		{
 			name: "Update all fields of a secret",
 			req: &proto.UpdateSecretRequest{
-				Name:     gophtest.SecretName,
-				Metadata: []byte(gophtest.Metadata),
-				Data:     []byte(gophtest.TextData),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				DataKey:   []byte(gophtest.DataKey),
+				Metadata:  []byte(gophtest.Metadata),
+				Data:      []byte(gophtest.TextData),
 			},
-			changed: []string{"data", "metadata", "name"},
+			changed: []string{"data", "data_key", "metadata", "name"},
 		},
 		{
 			name: "Update secret's name",
 			req: &proto.UpdateSecretRequest{
-				Name: gophtest.SecretName,
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
 			},
 			changed: []string{"name"},
 		},
@@ -457,12 +658,21 @@ func TestUpdateSecret(t *testing.T) {
 			},
 			changed: []string{"data"},
 		},
+		{
+			name: "Update secret of expected version",
+			req: &proto.UpdateSecretRequest{
+				Metadata: []byte(gophtest.Metadata),
+				Version:  3,
+			},
+			changed: []string{"metadata"},
+		},
 		{
 			name: "Update secret with maximum fields limits",
 			req: &proto.UpdateSecretRequest{
-				Name:     strings.Repeat("#", cgrpc.DefaultMaxSecretNameLength),
-				Metadata: []byte(strings.Repeat("#", cgrpc.DefaultMetadataLimit)),
-				Data:     []byte(strings.Repeat("#", cgrpc.DefaultDataLimit)),
+				Name:      []byte(strings.Repeat("#", cgrpc.DefaultSecretNameLimit)),
+				NameIndex: []byte(gophtest.NameIndex),
+				Metadata:  []byte(strings.Repeat("#", cgrpc.DefaultMetadataLimit)),
+				Data:      []byte(strings.Repeat("#", cgrpc.DefaultDataLimit)),
 			},
 			changed: []string{"data", "metadata", "name"},
 		},
@@ -484,8 +694,11 @@ func TestUpdateSecret(t *testing.T) {
 				mock.Anything,
 				mock.AnythingOfType("uuid.UUID"),
 				id,
+				tc.req.GetVersion(),
 				tc.changed,
 				tc.req.Name,
+				tc.req.NameIndex,
+				tc.req.DataKey,
 				tc.req.Metadata,
 				tc.req.Data,
 			).
@@ -513,31 +726,41 @@ func TestUpdateSecretOnBadRequest(t *testing.T) {
 			name: "Update fails if no mask specified",
 			req: &proto.UpdateSecretRequest{
 				Id:   uuid.New().String(),
-				Name: gophtest.SecretName,
+				Name: []byte(gophtest.SecretName),
 			},
 			changed: nil,
 		},
 		{
 			name: "Update fails if bad secret id provided",
 			req: &proto.UpdateSecretRequest{
-				Id:   "xxx",
-				Name: gophtest.SecretName,
+				Id:        "xxx",
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
 			},
 			changed: []string{"name"},
 		},
 		{
 			name: "Update fails if empty name provided",
 			req: &proto.UpdateSecretRequest{
-				Id:   uuid.New().String(),
-				Name: "",
+				Id:        uuid.New().String(),
+				NameIndex: []byte(gophtest.NameIndex),
 			},
 			changed: []string{"name"},
 		},
 		{
 			name: "Update fails if too long name provided",
+			req: &proto.UpdateSecretRequest{
+				Id:        uuid.New().String(),
+				Name:      []byte(strings.Repeat("#", cgrpc.DefaultSecretNameLimit+1)),
+				NameIndex: []byte(gophtest.NameIndex),
+			},
+			changed: []string{"name"},
+		},
+		{
+			name: "Update fails if name is provided without index",
 			req: &proto.UpdateSecretRequest{
 				Id:   uuid.New().String(),
-				Name: strings.Repeat("#", cgrpc.DefaultMaxSecretNameLength+1),
+				Name: []byte(gophtest.SecretName),
 			},
 			changed: []string{"name"},
 		},
@@ -564,6 +787,22 @@ func TestUpdateSecretOnBadRequest(t *testing.T) {
 			},
 			changed: []string{"data"},
 		},
+		{
+			name: "Update fails if empty data key provided",
+			req: &proto.UpdateSecretRequest{
+				Id: uuid.New().String(),
+			},
+			changed: []string{"data_key"},
+		},
+		{
+			name: "Update fails if negative version provided",
+			req: &proto.UpdateSecretRequest{
+				Id:       uuid.New().String(),
+				Metadata: []byte(gophtest.Metadata),
+				Version:  -1,
+			},
+			changed: []string{"metadata"},
+		},
 	}
 
 	for _, tc := range tt {
@@ -608,6 +847,11 @@ func TestUpdateSecretOnServiceFailure(t *testing.T) {
 			ucErr:    entity.ErrSecretNameConflict,
 			expected: codes.AlreadyExists,
 		},
+		{
+			name:     "Update secret fails if it was modified concurrently",
+			ucErr:    entity.ErrSecretModified,
+			expected: codes.Aborted,
+		},
 		{
 			name:     "Update secret fails on expected error",
 			ucErr:    gophtest.ErrUnexpected,
@@ -625,8 +869,11 @@ func TestUpdateSecretOnServiceFailure(t *testing.T) {
 				mock.Anything,
 				mock.AnythingOfType("uuid.UUID"),
 				id,
+				int32(0),
 				[]string{"name"},
-				gophtest.SecretName,
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
+				[]byte(nil),
 				[]byte(nil),
 				[]byte(nil),
 			).
@@ -634,8 +881,9 @@ func TestUpdateSecretOnServiceFailure(t *testing.T) {
 
 			conn := createTestServerWithFakeAuth(t, m)
 			req := &proto.UpdateSecretRequest{
-				Id:   id.String(),
-				Name: gophtest.SecretName,
+				Id:        id.String(),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
 			}
 
 			mask, err := fieldmaskpb.New(req, "name")
@@ -704,3 +952,854 @@ func TestDeleteSecretOnServiceFailure(t *testing.T) {
 		})
 	}
 }
+
+func newTestBlobHeader(id uuid.UUID) *proto.UploadBlobRequest {
+	return &proto.UploadBlobRequest{
+		Part: &proto.UploadBlobRequest_Header{
+			Header: &proto.BlobHeader{
+				Id:        id.String(),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				Metadata:  []byte(gophtest.Metadata),
+				DataKey:   []byte(gophtest.DataKey),
+			},
+		},
+	}
+}
+
+func newTestBlobChunk(chunk []byte) *proto.UploadBlobRequest {
+	return &proto.UploadBlobRequest{
+		Part: &proto.UploadBlobRequest_Chunk{Chunk: chunk},
+	}
+}
+
+// doUploadBlob sends the messages to the server and closes the stream.
+func doUploadBlob(
+	t *testing.T,
+	conn *grpc.ClientConn,
+	messages ...*proto.UploadBlobRequest,
+) (*proto.UploadBlobResponse, error) {
+	t.Helper()
+
+	client := proto.NewSecretsClient(conn)
+
+	stream, err := client.UploadBlob(context.Background())
+	require.NoError(t, err)
+
+	for _, msg := range messages {
+		if err := stream.Send(msg); err != nil {
+			break
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+// drainChunks reads all chunks passed to the service like the repo would.
+func drainChunks(chunks *[][]byte, drainErr *error) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		source := args.Get(7).(entity.ChunkSource)
+
+		for {
+			chunk, err := source()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					*drainErr = err
+				}
+
+				return
+			}
+
+			*chunks = append(*chunks, chunk)
+		}
+	}
+}
+
+func TestUploadBlob(t *testing.T) {
+	id := uuid.New()
+	first, second := []byte("first"), []byte("second")
+
+	var (
+		chunks   [][]byte
+		drainErr error
+	)
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).On(
+		"UploadBlob",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
+		[]byte(gophtest.Metadata),
+		mock.Anything,
+	).
+		Run(drainChunks(&chunks, &drainErr)).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+	rv, err := doUploadBlob(t, conn, newTestBlobHeader(id), newTestBlobChunk(first), newTestBlobChunk(second))
+
+	require.NoError(t, err)
+	require.NoError(t, drainErr)
+	require.Equal(t, id.String(), rv.GetId())
+	require.Equal(t, [][]byte{first, second}, chunks)
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestUploadBlobWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name     string
+		messages []*proto.UploadBlobRequest
+	}{
+		{
+			name: "Upload blob fails if header is missing",
+			messages: []*proto.UploadBlobRequest{
+				newTestBlobChunk([]byte("chunk")),
+			},
+		},
+		{
+			name: "Upload blob fails if stream is empty",
+		},
+		{
+			name: "Upload blob fails if header is invalid",
+			messages: []*proto.UploadBlobRequest{
+				{Part: &proto.UploadBlobRequest_Header{Header: &proto.BlobHeader{Id: "xxx"}}},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+			_, err := doUploadBlob(t, conn, tc.messages...)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestUploadBlobWithInvalidChunk(t *testing.T) {
+	tt := []struct {
+		name  string
+		chunk []byte
+	}{
+		{
+			name:  "Upload blob fails if chunk is empty",
+			chunk: nil,
+		},
+		{
+			name:  "Upload blob fails if chunk is too large",
+			chunk: []byte(strings.Repeat("x", cgrpc.DefaultChunkLimit+1)),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			var (
+				chunks   [][]byte
+				drainErr error
+			)
+
+			m := newServicesMock()
+			m.Secrets.(*service.SecretsServiceMock).On(
+				"UploadBlob",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				id,
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
+				[]byte(gophtest.DataKey),
+				[]byte(gophtest.Metadata),
+				mock.Anything,
+			).
+				Run(drainChunks(&chunks, &drainErr)).
+				Return(nil)
+
+			conn := createTestServerWithFakeAuth(t, m)
+			_, err := doUploadBlob(t, conn, newTestBlobHeader(id), newTestBlobChunk(tc.chunk))
+
+			require.NoError(t, err)
+			require.ErrorContains(t, drainErr, "invalid chunk")
+			require.Empty(t, chunks)
+		})
+	}
+}
+
+func TestUploadBlobFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	_, err := doUploadBlob(t, conn, newTestBlobHeader(uuid.New()))
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestUploadBlobOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		ucErr    error
+		expected codes.Code
+	}{
+		{
+			name:     "Upload blob fails if secret exists",
+			ucErr:    entity.ErrSecretExists,
+			expected: codes.AlreadyExists,
+		},
+		{
+			name:     "Upload blob fails if blob is too large",
+			ucErr:    entity.ErrBlobTooLarge,
+			expected: codes.ResourceExhausted,
+		},
+		{
+			name:     "Upload blob fails if blob has no chunks",
+			ucErr:    entity.ErrEmptyBlob,
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:     "Upload blob fails on unexpected error",
+			ucErr:    gophtest.ErrUnexpected,
+			expected: codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Secrets.(*service.SecretsServiceMock).On(
+				"UploadBlob",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				mock.AnythingOfType("uuid.UUID"),
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
+				[]byte(gophtest.DataKey),
+				[]byte(gophtest.Metadata),
+				mock.Anything,
+			).
+				Return(tc.ucErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+			_, err := doUploadBlob(t, conn, newTestBlobHeader(uuid.New()))
+
+			requireEqualCode(t, tc.expected, err)
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+// doDownloadBlob reads the whole stream,
+// returns the secret info, the chunks and the error which ended the stream.
+func doDownloadBlob(t *testing.T, conn *grpc.ClientConn, id uuid.UUID) (*proto.Secret, [][]byte, error) {
+	t.Helper()
+
+	client := proto.NewSecretsClient(conn)
+
+	stream, err := client.DownloadBlob(context.Background(), &proto.DownloadBlobRequest{Id: id.String()})
+	require.NoError(t, err)
+
+	var (
+		secret *proto.Secret
+		chunks [][]byte
+	)
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return secret, chunks, nil
+		}
+
+		if err != nil {
+			return secret, chunks, err
+		}
+
+		if resp.GetSecret() != nil {
+			secret = resp.GetSecret()
+
+			continue
+		}
+
+		chunks = append(chunks, resp.GetChunk())
+	}
+}
+
+func newTestBlobSecret(id uuid.UUID, blobSize int64) *entity.Secret {
+	return &entity.Secret{
+		ID:        id,
+		Name:      []byte(gophtest.SecretName),
+		NameIndex: []byte(gophtest.NameIndex),
+		Kind:      proto.DataKind_BINARY,
+		DataKey:   []byte(gophtest.DataKey),
+		Metadata:  []byte(gophtest.Metadata),
+		BlobSize:  blobSize,
+	}
+}
+
+func TestDownloadBlob(t *testing.T) {
+	id := uuid.New()
+	first, second := []byte("first"), []byte("second")
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).On("Get", mock.Anything, mock.AnythingOfType("uuid.UUID"), id, false).
+		Return(newTestBlobSecret(id, int64(len(first)+len(second))), nil)
+	m.Secrets.(*service.SecretsServiceMock).On(
+		"DownloadBlob",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		id,
+		mock.Anything,
+	).
+		Run(func(args mock.Arguments) {
+			sink := args.Get(3).(entity.ChunkSink)
+
+			require.NoError(t, sink(first))
+			require.NoError(t, sink(second))
+		}).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+	secret, chunks, err := doDownloadBlob(t, conn, id)
+
+	require.NoError(t, err)
+	require.Equal(t, id.String(), secret.GetId())
+	require.Equal(t, int64(len(first)+len(second)), secret.GetBlobSize())
+	require.Equal(t, []byte(gophtest.DataKey), secret.GetDataKey())
+	require.Equal(t, [][]byte{first, second}, chunks)
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestDownloadBlobFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	_, _, err := doDownloadBlob(t, conn, uuid.New())
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestDownloadBlobOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name        string
+		secret      *entity.Secret
+		getErr      error
+		downloadErr error
+		expected    codes.Code
+	}{
+		{
+			name:     "Download blob fails if secret not found",
+			getErr:   entity.ErrSecretNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "Download blob fails if secret isn't a blob",
+			secret:   newTestBlobSecret(uuid.New(), 0),
+			expected: codes.FailedPrecondition,
+		},
+		{
+			name:        "Download blob fails if blob was deleted meanwhile",
+			secret:      newTestBlobSecret(uuid.New(), 1),
+			downloadErr: entity.ErrSecretNotFound,
+			expected:    codes.NotFound,
+		},
+		{
+			name:        "Download blob fails on unexpected error",
+			secret:      newTestBlobSecret(uuid.New(), 1),
+			downloadErr: gophtest.ErrUnexpected,
+			expected:    codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Secrets.(*service.SecretsServiceMock).On(
+				"Get",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				mock.AnythingOfType("uuid.UUID"),
+				false,
+			).
+				Return(tc.secret, tc.getErr)
+
+			if tc.secret != nil && tc.secret.IsBlob() {
+				m.Secrets.(*service.SecretsServiceMock).On(
+					"DownloadBlob",
+					mock.Anything,
+					mock.AnythingOfType("uuid.UUID"),
+					mock.AnythingOfType("uuid.UUID"),
+					mock.Anything,
+				).
+					Return(tc.downloadErr)
+			}
+
+			conn := createTestServerWithFakeAuth(t, m)
+			_, _, err := doDownloadBlob(t, conn, uuid.New())
+
+			requireEqualCode(t, tc.expected, err)
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestDownloadBlobOutOfAPITokenScope(t *testing.T) {
+	scopeInterceptor := func(
+		srv any,
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, scopedStream{ss})
+	}
+
+	conn := createTestServer(t, newServicesMock(), grpc.StreamInterceptor(scopeInterceptor))
+	_, _, err := doDownloadBlob(t, conn, uuid.New())
+
+	requireEqualCode(t, codes.PermissionDenied, err)
+}
+
+// scopedStream authenticates the stream with API token limited to a random secret.
+type scopedStream struct {
+	grpc.ServerStream
+}
+
+func (s scopedStream) Context() context.Context {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	scope := entity.APITokenScope{ReadOnly: true, SecretIDs: []uuid.UUID{uuid.New()}}
+
+	return scope.WithContext(user.WithContext(s.ServerStream.Context()))
+}
+
+// newVersionsServicesMock expects call of the method for any secret of any user.
+func newVersionsServicesMock(method string, args []any, rv ...any) service.Services {
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).On(
+		method,
+		append(
+			[]any{mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")},
+			args...,
+		)...,
+	).
+		Return(rv...)
+
+	return m
+}
+
+func TestListVersions(t *testing.T) {
+	id := uuid.New()
+	now := time.Now()
+	versions := []entity.Secret{
+		{ID: id, Name: []byte(gophtest.SecretName), Kind: proto.DataKind_TEXT, Version: 2, UpdatedAt: now},
+		{ID: id, Name: []byte(gophtest.SecretName), Kind: proto.DataKind_TEXT, Version: 1, UpdatedAt: now.Add(-time.Hour)},
+	}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).
+		On("ListVersions", mock.Anything, mock.AnythingOfType("uuid.UUID"), id, false).
+		Return(versions, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.ListVersions(context.Background(), &proto.ListSecretVersionsRequest{Id: id.String()})
+
+	require.NoError(t, err)
+	require.Len(t, rv.GetVersions(), len(versions))
+
+	for i, version := range rv.GetVersions() {
+		require.Equal(t, id.String(), version.GetId())
+		require.Equal(t, versions[i].Version, version.GetVersion())
+		require.True(t, versions[i].UpdatedAt.Equal(version.GetUpdatedAt().AsTime()))
+	}
+
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestListVersionsOnBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.ListVersions(context.Background(), &proto.ListSecretVersionsRequest{Id: "xxx"})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestListVersionsFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.ListVersions(context.Background(), &proto.ListSecretVersionsRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestListVersionsOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		ucErr    error
+		expected codes.Code
+	}{
+		{
+			name:     "List versions fails if secret not found",
+			ucErr:    entity.ErrSecretNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "List versions fails on unexpected error",
+			ucErr:    gophtest.ErrUnexpected,
+			expected: codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newVersionsServicesMock("ListVersions", []any{false}, nil, tc.ucErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewSecretsClient(conn)
+			_, err := client.ListVersions(context.Background(), &proto.ListSecretVersionsRequest{Id: uuid.New().String()})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	secret := &entity.Secret{
+		ID:        uuid.New(),
+		Name:      []byte(gophtest.SecretName),
+		Kind:      proto.DataKind_TEXT,
+		Data:      []byte(gophtest.TextData),
+		Version:   1,
+		UpdatedAt: time.Now(),
+	}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).
+		On("GetVersion", mock.Anything, mock.AnythingOfType("uuid.UUID"), secret.ID, secret.Version, false).
+		Return(secret, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+	req := &proto.GetSecretVersionRequest{Id: secret.ID.String(), Version: secret.Version}
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.GetVersion(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, secret.ID.String(), rv.GetSecret().GetId())
+	require.Equal(t, secret.Version, rv.GetSecret().GetVersion())
+	require.Equal(t, secret.Data, rv.GetData())
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestSecretVersionOnBadRequest(t *testing.T) {
+	tt := []struct {
+		name    string
+		id      string
+		version int32
+	}{
+		{
+			name:    "Request fails if ID is invalid",
+			id:      "xxx",
+			version: 1,
+		},
+		{
+			name: "Request fails if version is not set",
+			id:   uuid.New().String(),
+		},
+		{
+			name:    "Request fails if version is negative",
+			id:      uuid.New().String(),
+			version: -1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+			client := proto.NewSecretsClient(conn)
+
+			_, err := client.GetVersion(
+				context.Background(),
+				&proto.GetSecretVersionRequest{Id: tc.id, Version: tc.version},
+			)
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestSecretVersionFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+	client := proto.NewSecretsClient(conn)
+
+	_, err := client.GetVersion(context.Background(), &proto.GetSecretVersionRequest{})
+	requireEqualCode(t, codes.Unauthenticated, err)
+
+	_, err = client.Restore(context.Background(), &proto.RestoreSecretRequest{})
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestGetVersionOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		ucErr    error
+		expected codes.Code
+	}{
+		{
+			name:     "Get version fails if version not found",
+			ucErr:    entity.ErrVersionNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "Get version fails on unexpected error",
+			ucErr:    gophtest.ErrUnexpected,
+			expected: codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newVersionsServicesMock("GetVersion", []any{int32(1), false}, nil, tc.ucErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+			req := &proto.GetSecretVersionRequest{Id: uuid.New().String(), Version: 1}
+
+			client := proto.NewSecretsClient(conn)
+			_, err := client.GetVersion(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	tt := []struct {
+		name     string
+		ucErr    error
+		expected codes.Code
+	}{
+		{
+			name:     "Restore version of a secret",
+			expected: codes.OK,
+		},
+		{
+			name:     "Restore fails if secret not found",
+			ucErr:    entity.ErrSecretNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "Restore fails if version not found",
+			ucErr:    entity.ErrVersionNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "Restore fails if restored name conflicts with other secret",
+			ucErr:    entity.ErrSecretNameConflict,
+			expected: codes.AlreadyExists,
+		},
+		{
+			name:     "Restore fails on unexpected error",
+			ucErr:    gophtest.ErrUnexpected,
+			expected: codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newVersionsServicesMock("Restore", []any{int32(1)}, int32(5), tc.ucErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+			req := &proto.RestoreSecretRequest{Id: uuid.New().String(), Version: 1}
+
+			client := proto.NewSecretsClient(conn)
+			rv, err := client.Restore(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+
+			if tc.ucErr == nil {
+				require.Equal(t, int32(5), rv.GetVersion())
+			}
+
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreFromTrash(t *testing.T) {
+	m := newVersionsServicesMock("Restore", []any{int32(0)}, int32(3), nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+	req := &proto.RestoreSecretRequest{Id: uuid.New().String()}
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.Restore(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(3), rv.GetVersion())
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestRestoreSecretOnBadRequest(t *testing.T) {
+	tt := []struct {
+		name string
+		req  *proto.RestoreSecretRequest
+	}{
+		{
+			name: "Restore secret fails on malformed ID",
+			req:  &proto.RestoreSecretRequest{Id: "xxx"},
+		},
+		{
+			name: "Restore secret fails on negative version",
+			req:  &proto.RestoreSecretRequest{Id: uuid.New().String(), Version: -1},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			client := proto.NewSecretsClient(conn)
+			_, err := client.Restore(context.Background(), tc.req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestListTrash(t *testing.T) {
+	now := time.Now()
+	secrets := []entity.Secret{
+		{ID: uuid.New(), Name: []byte(gophtest.SecretName), Version: 2, UpdatedAt: now.Add(-time.Hour), DeletedAt: now},
+	}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).
+		On("ListTrash", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return(secrets, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.ListTrash(context.Background(), &proto.ListTrashRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, rv.GetSecrets(), 1)
+	require.Equal(t, secrets[0].ID.String(), rv.GetSecrets()[0].GetId())
+	require.Equal(t, int32(2), rv.GetSecrets()[0].GetVersion())
+	require.True(t, now.Equal(rv.GetSecrets()[0].GetDeletedAt().AsTime()))
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestListTrashWithinAPITokenScope(t *testing.T) {
+	allowed := uuid.New()
+	secrets := []entity.Secret{{ID: uuid.New()}, {ID: allowed}}
+
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).
+		On("ListTrash", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return(secrets, nil)
+
+	scopeInterceptor := func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+		scope := entity.APITokenScope{ReadOnly: true, SecretIDs: []uuid.UUID{allowed}}
+
+		return handler(scope.WithContext(user.WithContext(ctx)), req)
+	}
+
+	conn := createTestServer(t, m, grpc.UnaryInterceptor(scopeInterceptor))
+
+	client := proto.NewSecretsClient(conn)
+	rv, err := client.ListTrash(context.Background(), &proto.ListTrashRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, rv.GetSecrets(), 1)
+	require.Equal(t, allowed.String(), rv.GetSecrets()[0].GetId())
+	m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+}
+
+func TestListTrashFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.ListTrash(context.Background(), &proto.ListTrashRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestListTrashOnServiceFailure(t *testing.T) {
+	m := newServicesMock()
+	m.Secrets.(*service.SecretsServiceMock).
+		On("ListTrash", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return(nil, gophtest.ErrUnexpected)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.ListTrash(context.Background(), &proto.ListTrashRequest{})
+
+	requireEqualCode(t, codes.Internal, err)
+}
+
+func TestPurgeSecret(t *testing.T) {
+	tt := []struct {
+		name     string
+		ucErr    error
+		expected codes.Code
+	}{
+		{
+			name:     "Purge secret",
+			expected: codes.OK,
+		},
+		{
+			name:     "Purge secret fails if secret not found",
+			ucErr:    entity.ErrSecretNotFound,
+			expected: codes.NotFound,
+		},
+		{
+			name:     "Purge secret fails on unexpected error",
+			ucErr:    gophtest.ErrUnexpected,
+			expected: codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newVersionsServicesMock("Purge", nil, tc.ucErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewSecretsClient(conn)
+			_, err := client.Purge(context.Background(), &proto.PurgeSecretRequest{Id: uuid.New().String()})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Secrets.(*service.SecretsServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeSecretOnBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.Purge(context.Background(), &proto.PurgeSecretRequest{Id: "xxx"})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestPurgeSecretFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewSecretsClient(conn)
+	_, err := client.Purge(context.Background(), &proto.PurgeSecretRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// SessionsServer provides implementation of the Sessions API.
+type SessionsServer struct {
+	proto.UnimplementedSessionsServer
+
+	authService service.Auth
+}
+
+// NewSessionsServer initializes and creates new SessionsServer.
+func NewSessionsServer(auth service.Auth) *SessionsServer {
+	return &SessionsServer{authService: auth}
+}
+
+// List returns active login sessions of current user.
+// The session of the request is marked as current.
+func (s SessionsServer) List(
+	ctx context.Context,
+	_ *proto.ListSessionsRequest,
+) (*proto.ListSessionsResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	sessions, err := s.authService.ListSessions(ctx, owner.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	var current uuid.UUID
+	if token := entity.TokenInfoFromContext(ctx); token != nil {
+		current = token.SessionID
+	}
+
+	rv := make([]*proto.Session, 0, len(sessions))
+	for _, session := range sessions {
+		rv = append(rv, sessionToProto(session, current))
+	}
+
+	return &proto.ListSessionsResponse{Sessions: rv}, nil
+}
+
+// Revoke ends login session of current user.
+func (s SessionsServer) Revoke(
+	ctx context.Context,
+	req *proto.RevokeSessionRequest,
+) (*proto.RevokeSessionResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.authService.RevokeSession(ctx, owner.ID, id); err != nil {
+		if errors.Is(err, entity.ErrSessionNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrSessionNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RevokeSessionResponse{}, nil
+}
+
+func sessionToProto(session entity.Session, current uuid.UUID) *proto.Session {
+	return &proto.Session{
+		Id:          session.ID.String(),
+		DeviceName:  session.Name,
+		UserAgent:   session.UserAgent,
+		PeerAddress: session.PeerAddress,
+		CreatedAt:   timestamppb.New(session.CreatedAt),
+		LastSeenAt:  timestamppb.New(session.LastSeenAt),
+		Current:     current != uuid.Nil && session.ID == current,
+	}
+}
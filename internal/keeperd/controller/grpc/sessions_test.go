@@ -0,0 +1,157 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func TestListSessions(t *testing.T) {
+	sessions := []entity.Session{
+		{
+			ID:         testSessionID,
+			Device:     entity.Device{Name: gophtest.DeviceName, PeerAddress: gophtest.PeerAddress},
+			CreatedAt:  time.Now().Add(-time.Hour),
+			LastSeenAt: time.Now(),
+		},
+		{
+			ID:         uuid.New(),
+			Device:     entity.Device{UserAgent: gophtest.UserAgent},
+			CreatedAt:  time.Now().Add(-2 * time.Hour),
+			LastSeenAt: time.Now().Add(-time.Hour),
+		},
+	}
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("ListSessions", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return(sessions, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSessionsClient(conn)
+	resp, err := client.List(context.Background(), &proto.ListSessionsRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.GetSessions(), 2)
+
+	current := resp.GetSessions()[0]
+	require.Equal(t, testSessionID.String(), current.GetId())
+	require.Equal(t, gophtest.DeviceName, current.GetDeviceName())
+	require.Equal(t, gophtest.PeerAddress, current.GetPeerAddress())
+	require.True(t, current.GetCurrent())
+	require.True(t, sessions[0].LastSeenAt.Equal(current.GetLastSeenAt().AsTime()))
+
+	other := resp.GetSessions()[1]
+	require.Equal(t, gophtest.UserAgent, other.GetUserAgent())
+	require.False(t, other.GetCurrent())
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestListSessionsFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewSessionsClient(conn)
+	_, err := client.List(context.Background(), &proto.ListSessionsRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestListSessionsOnServiceFailure(t *testing.T) {
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("ListSessions", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+		Return([]entity.Session(nil), gophtest.ErrUnexpected)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSessionsClient(conn)
+	_, err := client.List(context.Background(), &proto.ListSessionsRequest{})
+
+	requireEqualCode(t, codes.Internal, err)
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+	id := uuid.New()
+
+	m := newServicesMock()
+	m.Auth.(*service.AuthServiceMock).On("RevokeSession", mock.Anything, mock.AnythingOfType("uuid.UUID"), id).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewSessionsClient(conn)
+	_, err := client.Revoke(context.Background(), &proto.RevokeSessionRequest{Id: id.String()})
+
+	require.NoError(t, err)
+	m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+}
+
+func TestRevokeSessionFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewSessionsClient(conn)
+	_, err := client.Revoke(context.Background(), &proto.RevokeSessionRequest{Id: uuid.NewString()})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestRevokeSessionWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewSessionsClient(conn)
+	_, err := client.Revoke(context.Background(), &proto.RevokeSessionRequest{Id: "xxx"})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestRevokeSessionOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Revoke session fails if session is not found",
+			serviceErr: entity.ErrSessionNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Revoke session fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newServicesMock()
+			m.Auth.(*service.AuthServiceMock).On(
+				"RevokeSession",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				id,
+			).
+				Return(tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewSessionsClient(conn)
+			_, err := client.Revoke(context.Background(), &proto.RevokeSessionRequest{Id: id.String()})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Auth.(*service.AuthServiceMock).AssertExpectations(t)
+		})
+	}
+}
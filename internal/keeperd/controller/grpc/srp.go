@@ -0,0 +1,23 @@
+package grpc
+
+import (
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// verifierFromProto converts SRP verifier received from client.
+func verifierFromProto(verifier *proto.SRPVerifier) entity.Verifier {
+	return entity.Verifier{
+		Salt:     verifier.GetSalt(),
+		Verifier: verifier.GetVerifier(),
+	}
+}
+
+// challengeToProto converts SRP challenge to send it to client.
+func challengeToProto(challenge entity.Challenge) *proto.SRPChallenge {
+	return &proto.SRPChallenge{
+		HandshakeId:  challenge.HandshakeID.String(),
+		Salt:         challenge.Salt,
+		ServerPublic: challenge.ServerPublic,
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
@@ -29,23 +31,432 @@ func (s UsersServer) Register(
 	ctx context.Context,
 	req *proto.RegisterUserRequest,
 ) (*proto.RegisterUserResponse, error) {
-	username := req.GetUsername()
-	key := req.GetSecurityKey()
+	if details, ok := validateRegisterUserReq(req); !ok {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	tokens, err := s.usersService.Register(
+		ctx,
+		req.GetUsername(),
+		verifierFromProto(req.GetVerifier()),
+		kdfParamsFromProto(req.GetKdfParams()),
+		recoveryKitFromProto(req.GetRecovery()),
+	)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrUserExists) {
+			return nil, status.Errorf(codes.AlreadyExists, entity.ErrUserExists.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RegisterUserResponse{
+		AccessToken:  tokens.AccessToken.String(),
+		RefreshToken: tokens.RefreshToken.String(),
+	}, nil
+}
+
+// StartChangePassword starts SRP handshake to prove the current master password.
+func (s UsersServer) StartChangePassword(
+	ctx context.Context,
+	req *proto.StartChangePasswordRequest,
+) (*proto.StartChangePasswordResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if reason, ok := validateClientPublic(req.GetClientPublic()); !ok {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "client_public",
+					Description: reason,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
 
-	if details, ok := validateCredentials(username, key); !ok {
+	challenge, err := s.usersService.StartChangePassword(ctx, owner.ID, req.GetClientPublic())
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrUserNotFound.Error())
+		}
+
+		if errors.Is(err, srp.ErrInvalidPublic) {
+			return nil, status.Errorf(codes.InvalidArgument, srp.ErrInvalidPublic.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.StartChangePasswordResponse{Challenge: challengeToProto(challenge)}, nil
+}
+
+// ChangePassword replaces master password of current user.
+func (s UsersServer) ChangePassword(
+	ctx context.Context,
+	req *proto.ChangePasswordRequest,
+) (*proto.ChangePasswordResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	proof, secrets, details := validateChangePasswordReq(req)
+	if details != nil {
 		st := composeBadRequestError(details)
 
 		return nil, st.Err()
 	}
 
-	accessToken, err := s.usersService.Register(ctx, username, key)
+	serverProof, err := s.usersService.ChangePassword(
+		ctx,
+		*owner,
+		proof,
+		req.GetRecoverySecurityKey(),
+		verifierFromProto(req.GetVerifier()),
+		kdfParamsFromProto(req.GetKdfParams()),
+		req.GetVaultVersion(),
+		secrets,
+		recoveryKitFromProto(req.GetRecovery()),
+	)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
+		if errors.Is(err, entity.ErrVaultChanged) {
+			return nil, status.Errorf(codes.Aborted, entity.ErrVaultChanged.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.ChangePasswordResponse{ServerProof: serverProof}, nil
+}
+
+// StartDelete starts SRP handshake to prove the master password before deletion.
+func (s UsersServer) StartDelete(
+	ctx context.Context,
+	req *proto.StartDeleteUserRequest,
+) (*proto.StartDeleteUserResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if reason, ok := validateClientPublic(req.GetClientPublic()); !ok {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "client_public",
+					Description: reason,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	challenge, err := s.usersService.StartDelete(ctx, owner.ID, req.GetClientPublic())
 	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrUserNotFound.Error())
+		}
+
+		if errors.Is(err, srp.ErrInvalidPublic) {
+			return nil, status.Errorf(codes.InvalidArgument, srp.ErrInvalidPublic.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.StartDeleteUserResponse{Challenge: challengeToProto(challenge)}, nil
+}
+
+// Delete removes current user together with all secrets.
+func (s UsersServer) Delete(
+	ctx context.Context,
+	req *proto.DeleteUserRequest,
+) (*proto.DeleteUserResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	proof, violations := validateProof("proof", req.GetProof())
+	if len(violations) != 0 {
+		st := composeBadRequestError(&errdetails.BadRequest{FieldViolations: violations})
+
+		return nil, st.Err()
+	}
+
+	serverProof, err := s.usersService.Delete(ctx, *owner, proof)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrUserNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.DeleteUserResponse{ServerProof: serverProof}, nil
+}
+
+// StartRename starts SRP handshake to prove the master password before renaming.
+func (s UsersServer) StartRename(
+	ctx context.Context,
+	req *proto.StartRenameUserRequest,
+) (*proto.StartRenameUserResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if reason, ok := validateClientPublic(req.GetClientPublic()); !ok {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "client_public",
+					Description: reason,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	challenge, err := s.usersService.StartRename(ctx, owner.ID, req.GetClientPublic())
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrUserNotFound.Error())
+		}
+
+		if errors.Is(err, srp.ErrInvalidPublic) {
+			return nil, status.Errorf(codes.InvalidArgument, srp.ErrInvalidPublic.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.StartRenameUserResponse{Challenge: challengeToProto(challenge)}, nil
+}
+
+// Rename changes username of current user.
+func (s UsersServer) Rename(
+	ctx context.Context,
+	req *proto.RenameUserRequest,
+) (*proto.RenameUserResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	proof, secrets, details := validateRenameUserReq(req)
+	if details != nil {
+		st := composeBadRequestError(details)
+
+		return nil, st.Err()
+	}
+
+	serverProof, err := s.usersService.Rename(
+		ctx,
+		*owner,
+		req.GetUsername(),
+		proof,
+		verifierFromProto(req.GetVerifier()),
+		kdfParamsFromProto(req.GetKdfParams()),
+		req.GetVaultVersion(),
+		secrets,
+		recoveryKitFromProto(req.GetRecovery()),
+	)
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+		}
+
 		if errors.Is(err, entity.ErrUserExists) {
 			return nil, status.Errorf(codes.AlreadyExists, entity.ErrUserExists.Error())
 		}
 
+		if errors.Is(err, entity.ErrVaultChanged) {
+			return nil, status.Errorf(codes.Aborted, entity.ErrVaultChanged.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.RenameUserResponse{ServerProof: serverProof}, nil
+}
+
+// GetRecoveryKey returns recovery key of current user wrapped by the vault key.
+func (s UsersServer) GetRecoveryKey(
+	ctx context.Context,
+	_ *proto.GetRecoveryKeyRequest,
+) (*proto.GetRecoveryKeyResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	recoveryKey, err := s.usersService.GetRecoveryKey(ctx, owner.ID)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return nil, status.Errorf(codes.NotFound, entity.ErrUserNotFound.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.GetRecoveryKeyResponse{RecoveryKey: recoveryKey}, nil
+}
+
+// SetupTOTP generates new TOTP secret for current user
+// and returns it as provisioning URI.
+func (s UsersServer) SetupTOTP(
+	ctx context.Context,
+	_ *proto.SetupTOTPRequest,
+) (*proto.SetupTOTPResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	uri, err := s.usersService.SetupTOTP(ctx, *owner)
+	if err != nil {
+		if errors.Is(err, entity.ErrTOTPAlreadyEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, entity.ErrTOTPAlreadyEnabled.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &proto.SetupTOTPResponse{Uri: uri}, nil
+}
+
+// EnableTOTP confirms the TOTP secret with the first one-time code
+// and returns backup codes of current user.
+func (s UsersServer) EnableTOTP(
+	ctx context.Context,
+	req *proto.EnableTOTPRequest,
+) (*proto.EnableTOTPResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if req.GetCode() == "" {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "code",
+					Description: MissingField,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	backupCodes, err := s.usersService.EnableTOTP(ctx, *owner, req.GetCode())
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidSecondFactor) {
+			return nil, status.Errorf(codes.InvalidArgument, entity.ErrInvalidSecondFactor.Error())
+		}
+
+		if errors.Is(err, entity.ErrTOTPNotEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, entity.ErrTOTPNotEnabled.Error())
+		}
+
+		if errors.Is(err, entity.ErrTOTPAlreadyEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, entity.ErrTOTPAlreadyEnabled.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	rv := make([]string, 0, len(backupCodes))
+	for _, code := range backupCodes {
+		rv = append(rv, code.String())
+	}
+
+	return &proto.EnableTOTPResponse{BackupCodes: rv}, nil
+}
+
+// DisableTOTP turns off two-factor authentication of current user.
+func (s UsersServer) DisableTOTP(
+	ctx context.Context,
+	req *proto.DisableTOTPRequest,
+) (*proto.DisableTOTPResponse, error) {
+	owner := entity.UserFromContext(ctx)
+	if owner == nil {
+		return nil, status.Errorf(codes.Unauthenticated, entity.ErrInvalidCredentials.Error())
+	}
+
+	if req.GetCode() == "" {
+		st := composeBadRequestError(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "code",
+					Description: MissingField,
+				},
+			},
+		})
+
+		return nil, st.Err()
+	}
+
+	err := s.usersService.DisableTOTP(ctx, *owner, req.GetCode())
+	if err != nil {
+		var throttled *entity.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, composeThrottledError(throttled).Err()
+		}
+
+		if errors.Is(err, entity.ErrInvalidSecondFactor) {
+			return nil, status.Errorf(codes.InvalidArgument, entity.ErrInvalidSecondFactor.Error())
+		}
+
+		if errors.Is(err, entity.ErrTOTPNotEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, entity.ErrTOTPNotEnabled.Error())
+		}
+
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
-	return &proto.RegisterUserResponse{AccessToken: accessToken.String()}, nil
+	return &proto.DisableTOTPResponse{}, nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
@@ -13,6 +15,7 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
@@ -20,6 +23,7 @@ func TestRegisterUser(t *testing.T) {
 	tt := []struct {
 		name     string
 		userName string
+		recovery *proto.RecoveryKit
 	}{
 		{
 			name:     "Register user",
@@ -29,6 +33,11 @@ func TestRegisterUser(t *testing.T) {
 			name:     "Register user with long name",
 			userName: strings.Repeat("#", cgrpc.DefaultMaxUsernameLength),
 		},
+		{
+			name:     "Register user with recovery kit",
+			userName: gophtest.Username,
+			recovery: newTestRecoveryKit(),
+		},
 	}
 
 	for _, tc := range tt {
@@ -38,15 +47,23 @@ func TestRegisterUser(t *testing.T) {
 				"Register",
 				mock.Anything,
 				tc.userName,
-				gophtest.SecurityKey,
+				newTestEntityVerifier(),
+				newTestEntityKDFParams(),
+				entity.RecoveryKit{
+					SecurityKey: tc.recovery.GetSecurityKey(),
+					VaultKey:    tc.recovery.GetVaultKey(),
+					RecoveryKey: tc.recovery.GetRecoveryKey(),
+				},
 			).
-				Return(entity.AccessToken(gophtest.AccessToken), nil)
+				Return(newTestTokenPair(), nil)
 
 			conn := createTestServer(t, m)
 
 			req := &proto.RegisterUserRequest{
-				Username:    tc.userName,
-				SecurityKey: gophtest.SecurityKey,
+				Username:  tc.userName,
+				Verifier:  newTestVerifier(),
+				KdfParams: newTestKDFParams(),
+				Recovery:  tc.recovery,
 			}
 
 			client := proto.NewUsersClient(conn)
@@ -54,6 +71,7 @@ func TestRegisterUser(t *testing.T) {
 
 			require.NoError(t, err)
 			require.Equal(t, gophtest.AccessToken, resp.GetAccessToken())
+			require.Equal(t, gophtest.RefreshToken, resp.GetRefreshToken())
 			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
 		})
 	}
@@ -63,22 +81,157 @@ func TestRegisterUserWithBadRequest(t *testing.T) {
 	tt := []struct {
 		name     string
 		username string
-		key      string
+		verifier *proto.SRPVerifier
+		kdf      func(kdf *proto.KDFParams) *proto.KDFParams
+		recovery func(kit *proto.RecoveryKit) *proto.RecoveryKit
 	}{
 		{
 			name:     "Register user fails if username is empty",
 			username: "",
-			key:      gophtest.SecurityKey,
+			verifier: newTestVerifier(),
+		},
+		{
+			name:     "Register user fails if verifier is not set",
+			username: gophtest.Username,
+			verifier: nil,
+		},
+		{
+			name:     "Register user fails if verifier salt is too long",
+			username: gophtest.Username,
+			verifier: &proto.SRPVerifier{
+				Salt:     make([]byte, cgrpc.MaxSRPSaltLength+1),
+				Verifier: []byte(gophtest.SRPVerifier),
+			},
 		},
 		{
-			name:     "Register user fails if security key is empty",
+			name:     "Register user fails if verifier is empty",
 			username: gophtest.Username,
-			key:      "",
+			verifier: &proto.SRPVerifier{
+				Salt: []byte(gophtest.SRPSalt),
+			},
 		},
 		{
 			name:     "Register user fails if username is too long",
 			username: strings.Repeat("#", cgrpc.DefaultMaxUsernameLength+1),
-			key:      gophtest.SecurityKey,
+			verifier: newTestVerifier(),
+		},
+		{
+			name:     "Register user fails if KDF params are not set",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(_ *proto.KDFParams) *proto.KDFParams {
+				return nil
+			},
+		},
+		{
+			name:     "Register user fails if KDF algorithm is legacy",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Algorithm = proto.KDFAlgorithm_KDF_SHA256
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if salt is too short",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Salt = kdf.Salt[:cgrpc.MinKDFSaltLength-1]
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if time is zero",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Time = 0
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if memory is too low",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Memory = cgrpc.MinKDFMemory - 1
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if memory is too high",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Memory = cgrpc.MaxKDFMemory + 1
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if too many threads are set",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Threads = cgrpc.MaxKDFThreads + 1
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if threads are not set",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.Threads = 0
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if key schedule is legacy",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			kdf: func(kdf *proto.KDFParams) *proto.KDFParams {
+				kdf.KeySchedule = proto.KeySchedule_KEY_SCHEDULE_AUTH_SUBKEY
+
+				return kdf
+			},
+		},
+		{
+			name:     "Register user fails if recovery security key is empty",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			recovery: func(kit *proto.RecoveryKit) *proto.RecoveryKit {
+				kit.SecurityKey = ""
+
+				return kit
+			},
+		},
+		{
+			name:     "Register user fails if recovery vault key is empty",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			recovery: func(kit *proto.RecoveryKit) *proto.RecoveryKit {
+				kit.VaultKey = nil
+
+				return kit
+			},
+		},
+		{
+			name:     "Register user fails if recovery key is too long",
+			username: gophtest.Username,
+			verifier: newTestVerifier(),
+			recovery: func(kit *proto.RecoveryKit) *proto.RecoveryKit {
+				kit.RecoveryKey = []byte(strings.Repeat("#", cgrpc.DefaultDataKeyLimit+1))
+
+				return kit
+			},
 		},
 	}
 
@@ -86,9 +239,21 @@ func TestRegisterUserWithBadRequest(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			conn := createTestServer(t, newServicesMock())
 
+			kdf := newTestKDFParams()
+			if tc.kdf != nil {
+				kdf = tc.kdf(kdf)
+			}
+
+			var recovery *proto.RecoveryKit
+			if tc.recovery != nil {
+				recovery = tc.recovery(newTestRecoveryKit())
+			}
+
 			req := &proto.RegisterUserRequest{
-				Username:    tc.username,
-				SecurityKey: tc.key,
+				Username:  tc.username,
+				Verifier:  tc.verifier,
+				KdfParams: kdf,
+				Recovery:  recovery,
 			}
 
 			client := proto.NewUsersClient(conn)
@@ -105,6 +270,11 @@ func TestRegisterUserOnServiceFailure(t *testing.T) {
 		serviceErr error
 		expected   codes.Code
 	}{
+		{
+			name:       "Register user fails if throttled",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
 		{
 			name:       "Register user fails if user already exists",
 			serviceErr: entity.ErrUserExists,
@@ -124,15 +294,18 @@ func TestRegisterUserOnServiceFailure(t *testing.T) {
 				"Register",
 				mock.Anything,
 				gophtest.Username,
-				gophtest.SecurityKey,
+				newTestEntityVerifier(),
+				newTestEntityKDFParams(),
+				entity.RecoveryKit{},
 			).
-				Return(entity.AccessToken(""), tc.serviceErr)
+				Return(entity.TokenPair{}, tc.serviceErr)
 
 			conn := createTestServer(t, m)
 
 			req := &proto.RegisterUserRequest{
-				Username:    gophtest.Username,
-				SecurityKey: gophtest.SecurityKey,
+				Username:  gophtest.Username,
+				Verifier:  newTestVerifier(),
+				KdfParams: newTestKDFParams(),
 			}
 
 			client := proto.NewUsersClient(conn)
@@ -143,3 +316,1111 @@ func TestRegisterUserOnServiceFailure(t *testing.T) {
 		})
 	}
 }
+
+func TestStartChangePassword(t *testing.T) {
+	challenge := newTestChallenge()
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"StartChangePassword",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		[]byte(gophtest.ClientPublic),
+	).
+		Return(challenge, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.StartChangePasswordRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.StartChangePassword(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, challenge.HandshakeID.String(), resp.GetChallenge().GetHandshakeId())
+	require.Equal(t, challenge.Salt, resp.GetChallenge().GetSalt())
+	require.Equal(t, challenge.ServerPublic, resp.GetChallenge().GetServerPublic())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestStartChangePasswordFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	req := &proto.StartChangePasswordRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.StartChangePassword(context.Background(), req)
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestStartChangePasswordWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.StartChangePassword(context.Background(), &proto.StartChangePasswordRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestStartChangePasswordOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Start change password fails if user has no verifier",
+			serviceErr: entity.ErrUserNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Start change password fails on invalid client public",
+			serviceErr: srp.ErrInvalidPublic,
+			expected:   codes.InvalidArgument,
+		},
+		{
+			name:       "Start change password fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"StartChangePassword",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				[]byte(gophtest.ClientPublic),
+			).
+				Return(entity.Challenge{}, tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			req := &proto.StartChangePasswordRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.StartChangePassword(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartDeleteUser(t *testing.T) {
+	challenge := newTestChallenge()
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"StartDelete",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		[]byte(gophtest.ClientPublic),
+	).
+		Return(challenge, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.StartDeleteUserRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.StartDelete(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, challenge.HandshakeID.String(), resp.GetChallenge().GetHandshakeId())
+	require.Equal(t, challenge.Salt, resp.GetChallenge().GetSalt())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestStartDeleteUserFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	req := &proto.StartDeleteUserRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.StartDelete(context.Background(), req)
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestStartDeleteUserWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.StartDelete(context.Background(), &proto.StartDeleteUserRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestStartDeleteUserOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Start delete user fails if user has no verifier",
+			serviceErr: entity.ErrUserNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Start delete user fails on invalid client public",
+			serviceErr: srp.ErrInvalidPublic,
+			expected:   codes.InvalidArgument,
+		},
+		{
+			name:       "Start delete user fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"StartDelete",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+				[]byte(gophtest.ClientPublic),
+			).
+				Return(entity.Challenge{}, tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			req := &proto.StartDeleteUserRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.StartDelete(context.Background(), req)
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	proof := newTestProof()
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On("Delete", mock.Anything, mock.AnythingOfType("entity.User"), proof).
+		Return([]byte(gophtest.ServerProof), nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.Delete(context.Background(), &proto.DeleteUserRequest{Proof: proofToProto(proof)})
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), resp.GetServerProof())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestDeleteUserFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.Delete(context.Background(), &proto.DeleteUserRequest{Proof: proofToProto(newTestProof())})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestDeleteUserWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name string
+		req  *proto.DeleteUserRequest
+	}{
+		{
+			name: "Delete user fails if proof is missing",
+			req:  &proto.DeleteUserRequest{},
+		},
+		{
+			name: "Delete user fails if proof is malformed",
+			req: &proto.DeleteUserRequest{
+				Proof: &proto.SRPProof{HandshakeId: "xxx", ClientProof: []byte("proof")},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.Delete(context.Background(), tc.req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestDeleteUserOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Delete user fails on wrong proof",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Delete user fails if too many attempts failed",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Delete user fails if user is not found",
+			serviceErr: entity.ErrUserNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Delete user fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"Delete",
+				mock.Anything,
+				mock.AnythingOfType("entity.User"),
+				mock.AnythingOfType("entity.Proof"),
+			).
+				Return([]byte(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.Delete(context.Background(), &proto.DeleteUserRequest{Proof: proofToProto(newTestProof())})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func newChangePasswordRequest() *proto.ChangePasswordRequest {
+	return &proto.ChangePasswordRequest{
+		Proof:        proofToProto(newTestProof()),
+		Verifier:     newTestVerifier(),
+		KdfParams:    newTestKDFParams(),
+		VaultVersion: gophtest.VaultVersion,
+		Secrets: []*proto.ReencryptedSecret{
+			{
+				Id:        uuid.NewString(),
+				DataKey:   []byte(gophtest.DataKey),
+				NameIndex: []byte(gophtest.NameIndex),
+				Versions: []*proto.ReencryptedVersion{
+					{
+						Version:   1,
+						DataKey:   []byte(gophtest.DataKey),
+						NameIndex: []byte(gophtest.NameIndex),
+					},
+				},
+			},
+			{
+				Id:        uuid.NewString(),
+				DataKey:   []byte(gophtest.DataKey),
+				Name:      []byte(gophtest.SecretName),
+				NameIndex: []byte(gophtest.NameIndex),
+				Metadata:  []byte(gophtest.Metadata),
+				Data:      []byte(gophtest.TextData),
+			},
+		},
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(req *proto.ChangePasswordRequest)
+	}{
+		{
+			name:   "Change password",
+			modify: func(_ *proto.ChangePasswordRequest) {},
+		},
+		{
+			name: "Change password rewraps recovery kit",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Recovery = newTestRecoveryKit()
+				req.Recovery.SecurityKey = ""
+			},
+		},
+		{
+			name: "Change password with recovery code",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Proof = nil
+				req.RecoverySecurityKey = gophtest.RecoverySecurityKey
+				req.Recovery = newTestRecoveryKit()
+				req.Recovery.SecurityKey = ""
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := newChangePasswordRequest()
+			tc.modify(req)
+
+			secrets := make([]entity.ReencryptedSecret, 0, len(req.GetSecrets()))
+
+			for _, secret := range req.GetSecrets() {
+				versions := make([]entity.ReencryptedVersion, 0, len(secret.GetVersions()))
+
+				for _, version := range secret.GetVersions() {
+					versions = append(versions, entity.ReencryptedVersion{
+						Version:   version.GetVersion(),
+						DataKey:   version.GetDataKey(),
+						NameIndex: version.GetNameIndex(),
+					})
+				}
+
+				secrets = append(secrets, entity.ReencryptedSecret{
+					ID:        uuid.MustParse(secret.GetId()),
+					DataKey:   secret.GetDataKey(),
+					Name:      secret.GetName(),
+					NameIndex: secret.GetNameIndex(),
+					Metadata:  secret.GetMetadata(),
+					Data:      secret.GetData(),
+					Versions:  versions,
+				})
+			}
+
+			var proof entity.Proof
+			if req.GetProof() != nil {
+				proof = entity.Proof{
+					HandshakeID: uuid.MustParse(req.GetProof().GetHandshakeId()),
+					ClientProof: req.GetProof().GetClientProof(),
+				}
+			}
+
+			serverProof := []byte(gophtest.ServerProof)
+
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"ChangePassword",
+				mock.Anything,
+				mock.AnythingOfType("entity.User"),
+				proof,
+				req.GetRecoverySecurityKey(),
+				newTestEntityVerifier(),
+				newTestEntityKDFParams(),
+				gophtest.VaultVersion,
+				secrets,
+				entity.RecoveryKit{
+					VaultKey:    req.GetRecovery().GetVaultKey(),
+					RecoveryKey: req.GetRecovery().GetRecoveryKey(),
+				},
+			).
+				Return(serverProof, nil)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			resp, err := client.ChangePassword(context.Background(), req)
+
+			require.NoError(t, err)
+			require.Equal(t, serverProof, resp.GetServerProof())
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestChangePasswordFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.ChangePassword(context.Background(), newChangePasswordRequest())
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestChangePasswordWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(req *proto.ChangePasswordRequest)
+	}{
+		{
+			name: "Change password fails if proof is not set",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Proof = nil
+			},
+		},
+		{
+			name: "Change password fails if handshake ID is invalid",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Proof.HandshakeId = "xxx"
+			},
+		},
+		{
+			name: "Change password fails if both proof and recovery security key are set",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.RecoverySecurityKey = gophtest.RecoverySecurityKey
+			},
+		},
+		{
+			name: "Change password fails if recovery kit has security key",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Recovery = newTestRecoveryKit()
+			},
+		},
+		{
+			name: "Change password fails if recovery vault key is empty",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Recovery = newTestRecoveryKit()
+				req.Recovery.SecurityKey = ""
+				req.Recovery.VaultKey = nil
+			},
+		},
+		{
+			name: "Change password fails if new verifier is not set",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Verifier = nil
+			},
+		},
+		{
+			name: "Change password fails if KDF params are not set",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.KdfParams = nil
+			},
+		},
+		{
+			name: "Change password fails if secret ID is invalid",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].Id = "xxx"
+			},
+		},
+		{
+			name: "Change password fails if secret is duplicated",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets = append(req.Secrets, req.Secrets[0])
+			},
+		},
+		{
+			name: "Change password fails if data key is empty",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].DataKey = nil
+			},
+		},
+		{
+			name: "Change password fails if name index is empty",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].NameIndex = nil
+			},
+		},
+		{
+			name: "Change password fails if secret name is too long",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[1].Name = []byte(strings.Repeat("#", cgrpc.DefaultSecretNameLimit+1))
+			},
+		},
+		{
+			name: "Change password fails if secret data is too long",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[1].Data = []byte(strings.Repeat("#", cgrpc.DefaultDataLimit+1))
+			},
+		},
+		{
+			name: "Change password fails if version is not positive",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].Versions[0].Version = 0
+			},
+		},
+		{
+			name: "Change password fails if version is duplicated",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].Versions = append(req.Secrets[0].Versions, req.Secrets[0].Versions[0])
+			},
+		},
+		{
+			name: "Change password fails if data key of version is empty",
+			modify: func(req *proto.ChangePasswordRequest) {
+				req.Secrets[0].Versions[0].DataKey = nil
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			req := newChangePasswordRequest()
+			tc.modify(req)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.ChangePassword(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestChangePasswordOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Change password fails on invalid credentials",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Change password fails if too many attempts failed",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Change password fails if vault was changed",
+			serviceErr: entity.ErrVaultChanged,
+			expected:   codes.Aborted,
+		},
+		{
+			name:       "Change password fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"ChangePassword",
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+			).
+				Return([]byte(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.ChangePassword(context.Background(), newChangePasswordRequest())
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartRenameUser(t *testing.T) {
+	challenge := newTestChallenge()
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"StartRename",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+		[]byte(gophtest.ClientPublic),
+	).
+		Return(challenge, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	req := &proto.StartRenameUserRequest{ClientPublic: []byte(gophtest.ClientPublic)}
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.StartRename(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, challenge.HandshakeID.String(), resp.GetChallenge().GetHandshakeId())
+	require.Equal(t, challenge.Salt, resp.GetChallenge().GetSalt())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestStartRenameUserWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.StartRename(context.Background(), &proto.StartRenameUserRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func newRenameUserRequest() *proto.RenameUserRequest {
+	req := newChangePasswordRequest()
+
+	return &proto.RenameUserRequest{
+		Username:     gophtest.Username,
+		Proof:        req.GetProof(),
+		Verifier:     req.GetVerifier(),
+		KdfParams:    req.GetKdfParams(),
+		VaultVersion: req.GetVaultVersion(),
+		Secrets:      req.GetSecrets(),
+	}
+}
+
+func TestRenameUser(t *testing.T) {
+	proof := newTestProof()
+	req := newRenameUserRequest()
+	req.Proof = proofToProto(proof)
+	serverProof := []byte(gophtest.ServerProof)
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"Rename",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		gophtest.Username,
+		proof,
+		newTestEntityVerifier(),
+		newTestEntityKDFParams(),
+		gophtest.VaultVersion,
+		mock.AnythingOfType("[]entity.ReencryptedSecret"),
+		entity.RecoveryKit{},
+	).
+		Return(serverProof, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.Rename(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, serverProof, resp.GetServerProof())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestRenameUserFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.Rename(context.Background(), newRenameUserRequest())
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestRenameUserWithBadRequest(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(req *proto.RenameUserRequest)
+	}{
+		{
+			name: "Rename user fails if username is empty",
+			modify: func(req *proto.RenameUserRequest) {
+				req.Username = ""
+			},
+		},
+		{
+			name: "Rename user fails if proof is not set",
+			modify: func(req *proto.RenameUserRequest) {
+				req.Proof = nil
+			},
+		},
+		{
+			name: "Rename user fails if verifier is not set",
+			modify: func(req *proto.RenameUserRequest) {
+				req.Verifier = nil
+			},
+		},
+		{
+			name: "Rename user fails if secret ID is invalid",
+			modify: func(req *proto.RenameUserRequest) {
+				req.Secrets[0].Id = "xxx"
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := newRenameUserRequest()
+			tc.modify(req)
+
+			conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.Rename(context.Background(), req)
+
+			requireEqualCode(t, codes.InvalidArgument, err)
+		})
+	}
+}
+
+func TestRenameUserOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Rename user fails on invalid credentials",
+			serviceErr: entity.ErrInvalidCredentials,
+			expected:   codes.Unauthenticated,
+		},
+		{
+			name:       "Rename user fails if too many attempts failed",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Rename user fails if username is taken",
+			serviceErr: entity.ErrUserExists,
+			expected:   codes.AlreadyExists,
+		},
+		{
+			name:       "Rename user fails if vault was changed",
+			serviceErr: entity.ErrVaultChanged,
+			expected:   codes.Aborted,
+		},
+		{
+			name:       "Rename user fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"Rename",
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+			).
+				Return([]byte(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.Rename(context.Background(), newRenameUserRequest())
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetRecoveryKey(t *testing.T) {
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"GetRecoveryKey",
+		mock.Anything,
+		mock.AnythingOfType("uuid.UUID"),
+	).
+		Return([]byte(gophtest.WrappedRecoveryKey), nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.GetRecoveryKey(context.Background(), &proto.GetRecoveryKeyRequest{})
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedRecoveryKey), resp.GetRecoveryKey())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestGetRecoveryKeyFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.GetRecoveryKey(context.Background(), &proto.GetRecoveryKeyRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestGetRecoveryKeyOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Get recovery key fails if user doesn't exist",
+			serviceErr: entity.ErrUserNotFound,
+			expected:   codes.NotFound,
+		},
+		{
+			name:       "Get recovery key fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"GetRecoveryKey",
+				mock.Anything,
+				mock.AnythingOfType("uuid.UUID"),
+			).
+				Return([]byte(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.GetRecoveryKey(context.Background(), &proto.GetRecoveryKeyRequest{})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetupTOTP(t *testing.T) {
+	uri := "otpauth://totp/GophKeeper:" + gophtest.Username
+
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"SetupTOTP",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+	).
+		Return(uri, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.SetupTOTP(context.Background(), &proto.SetupTOTPRequest{})
+
+	require.NoError(t, err)
+	require.Equal(t, uri, resp.GetUri())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestSetupTOTPFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.SetupTOTP(context.Background(), &proto.SetupTOTPRequest{})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestSetupTOTPOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Setup TOTP fails if already enabled",
+			serviceErr: entity.ErrTOTPAlreadyEnabled,
+			expected:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Setup TOTP fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On("SetupTOTP", mock.Anything, mock.Anything).
+				Return("", tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.SetupTOTP(context.Background(), &proto.SetupTOTPRequest{})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestEnableTOTP(t *testing.T) {
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"EnableTOTP",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		gophtest.OTPCode,
+	).
+		Return([]entity.BackupCode{gophtest.BackupCode}, nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	resp, err := client.EnableTOTP(context.Background(), &proto.EnableTOTPRequest{Code: gophtest.OTPCode})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{gophtest.BackupCode}, resp.GetBackupCodes())
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestEnableTOTPFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.EnableTOTP(context.Background(), &proto.EnableTOTPRequest{Code: gophtest.OTPCode})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestEnableTOTPWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.EnableTOTP(context.Background(), &proto.EnableTOTPRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestEnableTOTPOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Enable TOTP fails on invalid code",
+			serviceErr: entity.ErrInvalidSecondFactor,
+			expected:   codes.InvalidArgument,
+		},
+		{
+			name:       "Enable TOTP fails if too many attempts failed",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Enable TOTP fails if not set up",
+			serviceErr: entity.ErrTOTPNotEnabled,
+			expected:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Enable TOTP fails if already enabled",
+			serviceErr: entity.ErrTOTPAlreadyEnabled,
+			expected:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Enable TOTP fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"EnableTOTP",
+				mock.Anything,
+				mock.AnythingOfType("entity.User"),
+				gophtest.OTPCode,
+			).
+				Return([]entity.BackupCode(nil), tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.EnableTOTP(context.Background(), &proto.EnableTOTPRequest{Code: gophtest.OTPCode})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	m := newServicesMock()
+	m.Users.(*service.UsersServiceMock).On(
+		"DisableTOTP",
+		mock.Anything,
+		mock.AnythingOfType("entity.User"),
+		gophtest.OTPCode,
+	).
+		Return(nil)
+
+	conn := createTestServerWithFakeAuth(t, m)
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.DisableTOTP(context.Background(), &proto.DisableTOTPRequest{Code: gophtest.OTPCode})
+
+	require.NoError(t, err)
+	m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+}
+
+func TestDisableTOTPFailsIfNoUserInfo(t *testing.T) {
+	conn := createTestServer(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.DisableTOTP(context.Background(), &proto.DisableTOTPRequest{Code: gophtest.OTPCode})
+
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestDisableTOTPWithBadRequest(t *testing.T) {
+	conn := createTestServerWithFakeAuth(t, newServicesMock())
+
+	client := proto.NewUsersClient(conn)
+	_, err := client.DisableTOTP(context.Background(), &proto.DisableTOTPRequest{})
+
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestDisableTOTPOnServiceFailure(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		expected   codes.Code
+	}{
+		{
+			name:       "Disable TOTP fails on invalid code",
+			serviceErr: entity.ErrInvalidSecondFactor,
+			expected:   codes.InvalidArgument,
+		},
+		{
+			name:       "Disable TOTP fails if too many attempts failed",
+			serviceErr: &entity.ThrottledError{RetryAfter: time.Minute},
+			expected:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Disable TOTP fails if not enabled",
+			serviceErr: entity.ErrTOTPNotEnabled,
+			expected:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Disable TOTP fails if something bad happened",
+			serviceErr: gophtest.ErrUnexpected,
+			expected:   codes.Internal,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newServicesMock()
+			m.Users.(*service.UsersServiceMock).On(
+				"DisableTOTP",
+				mock.Anything,
+				mock.AnythingOfType("entity.User"),
+				gophtest.OTPCode,
+			).
+				Return(tc.serviceErr)
+
+			conn := createTestServerWithFakeAuth(t, m)
+
+			client := proto.NewUsersClient(conn)
+			_, err := client.DisableTOTP(context.Background(), &proto.DisableTOTPRequest{Code: gophtest.OTPCode})
+
+			requireEqualCode(t, tc.expected, err)
+			m.Users.(*service.UsersServiceMock).AssertExpectations(t)
+		})
+	}
+}
@@ -2,22 +2,52 @@ package grpc
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
 const (
 	MissingField = "not set"
 
-	DefaultMaxUsernameLength   = 128
-	DefaultMaxSecretNameLength = 256
+	DefaultMaxUsernameLength = 128
+
+	DefaultMaxAPITokenNameLength = 128
+
+	// Names are encrypted by client, so the limit includes encryption overhead.
+	DefaultSecretNameLimit = 1024
+
+	// Blind index of a name is HMAC-SHA256 sum.
+	NameIndexLength = 32
 
 	DefaultMetadataLimit = 2 * 1024 * 1024
 
 	DefaultDataLimit = 4 * 1024 * 1024
+
+	DefaultDataKeyLimit = 1024
+
+	// Chunks of blobs are encrypted by client, so the limit includes encryption overhead.
+	DefaultChunkLimit = 2 * 1024 * 1024
+
+	DefaultListPageSize = 100
+	MaxListPageSize     = 1000
+
+	MinKDFSaltLength = 16
+	MaxKDFSaltLength = 64
+	MaxKDFTime       = 16
+	MinKDFMemory     = 19 * 1024
+	MaxKDFMemory     = 1024 * 1024
+	MaxKDFThreads    = 16
+
+	MaxSRPSaltLength = 64
+
+	// Proofs of the session key are SHA-256 sums.
+	SRPProofLength = 32
 )
 
 // validateUsername validates provided username.
@@ -71,14 +101,555 @@ func validateCredentials(username, key string) (*errdetails.BadRequest, bool) {
 	return br, false
 }
 
-// validateSecretName validates provided secret name.
-func validateSecretName(name string) (string, bool) {
-	if name == "" {
+// validateVerifier validates SRP verifier derived by client.
+func validateVerifier(
+	field string,
+	verifier *proto.SRPVerifier,
+) []*errdetails.BadRequest_FieldViolation {
+	if verifier == nil {
+		return []*errdetails.BadRequest_FieldViolation{
+			{
+				Field:       field,
+				Description: MissingField,
+			},
+		}
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	if l := len(verifier.GetSalt()); l < srp.SaltLength || l > MaxSRPSaltLength {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".salt",
+			Description: fmt.Sprintf("should be %d-%d bytes", srp.SaltLength, MaxSRPSaltLength),
+		})
+	}
+
+	if l := len(verifier.GetVerifier()); l == 0 || l > srp.PublicLength {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".verifier",
+			Description: fmt.Sprintf("should be 1-%d bytes", srp.PublicLength),
+		})
+	}
+
+	return violations
+}
+
+// validateClientPublic validates client public ephemeral value.
+func validateClientPublic(public []byte) (string, bool) {
+	if len(public) == 0 {
 		return MissingField, false
 	}
 
-	if len(name) > DefaultMaxSecretNameLength {
-		return fmt.Sprintf("should be <= %d characters", DefaultMaxSecretNameLength), false
+	if len(public) > srp.PublicLength {
+		return fmt.Sprintf("should be <= %d bytes", srp.PublicLength), false
+	}
+
+	return "", true
+}
+
+// validateProof validates proof of the session key.
+// Returns the proof with parsed handshake ID.
+func validateProof(
+	field string,
+	proof *proto.SRPProof,
+) (entity.Proof, []*errdetails.BadRequest_FieldViolation) {
+	if proof == nil {
+		return entity.Proof{}, []*errdetails.BadRequest_FieldViolation{
+			{
+				Field:       field,
+				Description: MissingField,
+			},
+		}
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	id, err := uuid.Parse(proof.GetHandshakeId())
+	if err != nil {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".handshake_id",
+			Description: err.Error(),
+		})
+	}
+
+	if len(proof.GetClientProof()) != SRPProofLength {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".client_proof",
+			Description: fmt.Sprintf("should be %d bytes", SRPProofLength),
+		})
+	}
+
+	return entity.Proof{HandshakeID: id, ClientProof: proof.GetClientProof()}, violations
+}
+
+// validateLoginReq validates goph.LoginRequest.
+func validateLoginReq(req *proto.LoginRequest) (*errdetails.BadRequest, bool) {
+	br := &errdetails.BadRequest{}
+
+	if details, ok := validateCredentials(req.GetUsername(), req.GetSecurityKey()); !ok {
+		br.FieldViolations = append(br.FieldViolations, details.GetFieldViolations()...)
+	}
+
+	br.FieldViolations = append(br.FieldViolations, validateVerifier("verifier", req.GetVerifier())...)
+
+	if len(br.FieldViolations) == 0 {
+		return nil, true
+	}
+
+	return br, false
+}
+
+// validateStartLoginReq validates goph.StartLoginRequest.
+func validateStartLoginReq(req *proto.StartLoginRequest) (*errdetails.BadRequest, bool) {
+	br := &errdetails.BadRequest{}
+
+	if reason, ok := validateUsername(req.GetUsername()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "username",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateClientPublic(req.GetClientPublic()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "client_public",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return nil, true
+	}
+
+	return br, false
+}
+
+// validateKDFParams validates key derivation parameters chosen by client.
+// Only Argon2id with split keys is accepted for new users, legacy derivation
+// is kept for already registered users.
+func validateKDFParams(kdf *proto.KDFParams) []*errdetails.BadRequest_FieldViolation {
+	if kdf == nil {
+		return []*errdetails.BadRequest_FieldViolation{
+			{
+				Field:       "kdf_params",
+				Description: MissingField,
+			},
+		}
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	if kdf.GetAlgorithm() != proto.KDFAlgorithm_KDF_ARGON2ID {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.algorithm",
+			Description: "only " + proto.KDFAlgorithm_KDF_ARGON2ID.String() + " is supported",
+		})
+	}
+
+	if l := len(kdf.GetSalt()); l < MinKDFSaltLength || l > MaxKDFSaltLength {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.salt",
+			Description: fmt.Sprintf("should be %d-%d bytes", MinKDFSaltLength, MaxKDFSaltLength),
+		})
+	}
+
+	if kdf.GetTime() == 0 || kdf.GetTime() > MaxKDFTime {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.time",
+			Description: fmt.Sprintf("should be 1-%d", MaxKDFTime),
+		})
+	}
+
+	if kdf.GetMemory() < MinKDFMemory || kdf.GetMemory() > MaxKDFMemory {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.memory",
+			Description: fmt.Sprintf("should be %d-%d KiB", MinKDFMemory, MaxKDFMemory),
+		})
+	}
+
+	if kdf.GetThreads() == 0 || kdf.GetThreads() > MaxKDFThreads {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.threads",
+			Description: fmt.Sprintf("should be 1-%d", MaxKDFThreads),
+		})
+	}
+
+	if kdf.GetKeySchedule() != proto.KeySchedule_KEY_SCHEDULE_SUBKEYS {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "kdf_params.key_schedule",
+			Description: "only " + proto.KeySchedule_KEY_SCHEDULE_SUBKEYS.String() + " is supported",
+		})
+	}
+
+	return violations
+}
+
+// validateRegisterUserReq validates goph.RegisterUserRequest.
+func validateRegisterUserReq(
+	req *proto.RegisterUserRequest,
+) (*errdetails.BadRequest, bool) {
+	br := &errdetails.BadRequest{}
+
+	if reason, ok := validateUsername(req.GetUsername()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "username",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	br.FieldViolations = append(br.FieldViolations, validateVerifier("verifier", req.GetVerifier())...)
+	br.FieldViolations = append(br.FieldViolations, validateKDFParams(req.GetKdfParams())...)
+
+	if req.GetRecovery() != nil {
+		br.FieldViolations = append(br.FieldViolations, validateRecoveryKit(req.GetRecovery(), true)...)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return nil, true
+	}
+
+	return br, false
+}
+
+// validateRecoveryKit validates recovery kit wrapped by client.
+// Security key of the kit is sent on registration only.
+func validateRecoveryKit(
+	kit *proto.RecoveryKit,
+	withSecurityKey bool,
+) []*errdetails.BadRequest_FieldViolation {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	if withSecurityKey {
+		if reason, ok := validateSecurityKey(kit.GetSecurityKey()); !ok {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       "recovery.security_key",
+				Description: reason,
+			})
+		}
+	} else if kit.GetSecurityKey() != "" {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "recovery.security_key",
+			Description: "should not be set",
+		})
+	}
+
+	if reason, ok := validateDataKey(kit.GetVaultKey()); !ok {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "recovery.vault_key",
+			Description: reason,
+		})
+	}
+
+	if reason, ok := validateDataKey(kit.GetRecoveryKey()); !ok {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "recovery.recovery_key",
+			Description: reason,
+		})
+	}
+
+	return violations
+}
+
+// validateRecoverReq validates goph.RecoverRequest.
+func validateRecoverReq(req *proto.RecoverRequest) (*errdetails.BadRequest, bool) {
+	br := &errdetails.BadRequest{}
+
+	if reason, ok := validateUsername(req.GetUsername()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "username",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateSecurityKey(req.GetRecoverySecurityKey()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "recovery_security_key",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return nil, true
+	}
+
+	return br, false
+}
+
+// validateChangePasswordReq validates goph.ChangePasswordRequest.
+// Returns the proof and re-encrypted secrets with parsed IDs.
+func validateChangePasswordReq(
+	req *proto.ChangePasswordRequest,
+) (entity.Proof, []entity.ReencryptedSecret, *errdetails.BadRequest) {
+	var (
+		proof      entity.Proof
+		violations []*errdetails.BadRequest_FieldViolation
+	)
+
+	br := &errdetails.BadRequest{}
+
+	// The user is verified either by the proof or by the recovery security key.
+	if req.GetRecoverySecurityKey() == "" {
+		proof, violations = validateProof("proof", req.GetProof())
+		br.FieldViolations = append(br.FieldViolations, violations...)
+	} else if req.GetProof() != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "recovery_security_key",
+			Description: "should not be set together with proof",
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	br.FieldViolations = append(br.FieldViolations, validateVerifier("verifier", req.GetVerifier())...)
+
+	br.FieldViolations = append(br.FieldViolations, validateKDFParams(req.GetKdfParams())...)
+
+	if req.GetRecovery() != nil {
+		br.FieldViolations = append(br.FieldViolations, validateRecoveryKit(req.GetRecovery(), false)...)
+	}
+
+	secrets, violations := validateReencryptedSecrets(req.GetSecrets())
+	br.FieldViolations = append(br.FieldViolations, violations...)
+
+	if len(br.FieldViolations) == 0 {
+		return proof, secrets, nil
+	}
+
+	return proof, nil, br
+}
+
+// validateReencryptedSecrets validates secrets re-encrypted with the new key.
+// Returns the secrets with parsed IDs.
+func validateReencryptedSecrets(
+	reencrypted []*proto.ReencryptedSecret,
+) ([]entity.ReencryptedSecret, []*errdetails.BadRequest_FieldViolation) {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	secrets := make([]entity.ReencryptedSecret, 0, len(reencrypted))
+	seen := make(map[uuid.UUID]struct{}, len(reencrypted))
+
+	for i, secret := range reencrypted {
+		field := fmt.Sprintf("secrets[%d]", i)
+
+		id, err := uuid.Parse(secret.GetId())
+		if err != nil {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".id",
+				Description: err.Error(),
+			}
+
+			violations = append(violations, v)
+		} else if _, ok := seen[id]; ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".id",
+				Description: "duplicated",
+			}
+
+			violations = append(violations, v)
+		}
+
+		seen[id] = struct{}{}
+
+		violations = append(violations, validateReencryptedContent(field, secret)...)
+
+		versions, versionViolations := validateReencryptedVersions(field, secret.GetVersions())
+		violations = append(violations, versionViolations...)
+
+		secrets = append(secrets, entity.ReencryptedSecret{
+			ID:        id,
+			DataKey:   secret.GetDataKey(),
+			Name:      secret.GetName(),
+			NameIndex: secret.GetNameIndex(),
+			Metadata:  secret.GetMetadata(),
+			Data:      secret.GetData(),
+			Versions:  versions,
+		})
+	}
+
+	return secrets, violations
+}
+
+// validateReencryptedVersions validates archived versions of a secret re-encrypted with the new key.
+func validateReencryptedVersions(
+	parent string,
+	reencrypted []*proto.ReencryptedVersion,
+) ([]entity.ReencryptedVersion, []*errdetails.BadRequest_FieldViolation) {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	versions := make([]entity.ReencryptedVersion, 0, len(reencrypted))
+	seen := make(map[int32]struct{}, len(reencrypted))
+
+	for i, version := range reencrypted {
+		field := fmt.Sprintf("%s.versions[%d]", parent, i)
+
+		if version.GetVersion() <= 0 {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".version",
+				Description: "must be positive",
+			}
+
+			violations = append(violations, v)
+		} else if _, ok := seen[version.GetVersion()]; ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".version",
+				Description: "duplicated",
+			}
+
+			violations = append(violations, v)
+		}
+
+		seen[version.GetVersion()] = struct{}{}
+
+		violations = append(violations, validateReencryptedContent(field, version)...)
+
+		versions = append(versions, entity.ReencryptedVersion{
+			Version:   version.GetVersion(),
+			DataKey:   version.GetDataKey(),
+			Name:      version.GetName(),
+			NameIndex: version.GetNameIndex(),
+			Metadata:  version.GetMetadata(),
+			Data:      version.GetData(),
+		})
+	}
+
+	return versions, violations
+}
+
+// reencryptedContent is content of a secret or its version re-encrypted with the new key.
+type reencryptedContent interface {
+	GetDataKey() []byte
+	GetName() []byte
+	GetNameIndex() []byte
+	GetMetadata() []byte
+	GetData() []byte
+}
+
+// validateReencryptedContent validates content of a secret or its version re-encrypted with the new key.
+func validateReencryptedContent(field string, content reencryptedContent) []*errdetails.BadRequest_FieldViolation {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0)
+
+	if reason, ok := validateDataKey(content.GetDataKey()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".data_key",
+			Description: reason,
+		}
+
+		violations = append(violations, v)
+	}
+
+	if reason, ok := validateNameIndex(content.GetNameIndex()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".name_index",
+			Description: reason,
+		}
+
+		violations = append(violations, v)
+	}
+
+	// Empty name means that the name is kept unchanged.
+	if len(content.GetName()) != 0 {
+		if reason, ok := validateSecretName(content.GetName()); !ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".name",
+				Description: reason,
+			}
+
+			violations = append(violations, v)
+		}
+	}
+
+	if reason, ok := validateMetadata(content.GetMetadata()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       field + ".metadata",
+			Description: reason,
+		}
+
+		violations = append(violations, v)
+	}
+
+	// Empty data means that only the data key was rewrapped.
+	if len(content.GetData()) != 0 {
+		if reason, ok := validateSecretData(content.GetData()); !ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       field + ".data",
+				Description: reason,
+			}
+
+			violations = append(violations, v)
+		}
+	}
+
+	return violations
+}
+
+// validateRenameUserReq validates goph.RenameUserRequest.
+// Returns the proof and re-encrypted secrets with parsed IDs.
+func validateRenameUserReq(
+	req *proto.RenameUserRequest,
+) (entity.Proof, []entity.ReencryptedSecret, *errdetails.BadRequest) {
+	br := &errdetails.BadRequest{}
+
+	if reason, ok := validateUsername(req.GetUsername()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "username",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	proof, violations := validateProof("proof", req.GetProof())
+	br.FieldViolations = append(br.FieldViolations, violations...)
+
+	br.FieldViolations = append(br.FieldViolations, validateVerifier("verifier", req.GetVerifier())...)
+
+	br.FieldViolations = append(br.FieldViolations, validateKDFParams(req.GetKdfParams())...)
+
+	if req.GetRecovery() != nil {
+		br.FieldViolations = append(br.FieldViolations, validateRecoveryKit(req.GetRecovery(), false)...)
+	}
+
+	secrets, violations := validateReencryptedSecrets(req.GetSecrets())
+	br.FieldViolations = append(br.FieldViolations, violations...)
+
+	if len(br.FieldViolations) == 0 {
+		return proof, secrets, nil
+	}
+
+	return proof, nil, br
+}
+
+// validateSecretName validates provided encrypted secret name.
+func validateSecretName(name []byte) (string, bool) {
+	if len(name) == 0 {
+		return MissingField, false
+	}
+
+	if len(name) > DefaultSecretNameLimit {
+		return fmt.Sprintf("should be <= %d bytes", DefaultSecretNameLimit), false
+	}
+
+	return "", true
+}
+
+// validateNameIndex validates provided blind index of a secret name.
+func validateNameIndex(index []byte) (string, bool) {
+	if len(index) == 0 {
+		return MissingField, false
+	}
+
+	if len(index) != NameIndexLength {
+		return fmt.Sprintf("should be %d bytes", NameIndexLength), false
 	}
 
 	return "", true
@@ -106,12 +677,35 @@ func validateSecretData(data []byte) (string, bool) {
 	return "", true
 }
 
+// validateDataKey validates provided wrapped data key.
+func validateDataKey(key []byte) (string, bool) {
+	if len(key) == 0 {
+		return MissingField, false
+	}
+
+	if len(key) > DefaultDataKeyLimit {
+		return fmt.Sprintf("should be <= %d bytes", DefaultDataKeyLimit), false
+	}
+
+	return "", true
+}
+
 // validateCreateSecretReq validates goph.validateCreateSecretReq.
 func validateCreateSecretReq(
 	req *proto.CreateSecretRequest,
-) (*errdetails.BadRequest, bool) {
+) (uuid.UUID, *errdetails.BadRequest) {
 	br := &errdetails.BadRequest{}
 
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "id",
+			Description: err.Error(),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
 	if reason, ok := validateSecretName(req.GetName()); !ok {
 		v := &errdetails.BadRequest_FieldViolation{
 			Field:       "name",
@@ -121,6 +715,15 @@ func validateCreateSecretReq(
 		br.FieldViolations = append(br.FieldViolations, v)
 	}
 
+	if reason, ok := validateNameIndex(req.GetNameIndex()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "name_index",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
 	if reason, ok := validateMetadata(req.GetMetadata()); !ok {
 		v := &errdetails.BadRequest_FieldViolation{
 			Field:       "metadata",
@@ -139,11 +742,99 @@ func validateCreateSecretReq(
 		br.FieldViolations = append(br.FieldViolations, v)
 	}
 
+	if reason, ok := validateDataKey(req.GetDataKey()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "data_key",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
 	if len(br.FieldViolations) == 0 {
-		return nil, true
+		return id, nil
 	}
 
-	return br, false
+	return id, br
+}
+
+// validateBlobHeader validates header of uploaded blob.
+func validateBlobHeader(header *proto.BlobHeader) (uuid.UUID, *errdetails.BadRequest) {
+	br := &errdetails.BadRequest{}
+
+	if header == nil {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "header",
+			Description: MissingField,
+		})
+
+		return uuid.Nil, br
+	}
+
+	id, err := uuid.Parse(header.GetId())
+	if err != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "id",
+			Description: err.Error(),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateSecretName(header.GetName()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "name",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateNameIndex(header.GetNameIndex()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "name_index",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateMetadata(header.GetMetadata()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "metadata",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if reason, ok := validateDataKey(header.GetDataKey()); !ok {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "data_key",
+			Description: reason,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return id, nil
+	}
+
+	return id, br
+}
+
+// validateBlobChunk validates encrypted chunk of uploaded blob.
+func validateBlobChunk(chunk []byte) (string, bool) {
+	if len(chunk) == 0 {
+		return MissingField, false
+	}
+
+	if len(chunk) > DefaultChunkLimit {
+		return fmt.Sprintf("should be <= %d bytes", DefaultChunkLimit), false
+	}
+
+	return "", true
 }
 
 // validateUpdateSecretReq validates goph.validateUpdateSecretReq.
@@ -176,6 +867,15 @@ func validateUpdateSecretReq(
 		br.FieldViolations = append(br.FieldViolations, v)
 	}
 
+	if req.GetVersion() < 0 {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "version",
+			Description: "should not be negative",
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
 	for _, field := range mask.GetPaths() {
 		var (
 			ok     bool
@@ -185,12 +885,19 @@ func validateUpdateSecretReq(
 		switch field {
 		case "name":
 			reason, ok = validateSecretName(req.GetName())
+			if ok {
+				field = "name_index"
+				reason, ok = validateNameIndex(req.GetNameIndex())
+			}
 
 		case "metadata":
 			reason, ok = validateMetadata(req.GetMetadata())
 
 		case "data":
 			reason, ok = validateSecretData(req.GetData())
+
+		case "data_key":
+			reason, ok = validateDataKey(req.GetDataKey())
 		}
 
 		if !ok {
@@ -209,3 +916,200 @@ func validateUpdateSecretReq(
 
 	return id, br
 }
+
+// validateCreateAPITokenReq validates goph.CreateAPITokenRequest.
+// Returns scope of the token with parsed secret IDs and expiration time, zero if the token never expires.
+func validateCreateAPITokenReq(
+	req *proto.CreateAPITokenRequest,
+) (entity.APITokenScope, time.Time, *errdetails.BadRequest) {
+	var expiresAt time.Time
+
+	br := &errdetails.BadRequest{}
+
+	if req.GetName() == "" {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "name",
+			Description: MissingField,
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	} else if len(req.GetName()) > DefaultMaxAPITokenNameLength {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "name",
+			Description: fmt.Sprintf("should be <= %d characters", DefaultMaxAPITokenNameLength),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	scope := entity.APITokenScope{
+		ReadOnly:  req.GetReadOnly(),
+		SecretIDs: make([]uuid.UUID, 0, len(req.GetSecretIds())),
+	}
+
+	for i, src := range req.GetSecretIds() {
+		id, err := uuid.Parse(src)
+		if err != nil {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("secret_ids[%d]", i),
+				Description: err.Error(),
+			}
+
+			br.FieldViolations = append(br.FieldViolations, v)
+
+			continue
+		}
+
+		scope.SecretIDs = append(scope.SecretIDs, id)
+	}
+
+	if req.GetExpiresAt() != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+
+		if !expiresAt.After(time.Now()) {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       "expires_at",
+				Description: "should be in the future",
+			}
+
+			br.FieldViolations = append(br.FieldViolations, v)
+		}
+	}
+
+	if len(br.FieldViolations) != 0 {
+		return scope, expiresAt, br
+	}
+
+	return scope, expiresAt, nil
+}
+
+// validateListSecretsReq validates goph.ListSecretsRequest.
+// Returns filter of the requested page, the service default is used if page size is not set.
+func validateListSecretsReq(req *proto.ListSecretsRequest) (entity.SecretsFilter, *errdetails.BadRequest) {
+	br := &errdetails.BadRequest{}
+
+	filter := entity.SecretsFilter{
+		Kinds:     req.GetKinds(),
+		NameIndex: req.GetNameIndex(),
+		Limit:     int(req.GetPageSize()),
+	}
+
+	switch {
+	case req.GetPageSize() == 0:
+		filter.Limit = DefaultListPageSize
+
+	case req.GetPageSize() < 0 || req.GetPageSize() > MaxListPageSize:
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "page_size",
+			Description: fmt.Sprintf("should be in range [0, %d]", MaxListPageSize),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	after, err := entity.ParsePageToken(req.GetPageToken())
+	if err != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "page_token",
+			Description: err.Error(),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	filter.After = after
+
+	for i, kind := range req.GetKinds() {
+		if _, ok := proto.DataKind_name[int32(kind)]; !ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("kinds[%d]", i),
+				Description: "unknown kind",
+			}
+
+			br.FieldViolations = append(br.FieldViolations, v)
+		}
+	}
+
+	if len(req.GetNameIndex()) != 0 {
+		if reason, ok := validateNameIndex(req.GetNameIndex()); !ok {
+			v := &errdetails.BadRequest_FieldViolation{
+				Field:       "name_index",
+				Description: reason,
+			}
+
+			br.FieldViolations = append(br.FieldViolations, v)
+		}
+	}
+
+	if len(br.FieldViolations) != 0 {
+		return filter, br
+	}
+
+	return filter, nil
+}
+
+// validateSecretVersionReq validates requests addressing particular version of a secret,
+// e.g. goph.GetSecretVersionRequest.
+func validateSecretVersionReq(req interface {
+	GetId() string
+	GetVersion() int32
+},
+) (uuid.UUID, *errdetails.BadRequest) {
+	br := &errdetails.BadRequest{}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "id",
+			Description: err.Error(),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if req.GetVersion() <= 0 {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "version",
+			Description: "should be positive",
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return id, nil
+	}
+
+	return id, br
+}
+
+// validateRestoreSecretReq validates goph.RestoreSecretRequest,
+// zero version stands for restoring of a secret from trash.
+func validateRestoreSecretReq(req *proto.RestoreSecretRequest) (uuid.UUID, *errdetails.BadRequest) {
+	br := &errdetails.BadRequest{}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "id",
+			Description: err.Error(),
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if req.GetVersion() < 0 {
+		v := &errdetails.BadRequest_FieldViolation{
+			Field:       "version",
+			Description: "should not be negative",
+		}
+
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+
+	if len(br.FieldViolations) == 0 {
+		return id, nil
+	}
+
+	return id, br
+}
@@ -0,0 +1,107 @@
+package entity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type scopeKey string
+
+const (
+	scopeKeyName scopeKey = "scope"
+)
+
+// APITokenPrefix distinguishes API tokens from JWT access tokens.
+const APITokenPrefix = "gkt_"
+
+const apiTokenLength = 32
+
+var (
+	ErrAPITokenExists   = errors.New("API token with such name already exists")
+	ErrAPITokenNotFound = errors.New("API token not found")
+	ErrOutOfScope       = errors.New("operation is not allowed by the API token scope")
+)
+
+// APIToken is opaque long-lived token used by automation instead of login.
+type APIToken string
+
+// NewAPIToken generates new random API token.
+func NewAPIToken() (APIToken, error) {
+	buf := make([]byte, apiTokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("APIToken - NewAPIToken - rand.Read: %w", err)
+	}
+
+	return APIToken(APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf)), nil
+}
+
+// IsAPIToken tells whether the credential presented by client is API token.
+func IsAPIToken(src string) bool {
+	return strings.HasPrefix(TokenFromString(src).String(), APITokenPrefix)
+}
+
+// String converts APIToken to string.
+func (t APIToken) String() string {
+	return string(t)
+}
+
+// Hash returns hash of the token, only the hash is stored.
+// The token is random enough, so salt and slow hashing are not required.
+func (t APIToken) Hash() []byte {
+	sum := sha256.Sum256([]byte(t))
+
+	return sum[:]
+}
+
+// APITokenScope limits what the holder of API token is allowed to do.
+// Empty list of secret IDs grants access to all secrets of the user.
+type APITokenScope struct {
+	ReadOnly  bool
+	SecretIDs []uuid.UUID
+}
+
+// AllowsSecret tells whether the secret is accessible with the token.
+func (s APITokenScope) AllowsSecret(id uuid.UUID) bool {
+	return len(s.SecretIDs) == 0 || slices.Contains(s.SecretIDs, id)
+}
+
+// WithContext injects scope of API token into context.
+func (s APITokenScope) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKeyName, &s)
+}
+
+// APITokenScopeFromContext extracts scope of API token from context.
+// Returns nil, if the request isn't authenticated with API token.
+func APITokenScopeFromContext(ctx context.Context) *APITokenScope {
+	if val := ctx.Value(scopeKeyName); val != nil {
+		return val.(*APITokenScope)
+	}
+
+	return nil
+}
+
+// APITokenRecord is stored API token.
+// ExpiresAt is zero if the token never expires.
+type APITokenRecord struct {
+	ID   uuid.UUID
+	Name string
+	Hash []byte
+	User User
+	APITokenScope
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired tells whether the token has expired.
+func (r APITokenRecord) IsExpired() bool {
+	return !r.ExpiresAt.IsZero() && time.Now().After(r.ExpiresAt)
+}
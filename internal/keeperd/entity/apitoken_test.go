@@ -0,0 +1,55 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	first, err := entity.NewAPIToken()
+	require.NoError(t, err)
+
+	second, err := entity.NewAPIToken()
+	require.NoError(t, err)
+
+	require.True(t, entity.IsAPIToken(first.String()))
+	require.NotEqual(t, first, second)
+	require.NotEqual(t, first.Hash(), second.Hash())
+}
+
+func TestIsAPIToken(t *testing.T) {
+	require.True(t, entity.IsAPIToken(gophtest.APIToken))
+	require.True(t, entity.IsAPIToken("Bearer "+gophtest.APIToken))
+	require.False(t, entity.IsAPIToken(gophtest.AccessToken))
+	require.False(t, entity.IsAPIToken(""))
+}
+
+func TestAPITokenScopeAllowsSecret(t *testing.T) {
+	id := uuid.New()
+
+	require.True(t, entity.APITokenScope{}.AllowsSecret(id))
+	require.True(t, entity.APITokenScope{SecretIDs: []uuid.UUID{id}}.AllowsSecret(id))
+	require.False(t, entity.APITokenScope{SecretIDs: []uuid.UUID{uuid.New()}}.AllowsSecret(id))
+}
+
+func TestAPITokenScopeFromContext(t *testing.T) {
+	require.Nil(t, entity.APITokenScopeFromContext(context.Background()))
+
+	expected := entity.APITokenScope{ReadOnly: true}
+	ctx := expected.WithContext(context.Background())
+
+	require.Equal(t, expected, *entity.APITokenScopeFromContext(ctx))
+}
+
+func TestAPITokenRecordIsExpired(t *testing.T) {
+	require.False(t, entity.APITokenRecord{}.IsExpired())
+	require.False(t, entity.APITokenRecord{ExpiresAt: time.Now().Add(time.Hour)}.IsExpired())
+	require.True(t, entity.APITokenRecord{ExpiresAt: time.Now().Add(-time.Hour)}.IsExpired())
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+// Default Argon2id cost, used to answer prelogin requests for unknown users.
+const (
+	DefaultKDFTime    = 3
+	DefaultKDFMemory  = 64 * 1024
+	DefaultKDFThreads = 4
+
+	KDFSaltLength = 16
+)
+
+// KDFParams describes how client derives encryption key from master password.
+// The service never derives keys itself, it only stores the parameters
+// chosen at registration and returns them back before login.
+type KDFParams struct {
+	Algorithm proto.KDFAlgorithm
+	Salt      []byte
+	Time      uint32
+	Memory    uint32
+	Threads   uint32
+
+	KeySchedule proto.KeySchedule
+
+	// KeyFile is set if the client mixes a key file into the master key.
+	KeyFile bool
+}
+
+// NewFakeKDFParams creates plausible parameters for a user which doesn't exist.
+// The salt is stable for the same username, so prelogin responses can't be used
+// to find out whether a user is registered.
+func NewFakeKDFParams(username string, secret creds.Password) KDFParams {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(username))
+
+	return KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      mac.Sum(nil)[:KDFSaltLength],
+		Time:      DefaultKDFTime,
+		Memory:    DefaultKDFMemory,
+		Threads:   DefaultKDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
@@ -0,0 +1,27 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func TestFakeKDFParamsAreStable(t *testing.T) {
+	first := entity.NewFakeKDFParams(gophtest.Username, gophtest.Secret)
+	second := entity.NewFakeKDFParams(gophtest.Username, gophtest.Secret)
+
+	require.Equal(t, first, second)
+	require.Equal(t, proto.KDFAlgorithm_KDF_ARGON2ID, first.Algorithm)
+	require.Len(t, first.Salt, entity.KDFSaltLength)
+}
+
+func TestFakeKDFParamsDependOnUsernameAndSecret(t *testing.T) {
+	sat := entity.NewFakeKDFParams(gophtest.Username, gophtest.Secret)
+
+	require.NotEqual(t, sat.Salt, entity.NewFakeKDFParams("root", gophtest.Secret).Salt)
+	require.NotEqual(t, sat.Salt, entity.NewFakeKDFParams(gophtest.Username, "yyy").Salt)
+}
@@ -0,0 +1,292 @@
+package entity
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported key: Ed25519 or RSA key in PEM format expected")
+	ErrUnknownKeyID   = errors.New("token is signed with unknown key")
+)
+
+// SigningKey is a key used to sign or verify access tokens.
+// Asymmetric keys are identified by the JWK thumbprint of the public key (RFC 7638),
+// which is put into "kid" header of the token.
+// HMAC secret has no ID, as it never leaves the service.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	// Private key or HMAC secret, nil if the key is used to verify tokens only.
+	Private any
+
+	// Public key or HMAC secret.
+	Public any
+}
+
+// NewSecretKey creates HS256 key from the service secret.
+func NewSecretKey(secret creds.Password) SigningKey {
+	return SigningKey{
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}
+}
+
+// ParseSigningKey parses Ed25519 or RSA private key in PEM format.
+func ParseSigningKey(data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, ErrUnsupportedKey
+	}
+
+	var (
+		private any
+		err     error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return SigningKey{}, ErrUnsupportedKey
+	}
+
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("ParseSigningKey - x509.Parse: %w", err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return SigningKey{}, ErrUnsupportedKey
+	}
+
+	key, err := newPublicKey(signer.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	key.Private = private
+
+	return key, nil
+}
+
+// ParseVerificationKey parses Ed25519 or RSA public key in PEM format.
+// Private key is accepted as well, only public part of it is kept.
+func ParseVerificationKey(data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, ErrUnsupportedKey
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		key, err := ParseSigningKey(data)
+		if err != nil {
+			return SigningKey{}, err
+		}
+
+		key.Private = nil
+
+		return key, nil
+	}
+
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("ParseVerificationKey - x509.ParsePKIXPublicKey: %w", err)
+	}
+
+	return newPublicKey(public)
+}
+
+func newPublicKey(public any) (SigningKey, error) {
+	key := SigningKey{Public: public}
+
+	switch public.(type) {
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	default:
+		return SigningKey{}, ErrUnsupportedKey
+	}
+
+	jwk := key.JWK()
+
+	id, err := jwk.Thumbprint()
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("newPublicKey - jwk.Thumbprint: %w", err)
+	}
+
+	key.ID = id
+
+	return key, nil
+}
+
+// JWK returns public part of the key in JSON Web Key format.
+// HMAC secret is never exposed, empty JWK is returned instead.
+func (k SigningKey) JWK() JSONWebKey {
+	jwk := JSONWebKey{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Method.Alg(),
+	}
+
+	switch public := k.Public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+
+	default:
+		return JSONWebKey{}
+	}
+
+	return jwk
+}
+
+// JSONWebKey is public key in format defined by RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// Thumbprint computes JWK thumbprint defined by RFC 7638.
+func (k JSONWebKey) Thumbprint() (string, error) {
+	var members any
+
+	// Only required members in lexicographic order.
+	switch k.KeyType {
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Curve, k.KeyType, k.X}
+
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.KeyType, k.N}
+
+	default:
+		return "", ErrUnsupportedKey
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Keyring signs access tokens with the current signing key and verifies them
+// with any of the active keys, so the signing key could be rotated
+// without invalidating tokens issued before.
+type Keyring struct {
+	signer SigningKey
+	keys   map[string]SigningKey
+
+	// Active keys in order of preference, the signer goes first.
+	active []SigningKey
+}
+
+// NewKeyring creates keyring signing tokens with the signer.
+// Tokens signed by any of the verifiers are accepted as well.
+func NewKeyring(signer SigningKey, verifiers ...SigningKey) *Keyring {
+	k := &Keyring{
+		signer: signer,
+		keys:   map[string]SigningKey{signer.ID: signer},
+		active: []SigningKey{signer},
+	}
+
+	for _, key := range verifiers {
+		if _, ok := k.keys[key.ID]; ok {
+			continue
+		}
+
+		k.keys[key.ID] = key
+		k.active = append(k.active, key)
+	}
+
+	return k
+}
+
+// NewSecretKeyring creates keyring signing and verifying tokens with the service secret.
+func NewSecretKeyring(secret creds.Password) *Keyring {
+	return NewKeyring(NewSecretKey(secret))
+}
+
+// Sign issues token with provided claims signed by the current signing key.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signer.Method, claims)
+
+	if k.signer.ID != "" {
+		token.Header["kid"] = k.signer.ID
+	}
+
+	return token.SignedString(k.signer.Private)
+}
+
+// Keyfunc looks up the key the token is signed with.
+// Tokens without key ID are verified with HMAC secret, if the keyring has it.
+func (k *Keyring) Keyfunc(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return key.Public, nil
+}
+
+// PublicKeys returns public keys of the keyring in JWK format,
+// so the tokens could be verified by third parties.
+// HMAC secret is never exposed.
+func (k *Keyring) PublicKeys() []JSONWebKey {
+	rv := make([]JSONWebKey, 0, len(k.active))
+
+	for _, key := range k.active {
+		if jwk := key.JWK(); jwk.KeyType != "" {
+			rv = append(rv, jwk)
+		}
+	}
+
+	return rv
+}
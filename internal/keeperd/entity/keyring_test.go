@@ -0,0 +1,211 @@
+package entity_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestUser() entity.User {
+	return entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+}
+
+func newEd25519PEM(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func newRSAPEM(t *testing.T) []byte {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	})
+}
+
+func TestTokenSignedWithEd25519Key(t *testing.T) {
+	privatePEM, publicPEM := newEd25519PEM(t)
+
+	signer, err := entity.ParseSigningKey(privatePEM)
+	require.NoError(t, err)
+
+	verifier, err := entity.ParseVerificationKey(publicPEM)
+	require.NoError(t, err)
+	require.Equal(t, signer.ID, verifier.ID)
+	require.Nil(t, verifier.Private)
+
+	user := newTestUser()
+
+	token, err := entity.NewAccessToken(user, entity.NewKeyring(signer))
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token.String(), jwt.MapClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "EdDSA", parsed.Header["alg"])
+	require.Equal(t, signer.ID, parsed.Header["kid"])
+
+	// Verification doesn't require the private key.
+	claims, err := token.Decode(entity.NewKeyring(verifier))
+	require.NoError(t, err)
+	require.Equal(t, user.ID.String(), claims.Subject)
+}
+
+func TestTokenSignedWithRSAKey(t *testing.T) {
+	signer, err := entity.ParseSigningKey(newRSAPEM(t))
+	require.NoError(t, err)
+	require.Equal(t, jwt.SigningMethodRS256, signer.Method)
+
+	keys := entity.NewKeyring(signer)
+
+	token, err := entity.NewAccessToken(newTestUser(), keys)
+	require.NoError(t, err)
+
+	_, err = token.Decode(keys)
+	require.NoError(t, err)
+}
+
+func TestTokenVerifiedAfterKeyRotation(t *testing.T) {
+	previousPEM, _ := newEd25519PEM(t)
+	currentPEM, _ := newEd25519PEM(t)
+
+	previous, err := entity.ParseSigningKey(previousPEM)
+	require.NoError(t, err)
+
+	current, err := entity.ParseSigningKey(currentPEM)
+	require.NoError(t, err)
+
+	token, err := entity.NewAccessToken(newTestUser(), entity.NewKeyring(previous))
+	require.NoError(t, err)
+
+	_, err = token.Decode(entity.NewKeyring(current, previous))
+	require.NoError(t, err)
+
+	_, err = token.Decode(entity.NewKeyring(current))
+	require.ErrorIs(t, err, entity.ErrUnknownKeyID)
+}
+
+func TestTokenDecodeRejectsOtherKeyTypes(t *testing.T) {
+	privatePEM, _ := newEd25519PEM(t)
+
+	signer, err := entity.ParseSigningKey(privatePEM)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		signer *entity.Keyring
+		keys   *entity.Keyring
+	}{
+		{
+			name:   "Token signed with secret is rejected by asymmetric keyring",
+			signer: entity.NewSecretKeyring(gophtest.Secret),
+			keys:   entity.NewKeyring(signer),
+		},
+		{
+			name:   "Token signed with asymmetric key is rejected by secret keyring",
+			signer: entity.NewKeyring(signer),
+			keys:   entity.NewSecretKeyring(gophtest.Secret),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := entity.NewAccessToken(newTestUser(), tc.signer)
+			require.NoError(t, err)
+
+			_, err = token.Decode(tc.keys)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseKeyFailsOnUnsupportedKey(t *testing.T) {
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "Not PEM",
+			data: []byte("xxx"),
+		},
+		{
+			name: "Certificate instead of key",
+			data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("xxx")}),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := entity.ParseSigningKey(tc.data)
+			require.ErrorIs(t, err, entity.ErrUnsupportedKey)
+
+			_, err = entity.ParseVerificationKey(tc.data)
+			require.ErrorIs(t, err, entity.ErrUnsupportedKey)
+		})
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	currentPEM, _ := newEd25519PEM(t)
+
+	current, err := entity.ParseSigningKey(currentPEM)
+	require.NoError(t, err)
+
+	previous, err := entity.ParseVerificationKey(newRSAPEM(t))
+	require.NoError(t, err)
+
+	keys := entity.NewKeyring(current, previous, entity.NewSecretKey(gophtest.Secret)).PublicKeys()
+
+	require.Len(t, keys, 2)
+	require.Equal(t, current.ID, keys[0].KeyID)
+	require.Equal(t, "OKP", keys[0].KeyType)
+	require.Equal(t, "EdDSA", keys[0].Algorithm)
+	require.Equal(t, previous.ID, keys[1].KeyID)
+	require.Equal(t, "RSA", keys[1].KeyType)
+	require.Equal(t, "AQAB", keys[1].E)
+}
+
+func TestPublicKeysOfSecretKeyring(t *testing.T) {
+	require.Empty(t, entity.NewSecretKeyring(gophtest.Secret).PublicKeys())
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// Example from RFC 8037, appendix A.3.
+	jwk := entity.JSONWebKey{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		X:       "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}
+
+	rv, err := jwk.Thumbprint()
+
+	require.NoError(t, err)
+	require.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", rv)
+}
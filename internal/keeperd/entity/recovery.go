@@ -0,0 +1,17 @@
+package entity
+
+// RecoveryKit lets a user regain access to the vault with the recovery code.
+// The service never sees the recovery code, only the security key derived from it
+// and the vault key wrapped by the recovery key.
+// RecoveryKey is the recovery key wrapped by the vault key, so the kit
+// could be rewrapped by client when the master password is changed.
+type RecoveryKit struct {
+	SecurityKey string
+	VaultKey    []byte
+	RecoveryKey []byte
+}
+
+// IsEmpty reports whether recovery is not set up.
+func (k RecoveryKit) IsEmpty() bool {
+	return len(k.VaultKey) == 0
+}
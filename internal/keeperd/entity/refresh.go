@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshTokenLifeTime is time given to client to exchange a refresh token.
+const RefreshTokenLifeTime = 30 * 24 * time.Hour
+
+const refreshTokenLength = 32
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, all tokens of the session are revoked")
+)
+
+// RefreshToken is opaque token used to issue new access token without login.
+// Each refresh token could be used only once, see RefreshTokenRecord.
+type RefreshToken string
+
+// NewRefreshToken generates new random refresh token.
+func NewRefreshToken() (RefreshToken, error) {
+	buf := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("RefreshToken - NewRefreshToken - rand.Read: %w", err)
+	}
+
+	return RefreshToken(base64.RawURLEncoding.EncodeToString(buf)), nil
+}
+
+// String converts RefreshToken to string.
+func (t RefreshToken) String() string {
+	return string(t)
+}
+
+// Hash returns hash of the token, only the hash is stored.
+// The token is random enough, so salt and slow hashing are not required.
+func (t RefreshToken) Hash() []byte {
+	sum := sha256.Sum256([]byte(t))
+
+	return sum[:]
+}
+
+// RefreshTokenRecord is stored refresh token.
+// All tokens rotated from the same login share the family,
+// so the whole family is revoked if a used token is presented again.
+// The family is the login session, Device is recorded when the session starts.
+type RefreshTokenRecord struct {
+	Hash      []byte
+	FamilyID  uuid.UUID
+	User      User
+	Device    Device
+	ExpiresAt time.Time
+}
+
+// TokenPair is a pair of access token and refresh token issued on login.
+// If the user has to pass second factor, only the partial token is issued instead.
+type TokenPair struct {
+	AccessToken  AccessToken
+	RefreshToken RefreshToken
+	PartialToken AccessToken
+}
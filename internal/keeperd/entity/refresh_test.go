@@ -0,0 +1,29 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+func TestNewRefreshTokenIsRandom(t *testing.T) {
+	first, err := entity.NewRefreshToken()
+	require.NoError(t, err)
+
+	second, err := entity.NewRefreshToken()
+	require.NoError(t, err)
+
+	require.NotEmpty(t, first)
+	require.NotEqual(t, first, second)
+	require.NotEqual(t, first.Hash(), second.Hash())
+}
+
+func TestRefreshTokenHashIsStable(t *testing.T) {
+	sat, err := entity.NewRefreshToken()
+	require.NoError(t, err)
+
+	require.Equal(t, sat.Hash(), entity.RefreshToken(sat.String()).Hash())
+	require.Len(t, sat.Hash(), 32)
+}
@@ -1,7 +1,10 @@
 package entity
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,13 +15,116 @@ var (
 	ErrSecretNotFound     = errors.New("secret not found")
 	ErrSecretExists       = errors.New("secret already exists")
 	ErrSecretNameConflict = errors.New("secret with such name already exists")
+	ErrVaultChanged       = errors.New("secrets were modified concurrently")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+	ErrEmptyBlob          = errors.New("blob has no chunks")
+	ErrBlobTooLarge       = errors.New("blob exceeds size limit")
+	ErrNotBlob            = errors.New("secret data is not stored as a blob")
+	ErrVersionNotFound    = errors.New("secret version not found")
+	ErrSecretModified     = errors.New("secret was modified concurrently")
 )
 
 // Secret represents full secret info stored in the service.
+// DataKey is empty for legacy secrets encrypted by the vault key directly.
+// NameIndex is empty for legacy secrets with plain text names.
+// Data of blobs is stored in chunks, so Data is empty and BlobSize is the total size of the chunks.
+// Version is incremented on every update, UpdatedAt is the time the version was created.
+// DeletedAt is set only for secrets moved to trash.
 type Secret struct {
-	ID       uuid.UUID `db:"secret_id"`
-	Name     string
-	Kind     proto.DataKind
-	Metadata []byte
-	Data     []byte
+	ID        uuid.UUID `db:"secret_id"`
+	Name      []byte
+	NameIndex []byte `db:"name_index"`
+	Kind      proto.DataKind
+	DataKey   []byte `db:"data_key"`
+	Metadata  []byte
+	Data      []byte
+	BlobSize  int64     `db:"blob_size"`
+	Version   int32     `db:"version"`
+	UpdatedAt time.Time `db:"updated_at"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// IsBlob tells whether data of the secret is stored in chunks.
+func (s Secret) IsBlob() bool {
+	return s.BlobSize > 0
+}
+
+// ChunkSource provides encrypted chunks of uploaded blob one by one,
+// io.EOF is returned after the last chunk.
+type ChunkSource func() ([]byte, error)
+
+// ChunkSink consumes encrypted chunks of downloaded blob one by one.
+type ChunkSink func(chunk []byte) error
+
+// ReencryptedSecret contains data key of a secret wrapped by a new key
+// and blind index of the name computed with the new key.
+// Name, Metadata and Data are set only if they were re-encrypted too.
+type ReencryptedSecret struct {
+	ID        uuid.UUID
+	DataKey   []byte
+	Name      []byte
+	NameIndex []byte
+	Metadata  []byte
+	Data      []byte
+	Versions  []ReencryptedVersion
+}
+
+// ReencryptedVersion is an archived version of a secret re-encrypted with a new key,
+// see ReencryptedSecret.
+type ReencryptedVersion struct {
+	Version   int32
+	DataKey   []byte
+	Name      []byte
+	NameIndex []byte
+	Metadata  []byte
+	Data      []byte
+}
+
+// SecretsFilter narrows down list of secrets, empty fields don't restrict the list.
+// Secrets are listed in order of their IDs starting right after the After cursor.
+// IDs restrict the list to the scope of API token.
+type SecretsFilter struct {
+	Kinds     []proto.DataKind
+	NameIndex []byte
+	IDs       []uuid.UUID
+	After     uuid.UUID
+	Limit     int
+}
+
+// SecretsPage is a page of listed secrets.
+// Next is the cursor of the next page, uuid.Nil if the page is the last one.
+type SecretsPage struct {
+	Secrets      []Secret
+	Next         uuid.UUID
+	VaultVersion int64
+}
+
+// NewPageToken creates opaque token of the page starting after the cursor.
+// Returns empty string for uuid.Nil, as there is no next page.
+func NewPageToken(cursor uuid.UUID) string {
+	if cursor == uuid.Nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(cursor[:])
+}
+
+// ParsePageToken extracts cursor from the page token.
+// Empty token stands for the first page.
+func ParsePageToken(token string) (uuid.UUID, error) {
+	if token == "" {
+		return uuid.Nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+
+	cursor, err := uuid.FromBytes(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+
+	return cursor, nil
 }
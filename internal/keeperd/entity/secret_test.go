@@ -0,0 +1,55 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+func TestPageToken(t *testing.T) {
+	cursor := uuid.New()
+
+	token := entity.NewPageToken(cursor)
+	require.NotEmpty(t, token)
+
+	parsed, err := entity.ParsePageToken(token)
+
+	require.NoError(t, err)
+	require.Equal(t, cursor, parsed)
+}
+
+func TestPageTokenOfLastPage(t *testing.T) {
+	require.Empty(t, entity.NewPageToken(uuid.Nil))
+
+	cursor, err := entity.ParsePageToken("")
+
+	require.NoError(t, err)
+	require.Equal(t, uuid.Nil, cursor)
+}
+
+func TestParseInvalidPageToken(t *testing.T) {
+	tt := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "Parse page token fails if token is not base64",
+			token: "!!!",
+		},
+		{
+			name:  "Parse page token fails if token has wrong length",
+			token: "AAAA",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := entity.ParsePageToken(tc.token)
+
+			require.ErrorIs(t, err, entity.ErrInvalidPageToken)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type deviceKey string
+
+const (
+	deviceKeyName deviceKey = "device"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Device describes the client which has started a session.
+type Device struct {
+	Name        string `db:"device_name"`
+	UserAgent   string `db:"user_agent"`
+	PeerAddress string `db:"peer_address"`
+}
+
+// Session is a login of a user on a device.
+// The session lasts as long as the family of refresh tokens rotated from the login,
+// so the ID of the session is the ID of the family.
+type Session struct {
+	ID uuid.UUID `db:"session_id"`
+	Device
+	CreatedAt  time.Time `db:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+}
+
+// WithDevice injects info about the client device into context.
+func WithDevice(ctx context.Context, device Device) context.Context {
+	return context.WithValue(ctx, deviceKeyName, device)
+}
+
+// DeviceFromContext extracts info about the client device from context.
+// Returns empty Device, if the device is unknown.
+func DeviceFromContext(ctx context.Context) Device {
+	if val := ctx.Value(deviceKeyName); val != nil {
+		return val.(Device)
+	}
+
+	return Device{}
+}
@@ -0,0 +1,20 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestDeviceFromContext(t *testing.T) {
+	require.Empty(t, entity.DeviceFromContext(context.Background()))
+
+	expected := entity.Device{Name: gophtest.DeviceName, UserAgent: gophtest.UserAgent}
+	ctx := entity.WithDevice(context.Background(), expected)
+
+	require.Equal(t, expected, entity.DeviceFromContext(ctx))
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+// HandshakeLifeTime is time given to client to prove the session key.
+const HandshakeLifeTime = time.Minute
+
+// Verifier is SRP-6a verifier of a user.
+// The service never receives the auth subkey the verifier is derived from.
+type Verifier struct {
+	Salt     []byte
+	Verifier []byte
+}
+
+// NewFakeVerifier creates plausible verifier for a user which doesn't exist.
+// The verifier is stable for the same username, so challenges can't be used
+// to find out whether a user is registered.
+func NewFakeVerifier(username string, secret creds.Password) Verifier {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("srp:" + username))
+	sum := mac.Sum(nil)
+
+	salt := sum[:srp.SaltLength]
+
+	return Verifier{
+		Salt:     salt,
+		Verifier: srp.Verifier(salt, sum[srp.SaltLength:]),
+	}
+}
+
+// Challenge is response of the service to the client public ephemeral value.
+type Challenge struct {
+	HandshakeID  uuid.UUID
+	Salt         []byte
+	ServerPublic []byte
+}
+
+// Handshake is SRP-6a handshake waiting for the client proof.
+type Handshake struct {
+	ID          uuid.UUID
+	User        User
+	ClientProof []byte
+	ServerProof []byte
+	ExpiresAt   time.Time
+}
+
+// Proof is the client proof of the session key of a handshake.
+type Proof struct {
+	HandshakeID uuid.UUID
+	ClientProof []byte
+}
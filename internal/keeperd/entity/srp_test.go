@@ -0,0 +1,27 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+func TestFakeVerifierIsStable(t *testing.T) {
+	first := entity.NewFakeVerifier(gophtest.Username, gophtest.Secret)
+	second := entity.NewFakeVerifier(gophtest.Username, gophtest.Secret)
+
+	require.Equal(t, first, second)
+	require.Len(t, first.Salt, srp.SaltLength)
+	require.Len(t, first.Verifier, srp.PublicLength)
+}
+
+func TestFakeVerifierDependsOnUsernameAndSecret(t *testing.T) {
+	sat := entity.NewFakeVerifier(gophtest.Username, gophtest.Secret)
+
+	require.NotEqual(t, sat.Salt, entity.NewFakeVerifier("root", gophtest.Secret).Salt)
+	require.NotEqual(t, sat.Salt, entity.NewFakeVerifier(gophtest.Username, "yyy").Salt)
+}
@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type peerKey string
+
+const (
+	peerKeyName peerKey = "peer"
+)
+
+const (
+	// ThrottleBaseDelay is the lockout after the first failure above the free attempts,
+	// each next failure doubles it.
+	ThrottleBaseDelay = time.Second
+
+	// ThrottleMaxDelay caps the lockout.
+	ThrottleMaxDelay = 15 * time.Minute
+
+	// ThrottleResetAfter is the period of silence after which failures are forgotten.
+	ThrottleResetAfter = 24 * time.Hour
+)
+
+var ErrTooManyAttempts = errors.New("too many failed attempts")
+
+// ThrottleScope tells what failed attempts are counted for.
+type ThrottleScope string
+
+const (
+	// ThrottleScopeUsername counts failed attempts to log in as a user.
+	ThrottleScopeUsername ThrottleScope = "username"
+
+	// ThrottleScopePeer counts failed attempts made from an IP address.
+	// More attempts are allowed, as the address could be shared by many clients behind NAT.
+	ThrottleScopePeer ThrottleScope = "peer"
+
+	// ThrottleScopeRegistration counts all attempts to register made from an IP address.
+	// It is kept apart from failed logins, so taken usernames don't lock out logins
+	// from an address shared by many clients.
+	ThrottleScopeRegistration ThrottleScope = "registration"
+)
+
+// ThrottleKey identifies a counter of failed attempts.
+type ThrottleKey struct {
+	Scope   ThrottleScope
+	Subject string
+}
+
+// UsernameThrottleKey creates key counting failed attempts to log in as the user.
+func UsernameThrottleKey(username string) ThrottleKey {
+	return ThrottleKey{Scope: ThrottleScopeUsername, Subject: username}
+}
+
+// PeerThrottleKey creates key counting failed attempts made from the IP address.
+func PeerThrottleKey(ip string) ThrottleKey {
+	return ThrottleKey{Scope: ThrottleScopePeer, Subject: ip}
+}
+
+// RegistrationThrottleKey creates key counting attempts to register made from the IP address.
+func RegistrationThrottleKey(ip string) ThrottleKey {
+	return ThrottleKey{Scope: ThrottleScopeRegistration, Subject: ip}
+}
+
+// FreeAttempts returns number of failures allowed before the lockout.
+func (k ThrottleKey) FreeAttempts() int {
+	switch k.Scope {
+	case ThrottleScopePeer:
+		return 20
+	case ThrottleScopeRegistration:
+		return 10
+	default:
+		return 5
+	}
+}
+
+// Delay returns lockout after the number of failures.
+// The lockout grows exponentially up to ThrottleMaxDelay.
+func (k ThrottleKey) Delay(failures int) time.Duration {
+	over := failures - k.FreeAttempts()
+	if over <= 0 {
+		return 0
+	}
+
+	delay := ThrottleBaseDelay
+	for i := 1; i < over; i++ {
+		delay *= 2
+		if delay >= ThrottleMaxDelay {
+			return ThrottleMaxDelay
+		}
+	}
+
+	return delay
+}
+
+// ThrottledError is returned when the caller is locked out after too many failed attempts.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// WithPeerIP injects IP address of the caller into context.
+func WithPeerIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, peerKeyName, ip)
+}
+
+// PeerIPFromContext extracts IP address of the caller from context.
+// Returns empty string, if the address is unknown.
+func PeerIPFromContext(ctx context.Context) string {
+	if val := ctx.Value(peerKeyName); val != nil {
+		return val.(string)
+	}
+
+	return ""
+}
@@ -0,0 +1,82 @@
+package entity_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	tt := []struct {
+		name     string
+		key      entity.ThrottleKey
+		failures int
+		expected time.Duration
+	}{
+		{
+			name:     "No delay within free attempts",
+			key:      entity.UsernameThrottleKey(gophtest.Username),
+			failures: 5,
+			expected: 0,
+		},
+		{
+			name:     "Base delay after free attempts",
+			key:      entity.UsernameThrottleKey(gophtest.Username),
+			failures: 6,
+			expected: entity.ThrottleBaseDelay,
+		},
+		{
+			name:     "Delay grows exponentially",
+			key:      entity.UsernameThrottleKey(gophtest.Username),
+			failures: 9,
+			expected: 8 * entity.ThrottleBaseDelay,
+		},
+		{
+			name:     "Delay is capped",
+			key:      entity.UsernameThrottleKey(gophtest.Username),
+			failures: 100,
+			expected: entity.ThrottleMaxDelay,
+		},
+		{
+			name:     "Peer has more free attempts",
+			key:      entity.PeerThrottleKey("127.0.0.1"),
+			failures: 20,
+			expected: 0,
+		},
+		{
+			name:     "Registration has own free attempts",
+			key:      entity.RegistrationThrottleKey("127.0.0.1"),
+			failures: 11,
+			expected: entity.ThrottleBaseDelay,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.key.Delay(tc.failures))
+		})
+	}
+}
+
+func TestThrottledErrorIsTooManyAttempts(t *testing.T) {
+	var err error = &entity.ThrottledError{RetryAfter: time.Second}
+
+	require.ErrorIs(t, err, entity.ErrTooManyAttempts)
+
+	var throttled *entity.ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	require.Equal(t, time.Second, throttled.RetryAfter)
+}
+
+func TestPeerIPFromContext(t *testing.T) {
+	require.Empty(t, entity.PeerIPFromContext(context.Background()))
+
+	ctx := entity.WithPeerIP(context.Background(), "127.0.0.1")
+	require.Equal(t, "127.0.0.1", entity.PeerIPFromContext(ctx))
+}
@@ -1,31 +1,75 @@
 package entity
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-
-	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
 )
 
 const TokenLifeTime = 15 * time.Minute
 
-var SigningMethod = jwt.SigningMethodHS256
+// PartialTokenLifeTime is time given to client to pass second factor.
+const PartialTokenLifeTime = 5 * time.Minute
+
+type tokenKey string
+
+const tokenKeyName tokenKey = "token"
 
 // AccessToken is JWT token used for authentication.
 type AccessToken string
 
 // Claims contain token's payload with various info about token itself and authenticated user.
+// Partial token only authorizes verification of second factor.
+// Tokens issued on login carry ID of the session, see Session.
 type Claims struct {
 	jwt.RegisteredClaims
-	Username string
+	Username  string
+	Partial   bool
+	SessionID string `json:"sid,omitempty"`
 }
 
 // NewAccessToken issues new access token valid for limited period of time.
-func NewAccessToken(user User, secret creds.Password) (AccessToken, error) {
+func NewAccessToken(user User, keys *Keyring) (AccessToken, error) {
+	token, err := newToken(user, keys, TokenLifeTime, false, uuid.Nil)
+	if err != nil {
+		return "", fmt.Errorf("AccessToken - NewAccessToken - newToken: %w", err)
+	}
+
+	return token, nil
+}
+
+// NewSessionAccessToken issues new access token bound to the login session,
+// so the token is rejected as soon as the session is revoked.
+func NewSessionAccessToken(user User, session uuid.UUID, keys *Keyring) (AccessToken, error) {
+	token, err := newToken(user, keys, TokenLifeTime, false, session)
+	if err != nil {
+		return "", fmt.Errorf("AccessToken - NewSessionAccessToken - newToken: %w", err)
+	}
+
+	return token, nil
+}
+
+// NewPartialToken issues new token for a user who has passed the first factor only.
+func NewPartialToken(user User, keys *Keyring) (AccessToken, error) {
+	token, err := newToken(user, keys, PartialTokenLifeTime, true, uuid.Nil)
+	if err != nil {
+		return "", fmt.Errorf("AccessToken - NewPartialToken - newToken: %w", err)
+	}
+
+	return token, nil
+}
+
+func newToken(
+	user User,
+	keys *Keyring,
+	lifeTime time.Duration,
+	partial bool,
+	session uuid.UUID,
+) (AccessToken, error) {
 	now := time.Now()
 
 	claims := jwt.MapClaims{}
@@ -34,17 +78,23 @@ func NewAccessToken(user User, secret creds.Password) (AccessToken, error) {
 	claims["jti"] = uuid.New()
 	claims["iat"] = now.Unix()
 	claims["nbf"] = now.Unix()
-	claims["exp"] = now.Add(TokenLifeTime).Unix()
+	claims["exp"] = now.Add(lifeTime).Unix()
 
 	// User info
 	claims["sub"] = user.ID
 	claims["username"] = user.Username
 
-	rawToken := jwt.NewWithClaims(SigningMethod, claims)
+	if partial {
+		claims["partial"] = true
+	}
+
+	if session != uuid.Nil {
+		claims["sid"] = session
+	}
 
-	signedToken, err := rawToken.SignedString([]byte(secret))
+	signedToken, err := keys.Sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("AccessToken - NewAccessToken - rawToken.SignedString: %w", err)
+		return "", fmt.Errorf("keys.Sign: %w", err)
 	}
 
 	return AccessToken(signedToken), nil
@@ -63,19 +113,65 @@ func (t AccessToken) String() string {
 	return string(t)
 }
 
-// Decode decodes token, verifies its signature and return claims if the token is valid.
-func (t AccessToken) Decode(secret creds.Password) (*Claims, error) {
+// Decode decodes token, verifies its signature with one of the active keys
+// and return claims if the token is valid.
+func (t AccessToken) Decode(keys *Keyring) (*Claims, error) {
 	claims := new(Claims)
 
-	if _, err := jwt.ParseWithClaims(
-		t.String(),
-		claims,
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		},
-	); err != nil {
+	if _, err := jwt.ParseWithClaims(t.String(), claims, keys.Keyfunc); err != nil {
 		return nil, err
 	}
 
 	return claims, nil
 }
+
+// TokenInfo identifies an access token, so it could be revoked before expiration.
+// SessionID is uuid.Nil if the token isn't bound to a session.
+type TokenInfo struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+// Info returns identity of the token.
+func (c *Claims) Info() (TokenInfo, error) {
+	id, err := uuid.Parse(c.ID)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("Claims - Info - uuid.Parse: %w", err)
+	}
+
+	if c.ExpiresAt == nil {
+		return TokenInfo{}, fmt.Errorf("Claims - Info: %w", jwt.ErrTokenRequiredClaimMissing)
+	}
+
+	user, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("Claims - Info - uuid.Parse(sub): %w", err)
+	}
+
+	info := TokenInfo{ID: id, UserID: user, ExpiresAt: c.ExpiresAt.Time}
+
+	if c.SessionID != "" {
+		if info.SessionID, err = uuid.Parse(c.SessionID); err != nil {
+			return TokenInfo{}, fmt.Errorf("Claims - Info - uuid.Parse(sid): %w", err)
+		}
+	}
+
+	return info, nil
+}
+
+// WithContext injects token info into context.
+func (t TokenInfo) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tokenKeyName, &t)
+}
+
+// TokenInfoFromContext extracts TokenInfo object from context.
+// Returns nil, if there is no info regarding access token in the context.
+func TokenInfoFromContext(ctx context.Context) *TokenInfo {
+	if val := ctx.Value(tokenKeyName); val != nil {
+		return val.(*TokenInfo)
+	}
+
+	return nil
+}
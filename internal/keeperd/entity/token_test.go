@@ -1,8 +1,11 @@
 package entity_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
@@ -16,14 +19,31 @@ func TestAccessTokenEncodeDecode(t *testing.T) {
 		Username: gophtest.Username,
 	}
 
-	token, err := entity.NewAccessToken(user, gophtest.Secret)
+	token, err := entity.NewAccessToken(user, entity.NewSecretKeyring(gophtest.Secret))
 	require.NoError(t, err)
 
-	claims, err := token.Decode(gophtest.Secret)
+	claims, err := token.Decode(entity.NewSecretKeyring(gophtest.Secret))
 	require.NoError(t, err)
 
 	require.Equal(t, user.ID.String(), claims.Subject)
 	require.Equal(t, user.Username, claims.Username)
+	require.False(t, claims.Partial)
+}
+
+func TestPartialTokenEncodeDecode(t *testing.T) {
+	user := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+
+	token, err := entity.NewPartialToken(user, entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	claims, err := token.Decode(entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	require.Equal(t, user.ID.String(), claims.Subject)
+	require.True(t, claims.Partial)
 }
 
 func TestAccessTokenDecodeWithWrongSecret(t *testing.T) {
@@ -32,10 +52,10 @@ func TestAccessTokenDecodeWithWrongSecret(t *testing.T) {
 		Username: gophtest.Username,
 	}
 
-	token, err := entity.NewAccessToken(user, gophtest.Secret)
+	token, err := entity.NewAccessToken(user, entity.NewSecretKeyring(gophtest.Secret))
 	require.NoError(t, err)
 
-	_, err = token.Decode("yyy")
+	_, err = token.Decode(entity.NewSecretKeyring("yyy"))
 	require.Error(t, err)
 }
 
@@ -75,3 +95,86 @@ func TestAccesTokenFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessTokenInfo(t *testing.T) {
+	user := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+
+	token, err := entity.NewAccessToken(user, entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	claims, err := token.Decode(entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	info, err := claims.Info()
+	require.NoError(t, err)
+
+	require.Equal(t, claims.ID, info.ID.String())
+	require.Equal(t, user.ID, info.UserID)
+	require.Equal(t, claims.ExpiresAt.Time, info.ExpiresAt)
+	require.Equal(t, uuid.Nil, info.SessionID)
+}
+
+func TestSessionAccessTokenInfo(t *testing.T) {
+	user := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+	session := uuid.New()
+
+	token, err := entity.NewSessionAccessToken(user, session, entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	claims, err := token.Decode(entity.NewSecretKeyring(gophtest.Secret))
+	require.NoError(t, err)
+
+	info, err := claims.Info()
+	require.NoError(t, err)
+
+	require.Equal(t, session, info.SessionID)
+}
+
+func TestAccessTokenInfoWithInvalidSessionID(t *testing.T) {
+	claims := entity.Claims{SessionID: "xxx"}
+	claims.ID = uuid.New().String()
+	claims.Subject = uuid.New().String()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(entity.TokenLifeTime))
+
+	_, err := claims.Info()
+	require.Error(t, err)
+}
+
+func TestAccessTokenInfoWithInvalidSubject(t *testing.T) {
+	claims := entity.Claims{}
+	claims.ID = uuid.New().String()
+	claims.Subject = "xxx"
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(entity.TokenLifeTime))
+
+	_, err := claims.Info()
+	require.Error(t, err)
+}
+
+func TestAccessTokenInfoWithInvalidID(t *testing.T) {
+	claims := entity.Claims{}
+	claims.ID = "xxx"
+
+	_, err := claims.Info()
+	require.Error(t, err)
+}
+
+func TestTokenInfoWithFromContext(t *testing.T) {
+	expected := entity.TokenInfo{
+		ID:        uuid.New(),
+		ExpiresAt: time.Now().Add(entity.TokenLifeTime),
+	}
+
+	ctx := expected.WithContext(context.Background())
+
+	require.Equal(t, expected, *entity.TokenInfoFromContext(ctx))
+}
+
+func TestTokenInfoFromCleanContext(t *testing.T) {
+	require.Nil(t, entity.TokenInfoFromContext(context.Background()))
+}
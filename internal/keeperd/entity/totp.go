@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+)
+
+// TOTPIssuer is name of the service shown by authenticator apps.
+const TOTPIssuer = "GophKeeper"
+
+const (
+	// BackupCodesCount is the number of backup codes issued when two-factor authentication is enabled.
+	BackupCodesCount = 10
+
+	backupCodeLength = 5
+	totpKeyInfo      = "gophkeeper totp secret"
+)
+
+var (
+	ErrInvalidSecondFactor = errors.New("invalid one-time code")
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+)
+
+// TwoFactor is TOTP secret of a user sealed by the TOTP key.
+// The secret is pending until the user confirms enrollment with the first code.
+type TwoFactor struct {
+	Secret  []byte
+	Enabled bool
+}
+
+// SealTOTPSecret encrypts TOTP secret with the key derived from the dedicated TOTP key,
+// so the secret isn't exposed by a dump of the database or by the key signing tokens.
+func SealTOTPSecret(key creds.Password, secret []byte) ([]byte, error) {
+	aead, err := totpAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("SealTOTPSecret - totpAEAD: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("SealTOTPSecret - io.ReadFull: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, secret, nil), nil
+}
+
+// OpenTOTPSecret decrypts TOTP secret sealed by SealTOTPSecret.
+func OpenTOTPSecret(key creds.Password, sealed []byte) ([]byte, error) {
+	aead, err := totpAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("OpenTOTPSecret - totpAEAD: %w", err)
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("OpenTOTPSecret: sealed secret is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("OpenTOTPSecret - aead.Open: %w", err)
+	}
+
+	return secret, nil
+}
+
+func totpAEAD(key creds.Password) (cipher.AEAD, error) {
+	derived := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(key), nil, []byte(totpKeyInfo)), derived); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// BackupCode is one-time code replacing TOTP code if authenticator is lost.
+type BackupCode string
+
+// NewBackupCodes generates new set of random backup codes.
+func NewBackupCodes() ([]BackupCode, error) {
+	codes := make([]BackupCode, BackupCodesCount)
+	buf := make([]byte, backupCodeLength)
+
+	for i := range codes {
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return nil, fmt.Errorf("NewBackupCodes - io.ReadFull: %w", err)
+		}
+
+		code := base32.StdEncoding.EncodeToString(buf)
+		codes[i] = BackupCode(code[:4] + "-" + code[4:])
+	}
+
+	return codes, nil
+}
+
+// String converts BackupCode to string.
+func (c BackupCode) String() string {
+	return string(c)
+}
+
+// Hash returns hash of the code, only the hash is stored.
+// Case and separators are ignored, so the code could be typed in any form.
+func (c BackupCode) Hash() []byte {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(string(c)))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return sum[:]
+}
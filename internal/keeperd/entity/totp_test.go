@@ -0,0 +1,73 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestSealOpenTOTPSecret(t *testing.T) {
+	sealed, err := entity.SealTOTPSecret(gophtest.Secret, []byte(gophtest.TOTPSecret))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), gophtest.TOTPSecret)
+
+	secret, err := entity.OpenTOTPSecret(gophtest.Secret, sealed)
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.TOTPSecret), secret)
+}
+
+func TestOpenTOTPSecretFailure(t *testing.T) {
+	sealed, err := entity.SealTOTPSecret(gophtest.Secret, []byte(gophtest.TOTPSecret))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		key    string
+		sealed []byte
+	}{
+		{
+			name:   "Open fails with wrong key",
+			key:    "yyy",
+			sealed: sealed,
+		},
+		{
+			name:   "Open fails if secret is too short",
+			key:    string(gophtest.Secret),
+			sealed: sealed[:4],
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := entity.OpenTOTPSecret(creds.Password(tc.key), tc.sealed)
+
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewBackupCodes(t *testing.T) {
+	codes, err := entity.NewBackupCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, entity.BackupCodesCount)
+
+	seen := make(map[entity.BackupCode]bool)
+	for _, code := range codes {
+		require.Regexp(t, `^[A-Z2-7]{4}-[A-Z2-7]{4}$`, code.String())
+		require.False(t, seen[code])
+
+		seen[code] = true
+	}
+}
+
+func TestBackupCodeHashIgnoresFormat(t *testing.T) {
+	expected := entity.BackupCode(gophtest.BackupCode).Hash()
+
+	require.Equal(t, expected, entity.BackupCode("abcdefgh").Hash())
+	require.Equal(t, expected, entity.BackupCode(" abcd efgh ").Hash())
+	require.NotEqual(t, expected, entity.BackupCode("ABCD-EFGI").Hash())
+}
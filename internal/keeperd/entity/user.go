@@ -16,6 +16,7 @@ const (
 var (
 	ErrInvalidCredentials = errors.New("invalid username or security key")
 	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
 )
 
 // User represents basic user of the system.
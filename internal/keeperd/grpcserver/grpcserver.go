@@ -3,13 +3,19 @@
 package grpcserver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 )
 
+var ErrAppendClientCA = errors.New("failed to append client certificate authority")
+
 // Server wraps gRPC server entity and handy means to simplify work with it.
 type Server struct {
 	address string
@@ -18,14 +24,36 @@ type Server struct {
 }
 
 // New creates new instance of gRPC server operating over SSL.
-func New(address, crtPath, keyPath string, opts ...grpc.ServerOption) (*Server, error) {
-	creds, err := credentials.NewServerTLSFromFile(crtPath, keyPath)
+// If the client CA is set, client certificates are verified with it, if presented,
+// clients without certificates authenticate with access tokens.
+func New(address, crtPath, keyPath, clientCAPath string, opts ...grpc.ServerOption) (*Server, error) {
+	crt, err := tls.LoadX509KeyPair(crtPath, keyPath)
 	if err != nil {
-		return nil, fmt.Errorf("grpcserver - New - credentials.NewServerTLSFromFile: %w", err)
+		return nil, fmt.Errorf("grpcserver - New - tls.LoadX509KeyPair: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{crt},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAPath != "" {
+		clientCA, err := os.ReadFile(clientCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("grpcserver - New - os.ReadFile: %w", err)
+		}
+
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(clientCA) {
+			return nil, ErrAppendClientCA
+		}
+
+		config.ClientCAs = cp
+		config.ClientAuth = tls.VerifyClientCertIfGiven
 	}
 
 	srvOpts := []grpc.ServerOption{
-		grpc.Creds(creds),
+		grpc.Creds(credentials.NewTLS(config)),
 	}
 	srvOpts = append(srvOpts, opts...)
 
@@ -0,0 +1,84 @@
+// Package janitor implements background removal of expired data.
+package janitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/derpartizanen/gophkeeper/internal/logger"
+)
+
+// Purger removes expired data and reports number of removed items.
+type Purger interface {
+	PurgeTrash(ctx context.Context) (int64, error)
+}
+
+// Janitor periodically purges secrets kept in trash longer than the retention period.
+// Failures are logged and retried on the next tick, as nothing is lost meanwhile.
+type Janitor struct {
+	purger   Purger
+	interval time.Duration
+	log      *logger.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates new janitor running every interval.
+func New(purger Purger, interval time.Duration, log *logger.Logger) *Janitor {
+	return &Janitor{
+		purger:   purger,
+		interval: interval,
+		log:      log,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start launches the janitor, the first purge is done right away.
+func (j *Janitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.purge(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops the janitor and waits for the running purge to finish.
+func (j *Janitor) Shutdown() {
+	if j.cancel == nil {
+		return
+	}
+
+	j.cancel()
+	<-j.done
+}
+
+func (j *Janitor) purge(ctx context.Context) {
+	purged, err := j.purger.PurgeTrash(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			j.log.Error().Err(err).Msg("janitor - purge - j.purger.PurgeTrash")
+		}
+
+		return
+	}
+
+	if purged > 0 {
+		j.log.Info().Msgf("Purged %d expired secrets from trash", purged)
+	}
+}
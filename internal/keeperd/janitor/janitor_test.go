@@ -0,0 +1,97 @@
+package janitor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/janitor"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/logger"
+)
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+
+	log, err := logger.New("error")
+	require.NoError(t, err)
+
+	return log
+}
+
+func TestJanitorPurgesPeriodically(t *testing.T) {
+	purged := make(chan struct{}, 3)
+
+	m := &service.SecretsServiceMock{}
+	m.On("PurgeTrash", mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case purged <- struct{}{}:
+			default:
+			}
+		}).
+		Return(int64(1), nil)
+
+	sat := janitor.New(m, time.Millisecond, newTestLogger(t))
+	sat.Start()
+
+	for i := 0; i < cap(purged); i++ {
+		select {
+		case <-purged:
+		case <-time.After(time.Second):
+			require.FailNow(t, "trash was not purged in time")
+		}
+	}
+
+	sat.Shutdown()
+	m.AssertExpectations(t)
+}
+
+func TestJanitorSurvivesPurgeFailure(t *testing.T) {
+	purged := make(chan struct{}, 2)
+
+	m := &service.SecretsServiceMock{}
+	m.On("PurgeTrash", mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case purged <- struct{}{}:
+			default:
+			}
+		}).
+		Return(int64(0), gophtest.ErrUnexpected)
+
+	sat := janitor.New(m, time.Millisecond, newTestLogger(t))
+	sat.Start()
+
+	for i := 0; i < cap(purged); i++ {
+		select {
+		case <-purged:
+		case <-time.After(time.Second):
+			require.FailNow(t, "trash was not purged in time")
+		}
+	}
+
+	sat.Shutdown()
+	m.AssertExpectations(t)
+}
+
+func TestJanitorShutdownBeforeStart(t *testing.T) {
+	sat := janitor.New(&service.SecretsServiceMock{}, time.Hour, newTestLogger(t))
+
+	sat.Shutdown()
+}
+
+func TestJanitorStopsOnShutdown(t *testing.T) {
+	m := &service.SecretsServiceMock{}
+	m.On("PurgeTrash", mock.Anything).
+		Return(int64(0), nil)
+
+	sat := janitor.New(m, time.Hour, newTestLogger(t))
+	sat.Start()
+	sat.Shutdown()
+
+	m.AssertNumberOfCalls(t, "PurgeTrash", 1)
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+var _ APITokens = (*APITokensRepoMock)(nil)
+
+type APITokensRepoMock struct {
+	mock.Mock
+}
+
+func (m *APITokensRepoMock) Create(ctx context.Context, token entity.APITokenRecord) error {
+	args := m.Called(ctx, token)
+
+	return args.Error(0)
+}
+
+func (m *APITokensRepoMock) List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error) {
+	args := m.Called(ctx, user)
+
+	return args.Get(0).([]entity.APITokenRecord), args.Error(1)
+}
+
+func (m *APITokensRepoMock) GetByHash(ctx context.Context, hash []byte) (entity.APITokenRecord, error) {
+	args := m.Called(ctx, hash)
+
+	return args.Get(0).(entity.APITokenRecord), args.Error(1)
+}
+
+func (m *APITokensRepoMock) Revoke(ctx context.Context, user, id uuid.UUID) error {
+	args := m.Called(ctx, user, id)
+
+	return args.Error(0)
+}
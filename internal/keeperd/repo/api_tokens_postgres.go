@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+)
+
+var _ APITokens = (*APITokensRepo)(nil)
+
+// APITokensRepo is facade to API tokens stored in Postgres.
+type APITokensRepo struct {
+	pg *postgres.Postgres
+}
+
+// NewAPITokensRepo creates and initializes APITokensRepo object.
+func NewAPITokensRepo(
+	pg *postgres.Postgres,
+) *APITokensRepo {
+	return &APITokensRepo{pg}
+}
+
+// Create saves new API token of the user.
+// Expired tokens are removed on the way, so their names could be reused.
+func (r *APITokensRepo) Create(ctx context.Context, token entity.APITokenRecord) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           api_tokens
+       WHERE expires_at < now()`,
+		)
+		if err != nil {
+			return fmt.Errorf("APITokensRepo - Create - tx.Exec(delete): %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO
+           api_tokens (token_id, user_id, name, token_hash, read_only, secret_ids, created_at, expires_at)
+       VALUES
+           ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			token.ID,
+			token.User.ID,
+			token.Name,
+			token.Hash,
+			token.ReadOnly,
+			secretIDsArg(token.SecretIDs),
+			token.CreatedAt,
+			expiresAtArg(token.ExpiresAt),
+		)
+		if err != nil {
+			if postgres.IsEntityExists(err) {
+				return entity.ErrAPITokenExists
+			}
+
+			return fmt.Errorf("APITokensRepo - Create - tx.Exec(insert): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("APITokensRepo - Create - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// List returns active API tokens of the user, hashes are not filled.
+func (r *APITokensRepo) List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error) {
+	rows, err := r.pg.Pool.Query(
+		ctx,
+		`SELECT
+         token_id, name, read_only, secret_ids, created_at, expires_at
+     FROM
+         api_tokens
+     WHERE user_id = $1 AND (expires_at IS NULL OR expires_at >= now())
+     ORDER BY created_at`,
+		user,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("APITokensRepo - List - r.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	rv := make([]entity.APITokenRecord, 0)
+
+	for rows.Next() {
+		var (
+			token     entity.APITokenRecord
+			expiresAt *time.Time
+		)
+
+		if err := rows.Scan(
+			&token.ID,
+			&token.Name,
+			&token.ReadOnly,
+			&token.SecretIDs,
+			&token.CreatedAt,
+			&expiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("APITokensRepo - List - rows.Scan: %w", err)
+		}
+
+		token.User.ID = user
+		if expiresAt != nil {
+			token.ExpiresAt = *expiresAt
+		}
+
+		rv = append(rv, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("APITokensRepo - List - rows.Err: %w", err)
+	}
+
+	return rv, nil
+}
+
+// GetByHash returns API token with its owner.
+func (r *APITokensRepo) GetByHash(ctx context.Context, hash []byte) (entity.APITokenRecord, error) {
+	var expiresAt *time.Time
+
+	token := entity.APITokenRecord{Hash: hash}
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           t.token_id, t.name, t.read_only, t.secret_ids, t.created_at, t.expires_at, u.user_id, u.username
+       FROM
+           api_tokens t
+       JOIN users u ON u.user_id = t.user_id
+       WHERE t.token_hash=$1`,
+			hash,
+		).
+		Scan(
+			&token.ID,
+			&token.Name,
+			&token.ReadOnly,
+			&token.SecretIDs,
+			&token.CreatedAt,
+			&expiresAt,
+			&token.User.ID,
+			&token.User.Username,
+		)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return token, entity.ErrAPITokenNotFound
+		}
+
+		return token, fmt.Errorf("APITokensRepo - GetByHash - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	if expiresAt != nil {
+		token.ExpiresAt = *expiresAt
+	}
+
+	return token, nil
+}
+
+// Revoke removes API token of the user.
+func (r *APITokensRepo) Revoke(ctx context.Context, user, id uuid.UUID) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           api_tokens
+       WHERE token_id = $1 AND user_id = $2`,
+			id,
+			user,
+		)
+		if err != nil {
+			return fmt.Errorf("APITokensRepo - Revoke - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrAPITokenNotFound
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("APITokensRepo - Revoke - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// secretIDsArg converts list of secret IDs to query argument, the column doesn't accept NULL.
+func secretIDsArg(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+
+	return ids
+}
+
+// expiresAtArg converts expiration time to query argument, NULL if the token never expires.
+func expiresAtArg(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
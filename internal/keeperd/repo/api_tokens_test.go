@@ -0,0 +1,329 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestAPITokenRecord() entity.APITokenRecord {
+	return entity.APITokenRecord{
+		ID:   uuid.New(),
+		Name: gophtest.APITokenName,
+		Hash: entity.APIToken(gophtest.APIToken).Hash(),
+		User: entity.User{
+			ID:       uuid.New(),
+			Username: gophtest.Username,
+		},
+		APITokenScope: entity.APITokenScope{
+			ReadOnly:  true,
+			SecretIDs: []uuid.UUID{uuid.New()},
+		},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func apiTokenArgs(token entity.APITokenRecord) []any {
+	return []any{
+		token.ID,
+		token.User.ID,
+		token.Name,
+		token.Hash,
+		token.ReadOnly,
+		token.SecretIDs,
+		token.CreatedAt,
+		&token.ExpiresAt,
+	}
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	token := newTestAPITokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM api_tokens WHERE expires_at < now()").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	m.ExpectExec("INSERT INTO api_tokens").
+		WithArgs(apiTokenArgs(token)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).APITokens
+	err := sat.Create(context.Background(), token)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCreateAPITokenWithoutExpiration(t *testing.T) {
+	token := newTestAPITokenRecord()
+	token.ExpiresAt = time.Time{}
+	token.SecretIDs = nil
+
+	args := apiTokenArgs(token)
+	args[5] = []uuid.UUID{}
+	args[7] = (*time.Time)(nil)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	m.ExpectExec("INSERT INTO api_tokens").
+		WithArgs(args...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).APITokens
+	err := sat.Create(context.Background(), token)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCreateAPITokenFailure(t *testing.T) {
+	token := newTestAPITokenRecord()
+
+	tt := []struct {
+		name     string
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name: "Create API token fails if expired tokens are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Create API token fails if name is taken",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT").
+					WithArgs(apiTokenArgs(token)...).
+					WillReturnError(errUniqueViolation)
+			},
+			expected: entity.ErrAPITokenExists,
+		},
+		{
+			name: "Create API token fails on unexpected error",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT").
+					WithArgs(apiTokenArgs(token)...).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).APITokens
+			err := sat.Create(context.Background(), token)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListAPITokens(t *testing.T) {
+	expected := newTestAPITokenRecord()
+	expected.Hash = nil
+	expected.User.Username = ""
+
+	unlimited := newTestAPITokenRecord()
+	unlimited.Hash = nil
+	unlimited.User = expected.User
+	unlimited.ExpiresAt = time.Time{}
+
+	rows := pgxmock.NewRows([]string{"token_id", "name", "read_only", "secret_ids", "created_at", "expires_at"}).
+		AddRow(
+			expected.ID,
+			expected.Name,
+			expected.ReadOnly,
+			expected.SecretIDs,
+			expected.CreatedAt,
+			&expected.ExpiresAt,
+		).
+		AddRow(
+			unlimited.ID,
+			unlimited.Name,
+			unlimited.ReadOnly,
+			unlimited.SecretIDs,
+			unlimited.CreatedAt,
+			(*time.Time)(nil),
+		)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT token_id, name, read_only, secret_ids, created_at, expires_at FROM api_tokens").
+		WithArgs(expected.User.ID).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).APITokens
+	tokens, err := sat.List(context.Background(), expected.User.ID)
+
+	require.NoError(t, err)
+	require.Equal(t, []entity.APITokenRecord{expected, unlimited}, tokens)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListAPITokensOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(user).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).APITokens
+	_, err := sat.List(context.Background(), user)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetAPITokenByHash(t *testing.T) {
+	expected := newTestAPITokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT t.token_id, (.+) FROM api_tokens t JOIN users u").
+		WithArgs(expected.Hash).
+		WillReturnRows(
+			pgxmock.NewRows([]string{
+				"token_id", "name", "read_only", "secret_ids", "created_at", "expires_at", "user_id", "username",
+			}).
+				AddRow(
+					expected.ID,
+					expected.Name,
+					expected.ReadOnly,
+					expected.SecretIDs,
+					expected.CreatedAt,
+					&expected.ExpiresAt,
+					expected.User.ID,
+					expected.User.Username,
+				),
+		)
+
+	sat := newTestRepos(t, m).APITokens
+	token, err := sat.GetByHash(context.Background(), expected.Hash)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, token)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetAPITokenByHashFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		dbErr    error
+		expected error
+	}{
+		{
+			name:     "Get API token fails if token doesn't exist",
+			dbErr:    pgx.ErrNoRows,
+			expected: entity.ErrAPITokenNotFound,
+		},
+		{
+			name:     "Get API token fails on unexpected error",
+			dbErr:    gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			hash := entity.APIToken(gophtest.APIToken).Hash()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(hash).
+				WillReturnError(tc.dbErr)
+
+			sat := newTestRepos(t, m).APITokens
+			_, err := sat.GetByHash(context.Background(), hash)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	tt := []struct {
+		name     string
+		affected int64
+		expected error
+	}{
+		{
+			name:     "API token is revoked",
+			affected: 1,
+			expected: nil,
+		},
+		{
+			name:     "Unknown API token is not found",
+			affected: 0,
+			expected: entity.ErrAPITokenNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user, id := uuid.New(), uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectExec("DELETE FROM api_tokens WHERE token_id = \\$1 AND user_id = \\$2").
+				WithArgs(id, user).
+				WillReturnResult(pgxmock.NewResult("DELETE", tc.affected))
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).APITokens
+			err := sat.Revoke(context.Background(), user, id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeAPITokenOnDBFailure(t *testing.T) {
+	user, id := uuid.New(), uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs(id, user).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).APITokens
+	err := sat.Revoke(context.Background(), user, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
@@ -56,6 +56,26 @@ func (qb *queryBuilder) Append(name, operator string, value any) *queryBuilder {
 	return qb
 }
 
+// AppendExpr adds new condition or assignment without values,
+// e.g. increment of a column.
+func (qb *queryBuilder) AppendExpr(expr string) *queryBuilder {
+	if qb.lastDef == "SET" && !strings.HasSuffix(qb.query, qb.lastDef) {
+		qb.query += ","
+	}
+
+	qb.query += " " + expr
+
+	return qb
+}
+
+// AppendAny adds new condition matching any of the values.
+func (qb *queryBuilder) AppendAny(name string, values any) *queryBuilder {
+	qb.values = append(qb.values, values)
+	qb.query += fmt.Sprintf(" %s = ANY($%d)", name, len(qb.values))
+
+	return qb
+}
+
 // Query returns full query with values placeholders.
 func (qb *queryBuilder) Query() string {
 	return qb.query
@@ -3,6 +3,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
@@ -11,45 +12,146 @@ import (
 	"github.com/google/uuid"
 )
 
+type APITokens interface {
+	Create(ctx context.Context, token entity.APITokenRecord) error
+	List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error)
+	GetByHash(ctx context.Context, hash []byte) (entity.APITokenRecord, error)
+	Revoke(ctx context.Context, user, id uuid.UUID) error
+}
+
 type Secrets interface {
 	Create(
 		ctx context.Context,
-		owner uuid.UUID,
-		name string,
+		owner, id uuid.UUID,
+		name, nameIndex []byte,
 		kind proto.DataKind,
-		metadata, data []byte,
-	) (uuid.UUID, error)
+		dataKey, metadata, data []byte,
+	) error
+
+	CreateBlob(
+		ctx context.Context,
+		owner, id uuid.UUID,
+		name, nameIndex []byte,
+		dataKey, metadata []byte,
+		chunks entity.ChunkSource,
+	) error
 
-	List(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error)
-	Get(ctx context.Context, owner, id uuid.UUID) (*entity.Secret, error)
+	List(ctx context.Context, owner uuid.UUID, filter entity.SecretsFilter) ([]entity.Secret, int64, error)
+	Get(ctx context.Context, owner, id uuid.UUID, trashed bool) (*entity.Secret, error)
+	ReadBlob(ctx context.Context, owner, id uuid.UUID, sink entity.ChunkSink) error
 
 	Update(
 		ctx context.Context,
 		owner, id uuid.UUID,
+		keep int,
+		version int32,
 		changed []string,
-		name string,
-		metadata []byte,
-		data []byte,
+		name, nameIndex []byte,
+		dataKey, metadata, data []byte,
 	) error
 
+	ListVersions(ctx context.Context, owner, id uuid.UUID, trashed bool) ([]entity.Secret, error)
+	GetVersion(ctx context.Context, owner, id uuid.UUID, version int32, trashed bool) (*entity.Secret, error)
+	Restore(ctx context.Context, owner, id uuid.UUID, keep int, version int32) (int32, error)
 	Delete(ctx context.Context, owner, id uuid.UUID) error
+	ListTrash(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error)
+	RestoreFromTrash(ctx context.Context, owner, id uuid.UUID) (int32, error)
+	Purge(ctx context.Context, owner, id uuid.UUID) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
 }
 
 type Users interface {
-	Register(ctx context.Context, username, securityKey string) (uuid.UUID, error)
+	Register(
+		ctx context.Context,
+		username string,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		recovery entity.RecoveryKit,
+	) (uuid.UUID, error)
+
+	GetKDFParams(ctx context.Context, username string) (entity.KDFParams, bool, error)
 	Verify(ctx context.Context, username, securityKey string) (entity.User, error)
+	Recover(ctx context.Context, username, recoverySecurityKey string) (entity.User, []byte, error)
+	GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error)
+	SetVerifier(ctx context.Context, id uuid.UUID, verifier entity.Verifier) error
+
+	GetUser(ctx context.Context, username string) (entity.User, error)
+	GetVerifier(ctx context.Context, username string) (entity.User, entity.Verifier, error)
+	GetVerifierByID(ctx context.Context, id uuid.UUID) (entity.Verifier, error)
+	CreateHandshake(ctx context.Context, handshake entity.Handshake) error
+	FinishHandshake(ctx context.Context, id uuid.UUID) (entity.Handshake, error)
+
+	ChangePassword(
+		ctx context.Context,
+		id uuid.UUID,
+		recoverySecurityKey string,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		vaultVersion int64,
+		secrets []entity.ReencryptedSecret,
+		recovery entity.RecoveryKit,
+	) error
+
+	Rename(
+		ctx context.Context,
+		id uuid.UUID,
+		username string,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		vaultVersion int64,
+		secrets []entity.ReencryptedSecret,
+		recovery entity.RecoveryKit,
+	) error
+
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type Tokens interface {
+	CreateRefreshToken(ctx context.Context, token entity.RefreshTokenRecord) error
+	RotateRefreshToken(ctx context.Context, hash []byte, next entity.RefreshTokenRecord) (entity.RefreshTokenRecord, error)
+	RevokeRefreshTokenFamily(ctx context.Context, user uuid.UUID, hash []byte) error
+	RevokeAccessToken(ctx context.Context, token entity.TokenInfo) error
+	IsAccessTokenRevoked(ctx context.Context, token entity.TokenInfo) (bool, error)
+
+	ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, user, id uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, user, current uuid.UUID) error
+}
+
+type Throttle interface {
+	GetBlockedUntil(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error)
+	ChargeAttempt(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error)
+	RefundAttempt(ctx context.Context, keys []entity.ThrottleKey) error
+	ResetFailures(ctx context.Context, key entity.ThrottleKey) error
+}
+
+type TwoFactor interface {
+	SetupTOTP(ctx context.Context, user uuid.UUID, secret []byte) error
+	GetTOTP(ctx context.Context, user uuid.UUID) (entity.TwoFactor, error)
+	EnableTOTP(ctx context.Context, user uuid.UUID, step int64, backupCodes [][]byte) error
+	DisableTOTP(ctx context.Context, user uuid.UUID) error
+	UseTOTPStep(ctx context.Context, user uuid.UUID, step int64) error
+	UseBackupCode(ctx context.Context, user uuid.UUID, hash []byte) error
 }
 
 // Repositories is a collection of data repositories.
 type Repositories struct {
-	Secrets Secrets
-	Users   Users
+	APITokens APITokens
+	Secrets   Secrets
+	Throttle  Throttle
+	Tokens    Tokens
+	TwoFactor TwoFactor
+	Users     Users
 }
 
 // New creates and initializes collection of data repositories.
 func New(pg *postgres.Postgres) *Repositories {
 	return &Repositories{
-		Secrets: NewSecretsRepo(pg),
-		Users:   NewUsersRepo(pg),
+		APITokens: NewAPITokensRepo(pg),
+		Secrets:   NewSecretsRepo(pg),
+		Throttle:  NewThrottleRepo(pg),
+		Tokens:    NewTokensRepo(pg),
+		TwoFactor: NewTwoFactorRepo(pg),
+		Users:     NewUsersRepo(pg),
 	}
 }
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -18,47 +19,105 @@ type SecretsRepoMock struct {
 
 func (m *SecretsRepoMock) Create(
 	ctx context.Context,
-	owner uuid.UUID,
-	name string,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	metadata, data []byte,
-) (uuid.UUID, error) {
-	args := m.Called(ctx, owner, name, kind, metadata, data)
+	dataKey, metadata, data []byte,
+) error {
+	args := m.Called(ctx, owner, id, name, nameIndex, kind, dataKey, metadata, data)
 
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	return args.Error(0)
+}
+
+func (m *SecretsRepoMock) CreateBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
+	chunks entity.ChunkSource,
+) error {
+	args := m.Called(ctx, owner, id, name, nameIndex, dataKey, metadata, chunks)
+
+	return args.Error(0)
 }
 
 func (m *SecretsRepoMock) List(
 	ctx context.Context,
 	owner uuid.UUID,
-) ([]entity.Secret, error) {
-	args := m.Called(ctx, owner)
+	filter entity.SecretsFilter,
+) ([]entity.Secret, int64, error) {
+	args := m.Called(ctx, owner, filter)
 
-	return args.Get(0).([]entity.Secret), args.Error(1)
+	return args.Get(0).([]entity.Secret), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *SecretsRepoMock) Get(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	trashed bool,
 ) (*entity.Secret, error) {
-	args := m.Called(ctx, owner, id)
+	args := m.Called(ctx, owner, id, trashed)
 
 	return args.Get(0).(*entity.Secret), args.Error(1)
 }
 
+func (m *SecretsRepoMock) ReadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	sink entity.ChunkSink,
+) error {
+	args := m.Called(ctx, owner, id, sink)
+
+	return args.Error(0)
+}
+
 func (m *SecretsRepoMock) Update(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	keep int,
+	version int32,
 	changed []string,
-	name string,
-	metadata []byte,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
 	data []byte,
 ) error {
-	args := m.Called(ctx, owner, id, changed, name, metadata, data)
+	args := m.Called(ctx, owner, id, keep, version, changed, name, nameIndex, dataKey, metadata, data)
 
 	return args.Error(0)
 }
 
+func (m *SecretsRepoMock) ListVersions(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	trashed bool,
+) ([]entity.Secret, error) {
+	args := m.Called(ctx, owner, id, trashed)
+
+	return args.Get(0).([]entity.Secret), args.Error(1)
+}
+
+func (m *SecretsRepoMock) GetVersion(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*entity.Secret, error) {
+	args := m.Called(ctx, owner, id, version, trashed)
+
+	return args.Get(0).(*entity.Secret), args.Error(1)
+}
+
+func (m *SecretsRepoMock) Restore(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	keep int,
+	version int32,
+) (int32, error) {
+	args := m.Called(ctx, owner, id, keep, version)
+
+	return args.Get(0).(int32), args.Error(1)
+}
+
 func (m *SecretsRepoMock) Delete(
 	ctx context.Context,
 	owner, id uuid.UUID,
@@ -67,3 +126,36 @@ func (m *SecretsRepoMock) Delete(
 
 	return args.Error(0)
 }
+
+func (m *SecretsRepoMock) ListTrash(
+	ctx context.Context,
+	owner uuid.UUID,
+) ([]entity.Secret, error) {
+	args := m.Called(ctx, owner)
+
+	return args.Get(0).([]entity.Secret), args.Error(1)
+}
+
+func (m *SecretsRepoMock) RestoreFromTrash(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) (int32, error) {
+	args := m.Called(ctx, owner, id)
+
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *SecretsRepoMock) Purge(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) error {
+	args := m.Called(ctx, owner, id)
+
+	return args.Error(0)
+}
+
+func (m *SecretsRepoMock) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -29,70 +31,209 @@ func NewSecretsRepo(pg *postgres.Postgres) *SecretsRepo {
 // Create stores new secret in database.
 func (r *SecretsRepo) Create(
 	ctx context.Context,
-	owner uuid.UUID,
-	name string,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	metadata, data []byte,
-) (id uuid.UUID, err error) {
+	dataKey, metadata, data []byte,
+) error {
 	fn := func(tx postgres.Transaction) error {
-		err := tx.QueryRow(
+		_, err := tx.Exec(
 			ctx,
 			`INSERT INTO
-           secrets (owner_id, name, kind, metadata, data)
+           secrets (secret_id, owner_id, name, name_index, kind, data_key, metadata, data)
        VALUES
-           ($1, $2, $3, $4, $5)
-       RETURNING secret_id`,
+           ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			id,
 			owner,
 			name,
+			nameIndex,
 			kind,
+			dataKey,
 			metadata,
 			data,
-		).Scan(&id)
+		)
 		if err != nil {
 			if postgres.IsEntityExists(err) {
 				return entity.ErrSecretExists
 			}
 
-			return fmt.Errorf("SecretsRepo - Create - tx.QueryRow.Scan: %w", err)
+			return fmt.Errorf("SecretsRepo - Create - tx.Exec: %w", err)
 		}
 
 		return nil
 	}
 
 	if err := r.pg.RunAtomic(ctx, fn); err != nil {
-		return id, fmt.Errorf("SecretsRepo - Create - r.pg.RunAtomic: %w", err)
+		return fmt.Errorf("SecretsRepo - Create - r.pg.RunAtomic: %w", err)
 	}
 
-	return id, nil
+	return nil
 }
 
-// List returns all secrets of the provided user.
+// CreateBlob stores new binary secret and its chunks in database.
+// Chunks are stored in order they are read from the source,
+// the blob is discarded if the source fails or has no chunks.
+func (r *SecretsRepo) CreateBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
+	chunks entity.ChunkSource,
+) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO
+           secrets (secret_id, owner_id, name, name_index, kind, data_key, metadata, data)
+       VALUES
+           ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			id,
+			owner,
+			name,
+			nameIndex,
+			proto.DataKind_BINARY,
+			dataKey,
+			metadata,
+			[]byte{},
+		)
+		if err != nil {
+			if postgres.IsEntityExists(err) {
+				return entity.ErrSecretExists
+			}
+
+			return fmt.Errorf("SecretsRepo - CreateBlob - tx.Exec(secret): %w", err)
+		}
+
+		var (
+			seq  int
+			size int64
+		)
+
+		for ; ; seq++ {
+			chunk, err := chunks()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return fmt.Errorf("SecretsRepo - CreateBlob - chunks: %w", err)
+			}
+
+			_, err = tx.Exec(
+				ctx,
+				`INSERT INTO
+           secret_chunks (secret_id, seq, data)
+       VALUES
+           ($1, $2, $3)`,
+				id,
+				seq,
+				chunk,
+			)
+			if err != nil {
+				return fmt.Errorf("SecretsRepo - CreateBlob - tx.Exec(chunk): %w", err)
+			}
+
+			size += int64(len(chunk))
+		}
+
+		if seq == 0 {
+			return entity.ErrEmptyBlob
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE secrets SET blob_size = $1 WHERE secret_id = $2`,
+			size,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("SecretsRepo - CreateBlob - tx.Exec(size): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("SecretsRepo - CreateBlob - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// List returns secrets of the provided user matching the filter and current version of the vault.
+// Trashed secrets are not listed. Secrets are ordered by ID, so pages listed with the cursor are stable.
 // Data is not filled in this case to reduce load on service.
+// The version is read first, so any change made after it increments the version.
 func (r *SecretsRepo) List(
 	ctx context.Context,
 	owner uuid.UUID,
-) ([]entity.Secret, error) {
+	filter entity.SecretsFilter,
+) ([]entity.Secret, int64, error) {
+	var version int64
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           vault_version
+       FROM
+           users
+       WHERE user_id = $1`,
+			owner,
+		).
+		Scan(&version)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SecretsRepo - List - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	qb := newQueryBuilder(
+		"SELECT secret_id, name, name_index, kind, data_key, metadata, blob_size, version FROM secrets",
+	).
+		Where().
+		Append("owner_id", "=", owner).
+		And().
+		AppendExpr("deleted_at IS NULL")
+
+	if len(filter.Kinds) != 0 {
+		kinds := make([]int32, 0, len(filter.Kinds))
+		for _, kind := range filter.Kinds {
+			kinds = append(kinds, int32(kind))
+		}
+
+		qb.And().AppendAny("kind", kinds)
+	}
+
+	if len(filter.NameIndex) != 0 {
+		qb.And().Append("name_index", "=", filter.NameIndex)
+	}
+
+	if len(filter.IDs) != 0 {
+		qb.And().AppendAny("secret_id", filter.IDs)
+	}
+
+	if filter.After != uuid.Nil {
+		qb.And().Append("secret_id", ">", filter.After)
+	}
+
+	query := qb.Query() + " ORDER BY secret_id"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
 	rv := make([]entity.Secret, 0)
-	if err := r.pg.Select(
-		ctx,
-		&rv,
-		`SELECT
-         secret_id, name, kind, metadata
-     FROM
-         secrets
-     WHERE owner_id = $1`,
-		owner,
-	); err != nil {
-		return nil, fmt.Errorf("SecretsRepo - List - r.Select: %w", err)
+	if err := r.pg.Select(ctx, &rv, query, qb.Values()...); err != nil {
+		return nil, 0, fmt.Errorf("SecretsRepo - List - r.Select: %w", err)
 	}
 
-	return rv, nil
+	return rv, version, nil
 }
 
 // Get returns full secret info and data.
+// The secret is looked up in the trash if trashed is set, otherwise in the vault.
 func (r *SecretsRepo) Get(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	trashed bool,
 ) (*entity.Secret, error) {
 	var secret entity.Secret
 
@@ -100,14 +241,25 @@ func (r *SecretsRepo) Get(
 		QueryRow(
 			ctx,
 			`SELECT
-           secret_id, name, kind, metadata, data
+           secret_id, name, name_index, kind, data_key, metadata, data, blob_size, version
        FROM
            secrets
-       WHERE secret_id=$1 AND owner_id = $2`,
+       WHERE secret_id=$1 AND owner_id = $2 AND (deleted_at IS NOT NULL) = $3`,
 			id,
 			owner,
+			trashed,
 		).
-		Scan(&secret.ID, &secret.Name, &secret.Kind, &secret.Metadata, &secret.Data)
+		Scan(
+			&secret.ID,
+			&secret.Name,
+			&secret.NameIndex,
+			&secret.Kind,
+			&secret.DataKey,
+			&secret.Metadata,
+			&secret.Data,
+			&secret.BlobSize,
+			&secret.Version,
+		)
 	if err != nil {
 		if postgres.IsEmptyResponse(err) {
 			return nil, entity.ErrSecretNotFound
@@ -119,13 +271,69 @@ func (r *SecretsRepo) Get(
 	return &secret, nil
 }
 
+// ReadBlob passes chunks of the blob to the sink in order they were uploaded.
+// Reading stops on the first error returned by the sink.
+func (r *SecretsRepo) ReadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	sink entity.ChunkSink,
+) error {
+	rows, err := r.pg.Pool.Query(
+		ctx,
+		`SELECT
+           c.data
+       FROM
+           secret_chunks c JOIN secrets s ON s.secret_id = c.secret_id
+       WHERE c.secret_id = $1 AND s.owner_id = $2 AND s.deleted_at IS NULL
+       ORDER BY c.seq`,
+		id,
+		owner,
+	)
+	if err != nil {
+		return fmt.Errorf("SecretsRepo - ReadBlob - r.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var read int
+
+	for rows.Next() {
+		var chunk []byte
+		if err := rows.Scan(&chunk); err != nil {
+			return fmt.Errorf("SecretsRepo - ReadBlob - rows.Scan: %w", err)
+		}
+
+		if err := sink(chunk); err != nil {
+			return fmt.Errorf("SecretsRepo - ReadBlob - sink: %w", err)
+		}
+
+		read++
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("SecretsRepo - ReadBlob - rows.Err: %w", err)
+	}
+
+	if read == 0 {
+		return entity.ErrSecretNotFound
+	}
+
+	return nil
+}
+
 // Update changes secret info and data.
+// Name is always changed together with its blind index.
+// The previous state of the secret is archived as a revision,
+// only keep latest revisions are kept.
+// If version is set, the secret is changed only if it is still current,
+// otherwise entity.ErrSecretModified is returned.
 func (r *SecretsRepo) Update(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	keep int,
+	version int32,
 	changed []string,
-	name string,
-	metadata, data []byte,
+	name, nameIndex []byte,
+	dataKey, metadata, data []byte,
 ) error {
 	fn := func(tx postgres.Transaction) error {
 		qb := newQueryBuilder("UPDATE secrets").Set()
@@ -133,7 +341,11 @@ func (r *SecretsRepo) Update(
 		for _, field := range changed {
 			switch field {
 			case "name":
-				qb.Append("name", "=", name)
+				qb.Append("name", "=", name).
+					Append("name_index", "=", nameIndex)
+
+			case "data_key":
+				qb.Append("data_key", "=", dataKey)
 
 			case "metadata":
 				qb.Append("metadata", "=", metadata)
@@ -147,10 +359,22 @@ func (r *SecretsRepo) Update(
 			return fmt.Errorf("SecretsRepo - Update: %w", ErrNoValuesToUpdate)
 		}
 
-		qb.Where().
+		if err := archiveVersion(ctx, tx, owner, id); err != nil {
+			return err
+		}
+
+		qb.AppendExpr("version = version + 1").
+			AppendExpr("updated_at = now()").
+			Where().
 			Append("secret_id", "=", id).
 			And().
-			Append("owner_id", "=", owner)
+			Append("owner_id", "=", owner).
+			And().
+			AppendExpr("deleted_at IS NULL")
+
+		if version > 0 {
+			qb.And().Append("version", "=", version)
+		}
 
 		tag, err := tx.Exec(ctx, qb.Query(), qb.Values()...)
 		if err != nil {
@@ -161,11 +385,16 @@ func (r *SecretsRepo) Update(
 			return fmt.Errorf("SecretsRepo - Update - tx.Exec: %w", err)
 		}
 
+		// The row is locked by archiveVersion, so only the version could mismatch.
 		if tag.RowsAffected() == 0 {
+			if version > 0 {
+				return entity.ErrSecretModified
+			}
+
 			return entity.ErrSecretNotFound
 		}
 
-		return nil
+		return pruneVersions(ctx, tx, id, keep)
 	}
 
 	if err := r.pg.RunAtomic(ctx, fn); err != nil {
@@ -175,17 +404,228 @@ func (r *SecretsRepo) Update(
 	return nil
 }
 
-// Delete removes secret from database.
+// ListVersions returns the current version of the secret followed by archived revisions,
+// the latest versions go first. Data is not filled in this case.
+// The secret is looked up in the trash if trashed is set, otherwise in the vault.
+func (r *SecretsRepo) ListVersions(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	trashed bool,
+) ([]entity.Secret, error) {
+	rv := make([]entity.Secret, 0)
+
+	err := r.pg.Select(
+		ctx,
+		&rv,
+		`SELECT
+           secret_id, name, name_index, kind, data_key, metadata, blob_size, version, updated_at
+       FROM
+           secrets
+       WHERE secret_id = $1 AND owner_id = $2 AND (deleted_at IS NOT NULL) = $3
+       UNION ALL
+       SELECT
+           v.secret_id, v.name, v.name_index, s.kind, v.data_key, v.metadata, s.blob_size, v.version,
+           v.created_at AS updated_at
+       FROM
+           secret_versions v JOIN secrets s ON s.secret_id = v.secret_id
+       WHERE v.secret_id = $1 AND s.owner_id = $2 AND (s.deleted_at IS NOT NULL) = $3
+       ORDER BY version DESC`,
+		id,
+		owner,
+		trashed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsRepo - ListVersions - r.pg.Select: %w", err)
+	}
+
+	if len(rv) == 0 {
+		return nil, entity.ErrSecretNotFound
+	}
+
+	return rv, nil
+}
+
+// GetVersion returns full secret info and data of the provided version,
+// either the current or an archived one.
+// The secret is looked up in the trash if trashed is set, otherwise in the vault.
+func (r *SecretsRepo) GetVersion(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*entity.Secret, error) {
+	var secret entity.Secret
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           secret_id, name, name_index, kind, data_key, metadata, data, blob_size, version, updated_at
+       FROM
+           secrets
+       WHERE secret_id = $1 AND owner_id = $2 AND version = $3 AND (deleted_at IS NOT NULL) = $4
+       UNION ALL
+       SELECT
+           v.secret_id, v.name, v.name_index, s.kind, v.data_key, v.metadata, v.data, s.blob_size, v.version,
+           v.created_at
+       FROM
+           secret_versions v JOIN secrets s ON s.secret_id = v.secret_id
+       WHERE v.secret_id = $1 AND s.owner_id = $2 AND v.version = $3 AND (s.deleted_at IS NOT NULL) = $4`,
+			id,
+			owner,
+			version,
+			trashed,
+		).
+		Scan(
+			&secret.ID,
+			&secret.Name,
+			&secret.NameIndex,
+			&secret.Kind,
+			&secret.DataKey,
+			&secret.Metadata,
+			&secret.Data,
+			&secret.BlobSize,
+			&secret.Version,
+			&secret.UpdatedAt,
+		)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return nil, entity.ErrVersionNotFound
+		}
+
+		return nil, fmt.Errorf("SecretsRepo - GetVersion - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return &secret, nil
+}
+
+// Restore makes content of the provided version current again.
+// Restore is an update as well, so the current state is archived
+// and the restored content gets new version number, which is returned.
+func (r *SecretsRepo) Restore(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	keep int,
+	version int32,
+) (int32, error) {
+	var restored int32
+
+	fn := func(tx postgres.Transaction) error {
+		if err := archiveVersion(ctx, tx, owner, id); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(
+			ctx,
+			`UPDATE
+           secrets s
+       SET
+           name = v.name,
+           name_index = v.name_index,
+           data_key = v.data_key,
+           metadata = v.metadata,
+           data = v.data,
+           version = s.version + 1,
+           updated_at = now()
+       FROM
+           secret_versions v
+       WHERE s.secret_id = $1 AND s.owner_id = $2 AND s.deleted_at IS NULL
+           AND v.secret_id = s.secret_id AND v.version = $3
+       RETURNING s.version`,
+			id,
+			owner,
+			version,
+		).Scan(&restored)
+		if err != nil {
+			if postgres.IsEmptyResponse(err) {
+				return entity.ErrVersionNotFound
+			}
+
+			if postgres.IsEntityExists(err) {
+				return entity.ErrSecretNameConflict
+			}
+
+			return fmt.Errorf("SecretsRepo - Restore - tx.QueryRow.Scan: %w", err)
+		}
+
+		return pruneVersions(ctx, tx, id, keep)
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return 0, fmt.Errorf("SecretsRepo - Restore - r.pg.RunAtomic: %w", err)
+	}
+
+	return restored, nil
+}
+
+// archiveVersion copies the current state of the secret to its revisions.
+// The secret is locked until the end of the transaction, trashed secrets are not found.
+func archiveVersion(
+	ctx context.Context,
+	tx postgres.Transaction,
+	owner, id uuid.UUID,
+) error {
+	tag, err := tx.Exec(
+		ctx,
+		`INSERT INTO
+           secret_versions (secret_id, version, name, name_index, data_key, metadata, data, created_at)
+       SELECT
+           secret_id, version, name, name_index, data_key, metadata, data, updated_at
+       FROM
+           secrets
+       WHERE secret_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+       FOR UPDATE`,
+		id,
+		owner,
+	)
+	if err != nil {
+		return fmt.Errorf("SecretsRepo - archiveVersion - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrSecretNotFound
+	}
+
+	return nil
+}
+
+// pruneVersions removes archived revisions of the secret except keep latest ones.
+func pruneVersions(
+	ctx context.Context,
+	tx postgres.Transaction,
+	id uuid.UUID,
+	keep int,
+) error {
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM
+           secret_versions v
+       USING
+           secrets s
+       WHERE v.secret_id = s.secret_id AND v.secret_id = $1 AND v.version < s.version - $2`,
+		id,
+		keep,
+	)
+	if err != nil {
+		return fmt.Errorf("SecretsRepo - pruneVersions - tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Delete moves secret to trash, so it is hidden but could be restored until purged.
 func (r *SecretsRepo) Delete(
 	ctx context.Context,
 	owner, id uuid.UUID,
-) (err error) {
+) error {
 	fn := func(tx postgres.Transaction) error {
 		tag, err := tx.Exec(
 			ctx,
-			`DELETE FROM
+			`UPDATE
            secrets
-       WHERE secret_id = $1 AND owner_id = $2`,
+       SET
+           deleted_at = now()
+       WHERE secret_id = $1 AND owner_id = $2 AND deleted_at IS NULL`,
 			id,
 			owner,
 		)
@@ -206,3 +646,133 @@ func (r *SecretsRepo) Delete(
 
 	return nil
 }
+
+// ListTrash returns trashed secrets of the provided user without data,
+// the latest deleted go first.
+func (r *SecretsRepo) ListTrash(
+	ctx context.Context,
+	owner uuid.UUID,
+) ([]entity.Secret, error) {
+	rv := make([]entity.Secret, 0)
+
+	err := r.pg.Select(
+		ctx,
+		&rv,
+		`SELECT
+           secret_id, name, name_index, kind, data_key, metadata, blob_size, version, updated_at, deleted_at
+       FROM
+           secrets
+       WHERE owner_id = $1 AND deleted_at IS NOT NULL
+       ORDER BY deleted_at DESC, secret_id`,
+		owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsRepo - ListTrash - r.pg.Select: %w", err)
+	}
+
+	return rv, nil
+}
+
+// RestoreFromTrash moves trashed secret back to the vault and returns its current version.
+// Fails if another secret with the same name was created in the meantime.
+func (r *SecretsRepo) RestoreFromTrash(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) (int32, error) {
+	var version int32
+
+	fn := func(tx postgres.Transaction) error {
+		err := tx.QueryRow(
+			ctx,
+			`UPDATE
+           secrets
+       SET
+           deleted_at = NULL
+       WHERE secret_id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL
+       RETURNING version`,
+			id,
+			owner,
+		).Scan(&version)
+		if err != nil {
+			if postgres.IsEmptyResponse(err) {
+				return entity.ErrSecretNotFound
+			}
+
+			if postgres.IsEntityExists(err) {
+				return entity.ErrSecretNameConflict
+			}
+
+			return fmt.Errorf("SecretsRepo - RestoreFromTrash - tx.QueryRow.Scan: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return 0, fmt.Errorf("SecretsRepo - RestoreFromTrash - r.pg.RunAtomic: %w", err)
+	}
+
+	return version, nil
+}
+
+// Purge removes secret from database permanently together with its revisions and chunks,
+// either trashed or not.
+func (r *SecretsRepo) Purge(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           secrets
+       WHERE secret_id = $1 AND owner_id = $2`,
+			id,
+			owner,
+		)
+		if err != nil {
+			return fmt.Errorf("SecretsRepo - Purge - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrSecretNotFound
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("SecretsRepo - Purge - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently removes secrets of all users trashed before the provided time.
+// Returns number of removed secrets.
+func (r *SecretsRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           secrets
+       WHERE deleted_at < $1`,
+			before,
+		)
+		if err != nil {
+			return fmt.Errorf("SecretsRepo - PurgeTrash - tx.Exec: %w", err)
+		}
+
+		purged = tag.RowsAffected()
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return 0, fmt.Errorf("SecretsRepo - PurgeTrash - r.pg.RunAtomic: %w", err)
+	}
+
+	return purged, nil
+}
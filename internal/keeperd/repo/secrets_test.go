@@ -2,9 +2,12 @@ package repo_test
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/require"
 
@@ -22,19 +25,35 @@ func doGetSecret(
 	t.Helper()
 
 	sat := newTestRepos(t, m).Secrets
-	secret, err := sat.Get(context.Background(), owner, id)
+	secret, err := sat.Get(context.Background(), owner, id, false)
 
 	require.NoError(t, m.ExpectationsWereMet())
 
 	return secret, err
 }
 
+// testVersionLimit is the number of archived revisions kept per secret in tests.
+const testVersionLimit = 3
+
+// expectArchiveVersion expects the current state of the secret to be archived.
+func expectArchiveVersion(m pgxmock.PgxPoolIface, owner, id uuid.UUID, archived int64) {
+	m.ExpectExec("INSERT INTO secret_versions").
+		WithArgs(id, owner).
+		WillReturnResult(pgxmock.NewResult("INSERT", archived))
+}
+
+// expectPruneVersions expects revisions of the secret beyond the limit to be removed.
+func expectPruneVersions(m pgxmock.PgxPoolIface, id uuid.UUID) *pgxmock.ExpectedExec {
+	return m.ExpectExec("DELETE FROM secret_versions").
+		WithArgs(id, testVersionLimit)
+}
+
 func doUpdateSecret(
 	t *testing.T,
 	owner, id uuid.UUID,
 	changed []string,
-	name string,
-	metadata, data []byte,
+	name, nameIndex []byte,
+	dataKey, metadata, data []byte,
 	m pgxmock.PgxPoolIface,
 ) error {
 	t.Helper()
@@ -44,8 +63,12 @@ func doUpdateSecret(
 		context.Background(),
 		owner,
 		id,
+		testVersionLimit,
+		0,
 		changed,
 		name,
+		nameIndex,
+		dataKey,
 		metadata,
 		data,
 	)
@@ -68,36 +91,38 @@ func doDeleteSecret(t *testing.T, owner, id uuid.UUID, m pgxmock.PgxPoolIface) e
 
 func TestCreateSecret(t *testing.T) {
 	owner := uuid.New()
-	expected := uuid.New()
-
-	rows := pgxmock.NewRows([]string{"id"}).
-		AddRow(expected.String())
+	id := uuid.New()
 
 	m := newPoolMock(t)
 	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectQuery("INSERT INTO secrets").
+	m.ExpectExec("INSERT INTO secrets").
 		WithArgs(
+			id,
 			owner,
-			gophtest.SecretName,
+			[]byte(gophtest.SecretName),
+			[]byte(gophtest.NameIndex),
 			proto.DataKind_TEXT,
+			[]byte(gophtest.DataKey),
 			[]byte(gophtest.Metadata),
 			[]byte(gophtest.TextData),
 		).
-		WillReturnRows(rows)
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	m.ExpectCommit()
 
 	sat := newTestRepos(t, m).Secrets
-	id, err := sat.Create(
+	err := sat.Create(
 		context.Background(),
 		owner,
-		gophtest.SecretName,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
 		proto.DataKind_TEXT,
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	)
 
 	require.NoError(t, err)
-	require.Equal(t, expected, id)
 	require.NoError(t, m.ExpectationsWereMet())
 }
 
@@ -122,14 +147,18 @@ func TestCreateSecretOnDBFailure(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			owner := uuid.New()
+			id := uuid.New()
 
 			m := newPoolMock(t)
 			m.ExpectBeginTx(postgres.DefaultTxOptions)
-			m.ExpectQuery("INSERT").
+			m.ExpectExec("INSERT").
 				WithArgs(
+					id,
 					owner,
-					gophtest.SecretName,
+					[]byte(gophtest.SecretName),
+					[]byte(gophtest.NameIndex),
 					proto.DataKind_TEXT,
+					[]byte(gophtest.DataKey),
 					[]byte(gophtest.Metadata),
 					[]byte(gophtest.TextData),
 				).
@@ -137,11 +166,14 @@ func TestCreateSecretOnDBFailure(t *testing.T) {
 			m.ExpectRollback()
 
 			sat := newTestRepos(t, m).Secrets
-			_, err := sat.Create(
+			err := sat.Create(
 				context.Background(),
 				owner,
-				gophtest.SecretName,
+				id,
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
 				proto.DataKind_TEXT,
+				[]byte(gophtest.DataKey),
 				[]byte(gophtest.Metadata),
 				[]byte(gophtest.TextData),
 			)
@@ -152,6 +184,147 @@ func TestCreateSecretOnDBFailure(t *testing.T) {
 	}
 }
 
+// newChunkSource provides the chunks and then io.EOF.
+func newChunkSource(chunks ...[]byte) entity.ChunkSource {
+	return func() ([]byte, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		return chunk, nil
+	}
+}
+
+func doCreateBlob(
+	t *testing.T,
+	owner, id uuid.UUID,
+	chunks entity.ChunkSource,
+	m pgxmock.PgxPoolIface,
+) error {
+	t.Helper()
+
+	sat := newTestRepos(t, m).Secrets
+	err := sat.CreateBlob(
+		context.Background(),
+		owner,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
+		[]byte(gophtest.Metadata),
+		chunks,
+	)
+
+	require.NoError(t, m.ExpectationsWereMet())
+
+	return err
+}
+
+func expectInsertBlob(m pgxmock.PgxPoolIface, owner, id uuid.UUID) *pgxmock.ExpectedExec {
+	return m.ExpectExec("INSERT INTO secrets").
+		WithArgs(
+			id,
+			owner,
+			[]byte(gophtest.SecretName),
+			[]byte(gophtest.NameIndex),
+			proto.DataKind_BINARY,
+			[]byte(gophtest.DataKey),
+			[]byte(gophtest.Metadata),
+			[]byte{},
+		)
+}
+
+func TestCreateBlob(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	first, second := []byte("first"), []byte("second")
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectInsertBlob(m, owner, id).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectExec("INSERT INTO secret_chunks").
+		WithArgs(id, 0, first).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectExec("INSERT INTO secret_chunks").
+		WithArgs(id, 1, second).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectExec("UPDATE secrets SET blob_size").
+		WithArgs(int64(len(first)+len(second)), id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	err := doCreateBlob(t, owner, id, newChunkSource(first, second), m)
+
+	require.NoError(t, err)
+}
+
+func TestCreateBlobFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	tt := []struct {
+		name     string
+		chunks   entity.ChunkSource
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name:   "Create blob fails if secret exists",
+			chunks: newChunkSource([]byte("chunk")),
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectInsertBlob(m, owner, id).WillReturnError(errUniqueViolation)
+			},
+			expected: entity.ErrSecretExists,
+		},
+		{
+			name:   "Create blob fails if there are no chunks",
+			chunks: newChunkSource(),
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectInsertBlob(m, owner, id).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			expected: entity.ErrEmptyBlob,
+		},
+		{
+			name: "Create blob fails if reading of chunks fails",
+			chunks: func() ([]byte, error) {
+				return nil, gophtest.ErrUnexpected
+			},
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectInsertBlob(m, owner, id).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name:   "Create blob fails if chunk isn't stored",
+			chunks: newChunkSource([]byte("chunk")),
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectInsertBlob(m, owner, id).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO secret_chunks").
+					WithArgs(id, 0, []byte("chunk")).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			err := doCreateBlob(t, owner, id, tc.chunks, m)
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
 func TestListSecrets(t *testing.T) {
 	tt := []struct {
 		name string
@@ -160,8 +333,26 @@ func TestListSecrets(t *testing.T) {
 		{
 			name: "List secrets of a user",
 			rows: [][]any{
-				{uuid.New().String(), gophtest.SecretName, proto.DataKind_TEXT, []byte("xxx")},
-				{uuid.New().String(), gophtest.SecretName + "ex", proto.DataKind_BINARY, []byte{}},
+				{
+					uuid.New().String(),
+					[]byte(gophtest.SecretName),
+					[]byte(gophtest.NameIndex),
+					proto.DataKind_TEXT,
+					[]byte(gophtest.DataKey),
+					[]byte("xxx"),
+					int64(0),
+					int32(3),
+				},
+				{
+					uuid.New().String(),
+					[]byte(gophtest.SecretName + "ex"),
+					nil,
+					proto.DataKind_BINARY,
+					nil,
+					[]byte{},
+					int64(42),
+					int32(1),
+				},
 			},
 		},
 		{
@@ -173,59 +364,135 @@ func TestListSecrets(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			owner := uuid.New()
-			rows := pgxmock.NewRows([]string{"secret_id", "name", "kind", "metadata"})
+			rows := pgxmock.NewRows(
+				[]string{"secret_id", "name", "name_index", "kind", "data_key", "metadata", "blob_size", "version"},
+			)
 
 			for _, row := range tc.rows {
 				rows.AddRow(row...)
 			}
 
 			m := newPoolMock(t)
-			m.ExpectQuery("SELECT secret_id, name, kind, metadata FROM secrets").
+			m.ExpectQuery("SELECT vault_version FROM users").
+				WithArgs(owner).
+				WillReturnRows(pgxmock.NewRows([]string{"vault_version"}).AddRow(gophtest.VaultVersion))
+			m.ExpectQuery(
+				"SELECT secret_id, name, name_index, kind, data_key, metadata, blob_size, version FROM secrets " +
+					"WHERE owner_id = \\$1 AND deleted_at IS NULL ORDER BY secret_id$",
+			).
 				WithArgs(owner).
 				WillReturnRows(rows)
 
 			sat := newTestRepos(t, m).Secrets
-			secrets, err := sat.List(context.Background(), owner)
+			secrets, version, err := sat.List(context.Background(), owner, entity.SecretsFilter{})
 
 			require.NoError(t, err)
 			require.Len(t, secrets, len(tc.rows))
+			require.Equal(t, gophtest.VaultVersion, version)
 			require.NoError(t, m.ExpectationsWereMet())
 		})
 	}
 }
 
+func TestListSecretsWithFilter(t *testing.T) {
+	owner := uuid.New()
+	filter := entity.SecretsFilter{
+		Kinds:     []proto.DataKind{proto.DataKind_TEXT, proto.DataKind_CARD},
+		NameIndex: []byte(gophtest.NameIndex),
+		IDs:       []uuid.UUID{uuid.New()},
+		After:     uuid.New(),
+		Limit:     10,
+	}
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT vault_version FROM users").
+		WithArgs(owner).
+		WillReturnRows(pgxmock.NewRows([]string{"vault_version"}).AddRow(gophtest.VaultVersion))
+	m.ExpectQuery(
+		"WHERE owner_id = \\$1 AND deleted_at IS NULL AND kind = ANY\\(\\$2\\) AND name_index = \\$3 "+
+			"AND secret_id = ANY\\(\\$4\\) AND secret_id > \\$5 ORDER BY secret_id LIMIT 10$",
+	).
+		WithArgs(owner, []int32{1, 3}, filter.NameIndex, filter.IDs, filter.After).
+		WillReturnRows(
+			pgxmock.NewRows(
+				[]string{"secret_id", "name", "name_index", "kind", "data_key", "metadata", "blob_size", "version"},
+			),
+		)
+
+	sat := newTestRepos(t, m).Secrets
+	secrets, version, err := sat.List(context.Background(), owner, filter)
+
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+	require.Equal(t, gophtest.VaultVersion, version)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
 func TestListSecretsOnDBFailure(t *testing.T) {
 	owner := uuid.New()
 
 	m := newPoolMock(t)
+	m.ExpectQuery("SELECT vault_version").
+		WithArgs(owner).
+		WillReturnRows(pgxmock.NewRows([]string{"vault_version"}).AddRow(gophtest.VaultVersion))
 	m.ExpectQuery("SELECT").
 		WithArgs(owner).
 		WillReturnError(gophtest.ErrUnexpected)
 
 	sat := newTestRepos(t, m).Secrets
-	_, err := sat.List(context.Background(), owner)
+	_, _, err := sat.List(context.Background(), owner, entity.SecretsFilter{})
 
 	require.Error(t, err)
 	require.NoError(t, m.ExpectationsWereMet())
 }
 
+func TestListSecretsOnVersionFailure(t *testing.T) {
+	owner := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT vault_version").
+		WithArgs(owner).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Secrets
+	_, _, err := sat.List(context.Background(), owner, entity.SecretsFilter{})
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
 func TestGetSecret(t *testing.T) {
 	owner := uuid.New()
 
 	expected := &entity.Secret{
-		ID:       uuid.New(),
-		Name:     gophtest.SecretName,
-		Kind:     proto.DataKind_TEXT,
-		Metadata: []byte(gophtest.Metadata),
-		Data:     []byte(gophtest.TextData),
+		ID:        uuid.New(),
+		Name:      []byte(gophtest.SecretName),
+		NameIndex: []byte(gophtest.NameIndex),
+		Kind:      proto.DataKind_TEXT,
+		DataKey:   []byte(gophtest.DataKey),
+		Metadata:  []byte(gophtest.Metadata),
+		Data:      []byte(gophtest.TextData),
+		Version:   3,
 	}
 
-	rows := pgxmock.NewRows([]string{"secret_id", "name", "kind", "metadata", "data"}).
-		AddRow(expected.ID.String(), expected.Name, expected.Kind, expected.Metadata, expected.Data)
+	rows := pgxmock.NewRows(
+		[]string{"secret_id", "name", "name_index", "kind", "data_key", "metadata", "data", "blob_size", "version"},
+	).
+		AddRow(
+			expected.ID.String(),
+			expected.Name,
+			expected.NameIndex,
+			expected.Kind,
+			expected.DataKey,
+			expected.Metadata,
+			expected.Data,
+			expected.BlobSize,
+			expected.Version,
+		)
 
 	m := newPoolMock(t)
-	m.ExpectQuery("SELECT secret_id, name, kind, metadata, data FROM secrets").
-		WithArgs(expected.ID, owner).
+	m.ExpectQuery("SELECT secret_id, name, name_index, kind, data_key, metadata, data, blob_size, version FROM secrets").
+		WithArgs(expected.ID, owner, false).
 		WillReturnRows(rows)
 
 	secret, err := doGetSecret(t, owner, expected.ID, m)
@@ -235,14 +502,16 @@ func TestGetSecret(t *testing.T) {
 }
 
 func TestGetUnexistingSecret(t *testing.T) {
-	rows := pgxmock.NewRows([]string{"secret_id", "name", "kind", "metadata", "data"})
+	rows := pgxmock.NewRows(
+		[]string{"secret_id", "name", "name_index", "kind", "data_key", "metadata", "data", "blob_size", "version"},
+	)
 
 	owner := uuid.New()
 	id := uuid.New()
 
 	m := newPoolMock(t)
 	m.ExpectQuery("SELECT").
-		WithArgs(id, owner).
+		WithArgs(id, owner, false).
 		WillReturnRows(rows)
 
 	_, err := doGetSecret(t, owner, id, m)
@@ -256,7 +525,7 @@ func TestGetSecretOnDBFailure(t *testing.T) {
 
 	m := newPoolMock(t)
 	m.ExpectQuery("SELECT").
-		WithArgs(id, owner).
+		WithArgs(id, owner, false).
 		WillReturnError(gophtest.ErrUnexpected)
 
 	_, err := doGetSecret(t, owner, id, m)
@@ -264,6 +533,92 @@ func TestGetSecretOnDBFailure(t *testing.T) {
 	require.ErrorIs(t, err, gophtest.ErrUnexpected)
 }
 
+func TestReadBlob(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	first, second := []byte("first"), []byte("second")
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT c.data FROM secret_chunks").
+		WithArgs(id, owner).
+		WillReturnRows(pgxmock.NewRows([]string{"data"}).AddRow(first).AddRow(second))
+
+	var chunks [][]byte
+
+	sat := newTestRepos(t, m).Secrets
+	err := sat.ReadBlob(context.Background(), owner, id, func(chunk []byte) error {
+		chunks = append(chunks, chunk)
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{first, second}, chunks)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestReadBlobFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		rows     *pgxmock.Rows
+		sinkErr  error
+		expected error
+	}{
+		{
+			name:     "Read blob fails if blob doesn't exist",
+			rows:     pgxmock.NewRows([]string{"data"}),
+			expected: entity.ErrSecretNotFound,
+		},
+		{
+			name:     "Read blob stops if sink fails",
+			rows:     pgxmock.NewRows([]string{"data"}).AddRow([]byte("first")).AddRow([]byte("second")),
+			sinkErr:  gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT c.data FROM secret_chunks").
+				WithArgs(id, owner).
+				WillReturnRows(tc.rows)
+
+			var calls int
+
+			sat := newTestRepos(t, m).Secrets
+			err := sat.ReadBlob(context.Background(), owner, id, func([]byte) error {
+				calls++
+
+				return tc.sinkErr
+			})
+
+			require.ErrorIs(t, err, tc.expected)
+			require.LessOrEqual(t, calls, 1)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReadBlobOnDBFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(id, owner).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Secrets
+	err := sat.ReadBlob(context.Background(), owner, id, func([]byte) error { return nil })
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
 func TestUpdateSecret(t *testing.T) {
 	owner := uuid.New()
 	id := uuid.New()
@@ -275,22 +630,28 @@ func TestUpdateSecret(t *testing.T) {
 
 	tt := []struct {
 		name       string
-		secretName string
+		secretName []byte
+		nameIndex  []byte
 		changed    []string
+		dataKey    []byte
 		metadata   []byte
 		data       []byte
 		expected   expected
 	}{
 		{
 			name:       "Update all fields",
-			changed:    []string{"name", "metadata", "data"},
-			secretName: gophtest.SecretName,
+			changed:    []string{"name", "data_key", "metadata", "data"},
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  []byte(gophtest.NameIndex),
+			dataKey:    []byte(gophtest.DataKey),
 			metadata:   []byte(gophtest.Metadata),
 			data:       []byte(gophtest.TextData),
 			expected: expected{
-				query: "UPDATE secrets SET name = \\$1, metadata = \\$2, data = \\$3",
+				query: "UPDATE secrets SET name = \\$1, name_index = \\$2, data_key = \\$3, metadata = \\$4, data = \\$5",
 				args: []any{
-					gophtest.SecretName,
+					[]byte(gophtest.SecretName),
+					[]byte(gophtest.NameIndex),
+					[]byte(gophtest.DataKey),
 					[]byte(gophtest.Metadata),
 					[]byte(gophtest.TextData),
 					id,
@@ -301,10 +662,11 @@ func TestUpdateSecret(t *testing.T) {
 		{
 			name:       "Update name",
 			changed:    []string{"name"},
-			secretName: gophtest.SecretName,
+			secretName: []byte(gophtest.SecretName),
+			nameIndex:  []byte(gophtest.NameIndex),
 			expected: expected{
-				query: "UPDATE secrets SET name = \\$1",
-				args:  []any{gophtest.SecretName, id, owner},
+				query: "UPDATE secrets SET name = \\$1, name_index = \\$2",
+				args:  []any{[]byte(gophtest.SecretName), []byte(gophtest.NameIndex), id, owner},
 			},
 		},
 		{
@@ -316,6 +678,15 @@ func TestUpdateSecret(t *testing.T) {
 				args:  []any{[]byte(gophtest.Metadata), id, owner},
 			},
 		},
+		{
+			name:    "Update data key",
+			changed: []string{"data_key"},
+			dataKey: []byte(gophtest.DataKey),
+			expected: expected{
+				query: "UPDATE secrets SET data_key = \\$1",
+				args:  []any{[]byte(gophtest.DataKey), id, owner},
+			},
+		},
 		{
 			name:    "Update data",
 			changed: []string{"data"},
@@ -331,9 +702,12 @@ func TestUpdateSecret(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			m := newPoolMock(t)
 			m.ExpectBeginTx(postgres.DefaultTxOptions)
-			m.ExpectExec(tc.expected.query).
+			expectArchiveVersion(m, owner, id, 1)
+			m.ExpectExec(tc.expected.query + ", version = version \\+ 1, updated_at = now\\(\\) WHERE").
 				WithArgs(tc.expected.args...).
 				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			expectPruneVersions(m, id).
+				WillReturnResult(pgxmock.NewResult("DELETE", 0))
 			m.ExpectCommit()
 
 			err := doUpdateSecret(
@@ -342,6 +716,8 @@ func TestUpdateSecret(t *testing.T) {
 				id,
 				tc.changed,
 				tc.secretName,
+				tc.nameIndex,
+				tc.dataKey,
 				tc.metadata,
 				tc.data,
 				m,
@@ -352,15 +728,73 @@ func TestUpdateSecret(t *testing.T) {
 	}
 }
 
+func TestUpdateSecretOfVersion(t *testing.T) {
+	tt := []struct {
+		name     string
+		updated  int64
+		expected error
+	}{
+		{
+			name:    "Update secret of the current version",
+			updated: 1,
+		},
+		{
+			name:     "Update secret fails if it was modified since the version",
+			expected: entity.ErrSecretModified,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			expectArchiveVersion(m, owner, id, 1)
+			m.ExpectExec(
+				"UPDATE secrets SET metadata = \\$1, version = version \\+ 1, updated_at = now\\(\\) "+
+					"WHERE secret_id = \\$2 AND owner_id = \\$3 AND deleted_at IS NULL AND version = \\$4$",
+			).
+				WithArgs([]byte(gophtest.Metadata), id, owner, int32(2)).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tc.updated))
+
+			if tc.expected == nil {
+				expectPruneVersions(m, id).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).Secrets
+			err := sat.Update(
+				context.Background(),
+				owner,
+				id,
+				testVersionLimit,
+				2,
+				[]string{"metadata"},
+				nil,
+				nil,
+				nil,
+				[]byte(gophtest.Metadata),
+				nil,
+			)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestUpdateUnexistingSecret(t *testing.T) {
 	owner := uuid.New()
 	id := uuid.New()
 
 	m := newPoolMock(t)
 	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectExec("UPDATE").
-		WithArgs(gophtest.SecretName, id, owner).
-		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	expectArchiveVersion(m, owner, id, 0)
 	m.ExpectRollback()
 
 	err := doUpdateSecret(
@@ -368,7 +802,9 @@ func TestUpdateUnexistingSecret(t *testing.T) {
 		owner,
 		id,
 		[]string{"name"},
-		gophtest.SecretName,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		nil,
 		nil,
 		nil,
 		m,
@@ -402,8 +838,9 @@ func TestUpdateSecretOnDBFailure(t *testing.T) {
 
 			m := newPoolMock(t)
 			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			expectArchiveVersion(m, owner, id, 1)
 			m.ExpectExec("UPDATE").
-				WithArgs(gophtest.SecretName, id, owner).
+				WithArgs([]byte(gophtest.SecretName), []byte(gophtest.NameIndex), id, owner).
 				WillReturnError(tc.err)
 			m.ExpectRollback()
 
@@ -412,7 +849,9 @@ func TestUpdateSecretOnDBFailure(t *testing.T) {
 				owner,
 				id,
 				[]string{"name"},
-				gophtest.SecretName,
+				[]byte(gophtest.SecretName),
+				[]byte(gophtest.NameIndex),
+				nil,
 				nil,
 				nil,
 				m,
@@ -430,9 +869,9 @@ func TestDeleteSecret(t *testing.T) {
 
 	m := newPoolMock(t)
 	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectExec("DELETE FROM secrets").
+	m.ExpectExec("UPDATE secrets SET deleted_at = now\\(\\) WHERE .* AND deleted_at IS NULL").
 		WithArgs(id, owner).
-		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 	m.ExpectCommit()
 
 	err := doDeleteSecret(t, owner, id, m)
@@ -446,9 +885,9 @@ func TestDeleteUnexistingSecret(t *testing.T) {
 
 	m := newPoolMock(t)
 	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectExec("DELETE").
+	m.ExpectExec("UPDATE secrets").
 		WithArgs(id, owner).
-		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 	m.ExpectRollback()
 
 	err := doDeleteSecret(t, owner, id, m)
@@ -462,7 +901,7 @@ func TestDeleteSecretOnDBFailure(t *testing.T) {
 
 	m := newPoolMock(t)
 	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectExec("DELETE").
+	m.ExpectExec("UPDATE secrets").
 		WithArgs(id, owner).
 		WillReturnError(gophtest.ErrUnexpected)
 	m.ExpectRollback()
@@ -471,3 +910,467 @@ func TestDeleteSecretOnDBFailure(t *testing.T) {
 
 	require.ErrorIs(t, err, gophtest.ErrUnexpected)
 }
+
+func TestListTrash(t *testing.T) {
+	owner := uuid.New()
+	now := time.Now()
+
+	rows := pgxmock.NewRows(
+		[]string{
+			"secret_id",
+			"name",
+			"name_index",
+			"kind",
+			"data_key",
+			"metadata",
+			"blob_size",
+			"version",
+			"updated_at",
+			"deleted_at",
+		},
+	).
+		AddRow(
+			uuid.New(),
+			[]byte(gophtest.SecretName),
+			[]byte(gophtest.NameIndex),
+			proto.DataKind_TEXT,
+			[]byte(gophtest.DataKey),
+			[]byte(gophtest.Metadata),
+			int64(0),
+			int32(2),
+			now.Add(-time.Hour),
+			now,
+		)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT .*, deleted_at FROM secrets WHERE owner_id = \\$1 AND deleted_at IS NOT NULL").
+		WithArgs(owner).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Secrets
+	secrets, err := sat.ListTrash(context.Background(), owner)
+
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	require.Equal(t, now, secrets[0].DeletedAt)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListTrashOnDBFailure(t *testing.T) {
+	owner := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(owner).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Secrets
+	_, err := sat.ListTrash(context.Background(), owner)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRestoreFromTrash(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectQuery("UPDATE secrets SET deleted_at = NULL WHERE .* AND deleted_at IS NOT NULL RETURNING version").
+		WithArgs(id, owner).
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(int32(4)))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Secrets
+	version, err := sat.RestoreFromTrash(context.Background(), owner, id)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(4), version)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRestoreFromTrashFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Restore fails if secret is not in trash",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrSecretNotFound,
+		},
+		{
+			name:     "Restore fails if name is taken",
+			err:      errUniqueViolation,
+			expected: entity.ErrSecretNameConflict,
+		},
+		{
+			name:     "Restore fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectQuery("UPDATE secrets").
+				WithArgs(id, owner).
+				WillReturnError(tc.err)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Secrets
+			_, err := sat.RestoreFromTrash(context.Background(), owner, id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPurgeSecret(t *testing.T) {
+	tt := []struct {
+		name     string
+		expect   func(e *pgxmock.ExpectedExec)
+		expected error
+	}{
+		{
+			name: "Purge secret",
+			expect: func(e *pgxmock.ExpectedExec) {
+				e.WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Purge unexisting secret",
+			expect: func(e *pgxmock.ExpectedExec) {
+				e.WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expected: entity.ErrSecretNotFound,
+		},
+		{
+			name: "Purge secret on DB failure",
+			expect: func(e *pgxmock.ExpectedExec) {
+				e.WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(
+				m.ExpectExec("DELETE FROM secrets WHERE secret_id = \\$1 AND owner_id = \\$2$").
+					WithArgs(id, owner),
+			)
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).Secrets
+			err := sat.Purge(context.Background(), owner, id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	before := time.Now()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM secrets WHERE deleted_at < \\$1").
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Secrets
+	purged, err := sat.PurgeTrash(context.Background(), before)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(3), purged)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestPurgeTrashOnDBFailure(t *testing.T) {
+	before := time.Now()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM secrets").
+		WithArgs(before).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Secrets
+	_, err := sat.PurgeTrash(context.Background(), before)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListVersions(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	now := time.Now()
+
+	rows := pgxmock.NewRows(
+		[]string{
+			"secret_id", "name", "name_index", "kind", "data_key", "metadata", "blob_size", "version", "updated_at",
+		},
+	).
+		AddRow(
+			id.String(),
+			[]byte(gophtest.SecretName),
+			[]byte(gophtest.NameIndex),
+			proto.DataKind_TEXT,
+			[]byte(gophtest.DataKey),
+			[]byte(gophtest.Metadata),
+			int64(0),
+			int32(2),
+			now,
+		).
+		AddRow(
+			id.String(),
+			[]byte(gophtest.SecretName),
+			[]byte(gophtest.NameIndex),
+			proto.DataKind_TEXT,
+			[]byte(gophtest.DataKey),
+			[]byte(gophtest.Metadata),
+			int64(0),
+			int32(1),
+			now.Add(-time.Hour),
+		)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT .* FROM secrets .* UNION ALL SELECT .* FROM secret_versions .* ORDER BY version DESC").
+		WithArgs(id, owner, false).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Secrets
+	versions, err := sat.ListVersions(context.Background(), owner, id, false)
+
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, int32(2), versions[0].Version)
+	require.Equal(t, int32(1), versions[1].Version)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListVersionsOfUnexistingSecret(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(id, owner, false).
+		WillReturnRows(pgxmock.NewRows([]string{"secret_id"}))
+
+	sat := newTestRepos(t, m).Secrets
+	_, err := sat.ListVersions(context.Background(), owner, id, false)
+
+	require.ErrorIs(t, err, entity.ErrSecretNotFound)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListVersionsOnDBFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(id, owner, false).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Secrets
+	_, err := sat.ListVersions(context.Background(), owner, id, false)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetVersion(t *testing.T) {
+	owner := uuid.New()
+
+	expected := &entity.Secret{
+		ID:        uuid.New(),
+		Name:      []byte(gophtest.SecretName),
+		NameIndex: []byte(gophtest.NameIndex),
+		Kind:      proto.DataKind_TEXT,
+		DataKey:   []byte(gophtest.DataKey),
+		Metadata:  []byte(gophtest.Metadata),
+		Data:      []byte(gophtest.TextData),
+		Version:   1,
+		UpdatedAt: time.Now(),
+	}
+
+	rows := pgxmock.NewRows(
+		[]string{
+			"secret_id", "name", "name_index", "kind", "data_key", "metadata", "data", "blob_size", "version", "updated_at",
+		},
+	).
+		AddRow(
+			expected.ID.String(),
+			expected.Name,
+			expected.NameIndex,
+			expected.Kind,
+			expected.DataKey,
+			expected.Metadata,
+			expected.Data,
+			expected.BlobSize,
+			expected.Version,
+			expected.UpdatedAt,
+		)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT .* FROM secrets .* UNION ALL SELECT .* FROM secret_versions").
+		WithArgs(expected.ID, owner, expected.Version, false).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Secrets
+	secret, err := sat.GetVersion(context.Background(), owner, expected.ID, expected.Version, false)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, secret)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetVersionFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get version fails if version doesn't exist",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrVersionNotFound,
+		},
+		{
+			name:     "Get version fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(id, owner, int32(3), false).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Secrets
+			_, err := sat.GetVersion(context.Background(), owner, id, 3, false)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectArchiveVersion(m, owner, id, 1)
+	m.ExpectQuery("UPDATE secrets s SET .* FROM secret_versions v .* RETURNING s.version").
+		WithArgs(id, owner, int32(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(int32(5)))
+	expectPruneVersions(m, id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Secrets
+	version, err := sat.Restore(context.Background(), owner, id, testVersionLimit, 1)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(5), version)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRestoreVersionFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	tt := []struct {
+		name     string
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name: "Restore fails if secret doesn't exist",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectArchiveVersion(m, owner, id, 0)
+			},
+			expected: entity.ErrSecretNotFound,
+		},
+		{
+			name: "Restore fails if version doesn't exist",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectArchiveVersion(m, owner, id, 1)
+				m.ExpectQuery("UPDATE secrets").
+					WithArgs(id, owner, int32(1)).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}))
+			},
+			expected: entity.ErrVersionNotFound,
+		},
+		{
+			name: "Restore fails if restored name conflicts with other secret",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectArchiveVersion(m, owner, id, 1)
+				m.ExpectQuery("UPDATE secrets").
+					WithArgs(id, owner, int32(1)).
+					WillReturnError(errUniqueViolation)
+			},
+			expected: entity.ErrSecretNameConflict,
+		},
+		{
+			name: "Restore fails if old versions can't be removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectArchiveVersion(m, owner, id, 1)
+				m.ExpectQuery("UPDATE secrets").
+					WithArgs(id, owner, int32(1)).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(int32(5)))
+				expectPruneVersions(m, id).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Secrets
+			_, err := sat.Restore(context.Background(), owner, id, testVersionLimit, 1)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
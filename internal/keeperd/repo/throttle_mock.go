@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+var _ Throttle = (*ThrottleRepoMock)(nil)
+
+type ThrottleRepoMock struct {
+	mock.Mock
+}
+
+func (m *ThrottleRepoMock) GetBlockedUntil(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error) {
+	args := m.Called(ctx, keys)
+
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *ThrottleRepoMock) ChargeAttempt(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error) {
+	args := m.Called(ctx, keys)
+
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *ThrottleRepoMock) RefundAttempt(ctx context.Context, keys []entity.ThrottleKey) error {
+	args := m.Called(ctx, keys)
+
+	return args.Error(0)
+}
+
+func (m *ThrottleRepoMock) ResetFailures(ctx context.Context, key entity.ThrottleKey) error {
+	args := m.Called(ctx, key)
+
+	return args.Error(0)
+}
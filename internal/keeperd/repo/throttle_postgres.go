@@ -0,0 +1,236 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+)
+
+var _ Throttle = (*ThrottleRepo)(nil)
+
+// ThrottleRepo is facade to counters of failed login attempts stored in Postgres.
+type ThrottleRepo struct {
+	pg *postgres.Postgres
+}
+
+// NewThrottleRepo creates and initializes ThrottleRepo object.
+func NewThrottleRepo(
+	pg *postgres.Postgres,
+) *ThrottleRepo {
+	return &ThrottleRepo{pg}
+}
+
+// GetBlockedUntil returns the latest end of lockout among provided keys.
+// Returns zero time if none of the keys is locked out.
+func (r *ThrottleRepo) GetBlockedUntil(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error) {
+	scopes := make([]string, 0, len(keys))
+	subjects := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		scopes = append(scopes, string(key.Scope))
+		subjects = append(subjects, key.Subject)
+	}
+
+	var blockedUntil *time.Time
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           max(blocked_until)
+       FROM
+           login_failures
+       WHERE (scope, subject) IN (
+           SELECT * FROM unnest($1::text[], $2::text[])
+       )`,
+			scopes,
+			subjects,
+		).
+		Scan(&blockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ThrottleRepo - GetBlockedUntil - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	if blockedUntil == nil {
+		return time.Time{}, nil
+	}
+
+	return *blockedUntil, nil
+}
+
+// ChargeAttempt counts the attempt as failed for each of the keys in advance,
+// before the credentials are verified, and locks out keys with too many failures,
+// see entity.ThrottleKey.Delay.
+// The rows of the keys are locked while they are checked and updated,
+// so concurrent attempts can't pass the check before any failure is counted.
+// Nothing is counted if any of the keys is already locked out,
+// the end of the lockout is returned then, otherwise zero time is returned.
+// Failures older than entity.ThrottleResetAfter are forgotten on the way.
+func (r *ThrottleRepo) ChargeAttempt(ctx context.Context, keys []entity.ThrottleKey) (time.Time, error) {
+	var blockedUntil time.Time
+
+	fn := func(tx postgres.Transaction) error {
+		staleBefore := time.Now().Add(-entity.ThrottleResetAfter)
+
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           login_failures
+       WHERE last_failure_at < $1`,
+			staleBefore,
+		)
+		if err != nil {
+			return fmt.Errorf("ThrottleRepo - ChargeAttempt - tx.Exec(delete): %w", err)
+		}
+
+		failures := make([]int, len(keys))
+
+		for i, key := range keys {
+			var until *time.Time
+
+			err = tx.QueryRow(
+				ctx,
+				`INSERT INTO
+           login_failures (scope, subject, failures, last_failure_at)
+       VALUES
+           ($1, $2, 0, now())
+       ON CONFLICT (scope, subject) DO UPDATE SET
+           failures = login_failures.failures
+       RETURNING failures, blocked_until`,
+				string(key.Scope),
+				key.Subject,
+			).Scan(&failures[i], &until)
+			if err != nil {
+				return fmt.Errorf("ThrottleRepo - ChargeAttempt - tx.QueryRow.Scan: %w", err)
+			}
+
+			if until != nil && until.After(blockedUntil) {
+				blockedUntil = *until
+			}
+		}
+
+		if time.Now().Before(blockedUntil) {
+			return nil
+		}
+
+		blockedUntil = time.Time{}
+
+		for i, key := range keys {
+			var until *time.Time
+
+			if delay := key.Delay(failures[i] + 1); delay > 0 {
+				lockout := time.Now().Add(delay)
+				until = &lockout
+			}
+
+			_, err = tx.Exec(
+				ctx,
+				`UPDATE
+           login_failures
+       SET
+           failures = $3,
+           last_failure_at = now(),
+           blocked_until = $4
+       WHERE scope=$1 AND subject=$2`,
+				string(key.Scope),
+				key.Subject,
+				failures[i]+1,
+				until,
+			)
+			if err != nil {
+				return fmt.Errorf("ThrottleRepo - ChargeAttempt - tx.Exec(update): %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return time.Time{}, fmt.Errorf("ThrottleRepo - ChargeAttempt - r.pg.RunAtomic: %w", err)
+	}
+
+	return blockedUntil, nil
+}
+
+// RefundAttempt takes back the attempt charged by ChargeAttempt for each of the keys,
+// if it hasn't failed. The lockout is lifted, if the rest of failures is allowed.
+func (r *ThrottleRepo) RefundAttempt(ctx context.Context, keys []entity.ThrottleKey) error {
+	fn := func(tx postgres.Transaction) error {
+		for _, key := range keys {
+			var failures int
+
+			err := tx.QueryRow(
+				ctx,
+				`UPDATE
+           login_failures
+       SET
+           failures = greatest(failures - 1, 0)
+       WHERE scope=$1 AND subject=$2
+       RETURNING failures`,
+				string(key.Scope),
+				key.Subject,
+			).Scan(&failures)
+			if err != nil {
+				if postgres.IsEmptyResponse(err) {
+					continue
+				}
+
+				return fmt.Errorf("ThrottleRepo - RefundAttempt - tx.QueryRow.Scan: %w", err)
+			}
+
+			if key.Delay(failures) > 0 {
+				continue
+			}
+
+			_, err = tx.Exec(
+				ctx,
+				`UPDATE
+           login_failures
+       SET
+           blocked_until = NULL
+       WHERE scope=$1 AND subject=$2`,
+				string(key.Scope),
+				key.Subject,
+			)
+			if err != nil {
+				return fmt.Errorf("ThrottleRepo - RefundAttempt - tx.Exec: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("ThrottleRepo - RefundAttempt - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// ResetFailures forgets failed attempts of the key after successful login.
+func (r *ThrottleRepo) ResetFailures(ctx context.Context, key entity.ThrottleKey) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           login_failures
+       WHERE scope=$1 AND subject=$2`,
+			string(key.Scope),
+			key.Subject,
+		)
+		if err != nil {
+			return fmt.Errorf("ThrottleRepo - ResetFailures - tx.Exec: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("ThrottleRepo - ResetFailures - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
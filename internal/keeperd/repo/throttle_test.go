@@ -0,0 +1,332 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestThrottleKeys() []entity.ThrottleKey {
+	return []entity.ThrottleKey{
+		entity.UsernameThrottleKey(gophtest.Username),
+		entity.PeerThrottleKey("127.0.0.1"),
+	}
+}
+
+func TestGetBlockedUntil(t *testing.T) {
+	blockedUntil := time.Now().Add(time.Minute)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT max\\(blocked_until\\) FROM login_failures").
+		WithArgs([]string{"username", "peer"}, []string{gophtest.Username, "127.0.0.1"}).
+		WillReturnRows(pgxmock.NewRows([]string{"max"}).AddRow(&blockedUntil))
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.GetBlockedUntil(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.Equal(t, blockedUntil, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetBlockedUntilIfNotBlocked(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT max\\(blocked_until\\) FROM login_failures").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"max"}).AddRow(nil))
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.GetBlockedUntil(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.True(t, rv.IsZero())
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetBlockedUntilOnDBFailure(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Throttle
+	_, err := sat.GetBlockedUntil(context.Background(), newTestThrottleKeys())
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func expectLockedFailures(m pgxmock.PgxPoolIface, failures int, blockedUntil *time.Time) {
+	m.ExpectQuery("INSERT INTO login_failures .+ ON CONFLICT .+ RETURNING failures, blocked_until").
+		WithArgs("username", gophtest.Username).
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "blocked_until"}).AddRow(failures, blockedUntil))
+	m.ExpectQuery("INSERT INTO login_failures").
+		WithArgs("peer", "127.0.0.1").
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "blocked_until"}).AddRow(0, nil))
+}
+
+func TestChargeAttempt(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM login_failures WHERE last_failure_at < \\$1").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	expectLockedFailures(m, 0, nil)
+	m.ExpectExec("UPDATE login_failures SET failures = \\$3, last_failure_at = now\\(\\), blocked_until = \\$4").
+		WithArgs("username", gophtest.Username, 1, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("UPDATE login_failures").
+		WithArgs("peer", "127.0.0.1", 1, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.ChargeAttempt(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.True(t, rv.IsZero())
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestChargeAttemptLocksOut(t *testing.T) {
+	key := entity.UsernameThrottleKey(gophtest.Username)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM login_failures").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	expectLockedFailures(m, key.FreeAttempts(), nil)
+	m.ExpectExec("UPDATE login_failures").
+		WithArgs("username", gophtest.Username, key.FreeAttempts()+1, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("UPDATE login_failures").
+		WithArgs("peer", "127.0.0.1", 1, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.ChargeAttempt(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.True(t, rv.IsZero())
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestChargeAttemptIfLockedOut(t *testing.T) {
+	blockedUntil := time.Now().Add(time.Minute)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM login_failures").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	expectLockedFailures(m, 10, &blockedUntil)
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.ChargeAttempt(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.Equal(t, blockedUntil, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestChargeAttemptAfterLockout(t *testing.T) {
+	blockedUntil := time.Now().Add(-time.Second)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM login_failures").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	expectLockedFailures(m, 10, &blockedUntil)
+	m.ExpectExec("UPDATE login_failures").
+		WithArgs("username", gophtest.Username, 11, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("UPDATE login_failures").
+		WithArgs("peer", "127.0.0.1", 1, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	rv, err := sat.ChargeAttempt(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.True(t, rv.IsZero())
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestChargeAttemptOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Charge attempt fails if stale failures are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Charge attempt fails if failures are not locked",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectQuery("INSERT").
+					WithArgs("username", gophtest.Username).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Charge attempt fails if failure is not counted",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				expectLockedFailures(m, 0, nil)
+				m.ExpectExec("UPDATE").
+					WithArgs("username", gophtest.Username, 1, (*time.Time)(nil)).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Throttle
+			_, err := sat.ChargeAttempt(context.Background(), newTestThrottleKeys())
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRefundAttempt(t *testing.T) {
+	key := entity.UsernameThrottleKey(gophtest.Username)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectQuery("UPDATE login_failures SET failures = greatest\\(failures - 1, 0\\)").
+		WithArgs("username", gophtest.Username).
+		WillReturnRows(pgxmock.NewRows([]string{"failures"}).AddRow(key.FreeAttempts()))
+	m.ExpectExec("UPDATE login_failures SET blocked_until = NULL").
+		WithArgs("username", gophtest.Username).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectQuery("UPDATE login_failures").
+		WithArgs("peer", "127.0.0.1").
+		WillReturnError(pgx.ErrNoRows)
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	err := sat.RefundAttempt(context.Background(), newTestThrottleKeys())
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRefundAttemptKeepsLockout(t *testing.T) {
+	key := entity.UsernameThrottleKey(gophtest.Username)
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectQuery("UPDATE login_failures").
+		WithArgs("username", gophtest.Username).
+		WillReturnRows(pgxmock.NewRows([]string{"failures"}).AddRow(key.FreeAttempts() + 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	err := sat.RefundAttempt(context.Background(), []entity.ThrottleKey{key})
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRefundAttemptOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Refund attempt fails if failure is not taken back",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("UPDATE").
+					WithArgs("username", gophtest.Username).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Refund attempt fails if lockout is not lifted",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("UPDATE").
+					WithArgs("username", gophtest.Username).
+					WillReturnRows(pgxmock.NewRows([]string{"failures"}).AddRow(0))
+				m.ExpectExec("UPDATE").
+					WithArgs("username", gophtest.Username).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Throttle
+			err := sat.RefundAttempt(context.Background(), []entity.ThrottleKey{entity.UsernameThrottleKey(gophtest.Username)})
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestResetFailures(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM login_failures WHERE scope=\\$1 AND subject=\\$2").
+		WithArgs("username", gophtest.Username).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Throttle
+	err := sat.ResetFailures(context.Background(), entity.UsernameThrottleKey(gophtest.Username))
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestResetFailuresOnDBFailure(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs("username", gophtest.Username).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Throttle
+	err := sat.ResetFailures(context.Background(), entity.UsernameThrottleKey(gophtest.Username))
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
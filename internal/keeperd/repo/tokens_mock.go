@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+var _ Tokens = (*TokensRepoMock)(nil)
+
+type TokensRepoMock struct {
+	mock.Mock
+}
+
+func (m *TokensRepoMock) CreateRefreshToken(ctx context.Context, token entity.RefreshTokenRecord) error {
+	args := m.Called(ctx, token)
+
+	return args.Error(0)
+}
+
+func (m *TokensRepoMock) RotateRefreshToken(
+	ctx context.Context,
+	hash []byte,
+	next entity.RefreshTokenRecord,
+) (entity.RefreshTokenRecord, error) {
+	args := m.Called(ctx, hash, next)
+
+	return args.Get(0).(entity.RefreshTokenRecord), args.Error(1)
+}
+
+func (m *TokensRepoMock) RevokeRefreshTokenFamily(ctx context.Context, user uuid.UUID, hash []byte) error {
+	args := m.Called(ctx, user, hash)
+
+	return args.Error(0)
+}
+
+func (m *TokensRepoMock) RevokeAccessToken(ctx context.Context, token entity.TokenInfo) error {
+	args := m.Called(ctx, token)
+
+	return args.Error(0)
+}
+
+func (m *TokensRepoMock) IsAccessTokenRevoked(ctx context.Context, token entity.TokenInfo) (bool, error) {
+	args := m.Called(ctx, token)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *TokensRepoMock) ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error) {
+	args := m.Called(ctx, user)
+
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *TokensRepoMock) RevokeSession(ctx context.Context, user, id uuid.UUID) error {
+	args := m.Called(ctx, user, id)
+
+	return args.Error(0)
+}
+
+func (m *TokensRepoMock) RevokeOtherSessions(ctx context.Context, user, current uuid.UUID) error {
+	args := m.Called(ctx, user, current)
+
+	return args.Error(0)
+}
@@ -0,0 +1,378 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+)
+
+var _ Tokens = (*TokensRepo)(nil)
+
+// TokensRepo is facade to refresh tokens, login sessions and revoked access tokens stored in Postgres.
+type TokensRepo struct {
+	pg *postgres.Postgres
+}
+
+// NewTokensRepo creates and initializes TokensRepo object.
+func NewTokensRepo(
+	pg *postgres.Postgres,
+) *TokensRepo {
+	return &TokensRepo{pg}
+}
+
+// CreateRefreshToken saves refresh token starting new family
+// and records the login session of the family.
+// Expired sessions and tokens are removed on the way.
+func (r *TokensRepo) CreateRefreshToken(ctx context.Context, token entity.RefreshTokenRecord) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           sessions
+       WHERE expires_at < now()`,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - CreateRefreshToken - tx.Exec(delete sessions): %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`DELETE FROM
+           refresh_tokens
+       WHERE expires_at < now()`,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - CreateRefreshToken - tx.Exec(delete): %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO
+           sessions (session_id, user_id, device_name, user_agent, peer_address, expires_at)
+       VALUES
+           ($1, $2, $3, $4, $5, $6)`,
+			token.FamilyID,
+			token.User.ID,
+			token.Device.Name,
+			token.Device.UserAgent,
+			token.Device.PeerAddress,
+			token.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - CreateRefreshToken - tx.Exec(insert session): %w", err)
+		}
+
+		if err := insertRefreshToken(ctx, tx, token); err != nil {
+			return fmt.Errorf("TokensRepo - CreateRefreshToken - insertRefreshToken: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TokensRepo - CreateRefreshToken - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken marks the refresh token as used and saves the next token of the same family.
+// Returns the next token with the family and the user of the used one.
+// The session of the family is prolonged and marked as seen.
+// The whole family is revoked if the token has been used already.
+func (r *TokensRepo) RotateRefreshToken(
+	ctx context.Context,
+	hash []byte,
+	next entity.RefreshTokenRecord,
+) (entity.RefreshTokenRecord, error) {
+	var reused bool
+
+	fn := func(tx postgres.Transaction) error {
+		var (
+			used      bool
+			expiresAt time.Time
+		)
+
+		err := tx.QueryRow(
+			ctx,
+			`SELECT
+           t.family_id, t.used, t.expires_at, u.user_id, u.username
+       FROM
+           refresh_tokens t
+       JOIN users u ON u.user_id = t.user_id
+       WHERE t.token_hash=$1
+       FOR UPDATE OF t`,
+			hash,
+		).Scan(&next.FamilyID, &used, &expiresAt, &next.User.ID, &next.User.Username)
+		if err != nil {
+			if postgres.IsEmptyResponse(err) {
+				return entity.ErrInvalidRefreshToken
+			}
+
+			return fmt.Errorf("TokensRepo - RotateRefreshToken - tx.QueryRow.Scan: %w", err)
+		}
+
+		if used {
+			reused = true
+
+			_, err := tx.Exec(
+				ctx,
+				`DELETE FROM
+           sessions
+       WHERE session_id=$1`,
+				next.FamilyID,
+			)
+			if err != nil {
+				return fmt.Errorf("TokensRepo - RotateRefreshToken - tx.Exec(delete): %w", err)
+			}
+
+			return nil
+		}
+
+		if time.Now().After(expiresAt) {
+			return entity.ErrInvalidRefreshToken
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE
+           refresh_tokens
+       SET
+           used = true
+       WHERE token_hash=$1`,
+			hash,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RotateRefreshToken - tx.Exec(update): %w", err)
+		}
+
+		if err := insertRefreshToken(ctx, tx, next); err != nil {
+			return fmt.Errorf("TokensRepo - RotateRefreshToken - insertRefreshToken: %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE
+           sessions
+       SET
+           last_seen_at = now(), expires_at = $2
+       WHERE session_id=$1`,
+			next.FamilyID,
+			next.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RotateRefreshToken - tx.Exec(update session): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return next, fmt.Errorf("TokensRepo - RotateRefreshToken - r.pg.RunAtomic: %w", err)
+	}
+
+	// Revocation of the family must be committed.
+	if reused {
+		return next, entity.ErrRefreshTokenReused
+	}
+
+	return next, nil
+}
+
+// RevokeRefreshTokenFamily removes the session of the refresh token issued to the user
+// together with the family, so none of the tokens rotated from the same login could be used.
+// Unknown tokens are ignored, as there is nothing to revoke.
+func (r *TokensRepo) RevokeRefreshTokenFamily(ctx context.Context, user uuid.UUID, hash []byte) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           sessions
+       WHERE session_id = (
+           SELECT family_id FROM refresh_tokens WHERE token_hash=$1 AND user_id=$2
+       )`,
+			hash,
+			user,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RevokeRefreshTokenFamily - tx.Exec: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TokensRepo - RevokeRefreshTokenFamily - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken saves the access token as revoked until its expiration.
+// Records of expired tokens are removed on the way.
+func (r *TokensRepo) RevokeAccessToken(ctx context.Context, token entity.TokenInfo) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           revoked_tokens
+       WHERE expires_at < now()`,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RevokeAccessToken - tx.Exec(delete): %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO
+           revoked_tokens (jti, expires_at)
+       VALUES
+           ($1, $2)
+       ON CONFLICT (jti) DO NOTHING`,
+			token.ID,
+			token.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RevokeAccessToken - tx.Exec(insert): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TokensRepo - RevokeAccessToken - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked tells whether the access token has been revoked
+// either by itself or together with its session.
+// Tokens of deleted users are revoked as well, including the ones without session.
+func (r *TokensRepo) IsAccessTokenRevoked(ctx context.Context, token entity.TokenInfo) (bool, error) {
+	var revoked bool
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT EXISTS (
+           SELECT 1 FROM revoked_tokens WHERE jti=$1
+       ) OR (
+           $2::uuid IS NOT NULL AND NOT EXISTS (SELECT 1 FROM sessions WHERE session_id=$2)
+       ) OR NOT EXISTS (
+           SELECT 1 FROM users WHERE user_id=$3
+       )`,
+			token.ID,
+			sessionArg(token.SessionID),
+			token.UserID,
+		).
+		Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("TokensRepo - IsAccessTokenRevoked - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// ListSessions returns active login sessions of the user, the most recently seen first.
+func (r *TokensRepo) ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error) {
+	rv := make([]entity.Session, 0)
+	if err := r.pg.Select(
+		ctx,
+		&rv,
+		`SELECT
+         session_id, device_name, user_agent, peer_address, created_at, last_seen_at
+     FROM
+         sessions
+     WHERE user_id = $1 AND expires_at >= now()
+     ORDER BY last_seen_at DESC`,
+		user,
+	); err != nil {
+		return nil, fmt.Errorf("TokensRepo - ListSessions - r.Select: %w", err)
+	}
+
+	return rv, nil
+}
+
+// RevokeSession removes the login session of the user together with its refresh tokens.
+// Access tokens of the session are rejected afterwards, see IsAccessTokenRevoked.
+func (r *TokensRepo) RevokeSession(ctx context.Context, user, id uuid.UUID) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           sessions
+       WHERE session_id = $1 AND user_id = $2`,
+			id,
+			user,
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RevokeSession - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrSessionNotFound
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TokensRepo - RevokeSession - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions removes all login sessions of the user except the current one
+// together with their refresh tokens, e.g. after the keys of the user are replaced.
+// All sessions are removed if the current one is not set.
+func (r *TokensRepo) RevokeOtherSessions(ctx context.Context, user, current uuid.UUID) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           sessions
+       WHERE user_id = $1 AND session_id IS DISTINCT FROM $2`,
+			user,
+			sessionArg(current),
+		)
+		if err != nil {
+			return fmt.Errorf("TokensRepo - RevokeOtherSessions - tx.Exec: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TokensRepo - RevokeOtherSessions - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// sessionArg converts ID of the session to query argument, NULL if the token isn't bound to a session.
+func sessionArg(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func insertRefreshToken(ctx context.Context, tx postgres.Transaction, token entity.RefreshTokenRecord) error {
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO
+           refresh_tokens (token_hash, family_id, user_id, expires_at)
+       VALUES
+           ($1, $2, $3, $4)`,
+		token.Hash,
+		token.FamilyID,
+		token.User.ID,
+		token.ExpiresAt,
+	)
+
+	return err
+}
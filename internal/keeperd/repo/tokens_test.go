@@ -0,0 +1,625 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestRefreshTokenRecord() entity.RefreshTokenRecord {
+	return entity.RefreshTokenRecord{
+		Hash:     entity.RefreshToken(gophtest.RefreshToken).Hash(),
+		FamilyID: uuid.New(),
+		User: entity.User{
+			ID:       uuid.New(),
+			Username: gophtest.Username,
+		},
+		Device:    newTestDevice(),
+		ExpiresAt: time.Now().Add(entity.RefreshTokenLifeTime),
+	}
+}
+
+func newTestDevice() entity.Device {
+	return entity.Device{
+		Name:        gophtest.DeviceName,
+		UserAgent:   gophtest.UserAgent,
+		PeerAddress: gophtest.PeerAddress,
+	}
+}
+
+func sessionArgs(token entity.RefreshTokenRecord) []any {
+	return []any{
+		token.FamilyID,
+		token.User.ID,
+		token.Device.Name,
+		token.Device.UserAgent,
+		token.Device.PeerAddress,
+		token.ExpiresAt,
+	}
+}
+
+func refreshTokenArgs(token entity.RefreshTokenRecord) []any {
+	return []any{token.Hash, token.FamilyID, token.User.ID, token.ExpiresAt}
+}
+
+func refreshTokenRows(token entity.RefreshTokenRecord, used bool, expiresAt time.Time) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"family_id", "used", "expires_at", "user_id", "username"}).
+		AddRow(token.FamilyID.String(), used, expiresAt, token.User.ID.String(), token.User.Username)
+}
+
+func TestCreateRefreshToken(t *testing.T) {
+	token := newTestRefreshTokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM sessions WHERE expires_at < now()").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectExec("DELETE FROM refresh_tokens WHERE expires_at < now()").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectExec("INSERT INTO sessions").
+		WithArgs(sessionArgs(token)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(refreshTokenArgs(token)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.CreateRefreshToken(context.Background(), token)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCreateRefreshTokenOnDBFailure(t *testing.T) {
+	token := newTestRefreshTokenRecord()
+
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Create refresh token fails if expired sessions are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM sessions").
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Create refresh token fails if expired tokens are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM sessions").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("DELETE FROM refresh_tokens").
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Create refresh token fails if session is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM sessions").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("DELETE FROM refresh_tokens").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT INTO sessions").
+					WithArgs(sessionArgs(token)...).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Create refresh token fails if token is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM sessions").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("DELETE FROM refresh_tokens").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT INTO sessions").
+					WithArgs(sessionArgs(token)...).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(refreshTokenArgs(token)...).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Tokens
+			err := sat.CreateRefreshToken(context.Background(), token)
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	used := newTestRefreshTokenRecord()
+
+	next := entity.RefreshTokenRecord{
+		Hash:      []byte("next token hash"),
+		ExpiresAt: time.Now().Add(entity.RefreshTokenLifeTime),
+	}
+
+	expected := next
+	expected.FamilyID = used.FamilyID
+	expected.User = used.User
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectQuery("SELECT t.family_id, t.used, t.expires_at, u.user_id, u.username FROM refresh_tokens t").
+		WithArgs(used.Hash).
+		WillReturnRows(refreshTokenRows(used, false, used.ExpiresAt))
+	m.ExpectExec("UPDATE refresh_tokens SET used = true").
+		WithArgs(used.Hash).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(refreshTokenArgs(expected)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectExec("UPDATE sessions SET last_seen_at = now\\(\\), expires_at = \\$2").
+		WithArgs(expected.FamilyID, expected.ExpiresAt).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	rv, err := sat.RotateRefreshToken(context.Background(), used.Hash, next)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRotateReusedRefreshToken(t *testing.T) {
+	used := newTestRefreshTokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectQuery("SELECT").
+		WithArgs(used.Hash).
+		WillReturnRows(refreshTokenRows(used, true, used.ExpiresAt))
+	m.ExpectExec("DELETE FROM sessions WHERE session_id").
+		WithArgs(used.FamilyID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	_, err := sat.RotateRefreshToken(context.Background(), used.Hash, newTestRefreshTokenRecord())
+
+	require.ErrorIs(t, err, entity.ErrRefreshTokenReused)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRotateRefreshTokenOnDBFailure(t *testing.T) {
+	used := newTestRefreshTokenRecord()
+
+	tt := []struct {
+		name     string
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name: "Rotate refresh token fails if token doesn't exist",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expected: entity.ErrInvalidRefreshToken,
+		},
+		{
+			name: "Rotate refresh token fails if token is expired",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnRows(refreshTokenRows(used, false, time.Now().Add(-time.Minute)))
+			},
+			expected: entity.ErrInvalidRefreshToken,
+		},
+		{
+			name: "Rotate refresh token fails on unexpected error",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Rotate refresh token fails if family is not revoked",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnRows(refreshTokenRows(used, true, used.ExpiresAt))
+				m.ExpectExec("DELETE").
+					WithArgs(used.FamilyID).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Rotate refresh token fails if token is not marked as used",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnRows(refreshTokenRows(used, false, used.ExpiresAt))
+				m.ExpectExec("UPDATE").
+					WithArgs(used.Hash).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Rotate refresh token fails if next token is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnRows(refreshTokenRows(used, false, used.ExpiresAt))
+				m.ExpectExec("UPDATE").
+					WithArgs(used.Hash).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("INSERT").
+					WithArgs(pgxmock.AnyArg(), used.FamilyID, used.User.ID, pgxmock.AnyArg()).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Rotate refresh token fails if session is not prolonged",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT").
+					WithArgs(used.Hash).
+					WillReturnRows(refreshTokenRows(used, false, used.ExpiresAt))
+				m.ExpectExec("UPDATE refresh_tokens").
+					WithArgs(used.Hash).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("INSERT").
+					WithArgs(pgxmock.AnyArg(), used.FamilyID, used.User.ID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("UPDATE sessions").
+					WithArgs(used.FamilyID, pgxmock.AnyArg()).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Tokens
+			_, err := sat.RotateRefreshToken(context.Background(), used.Hash, newTestRefreshTokenRecord())
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeRefreshTokenFamily(t *testing.T) {
+	token := newTestRefreshTokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM sessions WHERE session_id").
+		WithArgs(token.Hash, token.User.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeRefreshTokenFamily(context.Background(), token.User.ID, token.Hash)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRevokeRefreshTokenFamilyOnDBFailure(t *testing.T) {
+	token := newTestRefreshTokenRecord()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs(token.Hash, token.User.ID).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeRefreshTokenFamily(context.Background(), token.User.ID, token.Hash)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func newTestTokenInfo() entity.TokenInfo {
+	return entity.TokenInfo{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ExpiresAt: time.Now().Add(entity.TokenLifeTime),
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	token := newTestTokenInfo()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM revoked_tokens WHERE expires_at < now()").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectExec("INSERT INTO revoked_tokens").
+		WithArgs(token.ID, token.ExpiresAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeAccessToken(context.Background(), token)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRevokeAccessTokenOnDBFailure(t *testing.T) {
+	token := newTestTokenInfo()
+
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Revoke access token fails if expired records are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Revoke access token fails if token is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT").
+					WithArgs(token.ID, token.ExpiresAt).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Tokens
+			err := sat.RevokeAccessToken(context.Background(), token)
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsAccessTokenRevoked(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected bool
+	}{
+		{
+			name:     "Revoked token is reported",
+			expected: true,
+		},
+		{
+			name:     "Valid token is not reported",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			token := newTestTokenInfo()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT EXISTS").
+				WithArgs(token.ID, uuid.NullUUID{}, token.UserID).
+				WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(tc.expected))
+
+			sat := newTestRepos(t, m).Tokens
+			revoked, err := sat.IsAccessTokenRevoked(context.Background(), token)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, revoked)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsSessionAccessTokenRevoked(t *testing.T) {
+	token := newTestTokenInfo()
+	token.SessionID = uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT EXISTS (.+) FROM sessions WHERE session_id").
+		WithArgs(token.ID, uuid.NullUUID{UUID: token.SessionID, Valid: true}, token.UserID).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	sat := newTestRepos(t, m).Tokens
+	revoked, err := sat.IsAccessTokenRevoked(context.Background(), token)
+
+	require.NoError(t, err)
+	require.True(t, revoked)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestIsAccessTokenRevokedOnDBFailure(t *testing.T) {
+	token := newTestTokenInfo()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(token.ID, uuid.NullUUID{}, token.UserID).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Tokens
+	_, err := sat.IsAccessTokenRevoked(context.Background(), token)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListSessions(t *testing.T) {
+	user := uuid.New()
+	device := newTestDevice()
+	expected := []entity.Session{
+		{
+			ID:         uuid.New(),
+			Device:     device,
+			CreatedAt:  time.Now().Add(-time.Hour),
+			LastSeenAt: time.Now(),
+		},
+	}
+
+	rows := pgxmock.NewRows(
+		[]string{"session_id", "device_name", "user_agent", "peer_address", "created_at", "last_seen_at"},
+	)
+	for _, s := range expected {
+		rows.AddRow(s.ID, s.Name, s.UserAgent, s.PeerAddress, s.CreatedAt, s.LastSeenAt)
+	}
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT session_id, device_name, user_agent, peer_address, created_at, last_seen_at FROM sessions").
+		WithArgs(user).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Tokens
+	sessions, err := sat.ListSessions(context.Background(), user)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, sessions)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestListSessionsOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(user).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Tokens
+	_, err := sat.ListSessions(context.Background(), user)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	user, id := uuid.New(), uuid.New()
+
+	tt := []struct {
+		name     string
+		affected int64
+		expected error
+	}{
+		{
+			name:     "Session is revoked",
+			affected: 1,
+			expected: nil,
+		},
+		{
+			name:     "Unknown session is not found",
+			affected: 0,
+			expected: entity.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectExec("DELETE FROM sessions WHERE session_id = \\$1 AND user_id = \\$2").
+				WithArgs(id, user).
+				WillReturnResult(pgxmock.NewResult("DELETE", tc.affected))
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).Tokens
+			err := sat.RevokeSession(context.Background(), user, id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeSessionOnDBFailure(t *testing.T) {
+	user, id := uuid.New(), uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs(id, user).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeSession(context.Background(), user, id)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	user, current := uuid.New(), uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM sessions WHERE user_id = \\$1 AND session_id IS DISTINCT FROM \\$2").
+		WithArgs(user, uuid.NullUUID{UUID: current, Valid: true}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeOtherSessions(context.Background(), user, current)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRevokeOtherSessionsOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs(user, uuid.NullUUID{}).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Tokens
+	err := sat.RevokeOtherSessions(context.Background(), user, uuid.Nil)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
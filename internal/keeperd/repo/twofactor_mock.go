@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+var _ TwoFactor = (*TwoFactorRepoMock)(nil)
+
+type TwoFactorRepoMock struct {
+	mock.Mock
+}
+
+func (m *TwoFactorRepoMock) SetupTOTP(ctx context.Context, user uuid.UUID, secret []byte) error {
+	args := m.Called(ctx, user, secret)
+
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepoMock) GetTOTP(ctx context.Context, user uuid.UUID) (entity.TwoFactor, error) {
+	args := m.Called(ctx, user)
+
+	return args.Get(0).(entity.TwoFactor), args.Error(1)
+}
+
+func (m *TwoFactorRepoMock) EnableTOTP(ctx context.Context, user uuid.UUID, step int64, backupCodes [][]byte) error {
+	args := m.Called(ctx, user, step, backupCodes)
+
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepoMock) DisableTOTP(ctx context.Context, user uuid.UUID) error {
+	args := m.Called(ctx, user)
+
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepoMock) UseTOTPStep(ctx context.Context, user uuid.UUID, step int64) error {
+	args := m.Called(ctx, user, step)
+
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepoMock) UseBackupCode(ctx context.Context, user uuid.UUID, hash []byte) error {
+	args := m.Called(ctx, user, hash)
+
+	return args.Error(0)
+}
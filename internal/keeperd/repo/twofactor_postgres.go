@@ -0,0 +1,241 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+)
+
+var _ TwoFactor = (*TwoFactorRepo)(nil)
+
+// TwoFactorRepo is facade to TOTP secrets and backup codes stored in Postgres.
+type TwoFactorRepo struct {
+	pg *postgres.Postgres
+}
+
+// NewTwoFactorRepo creates and initializes TwoFactorRepo object.
+func NewTwoFactorRepo(
+	pg *postgres.Postgres,
+) *TwoFactorRepo {
+	return &TwoFactorRepo{pg}
+}
+
+// SetupTOTP saves pending TOTP secret of the user, replacing previous pending one.
+func (r *TwoFactorRepo) SetupTOTP(ctx context.Context, user uuid.UUID, secret []byte) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`INSERT INTO
+           two_factor (user_id, totp_secret)
+       VALUES
+           ($1, $2)
+       ON CONFLICT (user_id) DO UPDATE
+           SET totp_secret = excluded.totp_secret
+           WHERE two_factor.enabled = false`,
+			user,
+			secret,
+		)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - SetupTOTP - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrTOTPAlreadyEnabled
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TwoFactorRepo - SetupTOTP - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// GetTOTP returns TOTP secret of the user, either pending or enabled.
+func (r *TwoFactorRepo) GetTOTP(ctx context.Context, user uuid.UUID) (entity.TwoFactor, error) {
+	var tf entity.TwoFactor
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           totp_secret, enabled
+       FROM
+           two_factor
+       WHERE user_id=$1`,
+			user,
+		).
+		Scan(&tf.Secret, &tf.Enabled)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return tf, entity.ErrTOTPNotEnabled
+		}
+
+		return tf, fmt.Errorf("TwoFactorRepo - GetTOTP - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return tf, nil
+}
+
+// EnableTOTP enables pending TOTP secret of the user and replaces backup codes.
+// The step of the code confirming enrollment is saved as the last accepted one.
+func (r *TwoFactorRepo) EnableTOTP(ctx context.Context, user uuid.UUID, step int64, backupCodes [][]byte) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`UPDATE
+           two_factor
+       SET
+           enabled = true,
+           last_step = $2
+       WHERE user_id=$1 AND enabled = false`,
+			user,
+			step,
+		)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.Exec(update): %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrTOTPNotEnabled
+		}
+
+		if _, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           backup_codes
+       WHERE user_id=$1`,
+			user,
+		); err != nil {
+			return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.Exec(delete): %w", err)
+		}
+
+		for _, hash := range backupCodes {
+			if _, err := tx.Exec(
+				ctx,
+				`INSERT INTO
+           backup_codes (user_id, code_hash)
+       VALUES
+           ($1, $2)`,
+				user,
+				hash,
+			); err != nil {
+				return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.Exec(insert): %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTOTP removes TOTP secret and backup codes of the user.
+func (r *TwoFactorRepo) DisableTOTP(ctx context.Context, user uuid.UUID) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           two_factor
+       WHERE user_id=$1`,
+			user,
+		)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - DisableTOTP - tx.Exec(two_factor): %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrTOTPNotEnabled
+		}
+
+		if _, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           backup_codes
+       WHERE user_id=$1`,
+			user,
+		); err != nil {
+			return fmt.Errorf("TwoFactorRepo - DisableTOTP - tx.Exec(backup_codes): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep saves the time step of accepted TOTP code of the user.
+// Returns entity.ErrInvalidSecondFactor if code of the same or later step was accepted before,
+// so the code couldn't be replayed.
+func (r *TwoFactorRepo) UseTOTPStep(ctx context.Context, user uuid.UUID, step int64) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`UPDATE
+           two_factor
+       SET
+           last_step = $2
+       WHERE user_id=$1 AND (last_step IS NULL OR last_step < $2)`,
+			user,
+			step,
+		)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - UseTOTPStep - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrInvalidSecondFactor
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseTOTPStep - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// UseBackupCode removes the backup code of the user, so it couldn't be used twice.
+func (r *TwoFactorRepo) UseBackupCode(ctx context.Context, user uuid.UUID, hash []byte) error {
+	fn := func(tx postgres.Transaction) error {
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           backup_codes
+       WHERE user_id=$1 AND code_hash=$2`,
+			user,
+			hash,
+		)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - UseBackupCode - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrInvalidSecondFactor
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseBackupCode - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,426 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestSetupTOTP(t *testing.T) {
+	tt := []struct {
+		name     string
+		affected int64
+		expected error
+	}{
+		{
+			name:     "Pending secret is saved",
+			affected: 1,
+			expected: nil,
+		},
+		{
+			name:     "Setup fails if TOTP is enabled already",
+			affected: 0,
+			expected: entity.ErrTOTPAlreadyEnabled,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectExec("INSERT INTO two_factor").
+				WithArgs(user, []byte(gophtest.TOTPSecret)).
+				WillReturnResult(pgxmock.NewResult("INSERT", tc.affected))
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).TwoFactor
+			err := sat.SetupTOTP(context.Background(), user, []byte(gophtest.TOTPSecret))
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetupTOTPOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("INSERT").
+		WithArgs(user, []byte(gophtest.TOTPSecret)).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).TwoFactor
+	err := sat.SetupTOTP(context.Background(), user, []byte(gophtest.TOTPSecret))
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetTOTP(t *testing.T) {
+	user := uuid.New()
+	expected := entity.TwoFactor{Secret: []byte(gophtest.TOTPSecret), Enabled: true}
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT totp_secret, enabled FROM two_factor").
+		WithArgs(user).
+		WillReturnRows(pgxmock.NewRows([]string{"totp_secret", "enabled"}).
+			AddRow(expected.Secret, expected.Enabled))
+
+	sat := newTestRepos(t, m).TwoFactor
+	tf, err := sat.GetTOTP(context.Background(), user)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, tf)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetTOTPOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get TOTP fails if TOTP is not set up",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name:     "Get TOTP fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(user).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).TwoFactor
+			_, err := sat.GetTOTP(context.Background(), user)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEnableTOTP(t *testing.T) {
+	user := uuid.New()
+	codes := [][]byte{[]byte("first hash"), []byte("second hash")}
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("UPDATE two_factor SET enabled = true, last_step = \\$2").
+		WithArgs(user, int64(42)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("DELETE FROM backup_codes").
+		WithArgs(user).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	for _, hash := range codes {
+		m.ExpectExec("INSERT INTO backup_codes").
+			WithArgs(user, hash).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).TwoFactor
+	err := sat.EnableTOTP(context.Background(), user, 42, codes)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestEnableTOTPOnDBFailure(t *testing.T) {
+	user := uuid.New()
+	codes := [][]byte{[]byte("first hash")}
+
+	tt := []struct {
+		name     string
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name: "Enable TOTP fails if TOTP is not set up",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE").
+					WithArgs(user, int64(42)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name: "Enable TOTP fails if secret is not enabled",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE").
+					WithArgs(user, int64(42)).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Enable TOTP fails if old backup codes are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE").
+					WithArgs(user, int64(42)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("DELETE").
+					WithArgs(user).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Enable TOTP fails if backup code is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE").
+					WithArgs(user, int64(42)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("DELETE").
+					WithArgs(user).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT").
+					WithArgs(user, codes[0]).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).TwoFactor
+			err := sat.EnableTOTP(context.Background(), user, 42, codes)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := uuid.New()
+
+	tt := []struct {
+		name     string
+		affected int64
+		expected error
+	}{
+		{
+			name:     "Use newer TOTP step",
+			affected: 1,
+		},
+		{
+			name:     "Use TOTP step fails if code is replayed",
+			affected: 0,
+			expected: entity.ErrInvalidSecondFactor,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectExec("UPDATE two_factor SET last_step = \\$2 WHERE user_id=\\$1 AND \\(last_step IS NULL OR last_step < \\$2\\)").
+				WithArgs(user, int64(42)).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tc.affected))
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).TwoFactor
+			err := sat.UseTOTPStep(context.Background(), user, 42)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseTOTPStepOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("UPDATE").
+		WithArgs(user, int64(42)).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).TwoFactor
+	err := sat.UseTOTPStep(context.Background(), user, 42)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestDisableTOTP(t *testing.T) {
+	user := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM two_factor").
+		WithArgs(user).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectExec("DELETE FROM backup_codes").
+		WithArgs(user).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).TwoFactor
+	err := sat.DisableTOTP(context.Background(), user)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestDisableTOTPOnDBFailure(t *testing.T) {
+	user := uuid.New()
+
+	tt := []struct {
+		name     string
+		expect   func(m pgxmock.PgxPoolIface)
+		expected error
+	}{
+		{
+			name: "Disable TOTP fails if TOTP is not set up",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM two_factor").
+					WithArgs(user).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name: "Disable TOTP fails if secret is not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM two_factor").
+					WithArgs(user).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+		{
+			name: "Disable TOTP fails if backup codes are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM two_factor").
+					WithArgs(user).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				m.ExpectExec("DELETE FROM backup_codes").
+					WithArgs(user).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).TwoFactor
+			err := sat.DisableTOTP(context.Background(), user)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseBackupCode(t *testing.T) {
+	tt := []struct {
+		name     string
+		affected int64
+		expected error
+	}{
+		{
+			name:     "Backup code is used",
+			affected: 1,
+			expected: nil,
+		},
+		{
+			name:     "Unknown backup code is rejected",
+			affected: 0,
+			expected: entity.ErrInvalidSecondFactor,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user := uuid.New()
+			hash := entity.BackupCode(gophtest.BackupCode).Hash()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectExec("DELETE FROM backup_codes").
+				WithArgs(user, hash).
+				WillReturnResult(pgxmock.NewResult("DELETE", tc.affected))
+
+			if tc.expected == nil {
+				m.ExpectCommit()
+			} else {
+				m.ExpectRollback()
+			}
+
+			sat := newTestRepos(t, m).TwoFactor
+			err := sat.UseBackupCode(context.Background(), user, hash)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseBackupCodeOnDBFailure(t *testing.T) {
+	user := uuid.New()
+	hash := entity.BackupCode(gophtest.BackupCode).Hash()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE").
+		WithArgs(user, hash).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).TwoFactor
+	err := sat.UseBackupCode(context.Background(), user, hash)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
@@ -17,13 +17,25 @@ type UsersRepoMock struct {
 
 func (m *UsersRepoMock) Register(
 	ctx context.Context,
-	username, securityKey string,
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	recovery entity.RecoveryKit,
 ) (uuid.UUID, error) {
-	args := m.Called(ctx, username, securityKey)
+	args := m.Called(ctx, username, verifier, kdf, recovery)
 
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
+func (m *UsersRepoMock) GetKDFParams(
+	ctx context.Context,
+	username string,
+) (entity.KDFParams, bool, error) {
+	args := m.Called(ctx, username)
+
+	return args.Get(0).(entity.KDFParams), args.Bool(1), args.Error(2)
+}
+
 func (m *UsersRepoMock) Verify(
 	ctx context.Context,
 	username, securityKey string,
@@ -32,3 +44,115 @@ func (m *UsersRepoMock) Verify(
 
 	return args.Get(0).(entity.User), args.Error(1)
 }
+
+func (m *UsersRepoMock) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (entity.User, []byte, error) {
+	args := m.Called(ctx, username, recoverySecurityKey)
+
+	return args.Get(0).(entity.User), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *UsersRepoMock) GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	args := m.Called(ctx, id)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersRepoMock) SetVerifier(
+	ctx context.Context,
+	id uuid.UUID,
+	verifier entity.Verifier,
+) error {
+	args := m.Called(ctx, id, verifier)
+
+	return args.Error(0)
+}
+
+func (m *UsersRepoMock) GetUser(ctx context.Context, username string) (entity.User, error) {
+	args := m.Called(ctx, username)
+
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *UsersRepoMock) GetVerifier(
+	ctx context.Context,
+	username string,
+) (entity.User, entity.Verifier, error) {
+	args := m.Called(ctx, username)
+
+	return args.Get(0).(entity.User), args.Get(1).(entity.Verifier), args.Error(2)
+}
+
+func (m *UsersRepoMock) GetVerifierByID(ctx context.Context, id uuid.UUID) (entity.Verifier, error) {
+	args := m.Called(ctx, id)
+
+	return args.Get(0).(entity.Verifier), args.Error(1)
+}
+
+func (m *UsersRepoMock) CreateHandshake(ctx context.Context, handshake entity.Handshake) error {
+	args := m.Called(ctx, handshake)
+
+	return args.Error(0)
+}
+
+func (m *UsersRepoMock) FinishHandshake(ctx context.Context, id uuid.UUID) (entity.Handshake, error) {
+	args := m.Called(ctx, id)
+
+	return args.Get(0).(entity.Handshake), args.Error(1)
+}
+
+func (m *UsersRepoMock) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	recoverySecurityKey string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) error {
+	args := m.Called(
+		ctx,
+		id,
+		recoverySecurityKey,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Error(0)
+}
+
+func (m *UsersRepoMock) Rename(
+	ctx context.Context,
+	id uuid.UUID,
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) error {
+	args := m.Called(
+		ctx,
+		id,
+		username,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Error(0)
+}
+
+func (m *UsersRepoMock) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+
+	return args.Error(0)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
 var _ Users = (*UsersRepo)(nil)
@@ -25,9 +26,13 @@ func NewUsersRepo(
 }
 
 // Register creates a new user.
+// Recovery is not set up if the recovery kit is empty.
 func (r *UsersRepo) Register(
 	ctx context.Context,
-	username, securityKey string,
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	recovery entity.RecoveryKit,
 ) (uuid.UUID, error) {
 	var id uuid.UUID
 
@@ -35,12 +40,40 @@ func (r *UsersRepo) Register(
 		err := tx.QueryRow(
 			ctx,
 			`INSERT INTO
-           users (username, security_key)
+           users (
+               username,
+               srp_salt,
+               srp_verifier,
+               kdf_algorithm,
+               kdf_salt,
+               kdf_time,
+               kdf_memory,
+               kdf_threads,
+               key_schedule,
+               kdf_key_file,
+               recovery_security_key,
+               recovery_vault_key,
+               recovery_key
+           )
        VALUES
-           ($1, crypt($2, gen_salt('bf', 8)))
+           (
+               $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+               crypt(NULLIF($11, ''), gen_salt('bf', 8)), $12, $13
+           )
        RETURNING user_id`,
 			username,
-			securityKey,
+			verifier.Salt,
+			verifier.Verifier,
+			kdf.Algorithm,
+			kdf.Salt,
+			kdf.Time,
+			kdf.Memory,
+			kdf.Threads,
+			kdf.KeySchedule,
+			kdf.KeyFile,
+			recovery.SecurityKey,
+			recovery.VaultKey,
+			recovery.RecoveryKey,
 		).Scan(&id)
 		if err != nil {
 			if postgres.IsEntityExists(err) {
@@ -60,6 +93,215 @@ func (r *UsersRepo) Register(
 	return id, nil
 }
 
+// GetKDFParams returns key derivation parameters of the user
+// and whether the user has no SRP verifier yet.
+func (r *UsersRepo) GetKDFParams(
+	ctx context.Context,
+	username string,
+) (entity.KDFParams, bool, error) {
+	var (
+		kdf    entity.KDFParams
+		legacy bool
+	)
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           kdf_algorithm,
+           kdf_salt,
+           kdf_time,
+           kdf_memory,
+           kdf_threads,
+           key_schedule,
+           kdf_key_file,
+           srp_verifier IS NULL
+       FROM
+           users
+       WHERE username=$1`,
+			username,
+		).
+		Scan(
+			&kdf.Algorithm,
+			&kdf.Salt,
+			&kdf.Time,
+			&kdf.Memory,
+			&kdf.Threads,
+			&kdf.KeySchedule,
+			&kdf.KeyFile,
+			&legacy,
+		)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return kdf, false, entity.ErrUserNotFound
+		}
+
+		return kdf, false, fmt.Errorf("UsersRepo - GetKDFParams - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return kdf, legacy, nil
+}
+
+// GetUser returns the user with provided username.
+func (r *UsersRepo) GetUser(ctx context.Context, username string) (entity.User, error) {
+	var user entity.User
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           user_id, username
+       FROM
+           users
+       WHERE username=$1`,
+			username,
+		).
+		Scan(&user.ID, &user.Username)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return user, entity.ErrUserNotFound
+		}
+
+		return user, fmt.Errorf("UsersRepo - GetUser - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetVerifier returns SRP verifier of the user.
+// Users without verifier are treated as unknown.
+func (r *UsersRepo) GetVerifier(
+	ctx context.Context,
+	username string,
+) (entity.User, entity.Verifier, error) {
+	var (
+		user     entity.User
+		verifier entity.Verifier
+	)
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           user_id, username, srp_salt, srp_verifier
+       FROM
+           users
+       WHERE username=$1 AND srp_verifier IS NOT NULL`,
+			username,
+		).
+		Scan(&user.ID, &user.Username, &verifier.Salt, &verifier.Verifier)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return user, verifier, entity.ErrUserNotFound
+		}
+
+		return user, verifier, fmt.Errorf("UsersRepo - GetVerifier - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return user, verifier, nil
+}
+
+// GetVerifierByID returns SRP verifier of the user with provided ID.
+func (r *UsersRepo) GetVerifierByID(ctx context.Context, id uuid.UUID) (entity.Verifier, error) {
+	var verifier entity.Verifier
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           srp_salt, srp_verifier
+       FROM
+           users
+       WHERE user_id=$1 AND srp_verifier IS NOT NULL`,
+			id,
+		).
+		Scan(&verifier.Salt, &verifier.Verifier)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return verifier, entity.ErrUserNotFound
+		}
+
+		return verifier, fmt.Errorf("UsersRepo - GetVerifierByID - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return verifier, nil
+}
+
+// CreateHandshake saves SRP handshake waiting for the client proof.
+// Expired handshakes are removed on the way.
+func (r *UsersRepo) CreateHandshake(ctx context.Context, handshake entity.Handshake) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           srp_handshakes
+       WHERE expires_at < now()`,
+		)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - CreateHandshake - tx.Exec(delete): %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO
+           srp_handshakes (handshake_id, user_id, client_proof, server_proof, expires_at)
+       VALUES
+           ($1, $2, $3, $4, $5)`,
+			handshake.ID,
+			handshake.User.ID,
+			handshake.ClientProof,
+			handshake.ServerProof,
+			handshake.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - CreateHandshake - tx.Exec(insert): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("UsersRepo - CreateHandshake - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// FinishHandshake removes SRP handshake and returns it,
+// so the same handshake could never be finished twice.
+func (r *UsersRepo) FinishHandshake(ctx context.Context, id uuid.UUID) (entity.Handshake, error) {
+	handshake := entity.Handshake{ID: id}
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`DELETE FROM
+           srp_handshakes h
+       USING
+           users u
+       WHERE h.handshake_id=$1 AND u.user_id = h.user_id
+       RETURNING
+           u.user_id, u.username, h.client_proof, h.server_proof, h.expires_at`,
+			id,
+		).
+		Scan(
+			&handshake.User.ID,
+			&handshake.User.Username,
+			&handshake.ClientProof,
+			&handshake.ServerProof,
+			&handshake.ExpiresAt,
+		)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return handshake, entity.ErrInvalidCredentials
+		}
+
+		return handshake, fmt.Errorf("UsersRepo - FinishHandshake - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return handshake, nil
+}
+
 // Verify checks provided username and security key against data stored in database.
 // Returns entity.User, if verification was successful.
 func (r *UsersRepo) Verify(
@@ -90,3 +332,420 @@ func (r *UsersRepo) Verify(
 
 	return user, nil
 }
+
+// Recover checks provided username and security key derived from the recovery code.
+// Returns entity.User and the vault key wrapped by the recovery key,
+// if verification was successful.
+func (r *UsersRepo) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (entity.User, []byte, error) {
+	var (
+		user     entity.User
+		vaultKey []byte
+	)
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           user_id, username, recovery_vault_key
+       FROM
+           users
+       WHERE username=$1 AND recovery_security_key = crypt($2, recovery_security_key)`,
+			username,
+			recoverySecurityKey,
+		).
+		Scan(&user.ID, &user.Username, &vaultKey)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return user, nil, entity.ErrInvalidCredentials
+		}
+
+		return user, nil, fmt.Errorf("UsersRepo - Recover - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return user, vaultKey, nil
+}
+
+// GetRecoveryKey returns recovery key of the user wrapped by the vault key.
+// Returns nil if recovery is not set up.
+func (r *UsersRepo) GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var recoveryKey []byte
+
+	err := r.pg.Pool.
+		QueryRow(
+			ctx,
+			`SELECT
+           recovery_key
+       FROM
+           users
+       WHERE user_id=$1`,
+			id,
+		).
+		Scan(&recoveryKey)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return nil, entity.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("UsersRepo - GetRecoveryKey - r.pg.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return recoveryKey, nil
+}
+
+// SetVerifier replaces security key of a user registered before SRP with the verifier.
+// The verifier is derived from the auth subkey, so legacy key schedule is upgraded too.
+// Does nothing if the user already has a verifier.
+func (r *UsersRepo) SetVerifier(
+	ctx context.Context,
+	id uuid.UUID,
+	verifier entity.Verifier,
+) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`UPDATE
+           users
+       SET
+           srp_salt = $2,
+           srp_verifier = $3,
+           security_key = NULL,
+           key_schedule = GREATEST(key_schedule, $4)
+       WHERE user_id = $1 AND srp_verifier IS NULL`,
+			id,
+			verifier.Salt,
+			verifier.Verifier,
+			proto.KeySchedule_KEY_SCHEDULE_AUTH_SUBKEY,
+		)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - SetVerifier - tx.Exec: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("UsersRepo - SetVerifier - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword replaces verifier and key derivation parameters of a user
+// together with data keys of all secrets wrapped by the new key
+// and blind indexes of their names.
+// Name and content of a secret are replaced only if provided.
+// The user is verified by the recovery security key, if it is set,
+// otherwise the current master password must be proven by the caller.
+// Fails if secrets were modified since the provided vault version was read
+// or not all secrets of the user were re-encrypted.
+// Recovery kit must be rewrapped if recovery is set up.
+func (r *UsersRepo) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	recoverySecurityKey string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) error {
+	where, args := "user_id = $1", []any{id}
+	if recoverySecurityKey != "" {
+		where += " AND recovery_security_key = crypt($2, recovery_security_key)"
+		args = append(args, recoverySecurityKey)
+	}
+
+	fn := func(tx postgres.Transaction) error {
+		return r.replaceKeys(ctx, tx, id, where, args, "", verifier, kdf, vaultVersion, secrets, recovery)
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("UsersRepo - ChangePassword - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// Rename changes username of a user together with verifier and key derivation parameters,
+// as the encryption key is derived for the new username.
+// Data keys of all secrets must be rewrapped the same way as on password change,
+// the current master password must be proven by the caller.
+// Fails if the new username is taken.
+func (r *UsersRepo) Rename(
+	ctx context.Context,
+	id uuid.UUID,
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) error {
+	fn := func(tx postgres.Transaction) error {
+		return r.replaceKeys(
+			ctx,
+			tx,
+			id,
+			"user_id = $1",
+			[]any{id},
+			username,
+			verifier,
+			kdf,
+			vaultVersion,
+			secrets,
+			recovery,
+		)
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("UsersRepo - Rename - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
+
+// replaceKeys locks the user matching the condition and replaces keys of the vault:
+// verifier and key derivation parameters of the user together with data keys of all secrets.
+// Every secret including trashed ones must be re-encrypted together with all its archived versions,
+// otherwise the vault is considered changed.
+// Username is kept unchanged if empty.
+func (r *UsersRepo) replaceKeys(
+	ctx context.Context,
+	tx postgres.Transaction,
+	id uuid.UUID,
+	where string,
+	args []any,
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) error {
+	var (
+		version     int64
+		hasRecovery bool
+		count       int
+	)
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT
+           vault_version, recovery_key IS NOT NULL
+       FROM
+           users
+       WHERE `+where+`
+       FOR UPDATE`,
+		args...,
+	).Scan(&version, &hasRecovery)
+	if err != nil {
+		if postgres.IsEmptyResponse(err) {
+			return entity.ErrInvalidCredentials
+		}
+
+		return fmt.Errorf("UsersRepo - replaceKeys - tx.QueryRow.Scan(version): %w", err)
+	}
+
+	// Recovery kit was set up or removed since the vault was read.
+	if version != vaultVersion || hasRecovery == recovery.IsEmpty() {
+		return entity.ErrVaultChanged
+	}
+
+	versions := 0
+	for _, secret := range secrets {
+		versions += len(secret.Versions)
+	}
+
+	var versionCount int
+
+	// Trashed secrets and archived versions are re-encrypted too,
+	// so nothing stays encrypted with the old vault key.
+	err = tx.QueryRow(
+		ctx,
+		`SELECT
+           (SELECT count(*) FROM secrets WHERE owner_id = $1),
+           (SELECT
+               count(*)
+           FROM
+               secret_versions v JOIN secrets s ON s.secret_id = v.secret_id
+           WHERE s.owner_id = $1)`,
+		id,
+	).Scan(&count, &versionCount)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - replaceKeys - tx.QueryRow.Scan(count): %w", err)
+	}
+
+	if count != len(secrets) || versionCount != versions {
+		return entity.ErrVaultChanged
+	}
+
+	for _, secret := range secrets {
+		qb := newQueryBuilder("UPDATE secrets").Set().
+			Append("data_key", "=", secret.DataKey).
+			Append("name_index", "=", secret.NameIndex)
+
+		if len(secret.Name) != 0 {
+			qb.Append("name", "=", secret.Name)
+		}
+
+		if len(secret.Data) != 0 {
+			qb.Append("metadata", "=", secret.Metadata).
+				Append("data", "=", secret.Data)
+		}
+
+		// Edits of the secret prepared before the keys were replaced must be rejected.
+		qb.AppendExpr("version = version + 1").
+			Where().
+			Append("secret_id", "=", secret.ID).
+			And().
+			Append("owner_id", "=", id)
+
+		tag, err := tx.Exec(ctx, qb.Query(), qb.Values()...)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - replaceKeys - tx.Exec(secrets): %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrVaultChanged
+		}
+
+		for _, version := range secret.Versions {
+			if err := replaceVersionKeys(ctx, tx, secret.ID, version); err != nil {
+				return err
+			}
+		}
+	}
+
+	userArgs := []any{
+		id,
+		verifier.Salt,
+		verifier.Verifier,
+		kdf.Algorithm,
+		kdf.Salt,
+		kdf.Time,
+		kdf.Memory,
+		kdf.Threads,
+		kdf.KeySchedule,
+		kdf.KeyFile,
+		recovery.VaultKey,
+		recovery.RecoveryKey,
+	}
+
+	rename := ""
+	if username != "" {
+		rename = ", username = $13"
+		userArgs = append(userArgs, username)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE
+           users
+       SET
+           srp_salt = $2,
+           srp_verifier = $3,
+           security_key = NULL,
+           kdf_algorithm = $4,
+           kdf_salt = $5,
+           kdf_time = $6,
+           kdf_memory = $7,
+           kdf_threads = $8,
+           key_schedule = $9,
+           kdf_key_file = $10,
+           recovery_vault_key = $11,
+           recovery_key = $12`+rename+`
+       WHERE user_id = $1`,
+		userArgs...,
+	)
+	if err != nil {
+		if postgres.IsEntityExists(err) {
+			return entity.ErrUserExists
+		}
+
+		return fmt.Errorf("UsersRepo - replaceKeys - tx.Exec(users): %w", err)
+	}
+
+	return nil
+}
+
+// replaceVersionKeys stores archived version of the secret re-encrypted with the new key.
+// The secret is already known to belong to the user.
+func replaceVersionKeys(
+	ctx context.Context,
+	tx postgres.Transaction,
+	secretID uuid.UUID,
+	version entity.ReencryptedVersion,
+) error {
+	qb := newQueryBuilder("UPDATE secret_versions").Set().
+		Append("data_key", "=", version.DataKey).
+		Append("name_index", "=", version.NameIndex)
+
+	if len(version.Name) != 0 {
+		qb.Append("name", "=", version.Name)
+	}
+
+	if len(version.Data) != 0 {
+		qb.Append("metadata", "=", version.Metadata).
+			Append("data", "=", version.Data)
+	}
+
+	qb.Where().
+		Append("secret_id", "=", secretID).
+		And().
+		Append("version", "=", version.Version)
+
+	tag, err := tx.Exec(ctx, qb.Query(), qb.Values()...)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - replaceVersionKeys - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrVaultChanged
+	}
+
+	return nil
+}
+
+// Delete removes the user together with all the secrets.
+// Tokens, sessions and other records of the user are removed by cascade,
+// so the tokens bound to the sessions are rejected from now on.
+func (r *UsersRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	fn := func(tx postgres.Transaction) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           secrets
+       WHERE owner_id = $1`,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - Delete - tx.Exec(secrets): %w", err)
+		}
+
+		tag, err := tx.Exec(
+			ctx,
+			`DELETE FROM
+           users
+       WHERE user_id = $1`,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - Delete - tx.Exec(users): %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return entity.ErrUserNotFound
+		}
+
+		return nil
+	}
+
+	if err := r.pg.RunAtomic(ctx, fn); err != nil {
+		return fmt.Errorf("UsersRepo - Delete - r.pg.RunAtomic: %w", err)
+	}
+
+	return nil
+}
@@ -3,35 +3,102 @@ package repo_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/require"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/postgres"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/proto"
 )
 
+func newTestKDFParams() entity.KDFParams {
+	return entity.KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func kdfParamsArgs(kdf entity.KDFParams) []any {
+	return []any{kdf.Algorithm, kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, kdf.KeySchedule, kdf.KeyFile}
+}
+
+func newTestVerifier() entity.Verifier {
+	return entity.Verifier{
+		Salt:     []byte(gophtest.SRPSalt),
+		Verifier: []byte(gophtest.SRPVerifier),
+	}
+}
+
+func newTestRecoveryKit() entity.RecoveryKit {
+	return entity.RecoveryKit{
+		SecurityKey: gophtest.RecoverySecurityKey,
+		VaultKey:    []byte(gophtest.WrappedVaultKey),
+		RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+	}
+}
+
+func registerUserArgs(kdf entity.KDFParams, recovery entity.RecoveryKit) []any {
+	verifier := newTestVerifier()
+	args := append([]any{gophtest.Username, verifier.Salt, verifier.Verifier}, kdfParamsArgs(kdf)...)
+
+	return append(args, recovery.SecurityKey, recovery.VaultKey, recovery.RecoveryKey)
+}
+
 func TestRegisterUser(t *testing.T) {
-	expected := uuid.New()
+	tt := []struct {
+		name     string
+		recovery entity.RecoveryKit
+	}{
+		{
+			name:     "Register user without recovery",
+			recovery: entity.RecoveryKit{},
+		},
+		{
+			name:     "Register user with recovery",
+			recovery: newTestRecoveryKit(),
+		},
+	}
 
-	rows := pgxmock.NewRows([]string{"id"}).
-		AddRow(expected.String())
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := uuid.New()
 
-	m := newPoolMock(t)
-	m.ExpectBeginTx(postgres.DefaultTxOptions)
-	m.ExpectQuery("INSERT INTO users").
-		WithArgs(gophtest.Username, gophtest.SecurityKey).
-		WillReturnRows(rows)
-	m.ExpectCommit()
+			rows := pgxmock.NewRows([]string{"id"}).
+				AddRow(expected.String())
 
-	sat := newTestRepos(t, m).Users
-	id, err := sat.Register(context.Background(), gophtest.Username, gophtest.SecurityKey)
+			kdf := newTestKDFParams()
 
-	require.NoError(t, err)
-	require.Equal(t, expected, id)
-	require.NoError(t, m.ExpectationsWereMet())
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectQuery("INSERT INTO users").
+				WithArgs(registerUserArgs(kdf, tc.recovery)...).
+				WillReturnRows(rows)
+			m.ExpectCommit()
+
+			sat := newTestRepos(t, m).Users
+			id, err := sat.Register(
+				context.Background(),
+				gophtest.Username,
+				newTestVerifier(),
+				kdf,
+				tc.recovery,
+			)
+
+			require.NoError(t, err)
+			require.Equal(t, expected, id)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
 }
 
 func TestRegisterUserOnDBFailure(t *testing.T) {
@@ -54,15 +121,23 @@ func TestRegisterUserOnDBFailure(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
+			kdf := newTestKDFParams()
+
 			m := newPoolMock(t)
 			m.ExpectBeginTx(postgres.DefaultTxOptions)
 			m.ExpectQuery("INSERT").
-				WithArgs(gophtest.Username, gophtest.SecurityKey).
+				WithArgs(registerUserArgs(kdf, entity.RecoveryKit{})...).
 				WillReturnError(tc.err)
 			m.ExpectRollback()
 
 			sat := newTestRepos(t, m).Users
-			_, err := sat.Register(context.Background(), gophtest.Username, gophtest.SecurityKey)
+			_, err := sat.Register(
+				context.Background(),
+				gophtest.Username,
+				newTestVerifier(),
+				kdf,
+				entity.RecoveryKit{},
+			)
 
 			require.ErrorIs(t, err, tc.expected)
 			require.NoError(t, m.ExpectationsWereMet())
@@ -70,7 +145,92 @@ func TestRegisterUserOnDBFailure(t *testing.T) {
 	}
 }
 
-func TestVerifyUser(t *testing.T) {
+func TestGetKDFParams(t *testing.T) {
+	tt := []struct {
+		name   string
+		legacy bool
+	}{
+		{
+			name:   "Get KDF params of a user with verifier",
+			legacy: false,
+		},
+		{
+			name:   "Get KDF params of a user registered before SRP",
+			legacy: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := newTestKDFParams()
+			expected.KeyFile = true
+
+			rows := pgxmock.NewRows([]string{
+				"kdf_algorithm",
+				"kdf_salt",
+				"kdf_time",
+				"kdf_memory",
+				"kdf_threads",
+				"key_schedule",
+				"kdf_key_file",
+				"legacy",
+			}).
+				AddRow(append(kdfParamsArgs(expected), tc.legacy)...)
+
+			m := newPoolMock(t)
+			m.ExpectQuery(
+				"SELECT kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, key_schedule, kdf_key_file, " +
+					"srp_verifier IS NULL FROM users",
+			).
+				WithArgs(gophtest.Username).
+				WillReturnRows(rows)
+
+			sat := newTestRepos(t, m).Users
+			rv, legacy, err := sat.GetKDFParams(context.Background(), gophtest.Username)
+
+			require.NoError(t, err)
+			require.Equal(t, expected, rv)
+			require.Equal(t, tc.legacy, legacy)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetKDFParamsOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get KDF params fails if user not found",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name:     "Get KDF params fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(gophtest.Username).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, _, err := sat.GetKDFParams(context.Background(), gophtest.Username)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
 	expected := entity.User{
 		ID:       uuid.New(),
 		Username: gophtest.Username,
@@ -81,40 +241,938 @@ func TestVerifyUser(t *testing.T) {
 
 	m := newPoolMock(t)
 	m.ExpectQuery("SELECT user_id, username FROM users").
-		WithArgs(gophtest.Username, gophtest.SecurityKey).
+		WithArgs(gophtest.Username).
 		WillReturnRows(rows)
 
 	sat := newTestRepos(t, m).Users
-	rv, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	user, err := sat.GetUser(context.Background(), gophtest.Username)
 
 	require.NoError(t, err)
-	require.Equal(t, expected, rv)
+	require.Equal(t, expected, user)
 	require.NoError(t, m.ExpectationsWereMet())
 }
 
-func TestVerifyFailsOnBadCredentials(t *testing.T) {
-	rows := pgxmock.NewRows([]string{"user_id", "username"})
+func TestGetUserOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get user fails if user not found",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name:     "Get user fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(gophtest.Username).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, err := sat.GetUser(context.Background(), gophtest.Username)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetVerifier(t *testing.T) {
+	expected := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+	verifier := newTestVerifier()
+
+	rows := pgxmock.NewRows([]string{"user_id", "username", "srp_salt", "srp_verifier"}).
+		AddRow(expected.ID.String(), expected.Username, verifier.Salt, verifier.Verifier)
 
 	m := newPoolMock(t)
-	m.ExpectQuery("SELECT").
-		WithArgs(gophtest.Username, gophtest.SecurityKey).
+	m.ExpectQuery("SELECT user_id, username, srp_salt, srp_verifier FROM users").
+		WithArgs(gophtest.Username).
 		WillReturnRows(rows)
 
 	sat := newTestRepos(t, m).Users
-	_, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	user, rv, err := sat.GetVerifier(context.Background(), gophtest.Username)
 
-	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	require.NoError(t, err)
+	require.Equal(t, expected, user)
+	require.Equal(t, verifier, rv)
 	require.NoError(t, m.ExpectationsWereMet())
 }
 
-func TestVerifyFailsOnDBFailure(t *testing.T) {
+func TestGetVerifierOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get verifier fails if user not found or has no verifier",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name:     "Get verifier fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(gophtest.Username).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, _, err := sat.GetVerifier(context.Background(), gophtest.Username)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetVerifierByID(t *testing.T) {
+	id := uuid.New()
+	expected := newTestVerifier()
+
+	rows := pgxmock.NewRows([]string{"srp_salt", "srp_verifier"}).
+		AddRow(expected.Salt, expected.Verifier)
+
 	m := newPoolMock(t)
-	m.ExpectQuery("SELECT").
-		WithArgs(gophtest.Username, gophtest.SecurityKey).
-		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectQuery("SELECT srp_salt, srp_verifier FROM users").
+		WithArgs(id).
+		WillReturnRows(rows)
 
 	sat := newTestRepos(t, m).Users
-	_, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	rv, err := sat.GetVerifierByID(context.Background(), id)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGetVerifierByIDOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get verifier by ID fails if user not found or has no verifier",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name:     "Get verifier by ID fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(id).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, err := sat.GetVerifierByID(context.Background(), id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func newTestHandshake() entity.Handshake {
+	return entity.Handshake{
+		ID: uuid.New(),
+		User: entity.User{
+			ID:       uuid.New(),
+			Username: gophtest.Username,
+		},
+		ClientProof: []byte(gophtest.ClientProof),
+		ServerProof: []byte(gophtest.ServerProof),
+		ExpiresAt:   time.Now().Add(entity.HandshakeLifeTime),
+	}
+}
+
+func handshakeArgs(h entity.Handshake) []any {
+	return []any{h.ID, h.User.ID, h.ClientProof, h.ServerProof, h.ExpiresAt}
+}
+
+func TestCreateHandshake(t *testing.T) {
+	handshake := newTestHandshake()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM srp_handshakes WHERE expires_at < now()").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectExec("INSERT INTO srp_handshakes").
+		WithArgs(handshakeArgs(handshake)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Users
+	err := sat.CreateHandshake(context.Background(), handshake)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCreateHandshakeOnDBFailure(t *testing.T) {
+	handshake := newTestHandshake()
+
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Create handshake fails if expired handshakes are not removed",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Create handshake fails if handshake is not saved",
+			expect: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT").
+					WithArgs(handshakeArgs(handshake)...).
+					WillReturnError(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			sat := newTestRepos(t, m).Users
+			err := sat.CreateHandshake(context.Background(), handshake)
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFinishHandshake(t *testing.T) {
+	expected := newTestHandshake()
+
+	rows := pgxmock.NewRows([]string{"user_id", "username", "client_proof", "server_proof", "expires_at"}).
+		AddRow(
+			expected.User.ID.String(),
+			expected.User.Username,
+			expected.ClientProof,
+			expected.ServerProof,
+			expected.ExpiresAt,
+		)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("DELETE FROM srp_handshakes h USING users u").
+		WithArgs(expected.ID).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Users
+	rv, err := sat.FinishHandshake(context.Background(), expected.ID)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestFinishHandshakeOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Finish handshake fails if handshake doesn't exist",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Finish handshake fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("DELETE").
+				WithArgs(id).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, err := sat.FinishHandshake(context.Background(), id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	expected := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+
+	rows := pgxmock.NewRows([]string{"user_id", "username"}).
+		AddRow(expected.ID.String(), expected.Username)
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT user_id, username FROM users").
+		WithArgs(gophtest.Username, gophtest.SecurityKey).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Users
+	rv, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestVerifyFailsOnBadCredentials(t *testing.T) {
+	rows := pgxmock.NewRows([]string{"user_id", "username"})
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(gophtest.Username, gophtest.SecurityKey).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Users
+	_, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestVerifyFailsOnDBFailure(t *testing.T) {
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT").
+		WithArgs(gophtest.Username, gophtest.SecurityKey).
+		WillReturnError(gophtest.ErrUnexpected)
+
+	sat := newTestRepos(t, m).Users
+	_, err := sat.Verify(context.Background(), gophtest.Username, gophtest.SecurityKey)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRecoverUser(t *testing.T) {
+	expected := entity.User{
+		ID:       uuid.New(),
+		Username: gophtest.Username,
+	}
+
+	rows := pgxmock.NewRows([]string{"user_id", "username", "recovery_vault_key"}).
+		AddRow(expected.ID.String(), expected.Username, []byte(gophtest.WrappedVaultKey))
+
+	m := newPoolMock(t)
+	m.ExpectQuery("SELECT user_id, username, recovery_vault_key FROM users").
+		WithArgs(gophtest.Username, gophtest.RecoverySecurityKey).
+		WillReturnRows(rows)
+
+	sat := newTestRepos(t, m).Users
+	rv, vaultKey, err := sat.Recover(context.Background(), gophtest.Username, gophtest.RecoverySecurityKey)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, rv)
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), vaultKey)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestRecoverUserOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Recover fails on bad credentials or if recovery is not set up",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Recover fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(gophtest.Username, gophtest.RecoverySecurityKey).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, _, err := sat.Recover(context.Background(), gophtest.Username, gophtest.RecoverySecurityKey)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetRecoveryKey(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected []byte
+	}{
+		{
+			name:     "Get recovery key",
+			expected: []byte(gophtest.WrappedRecoveryKey),
+		},
+		{
+			name:     "Get recovery key if recovery is not set up",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT recovery_key FROM users").
+				WithArgs(id).
+				WillReturnRows(pgxmock.NewRows([]string{"recovery_key"}).AddRow(tc.expected))
+
+			sat := newTestRepos(t, m).Users
+			rv, err := sat.GetRecoveryKey(context.Background(), id)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rv)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetRecoveryKeyOnDBFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Get recovery key fails if user doesn't exist",
+			err:      pgx.ErrNoRows,
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name:     "Get recovery key fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectQuery("SELECT").
+				WithArgs(id).
+				WillReturnError(tc.err)
+
+			sat := newTestRepos(t, m).Users
+			_, err := sat.GetRecoveryKey(context.Background(), id)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func setVerifierArgs(id uuid.UUID) []any {
+	verifier := newTestVerifier()
+
+	return []any{id, verifier.Salt, verifier.Verifier, proto.KeySchedule_KEY_SCHEDULE_AUTH_SUBKEY}
+}
+
+func TestSetVerifier(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("UPDATE users SET srp_salt = \\$2, srp_verifier = \\$3, security_key = NULL").
+		WithArgs(setVerifierArgs(id)...).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	sat := newTestRepos(t, m).Users
+	err := sat.SetVerifier(context.Background(), id, newTestVerifier())
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestSetVerifierOnDBFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("UPDATE users").
+		WithArgs(setVerifierArgs(id)...).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	sat := newTestRepos(t, m).Users
+	err := sat.SetVerifier(context.Background(), id, newTestVerifier())
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func newReencryptedSecrets() []entity.ReencryptedSecret {
+	return []entity.ReencryptedSecret{
+		{
+			ID:        uuid.New(),
+			DataKey:   []byte(gophtest.DataKey),
+			NameIndex: []byte(gophtest.NameIndex),
+			Versions: []entity.ReencryptedVersion{
+				{
+					Version:   1,
+					DataKey:   []byte(gophtest.DataKey),
+					NameIndex: []byte(gophtest.NameIndex),
+				},
+			},
+		},
+		{
+			ID:        uuid.New(),
+			DataKey:   []byte(gophtest.DataKey),
+			Name:      []byte(gophtest.SecretName),
+			NameIndex: []byte(gophtest.NameIndex),
+			Metadata:  []byte(gophtest.Metadata),
+			Data:      []byte(gophtest.TextData),
+		},
+	}
+}
+
+func expectVaultVersion(m pgxmock.PgxPoolIface, id uuid.UUID) *pgxmock.ExpectedQuery {
+	return m.ExpectQuery("SELECT vault_version, recovery_key IS NOT NULL FROM users WHERE user_id = \\$1 FOR UPDATE").
+		WithArgs(id)
+}
+
+func vaultVersionRows(version int64, hasRecovery bool) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"vault_version", "has_recovery"}).AddRow(version, hasRecovery)
+}
+
+func doChangePassword(
+	t *testing.T,
+	id uuid.UUID,
+	secrets []entity.ReencryptedSecret,
+	m pgxmock.PgxPoolIface,
+) error {
+	t.Helper()
+
+	sat := newTestRepos(t, m).Users
+	err := sat.ChangePassword(
+		context.Background(),
+		id,
+		"",
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	)
+
+	require.NoError(t, m.ExpectationsWereMet())
+
+	return err
+}
+
+// expectCountSecrets expects count of secrets of the user and their archived versions to be re-encrypted.
+func expectCountSecrets(m pgxmock.PgxPoolIface, id uuid.UUID, count, versions int) {
+	m.ExpectQuery("SELECT \\(SELECT count").
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"count", "versions"}).AddRow(count, versions))
+}
+
+func TestChangePassword(t *testing.T) {
+	id := uuid.New()
+	secrets := newReencryptedSecrets()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectVaultVersion(m, id).
+		WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+	expectCountSecrets(m, id, len(secrets), 1)
+	m.ExpectExec("UPDATE secrets SET data_key = \\$1, name_index = \\$2, version = version \\+ 1 WHERE").
+		WithArgs(secrets[0].DataKey, secrets[0].NameIndex, secrets[0].ID, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("UPDATE secret_versions SET data_key = \\$1, name_index = \\$2 WHERE").
+		WithArgs(
+			secrets[0].Versions[0].DataKey,
+			secrets[0].Versions[0].NameIndex,
+			secrets[0].ID,
+			secrets[0].Versions[0].Version,
+		).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec(
+		"UPDATE secrets SET data_key = \\$1, name_index = \\$2, name = \\$3, metadata = \\$4, data = \\$5, "+
+			"version = version \\+ 1 WHERE",
+	).
+		WithArgs(
+			secrets[1].DataKey,
+			secrets[1].NameIndex,
+			secrets[1].Name,
+			secrets[1].Metadata,
+			secrets[1].Data,
+			secrets[1].ID,
+			id,
+		).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectExec("UPDATE users").
+		WithArgs(changePasswordArgs(id, entity.RecoveryKit{})...).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	err := doChangePassword(t, id, secrets, m)
+
+	require.NoError(t, err)
+}
+
+func changePasswordArgs(id uuid.UUID, recovery entity.RecoveryKit) []any {
+	verifier := newTestVerifier()
+	args := append([]any{id, verifier.Salt, verifier.Verifier}, kdfParamsArgs(newTestKDFParams())...)
+
+	return append(args, recovery.VaultKey, recovery.RecoveryKey)
+}
+
+func TestChangePasswordWithRecovery(t *testing.T) {
+	tt := []struct {
+		name                string
+		recoverySecurityKey string
+		query               string
+		args                func(id uuid.UUID) []any
+	}{
+		{
+			name:  "Change password rewraps recovery kit",
+			query: "WHERE user_id = \\$1 FOR UPDATE",
+			args: func(id uuid.UUID) []any {
+				return []any{id}
+			},
+		},
+		{
+			name:                "Change password with recovery code",
+			recoverySecurityKey: gophtest.RecoverySecurityKey,
+			query:               "AND recovery_security_key = crypt",
+			args: func(id uuid.UUID) []any {
+				return []any{id, gophtest.RecoverySecurityKey}
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+			recovery := entity.RecoveryKit{
+				VaultKey:    []byte(gophtest.WrappedVaultKey),
+				RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+			}
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			m.ExpectQuery(tc.query).
+				WithArgs(tc.args(id)...).
+				WillReturnRows(vaultVersionRows(gophtest.VaultVersion, true))
+			expectCountSecrets(m, id, 0, 0)
+			m.ExpectExec("UPDATE users").
+				WithArgs(changePasswordArgs(id, recovery)...).
+				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			m.ExpectCommit()
+
+			sat := newTestRepos(t, m).Users
+			err := sat.ChangePassword(
+				context.Background(),
+				id,
+				tc.recoverySecurityKey,
+				newTestVerifier(),
+				newTestKDFParams(),
+				gophtest.VaultVersion,
+				nil,
+				recovery,
+			)
+
+			require.NoError(t, err)
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestChangePasswordWithBadCredentials(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectVaultVersion(m, id).
+		WillReturnError(pgx.ErrNoRows)
+	m.ExpectRollback()
+
+	err := doChangePassword(t, id, newReencryptedSecrets(), m)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+}
+
+func TestChangePasswordOfChangedVault(t *testing.T) {
+	id := uuid.New()
+	secrets := newReencryptedSecrets()
+
+	tt := []struct {
+		name   string
+		expect func(m pgxmock.PgxPoolIface)
+	}{
+		{
+			name: "Change password fails if vault version differs",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion+1, false))
+			},
+		},
+		{
+			name: "Change password fails if recovery kit is not rewrapped",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion, true))
+			},
+		},
+		{
+			name: "Change password fails if not all secrets are re-encrypted",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+				expectCountSecrets(m, id, len(secrets)+1, 1)
+			},
+		},
+		{
+			name: "Change password fails if not all versions are re-encrypted",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+				expectCountSecrets(m, id, len(secrets), 2)
+			},
+		},
+		{
+			name: "Change password fails if secret doesn't exist",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+				expectCountSecrets(m, id, len(secrets), 1)
+				m.ExpectExec("UPDATE secrets").
+					WithArgs(secrets[0].DataKey, secrets[0].NameIndex, secrets[0].ID, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+		},
+		{
+			name: "Change password fails if version doesn't exist",
+			expect: func(m pgxmock.PgxPoolIface) {
+				expectVaultVersion(m, id).
+					WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+				expectCountSecrets(m, id, len(secrets), 1)
+				m.ExpectExec("UPDATE secrets").
+					WithArgs(secrets[0].DataKey, secrets[0].NameIndex, secrets[0].ID, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("UPDATE secret_versions").
+					WithArgs(
+						secrets[0].Versions[0].DataKey,
+						secrets[0].Versions[0].NameIndex,
+						secrets[0].ID,
+						secrets[0].Versions[0].Version,
+					).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			tc.expect(m)
+			m.ExpectRollback()
+
+			err := doChangePassword(t, id, secrets, m)
+
+			require.ErrorIs(t, err, entity.ErrVaultChanged)
+		})
+	}
+}
+
+func TestChangePasswordOnDBFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectVaultVersion(m, id).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	err := doChangePassword(t, id, newReencryptedSecrets(), m)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func doRenameUser(t *testing.T, id uuid.UUID, m pgxmock.PgxPoolIface) error {
+	t.Helper()
+
+	sat := newTestRepos(t, m).Users
+	err := sat.Rename(
+		context.Background(),
+		id,
+		gophtest.Username,
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		nil,
+		entity.RecoveryKit{},
+	)
+
+	require.NoError(t, m.ExpectationsWereMet())
+
+	return err
+}
+
+func expectRenameUser(m pgxmock.PgxPoolIface, id uuid.UUID) *pgxmock.ExpectedExec {
+	expectVaultVersion(m, id).
+		WillReturnRows(vaultVersionRows(gophtest.VaultVersion, false))
+	expectCountSecrets(m, id, 0, 0)
+
+	return m.ExpectExec("UPDATE users SET .*, username = \\$13 WHERE user_id = \\$1").
+		WithArgs(append(changePasswordArgs(id, entity.RecoveryKit{}), gophtest.Username)...)
+}
+
+func TestRenameUser(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	expectRenameUser(m, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	m.ExpectCommit()
+
+	err := doRenameUser(t, id, m)
+
+	require.NoError(t, err)
+}
+
+func TestRenameUserFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "Rename user fails if username is taken",
+			err:      errUniqueViolation,
+			expected: entity.ErrUserExists,
+		},
+		{
+			name:     "Rename user fails on unexpected error",
+			err:      gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := newPoolMock(t)
+			m.ExpectBeginTx(postgres.DefaultTxOptions)
+			expectRenameUser(m, id).
+				WillReturnError(tc.err)
+			m.ExpectRollback()
+
+			err := doRenameUser(t, id, m)
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM secrets").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	m.ExpectExec("DELETE FROM users").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.ExpectCommit()
+
+	err := newTestRepos(t, m).Users.Delete(context.Background(), id)
+
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestDeleteUnexistingUser(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM secrets").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	m.ExpectExec("DELETE FROM users").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	m.ExpectRollback()
+
+	err := newTestRepos(t, m).Users.Delete(context.Background(), id)
+
+	require.ErrorIs(t, err, entity.ErrUserNotFound)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestDeleteUserOnDBFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := newPoolMock(t)
+	m.ExpectBeginTx(postgres.DefaultTxOptions)
+	m.ExpectExec("DELETE FROM secrets").
+		WithArgs(id).
+		WillReturnError(gophtest.ErrUnexpected)
+	m.ExpectRollback()
+
+	err := newTestRepos(t, m).Users.Delete(context.Background(), id)
 
 	require.ErrorIs(t, err, gophtest.ErrUnexpected)
 	require.NoError(t, m.ExpectationsWereMet())
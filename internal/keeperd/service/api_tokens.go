@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+)
+
+var _ APITokens = (*APITokensService)(nil)
+
+// APITokensService contains business logic related to API tokens used by automation.
+type APITokensService struct {
+	apiTokensRepo repo.APITokens
+}
+
+// NewAPITokensService create and initializes new APITokensService object.
+func NewAPITokensService(apiTokens repo.APITokens) *APITokensService {
+	return &APITokensService{apiTokens}
+}
+
+// Create issues new named API token of the user limited by the scope.
+// Zero expiresAt means the token never expires.
+// The token is returned once, only its hash is stored.
+func (uc *APITokensService) Create(
+	ctx context.Context,
+	user entity.User,
+	name string,
+	scope entity.APITokenScope,
+	expiresAt time.Time,
+) (entity.APIToken, entity.APITokenRecord, error) {
+	token, err := entity.NewAPIToken()
+	if err != nil {
+		return "", entity.APITokenRecord{}, fmt.Errorf("APITokensService - Create - entity.NewAPIToken: %w", err)
+	}
+
+	record := entity.APITokenRecord{
+		ID:            uuid.New(),
+		Name:          name,
+		Hash:          token.Hash(),
+		User:          user,
+		APITokenScope: scope,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     expiresAt,
+	}
+
+	if err := uc.apiTokensRepo.Create(ctx, record); err != nil {
+		return "", record, fmt.Errorf("APITokensService - Create - uc.apiTokensRepo.Create: %w", err)
+	}
+
+	return token, record, nil
+}
+
+// List returns active API tokens of the user.
+func (uc *APITokensService) List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error) {
+	tokens, err := uc.apiTokensRepo.List(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("APITokensService - List - uc.apiTokensRepo.List: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke removes API token of the user, so it can't be used anymore.
+func (uc *APITokensService) Revoke(ctx context.Context, user, id uuid.UUID) error {
+	if err := uc.apiTokensRepo.Revoke(ctx, user, id); err != nil {
+		return fmt.Errorf("APITokensService - Revoke - uc.apiTokensRepo.Revoke: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate maps API token to its owner and scope.
+// Unknown and expired tokens are rejected as invalid credentials.
+func (uc *APITokensService) Authenticate(
+	ctx context.Context,
+	token entity.APIToken,
+) (entity.User, entity.APITokenScope, error) {
+	record, err := uc.apiTokensRepo.GetByHash(ctx, token.Hash())
+	if err != nil {
+		if errors.Is(err, entity.ErrAPITokenNotFound) {
+			return entity.User{}, entity.APITokenScope{}, entity.ErrInvalidCredentials
+		}
+
+		return entity.User{}, entity.APITokenScope{}, fmt.Errorf(
+			"APITokensService - Authenticate - uc.apiTokensRepo.GetByHash: %w",
+			err,
+		)
+	}
+
+	if record.IsExpired() {
+		return entity.User{}, entity.APITokenScope{}, entity.ErrInvalidCredentials
+	}
+
+	return record.User, record.APITokenScope, nil
+}
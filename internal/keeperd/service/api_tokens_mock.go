@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+var _ APITokens = (*APITokensServiceMock)(nil)
+
+type APITokensServiceMock struct {
+	mock.Mock
+}
+
+func (m *APITokensServiceMock) Create(
+	ctx context.Context,
+	user entity.User,
+	name string,
+	scope entity.APITokenScope,
+	expiresAt time.Time,
+) (entity.APIToken, entity.APITokenRecord, error) {
+	args := m.Called(ctx, user, name, scope, expiresAt)
+
+	return args.Get(0).(entity.APIToken), args.Get(1).(entity.APITokenRecord), args.Error(2)
+}
+
+func (m *APITokensServiceMock) List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error) {
+	args := m.Called(ctx, user)
+
+	return args.Get(0).([]entity.APITokenRecord), args.Error(1)
+}
+
+func (m *APITokensServiceMock) Revoke(ctx context.Context, user, id uuid.UUID) error {
+	args := m.Called(ctx, user, id)
+
+	return args.Error(0)
+}
+
+func (m *APITokensServiceMock) Authenticate(
+	ctx context.Context,
+	token entity.APIToken,
+) (entity.User, entity.APITokenScope, error) {
+	args := m.Called(ctx, token)
+
+	return args.Get(0).(entity.User), args.Get(1).(entity.APITokenScope), args.Error(2)
+}
@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	scope := entity.APITokenScope{ReadOnly: true, SecretIDs: []uuid.UUID{uuid.New()}}
+	expiresAt := time.Now().Add(time.Hour)
+
+	var saved entity.APITokenRecord
+
+	m := &repo.APITokensRepoMock{}
+	m.On("Create", mock.Anything, mock.AnythingOfType("entity.APITokenRecord")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(entity.APITokenRecord)
+		}).
+		Return(nil)
+
+	sat := service.NewAPITokensService(m)
+	token, record, err := sat.Create(context.Background(), user, gophtest.APITokenName, scope, expiresAt)
+
+	require.NoError(t, err)
+	require.True(t, entity.IsAPIToken(token.String()))
+	require.Equal(t, token.Hash(), saved.Hash)
+	require.Equal(t, saved, record)
+	require.Equal(t, user, record.User)
+	require.Equal(t, gophtest.APITokenName, record.Name)
+	require.Equal(t, scope, record.APITokenScope)
+	require.Equal(t, expiresAt, record.ExpiresAt)
+	require.NotEqual(t, uuid.Nil, record.ID)
+	m.AssertExpectations(t)
+}
+
+func TestCreateAPITokenOnRepoFailure(t *testing.T) {
+	m := &repo.APITokensRepoMock{}
+	m.On("Create", mock.Anything, mock.AnythingOfType("entity.APITokenRecord")).
+		Return(entity.ErrAPITokenExists)
+
+	sat := service.NewAPITokensService(m)
+	_, _, err := sat.Create(
+		context.Background(),
+		entity.User{ID: uuid.New()},
+		gophtest.APITokenName,
+		entity.APITokenScope{},
+		time.Time{},
+	)
+
+	require.ErrorIs(t, err, entity.ErrAPITokenExists)
+	m.AssertExpectations(t)
+}
+
+func TestListAPITokens(t *testing.T) {
+	user := uuid.New()
+	expected := []entity.APITokenRecord{{ID: uuid.New(), Name: gophtest.APITokenName}}
+
+	m := &repo.APITokensRepoMock{}
+	m.On("List", mock.Anything, user).
+		Return(expected, nil)
+
+	tokens, err := service.NewAPITokensService(m).List(context.Background(), user)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, tokens)
+	m.AssertExpectations(t)
+}
+
+func TestListAPITokensOnRepoFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := &repo.APITokensRepoMock{}
+	m.On("List", mock.Anything, user).
+		Return([]entity.APITokenRecord(nil), gophtest.ErrUnexpected)
+
+	_, err := service.NewAPITokensService(m).List(context.Background(), user)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	user, id := uuid.New(), uuid.New()
+
+	m := &repo.APITokensRepoMock{}
+	m.On("Revoke", mock.Anything, user, id).
+		Return(entity.ErrAPITokenNotFound)
+
+	err := service.NewAPITokensService(m).Revoke(context.Background(), user, id)
+
+	require.ErrorIs(t, err, entity.ErrAPITokenNotFound)
+	m.AssertExpectations(t)
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	scope := entity.APITokenScope{ReadOnly: true}
+
+	tt := []struct {
+		name      string
+		record    entity.APITokenRecord
+		repoErr   error
+		expected  error
+		withScope bool
+	}{
+		{
+			name:      "Active token is authenticated",
+			record:    entity.APITokenRecord{User: user, APITokenScope: scope},
+			withScope: true,
+		},
+		{
+			name: "Token before expiration is authenticated",
+			record: entity.APITokenRecord{
+				User:          user,
+				APITokenScope: scope,
+				ExpiresAt:     time.Now().Add(time.Hour),
+			},
+			withScope: true,
+		},
+		{
+			name: "Expired token is rejected",
+			record: entity.APITokenRecord{
+				User:          user,
+				APITokenScope: scope,
+				ExpiresAt:     time.Now().Add(-time.Hour),
+			},
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Unknown token is rejected",
+			repoErr:  entity.ErrAPITokenNotFound,
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Authentication fails on unexpected error",
+			repoErr:  gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			token := entity.APIToken(gophtest.APIToken)
+
+			m := &repo.APITokensRepoMock{}
+			m.On("GetByHash", mock.Anything, token.Hash()).
+				Return(tc.record, tc.repoErr)
+
+			rvUser, rvScope, err := service.NewAPITokensService(m).Authenticate(context.Background(), token)
+
+			require.ErrorIs(t, err, tc.expected)
+
+			if tc.withScope {
+				require.Equal(t, user, rvUser)
+				require.Equal(t, scope, rvScope)
+			}
+
+			m.AssertExpectations(t)
+		})
+	}
+}
@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
@@ -13,31 +17,434 @@ var _ Auth = (*AuthService)(nil)
 
 // AuthService contains business logic related to authentication.
 type AuthService struct {
-	secret    creds.Password
-	usersRepo repo.Users
+	secret        creds.Password
+	totpKey       creds.Password
+	keys          *entity.Keyring
+	usersRepo     repo.Users
+	tokensRepo    repo.Tokens
+	twoFactorRepo repo.TwoFactor
+	throttleRepo  repo.Throttle
+	revoked       *RevocationCache
 }
 
 // NewAuthService create and initializes new AuthService object.
+// TOTP secrets of users are sealed with totpKey, which must differ from the service secret.
+// Checked access tokens are cached in revoked, see IsRevoked.
 func NewAuthService(
 	secret creds.Password,
+	totpKey creds.Password,
+	keys *entity.Keyring,
 	users repo.Users,
+	tokens repo.Tokens,
+	twoFactor repo.TwoFactor,
+	throttle repo.Throttle,
+	revoked *RevocationCache,
 ) *AuthService {
-	return &AuthService{secret, users}
+	return &AuthService{
+		secret:        secret,
+		totpKey:       totpKey,
+		keys:          keys,
+		usersRepo:     users,
+		tokensRepo:    tokens,
+		twoFactorRepo: twoFactor,
+		throttleRepo:  throttle,
+		revoked:       revoked,
+	}
+}
+
+// Prelogin returns key derivation parameters of a user
+// and whether the user must log in with the security key to set the verifier.
+// Fake parameters are returned for unknown users to prevent users enumeration.
+func (uc *AuthService) Prelogin(
+	ctx context.Context,
+	username string,
+) (entity.KDFParams, bool, error) {
+	kdf, legacy, err := uc.usersRepo.GetKDFParams(ctx, username)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return entity.NewFakeKDFParams(username, uc.secret), false, nil
+		}
+
+		return kdf, false, fmt.Errorf("AuthService - Prelogin - uc.usersRepo.GetKDFParams: %w", err)
+	}
+
+	return kdf, legacy, nil
 }
 
+// Login authenticates a user registered before SRP and issues new access and refresh tokens,
+// or the partial token if the user has enabled two-factor authentication.
+// The security key of the user is replaced with the verifier,
+// so it couldn't be replayed later.
+// Failed attempts are throttled per username and per address of the caller.
 func (uc *AuthService) Login(
 	ctx context.Context,
 	username, securityKey string,
-) (entity.AccessToken, error) {
+	verifier entity.Verifier,
+) (entity.TokenPair, error) {
+	keys := throttleKeys(ctx, username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Login - chargeAttempt: %w", err)
+	}
+
 	user, err := uc.usersRepo.Verify(ctx, username, securityKey)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Login - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Login - uc.usersRepo.Verify: %w", err)
+	}
+
+	if err := uc.usersRepo.SetVerifier(ctx, user.ID, verifier); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Login - uc.usersRepo.SetVerifier: %w", err)
+	}
+
+	tokens, err := issueLoginTokens(ctx, uc.tokensRepo, uc.twoFactorRepo, uc.keys, user)
+	if err != nil {
+		return tokens, fmt.Errorf("AuthService - Login - issueLoginTokens: %w", err)
+	}
+
+	if tokens.PartialToken == "" {
+		if err := resetFailures(ctx, uc.throttleRepo, user.Username); err != nil {
+			return entity.TokenPair{}, fmt.Errorf("AuthService - Login - resetFailures: %w", err)
+		}
+	}
+
+	return tokens, nil
+}
+
+// StartLogin starts SRP handshake with the verifier of a user.
+// Challenge with fake verifier is returned for unknown users to prevent users enumeration,
+// such handshake could never be finished.
+// The handshake isn't started if the username or the caller is locked out.
+func (uc *AuthService) StartLogin(
+	ctx context.Context,
+	username string,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	if err := checkThrottle(ctx, uc.throttleRepo, throttleKeys(ctx, username)); err != nil {
+		return entity.Challenge{}, fmt.Errorf("AuthService - StartLogin - checkThrottle: %w", err)
+	}
+
+	user, verifier, err := uc.usersRepo.GetVerifier(ctx, username)
+	if err != nil && !errors.Is(err, entity.ErrUserNotFound) {
+		return entity.Challenge{}, fmt.Errorf("AuthService - StartLogin - uc.usersRepo.GetVerifier: %w", err)
+	}
+
+	known := &user
+	if err != nil {
+		known, verifier = nil, entity.NewFakeVerifier(username, uc.secret)
+	}
+
+	challenge, err := startHandshake(ctx, uc.usersRepo, known, verifier, clientPublic)
+	if err != nil {
+		return challenge, fmt.Errorf("AuthService - StartLogin - startHandshake: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// FinishLogin verifies the client proof and issues new access and refresh tokens,
+// or the partial token if the user has enabled two-factor authentication.
+// Returns the server proof, so client could verify the service as well.
+// Failures are counted for the address of the caller and for the user of the handshake,
+// if it is known, both are checked for lockout.
+func (uc *AuthService) FinishLogin(
+	ctx context.Context,
+	proof entity.Proof,
+) (entity.TokenPair, []byte, error) {
+	keys := throttleKeys(ctx, "")
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - FinishLogin - chargeAttempt: %w", err)
+	}
+
+	handshake, err := uc.finishLoginHandshake(ctx, proof)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - FinishLogin - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - FinishLogin - uc.finishLoginHandshake: %w", err)
+	}
+
+	tokens, err := issueLoginTokens(ctx, uc.tokensRepo, uc.twoFactorRepo, uc.keys, handshake.User)
+	if err != nil {
+		return tokens, nil, fmt.Errorf("AuthService - FinishLogin - issueLoginTokens: %w", err)
+	}
+
+	if tokens.PartialToken == "" {
+		if err := resetFailures(ctx, uc.throttleRepo, handshake.User.Username); err != nil {
+			return entity.TokenPair{}, nil, fmt.Errorf("AuthService - FinishLogin - resetFailures: %w", err)
+		}
+	}
+
+	return tokens, handshake.ServerProof, nil
+}
+
+// finishLoginHandshake verifies the client proof of the handshake,
+// the attempt is counted for the user as soon as the handshake is loaded.
+func (uc *AuthService) finishLoginHandshake(ctx context.Context, proof entity.Proof) (entity.Handshake, error) {
+	handshake, err := uc.usersRepo.FinishHandshake(ctx, proof.HandshakeID)
+	if err != nil {
+		return handshake, fmt.Errorf("finishLoginHandshake - uc.usersRepo.FinishHandshake: %w", err)
+	}
+
+	var keys []entity.ThrottleKey
+	if handshake.User.Username != "" {
+		keys = append(keys, entity.UsernameThrottleKey(handshake.User.Username))
+	}
+
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return handshake, fmt.Errorf("finishLoginHandshake - chargeAttempt: %w", err)
+	}
+
+	err = verifyHandshake(handshake, proof)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return handshake, fmt.Errorf("finishLoginHandshake - settleAttempt: %w", err)
+	}
+
+	return handshake, err
+}
+
+// VerifySecondFactor checks TOTP code or backup code of the user holding the partial token
+// and issues new access and refresh tokens.
+// The partial token is revoked, so it can't be exchanged twice.
+// Wrong codes are throttled together with failed logins of the user.
+func (uc *AuthService) VerifySecondFactor(
+	ctx context.Context,
+	user entity.User,
+	partialToken entity.TokenInfo,
+	code string,
+) (entity.TokenPair, error) {
+	keys := throttleKeys(ctx, user.Username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - VerifySecondFactor - chargeAttempt: %w", err)
+	}
+
+	err := verifySecondFactor(ctx, uc.twoFactorRepo, uc.totpKey, user.ID, code)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidSecondFactor); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - VerifySecondFactor - settleAttempt: %w", err)
+	}
+
 	if err != nil {
-		return "", fmt.Errorf("AuthService - Login - uc.usersRepo.Verify: %w", err)
+		return entity.TokenPair{}, fmt.Errorf("AuthService - VerifySecondFactor - verifySecondFactor: %w", err)
 	}
 
-	accessToken, err := entity.NewAccessToken(user, uc.secret)
+	if err := uc.tokensRepo.RevokeAccessToken(ctx, partialToken); err != nil {
+		return entity.TokenPair{}, fmt.Errorf(
+			"AuthService - VerifySecondFactor - uc.tokensRepo.RevokeAccessToken: %w",
+			err,
+		)
+	}
+
+	uc.revoked.set(partialToken, true)
+
+	tokens, err := issueTokens(ctx, uc.tokensRepo, uc.keys, user)
 	if err != nil {
-		return "", fmt.Errorf("AuthService - Login - entity.NewAccessToken: %w", err)
+		return tokens, fmt.Errorf("AuthService - VerifySecondFactor - issueTokens: %w", err)
 	}
 
-	return accessToken, nil
+	if err := resetFailures(ctx, uc.throttleRepo, user.Username); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - VerifySecondFactor - resetFailures: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// AuthenticateCertificate maps subject of the verified client certificate to a user.
+// The common name of the subject must be the username.
+// Such users don't need access tokens, as the certificate is checked on every connection.
+func (uc *AuthService) AuthenticateCertificate(ctx context.Context, subject string) (entity.User, error) {
+	user, err := uc.usersRepo.GetUser(ctx, subject)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return user, entity.ErrInvalidCredentials
+		}
+
+		return user, fmt.Errorf("AuthService - AuthenticateCertificate - uc.usersRepo.GetUser: %w", err)
+	}
+
+	return user, nil
+}
+
+// Refresh exchanges the refresh token for new access token and the next refresh token.
+// The refresh token can't be used twice, the whole family of tokens is revoked
+// if a used token is presented, as either the client or an attacker holds a stolen copy.
+func (uc *AuthService) Refresh(
+	ctx context.Context,
+	refreshToken entity.RefreshToken,
+) (entity.TokenPair, error) {
+	next, err := entity.NewRefreshToken()
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Refresh - entity.NewRefreshToken: %w", err)
+	}
+
+	record, err := uc.tokensRepo.RotateRefreshToken(
+		ctx,
+		refreshToken.Hash(),
+		entity.RefreshTokenRecord{
+			Hash:      next.Hash(),
+			ExpiresAt: time.Now().Add(entity.RefreshTokenLifeTime),
+		},
+	)
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Refresh - uc.tokensRepo.RotateRefreshToken: %w", err)
+	}
+
+	accessToken, err := entity.NewSessionAccessToken(record.User, record.FamilyID, uc.keys)
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("AuthService - Refresh - entity.NewSessionAccessToken: %w", err)
+	}
+
+	return entity.TokenPair{AccessToken: accessToken, RefreshToken: next}, nil
+}
+
+// Recover authenticates a user with the security key derived from the recovery code.
+// Issues new access and refresh tokens the same way as login and returns the vault key
+// wrapped by the recovery key, so client could set a new master password.
+// If the user has enabled two-factor authentication, only the partial token is issued
+// and the vault key is withheld until the second factor is verified, see RecoverVaultKey.
+// Failed attempts are throttled together with failed logins.
+func (uc *AuthService) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (entity.TokenPair, []byte, error) {
+	keys := throttleKeys(ctx, username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - Recover - chargeAttempt: %w", err)
+	}
+
+	user, vaultKey, err := uc.usersRepo.Recover(ctx, username, recoverySecurityKey)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - Recover - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - Recover - uc.usersRepo.Recover: %w", err)
+	}
+
+	tokens, err := issueLoginTokens(ctx, uc.tokensRepo, uc.twoFactorRepo, uc.keys, user)
+	if err != nil {
+		return tokens, nil, fmt.Errorf("AuthService - Recover - issueLoginTokens: %w", err)
+	}
+
+	if tokens.PartialToken != "" {
+		return tokens, nil, nil
+	}
+
+	if err := resetFailures(ctx, uc.throttleRepo, user.Username); err != nil {
+		return entity.TokenPair{}, nil, fmt.Errorf("AuthService - Recover - resetFailures: %w", err)
+	}
+
+	return tokens, vaultKey, nil
+}
+
+// RecoverVaultKey returns the vault key wrapped by the recovery key to the user
+// who has passed the second factor after Recover.
+// The security key derived from the recovery code is verified again,
+// so the access token alone doesn't reveal the wrapped key.
+// Failed attempts are throttled together with failed logins.
+func (uc *AuthService) RecoverVaultKey(
+	ctx context.Context,
+	user entity.User,
+	recoverySecurityKey string,
+) ([]byte, error) {
+	keys := throttleKeys(ctx, user.Username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return nil, fmt.Errorf("AuthService - RecoverVaultKey - chargeAttempt: %w", err)
+	}
+
+	recovered, vaultKey, err := uc.usersRepo.Recover(ctx, user.Username, recoverySecurityKey)
+	if err == nil && recovered.ID != user.ID {
+		err = entity.ErrInvalidCredentials
+	}
+
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return nil, fmt.Errorf("AuthService - RecoverVaultKey - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("AuthService - RecoverVaultKey - uc.usersRepo.Recover: %w", err)
+	}
+
+	return vaultKey, nil
+}
+
+// Logout revokes the access token of the user before expiration.
+// If the token belongs to a login session, the whole session is revoked,
+// so every access and refresh token issued since login is rejected.
+// Otherwise, if the refresh token is provided, its whole family is revoked as well,
+// so the session couldn't be prolonged.
+func (uc *AuthService) Logout(
+	ctx context.Context,
+	user uuid.UUID,
+	token entity.TokenInfo,
+	refreshToken entity.RefreshToken,
+) error {
+	switch {
+	case token.SessionID != uuid.Nil:
+		if err := uc.tokensRepo.RevokeSession(ctx, user, token.SessionID); err != nil {
+			return fmt.Errorf("AuthService - Logout - uc.tokensRepo.RevokeSession: %w", err)
+		}
+
+		uc.revoked.forgetSession(token.SessionID)
+
+	case refreshToken != "":
+		if err := uc.tokensRepo.RevokeRefreshTokenFamily(ctx, user, refreshToken.Hash()); err != nil {
+			return fmt.Errorf("AuthService - Logout - uc.tokensRepo.RevokeRefreshTokenFamily: %w", err)
+		}
+	}
+
+	if err := uc.tokensRepo.RevokeAccessToken(ctx, token); err != nil {
+		return fmt.Errorf("AuthService - Logout - uc.tokensRepo.RevokeAccessToken: %w", err)
+	}
+
+	uc.revoked.set(token, true)
+
+	return nil
+}
+
+// ListSessions returns active login sessions of the user.
+func (uc *AuthService) ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error) {
+	sessions, err := uc.tokensRepo.ListSessions(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("AuthService - ListSessions - uc.tokensRepo.ListSessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends the login session of the user, e.g. on a lost device.
+// Refresh tokens of the session are removed and its access tokens are rejected from now on.
+func (uc *AuthService) RevokeSession(ctx context.Context, user, id uuid.UUID) error {
+	if err := uc.tokensRepo.RevokeSession(ctx, user, id); err != nil {
+		return fmt.Errorf("AuthService - RevokeSession - uc.tokensRepo.RevokeSession: %w", err)
+	}
+
+	uc.revoked.forgetSession(id)
+
+	return nil
+}
+
+// IsRevoked tells whether the access token has been revoked, by itself or together with its session.
+// The answer is cached, see RevocationCacheTTL.
+func (uc *AuthService) IsRevoked(ctx context.Context, token entity.TokenInfo) (bool, error) {
+	if revoked, ok := uc.revoked.get(token.ID); ok {
+		return revoked, nil
+	}
+
+	revoked, err := uc.tokensRepo.IsAccessTokenRevoked(ctx, token)
+	if err != nil {
+		return false, fmt.Errorf("AuthService - IsRevoked - uc.tokensRepo.IsAccessTokenRevoked: %w", err)
+	}
+
+	uc.revoked.set(token, revoked)
+
+	return revoked, nil
+}
+
+// PublicKeys returns public keys verifying access tokens issued by the service.
+// Empty if tokens are signed with the service secret.
+func (uc *AuthService) PublicKeys() []entity.JSONWebKey {
+	return uc.keys.PublicKeys()
 }
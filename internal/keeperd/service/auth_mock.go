@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
@@ -14,11 +15,120 @@ type AuthServiceMock struct {
 	mock.Mock
 }
 
+func (m *AuthServiceMock) Prelogin(
+	ctx context.Context,
+	username string,
+) (entity.KDFParams, bool, error) {
+	args := m.Called(ctx, username)
+
+	return args.Get(0).(entity.KDFParams), args.Bool(1), args.Error(2)
+}
+
 func (m *AuthServiceMock) Login(
 	ctx context.Context,
 	username, securityKey string,
-) (entity.AccessToken, error) {
-	args := m.Called(ctx, username, securityKey)
+	verifier entity.Verifier,
+) (entity.TokenPair, error) {
+	args := m.Called(ctx, username, securityKey, verifier)
+
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+func (m *AuthServiceMock) StartLogin(
+	ctx context.Context,
+	username string,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	args := m.Called(ctx, username, clientPublic)
+
+	return args.Get(0).(entity.Challenge), args.Error(1)
+}
+
+func (m *AuthServiceMock) FinishLogin(
+	ctx context.Context,
+	proof entity.Proof,
+) (entity.TokenPair, []byte, error) {
+	args := m.Called(ctx, proof)
+
+	return args.Get(0).(entity.TokenPair), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *AuthServiceMock) AuthenticateCertificate(ctx context.Context, subject string) (entity.User, error) {
+	args := m.Called(ctx, subject)
+
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *AuthServiceMock) Refresh(
+	ctx context.Context,
+	refreshToken entity.RefreshToken,
+) (entity.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+func (m *AuthServiceMock) Recover(
+	ctx context.Context,
+	username, recoverySecurityKey string,
+) (entity.TokenPair, []byte, error) {
+	args := m.Called(ctx, username, recoverySecurityKey)
+
+	return args.Get(0).(entity.TokenPair), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *AuthServiceMock) RecoverVaultKey(
+	ctx context.Context,
+	user entity.User,
+	recoverySecurityKey string,
+) ([]byte, error) {
+	args := m.Called(ctx, user, recoverySecurityKey)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *AuthServiceMock) Logout(
+	ctx context.Context,
+	user uuid.UUID,
+	token entity.TokenInfo,
+	refreshToken entity.RefreshToken,
+) error {
+	args := m.Called(ctx, user, token, refreshToken)
+
+	return args.Error(0)
+}
+
+func (m *AuthServiceMock) ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error) {
+	args := m.Called(ctx, user)
+
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *AuthServiceMock) RevokeSession(ctx context.Context, user, id uuid.UUID) error {
+	args := m.Called(ctx, user, id)
+
+	return args.Error(0)
+}
+
+func (m *AuthServiceMock) IsRevoked(ctx context.Context, token entity.TokenInfo) (bool, error) {
+	args := m.Called(ctx, token)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *AuthServiceMock) VerifySecondFactor(
+	ctx context.Context,
+	user entity.User,
+	partialToken entity.TokenInfo,
+	code string,
+) (entity.TokenPair, error) {
+	args := m.Called(ctx, user, partialToken, code)
+
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+func (m *AuthServiceMock) PublicKeys() []entity.JSONWebKey {
+	args := m.Called()
 
-	return args.Get(0).(entity.AccessToken), args.Error(1)
+	return args.Get(0).([]entity.JSONWebKey)
 }
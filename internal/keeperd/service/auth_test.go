@@ -3,12 +3,15 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -16,9 +19,72 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
 )
 
-func doLogin(t *testing.T, repoErr error) (entity.AccessToken, error) {
+func doPrelogin(
+	t *testing.T,
+	repoRV entity.KDFParams,
+	repoLegacy bool,
+	repoErr error,
+) (entity.KDFParams, bool, error) {
 	t.Helper()
 
+	m := &repo.UsersRepoMock{}
+	m.On("GetKDFParams", mock.Anything, gophtest.Username).
+		Return(repoRV, repoLegacy, repoErr)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	kdf, legacy, err := sat.Prelogin(context.Background(), gophtest.Username)
+
+	m.AssertExpectations(t)
+
+	return kdf, legacy, err
+}
+
+func TestPrelogin(t *testing.T) {
+	expected := newTestKDFParams()
+
+	kdf, legacy, err := doPrelogin(t, expected, false, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, kdf)
+	require.False(t, legacy)
+}
+
+func TestPreloginOfLegacyUser(t *testing.T) {
+	_, legacy, err := doPrelogin(t, newTestKDFParams(), true, nil)
+
+	require.NoError(t, err)
+	require.True(t, legacy)
+}
+
+func TestPreloginOfUnknownUser(t *testing.T) {
+	kdf, legacy, err := doPrelogin(t, entity.KDFParams{}, false, entity.ErrUserNotFound)
+
+	require.NoError(t, err)
+	require.Equal(t, entity.NewFakeKDFParams(gophtest.Username, gophtest.Secret), kdf)
+	require.False(t, legacy)
+}
+
+func TestPreloginOnRepoFailure(t *testing.T) {
+	_, _, err := doPrelogin(t, entity.KDFParams{}, false, gophtest.ErrUnexpected)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func doLogin(t *testing.T, verifyErr, repoErr error) (entity.TokenPair, *entity.RefreshTokenRecord, error) {
+	t.Helper()
+
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	verifier := newTestVerifier()
+
 	m := &repo.UsersRepoMock{}
 	m.On(
 		"Verify",
@@ -26,25 +92,991 @@ func doLogin(t *testing.T, repoErr error) (entity.AccessToken, error) {
 		gophtest.Username,
 		gophtest.SecurityKey,
 	).
-		Return(entity.User{ID: uuid.New(), Username: gophtest.Username}, repoErr)
+		Return(user, verifyErr)
+
+	if verifyErr == nil {
+		m.On("SetVerifier", mock.Anything, user.ID, verifier).
+			Return(repoErr)
+	}
 
-	sat := service.NewAuthService(gophtest.Secret, m)
-	accessToken, err := sat.Login(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	tokensMock := &repo.TokensRepoMock{}
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+
+	var saved *entity.RefreshTokenRecord
+	if verifyErr == nil && repoErr == nil {
+		expectSecondFactor(twoFactorMock, user.ID, false)
+		saved = expectRefreshToken(tokensMock, user, nil)
+	}
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	tokens, err := sat.Login(
+		context.Background(),
+		gophtest.Username,
+		gophtest.SecurityKey,
+		verifier,
+	)
 
 	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
 
-	return accessToken, err
+	return tokens, saved, err
 }
 
-func TestLogin(t *testing.T) {
-	token, err := doLogin(t, nil)
+func TestLoginSetsVerifier(t *testing.T) {
+	tokens, saved, err := doLogin(t, nil, nil)
 
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+	require.Equal(t, tokens.RefreshToken.Hash(), saved.Hash)
 }
 
 func TestLoginOnBadCredentials(t *testing.T) {
-	_, err := doLogin(t, entity.ErrInvalidCredentials)
+	_, _, err := doLogin(t, entity.ErrInvalidCredentials, nil)
 
 	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
 }
+
+func TestLoginOnSetVerifierFailure(t *testing.T) {
+	_, _, err := doLogin(t, nil, gophtest.ErrUnexpected)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func TestLoginHandshake(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	verifier := newTestVerifier()
+
+	var saved entity.Handshake
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifier", mock.Anything, gophtest.Username).
+		Return(user, verifier, nil)
+	m.On("CreateHandshake", mock.Anything, mock.AnythingOfType("entity.Handshake")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(entity.Handshake)
+		}).
+		Return(nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	issued := expectRefreshToken(tokensMock, user, nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, false)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	challenge, err := sat.StartLogin(context.Background(), gophtest.Username, client.Public())
+	require.NoError(t, err)
+	require.Equal(t, verifier.Salt, challenge.Salt)
+	require.Equal(t, challenge.HandshakeID, saved.ID)
+	require.Equal(t, user, saved.User)
+
+	clientProof, err := client.Proof(challenge.Salt, []byte(gophtest.AuthKey), challenge.ServerPublic)
+	require.NoError(t, err)
+
+	m.On("FinishHandshake", mock.Anything, challenge.HandshakeID).
+		Return(saved, nil)
+
+	tokens, serverProof, err := sat.FinishLogin(
+		context.Background(),
+		entity.Proof{HandshakeID: challenge.HandshakeID, ClientProof: clientProof},
+	)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.Equal(t, tokens.RefreshToken.Hash(), issued.Hash)
+	require.NoError(t, client.VerifyServer(serverProof))
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+}
+
+func TestStartLoginOfUnknownUser(t *testing.T) {
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifier", mock.Anything, gophtest.Username).
+		Return(entity.User{}, entity.Verifier{}, entity.ErrUserNotFound)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	challenge, err := sat.StartLogin(context.Background(), gophtest.Username, client.Public())
+
+	require.NoError(t, err)
+	require.Equal(t, entity.NewFakeVerifier(gophtest.Username, gophtest.Secret).Salt, challenge.Salt)
+	require.NotEmpty(t, challenge.ServerPublic)
+	m.AssertExpectations(t)
+}
+
+func TestStartLoginWithInvalidPublic(t *testing.T) {
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifier", mock.Anything, gophtest.Username).
+		Return(entity.User{ID: uuid.New()}, newTestVerifier(), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, err := sat.StartLogin(context.Background(), gophtest.Username, make([]byte, srp.PublicLength))
+
+	require.ErrorIs(t, err, srp.ErrInvalidPublic)
+	m.AssertExpectations(t)
+}
+
+func TestStartLoginOnRepoFailure(t *testing.T) {
+	tt := []struct {
+		name   string
+		expect func(m *repo.UsersRepoMock)
+	}{
+		{
+			name: "Start login fails if verifier is not loaded",
+			expect: func(m *repo.UsersRepoMock) {
+				m.On("GetVerifier", mock.Anything, gophtest.Username).
+					Return(entity.User{}, entity.Verifier{}, gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Start login fails if handshake is not saved",
+			expect: func(m *repo.UsersRepoMock) {
+				m.On("GetVerifier", mock.Anything, gophtest.Username).
+					Return(entity.User{ID: uuid.New()}, newTestVerifier(), nil)
+				m.On("CreateHandshake", mock.Anything, mock.AnythingOfType("entity.Handshake")).
+					Return(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := srp.NewClient()
+			require.NoError(t, err)
+
+			m := &repo.UsersRepoMock{}
+			tc.expect(m)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			_, err = sat.StartLogin(context.Background(), gophtest.Username, client.Public())
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFinishLoginFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		mutate   func(h *entity.Handshake, proof *entity.Proof)
+		repoErr  error
+		expected error
+	}{
+		{
+			name:     "Finish login fails if handshake doesn't exist",
+			mutate:   func(_ *entity.Handshake, _ *entity.Proof) {},
+			repoErr:  entity.ErrInvalidCredentials,
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name: "Finish login fails if handshake expired",
+			mutate: func(h *entity.Handshake, _ *entity.Proof) {
+				h.ExpiresAt = time.Now().Add(-time.Second)
+			},
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name: "Finish login fails on wrong proof",
+			mutate: func(_ *entity.Handshake, proof *entity.Proof) {
+				proof.ClientProof = []byte(gophtest.ServerProof)
+			},
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Finish login fails on unexpected error",
+			mutate:   func(_ *entity.Handshake, _ *entity.Proof) {},
+			repoErr:  gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handshake := newTestHandshake(entity.User{ID: uuid.New(), Username: gophtest.Username})
+			proof := newTestProof(handshake)
+			tc.mutate(&handshake, &proof)
+
+			m := &repo.UsersRepoMock{}
+			m.On("FinishHandshake", mock.Anything, handshake.ID).
+				Return(handshake, tc.repoErr)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			token, serverProof, err := sat.FinishLogin(context.Background(), proof)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.Empty(t, token)
+			require.Nil(t, serverProof)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFinishLoginOnRefreshTokenFailure(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	expectRefreshToken(tokensMock, user, gophtest.ErrUnexpected)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, false)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, _, err := sat.FinishLogin(context.Background(), newTestProof(handshake))
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+}
+
+func TestRefresh(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	session := uuid.New()
+
+	var next entity.RefreshTokenRecord
+
+	m := &repo.TokensRepoMock{}
+	m.On(
+		"RotateRefreshToken",
+		mock.Anything,
+		entity.RefreshToken(gophtest.RefreshToken).Hash(),
+		mock.AnythingOfType("entity.RefreshTokenRecord"),
+	).
+		Run(func(args mock.Arguments) {
+			next = args.Get(2).(entity.RefreshTokenRecord)
+		}).
+		Return(entity.RefreshTokenRecord{FamilyID: session, User: user}, nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		m,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	tokens, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+	require.NoError(t, err)
+	require.NotEqual(t, entity.RefreshToken(gophtest.RefreshToken), tokens.RefreshToken)
+	require.Equal(t, tokens.RefreshToken.Hash(), next.Hash)
+	require.True(t, next.ExpiresAt.After(time.Now()))
+
+	claims, err := tokens.AccessToken.Decode(newTestKeyring())
+	require.NoError(t, err)
+	require.Equal(t, user.ID.String(), claims.Subject)
+	require.Equal(t, session.String(), claims.SessionID)
+	m.AssertExpectations(t)
+}
+
+func TestRefreshFailure(t *testing.T) {
+	tt := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "Refresh fails on invalid token",
+			err:  entity.ErrInvalidRefreshToken,
+		},
+		{
+			name: "Refresh fails on reused token",
+			err:  entity.ErrRefreshTokenReused,
+		},
+		{
+			name: "Refresh fails on unexpected error",
+			err:  gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.TokensRepoMock{}
+			m.On("RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything).
+				Return(entity.RefreshTokenRecord{}, tc.err)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				m,
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			tokens, err := sat.Refresh(context.Background(), gophtest.RefreshToken)
+
+			require.ErrorIs(t, err, tc.err)
+			require.Empty(t, tokens)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func doRecover(t *testing.T, twoFactor bool, repoErr error) (entity.TokenPair, []byte, error) {
+	t.Helper()
+
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	m := &repo.UsersRepoMock{}
+	m.On(
+		"Recover",
+		mock.Anything,
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	).
+		Return(user, []byte(gophtest.WrappedVaultKey), repoErr)
+
+	tokensMock := &repo.TokensRepoMock{}
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+
+	if repoErr == nil {
+		expectSecondFactor(twoFactorMock, user.ID, twoFactor)
+
+		if !twoFactor {
+			expectRefreshToken(tokensMock, user, nil)
+		}
+	}
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	tokens, vaultKey, err := sat.Recover(
+		context.Background(),
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	)
+
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+	twoFactorMock.AssertExpectations(t)
+
+	return tokens, vaultKey, err
+}
+
+func TestRecover(t *testing.T) {
+	tokens, vaultKey, err := doRecover(t, false, nil)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+	require.Empty(t, tokens.PartialToken)
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), vaultKey)
+
+	claims, err := tokens.AccessToken.Decode(newTestKeyring())
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.SessionID)
+}
+
+func TestRecoverWithSecondFactor(t *testing.T) {
+	tokens, vaultKey, err := doRecover(t, true, nil)
+
+	require.NoError(t, err)
+	require.Empty(t, tokens.AccessToken)
+	require.Empty(t, tokens.RefreshToken)
+	require.Nil(t, vaultKey)
+
+	claims, err := tokens.PartialToken.Decode(newTestKeyring())
+	require.NoError(t, err)
+	require.True(t, claims.Partial)
+}
+
+func TestRecoverOnBadCredentials(t *testing.T) {
+	_, _, err := doRecover(t, false, entity.ErrInvalidCredentials)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+}
+
+func TestLogout(t *testing.T) {
+	user := uuid.New()
+	token := newTestTokenInfo()
+
+	m := &repo.TokensRepoMock{}
+	m.On(
+		"RevokeRefreshTokenFamily",
+		mock.Anything,
+		user,
+		entity.RefreshToken(gophtest.RefreshToken).Hash(),
+	).
+		Return(nil)
+	m.On("RevokeAccessToken", mock.Anything, token).
+		Return(nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		m,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	err := sat.Logout(context.Background(), user, token, gophtest.RefreshToken)
+	require.NoError(t, err)
+
+	// Revocation is cached locally, so the store is not consulted.
+	revoked, err := sat.IsRevoked(context.Background(), token)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	m.AssertExpectations(t)
+}
+
+func TestLogoutWithoutRefreshToken(t *testing.T) {
+	token := newTestTokenInfo()
+
+	m := &repo.TokensRepoMock{}
+	m.On("RevokeAccessToken", mock.Anything, token).
+		Return(nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		m,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	err := sat.Logout(context.Background(), uuid.New(), token, "")
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	user := uuid.New()
+	token := newTestTokenInfo()
+	token.SessionID = uuid.New()
+
+	// Token issued earlier in the same session, e.g. before refresh.
+	earlier := newTestTokenInfo()
+	earlier.SessionID = token.SessionID
+
+	m := &repo.TokensRepoMock{}
+	m.On("IsAccessTokenRevoked", mock.Anything, earlier).
+		Return(false, nil).
+		Once()
+	m.On("RevokeSession", mock.Anything, user, token.SessionID).
+		Return(nil)
+	m.On("RevokeAccessToken", mock.Anything, token).
+		Return(nil)
+	m.On("IsAccessTokenRevoked", mock.Anything, earlier).
+		Return(true, nil).
+		Once()
+
+	sat := newTestAuthService(m)
+
+	revoked, err := sat.IsRevoked(context.Background(), earlier)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = sat.Logout(context.Background(), user, token, gophtest.RefreshToken)
+	require.NoError(t, err)
+
+	revoked, err = sat.IsRevoked(context.Background(), earlier)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	m.AssertExpectations(t)
+}
+
+func TestLogoutOnRepoFailure(t *testing.T) {
+	tt := []struct {
+		name        string
+		withSession bool
+		setup       func(m *repo.TokensRepoMock)
+	}{
+		{
+			name: "Logout fails if refresh token family is not revoked",
+			setup: func(m *repo.TokensRepoMock) {
+				m.On("RevokeRefreshTokenFamily", mock.Anything, mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name:        "Logout fails if session is not revoked",
+			withSession: true,
+			setup: func(m *repo.TokensRepoMock) {
+				m.On("RevokeSession", mock.Anything, mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Logout fails if access token is not revoked",
+			setup: func(m *repo.TokensRepoMock) {
+				m.On("RevokeRefreshTokenFamily", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil)
+				m.On("RevokeAccessToken", mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.TokensRepoMock{}
+			tc.setup(m)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				m,
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			token := newTestTokenInfo()
+			if tc.withSession {
+				token.SessionID = uuid.New()
+			}
+
+			err := sat.Logout(context.Background(), uuid.New(), token, gophtest.RefreshToken)
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsRevoked(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected bool
+	}{
+		{
+			name:     "Revoked token is reported",
+			expected: true,
+		},
+		{
+			name:     "Valid token is not reported",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			token := newTestTokenInfo()
+
+			m := &repo.TokensRepoMock{}
+			m.On("IsAccessTokenRevoked", mock.Anything, token).
+				Return(tc.expected, nil).
+				Once()
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				m,
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+
+			// The second check is answered from cache.
+			for i := 0; i < 2; i++ {
+				revoked, err := sat.IsRevoked(context.Background(), token)
+
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, revoked)
+			}
+
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsRevokedOnRepoFailure(t *testing.T) {
+	token := newTestTokenInfo()
+
+	m := &repo.TokensRepoMock{}
+	m.On("IsAccessTokenRevoked", mock.Anything, token).
+		Return(false, gophtest.ErrUnexpected).
+		Twice()
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		m,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+
+	// Failures are not cached.
+	for i := 0; i < 2; i++ {
+		_, err := sat.IsRevoked(context.Background(), token)
+
+		require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	}
+
+	m.AssertExpectations(t)
+}
+
+func TestFinishLoginWithSecondFactor(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, true)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	tokens, _, err := sat.FinishLogin(context.Background(), newTestProof(handshake))
+
+	require.NoError(t, err)
+	require.Empty(t, tokens.AccessToken)
+	require.Empty(t, tokens.RefreshToken)
+
+	claims, err := tokens.PartialToken.Decode(newTestKeyring())
+	require.NoError(t, err)
+	require.True(t, claims.Partial)
+	m.AssertExpectations(t)
+	twoFactorMock.AssertExpectations(t)
+}
+
+func TestFinishLoginOnSecondFactorFailure(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	twoFactorMock.On("GetTOTP", mock.Anything, user.ID).
+		Return(entity.TwoFactor{}, gophtest.ErrUnexpected)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, _, err := sat.FinishLogin(context.Background(), newTestProof(handshake))
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+	twoFactorMock.AssertExpectations(t)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	tt := []struct {
+		name  string
+		code  string
+		setup func(m *repo.TwoFactorRepoMock, user uuid.UUID)
+	}{
+		{
+			name: "TOTP code is accepted",
+			code: totp.Code([]byte(gophtest.TOTPSecret), time.Now()),
+			setup: func(m *repo.TwoFactorRepoMock, user uuid.UUID) {
+				expectTOTPStep(m, user, nil)
+			},
+		},
+		{
+			name: "Backup code is accepted",
+			code: gophtest.BackupCode,
+			setup: func(m *repo.TwoFactorRepoMock, user uuid.UUID) {
+				m.On("UseBackupCode", mock.Anything, user, entity.BackupCode(gophtest.BackupCode).Hash()).
+					Return(nil)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+			partialToken := newTestTokenInfo()
+
+			twoFactorMock := &repo.TwoFactorRepoMock{}
+			expectSecondFactor(twoFactorMock, user.ID, true)
+			tc.setup(twoFactorMock, user.ID)
+
+			tokensMock := &repo.TokensRepoMock{}
+			tokensMock.On("RevokeAccessToken", mock.Anything, partialToken).
+				Return(nil)
+			issued := expectRefreshToken(tokensMock, user, nil)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				tokensMock,
+				twoFactorMock,
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			tokens, err := sat.VerifySecondFactor(context.Background(), user, partialToken, tc.code)
+
+			require.NoError(t, err)
+			require.NotEmpty(t, tokens.AccessToken)
+			require.Equal(t, tokens.RefreshToken.Hash(), issued.Hash)
+
+			// The partial token can't be exchanged again.
+			revoked, err := sat.IsRevoked(context.Background(), partialToken)
+			require.NoError(t, err)
+			require.True(t, revoked)
+
+			twoFactorMock.AssertExpectations(t)
+			tokensMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifySecondFactorFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		code     string
+		setup    func(m *repo.TwoFactorRepoMock, tokensMock *repo.TokensRepoMock, user uuid.UUID)
+		expected error
+	}{
+		{
+			name: "Verification fails on wrong TOTP code",
+			code: totp.Code([]byte(gophtest.TOTPSecret), time.Now().Add(-time.Hour)),
+			setup: func(m *repo.TwoFactorRepoMock, _ *repo.TokensRepoMock, user uuid.UUID) {
+				expectSecondFactor(m, user, true)
+			},
+			expected: entity.ErrInvalidSecondFactor,
+		},
+		{
+			name: "Verification fails on replayed TOTP code",
+			code: totp.Code([]byte(gophtest.TOTPSecret), time.Now()),
+			setup: func(m *repo.TwoFactorRepoMock, _ *repo.TokensRepoMock, user uuid.UUID) {
+				expectSecondFactor(m, user, true)
+				expectTOTPStep(m, user, entity.ErrInvalidSecondFactor)
+			},
+			expected: entity.ErrInvalidSecondFactor,
+		},
+		{
+			name: "Verification fails on unknown backup code",
+			code: gophtest.BackupCode,
+			setup: func(m *repo.TwoFactorRepoMock, _ *repo.TokensRepoMock, user uuid.UUID) {
+				expectSecondFactor(m, user, true)
+				m.On("UseBackupCode", mock.Anything, user, mock.Anything).
+					Return(entity.ErrInvalidSecondFactor)
+			},
+			expected: entity.ErrInvalidSecondFactor,
+		},
+		{
+			name: "Verification fails if two-factor authentication is disabled",
+			code: gophtest.BackupCode,
+			setup: func(m *repo.TwoFactorRepoMock, _ *repo.TokensRepoMock, user uuid.UUID) {
+				expectSecondFactor(m, user, false)
+			},
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name: "Verification fails if partial token is not revoked",
+			code: totp.Code([]byte(gophtest.TOTPSecret), time.Now()),
+			setup: func(m *repo.TwoFactorRepoMock, tokensMock *repo.TokensRepoMock, user uuid.UUID) {
+				expectSecondFactor(m, user, true)
+				expectTOTPStep(m, user, nil)
+				tokensMock.On("RevokeAccessToken", mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+			twoFactorMock := &repo.TwoFactorRepoMock{}
+			tokensMock := &repo.TokensRepoMock{}
+			tc.setup(twoFactorMock, tokensMock, user.ID)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				tokensMock,
+				twoFactorMock,
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			tokens, err := sat.VerifySecondFactor(context.Background(), user, newTestTokenInfo(), tc.code)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.Empty(t, tokens)
+			twoFactorMock.AssertExpectations(t)
+			tokensMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticateCertificate(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	tt := []struct {
+		name     string
+		repoErr  error
+		expected error
+	}{
+		{
+			name: "Certificate of known user is accepted",
+		},
+		{
+			name:     "Certificate of unknown user is rejected",
+			repoErr:  entity.ErrUserNotFound,
+			expected: entity.ErrInvalidCredentials,
+		},
+		{
+			name:     "Authenticate certificate fails on unexpected error",
+			repoErr:  gophtest.ErrUnexpected,
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.UsersRepoMock{}
+			m.On("GetUser", mock.Anything, gophtest.Username).
+				Return(user, tc.repoErr)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			rv, err := sat.AuthenticateCertificate(context.Background(), gophtest.Username)
+
+			if tc.expected != nil {
+				require.ErrorIs(t, err, tc.expected)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, user, rv)
+		})
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+
+	require.Empty(t, sat.PublicKeys())
+}
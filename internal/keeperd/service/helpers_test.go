@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	"github.com/derpartizanen/gophkeeper/proto"
+)
+
+func newTestKeyring() *entity.Keyring {
+	return entity.NewSecretKeyring(gophtest.Secret)
+}
+
+func newTestKDFParams() entity.KDFParams {
+	return entity.KDFParams{
+		Algorithm: proto.KDFAlgorithm_KDF_ARGON2ID,
+		Salt:      []byte(gophtest.Salt),
+		Time:      gophtest.KDFTime,
+		Memory:    gophtest.KDFMemory,
+		Threads:   gophtest.KDFThreads,
+
+		KeySchedule: proto.KeySchedule_KEY_SCHEDULE_SUBKEYS,
+	}
+}
+
+func newTestVerifier() entity.Verifier {
+	return entity.Verifier{
+		Salt:     []byte(gophtest.SRPSalt),
+		Verifier: srp.Verifier([]byte(gophtest.SRPSalt), []byte(gophtest.AuthKey)),
+	}
+}
+
+func newTestHandshake(user entity.User) entity.Handshake {
+	return entity.Handshake{
+		ID:          uuid.New(),
+		User:        user,
+		ClientProof: []byte(gophtest.ClientProof),
+		ServerProof: []byte(gophtest.ServerProof),
+		ExpiresAt:   time.Now().Add(entity.HandshakeLifeTime),
+	}
+}
+
+func newTestProof(handshake entity.Handshake) entity.Proof {
+	return entity.Proof{HandshakeID: handshake.ID, ClientProof: []byte(gophtest.ClientProof)}
+}
+
+// expectRefreshToken expects new refresh token family of the user.
+// Returns the saved record once the mock is called.
+func expectRefreshToken(m *repo.TokensRepoMock, user entity.User, err error) *entity.RefreshTokenRecord {
+	saved := new(entity.RefreshTokenRecord)
+
+	m.On(
+		"CreateRefreshToken",
+		mock.Anything,
+		mock.MatchedBy(func(r entity.RefreshTokenRecord) bool {
+			return r.User == user && r.FamilyID != uuid.Nil && r.ExpiresAt.After(time.Now())
+		}),
+	).
+		Run(func(args mock.Arguments) {
+			*saved = args.Get(1).(entity.RefreshTokenRecord)
+		}).
+		Return(err)
+
+	return saved
+}
+
+func newTestTokenInfo() entity.TokenInfo {
+	return entity.TokenInfo{
+		ID:        uuid.New(),
+		ExpiresAt: time.Now().Add(entity.TokenLifeTime),
+	}
+}
+
+// expectSecondFactor mocks two-factor authentication settings of the user.
+func expectSecondFactor(m *repo.TwoFactorRepoMock, user uuid.UUID, enabled bool) {
+	if !enabled {
+		m.On("GetTOTP", mock.Anything, user).
+			Return(entity.TwoFactor{}, entity.ErrTOTPNotEnabled)
+
+		return
+	}
+
+	m.On("GetTOTP", mock.Anything, user).
+		Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret(), Enabled: true}, nil)
+}
+
+// expectTOTPStep mocks saving the time step of accepted TOTP code of the user.
+func expectTOTPStep(m *repo.TwoFactorRepoMock, user uuid.UUID, err error) {
+	m.On("UseTOTPStep", mock.Anything, user, mock.AnythingOfType("int64")).
+		Return(err)
+}
+
+func newTestSealedTOTPSecret() []byte {
+	sealed, err := entity.SealTOTPSecret(gophtest.TOTPKey, []byte(gophtest.TOTPSecret))
+	if err != nil {
+		panic(err)
+	}
+
+	return sealed
+}
+
+// newTestThrottle mocks counters of failed attempts which never lock out.
+func newTestThrottle() *repo.ThrottleRepoMock {
+	m := &repo.ThrottleRepoMock{}
+	m.On("GetBlockedUntil", mock.Anything, mock.Anything).
+		Return(time.Time{}, nil).
+		Maybe()
+	m.On("ChargeAttempt", mock.Anything, mock.Anything).
+		Return(time.Time{}, nil).
+		Maybe()
+	m.On("RefundAttempt", mock.Anything, mock.Anything).
+		Return(nil).
+		Maybe()
+	m.On("ResetFailures", mock.Anything, mock.Anything).
+		Return(nil).
+		Maybe()
+
+	return m
+}
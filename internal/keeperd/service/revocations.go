@@ -0,0 +1,109 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+)
+
+// RevocationCacheTTL is period of time the token is considered valid
+// without consulting the store of revoked tokens.
+// Tokens revoked by another instance of the server are rejected after this delay at most.
+const RevocationCacheTTL = 30 * time.Second
+
+type revocation struct {
+	revoked bool
+	user    uuid.UUID
+	session uuid.UUID
+	until   time.Time
+}
+
+// RevocationCache keeps the state of recently checked access tokens in memory,
+// so the store of revoked tokens is not hit on every request.
+// Revoked tokens are remembered until expiration, as the decision can't change.
+// The cache is shared by the services revoking tokens, see New.
+type RevocationCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]revocation
+	sweptAt time.Time
+}
+
+// NewRevocationCache creates new RevocationCache object.
+func NewRevocationCache() *RevocationCache {
+	return &RevocationCache{entries: make(map[uuid.UUID]revocation)}
+}
+
+// get returns the cached state of the token, if any.
+func (c *RevocationCache) get(id uuid.UUID) (revoked, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok {
+		return false, false
+	}
+
+	if time.Now().After(entry.until) {
+		delete(c.entries, id)
+
+		return false, false
+	}
+
+	return entry.revoked, true
+}
+
+// set caches the state of the token.
+// Stale entries are dropped on the way, once per RevocationCacheTTL.
+func (c *RevocationCache) set(token entity.TokenInfo, revoked bool) {
+	now := time.Now()
+
+	entry := revocation{revoked: revoked, user: token.UserID, session: token.SessionID, until: token.ExpiresAt}
+	if !revoked && now.Add(RevocationCacheTTL).Before(entry.until) {
+		entry.until = now.Add(RevocationCacheTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.sweptAt) > RevocationCacheTTL {
+		for id, e := range c.entries {
+			if now.After(e.until) {
+				delete(c.entries, id)
+			}
+		}
+
+		c.sweptAt = now
+	}
+
+	c.entries[token.ID] = entry
+}
+
+// forgetSession drops cached state of the tokens of the revoked session,
+// so they are checked against the store on the next request.
+func (c *RevocationCache) forgetSession(session uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, e := range c.entries {
+		if e.session == session && !e.revoked {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// forgetOtherSessions drops cached state of the tokens of the user
+// except for the tokens of the current session, so tokens of the revoked sessions
+// are checked against the store on the next request, see repo.Tokens.RevokeOtherSessions.
+func (c *RevocationCache) forgetOtherSessions(user, current uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, e := range c.entries {
+		if e.user == user && e.session != current && !e.revoked {
+			delete(c.entries, id)
+		}
+	}
+}
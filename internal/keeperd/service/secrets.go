@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -14,50 +15,105 @@ import (
 var _ Secrets = (*SecretsService)(nil)
 
 // SecretsService contains business logic related to secrets management.
+// Total size of chunks of a blob is limited by blobLimit,
+// at most versionLimit archived revisions are kept per secret,
+// deleted secrets are kept in trash for trashRetention.
 type SecretsService struct {
-	secretsRepo repo.Secrets
+	secretsRepo    repo.Secrets
+	blobLimit      int64
+	versionLimit   int
+	trashRetention time.Duration
 }
 
 // NewSecretsService create and initializes new SecretsService object.
-func NewSecretsService(secrets repo.Secrets) *SecretsService {
-	return &SecretsService{secrets}
+func NewSecretsService(
+	secrets repo.Secrets,
+	blobLimit int64,
+	versionLimit int,
+	trashRetention time.Duration,
+) *SecretsService {
+	return &SecretsService{secrets, blobLimit, versionLimit, trashRetention}
 }
 
-// Create creates new secret.
+// Create creates new secret with ID chosen by client.
 func (uc *SecretsService) Create(
 	ctx context.Context,
-	owner uuid.UUID,
-	name string,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	metadata, data []byte,
-) (uuid.UUID, error) {
-	id, err := uc.secretsRepo.Create(ctx, owner, name, kind, metadata, data)
-	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("SecretsService - Create - uc.secretsRepo.Create: %w", err)
+	dataKey, metadata, data []byte,
+) error {
+	if err := uc.secretsRepo.Create(ctx, owner, id, name, nameIndex, kind, dataKey, metadata, data); err != nil {
+		return fmt.Errorf("SecretsService - Create - uc.secretsRepo.Create: %w", err)
+	}
+
+	return nil
+}
+
+// UploadBlob creates new binary secret with data stored in chunks.
+// The upload is aborted with entity.ErrBlobTooLarge as soon as the chunks exceed the size limit.
+func (uc *SecretsService) UploadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
+	chunks entity.ChunkSource,
+) error {
+	var size int64
+
+	limited := func() ([]byte, error) {
+		chunk, err := chunks()
+		if err != nil {
+			return nil, err
+		}
+
+		size += int64(len(chunk))
+		if size > uc.blobLimit {
+			return nil, entity.ErrBlobTooLarge
+		}
+
+		return chunk, nil
 	}
 
-	return id, nil
+	if err := uc.secretsRepo.CreateBlob(ctx, owner, id, name, nameIndex, dataKey, metadata, limited); err != nil {
+		return fmt.Errorf("SecretsService - UploadBlob - uc.secretsRepo.CreateBlob: %w", err)
+	}
+
+	return nil
 }
 
-// List returns list of user's secrets.
+// List returns a page of user's secrets matching the filter and version of the vault.
+// One extra secret is requested to find out whether the next page exists.
 func (uc *SecretsService) List(
 	ctx context.Context,
 	owner uuid.UUID,
-) ([]entity.Secret, error) {
-	secrets, err := uc.secretsRepo.List(ctx, owner)
+	filter entity.SecretsFilter,
+) (entity.SecretsPage, error) {
+	pageSize := filter.Limit
+	filter.Limit++
+
+	secrets, version, err := uc.secretsRepo.List(ctx, owner, filter)
 	if err != nil {
-		return nil, fmt.Errorf("SecretsService - List - uc.secretsRepo.List: %w", err)
+		return entity.SecretsPage{}, fmt.Errorf("SecretsService - List - uc.secretsRepo.List: %w", err)
 	}
 
-	return secrets, nil
+	page := entity.SecretsPage{Secrets: secrets, VaultVersion: version}
+
+	if len(secrets) > pageSize {
+		page.Secrets = secrets[:pageSize]
+		page.Next = page.Secrets[pageSize-1].ID
+	}
+
+	return page, nil
 }
 
-// Get retrieves full secret info from database.
+// Get retrieves full secret info from database, either from the vault or from the trash.
 func (uc *SecretsService) Get(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	trashed bool,
 ) (*entity.Secret, error) {
-	secret, err := uc.secretsRepo.Get(ctx, owner, id)
+	secret, err := uc.secretsRepo.Get(ctx, owner, id, trashed)
 	if err != nil {
 		return nil, fmt.Errorf("SecretsService - Get - uc.secretsRepo.Get: %w", err)
 	}
@@ -65,23 +121,103 @@ func (uc *SecretsService) Get(
 	return secret, nil
 }
 
-// Update changes secret info and data.
+// DownloadBlob passes chunks of the blob to the sink in order they were uploaded.
+func (uc *SecretsService) DownloadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	sink entity.ChunkSink,
+) error {
+	if err := uc.secretsRepo.ReadBlob(ctx, owner, id, sink); err != nil {
+		return fmt.Errorf("SecretsService - DownloadBlob - uc.secretsRepo.ReadBlob: %w", err)
+	}
+
+	return nil
+}
+
+// Update changes secret info and data, the previous state is kept as a revision.
+// Non-zero version is the expected current version of the secret,
+// entity.ErrSecretModified is returned if the secret was changed since.
 func (uc *SecretsService) Update(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	version int32,
 	changed []string,
-	name string,
-	metadata []byte,
-	data []byte,
+	name, nameIndex []byte,
+	dataKey, metadata, data []byte,
 ) error {
-	if err := uc.secretsRepo.Update(ctx, owner, id, changed, name, metadata, data); err != nil {
+	if err := uc.secretsRepo.Update(
+		ctx,
+		owner,
+		id,
+		uc.versionLimit,
+		version,
+		changed,
+		name,
+		nameIndex,
+		dataKey,
+		metadata,
+		data,
+	); err != nil {
 		return fmt.Errorf("SecretsService - Update - uc.secretsRepo.Update: %w", err)
 	}
 
 	return nil
 }
 
-// Delete removes secret owned by user.
+// ListVersions returns all kept versions of the secret without data, the latest go first.
+func (uc *SecretsService) ListVersions(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	trashed bool,
+) ([]entity.Secret, error) {
+	versions, err := uc.secretsRepo.ListVersions(ctx, owner, id, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - ListVersions - uc.secretsRepo.ListVersions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves full secret info of the provided version.
+func (uc *SecretsService) GetVersion(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*entity.Secret, error) {
+	secret, err := uc.secretsRepo.GetVersion(ctx, owner, id, version, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - GetVersion - uc.secretsRepo.GetVersion: %w", err)
+	}
+
+	return secret, nil
+}
+
+// Restore makes content of the provided version current and returns the new version number.
+// Zero version stands for restoring of the trashed secret, its current version is returned.
+func (uc *SecretsService) Restore(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+) (int32, error) {
+	if version == 0 {
+		restored, err := uc.secretsRepo.RestoreFromTrash(ctx, owner, id)
+		if err != nil {
+			return 0, fmt.Errorf("SecretsService - Restore - uc.secretsRepo.RestoreFromTrash: %w", err)
+		}
+
+		return restored, nil
+	}
+
+	restored, err := uc.secretsRepo.Restore(ctx, owner, id, uc.versionLimit, version)
+	if err != nil {
+		return 0, fmt.Errorf("SecretsService - Restore - uc.secretsRepo.Restore: %w", err)
+	}
+
+	return restored, nil
+}
+
+// Delete moves secret owned by user to trash.
 func (uc *SecretsService) Delete(
 	ctx context.Context,
 	owner, id uuid.UUID,
@@ -92,3 +228,36 @@ func (uc *SecretsService) Delete(
 
 	return nil
 }
+
+// ListTrash returns trashed secrets owned by user without data, the latest deleted go first.
+func (uc *SecretsService) ListTrash(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error) {
+	secrets, err := uc.secretsRepo.ListTrash(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("SecretsService - ListTrash - uc.secretsRepo.ListTrash: %w", err)
+	}
+
+	return secrets, nil
+}
+
+// Purge removes secret owned by user permanently, either trashed or not.
+func (uc *SecretsService) Purge(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) error {
+	if err := uc.secretsRepo.Purge(ctx, owner, id); err != nil {
+		return fmt.Errorf("SecretsService - Purge - uc.secretsRepo.Purge: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently removes secrets of all users kept in trash longer than the retention period.
+// Returns number of removed secrets.
+func (uc *SecretsService) PurgeTrash(ctx context.Context) (int64, error) {
+	purged, err := uc.secretsRepo.PurgeTrash(ctx, time.Now().Add(-uc.trashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("SecretsService - PurgeTrash - uc.secretsRepo.PurgeTrash: %w", err)
+	}
+
+	return purged, nil
+}
@@ -18,51 +18,111 @@ type SecretsServiceMock struct {
 
 func (m *SecretsServiceMock) Create(
 	ctx context.Context,
-	owner uuid.UUID,
-	name string,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
 	kind proto.DataKind,
-	metadata, data []byte,
-) (uuid.UUID, error) {
-	args := m.Called(ctx, owner, name, kind, metadata, data)
+	dataKey, metadata, data []byte,
+) error {
+	args := m.Called(ctx, owner, id, name, nameIndex, kind, dataKey, metadata, data)
+
+	return args.Error(0)
+}
+
+func (m *SecretsServiceMock) UploadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
+	chunks entity.ChunkSource,
+) error {
+	args := m.Called(ctx, owner, id, name, nameIndex, dataKey, metadata, chunks)
 
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	return args.Error(0)
 }
 
 func (m *SecretsServiceMock) List(
 	ctx context.Context,
 	owner uuid.UUID,
-) ([]entity.Secret, error) {
-	args := m.Called(ctx, owner)
+	filter entity.SecretsFilter,
+) (entity.SecretsPage, error) {
+	args := m.Called(ctx, owner, filter)
 
-	if args.Get(0) == 1 {
-		return nil, args.Error(1)
-	}
-
-	return args.Get(0).([]entity.Secret), args.Error(1)
+	return args.Get(0).(entity.SecretsPage), args.Error(1)
 }
 
 func (m *SecretsServiceMock) Get(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	trashed bool,
 ) (*entity.Secret, error) {
-	args := m.Called(ctx, owner, id)
+	args := m.Called(ctx, owner, id, trashed)
 
 	return args.Get(0).(*entity.Secret), args.Error(1)
 }
 
+func (m *SecretsServiceMock) DownloadBlob(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	sink entity.ChunkSink,
+) error {
+	args := m.Called(ctx, owner, id, sink)
+
+	return args.Error(0)
+}
+
 func (m *SecretsServiceMock) Update(
 	ctx context.Context,
 	owner, id uuid.UUID,
+	version int32,
 	changed []string,
-	name string,
-	metadata []byte,
+	name, nameIndex []byte,
+	dataKey, metadata []byte,
 	data []byte,
 ) error {
-	args := m.Called(ctx, owner, id, changed, name, metadata, data)
+	args := m.Called(ctx, owner, id, version, changed, name, nameIndex, dataKey, metadata, data)
 
 	return args.Error(0)
 }
 
+func (m *SecretsServiceMock) ListVersions(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	trashed bool,
+) ([]entity.Secret, error) {
+	args := m.Called(ctx, owner, id, trashed)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]entity.Secret), args.Error(1)
+}
+
+func (m *SecretsServiceMock) GetVersion(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+	trashed bool,
+) (*entity.Secret, error) {
+	args := m.Called(ctx, owner, id, version, trashed)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*entity.Secret), args.Error(1)
+}
+
+func (m *SecretsServiceMock) Restore(
+	ctx context.Context,
+	owner, id uuid.UUID,
+	version int32,
+) (int32, error) {
+	args := m.Called(ctx, owner, id, version)
+
+	return args.Get(0).(int32), args.Error(1)
+}
+
 func (m *SecretsServiceMock) Delete(
 	ctx context.Context,
 	owner, id uuid.UUID,
@@ -71,3 +131,28 @@ func (m *SecretsServiceMock) Delete(
 
 	return args.Error(0)
 }
+
+func (m *SecretsServiceMock) ListTrash(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error) {
+	args := m.Called(ctx, owner)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]entity.Secret), args.Error(1)
+}
+
+func (m *SecretsServiceMock) Purge(
+	ctx context.Context,
+	owner, id uuid.UUID,
+) error {
+	args := m.Called(ctx, owner, id)
+
+	return args.Error(0)
+}
+
+func (m *SecretsServiceMock) PurgeTrash(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+
+	return args.Get(0).(int64), args.Error(1)
+}
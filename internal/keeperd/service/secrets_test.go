@@ -2,7 +2,10 @@ package service_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -15,40 +18,52 @@ import (
 	"github.com/derpartizanen/gophkeeper/proto"
 )
 
-func doCreateSecret(
-	t *testing.T,
-	repoSecretID uuid.UUID,
-	repoErr error,
-) (uuid.UUID, error) {
+const (
+	// testBlobLimit is small enough to be exceeded by a couple of chunks.
+	testBlobLimit = 16
+
+	testVersionLimit = 3
+
+	testTrashRetention = time.Hour
+)
+
+func doCreateSecret(t *testing.T, repoErr error) error {
 	t.Helper()
 
 	owner := uuid.New()
+	id := uuid.New()
 
 	m := &repo.SecretsRepoMock{}
 	m.On(
 		"Create",
 		mock.Anything,
 		owner,
-		gophtest.SecretName,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
 		proto.DataKind_TEXT,
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	).
-		Return(repoSecretID, repoErr)
+		Return(repoErr)
 
-	sat := service.NewSecretsService(m)
-	id, err := sat.Create(
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	err := sat.Create(
 		context.Background(),
 		owner,
-		gophtest.SecretName,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
 		proto.DataKind_TEXT,
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	)
 
 	m.AssertExpectations(t)
 
-	return id, err
+	return err
 }
 
 func doListSecrets(
@@ -64,15 +79,20 @@ func doListSecrets(
 	copy(rv, repoSecrets)
 
 	m := &repo.SecretsRepoMock{}
-	m.On("List", mock.Anything, owner).
-		Return(rv, repoErr)
+	m.On("List", mock.Anything, owner, entity.SecretsFilter{Limit: 11}).
+		Return(rv, gophtest.VaultVersion, repoErr)
 
-	sat := service.NewSecretsService(m)
-	secrets, err := sat.List(context.Background(), owner)
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	page, err := sat.List(context.Background(), owner, entity.SecretsFilter{Limit: 10})
+
+	if repoErr == nil {
+		require.Equal(t, gophtest.VaultVersion, page.VaultVersion)
+		require.Equal(t, uuid.Nil, page.Next)
+	}
 
 	m.AssertExpectations(t)
 
-	return secrets, err
+	return page.Secrets, err
 }
 
 func doGetSecret(t *testing.T, repoRV *entity.Secret, repoErr error) (*entity.Secret, error) {
@@ -82,11 +102,11 @@ func doGetSecret(t *testing.T, repoRV *entity.Secret, repoErr error) (*entity.Se
 	id := uuid.New()
 
 	m := &repo.SecretsRepoMock{}
-	m.On("Get", mock.Anything, owner, id).
+	m.On("Get", mock.Anything, owner, id, false).
 		Return(repoRV, repoErr)
 
-	sat := service.NewSecretsService(m)
-	secret, err := sat.Get(context.Background(), owner, id)
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	secret, err := sat.Get(context.Background(), owner, id, false)
 
 	m.AssertExpectations(t)
 
@@ -98,7 +118,7 @@ func doUpdateSecret(t *testing.T, repoErr error) error {
 
 	owner := uuid.New()
 	id := uuid.New()
-	changed := []string{"name", "metadata", "data"}
+	changed := []string{"name", "data_key", "metadata", "data"}
 
 	m := &repo.SecretsRepoMock{}
 	m.On(
@@ -106,20 +126,27 @@ func doUpdateSecret(t *testing.T, repoErr error) error {
 		mock.Anything,
 		owner,
 		id,
+		testVersionLimit,
+		int32(2),
 		changed,
-		gophtest.SecretName,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	).
 		Return(repoErr)
 
-	sat := service.NewSecretsService(m)
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
 	err := sat.Update(
 		context.Background(),
 		owner,
 		id,
+		2,
 		changed,
-		gophtest.SecretName,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
 		[]byte(gophtest.Metadata),
 		[]byte(gophtest.TextData),
 	)
@@ -139,7 +166,7 @@ func doDeleteSecret(t *testing.T, repoErr error) error {
 	m.On("Delete", mock.Anything, owner, id).
 		Return(repoErr)
 
-	sat := service.NewSecretsService(m)
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
 	err := sat.Delete(context.Background(), owner, id)
 
 	m.AssertExpectations(t)
@@ -148,37 +175,25 @@ func doDeleteSecret(t *testing.T, repoErr error) error {
 }
 
 func TestCreateSecret(t *testing.T) {
-	type expected struct {
-		id  uuid.UUID
-		err error
-	}
-
 	tt := []struct {
 		name     string
-		expected expected
+		expected error
 	}{
 		{
-			name: "Create secret",
-			expected: expected{
-				id:  uuid.New(),
-				err: nil,
-			},
+			name:     "Create secret",
+			expected: nil,
 		},
 		{
-			name: "Create secret fails if secret exists",
-			expected: expected{
-				id:  uuid.UUID{},
-				err: entity.ErrSecretExists,
-			},
+			name:     "Create secret fails if secret exists",
+			expected: entity.ErrSecretExists,
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			id, err := doCreateSecret(t, tc.expected.id, tc.expected.err)
+			err := doCreateSecret(t, tc.expected)
 
-			require.ErrorIs(t, err, tc.expected.err)
-			require.Equal(t, tc.expected.id, id)
+			require.ErrorIs(t, err, tc.expected)
 		})
 	}
 }
@@ -199,14 +214,16 @@ func TestListSecrets(t *testing.T) {
 				secrets: []entity.Secret{
 					{
 						ID:   uuid.New(),
-						Name: gophtest.SecretName,
+						Name: []byte(gophtest.SecretName),
 						Kind: proto.DataKind_BINARY,
 					},
 					{
-						ID:       uuid.New(),
-						Name:     gophtest.SecretName + "ex",
-						Kind:     proto.DataKind_TEXT,
-						Metadata: []byte(gophtest.Metadata),
+						ID:        uuid.New(),
+						Name:      []byte(gophtest.SecretName + "ex"),
+						NameIndex: []byte(gophtest.NameIndex),
+						Kind:      proto.DataKind_TEXT,
+						DataKey:   []byte(gophtest.DataKey),
+						Metadata:  []byte(gophtest.Metadata),
 					},
 				},
 				err: nil,
@@ -238,6 +255,130 @@ func TestListSecrets(t *testing.T) {
 	}
 }
 
+func TestListSecretsWithNextPage(t *testing.T) {
+	owner := uuid.New()
+	secrets := []entity.Secret{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+	kinds := []proto.DataKind{proto.DataKind_TEXT}
+
+	m := &repo.SecretsRepoMock{}
+	m.On("List", mock.Anything, owner, entity.SecretsFilter{Kinds: kinds, Limit: 3}).
+		Return(secrets, gophtest.VaultVersion, nil)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	page, err := sat.List(context.Background(), owner, entity.SecretsFilter{Kinds: kinds, Limit: 2})
+
+	require.NoError(t, err)
+	require.Equal(t, secrets[:2], page.Secrets)
+	require.Equal(t, secrets[1].ID, page.Next)
+	m.AssertExpectations(t)
+}
+
+// doUploadBlob uploads the chunks with the repo draining the chunk source like a database would.
+// Returns the stored chunks and the error of the chunk source.
+func doUploadBlob(t *testing.T, chunks ...[]byte) ([][]byte, error) {
+	t.Helper()
+
+	owner := uuid.New()
+	id := uuid.New()
+
+	var (
+		stored    [][]byte
+		sourceErr error
+	)
+
+	drain := func(args mock.Arguments) {
+		source := args.Get(7).(entity.ChunkSource)
+
+		for {
+			chunk, err := source()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					sourceErr = err
+				}
+
+				return
+			}
+
+			stored = append(stored, chunk)
+		}
+	}
+
+	m := &repo.SecretsRepoMock{}
+	m.On(
+		"CreateBlob",
+		mock.Anything,
+		owner,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
+		[]byte(gophtest.Metadata),
+		mock.Anything,
+	).
+		Run(drain).
+		Return(nil)
+
+	source := func() ([]byte, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		return chunk, nil
+	}
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	err := sat.UploadBlob(
+		context.Background(),
+		owner,
+		id,
+		[]byte(gophtest.SecretName),
+		[]byte(gophtest.NameIndex),
+		[]byte(gophtest.DataKey),
+		[]byte(gophtest.Metadata),
+		source,
+	)
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+
+	return stored, sourceErr
+}
+
+func TestUploadBlob(t *testing.T) {
+	chunks := [][]byte{[]byte("12345678"), []byte("12345678")}
+
+	stored, err := doUploadBlob(t, chunks...)
+
+	require.NoError(t, err)
+	require.Equal(t, chunks, stored)
+}
+
+func TestUploadBlobExceedingLimit(t *testing.T) {
+	stored, err := doUploadBlob(t, []byte("12345678"), []byte("12345678"), []byte("1"))
+
+	require.ErrorIs(t, err, entity.ErrBlobTooLarge)
+	require.Len(t, stored, 2)
+}
+
+func TestDownloadBlob(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	sink := func([]byte) error { return nil }
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ReadBlob", mock.Anything, owner, id, mock.Anything).
+		Return(entity.ErrSecretNotFound)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	err := sat.DownloadBlob(context.Background(), owner, id, sink)
+
+	require.ErrorIs(t, err, entity.ErrSecretNotFound)
+	m.AssertExpectations(t)
+}
+
 func TestGetSecret(t *testing.T) {
 	type expected struct {
 		secret *entity.Secret
@@ -252,11 +393,13 @@ func TestGetSecret(t *testing.T) {
 			name: "Get secret",
 			expected: expected{
 				secret: &entity.Secret{
-					ID:       uuid.New(),
-					Name:     gophtest.SecretName,
-					Kind:     proto.DataKind_TEXT,
-					Metadata: []byte(gophtest.Metadata),
-					Data:     []byte(gophtest.TextData),
+					ID:        uuid.New(),
+					Name:      []byte(gophtest.SecretName),
+					NameIndex: []byte(gophtest.NameIndex),
+					Kind:      proto.DataKind_TEXT,
+					DataKey:   []byte(gophtest.DataKey),
+					Metadata:  []byte(gophtest.Metadata),
+					Data:      []byte(gophtest.TextData),
 				},
 				err: nil,
 			},
@@ -293,6 +436,10 @@ func TestUpdateSecret(t *testing.T) {
 			name:     "Update secret if secret not found",
 			expected: entity.ErrSecretNotFound,
 		},
+		{
+			name:     "Update secret if secret was modified since the version",
+			expected: entity.ErrSecretModified,
+		},
 	}
 
 	for _, tc := range tt {
@@ -327,3 +474,238 @@ func TestDeleteSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestListVersions(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	versions := []entity.Secret{{ID: id, Version: 2}, {ID: id, Version: 1}}
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ListVersions", mock.Anything, owner, id, false).
+		Return(versions, nil)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	rv, err := sat.ListVersions(context.Background(), owner, id, false)
+
+	require.NoError(t, err)
+	require.Equal(t, versions, rv)
+	m.AssertExpectations(t)
+}
+
+func TestListVersionsOnRepoFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := &repo.SecretsRepoMock{}
+	m.On("ListVersions", mock.Anything, owner, id, false).
+		Return([]entity.Secret(nil), entity.ErrSecretNotFound)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	_, err := sat.ListVersions(context.Background(), owner, id, false)
+
+	require.ErrorIs(t, err, entity.ErrSecretNotFound)
+	m.AssertExpectations(t)
+}
+
+func TestGetVersion(t *testing.T) {
+	owner := uuid.New()
+	secret := &entity.Secret{ID: uuid.New(), Data: []byte(gophtest.TextData), Version: 1}
+
+	m := &repo.SecretsRepoMock{}
+	m.On("GetVersion", mock.Anything, owner, secret.ID, secret.Version, false).
+		Return(secret, nil)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	rv, err := sat.GetVersion(context.Background(), owner, secret.ID, secret.Version, false)
+
+	require.NoError(t, err)
+	require.Equal(t, secret, rv)
+	m.AssertExpectations(t)
+}
+
+func TestGetVersionOnRepoFailure(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+
+	m := &repo.SecretsRepoMock{}
+	m.On("GetVersion", mock.Anything, owner, id, int32(1), false).
+		Return((*entity.Secret)(nil), entity.ErrVersionNotFound)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	_, err := sat.GetVersion(context.Background(), owner, id, 1, false)
+
+	require.ErrorIs(t, err, entity.ErrVersionNotFound)
+	m.AssertExpectations(t)
+}
+
+func TestRestoreVersion(t *testing.T) {
+	tt := []struct {
+		name     string
+		restored int32
+		err      error
+	}{
+		{
+			name:     "Restore version of a secret",
+			restored: 5,
+		},
+		{
+			name: "Restore fails if version doesn't exist",
+			err:  entity.ErrVersionNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := &repo.SecretsRepoMock{}
+			m.On("Restore", mock.Anything, owner, id, testVersionLimit, int32(1)).
+				Return(tc.restored, tc.err)
+
+			sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+			restored, err := sat.Restore(context.Background(), owner, id, 1)
+
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.restored, restored)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreFromTrash(t *testing.T) {
+	tt := []struct {
+		name     string
+		restored int32
+		err      error
+	}{
+		{
+			name:     "Restore secret from trash",
+			restored: 2,
+		},
+		{
+			name: "Restore fails if secret is not in trash",
+			err:  entity.ErrSecretNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := &repo.SecretsRepoMock{}
+			m.On("RestoreFromTrash", mock.Anything, owner, id).
+				Return(tc.restored, tc.err)
+
+			sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+			restored, err := sat.Restore(context.Background(), owner, id, 0)
+
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.restored, restored)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListTrash(t *testing.T) {
+	tt := []struct {
+		name    string
+		secrets []entity.Secret
+		err     error
+	}{
+		{
+			name:    "List trash",
+			secrets: []entity.Secret{{ID: uuid.New(), DeletedAt: time.Now()}},
+		},
+		{
+			name:    "List trash on repo failure",
+			secrets: []entity.Secret(nil),
+			err:     gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+
+			m := &repo.SecretsRepoMock{}
+			m.On("ListTrash", mock.Anything, owner).
+				Return(tc.secrets, tc.err)
+
+			sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+			secrets, err := sat.ListTrash(context.Background(), owner)
+
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.secrets, secrets)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeSecret(t *testing.T) {
+	tt := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "Purge secret",
+		},
+		{
+			name: "Purge fails if secret doesn't exist",
+			err:  entity.ErrSecretNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := uuid.New()
+			id := uuid.New()
+
+			m := &repo.SecretsRepoMock{}
+			m.On("Purge", mock.Anything, owner, id).
+				Return(tc.err)
+
+			sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+			err := sat.Purge(context.Background(), owner, id)
+
+			require.ErrorIs(t, err, tc.err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	now := time.Now()
+
+	m := &repo.SecretsRepoMock{}
+	m.On(
+		"PurgeTrash",
+		mock.Anything,
+		mock.MatchedBy(func(before time.Time) bool {
+			// The secret is expired if deleted before the retention period started.
+			return !before.After(time.Now().Add(-testTrashRetention)) &&
+				!before.Before(now.Add(-testTrashRetention))
+		}),
+	).
+		Return(int64(2), nil)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	purged, err := sat.PurgeTrash(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, int64(2), purged)
+	m.AssertExpectations(t)
+}
+
+func TestPurgeTrashOnRepoFailure(t *testing.T) {
+	m := &repo.SecretsRepoMock{}
+	m.On("PurgeTrash", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return(int64(0), gophtest.ErrUnexpected)
+
+	sat := service.NewSecretsService(m, testBlobLimit, testVersionLimit, testTrashRetention)
+	_, err := sat.PurgeTrash(context.Background())
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
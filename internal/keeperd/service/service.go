@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,49 +13,155 @@ import (
 )
 
 type Auth interface {
-	Login(ctx context.Context, username, securityKey string) (entity.AccessToken, error)
+	Prelogin(ctx context.Context, username string) (entity.KDFParams, bool, error)
+	Login(ctx context.Context, username, securityKey string, verifier entity.Verifier) (entity.TokenPair, error)
+	StartLogin(ctx context.Context, username string, clientPublic []byte) (entity.Challenge, error)
+	FinishLogin(ctx context.Context, proof entity.Proof) (entity.TokenPair, []byte, error)
+	VerifySecondFactor(
+		ctx context.Context,
+		user entity.User,
+		partialToken entity.TokenInfo,
+		code string,
+	) (entity.TokenPair, error)
+
+	AuthenticateCertificate(ctx context.Context, subject string) (entity.User, error)
+	Refresh(ctx context.Context, refreshToken entity.RefreshToken) (entity.TokenPair, error)
+	Recover(ctx context.Context, username, recoverySecurityKey string) (entity.TokenPair, []byte, error)
+	RecoverVaultKey(ctx context.Context, user entity.User, recoverySecurityKey string) ([]byte, error)
+	Logout(ctx context.Context, user uuid.UUID, token entity.TokenInfo, refreshToken entity.RefreshToken) error
+	ListSessions(ctx context.Context, user uuid.UUID) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, user, id uuid.UUID) error
+	IsRevoked(ctx context.Context, token entity.TokenInfo) (bool, error)
+	PublicKeys() []entity.JSONWebKey
 }
 
-type Secrets interface {
+type APITokens interface {
 	Create(
 		ctx context.Context,
-		owner uuid.UUID,
+		user entity.User,
 		name string,
+		scope entity.APITokenScope,
+		expiresAt time.Time,
+	) (entity.APIToken, entity.APITokenRecord, error)
+
+	List(ctx context.Context, user uuid.UUID) ([]entity.APITokenRecord, error)
+	Revoke(ctx context.Context, user, id uuid.UUID) error
+	Authenticate(ctx context.Context, token entity.APIToken) (entity.User, entity.APITokenScope, error)
+}
+
+type Secrets interface {
+	Create(
+		ctx context.Context,
+		owner, id uuid.UUID,
+		name, nameIndex []byte,
 		kind proto.DataKind,
-		metadata, data []byte,
-	) (uuid.UUID, error)
+		dataKey, metadata, data []byte,
+	) error
 
-	List(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error)
-	Get(ctx context.Context, owner, id uuid.UUID) (*entity.Secret, error)
+	UploadBlob(
+		ctx context.Context,
+		owner, id uuid.UUID,
+		name, nameIndex []byte,
+		dataKey, metadata []byte,
+		chunks entity.ChunkSource,
+	) error
+
+	List(ctx context.Context, owner uuid.UUID, filter entity.SecretsFilter) (entity.SecretsPage, error)
+	Get(ctx context.Context, owner, id uuid.UUID, trashed bool) (*entity.Secret, error)
+	DownloadBlob(ctx context.Context, owner, id uuid.UUID, sink entity.ChunkSink) error
 
 	Update(
 		ctx context.Context,
 		owner, id uuid.UUID,
+		version int32,
 		changed []string,
-		name string,
-		metadata []byte,
-		data []byte,
+		name, nameIndex []byte,
+		dataKey, metadata, data []byte,
 	) error
 
+	ListVersions(ctx context.Context, owner, id uuid.UUID, trashed bool) ([]entity.Secret, error)
+	GetVersion(ctx context.Context, owner, id uuid.UUID, version int32, trashed bool) (*entity.Secret, error)
+	Restore(ctx context.Context, owner, id uuid.UUID, version int32) (int32, error)
 	Delete(ctx context.Context, owner, id uuid.UUID) error
+	ListTrash(ctx context.Context, owner uuid.UUID) ([]entity.Secret, error)
+	Purge(ctx context.Context, owner, id uuid.UUID) error
+	PurgeTrash(ctx context.Context) (int64, error)
 }
 
 type Users interface {
-	Register(ctx context.Context, username, securityKey string) (entity.AccessToken, error)
+	Register(
+		ctx context.Context,
+		username string,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		recovery entity.RecoveryKit,
+	) (entity.TokenPair, error)
+
+	StartChangePassword(ctx context.Context, id uuid.UUID, clientPublic []byte) (entity.Challenge, error)
+
+	ChangePassword(
+		ctx context.Context,
+		user entity.User,
+		proof entity.Proof,
+		recoverySecurityKey string,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		vaultVersion int64,
+		secrets []entity.ReencryptedSecret,
+		recovery entity.RecoveryKit,
+	) ([]byte, error)
+
+	StartDelete(ctx context.Context, id uuid.UUID, clientPublic []byte) (entity.Challenge, error)
+	Delete(ctx context.Context, user entity.User, proof entity.Proof) ([]byte, error)
+
+	StartRename(ctx context.Context, id uuid.UUID, clientPublic []byte) (entity.Challenge, error)
+
+	Rename(
+		ctx context.Context,
+		user entity.User,
+		username string,
+		proof entity.Proof,
+		verifier entity.Verifier,
+		kdf entity.KDFParams,
+		vaultVersion int64,
+		secrets []entity.ReencryptedSecret,
+		recovery entity.RecoveryKit,
+	) ([]byte, error)
+
+	GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error)
+
+	SetupTOTP(ctx context.Context, user entity.User) (string, error)
+	EnableTOTP(ctx context.Context, user entity.User, code string) ([]entity.BackupCode, error)
+	DisableTOTP(ctx context.Context, user entity.User, code string) error
 }
 
 // Services is a collection of business logic.
 type Services struct {
-	Auth    Auth
-	Secrets Secrets
-	Users   Users
+	APITokens APITokens
+	Auth      Auth
+	Secrets   Secrets
+	Users     Users
 }
 
 // New creates and initializes collection of business logic.
-func New(cfg *config.Config, repos *repo.Repositories) *Services {
+// Access tokens are signed and verified with provided keys.
+func New(cfg *config.Config, keys *entity.Keyring, repos *repo.Repositories) *Services {
+	// Both services revoke sessions, so they must agree on cached state of the tokens.
+	revoked := NewRevocationCache()
+
 	return &Services{
-		Auth:    NewAuthService(cfg.Secret, repos.Users),
-		Secrets: NewSecretsService(repos.Secrets),
-		Users:   NewUsersService(cfg.Secret, repos.Users),
+		APITokens: NewAPITokensService(repos.APITokens),
+		Auth: NewAuthService(
+			cfg.Secret,
+			cfg.TOTPKey,
+			keys,
+			repos.Users,
+			repos.Tokens,
+			repos.TwoFactor,
+			repos.Throttle,
+			revoked,
+		),
+		Secrets: NewSecretsService(repos.Secrets, cfg.BlobLimit, cfg.VersionLimit, cfg.TrashRetention),
+		Users:   NewUsersService(cfg.TOTPKey, keys, repos.Users, repos.Tokens, repos.TwoFactor, repos.Throttle, revoked),
 	}
 }
@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+)
+
+func newTestAuthService(tokensMock *repo.TokensRepoMock) *service.AuthService {
+	return service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+}
+
+func TestLoginRecordsSession(t *testing.T) {
+	tokens, saved, err := doLogin(t, nil, nil)
+	require.NoError(t, err)
+
+	claims, err := tokens.AccessToken.Decode(newTestKeyring())
+	require.NoError(t, err)
+	require.Equal(t, saved.FamilyID.String(), claims.SessionID)
+}
+
+func TestLoginRecordsDevice(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	device := entity.Device{Name: gophtest.DeviceName, UserAgent: gophtest.UserAgent}
+
+	m := &repo.UsersRepoMock{}
+	m.On("Verify", mock.Anything, gophtest.Username, gophtest.SecurityKey).
+		Return(user, nil)
+	m.On("SetVerifier", mock.Anything, user.ID, mock.Anything).
+		Return(nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	saved := expectRefreshToken(tokensMock, user, nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, false)
+
+	ctx := entity.WithDevice(entity.WithPeerIP(context.Background(), gophtest.PeerAddress), device)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Login(ctx, gophtest.Username, gophtest.SecurityKey, newTestVerifier())
+
+	require.NoError(t, err)
+
+	device.PeerAddress = gophtest.PeerAddress
+	require.Equal(t, device, saved.Device)
+	tokensMock.AssertExpectations(t)
+}
+
+func TestListSessions(t *testing.T) {
+	user := uuid.New()
+	expected := []entity.Session{
+		{
+			ID:         uuid.New(),
+			Device:     entity.Device{Name: gophtest.DeviceName},
+			CreatedAt:  time.Now().Add(-time.Hour),
+			LastSeenAt: time.Now(),
+		},
+	}
+
+	m := &repo.TokensRepoMock{}
+	m.On("ListSessions", mock.Anything, user).
+		Return(expected, nil)
+
+	sessions, err := newTestAuthService(m).ListSessions(context.Background(), user)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, sessions)
+	m.AssertExpectations(t)
+}
+
+func TestListSessionsOnRepoFailure(t *testing.T) {
+	user := uuid.New()
+
+	m := &repo.TokensRepoMock{}
+	m.On("ListSessions", mock.Anything, user).
+		Return([]entity.Session(nil), gophtest.ErrUnexpected)
+
+	_, err := newTestAuthService(m).ListSessions(context.Background(), user)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeSessionRejectsCachedToken(t *testing.T) {
+	user := uuid.New()
+	token := newTestTokenInfo()
+	token.SessionID = uuid.New()
+
+	m := &repo.TokensRepoMock{}
+	m.On("IsAccessTokenRevoked", mock.Anything, token).
+		Return(false, nil).
+		Once()
+	m.On("RevokeSession", mock.Anything, user, token.SessionID).
+		Return(nil)
+	m.On("IsAccessTokenRevoked", mock.Anything, token).
+		Return(true, nil).
+		Once()
+
+	sat := newTestAuthService(m)
+
+	revoked, err := sat.IsRevoked(context.Background(), token)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, sat.RevokeSession(context.Background(), user, token.SessionID))
+
+	// The cached answer is dropped, so the token is rejected immediately.
+	revoked, err = sat.IsRevoked(context.Background(), token)
+	require.NoError(t, err)
+	require.True(t, revoked)
+	m.AssertExpectations(t)
+}
+
+func TestRevokeSessionFailure(t *testing.T) {
+	tt := []struct {
+		name    string
+		repoErr error
+	}{
+		{
+			name:    "Revoke session fails if session is not found",
+			repoErr: entity.ErrSessionNotFound,
+		},
+		{
+			name:    "Revoke session fails on unexpected error",
+			repoErr: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			user, id := uuid.New(), uuid.New()
+
+			m := &repo.TokensRepoMock{}
+			m.On("RevokeSession", mock.Anything, user, id).
+				Return(tc.repoErr)
+
+			err := newTestAuthService(m).RevokeSession(context.Background(), user, id)
+
+			require.ErrorIs(t, err, tc.repoErr)
+			m.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+// startHandshake responds to the client public ephemeral value with the verifier of the user.
+// Only the expected proofs are saved, the handshake is not saved if the user is not set.
+func startHandshake(
+	ctx context.Context,
+	usersRepo repo.Users,
+	user *entity.User,
+	verifier entity.Verifier,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	h, err := srp.NewHandshake(verifier.Verifier, clientPublic)
+	if err != nil {
+		return entity.Challenge{}, fmt.Errorf("startHandshake - srp.NewHandshake: %w", err)
+	}
+
+	challenge := entity.Challenge{
+		HandshakeID:  uuid.New(),
+		Salt:         verifier.Salt,
+		ServerPublic: h.ServerPublic,
+	}
+
+	if user == nil {
+		return challenge, nil
+	}
+
+	handshake := entity.Handshake{
+		ID:          challenge.HandshakeID,
+		User:        *user,
+		ClientProof: h.ClientProof,
+		ServerProof: h.ServerProof,
+		ExpiresAt:   time.Now().Add(entity.HandshakeLifeTime),
+	}
+
+	if err := usersRepo.CreateHandshake(ctx, handshake); err != nil {
+		return challenge, fmt.Errorf("startHandshake - usersRepo.CreateHandshake: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// finishHandshake verifies the client proof of the session key.
+// The handshake is removed even if the proof is wrong, so it can't be guessed.
+func finishHandshake(
+	ctx context.Context,
+	usersRepo repo.Users,
+	proof entity.Proof,
+) (entity.Handshake, error) {
+	handshake, err := usersRepo.FinishHandshake(ctx, proof.HandshakeID)
+	if err != nil {
+		return handshake, fmt.Errorf("finishHandshake - usersRepo.FinishHandshake: %w", err)
+	}
+
+	if err := verifyHandshake(handshake, proof); err != nil {
+		return handshake, err
+	}
+
+	return handshake, nil
+}
+
+// verifyHandshake checks the client proof against the loaded handshake.
+func verifyHandshake(handshake entity.Handshake, proof entity.Proof) error {
+	if time.Now().After(handshake.ExpiresAt) {
+		return entity.ErrInvalidCredentials
+	}
+
+	expected := srp.Handshake{ClientProof: handshake.ClientProof}
+	if err := expected.VerifyClient(proof.ClientProof); err != nil {
+		return entity.ErrInvalidCredentials
+	}
+
+	return nil
+}
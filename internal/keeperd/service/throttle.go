@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+)
+
+// throttleKeys returns keys counting failed attempts to log in as the user
+// and failed attempts made from the address of the caller.
+// Either is omitted if unknown.
+func throttleKeys(ctx context.Context, username string) []entity.ThrottleKey {
+	keys := make([]entity.ThrottleKey, 0, 2)
+
+	if username != "" {
+		keys = append(keys, entity.UsernameThrottleKey(username))
+	}
+
+	if ip := entity.PeerIPFromContext(ctx); ip != "" {
+		keys = append(keys, entity.PeerThrottleKey(ip))
+	}
+
+	return keys
+}
+
+// checkThrottle returns entity.ThrottledError if any of the keys is locked out.
+func checkThrottle(ctx context.Context, throttleRepo repo.Throttle, keys []entity.ThrottleKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	blockedUntil, err := throttleRepo.GetBlockedUntil(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("checkThrottle - throttleRepo.GetBlockedUntil: %w", err)
+	}
+
+	if wait := time.Until(blockedUntil); wait > 0 {
+		return &entity.ThrottledError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// chargeAttempt counts the attempt as failed for each of the keys before it is made,
+// so concurrent attempts can't all pass the check before any failure is counted.
+// Returns entity.ThrottledError if any of the keys is locked out.
+// The attempt must be settled with settleAttempt.
+func chargeAttempt(ctx context.Context, throttleRepo repo.Throttle, keys []entity.ThrottleKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	blockedUntil, err := throttleRepo.ChargeAttempt(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("chargeAttempt - throttleRepo.ChargeAttempt: %w", err)
+	}
+
+	if wait := time.Until(blockedUntil); wait > 0 {
+		return &entity.ThrottledError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// settleAttempt takes back the attempt charged for each of the keys,
+// unless the attempt failed with the failure error.
+func settleAttempt(
+	ctx context.Context,
+	throttleRepo repo.Throttle,
+	keys []entity.ThrottleKey,
+	attemptErr, failure error,
+) error {
+	if len(keys) == 0 || errors.Is(attemptErr, failure) {
+		return nil
+	}
+
+	if err := throttleRepo.RefundAttempt(ctx, keys); err != nil {
+		return fmt.Errorf("settleAttempt - throttleRepo.RefundAttempt: %w", err)
+	}
+
+	return nil
+}
+
+// resetFailures forgets failed attempts to log in as the user.
+// Failures counted for the address of the caller are kept,
+// so successful logins to one account don't help to brute-force another.
+func resetFailures(ctx context.Context, throttleRepo repo.Throttle, username string) error {
+	if err := throttleRepo.ResetFailures(ctx, entity.UsernameThrottleKey(username)); err != nil {
+		return fmt.Errorf("resetFailures - throttleRepo.ResetFailures: %w", err)
+	}
+
+	return nil
+}
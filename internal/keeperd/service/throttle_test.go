@@ -0,0 +1,647 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
+)
+
+const testPeerIP = "192.0.2.1"
+
+func newTestPeerContext() context.Context {
+	return entity.WithPeerIP(context.Background(), testPeerIP)
+}
+
+func newTestThrottleKeys() []entity.ThrottleKey {
+	return []entity.ThrottleKey{
+		entity.UsernameThrottleKey(gophtest.Username),
+		entity.PeerThrottleKey(testPeerIP),
+	}
+}
+
+func TestLoginIsThrottled(t *testing.T) {
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Login(newTestPeerContext(), gophtest.Username, gophtest.SecurityKey, newTestVerifier())
+
+	var throttled *entity.ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	require.InDelta(t, time.Minute, throttled.RetryAfter, float64(time.Second))
+	throttleMock.AssertExpectations(t)
+}
+
+func TestLoginIsNotThrottledAfterLockout(t *testing.T) {
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(-time.Second), nil)
+
+	m := &repo.UsersRepoMock{}
+	m.On("Verify", mock.Anything, gophtest.Username, gophtest.SecurityKey).
+		Return(entity.User{}, entity.ErrInvalidCredentials)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Login(newTestPeerContext(), gophtest.Username, gophtest.SecurityKey, newTestVerifier())
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	m.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestLoginResetsFailures(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	verifier := newTestVerifier()
+
+	m := &repo.UsersRepoMock{}
+	m.On("Verify", mock.Anything, gophtest.Username, gophtest.SecurityKey).
+		Return(user, nil)
+	m.On("SetVerifier", mock.Anything, user.ID, verifier).
+		Return(nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	expectRefreshToken(tokensMock, user, nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, false)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+	throttleMock.On("RefundAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(nil)
+	throttleMock.On("ResetFailures", mock.Anything, entity.UsernameThrottleKey(gophtest.Username)).
+		Return(nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		twoFactorMock,
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Login(newTestPeerContext(), gophtest.Username, gophtest.SecurityKey, verifier)
+
+	require.NoError(t, err)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestLoginDoesNotResetFailuresBeforeSecondFactor(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	verifier := newTestVerifier()
+
+	m := &repo.UsersRepoMock{}
+	m.On("Verify", mock.Anything, gophtest.Username, gophtest.SecurityKey).
+		Return(user, nil)
+	m.On("SetVerifier", mock.Anything, user.ID, verifier).
+		Return(nil)
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, true)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+	throttleMock.On("RefundAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		twoFactorMock,
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	tokens, err := sat.Login(newTestPeerContext(), gophtest.Username, gophtest.SecurityKey, verifier)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.PartialToken)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestLoginOnThrottleFailure(t *testing.T) {
+	tt := []struct {
+		name   string
+		expect func(m *repo.UsersRepoMock, throttleMock *repo.ThrottleRepoMock)
+	}{
+		{
+			name: "Login fails if attempt is not counted",
+			expect: func(_ *repo.UsersRepoMock, throttleMock *repo.ThrottleRepoMock) {
+				throttleMock.On("ChargeAttempt", mock.Anything, mock.Anything).
+					Return(time.Time{}, gophtest.ErrUnexpected)
+			},
+		},
+		{
+			name: "Login fails if attempt is not taken back",
+			expect: func(m *repo.UsersRepoMock, throttleMock *repo.ThrottleRepoMock) {
+				m.On("Verify", mock.Anything, gophtest.Username, gophtest.SecurityKey).
+					Return(entity.User{}, gophtest.ErrUnexpected)
+				throttleMock.On("ChargeAttempt", mock.Anything, mock.Anything).
+					Return(time.Time{}, nil)
+				throttleMock.On("RefundAttempt", mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.UsersRepoMock{}
+			throttleMock := &repo.ThrottleRepoMock{}
+			tc.expect(m, throttleMock)
+
+			sat := service.NewAuthService(
+				gophtest.Secret,
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				throttleMock,
+				service.NewRevocationCache(),
+			)
+			_, err := sat.Login(newTestPeerContext(), gophtest.Username, gophtest.SecurityKey, newTestVerifier())
+
+			require.ErrorIs(t, err, gophtest.ErrUnexpected)
+			m.AssertExpectations(t)
+			throttleMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartLoginIsThrottled(t *testing.T) {
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("GetBlockedUntil", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.StartLogin(newTestPeerContext(), gophtest.Username, []byte(gophtest.ClientPublic))
+
+	require.ErrorIs(t, err, entity.ErrTooManyAttempts)
+}
+
+func TestFinishLoginCountsFailureOfHandshakeUser(t *testing.T) {
+	handshake := newTestHandshake(entity.User{ID: uuid.New(), Username: gophtest.Username})
+	proof := newTestProof(handshake)
+	proof.ClientProof = []byte(gophtest.ServerProof)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+
+	for _, key := range newTestThrottleKeys() {
+		throttleMock.On("ChargeAttempt", mock.Anything, []entity.ThrottleKey{key}).
+			Return(time.Time{}, nil)
+	}
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, _, err := sat.FinishLogin(newTestPeerContext(), proof)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestFinishLoginIsThrottledForHandshakeUser(t *testing.T) {
+	handshake := newTestHandshake(entity.User{ID: uuid.New(), Username: gophtest.Username})
+	peerKeys := []entity.ThrottleKey{entity.PeerThrottleKey(testPeerIP)}
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, peerKeys).
+		Return(time.Time{}, nil)
+	throttleMock.On("ChargeAttempt", mock.Anything, []entity.ThrottleKey{entity.UsernameThrottleKey(gophtest.Username)}).
+		Return(time.Now().Add(time.Minute), nil)
+	throttleMock.On("RefundAttempt", mock.Anything, peerKeys).
+		Return(nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, _, err := sat.FinishLogin(newTestPeerContext(), newTestProof(handshake))
+
+	require.ErrorIs(t, err, entity.ErrTooManyAttempts)
+	m.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestVerifySecondFactorCountsFailure(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(twoFactorMock, user.ID, true)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		twoFactorMock,
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.VerifySecondFactor(newTestPeerContext(), user, newTestTokenInfo(), "000000")
+
+	require.ErrorIs(t, err, entity.ErrInvalidSecondFactor)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestRecoverIsThrottled(t *testing.T) {
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, _, err := sat.Recover(newTestPeerContext(), gophtest.Username, gophtest.RecoverySecurityKey)
+
+	require.ErrorIs(t, err, entity.ErrTooManyAttempts)
+}
+
+func TestRegisterCountsAttemptPerPeer(t *testing.T) {
+	registrationKey := entity.RegistrationThrottleKey(testPeerIP)
+
+	m := &repo.UsersRepoMock{}
+	m.On("Register", mock.Anything, gophtest.Username, mock.Anything, mock.Anything, mock.Anything).
+		Return(uuid.Nil, entity.ErrUserExists)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, []entity.ThrottleKey{registrationKey}).
+		Return(time.Time{}, nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Register(
+		newTestPeerContext(),
+		gophtest.Username,
+		newTestVerifier(),
+		newTestKDFParams(),
+		entity.RecoveryKit{},
+	)
+
+	require.ErrorIs(t, err, entity.ErrUserExists)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestRegisterIsThrottled(t *testing.T) {
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, mock.Anything).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Register(
+		newTestPeerContext(),
+		gophtest.Username,
+		newTestVerifier(),
+		newTestKDFParams(),
+		entity.RecoveryKit{},
+	)
+
+	require.ErrorIs(t, err, entity.ErrTooManyAttempts)
+}
+
+func TestDeleteUserIsThrottled(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Delete(newTestPeerContext(), user, newTestProof(newTestHandshake(user)))
+
+	var throttled *entity.ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	throttleMock.AssertExpectations(t)
+}
+
+func TestChangePasswordCountsFailureOfWrongProof(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+	proof := newTestProof(handshake)
+	proof.ClientProof = []byte(gophtest.ServerProof)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.ChangePassword(
+		newTestPeerContext(),
+		user,
+		proof,
+		"",
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		newTestReencryptedSecrets(),
+		entity.RecoveryKit{},
+	)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	m.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestRenameUserRefundsAttemptOfRightProof(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+	m.On(
+		"Rename",
+		mock.Anything,
+		user.ID,
+		gophtest.Username,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).
+		Return(entity.ErrUserExists)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+	throttleMock.On("RefundAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.Rename(
+		newTestPeerContext(),
+		user,
+		gophtest.Username,
+		newTestProof(handshake),
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		newTestReencryptedSecrets(),
+		entity.RecoveryKit{},
+	)
+
+	require.ErrorIs(t, err, entity.ErrUserExists)
+	m.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestEnableTOTPCountsFailure(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	twoFactorMock := &repo.TwoFactorRepoMock{}
+	twoFactorMock.On("GetTOTP", mock.Anything, user.ID).
+		Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret()}, nil)
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		twoFactorMock,
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.EnableTOTP(newTestPeerContext(), user, "000000")
+
+	require.ErrorIs(t, err, entity.ErrInvalidSecondFactor)
+	twoFactorMock.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+}
+
+func TestDisableTOTPIsThrottled(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	err := sat.DisableTOTP(newTestPeerContext(), user, totp.Code([]byte(gophtest.TOTPSecret), time.Now()))
+
+	var throttled *entity.ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	throttleMock.AssertExpectations(t)
+}
+
+func doRecoverVaultKey(
+	t *testing.T,
+	user entity.User,
+	recovered entity.User,
+	throttleMock *repo.ThrottleRepoMock,
+) ([]byte, error) {
+	t.Helper()
+
+	m := &repo.UsersRepoMock{}
+	m.On(
+		"Recover",
+		mock.Anything,
+		gophtest.Username,
+		gophtest.RecoverySecurityKey,
+	).
+		Return(recovered, []byte(gophtest.WrappedVaultKey), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	vaultKey, err := sat.RecoverVaultKey(newTestPeerContext(), user, gophtest.RecoverySecurityKey)
+
+	m.AssertExpectations(t)
+	throttleMock.AssertExpectations(t)
+
+	return vaultKey, err
+}
+
+func TestRecoverVaultKeyRefundsAttempt(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+	throttleMock.On("RefundAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(nil)
+
+	vaultKey, err := doRecoverVaultKey(t, user, user, throttleMock)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedVaultKey), vaultKey)
+}
+
+func TestRecoverVaultKeyCountsFailureOfOtherUser(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	other := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Time{}, nil)
+
+	vaultKey, err := doRecoverVaultKey(t, user, other, throttleMock)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+	require.Nil(t, vaultKey)
+}
+
+func TestRecoverVaultKeyIsThrottled(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	throttleMock := &repo.ThrottleRepoMock{}
+	throttleMock.On("ChargeAttempt", mock.Anything, newTestThrottleKeys()).
+		Return(time.Now().Add(time.Minute), nil)
+
+	sat := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		throttleMock,
+		service.NewRevocationCache(),
+	)
+	_, err := sat.RecoverVaultKey(newTestPeerContext(), user, gophtest.RecoverySecurityKey)
+
+	var throttled *entity.ThrottledError
+	require.True(t, errors.As(err, &throttled))
+	throttleMock.AssertExpectations(t)
+}
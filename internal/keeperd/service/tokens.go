@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+)
+
+// issueTokens issues new access token and refresh token starting new family.
+// The family is recorded as login session of the device found in the context.
+func issueTokens(
+	ctx context.Context,
+	tokensRepo repo.Tokens,
+	keys *entity.Keyring,
+	user entity.User,
+) (entity.TokenPair, error) {
+	var tokens entity.TokenPair
+
+	refreshToken, err := entity.NewRefreshToken()
+	if err != nil {
+		return tokens, fmt.Errorf("issueTokens - entity.NewRefreshToken: %w", err)
+	}
+
+	device := entity.DeviceFromContext(ctx)
+	device.PeerAddress = entity.PeerIPFromContext(ctx)
+
+	record := entity.RefreshTokenRecord{
+		Hash:      refreshToken.Hash(),
+		FamilyID:  uuid.New(),
+		User:      user,
+		Device:    device,
+		ExpiresAt: time.Now().Add(entity.RefreshTokenLifeTime),
+	}
+
+	if err := tokensRepo.CreateRefreshToken(ctx, record); err != nil {
+		return tokens, fmt.Errorf("issueTokens - tokensRepo.CreateRefreshToken: %w", err)
+	}
+
+	accessToken, err := entity.NewSessionAccessToken(user, record.FamilyID, keys)
+	if err != nil {
+		return tokens, fmt.Errorf("issueTokens - entity.NewSessionAccessToken: %w", err)
+	}
+
+	return entity.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// currentSession returns ID of the login session of the access token found in the context.
+// Returns uuid.Nil if the token isn't bound to a session.
+func currentSession(ctx context.Context) uuid.UUID {
+	if token := entity.TokenInfoFromContext(ctx); token != nil {
+		return token.SessionID
+	}
+
+	return uuid.Nil
+}
+
+// issueLoginTokens issues tokens to the user who has passed the first factor.
+// Only the partial token is issued if the user has to pass second factor as well.
+func issueLoginTokens(
+	ctx context.Context,
+	tokensRepo repo.Tokens,
+	twoFactorRepo repo.TwoFactor,
+	keys *entity.Keyring,
+	user entity.User,
+) (entity.TokenPair, error) {
+	required, err := requiresSecondFactor(ctx, twoFactorRepo, user.ID)
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("issueLoginTokens - requiresSecondFactor: %w", err)
+	}
+
+	if !required {
+		tokens, err := issueTokens(ctx, tokensRepo, keys, user)
+		if err != nil {
+			return tokens, fmt.Errorf("issueLoginTokens - issueTokens: %w", err)
+		}
+
+		return tokens, nil
+	}
+
+	partialToken, err := entity.NewPartialToken(user, keys)
+	if err != nil {
+		return entity.TokenPair{}, fmt.Errorf("issueLoginTokens - entity.NewPartialToken: %w", err)
+	}
+
+	return entity.TokenPair{PartialToken: partialToken}, nil
+}
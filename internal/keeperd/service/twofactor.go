@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
+	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
+)
+
+// requiresSecondFactor tells whether the user has enabled two-factor authentication.
+func requiresSecondFactor(ctx context.Context, twoFactorRepo repo.TwoFactor, user uuid.UUID) (bool, error) {
+	tf, err := twoFactorRepo.GetTOTP(ctx, user)
+	if err != nil {
+		if errors.Is(err, entity.ErrTOTPNotEnabled) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("requiresSecondFactor - twoFactorRepo.GetTOTP: %w", err)
+	}
+
+	return tf.Enabled, nil
+}
+
+// verifySecondFactor checks TOTP code or backup code of the user.
+// Backup code is accepted only once, TOTP code is accepted only if it is newer
+// than the last accepted one.
+func verifySecondFactor(
+	ctx context.Context,
+	twoFactorRepo repo.TwoFactor,
+	totpKey creds.Password,
+	user uuid.UUID,
+	code string,
+) error {
+	tf, err := twoFactorRepo.GetTOTP(ctx, user)
+	if err != nil {
+		return fmt.Errorf("verifySecondFactor - twoFactorRepo.GetTOTP: %w", err)
+	}
+
+	if !tf.Enabled {
+		return entity.ErrTOTPNotEnabled
+	}
+
+	if len(code) != totp.Digits {
+		if err := twoFactorRepo.UseBackupCode(ctx, user, entity.BackupCode(code).Hash()); err != nil {
+			return fmt.Errorf("verifySecondFactor - twoFactorRepo.UseBackupCode: %w", err)
+		}
+
+		return nil
+	}
+
+	totpSecret, err := entity.OpenTOTPSecret(totpKey, tf.Secret)
+	if err != nil {
+		return fmt.Errorf("verifySecondFactor - entity.OpenTOTPSecret: %w", err)
+	}
+
+	step, ok := totp.Validate(totpSecret, code, time.Now())
+	if !ok {
+		return entity.ErrInvalidSecondFactor
+	}
+
+	if err := twoFactorRepo.UseTOTPStep(ctx, user, step); err != nil {
+		return fmt.Errorf("verifySecondFactor - twoFactorRepo.UseTOTPStep: %w", err)
+	}
+
+	return nil
+}
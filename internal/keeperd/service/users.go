@@ -3,33 +3,69 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/creds"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
 )
 
 var _ Users = (*UsersService)(nil)
 
 // UsersService contains business logic related to users management.
 type UsersService struct {
-	secret    creds.Password
-	usersRepo repo.Users
+	totpKey       creds.Password
+	keys          *entity.Keyring
+	usersRepo     repo.Users
+	tokensRepo    repo.Tokens
+	twoFactorRepo repo.TwoFactor
+	throttleRepo  repo.Throttle
+	revoked       *RevocationCache
 }
 
 // NewUsersService create and initializes new UsersService object.
-func NewUsersService(secret creds.Password, users repo.Users) *UsersService {
-	return &UsersService{secret, users}
+// TOTP secrets of users are sealed with totpKey.
+// Tokens of the revoked sessions are dropped from revoked, the cache shared with AuthService.
+func NewUsersService(
+	totpKey creds.Password,
+	keys *entity.Keyring,
+	users repo.Users,
+	tokens repo.Tokens,
+	twoFactor repo.TwoFactor,
+	throttle repo.Throttle,
+	revoked *RevocationCache,
+) *UsersService {
+	return &UsersService{totpKey, keys, users, tokens, twoFactor, throttle, revoked}
 }
 
-// Register creates a new user.
+// Register creates a new user and starts the login session of the user,
+// so the issued tokens are revoked together with the session, see ChangePassword.
+// Recovery is set up only if the recovery kit is provided.
+// All attempts to register are throttled per address of the caller,
+// so registration can't be used to enumerate users quickly.
+// They are counted apart from failed logins, which aren't affected by taken usernames.
 func (uc UsersService) Register(
 	ctx context.Context,
-	username, securityKey string,
-) (entity.AccessToken, error) {
-	id, err := uc.usersRepo.Register(ctx, username, securityKey)
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	recovery entity.RecoveryKit,
+) (entity.TokenPair, error) {
+	var keys []entity.ThrottleKey
+	if ip := entity.PeerIPFromContext(ctx); ip != "" {
+		keys = append(keys, entity.RegistrationThrottleKey(ip))
+	}
+
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.TokenPair{}, fmt.Errorf("UsersService - Register - chargeAttempt: %w", err)
+	}
+
+	id, err := uc.usersRepo.Register(ctx, username, verifier, kdf, recovery)
 	if err != nil {
-		return "", fmt.Errorf("UsersService - Register - uc.usersRepo.Register: %w", err)
+		return entity.TokenPair{}, fmt.Errorf("UsersService - Register - uc.usersRepo.Register: %w", err)
 	}
 
 	user := entity.User{
@@ -37,10 +73,306 @@ func (uc UsersService) Register(
 		Username: username,
 	}
 
-	accessToken, err := entity.NewAccessToken(user, uc.secret)
+	tokens, err := issueTokens(ctx, uc.tokensRepo, uc.keys, user)
+	if err != nil {
+		return tokens, fmt.Errorf("UsersService - Register - issueTokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// StartChangePassword starts SRP handshake to prove the current master password of a user.
+func (uc UsersService) StartChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	verifier, err := uc.usersRepo.GetVerifierByID(ctx, id)
+	if err != nil {
+		return entity.Challenge{}, fmt.Errorf(
+			"UsersService - StartChangePassword - uc.usersRepo.GetVerifierByID: %w",
+			err,
+		)
+	}
+
+	challenge, err := startHandshake(ctx, uc.usersRepo, &entity.User{ID: id}, verifier, clientPublic)
+	if err != nil {
+		return challenge, fmt.Errorf("UsersService - StartChangePassword - startHandshake: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// ChangePassword replaces verifier of a user and stores secrets re-encrypted with the new key.
+// The user is verified either by the proof of the current master password
+// or by the security key derived from the recovery code.
+// Returns the server proof, if the user was verified by the proof.
+// All other sessions of the user are revoked once the keys are replaced,
+// as they could have been opened by whoever knew the old password or made the user recover the vault.
+func (uc UsersService) ChangePassword(
+	ctx context.Context,
+	user entity.User,
+	proof entity.Proof,
+	recoverySecurityKey string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) ([]byte, error) {
+	var serverProof []byte
+
+	if recoverySecurityKey == "" {
+		handshake, err := uc.verifyProof(ctx, user, proof)
+		if err != nil {
+			return nil, fmt.Errorf("UsersService - ChangePassword - uc.verifyProof: %w", err)
+		}
+
+		serverProof = handshake.ServerProof
+	}
+
+	if err := uc.usersRepo.ChangePassword(
+		ctx,
+		user.ID,
+		recoverySecurityKey,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	); err != nil {
+		return nil, fmt.Errorf("UsersService - ChangePassword - uc.usersRepo.ChangePassword: %w", err)
+	}
+
+	if err := uc.tokensRepo.RevokeOtherSessions(ctx, user.ID, currentSession(ctx)); err != nil {
+		return nil, fmt.Errorf("UsersService - ChangePassword - uc.tokensRepo.RevokeOtherSessions: %w", err)
+	}
+
+	uc.revoked.forgetOtherSessions(user.ID, currentSession(ctx))
+
+	return serverProof, nil
+}
+
+// StartDelete starts SRP handshake to prove the master password of a user before deletion.
+func (uc UsersService) StartDelete(ctx context.Context, id uuid.UUID, clientPublic []byte) (entity.Challenge, error) {
+	verifier, err := uc.usersRepo.GetVerifierByID(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("AuthService - Login - entity.NewAccessToken: %w", err)
+		return entity.Challenge{}, fmt.Errorf("UsersService - StartDelete - uc.usersRepo.GetVerifierByID: %w", err)
+	}
+
+	challenge, err := startHandshake(ctx, uc.usersRepo, &entity.User{ID: id}, verifier, clientPublic)
+	if err != nil {
+		return challenge, fmt.Errorf("UsersService - StartDelete - startHandshake: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// Delete removes a user together with all the secrets, once the user is verified
+// by the proof of the master password.
+// Outstanding tokens of the user are rejected from now on, see repo.Tokens.IsAccessTokenRevoked.
+// Returns the server proof.
+func (uc UsersService) Delete(ctx context.Context, user entity.User, proof entity.Proof) ([]byte, error) {
+	handshake, err := uc.verifyProof(ctx, user, proof)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - Delete - uc.verifyProof: %w", err)
+	}
+
+	if err := uc.usersRepo.Delete(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("UsersService - Delete - uc.usersRepo.Delete: %w", err)
+	}
+
+	return handshake.ServerProof, nil
+}
+
+// StartRename starts SRP handshake to prove the master password of a user before renaming.
+func (uc UsersService) StartRename(ctx context.Context, id uuid.UUID, clientPublic []byte) (entity.Challenge, error) {
+	verifier, err := uc.usersRepo.GetVerifierByID(ctx, id)
+	if err != nil {
+		return entity.Challenge{}, fmt.Errorf("UsersService - StartRename - uc.usersRepo.GetVerifierByID: %w", err)
+	}
+
+	challenge, err := startHandshake(ctx, uc.usersRepo, &entity.User{ID: id}, verifier, clientPublic)
+	if err != nil {
+		return challenge, fmt.Errorf("UsersService - StartRename - startHandshake: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// Rename changes username of a user, once the user is verified by the proof of the master password.
+// The encryption key is derived for the new username, so the verifier is replaced
+// and secrets are stored re-encrypted with the new key, see ChangePassword.
+// All other sessions of the user are revoked, as they were opened with the old username.
+// Returns the server proof.
+func (uc UsersService) Rename(
+	ctx context.Context,
+	user entity.User,
+	username string,
+	proof entity.Proof,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) ([]byte, error) {
+	handshake, err := uc.verifyProof(ctx, user, proof)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - Rename - uc.verifyProof: %w", err)
+	}
+
+	if err := uc.usersRepo.Rename(
+		ctx,
+		user.ID,
+		username,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	); err != nil {
+		return nil, fmt.Errorf("UsersService - Rename - uc.usersRepo.Rename: %w", err)
+	}
+
+	if err := uc.tokensRepo.RevokeOtherSessions(ctx, user.ID, currentSession(ctx)); err != nil {
+		return nil, fmt.Errorf("UsersService - Rename - uc.tokensRepo.RevokeOtherSessions: %w", err)
+	}
+
+	uc.revoked.forgetOtherSessions(user.ID, currentSession(ctx))
+
+	return handshake.ServerProof, nil
+}
+
+// verifyProof checks the proof of the master password of the user, see StartChangePassword.
+// Wrong proofs are throttled together with failed logins of the user,
+// so a stolen access token can't be used to guess the password.
+func (uc UsersService) verifyProof(
+	ctx context.Context,
+	user entity.User,
+	proof entity.Proof,
+) (entity.Handshake, error) {
+	keys := throttleKeys(ctx, user.Username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return entity.Handshake{}, fmt.Errorf("verifyProof - chargeAttempt: %w", err)
+	}
+
+	handshake, err := finishHandshake(ctx, uc.usersRepo, proof)
+	if err == nil && handshake.User.ID != user.ID {
+		err = entity.ErrInvalidCredentials
+	}
+
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidCredentials); err != nil {
+		return handshake, fmt.Errorf("verifyProof - settleAttempt: %w", err)
+	}
+
+	return handshake, err
+}
+
+// GetRecoveryKey returns recovery key of a user wrapped by the vault key.
+// Returns nil if recovery is not set up.
+func (uc UsersService) GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	recoveryKey, err := uc.usersRepo.GetRecoveryKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - GetRecoveryKey - uc.usersRepo.GetRecoveryKey: %w", err)
+	}
+
+	return recoveryKey, nil
+}
+
+// SetupTOTP generates new TOTP secret of the user, pending until confirmed with EnableTOTP.
+// Returns otpauth URI of the secret to enroll it in an authenticator app.
+func (uc UsersService) SetupTOTP(ctx context.Context, user entity.User) (string, error) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return "", fmt.Errorf("UsersService - SetupTOTP - totp.NewSecret: %w", err)
+	}
+
+	sealed, err := entity.SealTOTPSecret(uc.totpKey, secret)
+	if err != nil {
+		return "", fmt.Errorf("UsersService - SetupTOTP - entity.SealTOTPSecret: %w", err)
+	}
+
+	if err := uc.twoFactorRepo.SetupTOTP(ctx, user.ID, sealed); err != nil {
+		return "", fmt.Errorf("UsersService - SetupTOTP - uc.twoFactorRepo.SetupTOTP: %w", err)
+	}
+
+	return totp.URI(entity.TOTPIssuer, user.Username, secret), nil
+}
+
+// EnableTOTP confirms enrollment of the pending TOTP secret with the first code.
+// Returns new backup codes, only their hashes are stored.
+// Wrong codes are throttled together with failed logins of the user.
+func (uc UsersService) EnableTOTP(ctx context.Context, user entity.User, code string) ([]entity.BackupCode, error) {
+	tf, err := uc.twoFactorRepo.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - uc.twoFactorRepo.GetTOTP: %w", err)
+	}
+
+	if tf.Enabled {
+		return nil, entity.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := entity.OpenTOTPSecret(uc.totpKey, tf.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - entity.OpenTOTPSecret: %w", err)
+	}
+
+	keys := throttleKeys(ctx, user.Username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - chargeAttempt: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		err = entity.ErrInvalidSecondFactor
+	}
+
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidSecondFactor); err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	backupCodes, err := entity.NewBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - entity.NewBackupCodes: %w", err)
+	}
+
+	hashes := make([][]byte, 0, len(backupCodes))
+	for _, c := range backupCodes {
+		hashes = append(hashes, c.Hash())
+	}
+
+	if err := uc.twoFactorRepo.EnableTOTP(ctx, user.ID, step, hashes); err != nil {
+		return nil, fmt.Errorf("UsersService - EnableTOTP - uc.twoFactorRepo.EnableTOTP: %w", err)
+	}
+
+	return backupCodes, nil
+}
+
+// DisableTOTP turns off two-factor authentication of the user,
+// valid TOTP code or backup code is required.
+// Wrong codes are throttled together with failed logins of the user.
+func (uc UsersService) DisableTOTP(ctx context.Context, user entity.User, code string) error {
+	keys := throttleKeys(ctx, user.Username)
+	if err := chargeAttempt(ctx, uc.throttleRepo, keys); err != nil {
+		return fmt.Errorf("UsersService - DisableTOTP - chargeAttempt: %w", err)
+	}
+
+	err := verifySecondFactor(ctx, uc.twoFactorRepo, uc.totpKey, user.ID, code)
+	if err := settleAttempt(ctx, uc.throttleRepo, keys, err, entity.ErrInvalidSecondFactor); err != nil {
+		return fmt.Errorf("UsersService - DisableTOTP - settleAttempt: %w", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("UsersService - DisableTOTP - verifySecondFactor: %w", err)
+	}
+
+	if err := uc.twoFactorRepo.DisableTOTP(ctx, user.ID); err != nil {
+		return fmt.Errorf("UsersService - DisableTOTP - uc.twoFactorRepo.DisableTOTP: %w", err)
 	}
 
-	return accessToken, nil
+	return nil
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/entity"
@@ -16,9 +17,128 @@ type UsersServiceMock struct {
 
 func (m *UsersServiceMock) Register(
 	ctx context.Context,
-	username, securityKey string,
-) (entity.AccessToken, error) {
-	args := m.Called(ctx, username, securityKey)
+	username string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	recovery entity.RecoveryKit,
+) (entity.TokenPair, error) {
+	args := m.Called(ctx, username, verifier, kdf, recovery)
 
-	return args.Get(0).(entity.AccessToken), args.Error(1)
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+func (m *UsersServiceMock) StartChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	args := m.Called(ctx, id, clientPublic)
+
+	return args.Get(0).(entity.Challenge), args.Error(1)
+}
+
+func (m *UsersServiceMock) ChangePassword(
+	ctx context.Context,
+	user entity.User,
+	proof entity.Proof,
+	recoverySecurityKey string,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) ([]byte, error) {
+	args := m.Called(
+		ctx,
+		user,
+		proof,
+		recoverySecurityKey,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersServiceMock) StartDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	args := m.Called(ctx, id, clientPublic)
+
+	return args.Get(0).(entity.Challenge), args.Error(1)
+}
+
+func (m *UsersServiceMock) Delete(ctx context.Context, user entity.User, proof entity.Proof) ([]byte, error) {
+	args := m.Called(ctx, user, proof)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersServiceMock) StartRename(
+	ctx context.Context,
+	id uuid.UUID,
+	clientPublic []byte,
+) (entity.Challenge, error) {
+	args := m.Called(ctx, id, clientPublic)
+
+	return args.Get(0).(entity.Challenge), args.Error(1)
+}
+
+func (m *UsersServiceMock) Rename(
+	ctx context.Context,
+	user entity.User,
+	username string,
+	proof entity.Proof,
+	verifier entity.Verifier,
+	kdf entity.KDFParams,
+	vaultVersion int64,
+	secrets []entity.ReencryptedSecret,
+	recovery entity.RecoveryKit,
+) ([]byte, error) {
+	args := m.Called(
+		ctx,
+		user,
+		username,
+		proof,
+		verifier,
+		kdf,
+		vaultVersion,
+		secrets,
+		recovery,
+	)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersServiceMock) GetRecoveryKey(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	args := m.Called(ctx, id)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *UsersServiceMock) SetupTOTP(ctx context.Context, user entity.User) (string, error) {
+	args := m.Called(ctx, user)
+
+	return args.String(0), args.Error(1)
+}
+
+func (m *UsersServiceMock) EnableTOTP(ctx context.Context, user entity.User, code string) ([]entity.BackupCode, error) {
+	args := m.Called(ctx, user, code)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]entity.BackupCode), args.Error(1)
+}
+
+func (m *UsersServiceMock) DisableTOTP(ctx context.Context, user entity.User, code string) error {
+	args := m.Called(ctx, user, code)
+
+	return args.Error(0)
 }
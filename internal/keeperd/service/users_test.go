@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -12,33 +13,71 @@ import (
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/repo"
 	"github.com/derpartizanen/gophkeeper/internal/keeperd/service"
 	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
 )
 
-func doRegisterUser(t *testing.T, repoErr error) (entity.AccessToken, error) {
+func doRegisterUser(t *testing.T, repoErr error) (entity.TokenPair, error) {
 	t.Helper()
 
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	kdf := newTestKDFParams()
+	recovery := entity.RecoveryKit{
+		SecurityKey: gophtest.RecoverySecurityKey,
+		VaultKey:    []byte(gophtest.WrappedVaultKey),
+		RecoveryKey: []byte(gophtest.WrappedRecoveryKey),
+	}
+
 	m := &repo.UsersRepoMock{}
 	m.On(
 		"Register",
 		mock.Anything,
 		gophtest.Username,
-		gophtest.SecurityKey,
+		newTestVerifier(),
+		kdf,
+		recovery,
 	).
-		Return(uuid.New(), repoErr)
+		Return(user.ID, repoErr)
+
+	tokensMock := &repo.TokensRepoMock{}
+	if repoErr == nil {
+		expectRefreshToken(tokensMock, user, nil)
+	}
 
-	sat := service.NewUsersService(gophtest.Secret, m)
-	token, err := sat.Register(context.Background(), gophtest.Username, gophtest.SecurityKey)
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	tokens, err := sat.Register(
+		context.Background(),
+		gophtest.Username,
+		newTestVerifier(),
+		kdf,
+		recovery,
+	)
 
 	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
 
-	return token, err
+	return tokens, err
 }
 
 func TestRegisterUser(t *testing.T) {
-	token, err := doRegisterUser(t, nil)
+	tokens, err := doRegisterUser(t, nil)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
 
+	// The token is bound to the login session, so it is revoked together with the session.
+	claims, err := tokens.AccessToken.Decode(newTestKeyring())
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
+	require.NotEmpty(t, claims.SessionID)
 }
 
 func TestRegisterUserFailsIfUserExists(t *testing.T) {
@@ -46,3 +85,900 @@ func TestRegisterUserFailsIfUserExists(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func TestStartChangePassword(t *testing.T) {
+	id := uuid.New()
+	verifier := newTestVerifier()
+
+	var saved entity.Handshake
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifierByID", mock.Anything, id).
+		Return(verifier, nil)
+	m.On("CreateHandshake", mock.Anything, mock.AnythingOfType("entity.Handshake")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(entity.Handshake)
+		}).
+		Return(nil)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	challenge, err := sat.StartChangePassword(context.Background(), id, client.Public())
+
+	require.NoError(t, err)
+	require.Equal(t, verifier.Salt, challenge.Salt)
+	require.Equal(t, challenge.HandshakeID, saved.ID)
+	require.Equal(t, id, saved.User.ID)
+	m.AssertExpectations(t)
+}
+
+func TestStartChangePasswordOnRepoFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifierByID", mock.Anything, id).
+		Return(entity.Verifier{}, entity.ErrUserNotFound)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, err = sat.StartChangePassword(context.Background(), id, client.Public())
+
+	require.ErrorIs(t, err, entity.ErrUserNotFound)
+	m.AssertExpectations(t)
+}
+
+func newTestReencryptedSecrets() []entity.ReencryptedSecret {
+	return []entity.ReencryptedSecret{
+		{
+			ID:       uuid.New(),
+			Metadata: []byte(gophtest.Metadata),
+			Data:     []byte(gophtest.TextData),
+		},
+	}
+}
+
+func doChangePassword(t *testing.T, repoErr error) ([]byte, error) {
+	t.Helper()
+
+	id := uuid.New()
+	kdf := newTestKDFParams()
+	secrets := newTestReencryptedSecrets()
+	handshake := newTestHandshake(entity.User{ID: id, Username: gophtest.Username})
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+	m.On(
+		"ChangePassword",
+		mock.Anything,
+		id,
+		"",
+		newTestVerifier(),
+		kdf,
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	).
+		Return(repoErr)
+
+	tokensMock := &repo.TokensRepoMock{}
+	if repoErr == nil {
+		tokensMock.On("RevokeOtherSessions", mock.Anything, id, uuid.Nil).
+			Return(nil)
+	}
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	serverProof, err := sat.ChangePassword(
+		context.Background(),
+		entity.User{ID: id, Username: gophtest.Username},
+		newTestProof(handshake),
+		"",
+		newTestVerifier(),
+		kdf,
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	)
+
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestChangePassword(t *testing.T) {
+	serverProof, err := doChangePassword(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestChangePasswordFailsIfVaultChanged(t *testing.T) {
+	_, err := doChangePassword(t, entity.ErrVaultChanged)
+
+	require.ErrorIs(t, err, entity.ErrVaultChanged)
+}
+
+func TestChangePasswordForgetsRevokedSessions(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+	handshake := newTestHandshake(user)
+
+	// Token of other session of the user, checked right before the change.
+	other := newTestTokenInfo()
+	other.UserID = user.ID
+	other.SessionID = uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+	m.On(
+		"ChangePassword",
+		mock.Anything,
+		user.ID,
+		"",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).
+		Return(nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	tokensMock.On("IsAccessTokenRevoked", mock.Anything, other).
+		Return(false, nil).
+		Once()
+	tokensMock.On("RevokeOtherSessions", mock.Anything, user.ID, uuid.Nil).
+		Return(nil)
+	tokensMock.On("IsAccessTokenRevoked", mock.Anything, other).
+		Return(true, nil).
+		Once()
+
+	revoked := service.NewRevocationCache()
+	auth := service.NewAuthService(
+		gophtest.Secret,
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		revoked,
+	)
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		revoked,
+	)
+
+	isRevoked, err := auth.IsRevoked(context.Background(), other)
+	require.NoError(t, err)
+	require.False(t, isRevoked)
+
+	_, err = sat.ChangePassword(
+		context.Background(),
+		user,
+		newTestProof(handshake),
+		"",
+		newTestVerifier(),
+		newTestKDFParams(),
+		gophtest.VaultVersion,
+		newTestReencryptedSecrets(),
+		entity.RecoveryKit{},
+	)
+	require.NoError(t, err)
+
+	isRevoked, err = auth.IsRevoked(context.Background(), other)
+	require.NoError(t, err)
+	require.True(t, isRevoked)
+
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithRecoveryCode(t *testing.T) {
+	id := uuid.New()
+	kdf := newTestKDFParams()
+	secrets := newTestReencryptedSecrets()
+	token := newTestTokenInfo()
+	token.SessionID = uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On(
+		"ChangePassword",
+		mock.Anything,
+		id,
+		gophtest.RecoverySecurityKey,
+		newTestVerifier(),
+		kdf,
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	).
+		Return(nil)
+
+	tokensMock := &repo.TokensRepoMock{}
+	tokensMock.On("RevokeOtherSessions", mock.Anything, id, token.SessionID).
+		Return(nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	serverProof, err := sat.ChangePassword(
+		token.WithContext(context.Background()),
+		entity.User{ID: id, Username: gophtest.Username},
+		entity.Proof{},
+		gophtest.RecoverySecurityKey,
+		newTestVerifier(),
+		kdf,
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	)
+
+	require.NoError(t, err)
+	require.Nil(t, serverProof)
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+}
+
+func TestChangePasswordWithBadProof(t *testing.T) {
+	id := uuid.New()
+
+	tt := []struct {
+		name   string
+		mutate func(h *entity.Handshake, proof *entity.Proof)
+	}{
+		{
+			name: "Change password fails on wrong proof",
+			mutate: func(_ *entity.Handshake, proof *entity.Proof) {
+				proof.ClientProof = []byte(gophtest.ServerProof)
+			},
+		},
+		{
+			name: "Change password fails if handshake expired",
+			mutate: func(h *entity.Handshake, _ *entity.Proof) {
+				h.ExpiresAt = time.Now().Add(-time.Second)
+			},
+		},
+		{
+			name: "Change password fails if handshake belongs to other user",
+			mutate: func(h *entity.Handshake, _ *entity.Proof) {
+				h.User.ID = uuid.New()
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handshake := newTestHandshake(entity.User{ID: id, Username: gophtest.Username})
+			proof := newTestProof(handshake)
+			tc.mutate(&handshake, &proof)
+
+			m := &repo.UsersRepoMock{}
+			m.On("FinishHandshake", mock.Anything, handshake.ID).
+				Return(handshake, nil)
+
+			sat := service.NewUsersService(
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			_, err := sat.ChangePassword(
+				context.Background(),
+				entity.User{ID: id, Username: gophtest.Username},
+				proof,
+				"",
+				newTestVerifier(),
+				newTestKDFParams(),
+				gophtest.VaultVersion,
+				newTestReencryptedSecrets(),
+				entity.RecoveryKit{},
+			)
+
+			require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartDelete(t *testing.T) {
+	id := uuid.New()
+	verifier := newTestVerifier()
+
+	var saved entity.Handshake
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifierByID", mock.Anything, id).
+		Return(verifier, nil)
+	m.On("CreateHandshake", mock.Anything, mock.AnythingOfType("entity.Handshake")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(entity.Handshake)
+		}).
+		Return(nil)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	challenge, err := sat.StartDelete(context.Background(), id, client.Public())
+
+	require.NoError(t, err)
+	require.Equal(t, verifier.Salt, challenge.Salt)
+	require.Equal(t, challenge.HandshakeID, saved.ID)
+	require.Equal(t, id, saved.User.ID)
+	m.AssertExpectations(t)
+}
+
+func TestStartDeleteOnRepoFailure(t *testing.T) {
+	id := uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifierByID", mock.Anything, id).
+		Return(entity.Verifier{}, entity.ErrUserNotFound)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, err = sat.StartDelete(context.Background(), id, client.Public())
+
+	require.ErrorIs(t, err, entity.ErrUserNotFound)
+	m.AssertExpectations(t)
+}
+
+func doDeleteUser(t *testing.T, repoErr error) ([]byte, error) {
+	t.Helper()
+
+	id := uuid.New()
+	handshake := newTestHandshake(entity.User{ID: id, Username: gophtest.Username})
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+	m.On("Delete", mock.Anything, id).
+		Return(repoErr)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	serverProof, err := sat.Delete(context.Background(), entity.User{ID: id, Username: gophtest.Username}, newTestProof(handshake))
+
+	m.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestDeleteUser(t *testing.T) {
+	serverProof, err := doDeleteUser(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestDeleteUserOnRepoFailure(t *testing.T) {
+	_, err := doDeleteUser(t, gophtest.ErrUnexpected)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func TestDeleteUserWithBadProof(t *testing.T) {
+	id := uuid.New()
+
+	tt := []struct {
+		name   string
+		mutate func(h *entity.Handshake, proof *entity.Proof)
+	}{
+		{
+			name: "Delete user fails on wrong proof",
+			mutate: func(_ *entity.Handshake, proof *entity.Proof) {
+				proof.ClientProof = []byte(gophtest.ServerProof)
+			},
+		},
+		{
+			name: "Delete user fails if handshake belongs to other user",
+			mutate: func(h *entity.Handshake, _ *entity.Proof) {
+				h.User.ID = uuid.New()
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handshake := newTestHandshake(entity.User{ID: id, Username: gophtest.Username})
+			proof := newTestProof(handshake)
+			tc.mutate(&handshake, &proof)
+
+			m := &repo.UsersRepoMock{}
+			m.On("FinishHandshake", mock.Anything, handshake.ID).
+				Return(handshake, nil)
+
+			sat := service.NewUsersService(
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				m,
+				&repo.TokensRepoMock{},
+				&repo.TwoFactorRepoMock{},
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			_, err := sat.Delete(context.Background(), entity.User{ID: id, Username: gophtest.Username}, proof)
+
+			require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartRename(t *testing.T) {
+	id := uuid.New()
+	verifier := newTestVerifier()
+
+	var saved entity.Handshake
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetVerifierByID", mock.Anything, id).
+		Return(verifier, nil)
+	m.On("CreateHandshake", mock.Anything, mock.AnythingOfType("entity.Handshake")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(entity.Handshake)
+		}).
+		Return(nil)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	challenge, err := sat.StartRename(context.Background(), id, client.Public())
+
+	require.NoError(t, err)
+	require.Equal(t, verifier.Salt, challenge.Salt)
+	require.Equal(t, challenge.HandshakeID, saved.ID)
+	require.Equal(t, id, saved.User.ID)
+	m.AssertExpectations(t)
+}
+
+func doRenameUser(t *testing.T, handshakeOwner uuid.UUID, repoErr error) ([]byte, error) {
+	t.Helper()
+
+	id := uuid.New()
+	if handshakeOwner == uuid.Nil {
+		handshakeOwner = id
+	}
+
+	handshake := newTestHandshake(entity.User{ID: handshakeOwner, Username: gophtest.Username})
+	kdf := newTestKDFParams()
+	secrets := newTestReencryptedSecrets()
+	token := newTestTokenInfo()
+	token.SessionID = uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On("FinishHandshake", mock.Anything, handshake.ID).
+		Return(handshake, nil)
+	m.On(
+		"Rename",
+		mock.Anything,
+		id,
+		gophtest.Username,
+		newTestVerifier(),
+		kdf,
+		int64(gophtest.VaultVersion),
+		secrets,
+		entity.RecoveryKit{},
+	).
+		Return(repoErr).
+		Maybe()
+
+	tokensMock := &repo.TokensRepoMock{}
+	if handshakeOwner == id && repoErr == nil {
+		tokensMock.On("RevokeOtherSessions", mock.Anything, id, token.SessionID).
+			Return(nil)
+	}
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		tokensMock,
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	serverProof, err := sat.Rename(
+		token.WithContext(context.Background()),
+		entity.User{ID: id, Username: gophtest.Username},
+		gophtest.Username,
+		newTestProof(handshake),
+		newTestVerifier(),
+		kdf,
+		gophtest.VaultVersion,
+		secrets,
+		entity.RecoveryKit{},
+	)
+
+	m.AssertExpectations(t)
+	tokensMock.AssertExpectations(t)
+
+	return serverProof, err
+}
+
+func TestRenameUser(t *testing.T) {
+	serverProof, err := doRenameUser(t, uuid.Nil, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.ServerProof), serverProof)
+}
+
+func TestRenameUserToTakenName(t *testing.T) {
+	_, err := doRenameUser(t, uuid.Nil, entity.ErrUserExists)
+
+	require.ErrorIs(t, err, entity.ErrUserExists)
+}
+
+func TestRenameUserWithForeignHandshake(t *testing.T) {
+	_, err := doRenameUser(t, uuid.New(), nil)
+
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
+}
+
+func doGetRecoveryKey(t *testing.T, repoErr error) ([]byte, error) {
+	t.Helper()
+
+	id := uuid.New()
+
+	m := &repo.UsersRepoMock{}
+	m.On("GetRecoveryKey", mock.Anything, id).
+		Return([]byte(gophtest.WrappedRecoveryKey), repoErr)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		m,
+		&repo.TokensRepoMock{},
+		&repo.TwoFactorRepoMock{},
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	recoveryKey, err := sat.GetRecoveryKey(context.Background(), id)
+
+	m.AssertExpectations(t)
+
+	return recoveryKey, err
+}
+
+func TestGetRecoveryKey(t *testing.T) {
+	recoveryKey, err := doGetRecoveryKey(t, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []byte(gophtest.WrappedRecoveryKey), recoveryKey)
+}
+
+func TestGetRecoveryKeyOnRepoFailure(t *testing.T) {
+	_, err := doGetRecoveryKey(t, gophtest.ErrUnexpected)
+
+	require.ErrorIs(t, err, gophtest.ErrUnexpected)
+}
+
+func TestSetupTOTP(t *testing.T) {
+	user := entity.User{ID: uuid.New(), Username: gophtest.Username}
+
+	var sealed []byte
+
+	m := &repo.TwoFactorRepoMock{}
+	m.On("SetupTOTP", mock.Anything, user.ID, mock.Anything).
+		Run(func(args mock.Arguments) {
+			sealed = args.Get(2).([]byte)
+		}).
+		Return(nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		m,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	uri, err := sat.SetupTOTP(context.Background(), user)
+	require.NoError(t, err)
+
+	secret, err := entity.OpenTOTPSecret(gophtest.TOTPKey, sealed)
+	require.NoError(t, err)
+	require.Equal(t, totp.URI(entity.TOTPIssuer, user.Username, secret), uri)
+	m.AssertExpectations(t)
+}
+
+func TestSetupTOTPOnRepoFailure(t *testing.T) {
+	m := &repo.TwoFactorRepoMock{}
+	m.On("SetupTOTP", mock.Anything, mock.Anything, mock.Anything).
+		Return(entity.ErrTOTPAlreadyEnabled)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		m,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	_, err := sat.SetupTOTP(context.Background(), entity.User{ID: uuid.New(), Username: gophtest.Username})
+
+	require.ErrorIs(t, err, entity.ErrTOTPAlreadyEnabled)
+	m.AssertExpectations(t)
+}
+
+func TestEnableTOTP(t *testing.T) {
+	id := uuid.New()
+	user := entity.User{ID: id, Username: gophtest.Username}
+
+	var saved [][]byte
+
+	m := &repo.TwoFactorRepoMock{}
+	m.On("GetTOTP", mock.Anything, id).
+		Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret()}, nil)
+	m.On("EnableTOTP", mock.Anything, id, mock.AnythingOfType("int64"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(3).([][]byte)
+		}).
+		Return(nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		m,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	codes, err := sat.EnableTOTP(context.Background(), user, totp.Code([]byte(gophtest.TOTPSecret), time.Now()))
+
+	require.NoError(t, err)
+	require.Len(t, codes, entity.BackupCodesCount)
+	require.Len(t, saved, entity.BackupCodesCount)
+
+	for i, code := range codes {
+		require.Equal(t, code.Hash(), saved[i])
+	}
+
+	m.AssertExpectations(t)
+}
+
+func TestEnableTOTPFailure(t *testing.T) {
+	validCode := totp.Code([]byte(gophtest.TOTPSecret), time.Now())
+
+	tt := []struct {
+		name     string
+		code     string
+		setup    func(m *repo.TwoFactorRepoMock)
+		expected error
+	}{
+		{
+			name: "Enable fails if TOTP is not set up",
+			code: validCode,
+			setup: func(m *repo.TwoFactorRepoMock) {
+				m.On("GetTOTP", mock.Anything, mock.Anything).
+					Return(entity.TwoFactor{}, entity.ErrTOTPNotEnabled)
+			},
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name: "Enable fails if TOTP is enabled already",
+			code: validCode,
+			setup: func(m *repo.TwoFactorRepoMock) {
+				m.On("GetTOTP", mock.Anything, mock.Anything).
+					Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret(), Enabled: true}, nil)
+			},
+			expected: entity.ErrTOTPAlreadyEnabled,
+		},
+		{
+			name: "Enable fails on wrong code",
+			code: "000000",
+			setup: func(m *repo.TwoFactorRepoMock) {
+				m.On("GetTOTP", mock.Anything, mock.Anything).
+					Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret()}, nil)
+			},
+			expected: entity.ErrInvalidSecondFactor,
+		},
+		{
+			name: "Enable fails if backup codes are not saved",
+			code: validCode,
+			setup: func(m *repo.TwoFactorRepoMock) {
+				m.On("GetTOTP", mock.Anything, mock.Anything).
+					Return(entity.TwoFactor{Secret: newTestSealedTOTPSecret()}, nil)
+				m.On("EnableTOTP", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &repo.TwoFactorRepoMock{}
+			tc.setup(m)
+
+			sat := service.NewUsersService(
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				&repo.TokensRepoMock{},
+				m,
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			codes, err := sat.EnableTOTP(
+				context.Background(),
+				entity.User{ID: uuid.New(), Username: gophtest.Username},
+				tc.code,
+			)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.Nil(t, codes)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	id := uuid.New()
+	user := entity.User{ID: id, Username: gophtest.Username}
+
+	m := &repo.TwoFactorRepoMock{}
+	expectSecondFactor(m, id, true)
+	expectTOTPStep(m, id, nil)
+	m.On("DisableTOTP", mock.Anything, id).
+		Return(nil)
+
+	sat := service.NewUsersService(
+		gophtest.TOTPKey,
+		newTestKeyring(),
+		&repo.UsersRepoMock{},
+		&repo.TokensRepoMock{},
+		m,
+		newTestThrottle(),
+		service.NewRevocationCache(),
+	)
+	err := sat.DisableTOTP(context.Background(), user, totp.Code([]byte(gophtest.TOTPSecret), time.Now()))
+
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func TestDisableTOTPFailure(t *testing.T) {
+	tt := []struct {
+		name     string
+		code     string
+		setup    func(m *repo.TwoFactorRepoMock, id uuid.UUID)
+		expected error
+	}{
+		{
+			name: "Disable fails on wrong code",
+			code: "000000",
+			setup: func(m *repo.TwoFactorRepoMock, id uuid.UUID) {
+				expectSecondFactor(m, id, true)
+			},
+			expected: entity.ErrInvalidSecondFactor,
+		},
+		{
+			name: "Disable fails if TOTP is not enabled",
+			code: "000000",
+			setup: func(m *repo.TwoFactorRepoMock, id uuid.UUID) {
+				expectSecondFactor(m, id, false)
+			},
+			expected: entity.ErrTOTPNotEnabled,
+		},
+		{
+			name: "Disable fails on repo failure",
+			code: totp.Code([]byte(gophtest.TOTPSecret), time.Now()),
+			setup: func(m *repo.TwoFactorRepoMock, id uuid.UUID) {
+				expectSecondFactor(m, id, true)
+				expectTOTPStep(m, id, nil)
+				m.On("DisableTOTP", mock.Anything, id).
+					Return(gophtest.ErrUnexpected)
+			},
+			expected: gophtest.ErrUnexpected,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+
+			m := &repo.TwoFactorRepoMock{}
+			tc.setup(m, id)
+
+			sat := service.NewUsersService(
+				gophtest.TOTPKey,
+				newTestKeyring(),
+				&repo.UsersRepoMock{},
+				&repo.TokensRepoMock{},
+				m,
+				newTestThrottle(),
+				service.NewRevocationCache(),
+			)
+			err := sat.DisableTOTP(
+				context.Background(),
+				entity.User{ID: id, Username: gophtest.Username},
+				tc.code,
+			)
+
+			require.ErrorIs(t, err, tc.expected)
+			m.AssertExpectations(t)
+		})
+	}
+}
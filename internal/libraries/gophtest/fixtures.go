@@ -7,15 +7,55 @@ import (
 )
 
 const (
-	Username                   = "admin"
-	Password    creds.Password = "1q2w3e"
-	SecurityKey                = "88bb5abaa61568b9f11ba091445d81772a3a264fb3f3054088f78baf7a091a9d"
-	AccessToken                = "SomeLongTokenInJWT"
-	Secret      creds.Password = "xxx"
+	Username                    = "admin"
+	Password     creds.Password = "1q2w3e"
+	SecurityKey                 = "88bb5abaa61568b9f11ba091445d81772a3a264fb3f3054088f78baf7a091a9d"
+	AuthKey                     = "f88bec1cde338b24acd0ecaab5ce57ebbe867a0df987f1edfe24edc9afb357f8"
+	AccessToken                 = "SomeLongTokenInJWT"
+	RefreshToken                = "SomeOpaqueRefreshToken"
+	PartialToken                = "SomePartialTokenInJWT"
+	Secret       creds.Password = "xxx"
+	TOTPKey      creds.Password = "yyy"
+
+	// Minimal Argon2id parameters accepted by keeperd.
+	Salt       = "0123456789abcdef"
+	KDFTime    = 1
+	KDFMemory  = 19 * 1024
+	KDFThreads = 1
+
+	VaultVersion int64 = 42
 
 	SecretName = "my-secret"
+	NameIndex  = "0123456789abcdef0123456789abcdef"
+	DataKey    = "wrapped data key"
 	Metadata   = "encrypted extra data"
 	TextData   = "encrypted secret data"
+
+	RecoveryCode        = "AAAA-BBBB-CCCC-DDDD-EEEE-FFFF-GGGG-HHHH"
+	RecoverySecurityKey = "0d5b3a3e2d8a9f3c1f5c2e7b6a4d9e8f7c6b5a4d3e2f1a0b9c8d7e6f5a4b3c2d"
+	WrappedVaultKey     = "wrapped vault key"
+	WrappedRecoveryKey  = "wrapped recovery key"
+
+	KeyFile = "contents of the key file"
+
+	// Not valid group elements, only for passing around.
+	SRPSalt      = "fedcba9876543210"
+	SRPVerifier  = "srp verifier"
+	ClientPublic = "client public ephemeral value"
+	ServerPublic = "server public ephemeral value"
+	ClientProof  = "0123456789abcdef0123456789abcdef"
+	ServerProof  = "fedcba9876543210fedcba9876543210"
+
+	TOTPSecret = "12345678901234567890"
+	OTPCode    = "287082"
+	BackupCode = "ABCD-EFGH"
+
+	DeviceName  = "laptop"
+	UserAgent   = "keeperctl/test"
+	PeerAddress = "192.0.2.1"
+
+	APIToken     = "gkt_SomeOpaqueAPIToken"
+	APITokenName = "ci"
 )
 
 var ErrUnexpected = errors.New("runtime error")
@@ -0,0 +1,263 @@
+// Package srp implements SRP-6a password-authenticated key exchange (RFC 5054),
+// so the service authenticates a user without ever receiving a password equivalent.
+// The service stores only the verifier derived from the password,
+// both sides prove knowledge of the same session key to each other.
+package srp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const (
+	SaltLength = 16
+
+	// PublicLength is the length of the padded public values and the verifier.
+	PublicLength = 2048 / 8
+
+	// Length of the private ephemeral values in bytes.
+	privateLength = 32
+)
+
+var (
+	ErrInvalidPublic = errors.New("invalid public ephemeral value")
+	ErrInvalidProof  = errors.New("invalid proof of the session key")
+)
+
+// 2048-bit group from RFC 5054, appendix A.
+var (
+	groupN = mustParseHex(`
+		AC6BDB41 324A9A9B F166DE5E 1389582F AF72B665 1987EE07 FC319294 3DB56050
+		A37329CB B4A099ED 8193E075 7767A13D D52312AB 4B03310D CD7F48A9 DA04FD50
+		E8083969 EDB767B0 CF609517 9A163AB3 661A05FB D5FAAAE8 2918A996 2F0B93B8
+		55F97993 EC975EEA A80D740A DBF4FF74 7359D041 D5C33EA7 1D281E44 6B14773B
+		CA97B43A 23FB8016 76BD207A 436C6481 F1D2B907 8717461A 5B9D32E6 88F87748
+		544523B5 24B0D57D 5EA77A27 75D2ECFA 032CFBDB F52FB378 61602790 04E57AE6
+		AF874E73 03CE5329 9CCC041C 7BC308D8 2A5698F3 A8D0C382 71AE35F8 E9DBFBB6
+		94B5C803 D89F7AE4 35DE236D 525F5475 9B65E372 FCD68EF2 0FA7111F 9E4AFF73`)
+	groupG = big.NewInt(2)
+
+	// Multiplier parameter k = H(N | PAD(g)).
+	multiplier = new(big.Int).SetBytes(hash(groupN.Bytes(), pad(groupG)))
+)
+
+// NewSalt generates random salt of a new verifier.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	return salt, nil
+}
+
+// Verifier computes verifier v = g^x of the password.
+// The identity is not included into x, so a user could be renamed
+// without resetting the verifier.
+func Verifier(salt, password []byte) []byte {
+	return pad(new(big.Int).Exp(groupG, privateKey(salt, password), groupN))
+}
+
+// Client is the client side of SRP-6a handshake.
+type Client struct {
+	private *big.Int
+	public  *big.Int
+
+	key   []byte
+	proof []byte
+}
+
+// NewClient generates ephemeral values of a new handshake.
+func NewClient() (*Client, error) {
+	private, err := randomPrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		private: private,
+		public:  new(big.Int).Exp(groupG, private, groupN),
+	}, nil
+}
+
+// Public returns public ephemeral value A sent to the service.
+func (c *Client) Public() []byte {
+	return pad(c.public)
+}
+
+// Proof computes the session key from the challenge of the service
+// and returns proof M1 of the key sent to the service.
+func (c *Client) Proof(salt, password, serverPublic []byte) ([]byte, error) {
+	b, err := parsePublic(serverPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	u := scrambler(c.public, b)
+	if u.Sign() == 0 {
+		return nil, ErrInvalidPublic
+	}
+
+	x := privateKey(salt, password)
+
+	// S = (B - k * g^x) ^ (a + u * x) mod N
+	base := new(big.Int).Exp(groupG, x, groupN)
+	base.Mul(base, multiplier)
+	base.Sub(b, base)
+	base.Mod(base, groupN)
+
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.private)
+
+	secret := new(big.Int).Exp(base, exp, groupN)
+
+	c.key = hash(pad(secret))
+	c.proof = clientProof(c.public, b, c.key)
+
+	return c.proof, nil
+}
+
+// VerifyServer checks proof M2 returned by the service,
+// so the client knows that the service holds the verifier.
+func (c *Client) VerifyServer(serverProof []byte) error {
+	if c.key == nil {
+		return ErrInvalidProof
+	}
+
+	if subtle.ConstantTimeCompare(serverProof, serverProofOf(c.public, c.proof, c.key)) != 1 {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
+
+// Handshake is the service side of SRP-6a handshake.
+// The private ephemeral value is dropped once the proofs are computed,
+// so only the expected proofs have to be kept until the client responds.
+type Handshake struct {
+	ServerPublic []byte // Public ephemeral value B sent to the client.
+	ClientProof  []byte // Proof M1 expected from the client.
+	ServerProof  []byte // Proof M2 sent to the client after M1 is verified.
+}
+
+// NewHandshake responds to the client public ephemeral value using the verifier of the user.
+func NewHandshake(verifier, clientPublic []byte) (Handshake, error) {
+	var h Handshake
+
+	a, err := parsePublic(clientPublic)
+	if err != nil {
+		return h, err
+	}
+
+	private, err := randomPrivate()
+	if err != nil {
+		return h, err
+	}
+
+	v := new(big.Int).SetBytes(verifier)
+
+	// B = k * v + g^b mod N
+	b := new(big.Int).Mul(multiplier, v)
+	b.Add(b, new(big.Int).Exp(groupG, private, groupN))
+	b.Mod(b, groupN)
+
+	u := scrambler(a, b)
+	if u.Sign() == 0 {
+		return h, ErrInvalidPublic
+	}
+
+	// S = (A * v^u) ^ b mod N
+	secret := new(big.Int).Exp(v, u, groupN)
+	secret.Mul(secret, a)
+	secret.Exp(secret, private, groupN)
+
+	key := hash(pad(secret))
+
+	h.ServerPublic = pad(b)
+	h.ClientProof = clientProof(a, b, key)
+	h.ServerProof = serverProofOf(a, h.ClientProof, key)
+
+	return h, nil
+}
+
+// VerifyClient checks proof M1 received from the client.
+func (h Handshake) VerifyClient(clientProof []byte) error {
+	if subtle.ConstantTimeCompare(clientProof, h.ClientProof) != 1 {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
+
+// privateKey computes x = H(salt | H(":" | password)).
+func privateKey(salt, password []byte) *big.Int {
+	return new(big.Int).SetBytes(hash(salt, hash([]byte(":"), password)))
+}
+
+// scrambler computes u = H(PAD(A) | PAD(B)).
+func scrambler(a, b *big.Int) *big.Int {
+	return new(big.Int).SetBytes(hash(pad(a), pad(b)))
+}
+
+// clientProof computes M1 = H(PAD(A) | PAD(B) | K).
+func clientProof(a, b *big.Int, key []byte) []byte {
+	return hash(pad(a), pad(b), key)
+}
+
+// serverProofOf computes M2 = H(PAD(A) | M1 | K).
+func serverProofOf(a *big.Int, clientProof, key []byte) []byte {
+	return hash(pad(a), clientProof, key)
+}
+
+// parsePublic rejects public values which are zero modulo N,
+// as they force the session key known in advance.
+func parsePublic(public []byte) (*big.Int, error) {
+	if len(public) == 0 || len(public) > PublicLength {
+		return nil, ErrInvalidPublic
+	}
+
+	v := new(big.Int).SetBytes(public)
+	if new(big.Int).Mod(v, groupN).Sign() == 0 {
+		return nil, ErrInvalidPublic
+	}
+
+	return v, nil
+}
+
+func randomPrivate() (*big.Int, error) {
+	buf := make([]byte, privateLength)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return nil, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	return new(big.Int).SetBytes(buf), nil
+}
+
+func hash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+
+	return h.Sum(nil)
+}
+
+// pad left pads value with zeroes to the length of N.
+func pad(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, PublicLength))
+}
+
+func mustParseHex(s string) *big.Int {
+	v, ok := new(big.Int).SetString(strings.Join(strings.Fields(s), ""), 16)
+	if !ok {
+		panic("srp: invalid group")
+	}
+
+	return v
+}
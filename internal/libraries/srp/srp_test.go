@@ -0,0 +1,102 @@
+package srp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/srp"
+)
+
+func newTestVerifier(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	salt, err := srp.NewSalt()
+	require.NoError(t, err)
+
+	return salt, srp.Verifier(salt, []byte(gophtest.AuthKey))
+}
+
+func TestHandshake(t *testing.T) {
+	salt, verifier := newTestVerifier(t)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat, err := srp.NewHandshake(verifier, client.Public())
+	require.NoError(t, err)
+
+	clientProof, err := client.Proof(salt, []byte(gophtest.AuthKey), sat.ServerPublic)
+	require.NoError(t, err)
+	require.NoError(t, sat.VerifyClient(clientProof))
+	require.NoError(t, client.VerifyServer(sat.ServerProof))
+}
+
+func TestHandshakeWithWrongPassword(t *testing.T) {
+	salt, verifier := newTestVerifier(t)
+
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	sat, err := srp.NewHandshake(verifier, client.Public())
+	require.NoError(t, err)
+
+	clientProof, err := client.Proof(salt, []byte(gophtest.SecurityKey), sat.ServerPublic)
+	require.NoError(t, err)
+	require.ErrorIs(t, sat.VerifyClient(clientProof), srp.ErrInvalidProof)
+	require.ErrorIs(t, client.VerifyServer(sat.ServerProof), srp.ErrInvalidProof)
+}
+
+func TestVerifierDependsOnSalt(t *testing.T) {
+	salt, verifier := newTestVerifier(t)
+
+	require.Len(t, verifier, srp.PublicLength)
+	require.Equal(t, verifier, srp.Verifier(salt, []byte(gophtest.AuthKey)))
+
+	other, err := srp.NewSalt()
+	require.NoError(t, err)
+	require.NotEqual(t, verifier, srp.Verifier(other, []byte(gophtest.AuthKey)))
+}
+
+func TestHandshakeRejectsInvalidPublic(t *testing.T) {
+	tt := []struct {
+		name   string
+		public []byte
+	}{
+		{
+			name:   "Empty value",
+			public: nil,
+		},
+		{
+			name:   "Zero value",
+			public: make([]byte, srp.PublicLength),
+		},
+		{
+			name:   "Too long value",
+			public: make([]byte, srp.PublicLength+1),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, verifier := newTestVerifier(t)
+
+			_, err := srp.NewHandshake(verifier, tc.public)
+			require.ErrorIs(t, err, srp.ErrInvalidPublic)
+
+			client, err := srp.NewClient()
+			require.NoError(t, err)
+
+			_, err = client.Proof([]byte(gophtest.Salt), []byte(gophtest.AuthKey), tc.public)
+			require.ErrorIs(t, err, srp.ErrInvalidPublic)
+		})
+	}
+}
+
+func TestVerifyServerBeforeProof(t *testing.T) {
+	client, err := srp.NewClient()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, client.VerifyServer([]byte(gophtest.AuthKey)), srp.ErrInvalidProof)
+}
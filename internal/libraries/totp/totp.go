@@ -0,0 +1,110 @@
+// Package totp implements time-based one-time passwords (RFC 6238)
+// compatible with common authenticator apps: HMAC-SHA1, 6 digits, 30 seconds period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+const (
+	// SecretLength is the length of a new secret, as recommended by RFC 4226.
+	SecretLength = 20
+
+	// Digits is the length of a code.
+	Digits = 6
+
+	// Period is the time step of codes.
+	Period = 30 * time.Second
+
+	// Skew is the number of time steps accepted before and after the current one,
+	// to tolerate clock drift and delays of user input.
+	Skew = 1
+
+	// modulo truncates HOTP value to Digits.
+	modulo = 1_000_000
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates random secret shared with the authenticator app.
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, SecretLength)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, fmt.Errorf("ReadFull error: %w", err)
+	}
+
+	return secret, nil
+}
+
+// Code computes the code of the secret at the moment.
+func Code(secret []byte, t time.Time) string {
+	return code(secret, uint64(t.Unix()/int64(Period/time.Second)))
+}
+
+// Validate checks the code against the secret at the moment, see Skew.
+// Returns the time step of the code, so the caller could reject codes
+// of the same or earlier steps, as required by RFC 6238, section 5.2.
+func Validate(secret []byte, value string, t time.Time) (int64, bool) {
+	if len(value) != Digits {
+		return 0, false
+	}
+
+	step := t.Unix() / int64(Period/time.Second)
+
+	var matched int64
+
+	valid := 0
+	for i := int64(-Skew); i <= Skew; i++ {
+		expected := code(secret, uint64(step+i))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(value)) == 1 {
+			matched = step + i
+			valid = 1
+		}
+	}
+
+	return matched, valid == 1
+}
+
+// URI composes otpauth URI of the secret to enroll it in an authenticator app,
+// usually rendered as QR code.
+func URI(issuer, account string, secret []byte) string {
+	params := url.Values{}
+	params.Set("secret", encoding.EncodeToString(secret))
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: params.Encode(),
+	}
+
+	return u.String()
+}
+
+// code computes HOTP value (RFC 4226) of the counter.
+func code(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo)
+}
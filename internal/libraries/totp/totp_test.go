@@ -0,0 +1,107 @@
+package totp_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/derpartizanen/gophkeeper/internal/libraries/gophtest"
+	"github.com/derpartizanen/gophkeeper/internal/libraries/totp"
+)
+
+// Secret of test vectors of RFC 6238, appendix B.
+var rfcSecret = []byte("12345678901234567890")
+
+// Codes are the last digits of 8-digit codes of RFC 6238 test vectors.
+func TestRFCVectors(t *testing.T) {
+	tt := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+		{unix: 20000000000, expected: "353130"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, totp.Code(rfcSecret, time.Unix(tc.unix, 0)))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	step := now.Unix() / int64(totp.Period/time.Second)
+
+	tt := []struct {
+		name     string
+		code     string
+		expected bool
+		step     int64
+	}{
+		{
+			name:     "Current code is valid",
+			code:     totp.Code(secret, now),
+			expected: true,
+			step:     step,
+		},
+		{
+			name:     "Previous code is valid",
+			code:     totp.Code(secret, now.Add(-totp.Period)),
+			expected: true,
+			step:     step - 1,
+		},
+		{
+			name:     "Next code is valid",
+			code:     totp.Code(secret, now.Add(totp.Period)),
+			expected: true,
+			step:     step + 1,
+		},
+		{
+			name:     "Outdated code is invalid",
+			code:     totp.Code(secret, now.Add(-3*totp.Period)),
+			expected: false,
+		},
+		{
+			name:     "Code of different length is invalid",
+			code:     totp.Code(secret, now) + "0",
+			expected: false,
+		},
+		{
+			name:     "Empty code is invalid",
+			code:     "",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, valid := totp.Validate(secret, tc.code, now)
+
+			require.Equal(t, tc.expected, valid)
+			require.Equal(t, tc.step, matched)
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("GophKeeper", gophtest.Username, rfcSecret)
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/GophKeeper:"+gophtest.Username, u.Path)
+	require.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", u.Query().Get("secret"))
+	require.Equal(t, "GophKeeper", u.Query().Get("issuer"))
+}
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS kdf_algorithm integer not null default 0,
+    ADD COLUMN IF NOT EXISTS kdf_salt      bytea,
+    ADD COLUMN IF NOT EXISTS kdf_time      integer not null default 0,
+    ADD COLUMN IF NOT EXISTS kdf_memory    integer not null default 0,
+    ADD COLUMN IF NOT EXISTS kdf_threads   integer not null default 0;
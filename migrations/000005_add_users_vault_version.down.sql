@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS secrets_bump_vault_version ON secrets;
+
+DROP FUNCTION IF EXISTS bump_vault_version();
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS vault_version;
@@ -0,0 +1,17 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS vault_version bigint not null default 0;
+
+-- Any change of secrets makes ciphertexts prepared for master password change stale.
+CREATE OR REPLACE FUNCTION bump_vault_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE users
+    SET vault_version = vault_version + 1
+    WHERE user_id = COALESCE(NEW.owner_id, OLD.owner_id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER secrets_bump_vault_version
+    AFTER INSERT OR UPDATE OR DELETE ON secrets
+    FOR EACH ROW EXECUTE FUNCTION bump_vault_version();
@@ -0,0 +1,2 @@
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS data_key bytea;
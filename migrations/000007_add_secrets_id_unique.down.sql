@@ -0,0 +1,2 @@
+ALTER TABLE secrets
+    DROP CONSTRAINT IF EXISTS secrets_secret_id_key;
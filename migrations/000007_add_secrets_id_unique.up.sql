@@ -0,0 +1,2 @@
+ALTER TABLE secrets
+    ADD CONSTRAINT secrets_secret_id_key UNIQUE (secret_id);
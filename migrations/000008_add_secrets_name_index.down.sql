@@ -0,0 +1,16 @@
+-- Names encrypted by client can't be converted back to plain text on the server,
+-- so the migration could be reverted only while all secrets keep legacy plain text names.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM secrets WHERE name_index IS NOT NULL) THEN
+        RAISE EXCEPTION 'irreversible migration: secret names are encrypted by client';
+    END IF;
+END
+$$;
+
+ALTER TABLE secrets
+    DROP CONSTRAINT IF EXISTS secrets_owner_id_name_index_key,
+    DROP COLUMN IF EXISTS name_index,
+    ALTER COLUMN name TYPE varchar(256) USING convert_from(name, 'UTF8'),
+    DROP CONSTRAINT IF EXISTS secrets_pkey,
+    ADD PRIMARY KEY (name, owner_id);
@@ -0,0 +1,8 @@
+-- Names are encrypted by client, uniqueness is enforced by the blind index.
+-- Legacy secrets keep plain text names without index until re-encrypted by client.
+ALTER TABLE secrets
+    DROP CONSTRAINT IF EXISTS secrets_pkey,
+    ADD PRIMARY KEY (secret_id),
+    ALTER COLUMN name TYPE bytea USING convert_to(name, 'UTF8'),
+    ADD COLUMN IF NOT EXISTS name_index bytea,
+    ADD CONSTRAINT secrets_owner_id_name_index_key UNIQUE (owner_id, name_index);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS recovery_key,
+    DROP COLUMN IF EXISTS recovery_vault_key,
+    DROP COLUMN IF EXISTS recovery_security_key;
@@ -0,0 +1,5 @@
+-- Recovery kit is optional, all columns are NULL if recovery is not set up.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS recovery_security_key text,
+    ADD COLUMN IF NOT EXISTS recovery_vault_key bytea,
+    ADD COLUMN IF NOT EXISTS recovery_key bytea;
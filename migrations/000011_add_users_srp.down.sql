@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS srp_handshakes;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS srp_verifier,
+    DROP COLUMN IF EXISTS srp_salt;
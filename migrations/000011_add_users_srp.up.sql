@@ -0,0 +1,15 @@
+-- Users registered before SRP have no verifier until the next login,
+-- the security key is dropped once the verifier is set.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS srp_salt bytea,
+    ADD COLUMN IF NOT EXISTS srp_verifier bytea,
+    ALTER COLUMN security_key DROP NOT NULL;
+
+-- Pending SRP handshakes, each of them could be finished only once.
+CREATE TABLE IF NOT EXISTS srp_handshakes (
+    handshake_id uuid primary key,
+    user_id      uuid not null REFERENCES users (user_id) on delete cascade,
+    client_proof bytea not null,
+    server_proof bytea not null,
+    expires_at   timestamptz not null
+);
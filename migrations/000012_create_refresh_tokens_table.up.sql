@@ -0,0 +1,11 @@
+-- Only hashes of refresh tokens are stored.
+-- Used tokens are kept until expiration to detect reuse.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash bytea primary key,
+    family_id  uuid not null,
+    user_id    uuid not null REFERENCES users (user_id) on delete cascade,
+    used       boolean not null default false,
+    expires_at timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
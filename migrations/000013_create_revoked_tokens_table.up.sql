@@ -0,0 +1,6 @@
+-- Access tokens revoked before expiration, identified by jti claim.
+-- Records are useless after expiration of the token and removed on the way.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        uuid primary key,
+    expires_at timestamptz not null
+);
@@ -0,0 +1,14 @@
+-- TOTP secrets are sealed by the service key.
+-- The secret is pending until enrollment is confirmed with the first code.
+CREATE TABLE IF NOT EXISTS two_factor (
+    user_id     uuid primary key REFERENCES users (user_id) on delete cascade,
+    totp_secret bytea not null,
+    enabled     boolean not null default false
+);
+
+-- Only hashes of backup codes are stored, each code is removed once used.
+CREATE TABLE IF NOT EXISTS backup_codes (
+    user_id   uuid not null REFERENCES users (user_id) on delete cascade,
+    code_hash bytea not null,
+    PRIMARY KEY (user_id, code_hash)
+);
@@ -0,0 +1,11 @@
+-- Failed login attempts counted per username and per peer IP address.
+-- The table is shared by all replicas of the service, so lockout can't be bypassed
+-- by hitting another replica.
+CREATE TABLE IF NOT EXISTS login_failures (
+    scope           text not null,
+    subject         text not null,
+    failures        integer not null default 0,
+    blocked_until   timestamptz,
+    last_failure_at timestamptz not null,
+    PRIMARY KEY (scope, subject)
+);
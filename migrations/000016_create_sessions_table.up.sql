@@ -0,0 +1,22 @@
+-- Login sessions, each session is a family of refresh tokens.
+-- The session is removed together with its tokens once revoked or expired.
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id   uuid primary key,
+    user_id      uuid not null REFERENCES users (user_id) on delete cascade,
+    device_name  text not null default '',
+    user_agent   text not null default '',
+    peer_address text not null default '',
+    created_at   timestamptz not null default now(),
+    last_seen_at timestamptz not null default now(),
+    expires_at   timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+INSERT INTO sessions (session_id, user_id, expires_at)
+    SELECT family_id, user_id, max(expires_at) FROM refresh_tokens GROUP BY family_id, user_id
+    ON CONFLICT (session_id) DO NOTHING;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey
+    FOREIGN KEY (family_id) REFERENCES sessions (session_id) on delete cascade;
@@ -0,0 +1,13 @@
+-- Long-lived API tokens for automation, only hashes of the tokens are stored.
+-- Empty list of secret IDs grants access to all secrets of the user.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    token_id   uuid primary key,
+    user_id    uuid not null REFERENCES users (user_id) on delete cascade,
+    name       text not null,
+    token_hash bytea not null UNIQUE,
+    read_only  boolean not null default true,
+    secret_ids uuid[] not null default '{}',
+    created_at timestamptz not null default now(),
+    expires_at timestamptz,
+    UNIQUE (user_id, name)
+);
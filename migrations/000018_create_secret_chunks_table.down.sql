@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS secret_chunks;
+
+ALTER TABLE secrets
+    DROP COLUMN IF EXISTS blob_size;
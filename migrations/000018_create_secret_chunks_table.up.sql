@@ -0,0 +1,11 @@
+-- Large binary secrets are uploaded as a stream of chunks encrypted by client.
+-- Data of such secrets is empty, blob_size is the total size of their chunks.
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS blob_size bigint not null default 0;
+
+CREATE TABLE IF NOT EXISTS secret_chunks (
+    secret_id uuid not null REFERENCES secrets (secret_id) on delete cascade,
+    seq       integer not null,
+    data      bytea not null,
+    primary key (secret_id, seq)
+);
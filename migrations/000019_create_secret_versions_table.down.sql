@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS secret_versions;
+
+ALTER TABLE secrets
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS version;
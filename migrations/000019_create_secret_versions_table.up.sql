@@ -0,0 +1,18 @@
+-- Every update of a secret archives its previous state as an immutable revision.
+-- Revisions are encrypted by client the same way as the secret itself,
+-- only the configured number of the latest revisions is kept per secret.
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS version integer not null default 1,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+
+CREATE TABLE IF NOT EXISTS secret_versions (
+    secret_id  uuid not null REFERENCES secrets (secret_id) on delete cascade,
+    version    integer not null,
+    name       bytea not null,
+    name_index bytea,
+    data_key   bytea,
+    metadata   bytea,
+    data       bytea not null,
+    created_at timestamptz not null,
+    primary key (secret_id, version)
+);
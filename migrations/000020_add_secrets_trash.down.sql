@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS secrets_deleted_at_idx;
+DROP INDEX IF EXISTS secrets_owner_id_name_index_key;
+
+DELETE FROM secrets WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE secrets
+    DROP COLUMN IF EXISTS deleted_at,
+    ADD CONSTRAINT secrets_owner_id_name_index_key UNIQUE (owner_id, name_index);
@@ -0,0 +1,11 @@
+-- Deleted secrets are kept in trash until restored, purged or expired.
+-- Names of trashed secrets don't block creation of new secrets with the same names.
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    DROP CONSTRAINT IF EXISTS secrets_owner_id_name_index_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS secrets_owner_id_name_index_key
+    ON secrets (owner_id, name_index) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS secrets_deleted_at_idx
+    ON secrets (deleted_at) WHERE deleted_at IS NOT NULL;
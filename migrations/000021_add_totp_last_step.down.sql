@@ -0,0 +1,2 @@
+ALTER TABLE two_factor
+    DROP COLUMN IF EXISTS last_step;
@@ -0,0 +1,4 @@
+-- The time step of the last accepted TOTP code,
+-- codes of the same or earlier steps are rejected, so they can't be replayed.
+ALTER TABLE two_factor
+    ADD COLUMN IF NOT EXISTS last_step bigint;
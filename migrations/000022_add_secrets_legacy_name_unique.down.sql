@@ -0,0 +1 @@
+DROP INDEX IF EXISTS secrets_owner_id_legacy_name_key;
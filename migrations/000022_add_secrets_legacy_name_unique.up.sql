@@ -0,0 +1,4 @@
+-- Legacy secrets keep plain text names without blind index until re-encrypted by client,
+-- so their names must stay unique among not deleted secrets of the owner.
+CREATE UNIQUE INDEX IF NOT EXISTS secrets_owner_id_legacy_name_key
+    ON secrets (owner_id, name) WHERE name_index IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: api_tokens.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	APITokens_Create_FullMethodName = "/proto.APITokens/Create"
+	APITokens_List_FullMethodName   = "/proto.APITokens/List"
+	APITokens_Revoke_FullMethodName = "/proto.APITokens/Revoke"
+)
+
+// APITokensClient is the client API for APITokens service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type APITokensClient interface {
+	// Create new API token of current user.
+	// Requires valid access_token passed in metadata.
+	Create(ctx context.Context, in *CreateAPITokenRequest, opts ...grpc.CallOption) (*CreateAPITokenResponse, error)
+	// List API tokens of current user.
+	// Requires valid access_token passed in metadata.
+	List(ctx context.Context, in *ListAPITokensRequest, opts ...grpc.CallOption) (*ListAPITokensResponse, error)
+	// Revoke API token of current user.
+	// Requires valid access_token passed in metadata.
+	Revoke(ctx context.Context, in *RevokeAPITokenRequest, opts ...grpc.CallOption) (*RevokeAPITokenResponse, error)
+}
+
+type aPITokensClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAPITokensClient(cc grpc.ClientConnInterface) APITokensClient {
+	return &aPITokensClient{cc}
+}
+
+func (c *aPITokensClient) Create(ctx context.Context, in *CreateAPITokenRequest, opts ...grpc.CallOption) (*CreateAPITokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPITokenResponse)
+	err := c.cc.Invoke(ctx, APITokens_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPITokensClient) List(ctx context.Context, in *ListAPITokensRequest, opts ...grpc.CallOption) (*ListAPITokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPITokensResponse)
+	err := c.cc.Invoke(ctx, APITokens_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPITokensClient) Revoke(ctx context.Context, in *RevokeAPITokenRequest, opts ...grpc.CallOption) (*RevokeAPITokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAPITokenResponse)
+	err := c.cc.Invoke(ctx, APITokens_Revoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// APITokensServer is the server API for APITokens service.
+// All implementations must embed UnimplementedAPITokensServer
+// for forward compatibility.
+type APITokensServer interface {
+	// Create new API token of current user.
+	// Requires valid access_token passed in metadata.
+	Create(context.Context, *CreateAPITokenRequest) (*CreateAPITokenResponse, error)
+	// List API tokens of current user.
+	// Requires valid access_token passed in metadata.
+	List(context.Context, *ListAPITokensRequest) (*ListAPITokensResponse, error)
+	// Revoke API token of current user.
+	// Requires valid access_token passed in metadata.
+	Revoke(context.Context, *RevokeAPITokenRequest) (*RevokeAPITokenResponse, error)
+	mustEmbedUnimplementedAPITokensServer()
+}
+
+// UnimplementedAPITokensServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAPITokensServer struct{}
+
+func (UnimplementedAPITokensServer) Create(context.Context, *CreateAPITokenRequest) (*CreateAPITokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedAPITokensServer) List(context.Context, *ListAPITokensRequest) (*ListAPITokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedAPITokensServer) Revoke(context.Context, *RevokeAPITokenRequest) (*RevokeAPITokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revoke not implemented")
+}
+func (UnimplementedAPITokensServer) mustEmbedUnimplementedAPITokensServer() {}
+func (UnimplementedAPITokensServer) testEmbeddedByValue()                   {}
+
+// UnsafeAPITokensServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to APITokensServer will
+// result in compilation errors.
+type UnsafeAPITokensServer interface {
+	mustEmbedUnimplementedAPITokensServer()
+}
+
+func RegisterAPITokensServer(s grpc.ServiceRegistrar, srv APITokensServer) {
+	// If the following call pancis, it indicates UnimplementedAPITokensServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&APITokens_ServiceDesc, srv)
+}
+
+func _APITokens_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPITokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APITokensServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: APITokens_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APITokensServer).Create(ctx, req.(*CreateAPITokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _APITokens_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPITokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APITokensServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: APITokens_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APITokensServer).List(ctx, req.(*ListAPITokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _APITokens_Revoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPITokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APITokensServer).Revoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: APITokens_Revoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APITokensServer).Revoke(ctx, req.(*RevokeAPITokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// APITokens_ServiceDesc is the grpc.ServiceDesc for APITokens service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var APITokens_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.APITokens",
+	HandlerType: (*APITokensServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _APITokens_Create_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _APITokens_List_Handler,
+		},
+		{
+			MethodName: "Revoke",
+			Handler:    _APITokens_Revoke_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api_tokens.proto",
+}
@@ -0,0 +1,56 @@
+package proto
+
+import (
+	context "context"
+
+	"github.com/stretchr/testify/mock"
+	grpc "google.golang.org/grpc"
+)
+
+var _ APITokensClient = (*APITokensClientMock)(nil)
+
+type APITokensClientMock struct {
+	mock.Mock
+}
+
+func (m *APITokensClientMock) Create(
+	ctx context.Context,
+	in *CreateAPITokenRequest,
+	opts ...grpc.CallOption,
+) (*CreateAPITokenResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*CreateAPITokenResponse), args.Error(1)
+}
+
+func (m *APITokensClientMock) List(
+	ctx context.Context,
+	in *ListAPITokensRequest,
+	opts ...grpc.CallOption,
+) (*ListAPITokensResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*ListAPITokensResponse), args.Error(1)
+}
+
+func (m *APITokensClientMock) Revoke(
+	ctx context.Context,
+	in *RevokeAPITokenRequest,
+	opts ...grpc.CallOption,
+) (*RevokeAPITokenResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RevokeAPITokenResponse), args.Error(1)
+}
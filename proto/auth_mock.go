@@ -13,6 +13,20 @@ type AuthClientMock struct {
 	mock.Mock
 }
 
+func (m *AuthClientMock) Prelogin(
+	ctx context.Context,
+	in *PreloginRequest,
+	opts ...grpc.CallOption,
+) (*PreloginResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*PreloginResponse), args.Error(1)
+}
+
 func (m *AuthClientMock) Login(
 	ctx context.Context,
 	in *LoginRequest,
@@ -26,3 +40,115 @@ func (m *AuthClientMock) Login(
 
 	return args.Get(0).(*LoginResponse), args.Error(1)
 }
+
+func (m *AuthClientMock) Refresh(
+	ctx context.Context,
+	in *RefreshRequest,
+	opts ...grpc.CallOption,
+) (*RefreshResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RefreshResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) Logout(
+	ctx context.Context,
+	in *LogoutRequest,
+	opts ...grpc.CallOption,
+) (*LogoutResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*LogoutResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) Recover(
+	ctx context.Context,
+	in *RecoverRequest,
+	opts ...grpc.CallOption,
+) (*RecoverResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RecoverResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) RecoverVaultKey(
+	ctx context.Context,
+	in *RecoverVaultKeyRequest,
+	opts ...grpc.CallOption,
+) (*RecoverVaultKeyResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RecoverVaultKeyResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) StartLogin(
+	ctx context.Context,
+	in *StartLoginRequest,
+	opts ...grpc.CallOption,
+) (*StartLoginResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*StartLoginResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) FinishLogin(
+	ctx context.Context,
+	in *FinishLoginRequest,
+	opts ...grpc.CallOption,
+) (*FinishLoginResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*FinishLoginResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) VerifySecondFactor(
+	ctx context.Context,
+	in *VerifySecondFactorRequest,
+	opts ...grpc.CallOption,
+) (*VerifySecondFactorResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*VerifySecondFactorResponse), args.Error(1)
+}
+
+func (m *AuthClientMock) GetJWKS(
+	ctx context.Context,
+	in *GetJWKSRequest,
+	opts ...grpc.CallOption,
+) (*GetJWKSResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*GetJWKSResponse), args.Error(1)
+}
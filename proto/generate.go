@@ -1,3 +1,3 @@
 package proto
 
-//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative auth.proto data.proto secrets.proto users.proto
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative api_tokens.proto auth.proto data.proto kdf.proto secrets.proto sessions.proto srp.proto users.proto
@@ -0,0 +1,292 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: kdf.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Function used by client to derive encryption key from master password.
+type KDFAlgorithm int32
+
+const (
+	KDFAlgorithm_KDF_SHA256   KDFAlgorithm = 0 // Legacy single SHA-256 pass over username and password.
+	KDFAlgorithm_KDF_ARGON2ID KDFAlgorithm = 1 // Argon2id with random per-user salt.
+)
+
+// Enum value maps for KDFAlgorithm.
+var (
+	KDFAlgorithm_name = map[int32]string{
+		0: "KDF_SHA256",
+		1: "KDF_ARGON2ID",
+	}
+	KDFAlgorithm_value = map[string]int32{
+		"KDF_SHA256":   0,
+		"KDF_ARGON2ID": 1,
+	}
+)
+
+func (x KDFAlgorithm) Enum() *KDFAlgorithm {
+	p := new(KDFAlgorithm)
+	*p = x
+	return p
+}
+
+func (x KDFAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KDFAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_kdf_proto_enumTypes[0].Descriptor()
+}
+
+func (KDFAlgorithm) Type() protoreflect.EnumType {
+	return &file_kdf_proto_enumTypes[0]
+}
+
+func (x KDFAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KDFAlgorithm.Descriptor instead.
+func (KDFAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_kdf_proto_rawDescGZIP(), []int{0}
+}
+
+// Keys derived by client from the master key.
+type KeySchedule int32
+
+const (
+	// Master key is used both as security key and to encrypt secrets.
+	KeySchedule_KEY_SCHEDULE_LEGACY KeySchedule = 0
+	// Security key is derived from master key with HKDF, secrets are still encrypted by master key.
+	// Legacy accounts are upgraded to this schedule on login.
+	KeySchedule_KEY_SCHEDULE_AUTH_SUBKEY KeySchedule = 1
+	// Both security key and vault key are derived from master key with HKDF.
+	KeySchedule_KEY_SCHEDULE_SUBKEYS KeySchedule = 2
+)
+
+// Enum value maps for KeySchedule.
+var (
+	KeySchedule_name = map[int32]string{
+		0: "KEY_SCHEDULE_LEGACY",
+		1: "KEY_SCHEDULE_AUTH_SUBKEY",
+		2: "KEY_SCHEDULE_SUBKEYS",
+	}
+	KeySchedule_value = map[string]int32{
+		"KEY_SCHEDULE_LEGACY":      0,
+		"KEY_SCHEDULE_AUTH_SUBKEY": 1,
+		"KEY_SCHEDULE_SUBKEYS":     2,
+	}
+)
+
+func (x KeySchedule) Enum() *KeySchedule {
+	p := new(KeySchedule)
+	*p = x
+	return p
+}
+
+func (x KeySchedule) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeySchedule) Descriptor() protoreflect.EnumDescriptor {
+	return file_kdf_proto_enumTypes[1].Descriptor()
+}
+
+func (KeySchedule) Type() protoreflect.EnumType {
+	return &file_kdf_proto_enumTypes[1]
+}
+
+func (x KeySchedule) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeySchedule.Descriptor instead.
+func (KeySchedule) EnumDescriptor() ([]byte, []int) {
+	return file_kdf_proto_rawDescGZIP(), []int{1}
+}
+
+// Parameters of the key derivation function chosen by client at registration.
+type KDFParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Algorithm     KDFAlgorithm           `protobuf:"varint,1,opt,name=algorithm,proto3,enum=proto.KDFAlgorithm" json:"algorithm,omitempty"`                       // Key derivation function.
+	Salt          []byte                 `protobuf:"bytes,2,opt,name=salt,proto3" json:"salt,omitempty"`                                                          // Random per-user salt.
+	Time          uint32                 `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`                                                         // Number of passes over the memory.
+	Memory        uint32                 `protobuf:"varint,4,opt,name=memory,proto3" json:"memory,omitempty"`                                                     // Size of the memory in KiB.
+	Threads       uint32                 `protobuf:"varint,5,opt,name=threads,proto3" json:"threads,omitempty"`                                                   // Number of threads.
+	KeySchedule   KeySchedule            `protobuf:"varint,6,opt,name=key_schedule,json=keySchedule,proto3,enum=proto.KeySchedule" json:"key_schedule,omitempty"` // Keys derived from master key.
+	KeyFile       bool                   `protobuf:"varint,7,opt,name=key_file,json=keyFile,proto3" json:"key_file,omitempty"`                                    // Contents of a key file are mixed into master key.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KDFParams) Reset() {
+	*x = KDFParams{}
+	mi := &file_kdf_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KDFParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KDFParams) ProtoMessage() {}
+
+func (x *KDFParams) ProtoReflect() protoreflect.Message {
+	mi := &file_kdf_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KDFParams.ProtoReflect.Descriptor instead.
+func (*KDFParams) Descriptor() ([]byte, []int) {
+	return file_kdf_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KDFParams) GetAlgorithm() KDFAlgorithm {
+	if x != nil {
+		return x.Algorithm
+	}
+	return KDFAlgorithm_KDF_SHA256
+}
+
+func (x *KDFParams) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *KDFParams) GetTime() uint32 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *KDFParams) GetMemory() uint32 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
+func (x *KDFParams) GetThreads() uint32 {
+	if x != nil {
+		return x.Threads
+	}
+	return 0
+}
+
+func (x *KDFParams) GetKeySchedule() KeySchedule {
+	if x != nil {
+		return x.KeySchedule
+	}
+	return KeySchedule_KEY_SCHEDULE_LEGACY
+}
+
+func (x *KDFParams) GetKeyFile() bool {
+	if x != nil {
+		return x.KeyFile
+	}
+	return false
+}
+
+var File_kdf_proto protoreflect.FileDescriptor
+
+const file_kdf_proto_rawDesc = "" +
+	"\n" +
+	"\tkdf.proto\x12\x05proto\"\xea\x01\n" +
+	"\tKDFParams\x121\n" +
+	"\talgorithm\x18\x01 \x01(\x0e2\x13.proto.KDFAlgorithmR\talgorithm\x12\x12\n" +
+	"\x04salt\x18\x02 \x01(\fR\x04salt\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\rR\x04time\x12\x16\n" +
+	"\x06memory\x18\x04 \x01(\rR\x06memory\x12\x18\n" +
+	"\athreads\x18\x05 \x01(\rR\athreads\x125\n" +
+	"\fkey_schedule\x18\x06 \x01(\x0e2\x12.proto.KeyScheduleR\vkeySchedule\x12\x19\n" +
+	"\bkey_file\x18\a \x01(\bR\akeyFile*0\n" +
+	"\fKDFAlgorithm\x12\x0e\n" +
+	"\n" +
+	"KDF_SHA256\x10\x00\x12\x10\n" +
+	"\fKDF_ARGON2ID\x10\x01*^\n" +
+	"\vKeySchedule\x12\x17\n" +
+	"\x13KEY_SCHEDULE_LEGACY\x10\x00\x12\x1c\n" +
+	"\x18KEY_SCHEDULE_AUTH_SUBKEY\x10\x01\x12\x18\n" +
+	"\x14KEY_SCHEDULE_SUBKEYS\x10\x02B+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
+
+var (
+	file_kdf_proto_rawDescOnce sync.Once
+	file_kdf_proto_rawDescData []byte
+)
+
+func file_kdf_proto_rawDescGZIP() []byte {
+	file_kdf_proto_rawDescOnce.Do(func() {
+		file_kdf_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kdf_proto_rawDesc), len(file_kdf_proto_rawDesc)))
+	})
+	return file_kdf_proto_rawDescData
+}
+
+var file_kdf_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_kdf_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_kdf_proto_goTypes = []any{
+	(KDFAlgorithm)(0), // 0: proto.KDFAlgorithm
+	(KeySchedule)(0),  // 1: proto.KeySchedule
+	(*KDFParams)(nil), // 2: proto.KDFParams
+}
+var file_kdf_proto_depIdxs = []int32{
+	0, // 0: proto.KDFParams.algorithm:type_name -> proto.KDFAlgorithm
+	1, // 1: proto.KDFParams.key_schedule:type_name -> proto.KeySchedule
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_kdf_proto_init() }
+func file_kdf_proto_init() {
+	if File_kdf_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kdf_proto_rawDesc), len(file_kdf_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_kdf_proto_goTypes,
+		DependencyIndexes: file_kdf_proto_depIdxs,
+		EnumInfos:         file_kdf_proto_enumTypes,
+		MessageInfos:      file_kdf_proto_msgTypes,
+	}.Build()
+	File_kdf_proto = out.File
+	file_kdf_proto_goTypes = nil
+	file_kdf_proto_depIdxs = nil
+}
@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -77,10 +78,16 @@ func (DataKind) EnumDescriptor() ([]byte, []int) {
 
 type Secret struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                          // ID of a secret in UUIDv4 form.
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                      // Name of a secret.
-	Kind          DataKind               `protobuf:"varint,3,opt,name=kind,proto3,enum=proto.DataKind" json:"kind,omitempty"` // Type of stored data.
-	Metadata      []byte                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`              // Arbitrary encrypted description (activation codes, bank names etc).
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                 // ID of a secret in UUIDv4 form.
+	Name          []byte                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                             // Encrypted name of a secret, plain text for legacy secrets without name_index.
+	Kind          DataKind               `protobuf:"varint,3,opt,name=kind,proto3,enum=proto.DataKind" json:"kind,omitempty"`        // Type of stored data.
+	Metadata      []byte                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`                     // Arbitrary encrypted description (activation codes, bank names etc).
+	DataKey       []byte                 `protobuf:"bytes,5,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`        // Data key of the secret wrapped by the vault key, empty for legacy secrets encrypted by the vault key.
+	NameIndex     []byte                 `protobuf:"bytes,6,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"`  // Blind index of the name, empty for legacy secrets with plain text names.
+	BlobSize      int64                  `protobuf:"varint,7,opt,name=blob_size,json=blobSize,proto3" json:"blob_size,omitempty"`    // Total size of encrypted chunks if data is stored as a blob, see DownloadBlob.
+	Version       int32                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`                      // Version of a secret incremented on every update, see ListVersions and UpdateSecretRequest.
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`  // Time the version was created.
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"` // Time a secret was moved to trash, see ListTrash.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -122,11 +129,11 @@ func (x *Secret) GetId() string {
 	return ""
 }
 
-func (x *Secret) GetName() string {
+func (x *Secret) GetName() []byte {
 	if x != nil {
 		return x.Name
 	}
-	return ""
+	return nil
 }
 
 func (x *Secret) GetKind() DataKind {
@@ -143,12 +150,57 @@ func (x *Secret) GetMetadata() []byte {
 	return nil
 }
 
+func (x *Secret) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+func (x *Secret) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
+func (x *Secret) GetBlobSize() int64 {
+	if x != nil {
+		return x.BlobSize
+	}
+	return 0
+}
+
+func (x *Secret) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Secret) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Secret) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
 type CreateSecretRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                      // Name of a secret.
-	Metadata      []byte                 `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`              // Arbitrary description data encrypted by client.
-	Kind          DataKind               `protobuf:"varint,3,opt,name=kind,proto3,enum=proto.DataKind" json:"kind,omitempty"` // Type of stored data.
-	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`                      // Actual secret data encrypted by client, see data.proto.
+	Name          []byte                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                            // Name of a secret encrypted by client.
+	Metadata      []byte                 `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`                    // Arbitrary description data encrypted by client.
+	Kind          DataKind               `protobuf:"varint,3,opt,name=kind,proto3,enum=proto.DataKind" json:"kind,omitempty"`       // Type of stored data.
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`                            // Actual secret data encrypted by client, see data.proto.
+	DataKey       []byte                 `protobuf:"bytes,5,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`       // Random data key encrypting name, metadata and data, wrapped by the vault key.
+	Id            string                 `protobuf:"bytes,6,opt,name=id,proto3" json:"id,omitempty"`                                // ID of a secret in UUIDv4 form chosen by client, encrypted data is bound to it.
+	NameIndex     []byte                 `protobuf:"bytes,7,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"` // Keyed hash of the normalized name computed by client, unique per user.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -183,11 +235,11 @@ func (*CreateSecretRequest) Descriptor() ([]byte, []int) {
 	return file_secrets_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *CreateSecretRequest) GetName() string {
+func (x *CreateSecretRequest) GetName() []byte {
 	if x != nil {
 		return x.Name
 	}
-	return ""
+	return nil
 }
 
 func (x *CreateSecretRequest) GetMetadata() []byte {
@@ -211,6 +263,27 @@ func (x *CreateSecretRequest) GetData() []byte {
 	return nil
 }
 
+func (x *CreateSecretRequest) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+func (x *CreateSecretRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateSecretRequest) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
 type CreateSecretResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // ID of a secret in UUIDv4 form.
@@ -255,8 +328,14 @@ func (x *CreateSecretResponse) GetId() string {
 	return ""
 }
 
+// Secrets are listed in stable order of their IDs.
+// Names are encrypted by client, so secrets could be filtered by exact name only, see name_index.
 type ListSecretsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`      // Maximum number of secrets in the response, the service default is used if 0.
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`    // Token of the page to list, see next_page_token; the first page is listed if empty.
+	Kinds         []DataKind             `protobuf:"varint,3,rep,packed,name=kinds,proto3,enum=proto.DataKind" json:"kinds,omitempty"` // List only secrets of these kinds, all kinds are listed if empty.
+	NameIndex     []byte                 `protobuf:"bytes,4,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"`    // List only secrets with this blind index of the name.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -291,9 +370,39 @@ func (*ListSecretsRequest) Descriptor() ([]byte, []int) {
 	return file_secrets_proto_rawDescGZIP(), []int{3}
 }
 
+func (x *ListSecretsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListSecretsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListSecretsRequest) GetKinds() []DataKind {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+func (x *ListSecretsRequest) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
 type ListSecretsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Secrets       []*Secret              `protobuf:"bytes,1,rep,name=secrets,proto3" json:"secrets,omitempty"` // List of secrets created by current user.
+	Secrets       []*Secret              `protobuf:"bytes,1,rep,name=secrets,proto3" json:"secrets,omitempty"`                                    // List of secrets created by current user.
+	VaultVersion  int64                  `protobuf:"varint,2,opt,name=vault_version,json=vaultVersion,proto3" json:"vault_version,omitempty"`     // Version of the vault, changed on every modification of secrets.
+	NextPageToken string                 `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Token of the next page, empty if this page is the last one.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -335,9 +444,24 @@ func (x *ListSecretsResponse) GetSecrets() []*Secret {
 	return nil
 }
 
+func (x *ListSecretsResponse) GetVaultVersion() int64 {
+	if x != nil {
+		return x.VaultVersion
+	}
+	return 0
+}
+
+func (x *ListSecretsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type GetSecretRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // ID of a secret in UUIDv4 form.
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // ID of a secret in UUIDv4 form.
+	Trashed       bool                   `protobuf:"varint,2,opt,name=trashed,proto3" json:"trashed,omitempty"` // Look the secret up in the trash instead of the vault.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -379,6 +503,13 @@ func (x *GetSecretRequest) GetId() string {
 	return ""
 }
 
+func (x *GetSecretRequest) GetTrashed() bool {
+	if x != nil {
+		return x.Trashed
+	}
+	return false
+}
+
 type GetSecretResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Secret        *Secret                `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"` // Secret info.
@@ -432,12 +563,17 @@ func (x *GetSecretResponse) GetData() []byte {
 }
 
 type UpdateSecretRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                   // ID of a secret in UUIDv4 form.
-	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"` // Specifies what values should be changed.
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                               // Name of a secret.
-	Metadata      []byte                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`                       // Arbitrary description data encrypted by client.
-	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`                               // Actual secret data encrypted by client, see data.proto.
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                   // ID of a secret in UUIDv4 form.
+	UpdateMask *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"` // Specifies what values should be changed.
+	Name       []byte                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                               // Name of a secret encrypted by client, updated together with name_index.
+	Metadata   []byte                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`                       // Arbitrary description data encrypted by client.
+	Data       []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`                               // Actual secret data encrypted by client, see data.proto.
+	DataKey    []byte                 `protobuf:"bytes,6,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`          // Random data key encrypting name, metadata and data, wrapped by the vault key.
+	NameIndex  []byte                 `protobuf:"bytes,7,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"`    // Keyed hash of the normalized name computed by client, unique per user.
+	// Version of a secret the changes are based on, the update is aborted if the secret was changed since.
+	// The version is not checked if 0.
+	Version       int32 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -486,11 +622,11 @@ func (x *UpdateSecretRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
 	return nil
 }
 
-func (x *UpdateSecretRequest) GetName() string {
+func (x *UpdateSecretRequest) GetName() []byte {
 	if x != nil {
 		return x.Name
 	}
-	return ""
+	return nil
 }
 
 func (x *UpdateSecretRequest) GetMetadata() []byte {
@@ -507,6 +643,27 @@ func (x *UpdateSecretRequest) GetData() []byte {
 	return nil
 }
 
+func (x *UpdateSecretRequest) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+func (x *UpdateSecretRequest) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
+func (x *UpdateSecretRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type UpdateSecretResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -623,111 +780,1036 @@ func (*DeleteSecretResponse) Descriptor() ([]byte, []int) {
 	return file_secrets_proto_rawDescGZIP(), []int{10}
 }
 
-var File_secrets_proto protoreflect.FileDescriptor
+type ListSecretVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // ID of a secret in UUIDv4 form.
+	Trashed       bool                   `protobuf:"varint,2,opt,name=trashed,proto3" json:"trashed,omitempty"` // Look the secret up in the trash instead of the vault.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_secrets_proto_rawDesc = "" +
-	"\n" +
-	"\rsecrets.proto\x12\x05proto\x1a google/protobuf/field_mask.proto\"m\n" +
-	"\x06Secret\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
-	"\x04kind\x18\x03 \x01(\x0e2\x0f.proto.DataKindR\x04kind\x12\x1a\n" +
-	"\bmetadata\x18\x04 \x01(\fR\bmetadata\"~\n" +
-	"\x13CreateSecretRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
-	"\bmetadata\x18\x02 \x01(\fR\bmetadata\x12#\n" +
-	"\x04kind\x18\x03 \x01(\x0e2\x0f.proto.DataKindR\x04kind\x12\x12\n" +
-	"\x04data\x18\x04 \x01(\fR\x04data\"&\n" +
-	"\x14CreateSecretResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
-	"\x12ListSecretsRequest\">\n" +
-	"\x13ListSecretsResponse\x12'\n" +
-	"\asecrets\x18\x01 \x03(\v2\r.proto.SecretR\asecrets\"\"\n" +
-	"\x10GetSecretRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"N\n" +
-	"\x11GetSecretResponse\x12%\n" +
-	"\x06secret\x18\x01 \x01(\v2\r.proto.SecretR\x06secret\x12\x12\n" +
-	"\x04data\x18\x02 \x01(\fR\x04data\"\xa6\x01\n" +
-	"\x13UpdateSecretRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12;\n" +
-	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
-	"updateMask\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1a\n" +
-	"\bmetadata\x18\x04 \x01(\fR\bmetadata\x12\x12\n" +
-	"\x04data\x18\x05 \x01(\fR\x04data\"\x16\n" +
-	"\x14UpdateSecretResponse\"%\n" +
-	"\x13DeleteSecretRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
-	"\x14DeleteSecretResponse*;\n" +
-	"\bDataKind\x12\n" +
-	"\n" +
-	"\x06BINARY\x10\x00\x12\b\n" +
-	"\x04TEXT\x10\x01\x12\x0f\n" +
-	"\vCREDENTIALS\x10\x02\x12\b\n" +
-	"\x04CARD\x10\x032\xcb\x02\n" +
-	"\aSecrets\x12A\n" +
-	"\x06Create\x12\x1a.proto.CreateSecretRequest\x1a\x1b.proto.CreateSecretResponse\x12=\n" +
-	"\x04List\x12\x19.proto.ListSecretsRequest\x1a\x1a.proto.ListSecretsResponse\x128\n" +
-	"\x03Get\x12\x17.proto.GetSecretRequest\x1a\x18.proto.GetSecretResponse\x12A\n" +
-	"\x06Update\x12\x1a.proto.UpdateSecretRequest\x1a\x1b.proto.UpdateSecretResponse\x12A\n" +
-	"\x06Delete\x12\x1a.proto.DeleteSecretRequest\x1a\x1b.proto.DeleteSecretResponseB+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
+func (x *ListSecretVersionsRequest) Reset() {
+	*x = ListSecretVersionsRequest{}
+	mi := &file_secrets_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-var (
-	file_secrets_proto_rawDescOnce sync.Once
-	file_secrets_proto_rawDescData []byte
-)
+func (x *ListSecretVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-func file_secrets_proto_rawDescGZIP() []byte {
-	file_secrets_proto_rawDescOnce.Do(func() {
-		file_secrets_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_secrets_proto_rawDesc), len(file_secrets_proto_rawDesc)))
-	})
-	return file_secrets_proto_rawDescData
+func (*ListSecretVersionsRequest) ProtoMessage() {}
+
+func (x *ListSecretVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var file_secrets_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_secrets_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
-var file_secrets_proto_goTypes = []any{
-	(DataKind)(0),                 // 0: proto.DataKind
-	(*Secret)(nil),                // 1: proto.Secret
-	(*CreateSecretRequest)(nil),   // 2: proto.CreateSecretRequest
-	(*CreateSecretResponse)(nil),  // 3: proto.CreateSecretResponse
-	(*ListSecretsRequest)(nil),    // 4: proto.ListSecretsRequest
-	(*ListSecretsResponse)(nil),   // 5: proto.ListSecretsResponse
-	(*GetSecretRequest)(nil),      // 6: proto.GetSecretRequest
-	(*GetSecretResponse)(nil),     // 7: proto.GetSecretResponse
-	(*UpdateSecretRequest)(nil),   // 8: proto.UpdateSecretRequest
-	(*UpdateSecretResponse)(nil),  // 9: proto.UpdateSecretResponse
-	(*DeleteSecretRequest)(nil),   // 10: proto.DeleteSecretRequest
-	(*DeleteSecretResponse)(nil),  // 11: proto.DeleteSecretResponse
-	(*fieldmaskpb.FieldMask)(nil), // 12: google.protobuf.FieldMask
+// Deprecated: Use ListSecretVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSecretVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{11}
 }
-var file_secrets_proto_depIdxs = []int32{
-	0,  // 0: proto.Secret.kind:type_name -> proto.DataKind
-	0,  // 1: proto.CreateSecretRequest.kind:type_name -> proto.DataKind
-	1,  // 2: proto.ListSecretsResponse.secrets:type_name -> proto.Secret
-	1,  // 3: proto.GetSecretResponse.secret:type_name -> proto.Secret
-	12, // 4: proto.UpdateSecretRequest.update_mask:type_name -> google.protobuf.FieldMask
-	2,  // 5: proto.Secrets.Create:input_type -> proto.CreateSecretRequest
-	4,  // 6: proto.Secrets.List:input_type -> proto.ListSecretsRequest
-	6,  // 7: proto.Secrets.Get:input_type -> proto.GetSecretRequest
-	8,  // 8: proto.Secrets.Update:input_type -> proto.UpdateSecretRequest
-	10, // 9: proto.Secrets.Delete:input_type -> proto.DeleteSecretRequest
-	3,  // 10: proto.Secrets.Create:output_type -> proto.CreateSecretResponse
-	5,  // 11: proto.Secrets.List:output_type -> proto.ListSecretsResponse
-	7,  // 12: proto.Secrets.Get:output_type -> proto.GetSecretResponse
-	9,  // 13: proto.Secrets.Update:output_type -> proto.UpdateSecretResponse
-	11, // 14: proto.Secrets.Delete:output_type -> proto.DeleteSecretResponse
-	10, // [10:15] is the sub-list for method output_type
-	5,  // [5:10] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+
+func (x *ListSecretVersionsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
 }
 
-func init() { file_secrets_proto_init() }
-func file_secrets_proto_init() {
-	if File_secrets_proto != nil {
-		return
+func (x *ListSecretVersionsRequest) GetTrashed() bool {
+	if x != nil {
+		return x.Trashed
+	}
+	return false
+}
+
+type ListSecretVersionsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The current version followed by kept previous versions, the latest go first.
+	// Every version is encrypted by its own data key bound to the secret, data is not included.
+	Versions      []*Secret `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSecretVersionsResponse) Reset() {
+	*x = ListSecretVersionsResponse{}
+	mi := &file_secrets_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSecretVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSecretVersionsResponse) ProtoMessage() {}
+
+func (x *ListSecretVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSecretVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSecretVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListSecretVersionsResponse) GetVersions() []*Secret {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type GetSecretVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // ID of a secret in UUIDv4 form.
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version of a secret, see ListVersions.
+	Trashed       bool                   `protobuf:"varint,3,opt,name=trashed,proto3" json:"trashed,omitempty"` // Look the secret up in the trash instead of the vault.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSecretVersionRequest) Reset() {
+	*x = GetSecretVersionRequest{}
+	mi := &file_secrets_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSecretVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSecretVersionRequest) ProtoMessage() {}
+
+func (x *GetSecretVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSecretVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetSecretVersionRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetSecretVersionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetSecretVersionRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetSecretVersionRequest) GetTrashed() bool {
+	if x != nil {
+		return x.Trashed
+	}
+	return false
+}
+
+type GetSecretVersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secret        *Secret                `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"` // Secret info of the version.
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`     // Actual encrypted secret data of the version, see data.proto.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSecretVersionResponse) Reset() {
+	*x = GetSecretVersionResponse{}
+	mi := &file_secrets_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSecretVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSecretVersionResponse) ProtoMessage() {}
+
+func (x *GetSecretVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSecretVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetSecretVersionResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetSecretVersionResponse) GetSecret() *Secret {
+	if x != nil {
+		return x.Secret
+	}
+	return nil
+}
+
+func (x *GetSecretVersionResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type RestoreSecretRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // ID of a secret in UUIDv4 form.
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version of a secret to restore, see ListVersions; 0 restores a secret from trash.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSecretRequest) Reset() {
+	*x = RestoreSecretRequest{}
+	mi := &file_secrets_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSecretRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSecretRequest) ProtoMessage() {}
+
+func (x *RestoreSecretRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSecretRequest.ProtoReflect.Descriptor instead.
+func (*RestoreSecretRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RestoreSecretRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RestoreSecretRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RestoreSecretResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // Version of a secret with the restored content.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSecretResponse) Reset() {
+	*x = RestoreSecretResponse{}
+	mi := &file_secrets_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSecretResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSecretResponse) ProtoMessage() {}
+
+func (x *RestoreSecretResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSecretResponse.ProtoReflect.Descriptor instead.
+func (*RestoreSecretResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RestoreSecretResponse) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListTrashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrashRequest) Reset() {
+	*x = ListTrashRequest{}
+	mi := &file_secrets_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrashRequest) ProtoMessage() {}
+
+func (x *ListTrashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrashRequest.ProtoReflect.Descriptor instead.
+func (*ListTrashRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{17}
+}
+
+type ListTrashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secrets       []*Secret              `protobuf:"bytes,1,rep,name=secrets,proto3" json:"secrets,omitempty"` // Trashed secrets of current user without data, the latest deleted go first.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrashResponse) Reset() {
+	*x = ListTrashResponse{}
+	mi := &file_secrets_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrashResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrashResponse) ProtoMessage() {}
+
+func (x *ListTrashResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrashResponse.ProtoReflect.Descriptor instead.
+func (*ListTrashResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListTrashResponse) GetSecrets() []*Secret {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+type PurgeSecretRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // ID of a secret in UUIDv4 form.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeSecretRequest) Reset() {
+	*x = PurgeSecretRequest{}
+	mi := &file_secrets_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeSecretRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeSecretRequest) ProtoMessage() {}
+
+func (x *PurgeSecretRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeSecretRequest.ProtoReflect.Descriptor instead.
+func (*PurgeSecretRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PurgeSecretRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type PurgeSecretResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeSecretResponse) Reset() {
+	*x = PurgeSecretResponse{}
+	mi := &file_secrets_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeSecretResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeSecretResponse) ProtoMessage() {}
+
+func (x *PurgeSecretResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeSecretResponse.ProtoReflect.Descriptor instead.
+func (*PurgeSecretResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{20}
+}
+
+// Header of a blob sent in the first message of the upload.
+// Blobs are always binary secrets.
+type BlobHeader struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                // ID of a secret in UUIDv4 form chosen by client, encrypted chunks are bound to it.
+	Name          []byte                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                            // Name of a secret encrypted by client.
+	NameIndex     []byte                 `protobuf:"bytes,3,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"` // Keyed hash of the normalized name computed by client, unique per user.
+	Metadata      []byte                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`                    // Arbitrary description data encrypted by client.
+	DataKey       []byte                 `protobuf:"bytes,5,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`       // Random data key encrypting name, metadata and chunks, wrapped by the vault key.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlobHeader) Reset() {
+	*x = BlobHeader{}
+	mi := &file_secrets_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlobHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlobHeader) ProtoMessage() {}
+
+func (x *BlobHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlobHeader.ProtoReflect.Descriptor instead.
+func (*BlobHeader) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BlobHeader) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlobHeader) GetName() []byte {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *BlobHeader) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
+func (x *BlobHeader) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *BlobHeader) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+// Blob is uploaded as the header followed by at least one chunk.
+// Every chunk is encrypted by client separately and bound to its position in the blob,
+// so chunks can't be reordered, dropped or truncated unnoticed.
+type UploadBlobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Part:
+	//
+	//	*UploadBlobRequest_Header
+	//	*UploadBlobRequest_Chunk
+	Part          isUploadBlobRequest_Part `protobuf_oneof:"part"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadBlobRequest) Reset() {
+	*x = UploadBlobRequest{}
+	mi := &file_secrets_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadBlobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadBlobRequest) ProtoMessage() {}
+
+func (x *UploadBlobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadBlobRequest.ProtoReflect.Descriptor instead.
+func (*UploadBlobRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UploadBlobRequest) GetPart() isUploadBlobRequest_Part {
+	if x != nil {
+		return x.Part
+	}
+	return nil
+}
+
+func (x *UploadBlobRequest) GetHeader() *BlobHeader {
+	if x != nil {
+		if x, ok := x.Part.(*UploadBlobRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *UploadBlobRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Part.(*UploadBlobRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadBlobRequest_Part interface {
+	isUploadBlobRequest_Part()
+}
+
+type UploadBlobRequest_Header struct {
+	Header *BlobHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"` // Header of the blob, the first message only.
+}
+
+type UploadBlobRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"` // Next encrypted chunk of the blob.
+}
+
+func (*UploadBlobRequest_Header) isUploadBlobRequest_Part() {}
+
+func (*UploadBlobRequest_Chunk) isUploadBlobRequest_Part() {}
+
+type UploadBlobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // ID of a secret in UUIDv4 form.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadBlobResponse) Reset() {
+	*x = UploadBlobResponse{}
+	mi := &file_secrets_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadBlobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadBlobResponse) ProtoMessage() {}
+
+func (x *UploadBlobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadBlobResponse.ProtoReflect.Descriptor instead.
+func (*UploadBlobResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UploadBlobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DownloadBlobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // ID of a secret in UUIDv4 form.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadBlobRequest) Reset() {
+	*x = DownloadBlobRequest{}
+	mi := &file_secrets_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadBlobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadBlobRequest) ProtoMessage() {}
+
+func (x *DownloadBlobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadBlobRequest.ProtoReflect.Descriptor instead.
+func (*DownloadBlobRequest) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DownloadBlobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Blob is downloaded as the secret info followed by the chunks in order of upload.
+type DownloadBlobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Part:
+	//
+	//	*DownloadBlobResponse_Secret
+	//	*DownloadBlobResponse_Chunk
+	Part          isDownloadBlobResponse_Part `protobuf_oneof:"part"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadBlobResponse) Reset() {
+	*x = DownloadBlobResponse{}
+	mi := &file_secrets_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadBlobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadBlobResponse) ProtoMessage() {}
+
+func (x *DownloadBlobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_secrets_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadBlobResponse.ProtoReflect.Descriptor instead.
+func (*DownloadBlobResponse) Descriptor() ([]byte, []int) {
+	return file_secrets_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DownloadBlobResponse) GetPart() isDownloadBlobResponse_Part {
+	if x != nil {
+		return x.Part
+	}
+	return nil
+}
+
+func (x *DownloadBlobResponse) GetSecret() *Secret {
+	if x != nil {
+		if x, ok := x.Part.(*DownloadBlobResponse_Secret); ok {
+			return x.Secret
+		}
+	}
+	return nil
+}
+
+func (x *DownloadBlobResponse) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Part.(*DownloadBlobResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isDownloadBlobResponse_Part interface {
+	isDownloadBlobResponse_Part()
+}
+
+type DownloadBlobResponse_Secret struct {
+	Secret *Secret `protobuf:"bytes,1,opt,name=secret,proto3,oneof"` // Secret info, the first message only.
+}
+
+type DownloadBlobResponse_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"` // Next encrypted chunk of the blob.
+}
+
+func (*DownloadBlobResponse_Secret) isDownloadBlobResponse_Part() {}
+
+func (*DownloadBlobResponse_Chunk) isDownloadBlobResponse_Part() {}
+
+var File_secrets_proto protoreflect.FileDescriptor
+
+const file_secrets_proto_rawDesc = "" +
+	"\n" +
+	"\rsecrets.proto\x12\x05proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd4\x02\n" +
+	"\x06Secret\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\fR\x04name\x12#\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x0f.proto.DataKindR\x04kind\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\fR\bmetadata\x12\x19\n" +
+	"\bdata_key\x18\x05 \x01(\fR\adataKey\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\x06 \x01(\fR\tnameIndex\x12\x1b\n" +
+	"\tblob_size\x18\a \x01(\x03R\bblobSize\x12\x18\n" +
+	"\aversion\x18\b \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"deleted_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"\xc8\x01\n" +
+	"\x13CreateSecretRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\fR\x04name\x12\x1a\n" +
+	"\bmetadata\x18\x02 \x01(\fR\bmetadata\x12#\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x0f.proto.DataKindR\x04kind\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x19\n" +
+	"\bdata_key\x18\x05 \x01(\fR\adataKey\x12\x0e\n" +
+	"\x02id\x18\x06 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\a \x01(\fR\tnameIndex\"&\n" +
+	"\x14CreateSecretResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x96\x01\n" +
+	"\x12ListSecretsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12%\n" +
+	"\x05kinds\x18\x03 \x03(\x0e2\x0f.proto.DataKindR\x05kinds\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\x04 \x01(\fR\tnameIndex\"\x8b\x01\n" +
+	"\x13ListSecretsResponse\x12'\n" +
+	"\asecrets\x18\x01 \x03(\v2\r.proto.SecretR\asecrets\x12#\n" +
+	"\rvault_version\x18\x02 \x01(\x03R\fvaultVersion\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"<\n" +
+	"\x10GetSecretRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\atrashed\x18\x02 \x01(\bR\atrashed\"N\n" +
+	"\x11GetSecretResponse\x12%\n" +
+	"\x06secret\x18\x01 \x01(\v2\r.proto.SecretR\x06secret\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\xfa\x01\n" +
+	"\x13UpdateSecretRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\fR\x04name\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\fR\bmetadata\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\x12\x19\n" +
+	"\bdata_key\x18\x06 \x01(\fR\adataKey\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\a \x01(\fR\tnameIndex\x12\x18\n" +
+	"\aversion\x18\b \x01(\x05R\aversion\"\x16\n" +
+	"\x14UpdateSecretResponse\"%\n" +
+	"\x13DeleteSecretRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
+	"\x14DeleteSecretResponse\"E\n" +
+	"\x19ListSecretVersionsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\atrashed\x18\x02 \x01(\bR\atrashed\"G\n" +
+	"\x1aListSecretVersionsResponse\x12)\n" +
+	"\bversions\x18\x01 \x03(\v2\r.proto.SecretR\bversions\"]\n" +
+	"\x17GetSecretVersionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12\x18\n" +
+	"\atrashed\x18\x03 \x01(\bR\atrashed\"U\n" +
+	"\x18GetSecretVersionResponse\x12%\n" +
+	"\x06secret\x18\x01 \x01(\v2\r.proto.SecretR\x06secret\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"@\n" +
+	"\x14RestoreSecretRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\"1\n" +
+	"\x15RestoreSecretResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\"\x12\n" +
+	"\x10ListTrashRequest\"<\n" +
+	"\x11ListTrashResponse\x12'\n" +
+	"\asecrets\x18\x01 \x03(\v2\r.proto.SecretR\asecrets\"$\n" +
+	"\x12PurgeSecretRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13PurgeSecretResponse\"\x86\x01\n" +
+	"\n" +
+	"BlobHeader\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\fR\x04name\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\x03 \x01(\fR\tnameIndex\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\fR\bmetadata\x12\x19\n" +
+	"\bdata_key\x18\x05 \x01(\fR\adataKey\"`\n" +
+	"\x11UploadBlobRequest\x12+\n" +
+	"\x06header\x18\x01 \x01(\v2\x11.proto.BlobHeaderH\x00R\x06header\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\x06\n" +
+	"\x04part\"$\n" +
+	"\x12UploadBlobResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"%\n" +
+	"\x13DownloadBlobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"_\n" +
+	"\x14DownloadBlobResponse\x12'\n" +
+	"\x06secret\x18\x01 \x01(\v2\r.proto.SecretH\x00R\x06secret\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\x06\n" +
+	"\x04part*;\n" +
+	"\bDataKind\x12\n" +
+	"\n" +
+	"\x06BINARY\x10\x00\x12\b\n" +
+	"\x04TEXT\x10\x01\x12\x0f\n" +
+	"\vCREDENTIALS\x10\x02\x12\b\n" +
+	"\x04CARD\x10\x032\xc5\x06\n" +
+	"\aSecrets\x12A\n" +
+	"\x06Create\x12\x1a.proto.CreateSecretRequest\x1a\x1b.proto.CreateSecretResponse\x12=\n" +
+	"\x04List\x12\x19.proto.ListSecretsRequest\x1a\x1a.proto.ListSecretsResponse\x128\n" +
+	"\x03Get\x12\x17.proto.GetSecretRequest\x1a\x18.proto.GetSecretResponse\x12A\n" +
+	"\x06Update\x12\x1a.proto.UpdateSecretRequest\x1a\x1b.proto.UpdateSecretResponse\x12A\n" +
+	"\x06Delete\x12\x1a.proto.DeleteSecretRequest\x1a\x1b.proto.DeleteSecretResponse\x12C\n" +
+	"\n" +
+	"UploadBlob\x12\x18.proto.UploadBlobRequest\x1a\x19.proto.UploadBlobResponse(\x01\x12I\n" +
+	"\fDownloadBlob\x12\x1a.proto.DownloadBlobRequest\x1a\x1b.proto.DownloadBlobResponse0\x01\x12S\n" +
+	"\fListVersions\x12 .proto.ListSecretVersionsRequest\x1a!.proto.ListSecretVersionsResponse\x12M\n" +
+	"\n" +
+	"GetVersion\x12\x1e.proto.GetSecretVersionRequest\x1a\x1f.proto.GetSecretVersionResponse\x12D\n" +
+	"\aRestore\x12\x1b.proto.RestoreSecretRequest\x1a\x1c.proto.RestoreSecretResponse\x12>\n" +
+	"\tListTrash\x12\x17.proto.ListTrashRequest\x1a\x18.proto.ListTrashResponse\x12>\n" +
+	"\x05Purge\x12\x19.proto.PurgeSecretRequest\x1a\x1a.proto.PurgeSecretResponseB+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
+
+var (
+	file_secrets_proto_rawDescOnce sync.Once
+	file_secrets_proto_rawDescData []byte
+)
+
+func file_secrets_proto_rawDescGZIP() []byte {
+	file_secrets_proto_rawDescOnce.Do(func() {
+		file_secrets_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_secrets_proto_rawDesc), len(file_secrets_proto_rawDesc)))
+	})
+	return file_secrets_proto_rawDescData
+}
+
+var file_secrets_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_secrets_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_secrets_proto_goTypes = []any{
+	(DataKind)(0),                      // 0: proto.DataKind
+	(*Secret)(nil),                     // 1: proto.Secret
+	(*CreateSecretRequest)(nil),        // 2: proto.CreateSecretRequest
+	(*CreateSecretResponse)(nil),       // 3: proto.CreateSecretResponse
+	(*ListSecretsRequest)(nil),         // 4: proto.ListSecretsRequest
+	(*ListSecretsResponse)(nil),        // 5: proto.ListSecretsResponse
+	(*GetSecretRequest)(nil),           // 6: proto.GetSecretRequest
+	(*GetSecretResponse)(nil),          // 7: proto.GetSecretResponse
+	(*UpdateSecretRequest)(nil),        // 8: proto.UpdateSecretRequest
+	(*UpdateSecretResponse)(nil),       // 9: proto.UpdateSecretResponse
+	(*DeleteSecretRequest)(nil),        // 10: proto.DeleteSecretRequest
+	(*DeleteSecretResponse)(nil),       // 11: proto.DeleteSecretResponse
+	(*ListSecretVersionsRequest)(nil),  // 12: proto.ListSecretVersionsRequest
+	(*ListSecretVersionsResponse)(nil), // 13: proto.ListSecretVersionsResponse
+	(*GetSecretVersionRequest)(nil),    // 14: proto.GetSecretVersionRequest
+	(*GetSecretVersionResponse)(nil),   // 15: proto.GetSecretVersionResponse
+	(*RestoreSecretRequest)(nil),       // 16: proto.RestoreSecretRequest
+	(*RestoreSecretResponse)(nil),      // 17: proto.RestoreSecretResponse
+	(*ListTrashRequest)(nil),           // 18: proto.ListTrashRequest
+	(*ListTrashResponse)(nil),          // 19: proto.ListTrashResponse
+	(*PurgeSecretRequest)(nil),         // 20: proto.PurgeSecretRequest
+	(*PurgeSecretResponse)(nil),        // 21: proto.PurgeSecretResponse
+	(*BlobHeader)(nil),                 // 22: proto.BlobHeader
+	(*UploadBlobRequest)(nil),          // 23: proto.UploadBlobRequest
+	(*UploadBlobResponse)(nil),         // 24: proto.UploadBlobResponse
+	(*DownloadBlobRequest)(nil),        // 25: proto.DownloadBlobRequest
+	(*DownloadBlobResponse)(nil),       // 26: proto.DownloadBlobResponse
+	(*timestamppb.Timestamp)(nil),      // 27: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil),      // 28: google.protobuf.FieldMask
+}
+var file_secrets_proto_depIdxs = []int32{
+	0,  // 0: proto.Secret.kind:type_name -> proto.DataKind
+	27, // 1: proto.Secret.updated_at:type_name -> google.protobuf.Timestamp
+	27, // 2: proto.Secret.deleted_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: proto.CreateSecretRequest.kind:type_name -> proto.DataKind
+	0,  // 4: proto.ListSecretsRequest.kinds:type_name -> proto.DataKind
+	1,  // 5: proto.ListSecretsResponse.secrets:type_name -> proto.Secret
+	1,  // 6: proto.GetSecretResponse.secret:type_name -> proto.Secret
+	28, // 7: proto.UpdateSecretRequest.update_mask:type_name -> google.protobuf.FieldMask
+	1,  // 8: proto.ListSecretVersionsResponse.versions:type_name -> proto.Secret
+	1,  // 9: proto.GetSecretVersionResponse.secret:type_name -> proto.Secret
+	1,  // 10: proto.ListTrashResponse.secrets:type_name -> proto.Secret
+	22, // 11: proto.UploadBlobRequest.header:type_name -> proto.BlobHeader
+	1,  // 12: proto.DownloadBlobResponse.secret:type_name -> proto.Secret
+	2,  // 13: proto.Secrets.Create:input_type -> proto.CreateSecretRequest
+	4,  // 14: proto.Secrets.List:input_type -> proto.ListSecretsRequest
+	6,  // 15: proto.Secrets.Get:input_type -> proto.GetSecretRequest
+	8,  // 16: proto.Secrets.Update:input_type -> proto.UpdateSecretRequest
+	10, // 17: proto.Secrets.Delete:input_type -> proto.DeleteSecretRequest
+	23, // 18: proto.Secrets.UploadBlob:input_type -> proto.UploadBlobRequest
+	25, // 19: proto.Secrets.DownloadBlob:input_type -> proto.DownloadBlobRequest
+	12, // 20: proto.Secrets.ListVersions:input_type -> proto.ListSecretVersionsRequest
+	14, // 21: proto.Secrets.GetVersion:input_type -> proto.GetSecretVersionRequest
+	16, // 22: proto.Secrets.Restore:input_type -> proto.RestoreSecretRequest
+	18, // 23: proto.Secrets.ListTrash:input_type -> proto.ListTrashRequest
+	20, // 24: proto.Secrets.Purge:input_type -> proto.PurgeSecretRequest
+	3,  // 25: proto.Secrets.Create:output_type -> proto.CreateSecretResponse
+	5,  // 26: proto.Secrets.List:output_type -> proto.ListSecretsResponse
+	7,  // 27: proto.Secrets.Get:output_type -> proto.GetSecretResponse
+	9,  // 28: proto.Secrets.Update:output_type -> proto.UpdateSecretResponse
+	11, // 29: proto.Secrets.Delete:output_type -> proto.DeleteSecretResponse
+	24, // 30: proto.Secrets.UploadBlob:output_type -> proto.UploadBlobResponse
+	26, // 31: proto.Secrets.DownloadBlob:output_type -> proto.DownloadBlobResponse
+	13, // 32: proto.Secrets.ListVersions:output_type -> proto.ListSecretVersionsResponse
+	15, // 33: proto.Secrets.GetVersion:output_type -> proto.GetSecretVersionResponse
+	17, // 34: proto.Secrets.Restore:output_type -> proto.RestoreSecretResponse
+	19, // 35: proto.Secrets.ListTrash:output_type -> proto.ListTrashResponse
+	21, // 36: proto.Secrets.Purge:output_type -> proto.PurgeSecretResponse
+	25, // [25:37] is the sub-list for method output_type
+	13, // [13:25] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_secrets_proto_init() }
+func file_secrets_proto_init() {
+	if File_secrets_proto != nil {
+		return
+	}
+	file_secrets_proto_msgTypes[22].OneofWrappers = []any{
+		(*UploadBlobRequest_Header)(nil),
+		(*UploadBlobRequest_Chunk)(nil),
+	}
+	file_secrets_proto_msgTypes[25].OneofWrappers = []any{
+		(*DownloadBlobResponse_Secret)(nil),
+		(*DownloadBlobResponse_Chunk)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -735,7 +1817,7 @@ func file_secrets_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_secrets_proto_rawDesc), len(file_secrets_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   11,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
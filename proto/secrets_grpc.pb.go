@@ -19,11 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Secrets_Create_FullMethodName = "/proto.Secrets/Create"
-	Secrets_List_FullMethodName   = "/proto.Secrets/List"
-	Secrets_Get_FullMethodName    = "/proto.Secrets/Get"
-	Secrets_Update_FullMethodName = "/proto.Secrets/Update"
-	Secrets_Delete_FullMethodName = "/proto.Secrets/Delete"
+	Secrets_Create_FullMethodName       = "/proto.Secrets/Create"
+	Secrets_List_FullMethodName         = "/proto.Secrets/List"
+	Secrets_Get_FullMethodName          = "/proto.Secrets/Get"
+	Secrets_Update_FullMethodName       = "/proto.Secrets/Update"
+	Secrets_Delete_FullMethodName       = "/proto.Secrets/Delete"
+	Secrets_UploadBlob_FullMethodName   = "/proto.Secrets/UploadBlob"
+	Secrets_DownloadBlob_FullMethodName = "/proto.Secrets/DownloadBlob"
+	Secrets_ListVersions_FullMethodName = "/proto.Secrets/ListVersions"
+	Secrets_GetVersion_FullMethodName   = "/proto.Secrets/GetVersion"
+	Secrets_Restore_FullMethodName      = "/proto.Secrets/Restore"
+	Secrets_ListTrash_FullMethodName    = "/proto.Secrets/ListTrash"
+	Secrets_Purge_FullMethodName        = "/proto.Secrets/Purge"
 )
 
 // SecretsClient is the client API for Secrets service.
@@ -34,14 +41,30 @@ const (
 type SecretsClient interface {
 	// Store new secret.
 	Create(ctx context.Context, in *CreateSecretRequest, opts ...grpc.CallOption) (*CreateSecretResponse, error)
-	// List brief secrets without data for the current user.
+	// List brief secrets without data for the current user page by page.
 	List(ctx context.Context, in *ListSecretsRequest, opts ...grpc.CallOption) (*ListSecretsResponse, error)
 	// Get a secret with data.
 	Get(ctx context.Context, in *GetSecretRequest, opts ...grpc.CallOption) (*GetSecretResponse, error)
 	// Change a secret and/or stored data.
+	// Fails with ABORTED if the secret was changed since the version provided in the request.
 	Update(ctx context.Context, in *UpdateSecretRequest, opts ...grpc.CallOption) (*UpdateSecretResponse, error)
-	// Remove a secret.
+	// Move a secret to trash, trashed secrets are purged after the retention period of the service.
 	Delete(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*DeleteSecretResponse, error)
+	// Store new binary secret too large for a single message chunk by chunk.
+	UploadBlob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadBlobRequest, UploadBlobResponse], error)
+	// Get a binary secret stored as a blob chunk by chunk.
+	DownloadBlob(ctx context.Context, in *DownloadBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadBlobResponse], error)
+	// List versions of a secret without data, every update keeps the previous version.
+	ListVersions(ctx context.Context, in *ListSecretVersionsRequest, opts ...grpc.CallOption) (*ListSecretVersionsResponse, error)
+	// Get a version of a secret with data.
+	GetVersion(ctx context.Context, in *GetSecretVersionRequest, opts ...grpc.CallOption) (*GetSecretVersionResponse, error)
+	// Make content of a previous version current again, the current content is kept as a version.
+	// Restore a secret from trash if the version is 0.
+	Restore(ctx context.Context, in *RestoreSecretRequest, opts ...grpc.CallOption) (*RestoreSecretResponse, error)
+	// List secrets moved to trash by Delete.
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error)
+	// Remove a secret permanently, either trashed or not.
+	Purge(ctx context.Context, in *PurgeSecretRequest, opts ...grpc.CallOption) (*PurgeSecretResponse, error)
 }
 
 type secretsClient struct {
@@ -102,6 +125,88 @@ func (c *secretsClient) Delete(ctx context.Context, in *DeleteSecretRequest, opt
 	return out, nil
 }
 
+func (c *secretsClient) UploadBlob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadBlobRequest, UploadBlobResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Secrets_ServiceDesc.Streams[0], Secrets_UploadBlob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadBlobRequest, UploadBlobResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Secrets_UploadBlobClient = grpc.ClientStreamingClient[UploadBlobRequest, UploadBlobResponse]
+
+func (c *secretsClient) DownloadBlob(ctx context.Context, in *DownloadBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadBlobResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Secrets_ServiceDesc.Streams[1], Secrets_DownloadBlob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadBlobRequest, DownloadBlobResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Secrets_DownloadBlobClient = grpc.ServerStreamingClient[DownloadBlobResponse]
+
+func (c *secretsClient) ListVersions(ctx context.Context, in *ListSecretVersionsRequest, opts ...grpc.CallOption) (*ListSecretVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSecretVersionsResponse)
+	err := c.cc.Invoke(ctx, Secrets_ListVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) GetVersion(ctx context.Context, in *GetSecretVersionRequest, opts ...grpc.CallOption) (*GetSecretVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSecretVersionResponse)
+	err := c.cc.Invoke(ctx, Secrets_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) Restore(ctx context.Context, in *RestoreSecretRequest, opts ...grpc.CallOption) (*RestoreSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreSecretResponse)
+	err := c.cc.Invoke(ctx, Secrets_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTrashResponse)
+	err := c.cc.Invoke(ctx, Secrets_ListTrash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) Purge(ctx context.Context, in *PurgeSecretRequest, opts ...grpc.CallOption) (*PurgeSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeSecretResponse)
+	err := c.cc.Invoke(ctx, Secrets_Purge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SecretsServer is the server API for Secrets service.
 // All implementations must embed UnimplementedSecretsServer
 // for forward compatibility.
@@ -110,14 +215,30 @@ func (c *secretsClient) Delete(ctx context.Context, in *DeleteSecretRequest, opt
 type SecretsServer interface {
 	// Store new secret.
 	Create(context.Context, *CreateSecretRequest) (*CreateSecretResponse, error)
-	// List brief secrets without data for the current user.
+	// List brief secrets without data for the current user page by page.
 	List(context.Context, *ListSecretsRequest) (*ListSecretsResponse, error)
 	// Get a secret with data.
 	Get(context.Context, *GetSecretRequest) (*GetSecretResponse, error)
 	// Change a secret and/or stored data.
+	// Fails with ABORTED if the secret was changed since the version provided in the request.
 	Update(context.Context, *UpdateSecretRequest) (*UpdateSecretResponse, error)
-	// Remove a secret.
+	// Move a secret to trash, trashed secrets are purged after the retention period of the service.
 	Delete(context.Context, *DeleteSecretRequest) (*DeleteSecretResponse, error)
+	// Store new binary secret too large for a single message chunk by chunk.
+	UploadBlob(grpc.ClientStreamingServer[UploadBlobRequest, UploadBlobResponse]) error
+	// Get a binary secret stored as a blob chunk by chunk.
+	DownloadBlob(*DownloadBlobRequest, grpc.ServerStreamingServer[DownloadBlobResponse]) error
+	// List versions of a secret without data, every update keeps the previous version.
+	ListVersions(context.Context, *ListSecretVersionsRequest) (*ListSecretVersionsResponse, error)
+	// Get a version of a secret with data.
+	GetVersion(context.Context, *GetSecretVersionRequest) (*GetSecretVersionResponse, error)
+	// Make content of a previous version current again, the current content is kept as a version.
+	// Restore a secret from trash if the version is 0.
+	Restore(context.Context, *RestoreSecretRequest) (*RestoreSecretResponse, error)
+	// List secrets moved to trash by Delete.
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error)
+	// Remove a secret permanently, either trashed or not.
+	Purge(context.Context, *PurgeSecretRequest) (*PurgeSecretResponse, error)
 	mustEmbedUnimplementedSecretsServer()
 }
 
@@ -143,6 +264,27 @@ func (UnimplementedSecretsServer) Update(context.Context, *UpdateSecretRequest)
 func (UnimplementedSecretsServer) Delete(context.Context, *DeleteSecretRequest) (*DeleteSecretResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedSecretsServer) UploadBlob(grpc.ClientStreamingServer[UploadBlobRequest, UploadBlobResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadBlob not implemented")
+}
+func (UnimplementedSecretsServer) DownloadBlob(*DownloadBlobRequest, grpc.ServerStreamingServer[DownloadBlobResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadBlob not implemented")
+}
+func (UnimplementedSecretsServer) ListVersions(context.Context, *ListSecretVersionsRequest) (*ListSecretVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedSecretsServer) GetVersion(context.Context, *GetSecretVersionRequest) (*GetSecretVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
+func (UnimplementedSecretsServer) Restore(context.Context, *RestoreSecretRequest) (*RestoreSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedSecretsServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedSecretsServer) Purge(context.Context, *PurgeSecretRequest) (*PurgeSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Purge not implemented")
+}
 func (UnimplementedSecretsServer) mustEmbedUnimplementedSecretsServer() {}
 func (UnimplementedSecretsServer) testEmbeddedByValue()                 {}
 
@@ -254,6 +396,114 @@ func _Secrets_Delete_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Secrets_UploadBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SecretsServer).UploadBlob(&grpc.GenericServerStream[UploadBlobRequest, UploadBlobResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Secrets_UploadBlobServer = grpc.ClientStreamingServer[UploadBlobRequest, UploadBlobResponse]
+
+func _Secrets_DownloadBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadBlobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SecretsServer).DownloadBlob(m, &grpc.GenericServerStream[DownloadBlobRequest, DownloadBlobResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Secrets_DownloadBlobServer = grpc.ServerStreamingServer[DownloadBlobResponse]
+
+func _Secrets_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSecretVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Secrets_ListVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).ListVersions(ctx, req.(*ListSecretVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSecretVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Secrets_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).GetVersion(ctx, req.(*GetSecretVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Secrets_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Restore(ctx, req.(*RestoreSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Secrets_ListTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_Purge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Purge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Secrets_Purge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Purge(ctx, req.(*PurgeSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Secrets_ServiceDesc is the grpc.ServiceDesc for Secrets service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -281,7 +531,38 @@ var Secrets_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _Secrets_Delete_Handler,
 		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _Secrets_ListVersions_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _Secrets_GetVersion_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _Secrets_Restore_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _Secrets_ListTrash_Handler,
+		},
+		{
+			MethodName: "Purge",
+			Handler:    _Secrets_Purge_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadBlob",
+			Handler:       _Secrets_UploadBlob_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadBlob",
+			Handler:       _Secrets_DownloadBlob_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "secrets.proto",
 }
@@ -82,3 +82,144 @@ func (m *SecretsClientMock) Delete(
 
 	return args.Get(0).(*DeleteSecretResponse), args.Error(1)
 }
+
+func (m *SecretsClientMock) ListVersions(
+	ctx context.Context,
+	in *ListSecretVersionsRequest,
+	opts ...grpc.CallOption,
+) (*ListSecretVersionsResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*ListSecretVersionsResponse), args.Error(1)
+}
+
+func (m *SecretsClientMock) GetVersion(
+	ctx context.Context,
+	in *GetSecretVersionRequest,
+	opts ...grpc.CallOption,
+) (*GetSecretVersionResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*GetSecretVersionResponse), args.Error(1)
+}
+
+func (m *SecretsClientMock) Restore(
+	ctx context.Context,
+	in *RestoreSecretRequest,
+	opts ...grpc.CallOption,
+) (*RestoreSecretResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RestoreSecretResponse), args.Error(1)
+}
+
+func (m *SecretsClientMock) ListTrash(
+	ctx context.Context,
+	in *ListTrashRequest,
+	opts ...grpc.CallOption,
+) (*ListTrashResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*ListTrashResponse), args.Error(1)
+}
+
+func (m *SecretsClientMock) Purge(
+	ctx context.Context,
+	in *PurgeSecretRequest,
+	opts ...grpc.CallOption,
+) (*PurgeSecretResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*PurgeSecretResponse), args.Error(1)
+}
+
+func (m *SecretsClientMock) UploadBlob(
+	ctx context.Context,
+	opts ...grpc.CallOption,
+) (grpc.ClientStreamingClient[UploadBlobRequest, UploadBlobResponse], error) {
+	args := m.Called(ctx, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(grpc.ClientStreamingClient[UploadBlobRequest, UploadBlobResponse]), args.Error(1)
+}
+
+func (m *SecretsClientMock) DownloadBlob(
+	ctx context.Context,
+	in *DownloadBlobRequest,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[DownloadBlobResponse], error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(grpc.ServerStreamingClient[DownloadBlobResponse]), args.Error(1)
+}
+
+var _ Secrets_UploadBlobClient = (*UploadBlobClientMock)(nil)
+
+// UploadBlobClientMock mocks client side of the upload stream,
+// methods of grpc.ClientStream which are not mocked panic.
+type UploadBlobClientMock struct {
+	grpc.ClientStream
+	mock.Mock
+}
+
+func (m *UploadBlobClientMock) Send(req *UploadBlobRequest) error {
+	args := m.Called(req)
+
+	return args.Error(0)
+}
+
+func (m *UploadBlobClientMock) CloseAndRecv() (*UploadBlobResponse, error) {
+	args := m.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*UploadBlobResponse), args.Error(1)
+}
+
+var _ Secrets_DownloadBlobClient = (*DownloadBlobClientMock)(nil)
+
+// DownloadBlobClientMock mocks client side of the download stream,
+// methods of grpc.ClientStream which are not mocked panic.
+type DownloadBlobClientMock struct {
+	grpc.ClientStream
+	mock.Mock
+}
+
+func (m *DownloadBlobClientMock) Recv() (*DownloadBlobResponse, error) {
+	args := m.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*DownloadBlobResponse), args.Error(1)
+}
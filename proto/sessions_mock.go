@@ -0,0 +1,42 @@
+package proto
+
+import (
+	context "context"
+
+	"github.com/stretchr/testify/mock"
+	grpc "google.golang.org/grpc"
+)
+
+var _ SessionsClient = (*SessionsClientMock)(nil)
+
+type SessionsClientMock struct {
+	mock.Mock
+}
+
+func (m *SessionsClientMock) List(
+	ctx context.Context,
+	in *ListSessionsRequest,
+	opts ...grpc.CallOption,
+) (*ListSessionsResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*ListSessionsResponse), args.Error(1)
+}
+
+func (m *SessionsClientMock) Revoke(
+	ctx context.Context,
+	in *RevokeSessionRequest,
+	opts ...grpc.CallOption,
+) (*RevokeSessionResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RevokeSessionResponse), args.Error(1)
+}
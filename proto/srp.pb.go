@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: srp.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SRP-6a verifier derived by client from the auth subkey.
+// The service never receives the auth subkey itself.
+type SRPVerifier struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Salt          []byte                 `protobuf:"bytes,1,opt,name=salt,proto3" json:"salt,omitempty"`         // Random per-user salt.
+	Verifier      []byte                 `protobuf:"bytes,2,opt,name=verifier,proto3" json:"verifier,omitempty"` // Verifier v = g^x, see RFC 5054.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SRPVerifier) Reset() {
+	*x = SRPVerifier{}
+	mi := &file_srp_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SRPVerifier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SRPVerifier) ProtoMessage() {}
+
+func (x *SRPVerifier) ProtoReflect() protoreflect.Message {
+	mi := &file_srp_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SRPVerifier.ProtoReflect.Descriptor instead.
+func (*SRPVerifier) Descriptor() ([]byte, []int) {
+	return file_srp_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SRPVerifier) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *SRPVerifier) GetVerifier() []byte {
+	if x != nil {
+		return x.Verifier
+	}
+	return nil
+}
+
+// Response of the service to the client public ephemeral value.
+type SRPChallenge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HandshakeId   string                 `protobuf:"bytes,1,opt,name=handshake_id,json=handshakeId,proto3" json:"handshake_id,omitempty"`    // ID of the handshake in UUIDv4 form.
+	Salt          []byte                 `protobuf:"bytes,2,opt,name=salt,proto3" json:"salt,omitempty"`                                     // Salt of the verifier.
+	ServerPublic  []byte                 `protobuf:"bytes,3,opt,name=server_public,json=serverPublic,proto3" json:"server_public,omitempty"` // Server public ephemeral value B.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SRPChallenge) Reset() {
+	*x = SRPChallenge{}
+	mi := &file_srp_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SRPChallenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SRPChallenge) ProtoMessage() {}
+
+func (x *SRPChallenge) ProtoReflect() protoreflect.Message {
+	mi := &file_srp_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SRPChallenge.ProtoReflect.Descriptor instead.
+func (*SRPChallenge) Descriptor() ([]byte, []int) {
+	return file_srp_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SRPChallenge) GetHandshakeId() string {
+	if x != nil {
+		return x.HandshakeId
+	}
+	return ""
+}
+
+func (x *SRPChallenge) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *SRPChallenge) GetServerPublic() []byte {
+	if x != nil {
+		return x.ServerPublic
+	}
+	return nil
+}
+
+// Proof of the session key computed by client.
+type SRPProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HandshakeId   string                 `protobuf:"bytes,1,opt,name=handshake_id,json=handshakeId,proto3" json:"handshake_id,omitempty"` // ID of the handshake in UUIDv4 form.
+	ClientProof   []byte                 `protobuf:"bytes,2,opt,name=client_proof,json=clientProof,proto3" json:"client_proof,omitempty"` // Client proof M1.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SRPProof) Reset() {
+	*x = SRPProof{}
+	mi := &file_srp_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SRPProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SRPProof) ProtoMessage() {}
+
+func (x *SRPProof) ProtoReflect() protoreflect.Message {
+	mi := &file_srp_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SRPProof.ProtoReflect.Descriptor instead.
+func (*SRPProof) Descriptor() ([]byte, []int) {
+	return file_srp_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SRPProof) GetHandshakeId() string {
+	if x != nil {
+		return x.HandshakeId
+	}
+	return ""
+}
+
+func (x *SRPProof) GetClientProof() []byte {
+	if x != nil {
+		return x.ClientProof
+	}
+	return nil
+}
+
+var File_srp_proto protoreflect.FileDescriptor
+
+const file_srp_proto_rawDesc = "" +
+	"\n" +
+	"\tsrp.proto\x12\x05proto\"=\n" +
+	"\vSRPVerifier\x12\x12\n" +
+	"\x04salt\x18\x01 \x01(\fR\x04salt\x12\x1a\n" +
+	"\bverifier\x18\x02 \x01(\fR\bverifier\"j\n" +
+	"\fSRPChallenge\x12!\n" +
+	"\fhandshake_id\x18\x01 \x01(\tR\vhandshakeId\x12\x12\n" +
+	"\x04salt\x18\x02 \x01(\fR\x04salt\x12#\n" +
+	"\rserver_public\x18\x03 \x01(\fR\fserverPublic\"P\n" +
+	"\bSRPProof\x12!\n" +
+	"\fhandshake_id\x18\x01 \x01(\tR\vhandshakeId\x12!\n" +
+	"\fclient_proof\x18\x02 \x01(\fR\vclientProofB+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
+
+var (
+	file_srp_proto_rawDescOnce sync.Once
+	file_srp_proto_rawDescData []byte
+)
+
+func file_srp_proto_rawDescGZIP() []byte {
+	file_srp_proto_rawDescOnce.Do(func() {
+		file_srp_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_srp_proto_rawDesc), len(file_srp_proto_rawDesc)))
+	})
+	return file_srp_proto_rawDescData
+}
+
+var file_srp_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_srp_proto_goTypes = []any{
+	(*SRPVerifier)(nil),  // 0: proto.SRPVerifier
+	(*SRPChallenge)(nil), // 1: proto.SRPChallenge
+	(*SRPProof)(nil),     // 2: proto.SRPProof
+}
+var file_srp_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_srp_proto_init() }
+func file_srp_proto_init() {
+	if File_srp_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_srp_proto_rawDesc), len(file_srp_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_srp_proto_goTypes,
+		DependencyIndexes: file_srp_proto_depIdxs,
+		MessageInfos:      file_srp_proto_msgTypes,
+	}.Build()
+	File_srp_proto = out.File
+	file_srp_proto_goTypes = nil
+	file_srp_proto_depIdxs = nil
+}
@@ -21,17 +21,81 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Recovery kit lets a user regain access to the vault with the recovery code
+// after the master password is forgotten.
+type RecoveryKit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SecurityKey   string                 `protobuf:"bytes,1,opt,name=security_key,json=securityKey,proto3" json:"security_key,omitempty"` // Security key derived from the recovery code, set on registration only.
+	VaultKey      []byte                 `protobuf:"bytes,2,opt,name=vault_key,json=vaultKey,proto3" json:"vault_key,omitempty"`          // Vault key wrapped by the recovery key.
+	RecoveryKey   []byte                 `protobuf:"bytes,3,opt,name=recovery_key,json=recoveryKey,proto3" json:"recovery_key,omitempty"` // Recovery key wrapped by the vault key, required to rewrap the kit on password change.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecoveryKit) Reset() {
+	*x = RecoveryKit{}
+	mi := &file_users_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecoveryKit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecoveryKit) ProtoMessage() {}
+
+func (x *RecoveryKit) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecoveryKit.ProtoReflect.Descriptor instead.
+func (*RecoveryKit) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecoveryKit) GetSecurityKey() string {
+	if x != nil {
+		return x.SecurityKey
+	}
+	return ""
+}
+
+func (x *RecoveryKit) GetVaultKey() []byte {
+	if x != nil {
+		return x.VaultKey
+	}
+	return nil
+}
+
+func (x *RecoveryKit) GetRecoveryKey() []byte {
+	if x != nil {
+		return x.RecoveryKey
+	}
+	return nil
+}
+
 type RegisterUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`                          // Name of a user.
-	SecurityKey   string                 `protobuf:"bytes,2,opt,name=security_key,json=securityKey,proto3" json:"security_key,omitempty"` // Hashed encryption key generated by client.
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`                    // Name of a user.
+	KdfParams     *KDFParams             `protobuf:"bytes,3,opt,name=kdf_params,json=kdfParams,proto3" json:"kdf_params,omitempty"` // Parameters used to derive encryption key.
+	Recovery      *RecoveryKit           `protobuf:"bytes,4,opt,name=recovery,proto3" json:"recovery,omitempty"`                    // Optional recovery kit.
+	Verifier      *SRPVerifier           `protobuf:"bytes,5,opt,name=verifier,proto3" json:"verifier,omitempty"`                    // Verifier derived from the auth subkey.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RegisterUserRequest) Reset() {
 	*x = RegisterUserRequest{}
-	mi := &file_users_proto_msgTypes[0]
+	mi := &file_users_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -43,7 +107,7 @@ func (x *RegisterUserRequest) String() string {
 func (*RegisterUserRequest) ProtoMessage() {}
 
 func (x *RegisterUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[0]
+	mi := &file_users_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,7 +120,7 @@ func (x *RegisterUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegisterUserRequest.ProtoReflect.Descriptor instead.
 func (*RegisterUserRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{0}
+	return file_users_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *RegisterUserRequest) GetUsername() string {
@@ -66,23 +130,38 @@ func (x *RegisterUserRequest) GetUsername() string {
 	return ""
 }
 
-func (x *RegisterUserRequest) GetSecurityKey() string {
+func (x *RegisterUserRequest) GetKdfParams() *KDFParams {
 	if x != nil {
-		return x.SecurityKey
+		return x.KdfParams
 	}
-	return ""
+	return nil
+}
+
+func (x *RegisterUserRequest) GetRecovery() *RecoveryKit {
+	if x != nil {
+		return x.Recovery
+	}
+	return nil
+}
+
+func (x *RegisterUserRequest) GetVerifier() *SRPVerifier {
+	if x != nil {
+		return x.Verifier
+	}
+	return nil
 }
 
 type RegisterUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"` // JWT access token.
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`    // JWT access token bound to the login session started on registration.
+	RefreshToken  string                 `protobuf:"bytes,2,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"` // Opaque token to issue new access token, see Auth.Refresh.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RegisterUserResponse) Reset() {
 	*x = RegisterUserResponse{}
-	mi := &file_users_proto_msgTypes[1]
+	mi := &file_users_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -94,7 +173,7 @@ func (x *RegisterUserResponse) String() string {
 func (*RegisterUserResponse) ProtoMessage() {}
 
 func (x *RegisterUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[1]
+	mi := &file_users_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -107,7 +186,7 @@ func (x *RegisterUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegisterUserResponse.ProtoReflect.Descriptor instead.
 func (*RegisterUserResponse) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{1}
+	return file_users_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *RegisterUserResponse) GetAccessToken() string {
@@ -117,18 +196,1242 @@ func (x *RegisterUserResponse) GetAccessToken() string {
 	return ""
 }
 
-var File_users_proto protoreflect.FileDescriptor
+func (x *RegisterUserResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
 
-const file_users_proto_rawDesc = "" +
-	"\n" +
-	"\vusers.proto\x12\x05proto\"T\n" +
-	"\x13RegisterUserRequest\x12\x1a\n" +
-	"\busername\x18\x01 \x01(\tR\busername\x12!\n" +
-	"\fsecurity_key\x18\x02 \x01(\tR\vsecurityKey\"9\n" +
-	"\x14RegisterUserResponse\x12!\n" +
-	"\faccess_token\x18\x01 \x01(\tR\vaccessToken2L\n" +
-	"\x05Users\x12C\n" +
-	"\bRegister\x12\x1a.proto.RegisterUserRequest\x1a\x1b.proto.RegisterUserResponseB+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
+// Secret data encrypted with the new key.
+type ReencryptedSecret struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                // ID of a secret in UUIDv4 form.
+	Metadata      []byte                 `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`                    // Arbitrary description data encrypted by client, kept unchanged if data is empty.
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                            // Actual secret data encrypted by client, empty if only the data key is rewrapped.
+	DataKey       []byte                 `protobuf:"bytes,4,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`       // Data key of the secret wrapped by the new vault key.
+	Name          []byte                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`                            // Name of a secret encrypted by client, kept unchanged if empty.
+	NameIndex     []byte                 `protobuf:"bytes,6,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"` // Blind index of the name computed with the new vault key.
+	Versions      []*ReencryptedVersion  `protobuf:"bytes,7,rep,name=versions,proto3" json:"versions,omitempty"`                    // All archived versions of the secret re-encrypted the same way.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReencryptedSecret) Reset() {
+	*x = ReencryptedSecret{}
+	mi := &file_users_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReencryptedSecret) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReencryptedSecret) ProtoMessage() {}
+
+func (x *ReencryptedSecret) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReencryptedSecret.ProtoReflect.Descriptor instead.
+func (*ReencryptedSecret) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReencryptedSecret) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReencryptedSecret) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ReencryptedSecret) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ReencryptedSecret) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+func (x *ReencryptedSecret) GetName() []byte {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *ReencryptedSecret) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
+func (x *ReencryptedSecret) GetVersions() []*ReencryptedVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+// Archived version of a secret encrypted with the new key, see ReencryptedSecret.
+type ReencryptedVersion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`                     // Version of a secret, see ListVersions.
+	Metadata      []byte                 `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`                    // Arbitrary description data encrypted by client, kept unchanged if data is empty.
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                            // Actual secret data encrypted by client, empty if only the data key is rewrapped.
+	DataKey       []byte                 `protobuf:"bytes,4,opt,name=data_key,json=dataKey,proto3" json:"data_key,omitempty"`       // Data key of the version wrapped by the new vault key.
+	Name          []byte                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`                            // Name of a secret encrypted by client, kept unchanged if empty.
+	NameIndex     []byte                 `protobuf:"bytes,6,opt,name=name_index,json=nameIndex,proto3" json:"name_index,omitempty"` // Blind index of the name computed with the new vault key.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReencryptedVersion) Reset() {
+	*x = ReencryptedVersion{}
+	mi := &file_users_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReencryptedVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReencryptedVersion) ProtoMessage() {}
+
+func (x *ReencryptedVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReencryptedVersion.ProtoReflect.Descriptor instead.
+func (*ReencryptedVersion) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReencryptedVersion) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReencryptedVersion) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ReencryptedVersion) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ReencryptedVersion) GetDataKey() []byte {
+	if x != nil {
+		return x.DataKey
+	}
+	return nil
+}
+
+func (x *ReencryptedVersion) GetName() []byte {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *ReencryptedVersion) GetNameIndex() []byte {
+	if x != nil {
+		return x.NameIndex
+	}
+	return nil
+}
+
+type StartChangePasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientPublic  []byte                 `protobuf:"bytes,1,opt,name=client_public,json=clientPublic,proto3" json:"client_public,omitempty"` // Client public ephemeral value A.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartChangePasswordRequest) Reset() {
+	*x = StartChangePasswordRequest{}
+	mi := &file_users_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartChangePasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartChangePasswordRequest) ProtoMessage() {}
+
+func (x *StartChangePasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartChangePasswordRequest.ProtoReflect.Descriptor instead.
+func (*StartChangePasswordRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StartChangePasswordRequest) GetClientPublic() []byte {
+	if x != nil {
+		return x.ClientPublic
+	}
+	return nil
+}
+
+type StartChangePasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Challenge     *SRPChallenge          `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"` // Challenge to prove the current master password.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartChangePasswordResponse) Reset() {
+	*x = StartChangePasswordResponse{}
+	mi := &file_users_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartChangePasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartChangePasswordResponse) ProtoMessage() {}
+
+func (x *StartChangePasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartChangePasswordResponse.ProtoReflect.Descriptor instead.
+func (*StartChangePasswordResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StartChangePasswordResponse) GetChallenge() *SRPChallenge {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+type ChangePasswordRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	KdfParams           *KDFParams             `protobuf:"bytes,3,opt,name=kdf_params,json=kdfParams,proto3" json:"kdf_params,omitempty"`                                 // Parameters used to derive the new encryption key.
+	VaultVersion        int64                  `protobuf:"varint,4,opt,name=vault_version,json=vaultVersion,proto3" json:"vault_version,omitempty"`                       // Version of the re-encrypted vault, see ListSecretsResponse.
+	Secrets             []*ReencryptedSecret   `protobuf:"bytes,5,rep,name=secrets,proto3" json:"secrets,omitempty"`                                                      // All secrets of the user with data keys wrapped by the new vault key.
+	RecoverySecurityKey string                 `protobuf:"bytes,6,opt,name=recovery_security_key,json=recoverySecurityKey,proto3" json:"recovery_security_key,omitempty"` // Security key derived from the recovery code, replaces proof on recovery.
+	Recovery            *RecoveryKit           `protobuf:"bytes,7,opt,name=recovery,proto3" json:"recovery,omitempty"`                                                    // Recovery kit rewrapped with the new vault key, required if recovery is set up.
+	Proof               *SRPProof              `protobuf:"bytes,8,opt,name=proof,proto3" json:"proof,omitempty"`                                                          // Proof of the current master password, see StartChangePassword.
+	Verifier            *SRPVerifier           `protobuf:"bytes,9,opt,name=verifier,proto3" json:"verifier,omitempty"`                                                    // Verifier derived from the new auth subkey.
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ChangePasswordRequest) Reset() {
+	*x = ChangePasswordRequest{}
+	mi := &file_users_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordRequest) ProtoMessage() {}
+
+func (x *ChangePasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordRequest.ProtoReflect.Descriptor instead.
+func (*ChangePasswordRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChangePasswordRequest) GetKdfParams() *KDFParams {
+	if x != nil {
+		return x.KdfParams
+	}
+	return nil
+}
+
+func (x *ChangePasswordRequest) GetVaultVersion() int64 {
+	if x != nil {
+		return x.VaultVersion
+	}
+	return 0
+}
+
+func (x *ChangePasswordRequest) GetSecrets() []*ReencryptedSecret {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+func (x *ChangePasswordRequest) GetRecoverySecurityKey() string {
+	if x != nil {
+		return x.RecoverySecurityKey
+	}
+	return ""
+}
+
+func (x *ChangePasswordRequest) GetRecovery() *RecoveryKit {
+	if x != nil {
+		return x.Recovery
+	}
+	return nil
+}
+
+func (x *ChangePasswordRequest) GetProof() *SRPProof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *ChangePasswordRequest) GetVerifier() *SRPVerifier {
+	if x != nil {
+		return x.Verifier
+	}
+	return nil
+}
+
+type ChangePasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerProof   []byte                 `protobuf:"bytes,1,opt,name=server_proof,json=serverProof,proto3" json:"server_proof,omitempty"` // Server proof M2 of the session key, empty on recovery.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangePasswordResponse) Reset() {
+	*x = ChangePasswordResponse{}
+	mi := &file_users_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordResponse) ProtoMessage() {}
+
+func (x *ChangePasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordResponse.ProtoReflect.Descriptor instead.
+func (*ChangePasswordResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ChangePasswordResponse) GetServerProof() []byte {
+	if x != nil {
+		return x.ServerProof
+	}
+	return nil
+}
+
+type StartDeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientPublic  []byte                 `protobuf:"bytes,1,opt,name=client_public,json=clientPublic,proto3" json:"client_public,omitempty"` // Client public ephemeral value A.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDeleteUserRequest) Reset() {
+	*x = StartDeleteUserRequest{}
+	mi := &file_users_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDeleteUserRequest) ProtoMessage() {}
+
+func (x *StartDeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*StartDeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StartDeleteUserRequest) GetClientPublic() []byte {
+	if x != nil {
+		return x.ClientPublic
+	}
+	return nil
+}
+
+type StartDeleteUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Challenge     *SRPChallenge          `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"` // Challenge to prove the master password.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDeleteUserResponse) Reset() {
+	*x = StartDeleteUserResponse{}
+	mi := &file_users_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDeleteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDeleteUserResponse) ProtoMessage() {}
+
+func (x *StartDeleteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDeleteUserResponse.ProtoReflect.Descriptor instead.
+func (*StartDeleteUserResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StartDeleteUserResponse) GetChallenge() *SRPChallenge {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+type DeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Proof         *SRPProof              `protobuf:"bytes,1,opt,name=proof,proto3" json:"proof,omitempty"` // Proof of the master password, see StartDelete.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_users_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteUserRequest) GetProof() *SRPProof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+type DeleteUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerProof   []byte                 `protobuf:"bytes,1,opt,name=server_proof,json=serverProof,proto3" json:"server_proof,omitempty"` // Server proof M2 of the session key.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserResponse) Reset() {
+	*x = DeleteUserResponse{}
+	mi := &file_users_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserResponse) ProtoMessage() {}
+
+func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
+func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteUserResponse) GetServerProof() []byte {
+	if x != nil {
+		return x.ServerProof
+	}
+	return nil
+}
+
+type StartRenameUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientPublic  []byte                 `protobuf:"bytes,1,opt,name=client_public,json=clientPublic,proto3" json:"client_public,omitempty"` // Client public ephemeral value A.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRenameUserRequest) Reset() {
+	*x = StartRenameUserRequest{}
+	mi := &file_users_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRenameUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRenameUserRequest) ProtoMessage() {}
+
+func (x *StartRenameUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRenameUserRequest.ProtoReflect.Descriptor instead.
+func (*StartRenameUserRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StartRenameUserRequest) GetClientPublic() []byte {
+	if x != nil {
+		return x.ClientPublic
+	}
+	return nil
+}
+
+type StartRenameUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Challenge     *SRPChallenge          `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"` // Challenge to prove the master password.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRenameUserResponse) Reset() {
+	*x = StartRenameUserResponse{}
+	mi := &file_users_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRenameUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRenameUserResponse) ProtoMessage() {}
+
+func (x *StartRenameUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRenameUserResponse.ProtoReflect.Descriptor instead.
+func (*StartRenameUserResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StartRenameUserResponse) GetChallenge() *SRPChallenge {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+type RenameUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`                              // New username.
+	Proof         *SRPProof              `protobuf:"bytes,2,opt,name=proof,proto3" json:"proof,omitempty"`                                    // Proof of the master password, see StartRename.
+	Verifier      *SRPVerifier           `protobuf:"bytes,3,opt,name=verifier,proto3" json:"verifier,omitempty"`                              // Verifier derived from the auth subkey of the key bound to the new username.
+	KdfParams     *KDFParams             `protobuf:"bytes,4,opt,name=kdf_params,json=kdfParams,proto3" json:"kdf_params,omitempty"`           // Parameters used to derive the new encryption key.
+	VaultVersion  int64                  `protobuf:"varint,5,opt,name=vault_version,json=vaultVersion,proto3" json:"vault_version,omitempty"` // Version of the re-encrypted vault, see ListSecretsResponse.
+	Secrets       []*ReencryptedSecret   `protobuf:"bytes,6,rep,name=secrets,proto3" json:"secrets,omitempty"`                                // All secrets of the user with data keys wrapped by the new vault key.
+	Recovery      *RecoveryKit           `protobuf:"bytes,7,opt,name=recovery,proto3" json:"recovery,omitempty"`                              // Recovery kit rewrapped with the new vault key, required if recovery is set up.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameUserRequest) Reset() {
+	*x = RenameUserRequest{}
+	mi := &file_users_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameUserRequest) ProtoMessage() {}
+
+func (x *RenameUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameUserRequest.ProtoReflect.Descriptor instead.
+func (*RenameUserRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RenameUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *RenameUserRequest) GetProof() *SRPProof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *RenameUserRequest) GetVerifier() *SRPVerifier {
+	if x != nil {
+		return x.Verifier
+	}
+	return nil
+}
+
+func (x *RenameUserRequest) GetKdfParams() *KDFParams {
+	if x != nil {
+		return x.KdfParams
+	}
+	return nil
+}
+
+func (x *RenameUserRequest) GetVaultVersion() int64 {
+	if x != nil {
+		return x.VaultVersion
+	}
+	return 0
+}
+
+func (x *RenameUserRequest) GetSecrets() []*ReencryptedSecret {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+func (x *RenameUserRequest) GetRecovery() *RecoveryKit {
+	if x != nil {
+		return x.Recovery
+	}
+	return nil
+}
+
+type RenameUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerProof   []byte                 `protobuf:"bytes,1,opt,name=server_proof,json=serverProof,proto3" json:"server_proof,omitempty"` // Server proof M2 of the session key.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameUserResponse) Reset() {
+	*x = RenameUserResponse{}
+	mi := &file_users_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameUserResponse) ProtoMessage() {}
+
+func (x *RenameUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameUserResponse.ProtoReflect.Descriptor instead.
+func (*RenameUserResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RenameUserResponse) GetServerProof() []byte {
+	if x != nil {
+		return x.ServerProof
+	}
+	return nil
+}
+
+type GetRecoveryKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRecoveryKeyRequest) Reset() {
+	*x = GetRecoveryKeyRequest{}
+	mi := &file_users_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecoveryKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecoveryKeyRequest) ProtoMessage() {}
+
+func (x *GetRecoveryKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecoveryKeyRequest.ProtoReflect.Descriptor instead.
+func (*GetRecoveryKeyRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{17}
+}
+
+type GetRecoveryKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RecoveryKey   []byte                 `protobuf:"bytes,1,opt,name=recovery_key,json=recoveryKey,proto3" json:"recovery_key,omitempty"` // Recovery key wrapped by the vault key, empty if recovery is not set up.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRecoveryKeyResponse) Reset() {
+	*x = GetRecoveryKeyResponse{}
+	mi := &file_users_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecoveryKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecoveryKeyResponse) ProtoMessage() {}
+
+func (x *GetRecoveryKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecoveryKeyResponse.ProtoReflect.Descriptor instead.
+func (*GetRecoveryKeyResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetRecoveryKeyResponse) GetRecoveryKey() []byte {
+	if x != nil {
+		return x.RecoveryKey
+	}
+	return nil
+}
+
+type SetupTOTPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetupTOTPRequest) Reset() {
+	*x = SetupTOTPRequest{}
+	mi := &file_users_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetupTOTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetupTOTPRequest) ProtoMessage() {}
+
+func (x *SetupTOTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetupTOTPRequest.ProtoReflect.Descriptor instead.
+func (*SetupTOTPRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{19}
+}
+
+type SetupTOTPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uri           string                 `protobuf:"bytes,1,opt,name=uri,proto3" json:"uri,omitempty"` // otpauth URI of new TOTP secret to enroll it in an authenticator app.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetupTOTPResponse) Reset() {
+	*x = SetupTOTPResponse{}
+	mi := &file_users_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetupTOTPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetupTOTPResponse) ProtoMessage() {}
+
+func (x *SetupTOTPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetupTOTPResponse.ProtoReflect.Descriptor instead.
+func (*SetupTOTPResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SetupTOTPResponse) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+type EnableTOTPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"` // The first TOTP code generated by authenticator app.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnableTOTPRequest) Reset() {
+	*x = EnableTOTPRequest{}
+	mi := &file_users_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnableTOTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnableTOTPRequest) ProtoMessage() {}
+
+func (x *EnableTOTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnableTOTPRequest.ProtoReflect.Descriptor instead.
+func (*EnableTOTPRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *EnableTOTPRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type EnableTOTPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BackupCodes   []string               `protobuf:"bytes,1,rep,name=backup_codes,json=backupCodes,proto3" json:"backup_codes,omitempty"` // One-time codes replacing TOTP code if authenticator is lost.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnableTOTPResponse) Reset() {
+	*x = EnableTOTPResponse{}
+	mi := &file_users_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnableTOTPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnableTOTPResponse) ProtoMessage() {}
+
+func (x *EnableTOTPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnableTOTPResponse.ProtoReflect.Descriptor instead.
+func (*EnableTOTPResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *EnableTOTPResponse) GetBackupCodes() []string {
+	if x != nil {
+		return x.BackupCodes
+	}
+	return nil
+}
+
+type DisableTOTPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"` // TOTP code from authenticator app or backup code.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisableTOTPRequest) Reset() {
+	*x = DisableTOTPRequest{}
+	mi := &file_users_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisableTOTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableTOTPRequest) ProtoMessage() {}
+
+func (x *DisableTOTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableTOTPRequest.ProtoReflect.Descriptor instead.
+func (*DisableTOTPRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DisableTOTPRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type DisableTOTPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisableTOTPResponse) Reset() {
+	*x = DisableTOTPResponse{}
+	mi := &file_users_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisableTOTPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableTOTPResponse) ProtoMessage() {}
+
+func (x *DisableTOTPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableTOTPResponse.ProtoReflect.Descriptor instead.
+func (*DisableTOTPResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{24}
+}
+
+var File_users_proto protoreflect.FileDescriptor
+
+const file_users_proto_rawDesc = "" +
+	"\n" +
+	"\vusers.proto\x12\x05proto\x1a\tkdf.proto\x1a\tsrp.proto\"p\n" +
+	"\vRecoveryKit\x12!\n" +
+	"\fsecurity_key\x18\x01 \x01(\tR\vsecurityKey\x12\x1b\n" +
+	"\tvault_key\x18\x02 \x01(\fR\bvaultKey\x12!\n" +
+	"\frecovery_key\x18\x03 \x01(\fR\vrecoveryKey\"\xd6\x01\n" +
+	"\x13RegisterUserRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12/\n" +
+	"\n" +
+	"kdf_params\x18\x03 \x01(\v2\x10.proto.KDFParamsR\tkdfParams\x12.\n" +
+	"\brecovery\x18\x04 \x01(\v2\x12.proto.RecoveryKitR\brecovery\x12.\n" +
+	"\bverifier\x18\x05 \x01(\v2\x12.proto.SRPVerifierR\bverifierJ\x04\b\x02\x10\x03R\fsecurity_key\"^\n" +
+	"\x14RegisterUserResponse\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12#\n" +
+	"\rrefresh_token\x18\x02 \x01(\tR\frefreshToken\"\xd8\x01\n" +
+	"\x11ReencryptedSecret\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bmetadata\x18\x02 \x01(\fR\bmetadata\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x19\n" +
+	"\bdata_key\x18\x04 \x01(\fR\adataKey\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\fR\x04name\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\x06 \x01(\fR\tnameIndex\x125\n" +
+	"\bversions\x18\a \x03(\v2\x19.proto.ReencryptedVersionR\bversions\"\xac\x01\n" +
+	"\x12ReencryptedVersion\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x1a\n" +
+	"\bmetadata\x18\x02 \x01(\fR\bmetadata\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x19\n" +
+	"\bdata_key\x18\x04 \x01(\fR\adataKey\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\fR\x04name\x12\x1d\n" +
+	"\n" +
+	"name_index\x18\x06 \x01(\fR\tnameIndex\"A\n" +
+	"\x1aStartChangePasswordRequest\x12#\n" +
+	"\rclient_public\x18\x01 \x01(\fR\fclientPublic\"P\n" +
+	"\x1bStartChangePasswordResponse\x121\n" +
+	"\tchallenge\x18\x01 \x01(\v2\x13.proto.SRPChallengeR\tchallenge\"\x88\x03\n" +
+	"\x15ChangePasswordRequest\x12/\n" +
+	"\n" +
+	"kdf_params\x18\x03 \x01(\v2\x10.proto.KDFParamsR\tkdfParams\x12#\n" +
+	"\rvault_version\x18\x04 \x01(\x03R\fvaultVersion\x122\n" +
+	"\asecrets\x18\x05 \x03(\v2\x18.proto.ReencryptedSecretR\asecrets\x122\n" +
+	"\x15recovery_security_key\x18\x06 \x01(\tR\x13recoverySecurityKey\x12.\n" +
+	"\brecovery\x18\a \x01(\v2\x12.proto.RecoveryKitR\brecovery\x12%\n" +
+	"\x05proof\x18\b \x01(\v2\x0f.proto.SRPProofR\x05proof\x12.\n" +
+	"\bverifier\x18\t \x01(\v2\x12.proto.SRPVerifierR\bverifierJ\x04\b\x01\x10\x02J\x04\b\x02\x10\x03R\fsecurity_keyR\x10new_security_key\";\n" +
+	"\x16ChangePasswordResponse\x12!\n" +
+	"\fserver_proof\x18\x01 \x01(\fR\vserverProof\"=\n" +
+	"\x16StartDeleteUserRequest\x12#\n" +
+	"\rclient_public\x18\x01 \x01(\fR\fclientPublic\"L\n" +
+	"\x17StartDeleteUserResponse\x121\n" +
+	"\tchallenge\x18\x01 \x01(\v2\x13.proto.SRPChallengeR\tchallenge\":\n" +
+	"\x11DeleteUserRequest\x12%\n" +
+	"\x05proof\x18\x01 \x01(\v2\x0f.proto.SRPProofR\x05proof\"7\n" +
+	"\x12DeleteUserResponse\x12!\n" +
+	"\fserver_proof\x18\x01 \x01(\fR\vserverProof\"=\n" +
+	"\x16StartRenameUserRequest\x12#\n" +
+	"\rclient_public\x18\x01 \x01(\fR\fclientPublic\"L\n" +
+	"\x17StartRenameUserResponse\x121\n" +
+	"\tchallenge\x18\x01 \x01(\v2\x13.proto.SRPChallengeR\tchallenge\"\xc0\x02\n" +
+	"\x11RenameUserRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12%\n" +
+	"\x05proof\x18\x02 \x01(\v2\x0f.proto.SRPProofR\x05proof\x12.\n" +
+	"\bverifier\x18\x03 \x01(\v2\x12.proto.SRPVerifierR\bverifier\x12/\n" +
+	"\n" +
+	"kdf_params\x18\x04 \x01(\v2\x10.proto.KDFParamsR\tkdfParams\x12#\n" +
+	"\rvault_version\x18\x05 \x01(\x03R\fvaultVersion\x122\n" +
+	"\asecrets\x18\x06 \x03(\v2\x18.proto.ReencryptedSecretR\asecrets\x12.\n" +
+	"\brecovery\x18\a \x01(\v2\x12.proto.RecoveryKitR\brecovery\"7\n" +
+	"\x12RenameUserResponse\x12!\n" +
+	"\fserver_proof\x18\x01 \x01(\fR\vserverProof\"\x17\n" +
+	"\x15GetRecoveryKeyRequest\";\n" +
+	"\x16GetRecoveryKeyResponse\x12!\n" +
+	"\frecovery_key\x18\x01 \x01(\fR\vrecoveryKey\"\x12\n" +
+	"\x10SetupTOTPRequest\"%\n" +
+	"\x11SetupTOTPResponse\x12\x10\n" +
+	"\x03uri\x18\x01 \x01(\tR\x03uri\"'\n" +
+	"\x11EnableTOTPRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"7\n" +
+	"\x12EnableTOTPResponse\x12!\n" +
+	"\fbackup_codes\x18\x01 \x03(\tR\vbackupCodes\"(\n" +
+	"\x12DisableTOTPRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"\x15\n" +
+	"\x13DisableTOTPResponse2\xab\x06\n" +
+	"\x05Users\x12C\n" +
+	"\bRegister\x12\x1a.proto.RegisterUserRequest\x1a\x1b.proto.RegisterUserResponse\x12\\\n" +
+	"\x13StartChangePassword\x12!.proto.StartChangePasswordRequest\x1a\".proto.StartChangePasswordResponse\x12M\n" +
+	"\x0eChangePassword\x12\x1c.proto.ChangePasswordRequest\x1a\x1d.proto.ChangePasswordResponse\x12L\n" +
+	"\vStartDelete\x12\x1d.proto.StartDeleteUserRequest\x1a\x1e.proto.StartDeleteUserResponse\x12=\n" +
+	"\x06Delete\x12\x18.proto.DeleteUserRequest\x1a\x19.proto.DeleteUserResponse\x12L\n" +
+	"\vStartRename\x12\x1d.proto.StartRenameUserRequest\x1a\x1e.proto.StartRenameUserResponse\x12=\n" +
+	"\x06Rename\x12\x18.proto.RenameUserRequest\x1a\x19.proto.RenameUserResponse\x12M\n" +
+	"\x0eGetRecoveryKey\x12\x1c.proto.GetRecoveryKeyRequest\x1a\x1d.proto.GetRecoveryKeyResponse\x12>\n" +
+	"\tSetupTOTP\x12\x17.proto.SetupTOTPRequest\x1a\x18.proto.SetupTOTPResponse\x12A\n" +
+	"\n" +
+	"EnableTOTP\x12\x18.proto.EnableTOTPRequest\x1a\x19.proto.EnableTOTPResponse\x12D\n" +
+	"\vDisableTOTP\x12\x19.proto.DisableTOTPRequest\x1a\x1a.proto.DisableTOTPResponseB+Z)github.com/derpartizanen/gophkeeper/protob\x06proto3"
 
 var (
 	file_users_proto_rawDescOnce sync.Once
@@ -142,19 +1445,84 @@ func file_users_proto_rawDescGZIP() []byte {
 	return file_users_proto_rawDescData
 }
 
-var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
 var file_users_proto_goTypes = []any{
-	(*RegisterUserRequest)(nil),  // 0: proto.RegisterUserRequest
-	(*RegisterUserResponse)(nil), // 1: proto.RegisterUserResponse
+	(*RecoveryKit)(nil),                 // 0: proto.RecoveryKit
+	(*RegisterUserRequest)(nil),         // 1: proto.RegisterUserRequest
+	(*RegisterUserResponse)(nil),        // 2: proto.RegisterUserResponse
+	(*ReencryptedSecret)(nil),           // 3: proto.ReencryptedSecret
+	(*ReencryptedVersion)(nil),          // 4: proto.ReencryptedVersion
+	(*StartChangePasswordRequest)(nil),  // 5: proto.StartChangePasswordRequest
+	(*StartChangePasswordResponse)(nil), // 6: proto.StartChangePasswordResponse
+	(*ChangePasswordRequest)(nil),       // 7: proto.ChangePasswordRequest
+	(*ChangePasswordResponse)(nil),      // 8: proto.ChangePasswordResponse
+	(*StartDeleteUserRequest)(nil),      // 9: proto.StartDeleteUserRequest
+	(*StartDeleteUserResponse)(nil),     // 10: proto.StartDeleteUserResponse
+	(*DeleteUserRequest)(nil),           // 11: proto.DeleteUserRequest
+	(*DeleteUserResponse)(nil),          // 12: proto.DeleteUserResponse
+	(*StartRenameUserRequest)(nil),      // 13: proto.StartRenameUserRequest
+	(*StartRenameUserResponse)(nil),     // 14: proto.StartRenameUserResponse
+	(*RenameUserRequest)(nil),           // 15: proto.RenameUserRequest
+	(*RenameUserResponse)(nil),          // 16: proto.RenameUserResponse
+	(*GetRecoveryKeyRequest)(nil),       // 17: proto.GetRecoveryKeyRequest
+	(*GetRecoveryKeyResponse)(nil),      // 18: proto.GetRecoveryKeyResponse
+	(*SetupTOTPRequest)(nil),            // 19: proto.SetupTOTPRequest
+	(*SetupTOTPResponse)(nil),           // 20: proto.SetupTOTPResponse
+	(*EnableTOTPRequest)(nil),           // 21: proto.EnableTOTPRequest
+	(*EnableTOTPResponse)(nil),          // 22: proto.EnableTOTPResponse
+	(*DisableTOTPRequest)(nil),          // 23: proto.DisableTOTPRequest
+	(*DisableTOTPResponse)(nil),         // 24: proto.DisableTOTPResponse
+	(*KDFParams)(nil),                   // 25: proto.KDFParams
+	(*SRPVerifier)(nil),                 // 26: proto.SRPVerifier
+	(*SRPChallenge)(nil),                // 27: proto.SRPChallenge
+	(*SRPProof)(nil),                    // 28: proto.SRPProof
 }
 var file_users_proto_depIdxs = []int32{
-	0, // 0: proto.Users.Register:input_type -> proto.RegisterUserRequest
-	1, // 1: proto.Users.Register:output_type -> proto.RegisterUserResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	25, // 0: proto.RegisterUserRequest.kdf_params:type_name -> proto.KDFParams
+	0,  // 1: proto.RegisterUserRequest.recovery:type_name -> proto.RecoveryKit
+	26, // 2: proto.RegisterUserRequest.verifier:type_name -> proto.SRPVerifier
+	4,  // 3: proto.ReencryptedSecret.versions:type_name -> proto.ReencryptedVersion
+	27, // 4: proto.StartChangePasswordResponse.challenge:type_name -> proto.SRPChallenge
+	25, // 5: proto.ChangePasswordRequest.kdf_params:type_name -> proto.KDFParams
+	3,  // 6: proto.ChangePasswordRequest.secrets:type_name -> proto.ReencryptedSecret
+	0,  // 7: proto.ChangePasswordRequest.recovery:type_name -> proto.RecoveryKit
+	28, // 8: proto.ChangePasswordRequest.proof:type_name -> proto.SRPProof
+	26, // 9: proto.ChangePasswordRequest.verifier:type_name -> proto.SRPVerifier
+	27, // 10: proto.StartDeleteUserResponse.challenge:type_name -> proto.SRPChallenge
+	28, // 11: proto.DeleteUserRequest.proof:type_name -> proto.SRPProof
+	27, // 12: proto.StartRenameUserResponse.challenge:type_name -> proto.SRPChallenge
+	28, // 13: proto.RenameUserRequest.proof:type_name -> proto.SRPProof
+	26, // 14: proto.RenameUserRequest.verifier:type_name -> proto.SRPVerifier
+	25, // 15: proto.RenameUserRequest.kdf_params:type_name -> proto.KDFParams
+	3,  // 16: proto.RenameUserRequest.secrets:type_name -> proto.ReencryptedSecret
+	0,  // 17: proto.RenameUserRequest.recovery:type_name -> proto.RecoveryKit
+	1,  // 18: proto.Users.Register:input_type -> proto.RegisterUserRequest
+	5,  // 19: proto.Users.StartChangePassword:input_type -> proto.StartChangePasswordRequest
+	7,  // 20: proto.Users.ChangePassword:input_type -> proto.ChangePasswordRequest
+	9,  // 21: proto.Users.StartDelete:input_type -> proto.StartDeleteUserRequest
+	11, // 22: proto.Users.Delete:input_type -> proto.DeleteUserRequest
+	13, // 23: proto.Users.StartRename:input_type -> proto.StartRenameUserRequest
+	15, // 24: proto.Users.Rename:input_type -> proto.RenameUserRequest
+	17, // 25: proto.Users.GetRecoveryKey:input_type -> proto.GetRecoveryKeyRequest
+	19, // 26: proto.Users.SetupTOTP:input_type -> proto.SetupTOTPRequest
+	21, // 27: proto.Users.EnableTOTP:input_type -> proto.EnableTOTPRequest
+	23, // 28: proto.Users.DisableTOTP:input_type -> proto.DisableTOTPRequest
+	2,  // 29: proto.Users.Register:output_type -> proto.RegisterUserResponse
+	6,  // 30: proto.Users.StartChangePassword:output_type -> proto.StartChangePasswordResponse
+	8,  // 31: proto.Users.ChangePassword:output_type -> proto.ChangePasswordResponse
+	10, // 32: proto.Users.StartDelete:output_type -> proto.StartDeleteUserResponse
+	12, // 33: proto.Users.Delete:output_type -> proto.DeleteUserResponse
+	14, // 34: proto.Users.StartRename:output_type -> proto.StartRenameUserResponse
+	16, // 35: proto.Users.Rename:output_type -> proto.RenameUserResponse
+	18, // 36: proto.Users.GetRecoveryKey:output_type -> proto.GetRecoveryKeyResponse
+	20, // 37: proto.Users.SetupTOTP:output_type -> proto.SetupTOTPResponse
+	22, // 38: proto.Users.EnableTOTP:output_type -> proto.EnableTOTPResponse
+	24, // 39: proto.Users.DisableTOTP:output_type -> proto.DisableTOTPResponse
+	29, // [29:40] is the sub-list for method output_type
+	18, // [18:29] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_users_proto_init() }
@@ -162,13 +1530,15 @@ func file_users_proto_init() {
 	if File_users_proto != nil {
 		return
 	}
+	file_kdf_proto_init()
+	file_srp_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_users_proto_rawDesc), len(file_users_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -26,3 +26,143 @@ func (m *UsersClientMock) Register(
 
 	return args.Get(0).(*RegisterUserResponse), args.Error(1)
 }
+
+func (m *UsersClientMock) ChangePassword(
+	ctx context.Context,
+	in *ChangePasswordRequest,
+	opts ...grpc.CallOption,
+) (*ChangePasswordResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*ChangePasswordResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) GetRecoveryKey(
+	ctx context.Context,
+	in *GetRecoveryKeyRequest,
+	opts ...grpc.CallOption,
+) (*GetRecoveryKeyResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*GetRecoveryKeyResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) StartChangePassword(
+	ctx context.Context,
+	in *StartChangePasswordRequest,
+	opts ...grpc.CallOption,
+) (*StartChangePasswordResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*StartChangePasswordResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) StartDelete(
+	ctx context.Context,
+	in *StartDeleteUserRequest,
+	opts ...grpc.CallOption,
+) (*StartDeleteUserResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*StartDeleteUserResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) Delete(
+	ctx context.Context,
+	in *DeleteUserRequest,
+	opts ...grpc.CallOption,
+) (*DeleteUserResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*DeleteUserResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) StartRename(
+	ctx context.Context,
+	in *StartRenameUserRequest,
+	opts ...grpc.CallOption,
+) (*StartRenameUserResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*StartRenameUserResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) Rename(
+	ctx context.Context,
+	in *RenameUserRequest,
+	opts ...grpc.CallOption,
+) (*RenameUserResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*RenameUserResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) SetupTOTP(
+	ctx context.Context,
+	in *SetupTOTPRequest,
+	opts ...grpc.CallOption,
+) (*SetupTOTPResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*SetupTOTPResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) EnableTOTP(
+	ctx context.Context,
+	in *EnableTOTPRequest,
+	opts ...grpc.CallOption,
+) (*EnableTOTPResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*EnableTOTPResponse), args.Error(1)
+}
+
+func (m *UsersClientMock) DisableTOTP(
+	ctx context.Context,
+	in *DisableTOTPRequest,
+	opts ...grpc.CallOption,
+) (*DisableTOTPResponse, error) {
+	args := m.Called(ctx, in, opts)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*DisableTOTPResponse), args.Error(1)
+}